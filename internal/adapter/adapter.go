@@ -0,0 +1,184 @@
+// Package adapter normalizes other agent CLIs' hook payloads into the Claude
+// Code hook protocol that chains are written against. Each adapter is
+// configured under `adapters:` as a mapping from hook input fields to paths
+// in the agent's payload, plus optional renames for event and tool names.
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// Fields are the hook input fields an adapter can fill.
+var Fields = []string{
+	"session_id",
+	"transcript_path",
+	"cwd",
+	"permission_mode",
+	"hook_event_name",
+	"tool_name",
+	"tool_use_id",
+	"tool_input",
+}
+
+// AgentField is the top-level field naming the adapter that produced an input.
+const AgentField = "agent"
+
+// ErrNoEvent is returned when a payload does not yield a hook event name.
+var ErrNoEvent = errors.New("adapter: payload has no hook event name")
+
+// Validate checks adapter configs: names are set and unique, fields are
+// known, paths are non-empty, and hook_event_name is mapped.
+func Validate(adapters []config.AdapterConfig) error {
+	seen := map[string]bool{}
+	for i, a := range adapters {
+		if a.Name == "" {
+			return fmt.Errorf("adapter %d: name is required", i+1)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("adapter %q: duplicate name", a.Name)
+		}
+		seen[a.Name] = true
+		if _, ok := a.Fields["hook_event_name"]; !ok {
+			return fmt.Errorf("adapter %q: fields.hook_event_name is required", a.Name)
+		}
+		for _, f := range slices.Sorted(maps.Keys(a.Fields)) {
+			if !slices.Contains(Fields, f) {
+				return fmt.Errorf("adapter %q: unknown field %q (want one of %v)", a.Name, f, Fields)
+			}
+			if a.Fields[f] == "" {
+				return fmt.Errorf("adapter %q: field %q has an empty path", a.Name, f)
+			}
+		}
+	}
+	return nil
+}
+
+// Select returns the adapter for a payload. A non-empty name picks that
+// adapter. Otherwise payloads that already carry hook_event_name use no
+// adapter, and the first adapter whose detect path is present is used.
+func Select(adapters []config.AdapterConfig, name string, data []byte) (config.AdapterConfig, bool, error) {
+	if name != "" {
+		for _, a := range adapters {
+			if a.Name == name {
+				return a, true, nil
+			}
+		}
+		return config.AdapterConfig{}, false, fmt.Errorf("adapter: %q is not configured", name)
+	}
+
+	var payload any
+	if err := decode(data, &payload); err != nil {
+		return config.AdapterConfig{}, false, err
+	}
+	if _, ok := lookup(payload, "hook_event_name"); ok {
+		return config.AdapterConfig{}, false, nil
+	}
+	for _, a := range adapters {
+		if a.Detect == "" {
+			continue
+		}
+		if _, ok := lookup(payload, a.Detect); ok {
+			return a, true, nil
+		}
+	}
+	return config.AdapterConfig{}, false, nil
+}
+
+// Normalize rewrites an agent payload as Claude Code hook input. The
+// payload's own top-level fields are kept so hooks written for the agent
+// still find them; mapped fields overwrite them, and the agent field names
+// the adapter.
+func Normalize(a config.AdapterConfig, data []byte) ([]byte, error) {
+	var payload any
+	if err := decode(data, &payload); err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{}
+	if m, ok := payload.(map[string]any); ok {
+		maps.Copy(out, m)
+	}
+	for field, path := range a.Fields {
+		v, ok := lookup(payload, path)
+		if !ok {
+			continue
+		}
+		switch field {
+		case "hook_event_name":
+			v = rename(v, a.Events)
+		case "tool_name":
+			v = rename(v, a.Tools)
+		case "tool_input":
+			// Some agents send arguments as a JSON-encoded string.
+			if s, ok := v.(string); ok {
+				var obj map[string]any
+				if err := decode([]byte(s), &obj); err == nil {
+					v = obj
+				}
+			}
+		}
+		out[field] = v
+	}
+	if s, _ := out["hook_event_name"].(string); s == "" {
+		return nil, fmt.Errorf("%w (adapter %q, path %q)", ErrNoEvent, a.Name, a.Fields["hook_event_name"])
+	}
+	out[AgentField] = a.Name
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("adapter: marshal %s input: %w", a.Name, err)
+	}
+	return b, nil
+}
+
+// rename maps a string value through names, leaving unlisted values alone.
+func rename(v any, names map[string]string) any {
+	if s, ok := v.(string); ok {
+		if to, ok := names[s]; ok {
+			return to
+		}
+	}
+	return v
+}
+
+// lookup follows a dotted path through objects and arrays.
+func lookup(v any, path string) (any, bool) {
+	for key := range strings.SplitSeq(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// decode unmarshals JSON keeping numbers exact.
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("adapter: parse payload: %w", err)
+	}
+	return nil
+}
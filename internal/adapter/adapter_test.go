@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+var testAdapter = config.AdapterConfig{
+	Name:   "acme",
+	Detect: "acme_version",
+	Fields: map[string]string{
+		"session_id":      "session.id",
+		"cwd":             "workspace.roots.0",
+		"hook_event_name": "event",
+		"tool_name":       "call.tool",
+		"tool_input":      "call.args",
+	},
+	Events: map[string]string{"before_tool": "PreToolUse"},
+	Tools:  map[string]string{"run_shell": "Bash"},
+}
+
+func TestNormalize(t *testing.T) {
+	payload := `{
+	  "acme_version": 3,
+	  "event": "before_tool",
+	  "session": {"id": "s-1"},
+	  "workspace": {"roots": ["/repo", "/other"]},
+	  "call": {"tool": "run_shell", "args": "{\"command\":\"ls\",\"timeout\":12345678901234567}"}
+	}`
+	out, err := Normalize(testAdapter, []byte(payload))
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+
+	var input hook.Input
+	if err := json.Unmarshal(out, &input); err != nil {
+		t.Fatalf("unmarshal normalized input: %v", err)
+	}
+	if input.HookEventName != "PreToolUse" || input.ToolName != "Bash" || input.SessionID != "s-1" || input.CWD != "/repo" {
+		t.Errorf("input = %+v", input)
+	}
+	if got, want := string(input.ToolInput), `{"command":"ls","timeout":12345678901234567}`; got != want {
+		t.Errorf("tool_input = %s, want %s", got, want)
+	}
+	for _, want := range []string{`"agent":"acme"`, `"acme_version":3`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("normalized input missing %s: %s", want, out)
+		}
+	}
+}
+
+func TestNormalizeNoEvent(t *testing.T) {
+	_, err := Normalize(testAdapter, []byte(`{"acme_version": 3, "event": ""}`))
+	if !errors.Is(err, ErrNoEvent) {
+		t.Errorf("err = %v, want ErrNoEvent", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	adapters := []config.AdapterConfig{
+		{Name: "manual", Fields: map[string]string{"hook_event_name": "e"}},
+		testAdapter,
+	}
+	tests := []struct {
+		name    string
+		flag    string
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{"claude payload", "", `{"hook_event_name": "PreToolUse", "acme_version": 1}`, "", false},
+		{"detected", "", `{"acme_version": 1, "event": "x"}`, "acme", false},
+		{"undetected", "", `{"event": "x"}`, "", false},
+		{"explicit", "manual", `{"e": "x"}`, "manual", false},
+		{"unknown", "nope", `{}`, "", true},
+		{"bad json", "", `{`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok, err := Select(adapters, tt.flag, []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != (tt.want != "") || a.Name != tt.want {
+				t.Errorf("Select = %q, %v; want %q", a.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ev := map[string]string{"hook_event_name": "event"}
+	tests := []struct {
+		name     string
+		adapters []config.AdapterConfig
+		wantErr  string
+	}{
+		{"ok", []config.AdapterConfig{testAdapter}, ""},
+		{"no name", []config.AdapterConfig{{Fields: ev}}, "name is required"},
+		{"duplicate", []config.AdapterConfig{{Name: "a", Fields: ev}, {Name: "a", Fields: ev}}, "duplicate name"},
+		{"no event", []config.AdapterConfig{{Name: "a", Fields: map[string]string{"tool_name": "t"}}}, "hook_event_name is required"},
+		{"unknown field", []config.AdapterConfig{{Name: "a", Fields: map[string]string{"hook_event_name": "e", "tool": "t"}}}, `unknown field "tool"`},
+		{"empty path", []config.AdapterConfig{{Name: "a", Fields: map[string]string{"hook_event_name": ""}}}, "empty path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.adapters)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// Anomaly kinds.
+const (
+	AnomalyDenySpike          = "deny_spike"
+	AnomalyLatencyRegression  = "latency_regression"
+	AnomalyDestructiveSession = "destructive_session"
+)
+
+// Detection thresholds. Deliberately conservative: anomalies should be rare
+// enough that every one is worth a look.
+const (
+	denySpikeMin       = 5   // min denies in the window before a spike is considered
+	denySpikeFactor    = 3.0 // window denies vs hourly baseline
+	latencyMinSamples  = 5   // min executions in both periods per hook
+	latencyFactor      = 2.0 // recent avg vs baseline avg
+	destructiveSession = 5   // destructive Bash commands per session per window
+)
+
+// destructivePattern matches Bash commands considered destructive for
+// per-session anomaly detection.
+var destructivePattern = regexp.MustCompile(`(?i)\brm\s+-[a-z]*[rf]|\bgit\s+push\b.*(--force|\s-f\b)|\bgit\s+reset\s+--hard|\bdd\s+.*\bof=/dev/|\bmkfs\b|\bchmod\s+-R\s+777|\bdrop\s+(table|database)\b`)
+
+// Anomaly is a flagged deviation from normal audit patterns.
+type Anomaly struct {
+	ID          int64
+	DetectedAt  time.Time
+	Kind        string // deny_spike|latency_regression|destructive_session
+	Subject     string // hook name or session ID; empty for global anomalies
+	WindowStart time.Time
+	Value       float64
+	Baseline    float64
+	Detail      string
+}
+
+// DetectAnomalies analyzes the audit log relative to now. The detection window
+// is the last complete hour; baselines are computed from the preceding period.
+func DetectAnomalies(db *sql.DB, now time.Time) ([]Anomaly, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: DetectAnomalies called with nil db")
+	}
+
+	now = now.UTC()
+	windowEnd := now.Truncate(time.Hour)
+	windowStart := windowEnd.Add(-time.Hour)
+
+	var found []Anomaly
+
+	spike, err := detectDenySpike(db, windowStart, windowEnd)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, spike...)
+
+	latency, err := detectLatencyRegressions(db, windowEnd)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, latency...)
+
+	sessions, err := detectDestructiveSessions(db, windowStart, windowEnd)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, sessions...)
+
+	for i := range found {
+		found[i].DetectedAt = now
+	}
+	return found, nil
+}
+
+// detectDenySpike compares denies in the window against the hourly average of the prior 24h.
+func detectDenySpike(db *sql.DB, windowStart, windowEnd time.Time) ([]Anomaly, error) {
+	var recent, prior int64
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM chain_executions WHERE outcome = ? AND timestamp >= ? AND timestamp < ?",
+		OutcomeDeny, windowStart.Format("2006-01-02T15:04:05.000"), windowEnd.Format("2006-01-02T15:04:05.000"),
+	).Scan(&recent)
+	if err != nil {
+		return nil, fmt.Errorf("audit: count recent denies: %w", err)
+	}
+	if recent < denySpikeMin {
+		return nil, nil
+	}
+
+	err = db.QueryRow(
+		"SELECT COUNT(*) FROM chain_executions WHERE outcome = ? AND timestamp >= ? AND timestamp < ?",
+		OutcomeDeny, windowStart.Add(-24*time.Hour).Format("2006-01-02T15:04:05.000"), windowStart.Format("2006-01-02T15:04:05.000"),
+	).Scan(&prior)
+	if err != nil {
+		return nil, fmt.Errorf("audit: count baseline denies: %w", err)
+	}
+
+	baseline := float64(prior) / 24
+	if float64(recent) < denySpikeFactor*baseline {
+		return nil, nil
+	}
+	return []Anomaly{{
+		Kind:        AnomalyDenySpike,
+		WindowStart: windowStart,
+		Value:       float64(recent),
+		Baseline:    baseline,
+		Detail:      fmt.Sprintf("%d denies in the last hour (baseline %.1f/h)", recent, baseline),
+	}}, nil
+}
+
+// detectLatencyRegressions flags hooks whose average duration over the last
+// 24h is at least latencyFactor times their average over the 6 days before.
+// The window is the day, so a persistent regression is reported once per day.
+func detectLatencyRegressions(db *sql.DB, windowEnd time.Time) ([]Anomaly, error) {
+	recentStart := windowEnd.Add(-24 * time.Hour)
+	baselineStart := windowEnd.Add(-7 * 24 * time.Hour)
+
+	rows, err := db.Query(`
+		SELECT h.hook_name,
+		       AVG(CASE WHEN c.timestamp >= ? THEN h.duration_ms END),
+		       SUM(CASE WHEN c.timestamp >= ? THEN 1 ELSE 0 END),
+		       AVG(CASE WHEN c.timestamp < ? THEN h.duration_ms END),
+		       SUM(CASE WHEN c.timestamp < ? THEN 1 ELSE 0 END)
+		FROM hook_results h JOIN chain_executions c ON c.id = h.chain_id
+		WHERE c.timestamp >= ? AND c.timestamp < ? AND h.variant != 'b'
+		GROUP BY h.hook_name`,
+		recentStart.Format("2006-01-02T15:04:05.000"),
+		recentStart.Format("2006-01-02T15:04:05.000"),
+		recentStart.Format("2006-01-02T15:04:05.000"),
+		recentStart.Format("2006-01-02T15:04:05.000"),
+		baselineStart.Format("2006-01-02T15:04:05.000"),
+		windowEnd.Format("2006-01-02T15:04:05.000"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query hook latency: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var found []Anomaly
+	for rows.Next() {
+		var name string
+		var recentAvg, baselineAvg sql.NullFloat64
+		var recentN, baselineN int64
+		if err := rows.Scan(&name, &recentAvg, &recentN, &baselineAvg, &baselineN); err != nil {
+			return nil, fmt.Errorf("audit: scan hook latency: %w", err)
+		}
+		if recentN < latencyMinSamples || baselineN < latencyMinSamples {
+			continue
+		}
+		if !recentAvg.Valid || !baselineAvg.Valid || baselineAvg.Float64 <= 0 {
+			continue
+		}
+		if recentAvg.Float64 < latencyFactor*baselineAvg.Float64 {
+			continue
+		}
+		found = append(found, Anomaly{
+			Kind:        AnomalyLatencyRegression,
+			Subject:     name,
+			WindowStart: windowEnd.Truncate(24 * time.Hour),
+			Value:       recentAvg.Float64,
+			Baseline:    baselineAvg.Float64,
+			Detail: fmt.Sprintf("hook %q averaged %.0fms over the last 24h (baseline %.0fms)",
+				name, recentAvg.Float64, baselineAvg.Float64),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate hook latency: %w", err)
+	}
+	return found, nil
+}
+
+// detectDestructiveSessions flags sessions issuing many destructive Bash commands in the window.
+func detectDestructiveSessions(db *sql.DB, windowStart, windowEnd time.Time) ([]Anomaly, error) {
+	rows, err := db.Query(
+		"SELECT session_id, tool_detail FROM chain_executions WHERE tool_name = 'Bash' AND session_id != '' AND timestamp >= ? AND timestamp < ?",
+		windowStart.Format("2006-01-02T15:04:05.000"), windowEnd.Format("2006-01-02T15:04:05.000"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query session commands: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]int)
+	var order []string
+	for rows.Next() {
+		var session, detail string
+		if err := rows.Scan(&session, &detail); err != nil {
+			return nil, fmt.Errorf("audit: scan session command: %w", err)
+		}
+		if !destructivePattern.MatchString(detail) {
+			continue
+		}
+		if counts[session] == 0 {
+			order = append(order, session)
+		}
+		counts[session]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate session commands: %w", err)
+	}
+
+	var found []Anomaly
+	for _, session := range order {
+		n := counts[session]
+		if n < destructiveSession {
+			continue
+		}
+		found = append(found, Anomaly{
+			Kind:        AnomalyDestructiveSession,
+			Subject:     session,
+			WindowStart: windowStart,
+			Value:       float64(n),
+			Detail:      fmt.Sprintf("session %s issued %d destructive commands in the last hour", session, n),
+		})
+	}
+	return found, nil
+}
+
+// RecordAnomalies stores anomalies, ignoring ones already recorded for the
+// same kind, subject, and window. It returns only the newly recorded ones.
+func RecordAnomalies(db *sql.DB, anomalies []Anomaly) ([]Anomaly, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: RecordAnomalies called with nil db")
+	}
+
+	var inserted []Anomaly
+	for _, a := range anomalies {
+		res, err := db.Exec(
+			`INSERT OR IGNORE INTO anomalies (detected_at, kind, subject, window_start, value, baseline, detail)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			a.DetectedAt.Format("2006-01-02T15:04:05.000"),
+			a.Kind,
+			a.Subject,
+			a.WindowStart.Format("2006-01-02T15:04:05.000"),
+			a.Value,
+			a.Baseline,
+			a.Detail,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("audit: insert anomaly %s: %w", a.Kind, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("audit: anomaly rows affected: %w", err)
+		}
+		if n == 0 {
+			continue
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("audit: anomaly last insert id: %w", err)
+		}
+		a.ID = id
+		inserted = append(inserted, a)
+	}
+	return inserted, nil
+}
+
+// ListAnomalies returns recorded anomalies, newest first.
+func ListAnomalies(db *sql.DB, limit int) ([]Anomaly, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: ListAnomalies called with nil db")
+	}
+
+	query := "SELECT id, detected_at, kind, subject, window_start, value, baseline, detail FROM anomalies ORDER BY detected_at DESC, id DESC"
+	var args []any
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: list anomalies: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var anomalies []Anomaly
+	for rows.Next() {
+		var a Anomaly
+		var detectedStr, windowStr string
+		if err := rows.Scan(&a.ID, &detectedStr, &a.Kind, &a.Subject, &windowStr, &a.Value, &a.Baseline, &a.Detail); err != nil {
+			return nil, fmt.Errorf("audit: scan anomaly: %w", err)
+		}
+		if a.DetectedAt, err = time.Parse("2006-01-02T15:04:05.000", detectedStr); err != nil {
+			return nil, fmt.Errorf("audit: parse detected_at %q: %w", detectedStr, err)
+		}
+		if a.WindowStart, err = time.Parse("2006-01-02T15:04:05.000", windowStr); err != nil {
+			return nil, fmt.Errorf("audit: parse window_start %q: %w", windowStr, err)
+		}
+		anomalies = append(anomalies, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate anomalies: %w", err)
+	}
+	return anomalies, nil
+}
+
+// scanAnomalies runs detection, records new anomalies, and notifies onAnomaly
+// for each one. Errors are logged — like rotation, detection is best-effort.
+func scanAnomalies(db *sql.DB, now time.Time, onAnomaly func(Anomaly), logger *slog.Logger) {
+	found, err := DetectAnomalies(db, now)
+	if err != nil {
+		logger.Warn("anomaly detection failed", "err", err)
+		return
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	inserted, err := RecordAnomalies(db, found)
+	if err != nil {
+		logger.Warn("record anomalies failed", "err", err)
+		return
+	}
+	for _, a := range inserted {
+		logger.Warn("audit anomaly detected", "kind", a.Kind, "subject", a.Subject, "detail", a.Detail)
+		if onAnomaly != nil {
+			onAnomaly(a)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// anomalyNow is a fixed reference time; the detection window is [10:00, 11:00).
+var anomalyNow = time.Date(2025, 6, 10, 11, 30, 0, 0, time.UTC)
+
+func TestDetectAnomalies_Empty(t *testing.T) {
+	a := openTestDB(t)
+
+	found, err := DetectAnomalies(a.DB(), anomalyNow)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("expected 0 anomalies, got %+v", found)
+	}
+}
+
+func TestDetectAnomalies_DenySpike(t *testing.T) {
+	a := openTestDB(t)
+
+	// One deny per 6 hours over the previous day (baseline ~0.17/h).
+	for h := 2; h <= 24; h += 6 {
+		ts := anomalyNow.Add(-time.Duration(h) * time.Hour)
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+	// Six denies inside the window.
+	for i := range 6 {
+		ts := time.Date(2025, 6, 10, 10, i*5, 0, 0, time.UTC)
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	found, err := DetectAnomalies(a.DB(), anomalyNow)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 anomaly, got %+v", found)
+	}
+	if found[0].Kind != AnomalyDenySpike {
+		t.Errorf("Kind = %q, want %q", found[0].Kind, AnomalyDenySpike)
+	}
+	if found[0].Value != 6 {
+		t.Errorf("Value = %v, want 6", found[0].Value)
+	}
+}
+
+func TestDetectAnomalies_DenyBelowThreshold(t *testing.T) {
+	a := openTestDB(t)
+
+	for i := range denySpikeMin - 1 {
+		ts := time.Date(2025, 6, 10, 10, i*5, 0, 0, time.UTC)
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	found, err := DetectAnomalies(a.DB(), anomalyNow)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("expected 0 anomalies, got %+v", found)
+	}
+}
+
+func TestDetectAnomalies_LatencyRegression(t *testing.T) {
+	a := openTestDB(t)
+
+	record := func(ts time.Time, name string, ms int64) {
+		t.Helper()
+		hooks := []HookResult{{HookIndex: 0, HookName: name, Outcome: HookOutcomePass, DurationMs: ms}}
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, ts, hooks)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	for i := range 6 {
+		// Baseline: 3 days ago. Recent: within the last 24h.
+		record(anomalyNow.Add(-72*time.Hour+time.Duration(i)*time.Minute), "slow", 50)
+		record(anomalyNow.Add(-5*time.Hour+time.Duration(i)*time.Minute), "slow", 150)
+		record(anomalyNow.Add(-72*time.Hour+time.Duration(i)*time.Minute), "steady", 50)
+		record(anomalyNow.Add(-5*time.Hour+time.Duration(i)*time.Minute), "steady", 60)
+	}
+
+	found, err := DetectAnomalies(a.DB(), anomalyNow)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 anomaly, got %+v", found)
+	}
+	if found[0].Kind != AnomalyLatencyRegression || found[0].Subject != "slow" {
+		t.Errorf("anomaly = %+v, want latency_regression for slow", found[0])
+	}
+	if found[0].Baseline != 50 || found[0].Value != 150 {
+		t.Errorf("Value/Baseline = %v/%v, want 150/50", found[0].Value, found[0].Baseline)
+	}
+}
+
+func TestDetectAnomalies_DestructiveSession(t *testing.T) {
+	a := openTestDB(t)
+
+	commands := []string{
+		"rm -rf build",
+		"git push --force origin main",
+		"git reset --hard HEAD~3",
+		"rm -f important.txt",
+		"dd if=/dev/zero of=/dev/sda",
+		"ls -la",
+	}
+	for i, cmd := range commands {
+		for _, session := range []string{"risky", "calm"} {
+			c := sampleChain("PreToolUse", OutcomeAllow, time.Date(2025, 6, 10, 10, i, 0, 0, time.UTC), nil)
+			c.SessionID = session
+			c.ToolDetail = cmd
+			if session == "calm" {
+				c.ToolDetail = "echo " + fmt.Sprint(i)
+			}
+			if err := a.RecordChain(c); err != nil {
+				t.Fatalf("RecordChain: %v", err)
+			}
+		}
+	}
+
+	found, err := DetectAnomalies(a.DB(), anomalyNow)
+	if err != nil {
+		t.Fatalf("DetectAnomalies: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 anomaly, got %+v", found)
+	}
+	if found[0].Kind != AnomalyDestructiveSession || found[0].Subject != "risky" {
+		t.Errorf("anomaly = %+v, want destructive_session for risky", found[0])
+	}
+	if found[0].Value != 5 {
+		t.Errorf("Value = %v, want 5", found[0].Value)
+	}
+}
+
+func TestRecordAnomaliesDeduplicates(t *testing.T) {
+	a := openTestDB(t)
+
+	an := Anomaly{
+		DetectedAt:  anomalyNow,
+		Kind:        AnomalyDenySpike,
+		WindowStart: anomalyNow.Truncate(time.Hour),
+		Value:       9,
+		Detail:      "spike",
+	}
+
+	first, err := RecordAnomalies(a.DB(), []Anomaly{an})
+	if err != nil {
+		t.Fatalf("RecordAnomalies: %v", err)
+	}
+	if len(first) != 1 || first[0].ID == 0 {
+		t.Fatalf("first insert = %+v, want 1 with ID", first)
+	}
+
+	second, err := RecordAnomalies(a.DB(), []Anomaly{an})
+	if err != nil {
+		t.Fatalf("RecordAnomalies: %v", err)
+	}
+	if len(second) != 0 {
+		t.Errorf("second insert = %+v, want none (duplicate)", second)
+	}
+
+	listed, err := ListAnomalies(a.DB(), 10)
+	if err != nil {
+		t.Fatalf("ListAnomalies: %v", err)
+	}
+	if len(listed) != 1 {
+		t.Fatalf("len(ListAnomalies) = %d, want 1", len(listed))
+	}
+	if !listed[0].WindowStart.Equal(an.WindowStart) || listed[0].Detail != "spike" {
+		t.Errorf("listed = %+v, want round-tripped anomaly", listed[0])
+	}
+}
+
+func TestMaybeRotate_NotifiesAnomalies(t *testing.T) {
+	a := openTestDB(t)
+	dir := t.TempDir()
+
+	// Enough denies in the previous full hour to trigger a spike.
+	windowStart := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+	for i := range denySpikeMin {
+		ts := windowStart.Add(time.Duration(i) * time.Minute)
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	var notified []Anomaly
+	cfg := RotationConfig{
+		Retention:   7 * 24 * time.Hour,
+		ArchiveDir:  filepath.Join(dir, "archives"),
+		ThrottleDir: filepath.Join(dir, "archives"),
+		OnAnomaly:   func(an Anomaly) { notified = append(notified, an) },
+	}
+	MaybeRotate(a.DB(), cfg, testLogger())
+
+	if len(notified) != 1 || notified[0].Kind != AnomalyDenySpike {
+		t.Errorf("notified = %+v, want one deny_spike", notified)
+	}
+}
@@ -1,24 +1,36 @@
 package audit
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Outcome constants for ChainExecution.
 const (
-	OutcomeAllow = "allow"
-	OutcomeDeny  = "deny"
-	OutcomeAsk   = "ask"
-	OutcomeError = "error"
+	OutcomeAllow     = "allow"
+	OutcomeDeny      = "deny"
+	OutcomeAsk       = "ask"
+	OutcomeError     = "error"
+	OutcomeCancelled = "cancelled" // the agent gave up (SIGINT/SIGTERM) before the chain decided
 )
 
 // HookOutcome constants for HookResult.
 const (
-	HookOutcomePass    = "pass"
-	HookOutcomeDeny    = "deny"
-	HookOutcomeSkip    = "skip"
-	HookOutcomeError   = "error"
-	HookOutcomeAsk     = "ask"
-	HookOutcomeMerge   = "merge"
-	HookOutcomeContext = "context"
+	HookOutcomePass      = "pass"
+	HookOutcomeDeny      = "deny"
+	HookOutcomeSkip      = "skip"
+	HookOutcomeError     = "error"
+	HookOutcomeAsk       = "ask"
+	HookOutcomeMerge     = "merge"
+	HookOutcomeContext   = "context"
+	HookOutcomeReport    = "report"    // report-only hook that would have denied, asked, or failed
+	HookOutcomeAsync     = "async"     // async hook launched; replaced by its result when it finishes
+	HookOutcomeWaived    = "waived"    // denial downgraded to a warning by an exception
+	HookOutcomeFailOpen  = "failopen"  // hook failed with on_error: allow; the chain allowed without the rest
+	HookOutcomeRetry     = "retry"     // failed attempt of a hook that was run again
+	HookOutcomeCancelled = "cancelled" // hook in flight when the chain was cancelled
+	HookOutcomeQuota     = "quota"     // hook not run: over its quota, with on_exceeded: skip
+	HookOutcomeBudget    = "budget"    // hook not started: the chain's max_duration had passed
 )
 
 // Auditor records chain execution audit trails.
@@ -35,11 +47,29 @@ type ChainExecution struct {
 	ToolName   string
 	ToolDetail string // e.g. bash command for Bash tool
 	ChainLen   int
-	Outcome    string // allow|deny|ask|error
+	Outcome    string // allow|deny|ask|error|cancelled
 	Reason     string
 	DurationMs int64
 	SessionID  string
 	Hooks      []HookResult
+	// Shape of the hook input (see hook.Input.Fingerprint): "claude-1",
+	// "adapter/<name>", "unknown", or a version the payload declared.
+	ProtocolVersion string
+	// OverheadMs is the time hook-chain itself spent on the chain
+	// (marshaling, merging, writing this record) rather than inside hooks.
+	// Writing the record is not part of DurationMs, so OverheadMs can
+	// exceed it by that much.
+	OverheadMs int64
+	// Modified and AddedContext qualify an allow: a hook's updatedInput
+	// rewrote the tool input, or hooks added context for the model.
+	Modified     bool
+	AddedContext bool
+	// DeliveryFailed is set when the decision could not be written to the
+	// agent (see MarkDeliveryFailed).
+	DeliveryFailed bool
+	// RiskScore is the combined riskScore of the chain's hooks (see
+	// config.RiskConfig; nil if no hook reported one).
+	RiskScore *float64
 }
 
 // HookResult represents one hook execution within a chain.
@@ -49,18 +79,33 @@ type HookResult struct {
 	HookIndex  int
 	HookName   string
 	ExitCode   int
-	Outcome    string // pass|deny|skip|error|ask|merge|context
+	Outcome    string // pass|deny|skip|error|ask|merge|context|report|async|waived|failopen|retry|quota|budget
 	DurationMs int64
-	Stderr     string // truncated to maxStderrLen bytes
+	Stderr     string          // truncated to maxStderrLen bytes
+	ErrorKind  string          // runner failure class: not_found|permission|timeout|other ("" if the hook ran)
+	Metadata   json.RawMessage // hook-supplied metadata object (nil if none)
+	RuleID     string          // policy rule reported by the hook ("" if none)
+	Variant    string          // "a" (enforced) or "b" (shadow) for hooks with variants ("" otherwise)
+	Severity   string          // info|warn|high|critical, as declared by the hook ("" if none)
+	Signal     string          // signal that terminated the hook, e.g. SIGKILL ("" if it exited)
+	Patch      string          // the hook's updatedInput, redacted and capped (see RedactPatch; "" if none)
+	RiskScore  *float64        // riskScore reported by the hook (nil if none)
 }
 
 // AuditStats holds aggregate statistics from the audit database.
 type AuditStats struct {
 	TotalChains    int64
 	CountByOutcome map[string]int64
-	AvgDurationMs  float64
-	OldestEntry    time.Time
-	NewestEntry    time.Time
+	// CountBySeverity counts hook results that carry a severity, by
+	// severity and then by hook outcome.
+	CountBySeverity map[string]map[string]int64
+	AvgDurationMs   float64
+	AvgOverheadMs   float64
+	MaxOverheadMs   int64
+	OldestEntry     time.Time
+	NewestEntry     time.Time
+	// WALBytes is the size of the database's write-ahead log.
+	WALBytes int64
 }
 
 // TruncateStderr truncates s to max bytes, appending "..." if truncated.
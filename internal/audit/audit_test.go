@@ -1,6 +1,9 @@
 package audit
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -24,16 +27,17 @@ func openTestDB(t *testing.T) *SQLiteAuditor {
 
 func sampleChain(eventName, outcome string, ts time.Time, hooks []HookResult) ChainExecution {
 	return ChainExecution{
-		Timestamp:  ts,
-		EventName:  eventName,
-		ToolName:   "Bash",
-		ToolDetail: "ls -la",
-		ChainLen:   len(hooks),
-		Outcome:    outcome,
-		Reason:     "test reason",
-		DurationMs: 42,
-		SessionID:  "sess-001",
-		Hooks:      hooks,
+		Timestamp:       ts,
+		EventName:       eventName,
+		ToolName:        "Bash",
+		ToolDetail:      "ls -la",
+		ChainLen:        len(hooks),
+		Outcome:         outcome,
+		Reason:          "test reason",
+		DurationMs:      42,
+		SessionID:       "sess-001",
+		Hooks:           hooks,
+		ProtocolVersion: "claude-1",
 	}
 }
 
@@ -142,6 +146,9 @@ func TestRecordAndRetrieveChain(t *testing.T) {
 	if got.SessionID != "sess-001" {
 		t.Errorf("SessionID = %q, want sess-001", got.SessionID)
 	}
+	if got.ProtocolVersion != "claude-1" {
+		t.Errorf("ProtocolVersion = %q, want claude-1", got.ProtocolVersion)
+	}
 	if len(got.Hooks) != 2 {
 		t.Fatalf("len(Hooks) = %d, want 2", len(got.Hooks))
 	}
@@ -199,6 +206,58 @@ func TestListChainsWithFilters(t *testing.T) {
 	}
 }
 
+func TestListChainsFilterModifiedContext(t *testing.T) {
+	a := openTestDB(t)
+
+	ts := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	plain := sampleChain("PreToolUse", OutcomeAllow, ts, nil)
+	modified := sampleChain("PreToolUse", OutcomeAllow, ts.Add(time.Minute), nil)
+	modified.Modified = true
+	both := sampleChain("PreToolUse", OutcomeAllow, ts.Add(2*time.Minute), nil)
+	both.Modified = true
+	both.AddedContext = true
+	for _, e := range []ChainExecution{plain, modified, both} {
+		if err := a.RecordChain(e); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    ChainFilter
+		wantCount int
+	}{
+		{"no filter", ChainFilter{}, 3},
+		{"modified", ChainFilter{Modified: true}, 2},
+		{"context", ChainFilter{AddedContext: true}, 1},
+		{"modified and context", ChainFilter{Modified: true, AddedContext: true}, 1},
+		{"modified deny", ChainFilter{Outcome: OutcomeDeny, Modified: true}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains, err := ListChainsFilter(a.DB(), 100, 0, tt.filter)
+			if err != nil {
+				t.Fatalf("ListChainsFilter: %v", err)
+			}
+			if len(chains) != tt.wantCount {
+				t.Errorf("got %d chains, want %d", len(chains), tt.wantCount)
+			}
+		})
+	}
+
+	chains, err := ListChainsFilter(a.DB(), 1, 0, ChainFilter{})
+	if err != nil {
+		t.Fatalf("ListChainsFilter: %v", err)
+	}
+	got, err := GetChain(a.DB(), chains[0].ID)
+	if err != nil {
+		t.Fatalf("GetChain: %v", err)
+	}
+	if !got.Modified || !got.AddedContext {
+		t.Errorf("GetChain = modified %v, context %v, want both true", got.Modified, got.AddedContext)
+	}
+}
+
 func TestTail(t *testing.T) {
 	a := openTestDB(t)
 
@@ -286,9 +345,9 @@ func TestStats(t *testing.T) {
 	ts := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
 
 	entries := []ChainExecution{
-		{Timestamp: ts, EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeAllow, DurationMs: 10},
-		{Timestamp: ts.Add(1 * time.Minute), EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeAllow, DurationMs: 20},
-		{Timestamp: ts.Add(2 * time.Minute), EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeDeny, DurationMs: 30},
+		{Timestamp: ts, EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeAllow, DurationMs: 10, OverheadMs: 1},
+		{Timestamp: ts.Add(1 * time.Minute), EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeAllow, DurationMs: 20, OverheadMs: 2},
+		{Timestamp: ts.Add(2 * time.Minute), EventName: "PreToolUse", ToolName: "Bash", Outcome: OutcomeDeny, DurationMs: 30, OverheadMs: 6},
 	}
 	for _, e := range entries {
 		if err := a.RecordChain(e); err != nil {
@@ -317,6 +376,11 @@ func TestStats(t *testing.T) {
 		t.Errorf("AvgDurationMs = %f, want 20", stats.AvgDurationMs)
 	}
 
+	// Overhead: (1+2+6)/3 = 3, plus any time spent writing the records.
+	if stats.AvgOverheadMs < 3 || stats.MaxOverheadMs < 6 {
+		t.Errorf("overhead avg %f, max %d; want at least 3 and 6", stats.AvgOverheadMs, stats.MaxOverheadMs)
+	}
+
 	if !stats.OldestEntry.Equal(ts) {
 		t.Errorf("OldestEntry = %v, want %v", stats.OldestEntry, ts)
 	}
@@ -325,6 +389,42 @@ func TestStats(t *testing.T) {
 	}
 }
 
+func TestCheckpoint(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	a, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	for i := range 20 {
+		e := sampleChain("PreToolUse", OutcomeAllow, time.Now().Add(time.Duration(i)*time.Second), sampleHooks())
+		if err := a.RecordChain(e); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+	if WALSize(dbPath) == 0 {
+		t.Fatal("no WAL after writes")
+	}
+	stats, err := Stats(a.DB())
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.WALBytes != WALSize(dbPath) {
+		t.Errorf("WALBytes = %d, want %d", stats.WALBytes, WALSize(dbPath))
+	}
+
+	if err := Checkpoint(a.DB()); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if n := WALSize(dbPath); n != 0 {
+		t.Errorf("WAL is %d bytes after checkpoint, want 0", n)
+	}
+	if WALSize(filepath.Join(t.TempDir(), "missing.db")) != 0 {
+		t.Error("WALSize of a missing database is not 0")
+	}
+}
+
 func TestStatsEmpty(t *testing.T) {
 	a := openTestDB(t)
 
@@ -340,6 +440,48 @@ func TestStatsEmpty(t *testing.T) {
 	}
 }
 
+func TestMergeStats(t *testing.T) {
+	ts := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	a := &AuditStats{
+		TotalChains:     3,
+		CountByOutcome:  map[string]int64{OutcomeAllow: 2, OutcomeDeny: 1},
+		CountBySeverity: map[string]map[string]int64{"high": {HookOutcomeDeny: 1}},
+		AvgDurationMs:   10,
+		MaxOverheadMs:   4,
+		OldestEntry:     ts,
+		NewestEntry:     ts.Add(time.Hour),
+	}
+	b := &AuditStats{
+		TotalChains:     1,
+		CountByOutcome:  map[string]int64{OutcomeDeny: 1},
+		CountBySeverity: map[string]map[string]int64{"high": {HookOutcomeDeny: 2}},
+		AvgDurationMs:   30,
+		MaxOverheadMs:   9,
+		OldestEntry:     ts.Add(-time.Hour),
+		NewestEntry:     ts,
+		WALBytes:        100,
+	}
+	empty := &AuditStats{}
+
+	m := MergeStats(a, empty, b)
+	if m.TotalChains != 4 || m.CountByOutcome[OutcomeDeny] != 2 || m.CountByOutcome[OutcomeAllow] != 2 {
+		t.Errorf("counts = %d, %v", m.TotalChains, m.CountByOutcome)
+	}
+	if m.CountBySeverity["high"][HookOutcomeDeny] != 3 {
+		t.Errorf("CountBySeverity = %v", m.CountBySeverity)
+	}
+	// (3*10 + 1*30) / 4 = 15.
+	if m.AvgDurationMs != 15 || m.MaxOverheadMs != 9 || m.WALBytes != 100 {
+		t.Errorf("avg %f, max overhead %d, WAL %d", m.AvgDurationMs, m.MaxOverheadMs, m.WALBytes)
+	}
+	if !m.OldestEntry.Equal(ts.Add(-time.Hour)) || !m.NewestEntry.Equal(ts.Add(time.Hour)) {
+		t.Errorf("range = %v .. %v", m.OldestEntry, m.NewestEntry)
+	}
+	if m := MergeStats(empty); m.TotalChains != 0 || !m.OldestEntry.IsZero() {
+		t.Errorf("MergeStats(empty) = %+v", m)
+	}
+}
+
 func TestNilAuditorNoOp(t *testing.T) {
 	var a *SQLiteAuditor
 
@@ -354,6 +496,21 @@ func TestNilAuditorNoOp(t *testing.T) {
 	}
 }
 
+func TestCloseReportsCheckpointError(t *testing.T) {
+	a, err := Open(filepath.Join(t.TempDir(), "test-audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	// With the database already closed the checkpoint fails; Close must
+	// still report it rather than drop it.
+	if err := a.DB().Close(); err != nil {
+		t.Fatalf("DB().Close: %v", err)
+	}
+	if err := a.Close(); err == nil || !strings.Contains(err.Error(), "checkpoint") {
+		t.Errorf("Close error = %v, want the checkpoint error", err)
+	}
+}
+
 func TestRecordChainTransaction(t *testing.T) {
 	a := openTestDB(t)
 
@@ -610,3 +767,132 @@ func TestListChainsOffsetWithoutLimit(t *testing.T) {
 		t.Errorf("got %d chains, want 5 (limit=0 means all)", len(chains))
 	}
 }
+
+func TestMarkDeliveryFailed(t *testing.T) {
+	a := openTestDB(t)
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, time.Now().UTC(), nil)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+	chainID := a.LastChainID()
+
+	if err := MarkDeliveryFailed(a.DB(), chainID); err != nil {
+		t.Fatalf("MarkDeliveryFailed: %v", err)
+	}
+	c, err := GetChain(a.DB(), chainID)
+	if err != nil {
+		t.Fatalf("GetChain: %v", err)
+	}
+	if !c.DeliveryFailed || c.Outcome != OutcomeDeny {
+		t.Errorf("chain = %s, delivery failed %v; want deny with delivery failed", c.Outcome, c.DeliveryFailed)
+	}
+	chains, err := ListChains(a.DB(), 1, 0, "", "")
+	if err != nil || len(chains) != 1 || !chains[0].DeliveryFailed {
+		t.Errorf("ListChains = %+v, %v; want the chain with delivery failed", chains, err)
+	}
+
+	if err := MarkDeliveryFailed(a.DB(), chainID+1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("MarkDeliveryFailed(missing) err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestRiskScoreRecorded(t *testing.T) {
+	a := openTestDB(t)
+	score := 0.75
+	hooks := []HookResult{
+		{HookIndex: 0, HookName: "guard", Outcome: HookOutcomePass, RiskScore: &score},
+		{HookIndex: 1, HookName: "log", Outcome: HookOutcomePass},
+	}
+	entry := sampleChain("PreToolUse", OutcomeAsk, time.Now().UTC(), hooks)
+	entry.RiskScore = &score
+	if err := a.RecordChain(entry); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+
+	c, err := GetChain(a.DB(), a.LastChainID())
+	if err != nil {
+		t.Fatalf("GetChain: %v", err)
+	}
+	if c.RiskScore == nil || *c.RiskScore != score {
+		t.Errorf("chain risk score = %v, want %v", c.RiskScore, score)
+	}
+	if h := c.Hooks[0]; h.RiskScore == nil || *h.RiskScore != score {
+		t.Errorf("hook risk score = %v, want %v", h.RiskScore, score)
+	}
+	if h := c.Hooks[1]; h.RiskScore != nil {
+		t.Errorf("hook without a score = %v, want nil", *h.RiskScore)
+	}
+	chains, err := ListChains(a.DB(), 1, 0, "", "")
+	if err != nil || len(chains) != 1 || chains[0].RiskScore == nil {
+		t.Errorf("ListChains = %+v, %v; want the chain with its risk score", chains, err)
+	}
+}
+
+func TestUpdateHookResult(t *testing.T) {
+	a := openTestDB(t)
+	hooks := []HookResult{
+		{HookIndex: 0, HookName: "guard", Outcome: HookOutcomePass},
+		{HookIndex: 1, HookName: "telemetry", Outcome: HookOutcomeAsync},
+	}
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, time.Now().UTC(), hooks)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+	chainID := a.LastChainID()
+	if chainID == 0 {
+		t.Fatal("LastChainID = 0 after RecordChain")
+	}
+
+	err := UpdateHookResult(a.DB(), chainID, HookResult{HookIndex: 1, ExitCode: -1, Outcome: HookOutcomeError, DurationMs: 42, Stderr: "boom", ErrorKind: "timeout", Signal: "SIGKILL"})
+	if err != nil {
+		t.Fatalf("UpdateHookResult: %v", err)
+	}
+
+	c, err := GetChain(a.DB(), chainID)
+	if err != nil {
+		t.Fatalf("GetChain: %v", err)
+	}
+	got := c.Hooks[1]
+	if got.HookName != "telemetry" || got.Outcome != HookOutcomeError || got.ExitCode != -1 || got.DurationMs != 42 || got.Stderr != "boom" || got.ErrorKind != "timeout" || got.Signal != "SIGKILL" {
+		t.Errorf("hook = %+v, want updated telemetry result", got)
+	}
+
+	if err := UpdateHookResult(a.DB(), chainID, HookResult{HookIndex: 9}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpdateHookResult(missing) err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestHookMetadataAndRuleIDRoundTrip(t *testing.T) {
+	a := openTestDB(t)
+	hooks := []HookResult{
+		{HookIndex: 0, HookName: "guard", Outcome: HookOutcomeDeny, Metadata: json.RawMessage(`{"ruleId":"R1"}`), RuleID: "R1", Severity: "high"},
+		{HookIndex: 1, HookName: "log", Outcome: HookOutcomePass},
+		{HookIndex: 2, HookName: "oom", ExitCode: -1, Outcome: HookOutcomeDeny, Signal: "SIGKILL"},
+	}
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeDeny, time.Now().UTC(), hooks)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+
+	c, err := GetChain(a.DB(), a.LastChainID())
+	if err != nil {
+		t.Fatalf("GetChain: %v", err)
+	}
+	if got := string(c.Hooks[0].Metadata); got != `{"ruleId":"R1"}` {
+		t.Errorf("hook 0 Metadata = %q, want {\"ruleId\":\"R1\"}", got)
+	}
+	if c.Hooks[0].RuleID != "R1" {
+		t.Errorf("hook 0 RuleID = %q, want R1", c.Hooks[0].RuleID)
+	}
+	if c.Hooks[0].Severity != "high" {
+		t.Errorf("hook 0 Severity = %q, want high", c.Hooks[0].Severity)
+	}
+	if c.Hooks[1].Metadata != nil {
+		t.Errorf("hook 1 Metadata = %q, want nil", c.Hooks[1].Metadata)
+	}
+	if c.Hooks[1].Signal != "" || c.Hooks[2].Signal != "SIGKILL" {
+		t.Errorf("Signal = %q, %q; want \"\", SIGKILL", c.Hooks[1].Signal, c.Hooks[2].Signal)
+	}
+
+	// Chains without metadata must still marshal cleanly for --json output.
+	if _, err := json.Marshal(c); err != nil {
+		t.Errorf("Marshal chain: %v", err)
+	}
+}
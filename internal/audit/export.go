@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ChainsAfter returns up to limit chain executions with ID greater than afterID,
+// ordered by ID ascending, including their hook results. It is the building
+// block for draining the audit log into external sinks.
+func ChainsAfter(db *sql.DB, afterID int64, limit int) ([]ChainExecution, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: ChainsAfter called with nil db")
+	}
+
+	query := "SELECT id FROM chain_executions WHERE id > ? ORDER BY id ASC"
+	args := []any{afterID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query chains after %d: %w", afterID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("audit: scan chain ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate chain IDs: %w", err)
+	}
+
+	chains := make([]ChainExecution, 0, len(ids))
+	for _, id := range ids {
+		c, err := GetChain(db, id)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, *c)
+	}
+	return chains, nil
+}
+
+// ExportCursor returns the last chain ID delivered to the named sink (0 if never exported).
+func ExportCursor(db *sql.DB, sink string) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("audit: ExportCursor called with nil db")
+	}
+	var id int64
+	err := db.QueryRow("SELECT last_id FROM export_cursors WHERE sink = ?", sink).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("audit: read export cursor for %q: %w", sink, err)
+	}
+	return id, nil
+}
+
+// SetExportCursor records the last chain ID delivered to the named sink.
+func SetExportCursor(db *sql.DB, sink string, lastID int64) error {
+	if db == nil {
+		return fmt.Errorf("audit: SetExportCursor called with nil db")
+	}
+	_, err := db.Exec(
+		`INSERT INTO export_cursors (sink, last_id, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(sink) DO UPDATE SET last_id = excluded.last_id, updated_at = excluded.updated_at`,
+		sink, lastID, time.Now().UTC().Format("2006-01-02T15:04:05.000"),
+	)
+	if err != nil {
+		return fmt.Errorf("audit: set export cursor for %q: %w", sink, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// HookLatency summarizes a hook's most recent run times.
+type HookLatency struct {
+	HookName string
+	Samples  int
+	MedianMs int64
+	P95Ms    int64
+}
+
+// RecentHookLatency returns the median and p95 duration of the hook's last
+// limit runs. Shadow variant runs are excluded. A hook with no recorded runs
+// has zero samples.
+func RecentHookLatency(db *sql.DB, hookName string, limit int) (HookLatency, error) {
+	if db == nil {
+		return HookLatency{}, fmt.Errorf("audit: RecentHookLatency called with nil db")
+	}
+
+	rows, err := db.Query("SELECT duration_ms FROM hook_results WHERE hook_name = ? AND variant != 'b' ORDER BY id DESC LIMIT ?", hookName, limit)
+	if err != nil {
+		return HookLatency{}, fmt.Errorf("audit: query latency for hook %q: %w", hookName, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var durations []int64
+	for rows.Next() {
+		var d int64
+		if err := rows.Scan(&d); err != nil {
+			return HookLatency{}, fmt.Errorf("audit: scan latency row: %w", err)
+		}
+		durations = append(durations, d)
+	}
+	if err := rows.Err(); err != nil {
+		return HookLatency{}, fmt.Errorf("audit: iterate latency rows: %w", err)
+	}
+
+	lat := HookLatency{HookName: hookName, Samples: len(durations)}
+	if len(durations) == 0 {
+		return lat, nil
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	lat.MedianMs = durations[len(durations)/2]
+	lat.P95Ms = durations[(len(durations)*95-1)/100]
+	return lat, nil
+}
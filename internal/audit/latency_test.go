@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentHookLatency(t *testing.T) {
+	a := openTestDB(t)
+	ts := time.Now().UTC()
+
+	// Older slow runs fall outside the window of the last 10.
+	for range 5 {
+		hooks := []HookResult{{HookIndex: 0, HookName: "guard", Outcome: HookOutcomePass, DurationMs: 1000}}
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, ts, hooks)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+	for i := range 10 {
+		hooks := []HookResult{
+			{HookIndex: 0, HookName: "guard", Outcome: HookOutcomePass, DurationMs: int64(10 * (i + 1))},
+			{HookIndex: 1, HookName: "other", Outcome: HookOutcomePass, DurationMs: 5},
+		}
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, ts, hooks)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	lat, err := RecentHookLatency(a.DB(), "guard", 10)
+	if err != nil {
+		t.Fatalf("RecentHookLatency: %v", err)
+	}
+	if lat.Samples != 10 || lat.MedianMs != 60 || lat.P95Ms != 100 {
+		t.Errorf("latency = %+v, want samples=10 median=60 p95=100", lat)
+	}
+
+	none, err := RecentHookLatency(a.DB(), "missing", 10)
+	if err != nil {
+		t.Fatalf("RecentHookLatency(missing): %v", err)
+	}
+	if none.Samples != 0 {
+		t.Errorf("missing hook samples = %d, want 0", none.Samples)
+	}
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// OutboxStat summarizes undelivered outbox entries for one sink.
+type OutboxStat struct {
+	Sink        string
+	Pending     int64
+	MaxAttempts int64
+	LastError   string
+}
+
+// PendingOutbox returns up to limit chain IDs queued for sink, oldest first.
+func PendingOutbox(db *sql.DB, sink string, limit int) ([]int64, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: PendingOutbox called with nil db")
+	}
+
+	query := "SELECT chain_id FROM outbox WHERE sink = ? ORDER BY id ASC"
+	args := []any{sink}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query outbox for %q: %w", sink, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("audit: scan outbox row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate outbox rows: %w", err)
+	}
+	return ids, nil
+}
+
+// AckOutbox removes delivered chain IDs from sink's outbox.
+func AckOutbox(db *sql.DB, sink string, chainIDs []int64) error {
+	if db == nil {
+		return fmt.Errorf("audit: AckOutbox called with nil db")
+	}
+	if len(chainIDs) == 0 {
+		return nil
+	}
+	query, args := outboxIDQuery("DELETE FROM outbox WHERE sink = ? AND chain_id IN", sink, chainIDs)
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("audit: ack outbox for %q: %w", sink, err)
+	}
+	return nil
+}
+
+// FailOutbox records a failed delivery attempt for the given chain IDs.
+func FailOutbox(db *sql.DB, sink string, chainIDs []int64, deliveryErr error) error {
+	if db == nil {
+		return fmt.Errorf("audit: FailOutbox called with nil db")
+	}
+	if len(chainIDs) == 0 {
+		return nil
+	}
+	query, args := outboxIDQuery("UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE sink = ? AND chain_id IN", sink, chainIDs)
+	args = append([]any{TruncateStderr(deliveryErr.Error(), maxStderrLen)}, args...)
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("audit: mark outbox failure for %q: %w", sink, err)
+	}
+	return nil
+}
+
+// OutboxStats returns pending-delivery summaries for every sink with queued entries.
+func OutboxStats(db *sql.DB) ([]OutboxStat, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: OutboxStats called with nil db")
+	}
+
+	rows, err := db.Query(`
+		SELECT o.sink, COUNT(*), MAX(o.attempts),
+		       COALESCE((SELECT last_error FROM outbox l WHERE l.sink = o.sink AND l.last_error != '' ORDER BY l.id DESC LIMIT 1), '')
+		FROM outbox o GROUP BY o.sink ORDER BY o.sink`)
+	if err != nil {
+		return nil, fmt.Errorf("audit: outbox stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []OutboxStat
+	for rows.Next() {
+		var st OutboxStat
+		if err := rows.Scan(&st.Sink, &st.Pending, &st.MaxAttempts, &st.LastError); err != nil {
+			return nil, fmt.Errorf("audit: scan outbox stats: %w", err)
+		}
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate outbox stats: %w", err)
+	}
+	return stats, nil
+}
+
+// outboxIDQuery appends an "(?, ?, ...)" list for chainIDs to prefix.
+func outboxIDQuery(prefix, sink string, chainIDs []int64) (string, []any) {
+	args := make([]any, 0, len(chainIDs)+1)
+	args = append(args, sink)
+	for _, id := range chainIDs {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chainIDs)), ", ")
+	return prefix + " (" + placeholders + ")", args
+}
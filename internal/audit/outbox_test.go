@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOutboxQueuedWithChain(t *testing.T) {
+	a := openTestDB(t)
+	a.SetOutboxSinks("nats", "kafka")
+
+	ts := time.Now().UTC()
+	for range 3 {
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	for _, sink := range []string{"nats", "kafka"} {
+		ids, err := PendingOutbox(a.DB(), sink, 0)
+		if err != nil {
+			t.Fatalf("PendingOutbox(%s): %v", sink, err)
+		}
+		if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+			t.Errorf("PendingOutbox(%s) = %v, want [1 2 3]", sink, ids)
+		}
+	}
+
+	ids, err := PendingOutbox(a.DB(), "nats", 2)
+	if err != nil {
+		t.Fatalf("PendingOutbox limited: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("limited PendingOutbox = %v, want 2 ids", ids)
+	}
+}
+
+func TestOutboxAckAndFail(t *testing.T) {
+	a := openTestDB(t)
+	a.SetOutboxSinks("nats")
+
+	ts := time.Now().UTC()
+	for range 3 {
+		if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, ts, nil)); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	if err := AckOutbox(a.DB(), "nats", []int64{1, 2}); err != nil {
+		t.Fatalf("AckOutbox: %v", err)
+	}
+	if err := FailOutbox(a.DB(), "nats", []int64{3}, errors.New("connection refused")); err != nil {
+		t.Fatalf("FailOutbox: %v", err)
+	}
+
+	stats, err := OutboxStats(a.DB())
+	if err != nil {
+		t.Fatalf("OutboxStats: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("len(stats) = %d, want 1", len(stats))
+	}
+	st := stats[0]
+	if st.Sink != "nats" || st.Pending != 1 || st.MaxAttempts != 1 || st.LastError != "connection refused" {
+		t.Errorf("stats = %+v, want nats pending=1 attempts=1 last_error set", st)
+	}
+}
+
+func TestOutboxEmptyWithoutLiveSinks(t *testing.T) {
+	a := openTestDB(t)
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, time.Now().UTC(), nil)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+
+	stats, err := OutboxStats(a.DB())
+	if err != nil {
+		t.Fatalf("OutboxStats: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("stats = %+v, want empty outbox", stats)
+	}
+}
+
+func TestPruneRemovesOutboxRows(t *testing.T) {
+	a := openTestDB(t)
+	a.SetOutboxSinks("nats")
+
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, old, nil)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, time.Now().UTC(), nil)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+
+	if _, err := PruneBefore(a.DB(), time.Now().UTC().Add(-24*time.Hour)); err != nil {
+		t.Fatalf("PruneBefore: %v", err)
+	}
+
+	ids, err := PendingOutbox(a.DB(), "nats", 0)
+	if err != nil {
+		t.Fatalf("PendingOutbox: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("PendingOutbox after prune = %v, want [2]", ids)
+	}
+}
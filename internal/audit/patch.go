@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// maxPatchLen caps the stored updatedInput patch of one hook.
+const maxPatchLen = 2048
+
+// redacted replaces secret-looking values in stored patches.
+const redacted = "[REDACTED]"
+
+// sensitiveKey matches object keys whose values are never stored.
+var sensitiveKey = regexp.MustCompile(`(?i)^pass$|passw(or)?d|secret|token|api[_-]?key|credential|private[_-]?key|authorization|cookie`)
+
+// secretValue matches well-known credential formats inside string values.
+var secretValue = regexp.MustCompile(`AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9-]{10,}|sk-[A-Za-z0-9_-]{20,}|-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)|(?i:bearer)\s+[A-Za-z0-9._~+/-]{8,}=*`)
+
+// secretAssignment matches NAME=value assignments of secret-looking
+// variables in commands, such as "API_TOKEN=abc make deploy".
+var secretAssignment = regexp.MustCompile(`(?i)\b([A-Z0-9_]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY)[A-Z0-9_]*=)("[^"]*"|'[^']*'|\S+)`)
+
+// RedactPatch prepares a hook's updatedInput for the audit log: values under
+// secret-looking keys (password, token, api_key, ...) and well-known
+// credential formats inside strings are replaced with "[REDACTED]", and the
+// result is compact JSON capped at maxPatchLen bytes. A patch that is not
+// JSON is dropped rather than stored unredacted.
+func RedactPatch(patch json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(patch, &v); err != nil {
+		return ""
+	}
+	// Commands keep their <, >, and & unescaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(v)); err != nil {
+		return ""
+	}
+	return TruncateStderr(string(bytes.TrimSpace(buf.Bytes())), maxPatchLen)
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			if sensitiveKey.MatchString(k) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(elem)
+		}
+		return v
+	case []any:
+		for i, elem := range v {
+			v[i] = redactValue(elem)
+		}
+		return v
+	case string:
+		v = secretValue.ReplaceAllString(v, redacted)
+		return secretAssignment.ReplaceAllString(v, "${1}"+redacted)
+	}
+	return v
+}
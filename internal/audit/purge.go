@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PurgeFilter selects the chain executions a purge deletes. When both
+// fields are set, a chain must satisfy both.
+type PurgeFilter struct {
+	SessionID string
+	// Matching is searched for in the chain's tool detail and reason and in
+	// each hook's stderr and updatedInput patch.
+	Matching *regexp.Regexp
+}
+
+// Empty reports whether the filter selects nothing in particular; Purge
+// refuses such a filter rather than deleting everything.
+func (f PurgeFilter) Empty() bool {
+	return f.SessionID == "" && f.Matching == nil
+}
+
+// Matches reports whether the filter selects c.
+func (f PurgeFilter) Matches(c ChainExecution) bool {
+	if f.Empty() || (f.SessionID != "" && c.SessionID != f.SessionID) {
+		return false
+	}
+	if f.Matching == nil {
+		return true
+	}
+	if f.Matching.MatchString(c.ToolDetail) || f.Matching.MatchString(c.Reason) {
+		return true
+	}
+	for _, h := range c.Hooks {
+		if f.Matching.MatchString(h.Stderr) || f.Matching.MatchString(h.Patch) {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeReceipt records what a purge deleted, without the deleted data. The
+// pattern is often the personal data being erased, so it is recorded as
+// MatchingSHA256 and in plain text only on request.
+type PurgeReceipt struct {
+	PurgedAt       time.Time       `json:"purged_at"`
+	Database       string          `json:"database"`
+	Session        string          `json:"session,omitempty"`
+	Matching       string          `json:"matching,omitempty"`
+	MatchingSHA256 string          `json:"matching_sha256,omitempty"`
+	DryRun         bool            `json:"dry_run,omitempty"`
+	ChainIDs       []int64         `json:"chain_ids"`
+	Anomalies      int64           `json:"anomalies"`
+	Archives       []ArchivePurged `json:"archives,omitempty"`
+}
+
+// ArchivePurged is the number of entries a purge removed from one archive.
+type ArchivePurged struct {
+	Path    string `json:"path"`
+	Removed int    `json:"removed"`
+}
+
+// PurgeCandidates returns the IDs of the chain executions f selects,
+// oldest first.
+func PurgeCandidates(db *sql.DB, f PurgeFilter) ([]int64, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: PurgeCandidates called with nil db")
+	}
+	if f.Empty() {
+		return nil, errors.New("audit: purge needs a session or a pattern")
+	}
+
+	rows, err := db.Query(
+		`SELECT c.id, c.session_id, c.tool_detail, c.reason,
+		        COALESCE((SELECT group_concat(h.stderr || char(10) || h.patch, char(10)) FROM hook_results h WHERE h.chain_id = c.id), '')
+		 FROM chain_executions c
+		 WHERE ? = '' OR c.session_id = ?
+		 ORDER BY c.id`,
+		f.SessionID, f.SessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query purge candidates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []int64{}
+	for rows.Next() {
+		var c ChainExecution
+		var stderr string
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.ToolDetail, &c.Reason, &stderr); err != nil {
+			return nil, fmt.Errorf("audit: scan purge candidate: %w", err)
+		}
+		c.Hooks = []HookResult{{Stderr: stderr}}
+		if f.Matches(c) {
+			ids = append(ids, c.ID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate purge candidates: %w", err)
+	}
+	return ids, nil
+}
+
+// Purge hard-deletes the given chain executions with their hook results and
+// outbox entries, and, for a session purge, the anomalies recorded for that
+// session. Deleted content is overwritten in the database file
+// (secure_delete) and the write-ahead log is truncated afterwards, so no copy
+// survives on disk. It returns the number of anomalies deleted.
+func Purge(db *sql.DB, ids []int64, sessionID string) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("audit: Purge called with nil db")
+	}
+	if len(ids) == 0 && sessionID == "" {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("audit: purge connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, "PRAGMA secure_delete = ON"); err != nil {
+		return 0, fmt.Errorf("audit: enable secure_delete: %w", err)
+	}
+	defer func() { _, _ = conn.ExecContext(ctx, "PRAGMA secure_delete = OFF") }()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("audit: begin purge transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Batches stay under SQLite's limit on bound parameters.
+	for batch := range slices.Chunk(ids, purgeBatch) {
+		for _, table := range []string{"hook_results", "outbox"} {
+			query, args := idListQuery("DELETE FROM "+table+" WHERE chain_id IN", batch)
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return 0, fmt.Errorf("audit: purge %s: %w", table, err)
+			}
+		}
+		query, args := idListQuery("DELETE FROM chain_executions WHERE id IN", batch)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("audit: purge chain executions: %w", err)
+		}
+	}
+
+	var anomalies int64
+	if sessionID != "" {
+		result, err := tx.ExecContext(ctx, "DELETE FROM anomalies WHERE subject = ?", sessionID)
+		if err != nil {
+			return 0, fmt.Errorf("audit: purge anomalies: %w", err)
+		}
+		if anomalies, err = result.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("audit: purge anomalies rows affected: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("audit: commit purge: %w", err)
+	}
+	if err := Checkpoint(db); err != nil {
+		return anomalies, fmt.Errorf("audit: purged, but the write-ahead log still holds the deleted rows: %w", err)
+	}
+	return anomalies, nil
+}
+
+// purgeBatch is how many chain executions one DELETE statement names.
+const purgeBatch = 500
+
+// idListQuery builds "<prefix> (?, ?, ...)" for ids.
+func idListQuery(prefix string, ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	return prefix + " (" + marks + ")", args
+}
+
+// PurgeArchive removes the entries f selects from the archive at path,
+// rewriting it in place (atomically, as rotation writes it). With dryRun it
+// only counts them. It returns the number of entries removed.
+func PurgeArchive(path string, f PurgeFilter, dryRun bool) (int, error) {
+	entries, err := readArchive(path)
+	if err != nil {
+		return 0, err
+	}
+	kept := make([]ChainExecution, 0, len(entries))
+	for _, e := range entries {
+		if !f.Matches(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(entries) - len(kept)
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+	if err := writeArchive(path, kept); err != nil {
+		return 0, fmt.Errorf("audit: rewrite archive %s: %w", path, err)
+	}
+	return removed, nil
+}
+
+// readArchive reads the entries of an archive written by writeArchive.
+func readArchive(path string) ([]ChainExecution, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open archive %s: %w", path, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	f, err := zr.Open("audit.json")
+	if err != nil {
+		return nil, fmt.Errorf("audit: open archive %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("audit: read archive %s: %w", path, err)
+	}
+	var entries []ChainExecution
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("audit: parse archive %s: %w", path, err)
+	}
+	return entries, nil
+}
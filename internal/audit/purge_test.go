@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurgeFilterMatches(t *testing.T) {
+	c := sampleChain("PreToolUse", OutcomeDeny, time.Now(), sampleHooks())
+	c.ToolDetail = "curl https://example.com/?email=jane@example.com"
+	tests := []struct {
+		name string
+		f    PurgeFilter
+		want bool
+	}{
+		{"empty filter", PurgeFilter{}, false},
+		{"session", PurgeFilter{SessionID: "sess-001"}, true},
+		{"other session", PurgeFilter{SessionID: "sess-002"}, false},
+		{"detail", PurgeFilter{Matching: regexp.MustCompile(`jane@`)}, true},
+		{"reason", PurgeFilter{Matching: regexp.MustCompile(`^test reason$`)}, true},
+		{"hook stderr", PurgeFilter{Matching: regexp.MustCompile(`debug output`)}, true},
+		{"no match", PurgeFilter{Matching: regexp.MustCompile(`john@`)}, false},
+		{"session and pattern", PurgeFilter{SessionID: "sess-001", Matching: regexp.MustCompile(`jane@`)}, true},
+		{"pattern in another session", PurgeFilter{SessionID: "sess-002", Matching: regexp.MustCompile(`jane@`)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.Matches(c); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	a := openTestDB(t)
+	db := a.DB()
+	now := time.Now().UTC()
+
+	keep := sampleChain("PreToolUse", OutcomeAllow, now.Add(-time.Hour), sampleHooks())
+	bySession := sampleChain("PreToolUse", OutcomeDeny, now.Add(-2*time.Hour), sampleHooks())
+	bySession.SessionID = "sess-gone"
+	byStderr := sampleChain("PreToolUse", OutcomeDeny, now.Add(-3*time.Hour), []HookResult{{HookName: "guard", Outcome: HookOutcomeDeny, Stderr: "blocked mail to jane@example.com"}})
+	for _, c := range []ChainExecution{keep, bySession, byStderr} {
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+	if _, err := RecordAnomalies(db, []Anomaly{
+		{Kind: AnomalyDestructiveSession, Subject: "sess-gone", WindowStart: now, DetectedAt: now},
+		{Kind: AnomalyDestructiveSession, Subject: "sess-001", WindowStart: now, DetectedAt: now},
+	}); err != nil {
+		t.Fatalf("RecordAnomalies: %v", err)
+	}
+
+	if _, err := PurgeCandidates(db, PurgeFilter{}); err == nil {
+		t.Error("PurgeCandidates(empty filter): want error")
+	}
+
+	ids, err := PurgeCandidates(db, PurgeFilter{SessionID: "sess-gone"})
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("PurgeCandidates(session) = %v, %v; want 1", ids, err)
+	}
+	anomalies, err := Purge(db, ids, "sess-gone")
+	if err != nil || anomalies != 1 {
+		t.Fatalf("Purge(session) = %d, %v; want 1 anomaly", anomalies, err)
+	}
+
+	ids, err = PurgeCandidates(db, PurgeFilter{Matching: regexp.MustCompile(`jane@`)})
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("PurgeCandidates(pattern) = %v, %v; want 1", ids, err)
+	}
+	if _, err := Purge(db, ids, ""); err != nil {
+		t.Fatalf("Purge(pattern): %v", err)
+	}
+
+	chains, err := ListChains(db, 10, 0, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) != 1 || chains[0].SessionID != "sess-001" {
+		t.Errorf("remaining chains = %+v, want only the kept one", chains)
+	}
+	var orphans int
+	if err := db.QueryRow("SELECT COUNT(*) FROM hook_results WHERE chain_id NOT IN (SELECT id FROM chain_executions)").Scan(&orphans); err != nil || orphans != 0 {
+		t.Errorf("orphaned hook results = %d, %v", orphans, err)
+	}
+	if list, _ := ListAnomalies(db, 10); len(list) != 1 || list[0].Subject != "sess-001" {
+		t.Errorf("remaining anomalies = %+v", list)
+	}
+}
+
+func TestPurgeArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit-20260101T000000Z.zip")
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	gone := sampleChain("PreToolUse", OutcomeAllow, now, nil)
+	gone.SessionID = "sess-gone"
+	kept := sampleChain("PreToolUse", OutcomeAllow, now, nil)
+	if err := writeArchive(path, []ChainExecution{gone, kept, gone}); err != nil {
+		t.Fatal(err)
+	}
+
+	f := PurgeFilter{SessionID: "sess-gone"}
+	if n, err := PurgeArchive(path, f, true); err != nil || n != 2 {
+		t.Fatalf("PurgeArchive(dry run) = %d, %v; want 2", n, err)
+	}
+	if entries, _ := readArchive(path); len(entries) != 3 {
+		t.Errorf("dry run rewrote the archive: %d entries", len(entries))
+	}
+	if n, err := PurgeArchive(path, f, false); err != nil || n != 2 {
+		t.Fatalf("PurgeArchive = %d, %v; want 2", n, err)
+	}
+	entries, err := readArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || slices.ContainsFunc(entries, f.Matches) {
+		t.Errorf("archive after purge = %+v", entries)
+	}
+}
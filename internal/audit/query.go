@@ -2,6 +2,7 @@ package audit
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -9,20 +10,40 @@ import (
 // ListChains returns chain executions with optional filtering by event name and outcome.
 // Results are ordered by timestamp descending (newest first).
 func ListChains(db *sql.DB, limit, offset int, filterEvent, filterOutcome string) ([]ChainExecution, error) {
+	return ListChainsFilter(db, limit, offset, ChainFilter{Event: filterEvent, Outcome: filterOutcome})
+}
+
+// ChainFilter selects chain executions for ListChainsFilter. Zero fields
+// do not filter.
+type ChainFilter struct {
+	Event        string
+	Outcome      string
+	Modified     bool // only chains whose tool input a hook rewrote
+	AddedContext bool // only chains where hooks added context
+}
+
+// ListChainsFilter is ListChains with the filters of f.
+func ListChainsFilter(db *sql.DB, limit, offset int, f ChainFilter) ([]ChainExecution, error) {
 	if db == nil {
 		return nil, fmt.Errorf("audit: ListChains called with nil db")
 	}
 
-	query := "SELECT id, timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id FROM chain_executions WHERE 1=1"
+	query := "SELECT id, timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id, modified, added_context, delivery_failed, risk_score FROM chain_executions WHERE 1=1"
 	var args []any
 
-	if filterEvent != "" {
+	if f.Event != "" {
 		query += " AND event_name = ?"
-		args = append(args, filterEvent)
+		args = append(args, f.Event)
 	}
-	if filterOutcome != "" {
+	if f.Outcome != "" {
 		query += " AND outcome = ?"
-		args = append(args, filterOutcome)
+		args = append(args, f.Outcome)
+	}
+	if f.Modified {
+		query += " AND modified = 1"
+	}
+	if f.AddedContext {
+		query += " AND added_context = 1"
 	}
 
 	query += " ORDER BY timestamp DESC"
@@ -46,7 +67,7 @@ func ListChains(db *sql.DB, limit, offset int, filterEvent, filterOutcome string
 	for rows.Next() {
 		var c ChainExecution
 		var tsStr string
-		if err := rows.Scan(&c.ID, &tsStr, &c.EventName, &c.ToolName, &c.ToolDetail, &c.ChainLen, &c.Outcome, &c.Reason, &c.DurationMs, &c.SessionID); err != nil {
+		if err := rows.Scan(&c.ID, &tsStr, &c.EventName, &c.ToolName, &c.ToolDetail, &c.ChainLen, &c.Outcome, &c.Reason, &c.DurationMs, &c.SessionID, &c.Modified, &c.AddedContext, &c.DeliveryFailed, &c.RiskScore); err != nil {
 			return nil, fmt.Errorf("audit: scan chain row: %w", err)
 		}
 		ts, err := time.Parse("2006-01-02T15:04:05.000", tsStr)
@@ -72,9 +93,9 @@ func GetChain(db *sql.DB, id int64) (*ChainExecution, error) {
 	var c ChainExecution
 	var tsStr string
 	err := db.QueryRow(
-		"SELECT id, timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id FROM chain_executions WHERE id = ?",
+		"SELECT id, timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id, protocol_version, overhead_ms, modified, added_context, delivery_failed, risk_score FROM chain_executions WHERE id = ?",
 		id,
-	).Scan(&c.ID, &tsStr, &c.EventName, &c.ToolName, &c.ToolDetail, &c.ChainLen, &c.Outcome, &c.Reason, &c.DurationMs, &c.SessionID)
+	).Scan(&c.ID, &tsStr, &c.EventName, &c.ToolName, &c.ToolDetail, &c.ChainLen, &c.Outcome, &c.Reason, &c.DurationMs, &c.SessionID, &c.ProtocolVersion, &c.OverheadMs, &c.Modified, &c.AddedContext, &c.DeliveryFailed, &c.RiskScore)
 	if err != nil {
 		return nil, fmt.Errorf("audit: get chain %d: %w", id, err)
 	}
@@ -86,7 +107,7 @@ func GetChain(db *sql.DB, id int64) (*ChainExecution, error) {
 	c.Timestamp = ts
 
 	rows, err := db.Query(
-		"SELECT id, chain_id, hook_index, hook_name, exit_code, outcome, duration_ms, stderr FROM hook_results WHERE chain_id = ? ORDER BY hook_index",
+		"SELECT id, chain_id, hook_index, hook_name, exit_code, outcome, duration_ms, stderr, error_kind, metadata, rule_id, variant, severity, signal, patch, risk_score FROM hook_results WHERE chain_id = ? ORDER BY hook_index, id",
 		id,
 	)
 	if err != nil {
@@ -96,9 +117,13 @@ func GetChain(db *sql.DB, id int64) (*ChainExecution, error) {
 
 	for rows.Next() {
 		var h HookResult
-		if err := rows.Scan(&h.ID, &h.ChainID, &h.HookIndex, &h.HookName, &h.ExitCode, &h.Outcome, &h.DurationMs, &h.Stderr); err != nil {
+		var metadata string
+		if err := rows.Scan(&h.ID, &h.ChainID, &h.HookIndex, &h.HookName, &h.ExitCode, &h.Outcome, &h.DurationMs, &h.Stderr, &h.ErrorKind, &metadata, &h.RuleID, &h.Variant, &h.Severity, &h.Signal, &h.Patch, &h.RiskScore); err != nil {
 			return nil, fmt.Errorf("audit: scan hook result: %w", err)
 		}
+		if metadata != "" {
+			h.Metadata = json.RawMessage(metadata)
+		}
 		c.Hooks = append(c.Hooks, h)
 	}
 	if err := rows.Err(); err != nil {
@@ -145,6 +170,14 @@ func PruneBefore(db *sql.DB, cutoff time.Time) (int64, error) {
 		return 0, fmt.Errorf("audit: prune hook results: %w", err)
 	}
 
+	_, err = tx.Exec(
+		"DELETE FROM outbox WHERE chain_id IN (SELECT id FROM chain_executions WHERE timestamp < ?)",
+		cutoffStr,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("audit: prune outbox: %w", err)
+	}
+
 	result, err := tx.Exec("DELETE FROM chain_executions WHERE timestamp < ?", cutoffStr)
 	if err != nil {
 		return 0, fmt.Errorf("audit: prune chain executions: %w", err)
@@ -169,16 +202,27 @@ func Stats(db *sql.DB) (*AuditStats, error) {
 	}
 
 	stats := &AuditStats{
-		CountByOutcome: make(map[string]int64),
+		CountByOutcome:  make(map[string]int64),
+		CountBySeverity: make(map[string]map[string]int64),
 	}
 
-	// Total count and average duration.
-	err := db.QueryRow("SELECT COALESCE(COUNT(*), 0), COALESCE(AVG(duration_ms), 0) FROM chain_executions").
-		Scan(&stats.TotalChains, &stats.AvgDurationMs)
+	// Total count, average duration, and hook-chain's own share of it.
+	err := db.QueryRow("SELECT COALESCE(COUNT(*), 0), COALESCE(AVG(duration_ms), 0), COALESCE(AVG(overhead_ms), 0), COALESCE(MAX(overhead_ms), 0) FROM chain_executions").
+		Scan(&stats.TotalChains, &stats.AvgDurationMs, &stats.AvgOverheadMs, &stats.MaxOverheadMs)
 	if err != nil {
 		return nil, fmt.Errorf("audit: stats totals: %w", err)
 	}
 
+	// Size of the write-ahead log of the main database file.
+	var seq int
+	var name, file string
+	if err := db.QueryRow("SELECT seq, name, file FROM pragma_database_list WHERE name = 'main'").Scan(&seq, &name, &file); err != nil {
+		return nil, fmt.Errorf("audit: stats database file: %w", err)
+	}
+	if file != "" {
+		stats.WALBytes = WALSize(file)
+	}
+
 	if stats.TotalChains == 0 {
 		return stats, nil
 	}
@@ -222,5 +266,52 @@ func Stats(db *sql.DB) (*AuditStats, error) {
 		return nil, fmt.Errorf("audit: iterate outcome rows: %w", err)
 	}
 
+	stats.CountBySeverity, err = SeverityOutcomes(db, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
+
+// MergeStats combines the statistics of several audit databases: counts
+// add up, averages are weighted by each database's chain count, and the
+// entry range spans all of them.
+func MergeStats(stats ...*AuditStats) *AuditStats {
+	merged := &AuditStats{
+		CountByOutcome:  make(map[string]int64),
+		CountBySeverity: make(map[string]map[string]int64),
+	}
+	var duration, overhead float64
+	for _, s := range stats {
+		if s.TotalChains > 0 {
+			if merged.TotalChains == 0 || s.OldestEntry.Before(merged.OldestEntry) {
+				merged.OldestEntry = s.OldestEntry
+			}
+			if s.NewestEntry.After(merged.NewestEntry) {
+				merged.NewestEntry = s.NewestEntry
+			}
+		}
+		merged.TotalChains += s.TotalChains
+		duration += s.AvgDurationMs * float64(s.TotalChains)
+		overhead += s.AvgOverheadMs * float64(s.TotalChains)
+		merged.MaxOverheadMs = max(merged.MaxOverheadMs, s.MaxOverheadMs)
+		merged.WALBytes += s.WALBytes
+		for outcome, n := range s.CountByOutcome {
+			merged.CountByOutcome[outcome] += n
+		}
+		for sev, counts := range s.CountBySeverity {
+			if merged.CountBySeverity[sev] == nil {
+				merged.CountBySeverity[sev] = make(map[string]int64)
+			}
+			for outcome, n := range counts {
+				merged.CountBySeverity[sev][outcome] += n
+			}
+		}
+	}
+	if merged.TotalChains > 0 {
+		merged.AvgDurationMs = duration / float64(merged.TotalChains)
+		merged.AvgOverheadMs = overhead / float64(merged.TotalChains)
+	}
+	return merged
+}
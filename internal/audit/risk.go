@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RiskWindow is the rolling window over which session risk is computed.
+const RiskWindow = 24 * time.Hour
+
+// Risk score weights. The score is capped at maxRiskScore.
+const (
+	riskWeightDeny        = 10
+	riskWeightDestructive = 5
+	riskWeightAsk         = 2
+	maxRiskScore          = 100
+)
+
+// SessionRisk summarizes risk signals for one session within the rolling window.
+type SessionRisk struct {
+	SessionID   string
+	Chains      int64
+	Denies      int64
+	Asks        int64
+	Destructive int64 // Bash commands matching destructive patterns
+	Score       int   // 0-100
+	LastSeen    time.Time
+}
+
+// computeScore derives the 0-100 risk score from the session's signals.
+func (r *SessionRisk) computeScore() {
+	score := riskWeightDeny*r.Denies + riskWeightDestructive*r.Destructive + riskWeightAsk*r.Asks
+	r.Score = int(min(score, maxRiskScore))
+}
+
+// SessionRisks returns risk summaries for every session seen since the given
+// time, ordered by score descending (then most recently seen).
+func SessionRisks(db *sql.DB, since time.Time, limit int) ([]SessionRisk, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: SessionRisks called with nil db")
+	}
+
+	risks, err := sessionRisks(db, since, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		if risks[i].Score != risks[j].Score {
+			return risks[i].Score > risks[j].Score
+		}
+		return risks[i].LastSeen.After(risks[j].LastSeen)
+	})
+
+	if limit > 0 && len(risks) > limit {
+		risks = risks[:limit]
+	}
+	return risks, nil
+}
+
+// SessionRiskScore returns the risk summary for a single session since the given time.
+// A session with no recorded chains has a zero score.
+func SessionRiskScore(db *sql.DB, sessionID string, since time.Time) (SessionRisk, error) {
+	if db == nil {
+		return SessionRisk{}, fmt.Errorf("audit: SessionRiskScore called with nil db")
+	}
+	if sessionID == "" {
+		return SessionRisk{}, nil
+	}
+
+	risks, err := sessionRisks(db, since, sessionID)
+	if err != nil {
+		return SessionRisk{}, err
+	}
+	if len(risks) == 0 {
+		return SessionRisk{SessionID: sessionID}, nil
+	}
+	return risks[0], nil
+}
+
+// sessionRisks aggregates signals per session; sessionID filters to one session when non-empty.
+func sessionRisks(db *sql.DB, since time.Time, sessionID string) ([]SessionRisk, error) {
+	query := "SELECT session_id, timestamp, tool_name, tool_detail, outcome FROM chain_executions WHERE session_id != '' AND timestamp >= ?"
+	args := []any{since.UTC().Format("2006-01-02T15:04:05.000")}
+	if sessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, sessionID)
+	}
+	query += " ORDER BY timestamp ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query session risk: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	bySession := make(map[string]*SessionRisk)
+	var order []string
+	for rows.Next() {
+		var session, tsStr, tool, detail, outcome string
+		if err := rows.Scan(&session, &tsStr, &tool, &detail, &outcome); err != nil {
+			return nil, fmt.Errorf("audit: scan session risk row: %w", err)
+		}
+		ts, err := time.Parse("2006-01-02T15:04:05.000", tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("audit: parse timestamp %q: %w", tsStr, err)
+		}
+
+		r, ok := bySession[session]
+		if !ok {
+			r = &SessionRisk{SessionID: session}
+			bySession[session] = r
+			order = append(order, session)
+		}
+		r.Chains++
+		r.LastSeen = ts
+		switch outcome {
+		case OutcomeDeny:
+			r.Denies++
+		case OutcomeAsk:
+			r.Asks++
+		}
+		if tool == "Bash" && destructivePattern.MatchString(detail) {
+			r.Destructive++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate session risk rows: %w", err)
+	}
+
+	risks := make([]SessionRisk, 0, len(order))
+	for _, s := range order {
+		r := bySession[s]
+		r.computeScore()
+		risks = append(risks, *r)
+	}
+	return risks, nil
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func recordSessionChain(t *testing.T, a *SQLiteAuditor, session, outcome, detail string, ts time.Time) {
+	t.Helper()
+	c := sampleChain("PreToolUse", outcome, ts, nil)
+	c.SessionID = session
+	c.ToolDetail = detail
+	if err := a.RecordChain(c); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+}
+
+func TestSessionRisks(t *testing.T) {
+	a := openTestDB(t)
+	now := time.Now().UTC()
+
+	recordSessionChain(t, a, "calm", OutcomeAllow, "ls", now.Add(-time.Hour))
+	recordSessionChain(t, a, "calm", OutcomeAsk, "make build", now.Add(-50*time.Minute))
+
+	recordSessionChain(t, a, "risky", OutcomeDeny, "rm -rf /", now.Add(-40*time.Minute))
+	recordSessionChain(t, a, "risky", OutcomeAllow, "git push --force", now.Add(-30*time.Minute))
+	recordSessionChain(t, a, "risky", OutcomeAsk, "ls", now.Add(-20*time.Minute))
+
+	// Outside the window: must not count.
+	recordSessionChain(t, a, "old", OutcomeDeny, "rm -rf /", now.Add(-48*time.Hour))
+
+	risks, err := SessionRisks(a.DB(), now.Add(-RiskWindow), 0)
+	if err != nil {
+		t.Fatalf("SessionRisks: %v", err)
+	}
+	if len(risks) != 2 {
+		t.Fatalf("len(risks) = %d, want 2: %+v", len(risks), risks)
+	}
+
+	risky := risks[0]
+	if risky.SessionID != "risky" {
+		t.Fatalf("risks[0].SessionID = %q, want risky (highest score first)", risky.SessionID)
+	}
+	if risky.Denies != 1 || risky.Asks != 1 || risky.Destructive != 2 || risky.Chains != 3 {
+		t.Errorf("risky = %+v, want denies=1 asks=1 destructive=2 chains=3", risky)
+	}
+	// 10*1 + 5*2 + 2*1
+	if risky.Score != 22 {
+		t.Errorf("risky.Score = %d, want 22", risky.Score)
+	}
+
+	if risks[1].SessionID != "calm" || risks[1].Score != 2 {
+		t.Errorf("risks[1] = %+v, want calm with score 2", risks[1])
+	}
+}
+
+func TestSessionRiskScoreCapped(t *testing.T) {
+	a := openTestDB(t)
+	now := time.Now().UTC()
+
+	for i := range 15 {
+		recordSessionChain(t, a, "sess", OutcomeDeny, "ls", now.Add(-time.Duration(i)*time.Minute))
+	}
+
+	r, err := SessionRiskScore(a.DB(), "sess", now.Add(-RiskWindow))
+	if err != nil {
+		t.Fatalf("SessionRiskScore: %v", err)
+	}
+	if r.Score != maxRiskScore {
+		t.Errorf("Score = %d, want %d", r.Score, maxRiskScore)
+	}
+}
+
+func TestSessionRiskScoreUnknownSession(t *testing.T) {
+	a := openTestDB(t)
+
+	r, err := SessionRiskScore(a.DB(), "nobody", time.Now().Add(-RiskWindow))
+	if err != nil {
+		t.Fatalf("SessionRiskScore: %v", err)
+	}
+	if r.Score != 0 || r.SessionID != "nobody" {
+		t.Errorf("risk = %+v, want zero score for nobody", r)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -18,6 +19,7 @@ type RotationConfig struct {
 	Retention   time.Duration // entries older than this are archived
 	ArchiveDir  string        // directory for zip archives
 	ThrottleDir string        // directory for .last-rotation marker
+	OnAnomaly   func(Anomaly) // called for each newly detected anomaly (optional)
 }
 
 // ArchiveInfo describes a single audit archive file.
@@ -28,9 +30,10 @@ type ArchiveInfo struct {
 	ModTime time.Time
 }
 
-// MaybeRotate exports old entries to a zip archive and prunes them from the DB.
-// It is throttled to run at most once per hour. All errors are logged but never
-// returned — rotation is best-effort and must not affect the pipeline.
+// MaybeRotate exports old entries to a zip archive and prunes them from the DB,
+// runs the anomaly detection pass, and truncates the write-ahead log. It is
+// throttled to run at most once per hour. All errors are logged but never returned — rotation is best-effort and
+// must not affect the pipeline.
 func MaybeRotate(db *sql.DB, cfg RotationConfig, logger *slog.Logger) {
 	if db == nil {
 		return
@@ -45,37 +48,70 @@ func MaybeRotate(db *sql.DB, cfg RotationConfig, logger *slog.Logger) {
 	// Touch marker FIRST — prevents thundering herd if rotation fails.
 	touchMarker(markerPath, logger)
 
+	if err := rotate(db, cfg, logger); err != nil {
+		logger.Warn("rotation failed", "err", err)
+	}
+	scanAnomalies(db, time.Now(), cfg.OnAnomaly, logger)
+
+	// Processes killed mid-run never checkpoint on close, so the WAL can
+	// grow without bound; reset it here.
+	if err := Checkpoint(db); err != nil {
+		logger.Warn("rotation: checkpoint failed", "err", err)
+	}
+}
+
+// Maintain runs the retention tasks now, ignoring the hourly throttle: it
+// archives and prunes old entries, runs the anomaly detection pass,
+// truncates the write-ahead log, and vacuums the database. It is what
+// `hook-chain maintain` runs on a schedule, outside the pipeline. The
+// throttle marker is touched so pipeline runs do not repeat the work within
+// the hour. Unlike MaybeRotate it returns the failures, joined.
+func Maintain(db *sql.DB, cfg RotationConfig, logger *slog.Logger) error {
+	touchMarker(filepath.Join(cfg.ThrottleDir, ".last-rotation"), logger)
+
+	var errs []error
+	if err := rotate(db, cfg, logger); err != nil {
+		errs = append(errs, err)
+	}
+	scanAnomalies(db, time.Now(), cfg.OnAnomaly, logger)
+	if err := Checkpoint(db); err != nil {
+		errs = append(errs, err)
+	}
+	if err := Vacuum(db); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// rotate archives and prunes entries older than the retention period.
+func rotate(db *sql.DB, cfg RotationConfig, logger *slog.Logger) error {
 	cutoff := time.Now().UTC().Add(-cfg.Retention)
 
 	entries, err := exportEntries(db, cutoff)
 	if err != nil {
-		logger.Warn("rotation: export entries failed", "err", err)
-		return
+		return fmt.Errorf("audit: rotation: export entries: %w", err)
 	}
 	if len(entries) == 0 {
 		logger.Debug("rotation: no entries to archive")
-		return
+		return nil
 	}
 
 	// Ensure archive dir exists.
 	if err := os.MkdirAll(cfg.ArchiveDir, 0o755); err != nil {
-		logger.Warn("rotation: create archive dir", "err", err)
-		return
+		return fmt.Errorf("audit: rotation: create archive dir: %w", err)
 	}
 
 	archiveName := fmt.Sprintf("audit-%s.zip", time.Now().UTC().Format("20060102T150405Z"))
 	archivePath := filepath.Join(cfg.ArchiveDir, archiveName)
 
 	if err := writeArchive(archivePath, entries); err != nil {
-		logger.Warn("rotation: write archive failed", "err", err)
-		return
+		return fmt.Errorf("audit: rotation: write archive: %w", err)
 	}
 
 	// Prune exported entries.
 	pruned, err := PruneBefore(db, cutoff)
 	if err != nil {
-		logger.Warn("rotation: prune failed (archive already written)", "err", err)
-		return
+		return fmt.Errorf("audit: rotation: prune (archive already written to %s): %w", archivePath, err)
 	}
 
 	logger.Info("rotation complete",
@@ -83,6 +119,7 @@ func MaybeRotate(db *sql.DB, cfg RotationConfig, logger *slog.Logger) {
 		"pruned", pruned,
 		"archive", archivePath,
 	)
+	return nil
 }
 
 // shouldRotate returns true if the marker file does not exist or is older than 1 hour.
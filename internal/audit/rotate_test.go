@@ -170,6 +170,45 @@ func TestMaybeRotate_ThrottleExpired(t *testing.T) {
 	}
 }
 
+func TestMaintain_IgnoresThrottle(t *testing.T) {
+	a := openTestDB(t)
+	archiveDir := filepath.Join(t.TempDir(), "archives")
+	cfg := RotationConfig{
+		Retention:   24 * time.Hour,
+		ArchiveDir:  archiveDir,
+		ThrottleDir: archiveDir,
+	}
+
+	// A pipeline run just rotated, so MaybeRotate would skip.
+	MaybeRotate(a.DB(), cfg, testLogger())
+	oldTS := time.Now().UTC().Add(-48 * time.Hour)
+	if err := a.RecordChain(sampleChain("PreToolUse", OutcomeAllow, oldTS, nil)); err != nil {
+		t.Fatalf("RecordChain: %v", err)
+	}
+
+	if err := Maintain(a.DB(), cfg, testLogger()); err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+
+	archives, err := ListArchives(archiveDir)
+	if err != nil {
+		t.Fatalf("ListArchives: %v", err)
+	}
+	if len(archives) != 1 {
+		t.Errorf("expected 1 archive, got %d", len(archives))
+	}
+	remaining, err := ListChains(a.DB(), 100, 0, "", "")
+	if err != nil {
+		t.Fatalf("ListChains: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected the old chain pruned, %d remain", len(remaining))
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, ".last-rotation")); err != nil {
+		t.Errorf("throttle marker not touched: %v", err)
+	}
+}
+
 func TestArchiveContents(t *testing.T) {
 	a := openTestDB(t)
 	dir := t.TempDir()
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SeverityOutcomes counts hook results that carry a severity since the
+// given time, keyed by severity and then by hook outcome. Shadow variant
+// runs are excluded. A zero since counts the whole log.
+func SeverityOutcomes(db *sql.DB, since time.Time) (map[string]map[string]int64, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: SeverityOutcomes called with nil db")
+	}
+	rows, err := db.Query(`SELECT h.severity, h.outcome, COUNT(*)
+		FROM hook_results h JOIN chain_executions c ON c.id = h.chain_id
+		WHERE h.severity != '' AND h.variant != 'b' AND c.timestamp >= ?
+		GROUP BY h.severity, h.outcome`, since.UTC().Format("2006-01-02T15:04:05.000"))
+	if err != nil {
+		return nil, fmt.Errorf("audit: query severity outcomes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := map[string]map[string]int64{}
+	for rows.Next() {
+		var severity, outcome string
+		var n int64
+		if err := rows.Scan(&severity, &outcome, &n); err != nil {
+			return nil, fmt.Errorf("audit: scan severity row: %w", err)
+		}
+		if counts[severity] == nil {
+			counts[severity] = map[string]int64{}
+		}
+		counts[severity][outcome] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate severity rows: %w", err)
+	}
+	return counts, nil
+}
+
+// OutcomesSince counts chain executions since the given time by outcome.
+func OutcomesSince(db *sql.DB, since time.Time) (map[string]int64, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: OutcomesSince called with nil db")
+	}
+	rows, err := db.Query("SELECT outcome, COUNT(*) FROM chain_executions WHERE timestamp >= ? GROUP BY outcome",
+		since.UTC().Format("2006-01-02T15:04:05.000"))
+	if err != nil {
+		return nil, fmt.Errorf("audit: query outcomes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var outcome string
+		var n int64
+		if err := rows.Scan(&outcome, &n); err != nil {
+			return nil, fmt.Errorf("audit: scan outcome count: %w", err)
+		}
+		counts[outcome] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate outcome rows: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeverityOutcomes(t *testing.T) {
+	a := openTestDB(t)
+	now := time.Now().UTC()
+	chains := []ChainExecution{
+		sampleChain("PreToolUse", OutcomeDeny, now.Add(-time.Hour), []HookResult{
+			{HookIndex: 0, HookName: "guard", Outcome: HookOutcomeDeny, Severity: "critical"},
+		}),
+		sampleChain("PreToolUse", OutcomeAsk, now.Add(-2*time.Hour), []HookResult{
+			{HookIndex: 0, HookName: "lint", Outcome: HookOutcomeContext, Severity: "info"},
+			{HookIndex: 1, HookName: "guard", Outcome: HookOutcomeAsk, Severity: "warn"},
+			{HookIndex: 1, HookName: "guard", Outcome: HookOutcomeReport, Severity: "warn", Variant: "b"},
+		}),
+		sampleChain("PreToolUse", OutcomeAllow, now.Add(-3*time.Hour), []HookResult{
+			{HookIndex: 0, HookName: "lint", Outcome: HookOutcomeContext, Severity: "info"},
+			{HookIndex: 1, HookName: "guard", Outcome: HookOutcomePass},
+		}),
+		sampleChain("PreToolUse", OutcomeDeny, now.Add(-30*24*time.Hour), []HookResult{
+			{HookIndex: 0, HookName: "guard", Outcome: HookOutcomeDeny, Severity: "critical"},
+		}),
+	}
+	for _, c := range chains {
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	got, err := SeverityOutcomes(a.DB(), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("SeverityOutcomes: %v", err)
+	}
+	want := map[string]map[string]int64{
+		"critical": {"deny": 1},
+		"warn":     {"ask": 1},
+		"info":     {"context": 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SeverityOutcomes = %v, want %v", got, want)
+	}
+
+	outcomes, err := OutcomesSince(a.DB(), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("OutcomesSince: %v", err)
+	}
+	if want := map[string]int64{"deny": 1, "ask": 1, "allow": 1}; !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("OutcomesSince = %v, want %v", outcomes, want)
+	}
+
+	stats, err := Stats(a.DB())
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if n := stats.CountBySeverity["critical"]["deny"]; n != 2 {
+		t.Errorf("Stats critical denies = %d, want 2", n)
+	}
+}
@@ -2,6 +2,7 @@ package audit
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,7 +15,9 @@ const maxStderrLen = 512
 
 // SQLiteAuditor implements Auditor using a local SQLite database.
 type SQLiteAuditor struct {
-	db *sql.DB
+	db          *sql.DB
+	outboxSinks []string
+	lastChainID int64
 }
 
 const schema = `
@@ -45,6 +48,43 @@ CREATE INDEX IF NOT EXISTS idx_chain_ts ON chain_executions(timestamp);
 CREATE INDEX IF NOT EXISTS idx_hook_chain ON hook_results(chain_id);
 `
 
+const anomaliesSchema = `
+CREATE TABLE IF NOT EXISTS anomalies (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    detected_at  TEXT    NOT NULL,
+    kind         TEXT    NOT NULL,
+    subject      TEXT    NOT NULL DEFAULT '',
+    window_start TEXT    NOT NULL,
+    value        REAL    NOT NULL DEFAULT 0,
+    baseline     REAL    NOT NULL DEFAULT 0,
+    detail       TEXT    NOT NULL DEFAULT '',
+    UNIQUE (kind, subject, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomaly_ts ON anomalies(detected_at);
+`
+
+const exportCursorsSchema = `
+CREATE TABLE IF NOT EXISTS export_cursors (
+    sink       TEXT    PRIMARY KEY,
+    last_id    INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT    NOT NULL
+);
+`
+
+const outboxSchema = `
+CREATE TABLE IF NOT EXISTS outbox (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    sink       TEXT    NOT NULL,
+    chain_id   INTEGER NOT NULL REFERENCES chain_executions(id),
+    created_at TEXT    NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_sink ON outbox(sink, id);
+`
+
 // DefaultDBPath returns the default audit database path.
 // It checks $HOOK_CHAIN_AUDIT_DB, then $XDG_DATA_HOME/hook-chain/audit.db,
 // then falls back to ~/.local/share/hook-chain/audit.db.
@@ -122,7 +162,7 @@ func migrate(db *sql.DB) error {
 		return fmt.Errorf("read user_version: %w", err)
 	}
 
-	if version == 0 {
+	if version < 1 {
 		exists, err := columnExists(db, "chain_executions", "tool_detail")
 		if err != nil {
 			return fmt.Errorf("check tool_detail column: %w", err)
@@ -137,7 +177,229 @@ func migrate(db *sql.DB) error {
 		}
 	}
 
-	// version >= 1: schema is current, nothing to do.
+	if version < 2 {
+		if _, err := db.Exec(anomaliesSchema); err != nil {
+			return fmt.Errorf("create anomalies table: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 2"); err != nil {
+			return fmt.Errorf("set user_version to 2: %w", err)
+		}
+	}
+
+	if version < 3 {
+		if _, err := db.Exec(exportCursorsSchema); err != nil {
+			return fmt.Errorf("create export_cursors table: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 3"); err != nil {
+			return fmt.Errorf("set user_version to 3: %w", err)
+		}
+	}
+
+	if version < 4 {
+		if _, err := db.Exec(outboxSchema); err != nil {
+			return fmt.Errorf("create outbox table: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 4"); err != nil {
+			return fmt.Errorf("set user_version to 4: %w", err)
+		}
+	}
+
+	if version < 5 {
+		exists, err := columnExists(db, "hook_results", "error_kind")
+		if err != nil {
+			return fmt.Errorf("check error_kind column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN error_kind TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add error_kind column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 5"); err != nil {
+			return fmt.Errorf("set user_version to 5: %w", err)
+		}
+	}
+
+	if version < 6 {
+		exists, err := columnExists(db, "hook_results", "metadata")
+		if err != nil {
+			return fmt.Errorf("check metadata column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN metadata TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add metadata column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 6"); err != nil {
+			return fmt.Errorf("set user_version to 6: %w", err)
+		}
+	}
+
+	if version < 7 {
+		exists, err := columnExists(db, "hook_results", "rule_id")
+		if err != nil {
+			return fmt.Errorf("check rule_id column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN rule_id TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add rule_id column: %w", err)
+			}
+		}
+		if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_hook_rule ON hook_results(rule_id)"); err != nil {
+			return fmt.Errorf("create rule_id index: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 7"); err != nil {
+			return fmt.Errorf("set user_version to 7: %w", err)
+		}
+	}
+
+	if version < 8 {
+		exists, err := columnExists(db, "hook_results", "variant")
+		if err != nil {
+			return fmt.Errorf("check variant column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN variant TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add variant column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 8"); err != nil {
+			return fmt.Errorf("set user_version to 8: %w", err)
+		}
+	}
+
+	if version < 9 {
+		exists, err := columnExists(db, "hook_results", "severity")
+		if err != nil {
+			return fmt.Errorf("check severity column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN severity TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add severity column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 9"); err != nil {
+			return fmt.Errorf("set user_version to 9: %w", err)
+		}
+	}
+
+	if version < 10 {
+		exists, err := columnExists(db, "chain_executions", "protocol_version")
+		if err != nil {
+			return fmt.Errorf("check protocol_version column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE chain_executions ADD COLUMN protocol_version TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add protocol_version column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 10"); err != nil {
+			return fmt.Errorf("set user_version to 10: %w", err)
+		}
+	}
+
+	if version < 11 {
+		exists, err := columnExists(db, "hook_results", "signal")
+		if err != nil {
+			return fmt.Errorf("check signal column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN signal TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add signal column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 11"); err != nil {
+			return fmt.Errorf("set user_version to 11: %w", err)
+		}
+	}
+
+	if version < 12 {
+		exists, err := columnExists(db, "chain_executions", "overhead_ms")
+		if err != nil {
+			return fmt.Errorf("check overhead_ms column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE chain_executions ADD COLUMN overhead_ms INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return fmt.Errorf("add overhead_ms column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 12"); err != nil {
+			return fmt.Errorf("set user_version to 12: %w", err)
+		}
+	}
+
+	if version < 13 {
+		exists, err := columnExists(db, "hook_results", "patch")
+		if err != nil {
+			return fmt.Errorf("check patch column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE hook_results ADD COLUMN patch TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("add patch column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 13"); err != nil {
+			return fmt.Errorf("set user_version to 13: %w", err)
+		}
+	}
+
+	if version < 14 {
+		for _, column := range []string{"modified", "added_context"} {
+			exists, err := columnExists(db, "chain_executions", column)
+			if err != nil {
+				return fmt.Errorf("check %s column: %w", column, err)
+			}
+			if !exists {
+				if _, err := db.Exec("ALTER TABLE chain_executions ADD COLUMN " + column + " INTEGER NOT NULL DEFAULT 0"); err != nil {
+					return fmt.Errorf("add %s column: %w", column, err)
+				}
+			}
+		}
+		// Older allows are flagged from their hook outcomes; a hook that both
+		// merged and added context was recorded as merge only.
+		if _, err := db.Exec(`UPDATE chain_executions SET
+			modified = EXISTS (SELECT 1 FROM hook_results h WHERE h.chain_id = chain_executions.id AND h.outcome = 'merge'),
+			added_context = EXISTS (SELECT 1 FROM hook_results h WHERE h.chain_id = chain_executions.id AND h.outcome = 'context')
+			WHERE outcome = 'allow'`); err != nil {
+			return fmt.Errorf("backfill modified and added_context: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 14"); err != nil {
+			return fmt.Errorf("set user_version to 14: %w", err)
+		}
+	}
+
+	if version < 15 {
+		exists, err := columnExists(db, "chain_executions", "delivery_failed")
+		if err != nil {
+			return fmt.Errorf("check delivery_failed column: %w", err)
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE chain_executions ADD COLUMN delivery_failed INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return fmt.Errorf("add delivery_failed column: %w", err)
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 15"); err != nil {
+			return fmt.Errorf("set user_version to 15: %w", err)
+		}
+	}
+
+	if version < 16 {
+		for _, table := range []string{"chain_executions", "hook_results"} {
+			exists, err := columnExists(db, table, "risk_score")
+			if err != nil {
+				return fmt.Errorf("check %s.risk_score column: %w", table, err)
+			}
+			if !exists {
+				if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN risk_score REAL"); err != nil {
+					return fmt.Errorf("add %s.risk_score column: %w", table, err)
+				}
+			}
+		}
+		if _, err := db.Exec("PRAGMA user_version = 16"); err != nil {
+			return fmt.Errorf("set user_version to 16: %w", err)
+		}
+	}
+
+	// version >= 16: schema is current, nothing to do.
 	return nil
 }
 
@@ -173,12 +435,23 @@ func (a *SQLiteAuditor) DB() *sql.DB {
 	return a.db
 }
 
-// RecordChain inserts a chain execution and its hook results in a single transaction.
-// Nil receiver is a no-op.
+// SetOutboxSinks names the live sinks that every recorded chain is queued for.
+// Outbox rows are written in the same transaction as the chain itself, so a
+// record is never lost between the audit log and a live sink.
+func (a *SQLiteAuditor) SetOutboxSinks(names ...string) {
+	if a == nil {
+		return
+	}
+	a.outboxSinks = names
+}
+
+// RecordChain inserts a chain execution and its hook results in a single transaction,
+// queuing it in the outbox for each live sink. Nil receiver is a no-op.
 func (a *SQLiteAuditor) RecordChain(entry ChainExecution) error {
 	if a == nil {
 		return nil
 	}
+	writeStart := time.Now()
 
 	tx, err := a.db.Begin()
 	if err != nil {
@@ -195,8 +468,8 @@ func (a *SQLiteAuditor) RecordChain(entry ChainExecution) error {
 	}
 
 	result, err := tx.Exec(
-		`INSERT INTO chain_executions (timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO chain_executions (timestamp, event_name, tool_name, tool_detail, chain_len, outcome, reason, duration_ms, session_id, protocol_version, overhead_ms, modified, added_context, delivery_failed, risk_score)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		ts.Format("2006-01-02T15:04:05.000"),
 		entry.EventName,
 		entry.ToolName,
@@ -206,6 +479,12 @@ func (a *SQLiteAuditor) RecordChain(entry ChainExecution) error {
 		entry.Reason,
 		entry.DurationMs,
 		entry.SessionID,
+		entry.ProtocolVersion,
+		entry.OverheadMs,
+		entry.Modified,
+		entry.AddedContext,
+		entry.DeliveryFailed,
+		entry.RiskScore,
 	)
 	if err != nil {
 		return fmt.Errorf("audit: insert chain_execution: %w", err)
@@ -219,8 +498,8 @@ func (a *SQLiteAuditor) RecordChain(entry ChainExecution) error {
 	for _, h := range entry.Hooks {
 		stderr := TruncateStderr(h.Stderr, maxStderrLen)
 		_, err := tx.Exec(
-			`INSERT INTO hook_results (chain_id, hook_index, hook_name, exit_code, outcome, duration_ms, stderr)
-			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO hook_results (chain_id, hook_index, hook_name, exit_code, outcome, duration_ms, stderr, error_kind, metadata, rule_id, variant, severity, signal, patch, risk_score)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			chainID,
 			h.HookIndex,
 			h.HookName,
@@ -228,16 +507,99 @@ func (a *SQLiteAuditor) RecordChain(entry ChainExecution) error {
 			h.Outcome,
 			h.DurationMs,
 			stderr,
+			h.ErrorKind,
+			string(h.Metadata),
+			h.RuleID,
+			h.Variant,
+			h.Severity,
+			h.Signal,
+			TruncateStderr(h.Patch, maxPatchLen),
+			h.RiskScore,
 		)
 		if err != nil {
 			return fmt.Errorf("audit: insert hook_result for hook %q: %w", h.HookName, err)
 		}
 	}
 
+	for _, sink := range a.outboxSinks {
+		_, err := tx.Exec(
+			"INSERT INTO outbox (sink, chain_id, created_at) VALUES (?, ?, ?)",
+			sink, chainID, ts.Format("2006-01-02T15:04:05.000"),
+		)
+		if err != nil {
+			return fmt.Errorf("audit: queue chain for sink %q: %w", sink, err)
+		}
+	}
+
+	// Writing the record is overhead too; everything but the commit is
+	// added to overhead_ms. duration_ms stays the time up to the write.
+	if ms := time.Since(writeStart).Milliseconds(); ms > 0 {
+		_, err := tx.Exec(
+			"UPDATE chain_executions SET overhead_ms = overhead_ms + ? WHERE id = ?",
+			ms, chainID,
+		)
+		if err != nil {
+			return fmt.Errorf("audit: record write time: %w", err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("audit: commit transaction: %w", err)
 	}
 
+	a.lastChainID = chainID
+	return nil
+}
+
+// LastChainID returns the ID of the most recently recorded chain, or 0 if
+// none was recorded through this auditor. Nil receiver returns 0.
+func (a *SQLiteAuditor) LastChainID() int64 {
+	if a == nil {
+		return 0
+	}
+	return a.lastChainID
+}
+
+// MarkDeliveryFailed records that the decision of a recorded chain could
+// not be written to the agent, which had stopped reading.
+func MarkDeliveryFailed(db *sql.DB, chainID int64) error {
+	if db == nil {
+		return fmt.Errorf("audit: MarkDeliveryFailed called with nil db")
+	}
+	res, err := db.Exec("UPDATE chain_executions SET delivery_failed = 1 WHERE id = ?", chainID)
+	if err != nil {
+		return fmt.Errorf("audit: mark chain %d delivery failed: %w", chainID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("audit: mark chain %d delivery failed: %w", chainID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("audit: mark chain %d delivery failed: %w", chainID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// UpdateHookResult replaces the result of one hook in a recorded chain. It is
+// used to fill in async hooks, which finish after their chain was recorded.
+func UpdateHookResult(db *sql.DB, chainID int64, hr HookResult) error {
+	if db == nil {
+		return fmt.Errorf("audit: UpdateHookResult called with nil db")
+	}
+	res, err := db.Exec(
+		"UPDATE hook_results SET exit_code = ?, outcome = ?, duration_ms = ?, stderr = ?, error_kind = ?, signal = ? WHERE chain_id = ? AND hook_index = ?",
+		hr.ExitCode, hr.Outcome, hr.DurationMs, TruncateStderr(hr.Stderr, maxStderrLen), hr.ErrorKind, hr.Signal, chainID, hr.HookIndex,
+	)
+	if err != nil {
+		return fmt.Errorf("audit: update hook %d of chain %d: %w", hr.HookIndex, chainID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("audit: update hook %d of chain %d: %w", hr.HookIndex, chainID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("audit: update hook %d of chain %d: %w", hr.HookIndex, chainID, sql.ErrNoRows)
+	}
 	return nil
 }
 
@@ -247,8 +609,49 @@ func (a *SQLiteAuditor) Close() error {
 	if a == nil {
 		return nil
 	}
+	// Fold the WAL back into the database without waiting on other
+	// connections; a busy log is left to a later Close or to rotation.
+	// The database is closed even when the checkpoint fails.
+	var checkpointErr, closeErr error
+	if _, err := a.db.Exec("PRAGMA wal_checkpoint(PASSIVE)"); err != nil {
+		checkpointErr = fmt.Errorf("audit: checkpoint WAL: %w", err)
+	}
 	if err := a.db.Close(); err != nil {
-		return fmt.Errorf("audit: close database: %w", err)
+		closeErr = fmt.Errorf("audit: close database: %w", err)
+	}
+	return errors.Join(checkpointErr, closeErr)
+}
+
+// Checkpoint copies the write-ahead log into the database file and truncates
+// the log to zero bytes. It waits up to the busy timeout for other
+// connections' transactions and fails if they still hold the log.
+func Checkpoint(db *sql.DB) error {
+	var busy, logPages, checkpointed int
+	if err := db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logPages, &checkpointed); err != nil {
+		return fmt.Errorf("audit: checkpoint: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("audit: checkpoint: database busy (%d of %d WAL pages copied)", checkpointed, logPages)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file to return the pages freed by pruning
+// to the filesystem. It needs exclusive access and waits up to the busy
+// timeout for it.
+func Vacuum(db *sql.DB) error {
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("audit: vacuum: %w", err)
 	}
 	return nil
 }
+
+// WALSize returns the size in bytes of the write-ahead log next to the
+// database at dbPath, or 0 when there is none.
+func WALSize(dbPath string) int64 {
+	info, err := os.Stat(dbPath + "-wal")
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Dimensions accepted by Top.
+const (
+	TopByRule = "rule"
+	TopByHook = "hook"
+	TopByTool = "tool"
+)
+
+// TopEntry is one row of a Top aggregation.
+type TopEntry struct {
+	Key      string
+	Count    int64 // hook results (rule, hook) or chains (tool)
+	Denies   int64
+	LastSeen time.Time
+}
+
+// topQueries maps each dimension to its aggregation query. Each query takes
+// the window start and a limit, and selects key, count, denies, last seen.
+var topQueries = map[string]string{
+	TopByRule: `SELECT h.rule_id, COUNT(*), SUM(CASE WHEN h.outcome = 'deny' THEN 1 ELSE 0 END), MAX(c.timestamp)
+		FROM hook_results h JOIN chain_executions c ON c.id = h.chain_id
+		WHERE h.rule_id != '' AND c.timestamp >= ?
+		GROUP BY h.rule_id ORDER BY 2 DESC, 1 LIMIT ?`,
+	TopByHook: `SELECT h.hook_name, COUNT(*), SUM(CASE WHEN h.outcome = 'deny' THEN 1 ELSE 0 END), MAX(c.timestamp)
+		FROM hook_results h JOIN chain_executions c ON c.id = h.chain_id
+		WHERE c.timestamp >= ?
+		GROUP BY h.hook_name ORDER BY 2 DESC, 1 LIMIT ?`,
+	TopByTool: `SELECT tool_name, COUNT(*), SUM(CASE WHEN outcome = 'deny' THEN 1 ELSE 0 END), MAX(timestamp)
+		FROM chain_executions
+		WHERE tool_name != '' AND timestamp >= ?
+		GROUP BY tool_name ORDER BY 2 DESC, 1 LIMIT ?`,
+}
+
+// Top returns the most frequent keys of the given dimension (TopByRule,
+// TopByHook, TopByTool) since the given time, ordered by count descending.
+// limit <= 0 means no limit.
+func Top(db *sql.DB, by string, since time.Time, limit int) ([]TopEntry, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: Top called with nil db")
+	}
+	query, ok := topQueries[by]
+	if !ok {
+		return nil, fmt.Errorf("audit: unknown top dimension %q (want %s, %s, or %s)", by, TopByRule, TopByHook, TopByTool)
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := db.Query(query, since.UTC().Format("2006-01-02T15:04:05.000"), limit)
+	if err != nil {
+		return nil, fmt.Errorf("audit: query top by %s: %w", by, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []TopEntry
+	for rows.Next() {
+		var e TopEntry
+		var tsStr string
+		if err := rows.Scan(&e.Key, &e.Count, &e.Denies, &tsStr); err != nil {
+			return nil, fmt.Errorf("audit: scan top row: %w", err)
+		}
+		ts, err := time.Parse("2006-01-02T15:04:05.000", tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("audit: parse timestamp %q: %w", tsStr, err)
+		}
+		e.LastSeen = ts
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate top rows: %w", err)
+	}
+	return entries, nil
+}
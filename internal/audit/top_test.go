@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTop(t *testing.T) {
+	a := openTestDB(t)
+	now := time.Now().UTC()
+
+	record := func(ts time.Time, outcome, tool string, hooks ...HookResult) {
+		t.Helper()
+		c := sampleChain("PreToolUse", outcome, ts, hooks)
+		c.ToolName = tool
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+	deny := func(rule string) HookResult {
+		return HookResult{HookName: "guard", Outcome: HookOutcomeDeny, RuleID: rule}
+	}
+	pass := func(rule string) HookResult {
+		return HookResult{HookName: "lint", Outcome: HookOutcomePass, RuleID: rule}
+	}
+
+	record(now.Add(-3*time.Hour), OutcomeDeny, "Bash", deny("no-rm"))
+	record(now.Add(-2*time.Hour), OutcomeDeny, "Bash", deny("no-rm"))
+	record(now.Add(-time.Hour), OutcomeAllow, "Write", pass("style"), pass(""))
+	// Outside the window: must not count.
+	record(now.Add(-48*time.Hour), OutcomeDeny, "Bash", deny("no-curl"))
+
+	since := now.Add(-24 * time.Hour)
+	tests := []struct {
+		by   string
+		want []TopEntry
+	}{
+		{TopByRule, []TopEntry{{Key: "no-rm", Count: 2, Denies: 2}, {Key: "style", Count: 1}}},
+		{TopByHook, []TopEntry{{Key: "guard", Count: 2, Denies: 2}, {Key: "lint", Count: 2}}},
+		{TopByTool, []TopEntry{{Key: "Bash", Count: 2, Denies: 2}, {Key: "Write", Count: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			got, err := Top(a.DB(), tt.by, since, 10)
+			if err != nil {
+				t.Fatalf("Top: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Top = %+v, want %d entries", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got[i].Key != w.Key || got[i].Count != w.Count || got[i].Denies != w.Denies {
+					t.Errorf("entry %d = %+v, want key=%s count=%d denies=%d", i, got[i], w.Key, w.Count, w.Denies)
+				}
+				if got[i].LastSeen.IsZero() {
+					t.Errorf("entry %d LastSeen is zero", i)
+				}
+			}
+		})
+	}
+
+	if got, err := Top(a.DB(), TopByRule, since, 1); err != nil || len(got) != 1 {
+		t.Errorf("Top(limit=1) = %+v, %v; want 1 entry", got, err)
+	}
+	if _, err := Top(a.DB(), "session", since, 0); err == nil {
+		t.Error("Top(unknown dimension) err = nil, want error")
+	}
+}
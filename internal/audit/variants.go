@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// VariantStats compares the enforced ("a") and shadow ("b") variants of one
+// hook over the chains where both ran.
+type VariantStats struct {
+	Hook          string
+	Runs          int64 // chains where both variants ran
+	PrimaryBlocks int64 // runs where the enforced variant denied, asked, or failed
+	ShadowBlocks  int64 // runs where the shadow variant would have
+	Disagreements int64 // runs where exactly one of the two blocked
+	PrimaryAvgMs  float64
+	ShadowAvgMs   float64
+}
+
+// variantQuery pairs each enforced variant result with the shadow result of
+// the same hook in the same chain. The enforced variant blocked if its
+// outcome was anything that stops or would stop the tool call; the shadow
+// runs report-only, so it blocked if its outcome is report.
+const variantQuery = `
+	SELECT a.hook_name, COUNT(*),
+		SUM(CASE WHEN a.outcome IN ('deny', 'ask', 'error', 'report', 'waived') THEN 1 ELSE 0 END),
+		SUM(CASE WHEN b.outcome = 'report' THEN 1 ELSE 0 END),
+		SUM(CASE WHEN (a.outcome IN ('deny', 'ask', 'error', 'report', 'waived')) != (b.outcome = 'report') THEN 1 ELSE 0 END),
+		AVG(a.duration_ms), AVG(b.duration_ms)
+	FROM hook_results a
+	JOIN hook_results b ON b.chain_id = a.chain_id AND b.hook_index = a.hook_index AND b.variant = 'b'
+	JOIN chain_executions c ON c.id = a.chain_id
+	WHERE a.variant = 'a' AND c.timestamp >= ?
+	GROUP BY a.hook_name ORDER BY a.hook_name`
+
+// CompareVariants returns per-hook agreement between enforced and shadow
+// variants since the given time, ordered by hook name.
+func CompareVariants(db *sql.DB, since time.Time) ([]VariantStats, error) {
+	if db == nil {
+		return nil, fmt.Errorf("audit: CompareVariants called with nil db")
+	}
+
+	rows, err := db.Query(variantQuery, since.UTC().Format("2006-01-02T15:04:05.000"))
+	if err != nil {
+		return nil, fmt.Errorf("audit: query variants: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []VariantStats
+	for rows.Next() {
+		var s VariantStats
+		if err := rows.Scan(&s.Hook, &s.Runs, &s.PrimaryBlocks, &s.ShadowBlocks, &s.Disagreements, &s.PrimaryAvgMs, &s.ShadowAvgMs); err != nil {
+			return nil, fmt.Errorf("audit: scan variant row: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: iterate variant rows: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareVariants(t *testing.T) {
+	a := openTestDB(t)
+	now := time.Now().UTC()
+
+	record := func(ts time.Time, primary, shadow string) {
+		t.Helper()
+		c := sampleChain("PreToolUse", OutcomeAllow, ts, []HookResult{
+			{HookIndex: 0, HookName: "guard", Outcome: shadow, DurationMs: 20, Variant: "b"},
+			{HookIndex: 0, HookName: "guard", Outcome: primary, DurationMs: 10, Variant: "a"},
+			{HookIndex: 1, HookName: "lint", Outcome: HookOutcomePass},
+		})
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	record(now.Add(-3*time.Hour), HookOutcomeDeny, HookOutcomeReport) // agree: both block
+	record(now.Add(-2*time.Hour), HookOutcomePass, HookOutcomeReport) // shadow stricter
+	record(now.Add(-time.Hour), HookOutcomePass, HookOutcomePass)     // agree: both pass
+	// Outside the window: must not count.
+	record(now.Add(-48*time.Hour), HookOutcomeDeny, HookOutcomePass)
+
+	got, err := CompareVariants(a.DB(), now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("CompareVariants: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("CompareVariants = %+v, want 1 hook", got)
+	}
+	want := VariantStats{Hook: "guard", Runs: 3, PrimaryBlocks: 1, ShadowBlocks: 2, Disagreements: 1, PrimaryAvgMs: 10, ShadowAvgMs: 20}
+	if got[0] != want {
+		t.Errorf("CompareVariants = %+v, want %+v", got[0], want)
+	}
+}
@@ -0,0 +1,18 @@
+// Package auditpb encodes audit records using the hookchain.audit.v1
+// protobuf schema (audit.proto). The codecs are hand-written against the
+// protobuf wire format and the proto3 JSON mapping, so consumers can use
+// generated code from audit.proto while hook-chain stays free of a protobuf
+// runtime dependency.
+package auditpb
+
+import (
+	_ "embed"
+)
+
+// SchemaName is the fully qualified protobuf package of the audit schema.
+const SchemaName = "hookchain.audit.v1"
+
+// Schema is the audit.proto source, published with `hook-chain audit schema`.
+//
+//go:embed audit.proto
+var Schema string
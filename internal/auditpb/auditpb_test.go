@@ -0,0 +1,145 @@
+package auditpb
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+func sampleChain() audit.ChainExecution {
+	high, zero := 0.9, 0.0
+	return audit.ChainExecution{
+		ID:              42,
+		Timestamp:       time.Date(2025, 6, 1, 12, 0, 0, 123000000, time.UTC),
+		EventName:       "PreToolUse",
+		ToolName:        "Bash",
+		ToolDetail:      "rm -rf /tmp/x",
+		ChainLen:        2,
+		Outcome:         audit.OutcomeDeny,
+		Reason:          "blocked",
+		DurationMs:      15,
+		SessionID:       "sess-1",
+		ProtocolVersion: "claude-1",
+		OverheadMs:      3,
+		Modified:        true,
+		AddedContext:    true,
+		DeliveryFailed:  true,
+		RiskScore:       &high,
+		Hooks: []audit.HookResult{
+			{ID: 1, ChainID: 42, HookIndex: 0, HookName: "guard", ExitCode: 2, Outcome: "deny", DurationMs: 10, Stderr: "nope", Metadata: json.RawMessage(`{"score":0.9}`), RuleID: "R1", Variant: "a", Severity: "high", Signal: "SIGKILL", RiskScore: &high},
+			{ID: 3, ChainID: 42, HookIndex: 2, HookName: "rewrite", Outcome: "merge", DurationMs: 1, Patch: `{"command":"ls -la"}`, RiskScore: &zero},
+			{ID: 2, ChainID: 42, HookIndex: 1, HookName: "log", ExitCode: -1, Outcome: "error", DurationMs: 5, ErrorKind: "timeout"},
+		},
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	want := sampleChain()
+	got, err := Unmarshal(Marshal(want))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+func TestMarshalWireFormat(t *testing.T) {
+	// id=150 (field 1 varint), event_name="a" (field 3 bytes).
+	got := Marshal(audit.ChainExecution{ID: 150, EventName: "a"})
+	want := []byte{0x08, 0x96, 0x01, 0x1a, 0x01, 'a'}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal = % x, want % x", got, want)
+	}
+
+	if got := Marshal(audit.ChainExecution{}); len(got) != 0 {
+		t.Errorf("zero value encodes to % x, want empty", got)
+	}
+
+	// A set risk_score (field 17, fixed64) is written even when zero.
+	zero := 0.0
+	got = Marshal(audit.ChainExecution{RiskScore: &zero})
+	want = []byte{0x89, 0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal(risk_score 0) = % x, want % x", got, want)
+	}
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	data := Marshal(audit.ChainExecution{ID: 7})
+	// field 99, varint 1; field 100, bytes "zz"; field 101, fixed64.
+	data = append(data, 0x98, 0x06, 0x01)
+	data = append(data, 0xa2, 0x06, 0x02, 'z', 'z')
+	data = append(data, 0xa9, 0x06, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.ID != 7 {
+		t.Errorf("ID = %d, want 7", got.ID)
+	}
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	data := Marshal(sampleChain())
+	// Dropping the last byte always cuts the final field short.
+	if _, err := Unmarshal(data[:len(data)-1]); err == nil {
+		t.Fatal("expected error for truncated message, got nil")
+	}
+}
+
+func TestJSONMapping(t *testing.T) {
+	data, err := MarshalJSON(sampleChain())
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	s := string(data)
+	for _, want := range []string{
+		`"id":"42"`,
+		`"timestamp":"2025-06-01T12:00:00.123Z"`,
+		`"eventName":"PreToolUse"`,
+		`"chainLen":2`,
+		`"durationMs":"15"`,
+		`"sessionId":"sess-1"`,
+		`"chainId":"42"`,
+		`"exitCode":-1`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("JSON missing %s: %s", want, s)
+		}
+	}
+	// Zero values are omitted (first hook has hookIndex 0).
+	if strings.Contains(s, `"hookIndex":0`) {
+		t.Errorf("JSON includes default hookIndex: %s", s)
+	}
+
+	got, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, sampleChain()) {
+		t.Errorf("JSON round trip mismatch:\n got  %+v\n want %+v", got, sampleChain())
+	}
+}
+
+func TestUnmarshalJSONAcceptsNumericInt64(t *testing.T) {
+	got, err := UnmarshalJSON([]byte(`{"id":9,"durationMs":"3"}`))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if got.ID != 9 || got.DurationMs != 3 {
+		t.Errorf("got %+v, want id=9 durationMs=3", got)
+	}
+}
+
+func TestSchemaEmbedded(t *testing.T) {
+	if !strings.Contains(Schema, "package "+SchemaName+";") {
+		t.Errorf("Schema does not declare package %s", SchemaName)
+	}
+}
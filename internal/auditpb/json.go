@@ -0,0 +1,179 @@
+package auditpb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// chainJSON is the proto3 JSON mapping of ChainExecution: lowerCamelCase
+// names, int64 as decimal strings, Timestamp as RFC 3339, defaults omitted.
+type chainJSON struct {
+	ID              int64Str   `json:"id,omitzero"`
+	Timestamp       string     `json:"timestamp,omitempty"`
+	EventName       string     `json:"eventName,omitempty"`
+	ToolName        string     `json:"toolName,omitempty"`
+	ToolDetail      string     `json:"toolDetail,omitempty"`
+	ChainLen        int32      `json:"chainLen,omitempty"`
+	Outcome         string     `json:"outcome,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	DurationMs      int64Str   `json:"durationMs,omitzero"`
+	SessionID       string     `json:"sessionId,omitempty"`
+	Hooks           []hookJSON `json:"hooks,omitempty"`
+	ProtocolVersion string     `json:"protocolVersion,omitempty"`
+	OverheadMs      int64Str   `json:"overheadMs,omitzero"`
+	Modified        bool       `json:"modified,omitempty"`
+	AddedContext    bool       `json:"addedContext,omitempty"`
+	DeliveryFailed  bool       `json:"deliveryFailed,omitempty"`
+	RiskScore       *float64   `json:"riskScore,omitempty"`
+}
+
+type hookJSON struct {
+	ID         int64Str `json:"id,omitzero"`
+	ChainID    int64Str `json:"chainId,omitzero"`
+	HookIndex  int32    `json:"hookIndex,omitempty"`
+	HookName   string   `json:"hookName,omitempty"`
+	ExitCode   int32    `json:"exitCode,omitempty"`
+	Outcome    string   `json:"outcome,omitempty"`
+	DurationMs int64Str `json:"durationMs,omitzero"`
+	Stderr     string   `json:"stderr,omitempty"`
+	ErrorKind  string   `json:"errorKind,omitempty"`
+	Metadata   string   `json:"metadata,omitempty"`
+	RuleID     string   `json:"ruleId,omitempty"`
+	Variant    string   `json:"variant,omitempty"`
+	Severity   string   `json:"severity,omitempty"`
+	Signal     string   `json:"signal,omitempty"`
+	Patch      string   `json:"patch,omitempty"`
+	RiskScore  *float64 `json:"riskScore,omitempty"`
+}
+
+// int64Str is an int64 encoded as a JSON string (proto3 JSON mapping);
+// decoding also accepts a bare number, as protobuf parsers do.
+type int64Str int64
+
+func (v int64Str) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatInt(int64(v), 10)), nil
+}
+
+func (v *int64Str) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("auditpb: invalid int64 %s: %w", data, err)
+	}
+	*v = int64Str(n)
+	return nil
+}
+
+// MarshalJSON encodes c using the proto3 JSON mapping of ChainExecution.
+func MarshalJSON(c audit.ChainExecution) ([]byte, error) {
+	out := chainJSON{
+		ID:              int64Str(c.ID),
+		EventName:       c.EventName,
+		ToolName:        c.ToolName,
+		ToolDetail:      c.ToolDetail,
+		ChainLen:        int32(c.ChainLen),
+		Outcome:         c.Outcome,
+		Reason:          c.Reason,
+		DurationMs:      int64Str(c.DurationMs),
+		SessionID:       c.SessionID,
+		ProtocolVersion: c.ProtocolVersion,
+		OverheadMs:      int64Str(c.OverheadMs),
+		Modified:        c.Modified,
+		AddedContext:    c.AddedContext,
+		DeliveryFailed:  c.DeliveryFailed,
+		RiskScore:       c.RiskScore,
+	}
+	if !c.Timestamp.IsZero() {
+		out.Timestamp = c.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	for _, h := range c.Hooks {
+		out.Hooks = append(out.Hooks, hookJSON{
+			ID:         int64Str(h.ID),
+			ChainID:    int64Str(h.ChainID),
+			HookIndex:  int32(h.HookIndex),
+			HookName:   h.HookName,
+			ExitCode:   int32(h.ExitCode),
+			Outcome:    h.Outcome,
+			DurationMs: int64Str(h.DurationMs),
+			Stderr:     h.Stderr,
+			ErrorKind:  h.ErrorKind,
+			Metadata:   string(h.Metadata),
+			RuleID:     h.RuleID,
+			Variant:    h.Variant,
+			Severity:   h.Severity,
+			Signal:     h.Signal,
+			Patch:      h.Patch,
+			RiskScore:  h.RiskScore,
+		})
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("auditpb: marshal chain %d: %w", c.ID, err)
+	}
+	return data, nil
+}
+
+// UnmarshalJSON decodes a proto3 JSON ChainExecution.
+func UnmarshalJSON(data []byte) (audit.ChainExecution, error) {
+	var in chainJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return audit.ChainExecution{}, fmt.Errorf("auditpb: unmarshal chain: %w", err)
+	}
+
+	c := audit.ChainExecution{
+		ID:              int64(in.ID),
+		EventName:       in.EventName,
+		ToolName:        in.ToolName,
+		ToolDetail:      in.ToolDetail,
+		ChainLen:        int(in.ChainLen),
+		Outcome:         in.Outcome,
+		Reason:          in.Reason,
+		DurationMs:      int64(in.DurationMs),
+		SessionID:       in.SessionID,
+		ProtocolVersion: in.ProtocolVersion,
+		OverheadMs:      int64(in.OverheadMs),
+		Modified:        in.Modified,
+		AddedContext:    in.AddedContext,
+		DeliveryFailed:  in.DeliveryFailed,
+		RiskScore:       in.RiskScore,
+	}
+	if in.Timestamp != "" {
+		ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+		if err != nil {
+			return audit.ChainExecution{}, fmt.Errorf("auditpb: parse timestamp %q: %w", in.Timestamp, err)
+		}
+		c.Timestamp = ts.UTC()
+	}
+	for _, h := range in.Hooks {
+		hr := audit.HookResult{
+			ID:         int64(h.ID),
+			ChainID:    int64(h.ChainID),
+			HookIndex:  int(h.HookIndex),
+			HookName:   h.HookName,
+			ExitCode:   int(h.ExitCode),
+			Outcome:    h.Outcome,
+			DurationMs: int64(h.DurationMs),
+			Stderr:     h.Stderr,
+			ErrorKind:  h.ErrorKind,
+			RuleID:     h.RuleID,
+			Variant:    h.Variant,
+			Severity:   h.Severity,
+			Signal:     h.Signal,
+			Patch:      h.Patch,
+			RiskScore:  h.RiskScore,
+		}
+		if h.Metadata != "" {
+			hr.Metadata = json.RawMessage(h.Metadata)
+		}
+		c.Hooks = append(c.Hooks, hr)
+	}
+	return c, nil
+}
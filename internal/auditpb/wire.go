@@ -0,0 +1,304 @@
+package auditpb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// Protobuf wire types used by the schema.
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireBytes  = 2
+	wireI32    = 5
+)
+
+var errTruncated = errors.New("auditpb: truncated message")
+
+// Marshal encodes c as a hookchain.audit.v1.ChainExecution message.
+// Fields holding their zero value are omitted, as proto3 requires.
+func Marshal(c audit.ChainExecution) []byte {
+	var b []byte
+	b = appendInt(b, 1, c.ID)
+	if !c.Timestamp.IsZero() {
+		b = appendMessage(b, 2, appendTimestamp(nil, c.Timestamp))
+	}
+	b = appendString(b, 3, c.EventName)
+	b = appendString(b, 4, c.ToolName)
+	b = appendString(b, 5, c.ToolDetail)
+	b = appendInt(b, 6, int64(int32(c.ChainLen)))
+	b = appendString(b, 7, c.Outcome)
+	b = appendString(b, 8, c.Reason)
+	b = appendInt(b, 9, c.DurationMs)
+	b = appendString(b, 10, c.SessionID)
+	for _, h := range c.Hooks {
+		b = appendMessage(b, 11, marshalHook(h))
+	}
+	b = appendString(b, 12, c.ProtocolVersion)
+	b = appendInt(b, 13, c.OverheadMs)
+	b = appendBool(b, 14, c.Modified)
+	b = appendBool(b, 15, c.AddedContext)
+	b = appendBool(b, 16, c.DeliveryFailed)
+	b = appendDouble(b, 17, c.RiskScore)
+	return b
+}
+
+func marshalHook(h audit.HookResult) []byte {
+	var b []byte
+	b = appendInt(b, 1, h.ID)
+	b = appendInt(b, 2, h.ChainID)
+	b = appendInt(b, 3, int64(int32(h.HookIndex)))
+	b = appendString(b, 4, h.HookName)
+	b = appendInt(b, 5, int64(int32(h.ExitCode)))
+	b = appendString(b, 6, h.Outcome)
+	b = appendInt(b, 7, h.DurationMs)
+	b = appendString(b, 8, h.Stderr)
+	b = appendString(b, 9, h.ErrorKind)
+	b = appendString(b, 10, string(h.Metadata))
+	b = appendString(b, 11, h.RuleID)
+	b = appendString(b, 12, h.Variant)
+	b = appendString(b, 13, h.Severity)
+	b = appendString(b, 14, h.Signal)
+	b = appendString(b, 15, h.Patch)
+	b = appendDouble(b, 16, h.RiskScore)
+	return b
+}
+
+func appendTimestamp(b []byte, t time.Time) []byte {
+	b = appendInt(b, 1, t.Unix())
+	b = appendInt(b, 2, int64(t.Nanosecond()))
+	return b
+}
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// appendInt encodes an int32/int64 field; negative values use the
+// ten-byte two's complement form, matching protobuf's int32/int64 types.
+func appendInt(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, uint64(v))
+}
+
+// appendBool encodes a bool field as the varint 1; false is omitted.
+func appendBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendInt(b, field, 1)
+}
+
+// appendDouble encodes an optional double field; nil is omitted, while a
+// set zero is written so readers can tell it from no value.
+func appendDouble(b []byte, field int, v *float64) []byte {
+	if v == nil {
+		return b
+	}
+	b = appendTag(b, field, wireI64)
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(*v))
+}
+
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendMessage(b []byte, field int, msg []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(msg)))
+	return append(b, msg...)
+}
+
+// Unmarshal decodes a hookchain.audit.v1.ChainExecution message.
+// Unknown fields are skipped so newer producers stay readable.
+func Unmarshal(data []byte) (audit.ChainExecution, error) {
+	var c audit.ChainExecution
+	err := decodeFields(data, func(field, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			c.ID = int64(v)
+		case 2:
+			ts, err := unmarshalTimestamp(raw)
+			if err != nil {
+				return err
+			}
+			c.Timestamp = ts
+		case 3:
+			c.EventName = string(raw)
+		case 4:
+			c.ToolName = string(raw)
+		case 5:
+			c.ToolDetail = string(raw)
+		case 6:
+			c.ChainLen = int(int32(v))
+		case 7:
+			c.Outcome = string(raw)
+		case 8:
+			c.Reason = string(raw)
+		case 9:
+			c.DurationMs = int64(v)
+		case 10:
+			c.SessionID = string(raw)
+		case 11:
+			h, err := unmarshalHook(raw)
+			if err != nil {
+				return err
+			}
+			c.Hooks = append(c.Hooks, h)
+		case 12:
+			c.ProtocolVersion = string(raw)
+		case 13:
+			c.OverheadMs = int64(v)
+		case 14:
+			c.Modified = v != 0
+		case 15:
+			c.AddedContext = v != 0
+		case 16:
+			c.DeliveryFailed = v != 0
+		case 17:
+			c.RiskScore = float64Ptr(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return audit.ChainExecution{}, err
+	}
+	return c, nil
+}
+
+func unmarshalHook(data []byte) (audit.HookResult, error) {
+	var h audit.HookResult
+	err := decodeFields(data, func(field, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			h.ID = int64(v)
+		case 2:
+			h.ChainID = int64(v)
+		case 3:
+			h.HookIndex = int(int32(v))
+		case 4:
+			h.HookName = string(raw)
+		case 5:
+			h.ExitCode = int(int32(v))
+		case 6:
+			h.Outcome = string(raw)
+		case 7:
+			h.DurationMs = int64(v)
+		case 8:
+			h.Stderr = string(raw)
+		case 9:
+			h.ErrorKind = string(raw)
+		case 10:
+			if len(raw) > 0 {
+				h.Metadata = append(json.RawMessage(nil), raw...)
+			}
+		case 11:
+			h.RuleID = string(raw)
+		case 12:
+			h.Variant = string(raw)
+		case 13:
+			h.Severity = string(raw)
+		case 14:
+			h.Signal = string(raw)
+		case 15:
+			h.Patch = string(raw)
+		case 16:
+			h.RiskScore = float64Ptr(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return audit.HookResult{}, fmt.Errorf("auditpb: hook result: %w", err)
+	}
+	return h, nil
+}
+
+// float64Ptr decodes the bits of a double field.
+func float64Ptr(bits uint64) *float64 {
+	f := math.Float64frombits(bits)
+	return &f
+}
+
+func unmarshalTimestamp(data []byte) (time.Time, error) {
+	var secs, nanos int64
+	err := decodeFields(data, func(field, wire int, v uint64, raw []byte) error {
+		switch field {
+		case 1:
+			secs = int64(v)
+		case 2:
+			nanos = int64(int32(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("auditpb: timestamp: %w", err)
+	}
+	return time.Unix(secs, nanos).UTC(), nil
+}
+
+// decodeFields walks the fields of one message, calling fn with the varint
+// value (varint fields), the little-endian value (64-bit fields), or the raw
+// payload (length-delimited fields).
+func decodeFields(data []byte, fn func(field, wire int, v uint64, raw []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		field, wire := int(tag>>3), int(tag&7)
+		if field == 0 || field > math.MaxInt32 {
+			return fmt.Errorf("auditpb: invalid field number %d", field)
+		}
+
+		var v uint64
+		var raw []byte
+		switch wire {
+		case wireVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireI64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			v = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return errTruncated
+			}
+			raw = data[n : n+int(l)]
+			data = data[n+int(l):]
+		case wireI32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("auditpb: unsupported wire type %d for field %d", wire, field)
+		}
+
+		if err := fn(field, wire, v, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
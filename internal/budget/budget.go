@@ -0,0 +1,107 @@
+// Package budget enforces per-hook latency budgets. A hook is over budget
+// when the median of its recent runs (from the audit log) exceeds its
+// latency_budget; its over_budget action then decides whether hook-chain
+// warns, demotes it to report-only, or fails `validate`.
+package budget
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+const (
+	// Window is how many recent runs of a hook are considered.
+	Window = 20
+	// MinSamples is the fewest runs needed before a hook can be judged over budget.
+	MinSamples = 5
+)
+
+// Status is the budget evaluation of one hook.
+type Status struct {
+	Hook    string
+	Budget  time.Duration
+	Latency audit.HookLatency
+	Over    bool
+	Action  string // the hook's effective over_budget action
+}
+
+// Median returns the observed median run time.
+func (s Status) Median() time.Duration {
+	return time.Duration(s.Latency.MedianMs) * time.Millisecond
+}
+
+// Check evaluates every hook that declares a latency_budget.
+func Check(db *sql.DB, hooks []config.HookEntry) ([]Status, error) {
+	var statuses []Status
+	for _, h := range hooks {
+		if h.LatencyBudget <= 0 {
+			continue
+		}
+		lat, err := audit.RecentHookLatency(db, h.Name, Window)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{
+			Hook:    h.Name,
+			Budget:  h.LatencyBudget,
+			Latency: lat,
+			Over:    lat.Samples >= MinSamples && time.Duration(lat.MedianMs)*time.Millisecond > h.LatencyBudget,
+			Action:  h.EffectiveOverBudget(),
+		})
+	}
+	return statuses, nil
+}
+
+// Apply acts on over-budget hooks at run time: it logs a warning for each and
+// returns a copy of hooks where report_only hooks are demoted. fail_validate
+// hooks keep running; they are rejected by `hook-chain validate` instead.
+func Apply(hooks []config.HookEntry, statuses []Status, logger *slog.Logger) []config.HookEntry {
+	over := make(map[string]Status, len(statuses))
+	for _, s := range statuses {
+		if s.Over {
+			over[s.Hook] = s
+		}
+	}
+	if len(over) == 0 {
+		return hooks
+	}
+
+	out := make([]config.HookEntry, len(hooks))
+	for i, h := range hooks {
+		out[i] = h
+		s, ok := over[h.Name]
+		if !ok {
+			continue
+		}
+		logger.Warn("hook over latency budget",
+			"hook", h.Name, "budget", s.Budget, "median", s.Median(), "samples", s.Latency.Samples, "action", s.Action)
+		if s.Action == config.OverBudgetReportOnly {
+			out[i].ReportOnly = true
+		}
+	}
+	return out
+}
+
+// ValidateChain reports configuration errors in a chain's budgets: unknown
+// over_budget actions and hook budgets that cannot fit the chain budget.
+func ValidateChain(chain config.ChainEntry) []error {
+	var errs []error
+	var total time.Duration
+	for _, h := range chain.Hooks {
+		switch h.EffectiveOverBudget() {
+		case config.OverBudgetWarn, config.OverBudgetReportOnly, config.OverBudgetFailValidate:
+		default:
+			errs = append(errs, fmt.Errorf("hook %q: unknown over_budget %q", h.Name, h.OverBudget))
+		}
+		total += h.LatencyBudget
+	}
+	if chain.LatencyBudget > 0 && total > chain.LatencyBudget {
+		errs = append(errs, fmt.Errorf("hook latency budgets total %s, over the chain budget of %s", total, chain.LatencyBudget))
+	}
+	return errs
+}
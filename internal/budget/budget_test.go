@@ -0,0 +1,111 @@
+package budget
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func recordRuns(t *testing.T, a *audit.SQLiteAuditor, hook string, n int, durationMs int64) {
+	t.Helper()
+	for range n {
+		err := a.RecordChain(audit.ChainExecution{
+			Timestamp: time.Now().UTC(),
+			EventName: "PreToolUse",
+			ToolName:  "Bash",
+			Outcome:   audit.OutcomeAllow,
+			Hooks:     []audit.HookResult{{HookName: hook, Outcome: audit.HookOutcomePass, DurationMs: durationMs}},
+		})
+		if err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+
+	recordRuns(t, a, "slow", MinSamples, 250)
+	recordRuns(t, a, "fast", MinSamples, 20)
+	recordRuns(t, a, "fresh", MinSamples-1, 900)
+
+	hooks := []config.HookEntry{
+		{Name: "slow", LatencyBudget: 100 * time.Millisecond, OverBudget: config.OverBudgetReportOnly},
+		{Name: "fast", LatencyBudget: 100 * time.Millisecond},
+		{Name: "fresh", LatencyBudget: 100 * time.Millisecond},
+		{Name: "unbudgeted"},
+	}
+	statuses, err := Check(a.DB(), hooks)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	want := map[string]bool{"slow": true, "fast": false, "fresh": false}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %+v, want %d entries (budgeted hooks only)", statuses, len(want))
+	}
+	for _, s := range statuses {
+		if s.Over != want[s.Hook] {
+			t.Errorf("%s: Over = %v, want %v (median %s)", s.Hook, s.Over, want[s.Hook], s.Median())
+		}
+	}
+	if statuses[0].Action != config.OverBudgetReportOnly || statuses[1].Action != config.OverBudgetWarn {
+		t.Errorf("actions = %s, %s, want report_only, warn", statuses[0].Action, statuses[1].Action)
+	}
+}
+
+func TestApply(t *testing.T) {
+	hooks := []config.HookEntry{
+		{Name: "demote", OverBudget: config.OverBudgetReportOnly},
+		{Name: "warn"},
+		{Name: "ok", OverBudget: config.OverBudgetReportOnly},
+	}
+	statuses := []Status{
+		{Hook: "demote", Over: true, Action: config.OverBudgetReportOnly},
+		{Hook: "warn", Over: true, Action: config.OverBudgetWarn},
+		{Hook: "ok", Over: false, Action: config.OverBudgetReportOnly},
+	}
+
+	got := Apply(hooks, statuses, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !got[0].ReportOnly || got[1].ReportOnly || got[2].ReportOnly {
+		t.Errorf("ReportOnly = %v %v %v, want true false false", got[0].ReportOnly, got[1].ReportOnly, got[2].ReportOnly)
+	}
+	if hooks[0].ReportOnly {
+		t.Error("Apply modified the input slice")
+	}
+}
+
+func TestValidateChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   config.ChainEntry
+		wantErr int
+	}{
+		{"no budgets", config.ChainEntry{Hooks: []config.HookEntry{{Name: "a"}}}, 0},
+		{"fits", config.ChainEntry{LatencyBudget: 300 * time.Millisecond, Hooks: []config.HookEntry{
+			{Name: "a", LatencyBudget: 100 * time.Millisecond},
+			{Name: "b", LatencyBudget: 200 * time.Millisecond},
+		}}, 0},
+		{"exceeds chain", config.ChainEntry{LatencyBudget: 300 * time.Millisecond, Hooks: []config.HookEntry{
+			{Name: "a", LatencyBudget: 200 * time.Millisecond},
+			{Name: "b", LatencyBudget: 200 * time.Millisecond},
+		}}, 1},
+		{"unknown action", config.ChainEntry{Hooks: []config.HookEntry{{Name: "a", OverBudget: "explode"}}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := ValidateChain(tt.chain); len(errs) != tt.wantErr {
+				t.Errorf("ValidateChain() = %v, want %d error(s)", errs, tt.wantErr)
+			}
+		})
+	}
+}
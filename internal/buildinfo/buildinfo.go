@@ -0,0 +1,98 @@
+// Package buildinfo describes the running binary. Values injected with
+// -ldflags take precedence; anything left at its default is filled in from
+// runtime/debug.ReadBuildInfo, so `go install` builds report real metadata.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Defaults of the ldflags-injected variables in package cli.
+const (
+	DefaultVersion = "dev"
+	DefaultCommit  = "unknown"
+)
+
+// Manifest is the release manifest emitted by `hook-chain release-manifest`.
+type Manifest struct {
+	Name         string            `json:"name"`
+	Module       string            `json:"module"`
+	Version      string            `json:"version"`
+	Commit       string            `json:"commit"`
+	CommitTime   string            `json:"commit_time,omitempty"`
+	Dirty        bool              `json:"dirty"`
+	GoVersion    string            `json:"go_version"`
+	OS           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	BuildFlags   map[string]string `json:"build_flags"`
+	Dependencies []Dependency      `json:"dependencies"`
+}
+
+// Dependency is one module compiled into the binary.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+}
+
+// Read returns the manifest for the running binary, given the
+// ldflags-injected version and commit.
+func Read(version, commit string) Manifest {
+	bi, _ := debug.ReadBuildInfo() // nil when not built with module support
+	return fromBuildInfo(bi, version, commit)
+}
+
+// fromBuildInfo builds the manifest from bi (nil when unavailable).
+func fromBuildInfo(bi *debug.BuildInfo, version, commit string) Manifest {
+	m := Manifest{
+		Name:         "hook-chain",
+		Version:      version,
+		Commit:       commit,
+		GoVersion:    runtime.Version(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		BuildFlags:   map[string]string{},
+		Dependencies: []Dependency{},
+	}
+	if bi == nil {
+		return m
+	}
+
+	m.Module = bi.Main.Path
+	if bi.GoVersion != "" {
+		m.GoVersion = bi.GoVersion
+	}
+	if m.Version == DefaultVersion && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		m.Version = strings.TrimPrefix(bi.Main.Version, "v")
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if m.Commit == DefaultCommit {
+				m.Commit = s.Value
+			}
+		case "vcs.time":
+			m.CommitTime = s.Value
+		case "vcs.modified":
+			m.Dirty = s.Value == "true"
+		case "vcs":
+			// The VCS name adds nothing to the manifest.
+		default:
+			// -ldflags, -tags, -trimpath, CGO_ENABLED, GOOS, GOARCH, GOAMD64, ...
+			m.BuildFlags[s.Key] = s.Value
+		}
+	}
+
+	for _, d := range bi.Deps {
+		if d.Replace != nil {
+			d = d.Replace
+		}
+		m.Dependencies = append(m.Dependencies, Dependency{Path: d.Path, Version: d.Version, Sum: d.Sum})
+	}
+	sort.Slice(m.Dependencies, func(i, j int) bool { return m.Dependencies[i].Path < m.Dependencies[j].Path })
+	return m
+}
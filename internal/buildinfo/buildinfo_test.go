@@ -0,0 +1,86 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func sampleBuildInfo() *debug.BuildInfo {
+	return &debug.BuildInfo{
+		GoVersion: "go1.26.0",
+		Path:      "github.com/Fuabioo/hook-chain",
+		Main:      debug.Module{Path: "github.com/Fuabioo/hook-chain", Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "modernc.org/sqlite", Version: "v1.45.0", Sum: "h1:abc"},
+			{Path: "github.com/spf13/cobra", Version: "v1.10.2"},
+			{Path: "example.com/old", Version: "v0.1.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v0.1.1"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-ldflags", Value: "-s -w"},
+			{Key: "CGO_ENABLED", Value: "0"},
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2025-06-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+}
+
+func TestFromBuildInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		commit      string
+		wantVersion string
+		wantCommit  string
+	}{
+		{"defaults filled from build info", DefaultVersion, DefaultCommit, "1.4.0", "0123456789abcdef"},
+		{"ldflags take precedence", "2.0.0", "abc1234", "2.0.0", "abc1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := fromBuildInfo(sampleBuildInfo(), tt.version, tt.commit)
+			if m.Version != tt.wantVersion || m.Commit != tt.wantCommit {
+				t.Errorf("version/commit = %s/%s, want %s/%s", m.Version, m.Commit, tt.wantVersion, tt.wantCommit)
+			}
+			if !m.Dirty || m.CommitTime != "2025-06-01T12:00:00Z" || m.GoVersion != "go1.26.0" {
+				t.Errorf("manifest = %+v, want dirty build with commit time and go version", m)
+			}
+			if m.BuildFlags["-ldflags"] != "-s -w" || m.BuildFlags["CGO_ENABLED"] != "0" {
+				t.Errorf("BuildFlags = %v, want -ldflags and CGO_ENABLED", m.BuildFlags)
+			}
+			if _, ok := m.BuildFlags["vcs"]; ok {
+				t.Errorf("BuildFlags includes vcs settings: %v", m.BuildFlags)
+			}
+		})
+	}
+}
+
+func TestFromBuildInfoDependencies(t *testing.T) {
+	m := fromBuildInfo(sampleBuildInfo(), DefaultVersion, DefaultCommit)
+	want := []string{"example.com/fork", "github.com/spf13/cobra", "modernc.org/sqlite"}
+	if len(m.Dependencies) != len(want) {
+		t.Fatalf("Dependencies = %+v, want %v", m.Dependencies, want)
+	}
+	for i, p := range want {
+		if m.Dependencies[i].Path != p {
+			t.Errorf("Dependencies[%d] = %s, want %s (sorted, replacements applied)", i, m.Dependencies[i].Path, p)
+		}
+	}
+}
+
+func TestFromBuildInfoDevel(t *testing.T) {
+	bi := sampleBuildInfo()
+	bi.Main.Version = "(devel)"
+	m := fromBuildInfo(bi, DefaultVersion, DefaultCommit)
+	if m.Version != DefaultVersion {
+		t.Errorf("Version = %q, want %q for (devel) builds", m.Version, DefaultVersion)
+	}
+}
+
+func TestFromBuildInfoUnavailable(t *testing.T) {
+	m := fromBuildInfo(nil, DefaultVersion, DefaultCommit)
+	if m.Version != DefaultVersion || m.Commit != DefaultCommit || m.BuildFlags == nil || m.Dependencies == nil {
+		t.Errorf("manifest = %+v, want defaults with non-nil collections", m)
+	}
+}
@@ -0,0 +1,158 @@
+// Package builtin implements hooks that run inside hook-chain instead of as
+// separate processes. A hook selects one with `builtin: <name>` in place of
+// `command` and configures it with `options`. Builtins answer in the hook
+// protocol, so the pipeline treats them like any other hook.
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+// ErrUnknown is wrapped by errors for builtin names that do not exist.
+var ErrUnknown = errors.New("unknown builtin")
+
+// Builtin checks one tool call. A nil Output lets the call through.
+type Builtin interface {
+	Check(input hook.Input) (*hook.Output, error)
+}
+
+// contextBuiltin is a Builtin that waits on the network, so it takes the
+// hook's context and stops when the chain is cancelled.
+type contextBuiltin interface {
+	CheckContext(ctx context.Context, input hook.Input) (*hook.Output, error)
+}
+
+// factory builds a builtin from its hook's options.
+type factory func(options map[string]any) (Builtin, error)
+
+var registry = map[string]factory{
+	"command-guard": newCommandGuard,
+	"egress-guard":  newEgressGuard,
+	"install-guard": newInstallGuard,
+	"license-guard": newLicenseGuard,
+	"llm-review":    newLLMReview,
+	"write-guard":   newWriteGuard,
+}
+
+// Names returns the available builtins, sorted.
+func Names() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// New returns the named builtin configured with options.
+func New(name string, options map[string]any) (Builtin, error) {
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("builtin: %w %q (available: %v)", ErrUnknown, name, Names())
+	}
+	b, err := f(options)
+	if err != nil {
+		return nil, fmt.Errorf("builtin %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// Validate checks a hook that uses a builtin: the name exists, the options
+// parse, and no field that only applies to commands is set.
+func Validate(h config.HookEntry) error {
+	switch {
+	case h.Command != "":
+		return fmt.Errorf("hook %q: builtin and command are mutually exclusive", h.Name)
+	case len(h.Variants) > 0:
+		return fmt.Errorf("hook %q: builtin and variants are mutually exclusive", h.Name)
+	case h.Async:
+		return fmt.Errorf("hook %q: builtins cannot be async", h.Name)
+	}
+	_, err := New(h.Builtin, h.Options)
+	return err
+}
+
+// Runner runs builtin hooks in-process and hands every other hook to Next.
+type Runner struct {
+	Next runner.Runner
+}
+
+// Register adds the builtin hook type to reg.
+func Register(reg *runner.Registry) {
+	reg.Register(config.HookTypeBuiltin, Runner{})
+}
+
+// Run implements runner.Runner. A builtin that lets the call through
+// produces empty stdout, like a hook that exits 0 silently.
+func (r Runner) Run(ctx context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
+	if h.Builtin == "" {
+		if r.Next == nil {
+			return runner.Result{}, fmt.Errorf("builtin: hook %q names no builtin", h.Name)
+		}
+		return r.Next.Run(ctx, h, input)
+	}
+	b, err := New(h.Builtin, h.Options)
+	if err != nil {
+		return runner.Result{}, err
+	}
+	var in hook.Input
+	if err := json.Unmarshal(input, &in); err != nil {
+		return runner.Result{}, fmt.Errorf("builtin %s: parse input: %w", h.Builtin, err)
+	}
+	var out *hook.Output
+	if cb, ok := b.(contextBuiltin); ok {
+		out, err = cb.CheckContext(ctx, in)
+	} else {
+		out, err = b.Check(in)
+	}
+	if err != nil {
+		return runner.Result{}, fmt.Errorf("builtin %s: %w", h.Builtin, err)
+	}
+	if out == nil {
+		return runner.Result{}, nil
+	}
+	stdout, err := json.Marshal(out)
+	if err != nil {
+		return runner.Result{}, fmt.Errorf("builtin %s: marshal output: %w", h.Builtin, err)
+	}
+	return runner.Result{Stdout: stdout}, nil
+}
+
+// decodeOptions decodes a hook's options into v (a pointer to a struct with
+// json tags), rejecting unknown keys so typos surface in validate.
+func decodeOptions(options map[string]any, v any) error {
+	if len(options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("encode options: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("options: %w", err)
+	}
+	return nil
+}
+
+// decision builds an Output that denies or asks with reason.
+func decision(action, ruleID, reason string, metadata any) *hook.Output {
+	out := &hook.Output{HookSpecificOutput: hook.HookSpecificOutput{
+		HookEventName:            "PreToolUse",
+		PermissionDecision:       action,
+		PermissionDecisionReason: reason,
+		RuleID:                   ruleID,
+	}}
+	if metadata != nil {
+		if raw, err := json.Marshal(metadata); err == nil {
+			out.HookSpecificOutput.Metadata = raw
+		}
+	}
+	return out
+}
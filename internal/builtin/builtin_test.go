@@ -0,0 +1,94 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+type stubRunner struct{ called bool }
+
+func (s *stubRunner) Run(context.Context, config.HookEntry, []byte) (runner.Result, error) {
+	s.called = true
+	return runner.Result{Stdout: []byte("external")}, nil
+}
+
+func writeInputJSON(t *testing.T, path, content string) []byte {
+	t.Helper()
+	ti, err := json.Marshal(map[string]string{"file_path": path, "content": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]any{"hook_event_name": "PreToolUse", "tool_name": "Write", "tool_input": json.RawMessage(ti)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRunnerDispatch(t *testing.T) {
+	next := &stubRunner{}
+	r := Runner{Next: next}
+
+	res, err := r.Run(context.Background(), config.HookEntry{Name: "ext", Command: "true"}, nil)
+	if err != nil || !next.called || string(res.Stdout) != "external" {
+		t.Fatalf("non-builtin hook not delegated: res=%q err=%v called=%v", res.Stdout, err, next.called)
+	}
+
+	next.called = false
+	h := config.HookEntry{Name: "size", Builtin: "write-guard", Options: map[string]any{"max_size": "10", "action": "deny"}}
+	res, err = r.Run(context.Background(), h, writeInputJSON(t, "/repo/big.txt", "more than ten bytes"))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if next.called {
+		t.Error("builtin hook was delegated to the process runner")
+	}
+	var out hook.Output
+	if err := json.Unmarshal(res.Stdout, &out); err != nil {
+		t.Fatalf("parse output %q: %v", res.Stdout, err)
+	}
+	if out.HookSpecificOutput.PermissionDecision != "deny" || out.HookSpecificOutput.RuleID != RuleWriteSize {
+		t.Errorf("output = %+v, want deny with %s", out.HookSpecificOutput, RuleWriteSize)
+	}
+
+	res, err = r.Run(context.Background(), h, writeInputJSON(t, "/repo/ok.txt", "short"))
+	if err != nil || len(res.Stdout) != 0 {
+		t.Errorf("passing write: stdout=%q err=%v, want empty", res.Stdout, err)
+	}
+}
+
+func TestRunnerUnknownBuiltin(t *testing.T) {
+	r := Runner{Next: &stubRunner{}}
+	_, err := r.Run(context.Background(), config.HookEntry{Name: "x", Builtin: "nope"}, []byte(`{}`))
+	if !errors.Is(err, ErrUnknown) {
+		t.Errorf("err = %v, want ErrUnknown", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    config.HookEntry
+		wantErr bool
+	}{
+		{"valid", config.HookEntry{Name: "g", Builtin: "write-guard"}, false},
+		{"unknown", config.HookEntry{Name: "g", Builtin: "nope"}, true},
+		{"with command", config.HookEntry{Name: "g", Builtin: "write-guard", Command: "x"}, true},
+		{"with variants", config.HookEntry{Name: "g", Builtin: "write-guard", Variants: []string{"a", "b"}}, true},
+		{"async", config.HookEntry{Name: "g", Builtin: "write-guard", Async: true}, true},
+		{"unknown option", config.HookEntry{Name: "g", Builtin: "write-guard", Options: map[string]any{"max_sise": "1MB"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hook); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
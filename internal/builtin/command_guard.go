@@ -0,0 +1,90 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+	"github.com/Fuabioo/hook-chain/internal/rules"
+)
+
+// ruleCommandGuardPrefix prefixes the ruleset rule ID in command-guard
+// decisions, e.g. "command-guard/rm-root".
+const ruleCommandGuardPrefix = "command-guard/"
+
+type commandGuardOptions struct {
+	Rules   string   `json:"rules"`   // extra ruleset file merged over the active one
+	Disable []string `json:"disable"` // rule IDs to skip
+	Action  string   `json:"action"`  // override every rule's action: "deny" | "ask"
+}
+
+// commandGuard checks each command in a Bash call against the dangerous
+// command ruleset (see internal/rules).
+type commandGuard struct {
+	set    rules.Set
+	action string
+}
+
+func newCommandGuard(options map[string]any) (Builtin, error) {
+	var opts commandGuardOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	switch opts.Action {
+	case "", rules.ActionDeny, rules.ActionAsk:
+	default:
+		return nil, fmt.Errorf("action %q is not \"deny\" or \"ask\"", opts.Action)
+	}
+
+	// An installed ruleset that fails to load falls back to the embedded
+	// one; a broken update must not disable the guard.
+	set, err := rules.Load(rules.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: command-guard: %v (using embedded ruleset)\n", err)
+	}
+	if opts.Rules != "" {
+		data, err := os.ReadFile(pathutil.Expand(opts.Rules))
+		if err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+		extra, err := rules.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("rules %s: %w", opts.Rules, err)
+		}
+		set = set.Merge(extra)
+	}
+	return &commandGuard{set: set.Without(opts.Disable), action: opts.Action}, nil
+}
+
+// Check implements Builtin. Only Bash calls are inspected.
+func (g *commandGuard) Check(input hook.Input) (*hook.Output, error) {
+	if input.ToolName != "Bash" {
+		return nil, nil
+	}
+	var ti struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+		return nil, fmt.Errorf("parse Bash input: %w", err)
+	}
+	for _, c := range parseShell(ti.Command) {
+		r, ok := g.set.Match(c.Name, c.Args)
+		if !ok {
+			continue
+		}
+		action := r.Action
+		if g.action != "" {
+			action = g.action
+		}
+		command := strings.Join(append([]string{c.Name}, c.Args...), " ")
+		out := decision(action, ruleCommandGuardPrefix+r.ID,
+			fmt.Sprintf("hook-chain: %q matches dangerous-command rule %s: %s", command, r.ID, r.Reason),
+			map[string]any{"rule": r.ID, "ruleset_version": g.set.Version, "command": command})
+		out.HookSpecificOutput.Severity = r.Severity
+		return out, nil
+	}
+	return nil, nil
+}
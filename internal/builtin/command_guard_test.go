@@ -0,0 +1,87 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandGuard(t *testing.T) {
+	// Keep an installed ruleset on this machine out of the test.
+	t.Setenv("HOOK_CHAIN_RULES", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	extra := filepath.Join(t.TempDir(), "extra.yaml")
+	if err := os.WriteFile(extra, []byte(`version: 1
+rules:
+  - id: npm-publish
+    command: npm
+    subcommand: publish
+    action: deny
+    reason: publishing packages
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		options    map[string]any
+		tool       string
+		command    string
+		wantAction string // "" = pass
+		wantRule   string
+	}{
+		{name: "rm -rf root", command: "rm -rf /", wantAction: "deny", wantRule: "command-guard/rm-root"},
+		{name: "behind sudo and a pipe", command: "echo y | sudo rm -fr ~/", wantAction: "deny", wantRule: "command-guard/rm-root"},
+		{name: "build dir", command: "rm -rf ./build"},
+		{name: "force push", command: "git fetch && git push -f origin main", wantAction: "ask", wantRule: "command-guard/git-force-push"},
+		{name: "force with lease", command: "git push --force-with-lease origin main"},
+		{name: "dd to device", command: "dd if=img.iso of=/dev/sda bs=4M", wantAction: "deny", wantRule: "command-guard/dd-device"},
+		{name: "non-Bash tool", tool: "Read", command: "rm -rf /"},
+		{name: "disabled rule", options: map[string]any{"disable": []any{"git-force-push"}}, command: "git push -f"},
+		{name: "action override", options: map[string]any{"action": "ask"}, command: "rm -rf /", wantAction: "ask", wantRule: "command-guard/rm-root"},
+		{name: "extra rules", options: map[string]any{"rules": extra}, command: "npm publish --access public", wantAction: "deny", wantRule: "command-guard/npm-publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newCommandGuard(tt.options)
+			if err != nil {
+				t.Fatalf("newCommandGuard: %v", err)
+			}
+			tool := tt.tool
+			if tool == "" {
+				tool = "Bash"
+			}
+			out, err := g.Check(toolCall(t, tool, "/repo", map[string]any{"command": tt.command}))
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if tt.wantAction == "" {
+				if out != nil {
+					t.Errorf("want pass, got %s", out.HookSpecificOutput.PermissionDecisionReason)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("want %s, got pass", tt.wantAction)
+			}
+			hso := out.HookSpecificOutput
+			if hso.PermissionDecision != tt.wantAction || hso.RuleID != tt.wantRule {
+				t.Errorf("got %s/%s, want %s/%s", hso.PermissionDecision, hso.RuleID, tt.wantAction, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestCommandGuardOptions(t *testing.T) {
+	t.Setenv("HOOK_CHAIN_RULES", filepath.Join(t.TempDir(), "missing.yaml"))
+	for _, options := range []map[string]any{
+		{"action": "block"},
+		{"rules": filepath.Join(t.TempDir(), "nope.yaml")},
+		{"disabel": []any{"x"}},
+	} {
+		if _, err := newCommandGuard(options); err == nil {
+			t.Errorf("newCommandGuard(%v) succeeded, want error", options)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package builtin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// Rule IDs reported by the egress guard.
+const (
+	RuleEgressDeny     = "egress-guard/deny"
+	RuleEgressUnlisted = "egress-guard/unlisted"
+)
+
+// hostUnknown stands for a destination that is only known at run time,
+// such as `curl "$URL"`.
+const hostUnknown = "(unknown)"
+
+// netTool describes how to find destinations in one network command's
+// arguments.
+type netTool struct {
+	// valueFlags take an argument that is not a destination.
+	valueFlags map[string]bool
+	// hostFlags take an argument that is a destination (proxies, jump hosts).
+	hostFlags map[string]bool
+	// firstOnly: only the first operand is a destination (nc, ssh); the
+	// rest is a port or a remote command.
+	firstOnly bool
+	// remotePaths: operands are [user@]host:path, or local paths (scp, rsync).
+	remotePaths bool
+}
+
+var netTools = map[string]netTool{
+	"curl": {
+		valueFlags: flagSet("-o", "--output", "-H", "--header", "-d", "--data", "--data-raw", "--data-binary", "--data-urlencode",
+			"-X", "--request", "-u", "--user", "-A", "--user-agent", "-e", "--referer", "-b", "--cookie", "-c", "--cookie-jar",
+			"-T", "--upload-file", "-F", "--form", "-w", "--write-out", "--connect-timeout", "-m", "--max-time", "--retry",
+			"-K", "--config", "-r", "--range", "-E", "--cert", "--key", "--cacert", "-C", "--continue-at", "--resolve", "-Y", "-y"),
+		hostFlags: flagSet("-x", "--proxy", "--url"),
+	},
+	"wget": {
+		valueFlags: flagSet("-O", "--output-document", "-o", "--output-file", "-a", "--append-output", "-P", "--directory-prefix",
+			"--header", "-U", "--user-agent", "--post-data", "--post-file", "-e", "--execute", "-t", "--tries", "-T", "--timeout",
+			"-i", "--input-file", "--user", "--password", "-l", "--level", "-A", "-R", "-D", "--domains"),
+	},
+	"http":    {valueFlags: flagSet("-a", "--auth", "-o", "--output", "--session"), hostFlags: flagSet("--proxy")},
+	"https":   {valueFlags: flagSet("-a", "--auth", "-o", "--output", "--session"), hostFlags: flagSet("--proxy")},
+	"nc":      {valueFlags: flagSet("-p", "-s", "-w", "-i", "-e", "-c", "-q", "-X", "-I", "-O", "-T", "-V"), hostFlags: flagSet("-x"), firstOnly: true},
+	"ncat":    {valueFlags: flagSet("-p", "-s", "-w", "-i", "-e", "-c", "--sh-exec", "--exec"), hostFlags: flagSet("--proxy"), firstOnly: true},
+	"netcat":  {valueFlags: flagSet("-p", "-s", "-w", "-i", "-e", "-c", "-q"), hostFlags: flagSet("-x"), firstOnly: true},
+	"telnet":  {valueFlags: flagSet("-l", "-n", "-e"), firstOnly: true},
+	"socat":   {},
+	"ssh":     {valueFlags: flagSet("-p", "-i", "-l", "-o", "-F", "-L", "-R", "-D", "-b", "-c", "-E", "-e", "-I", "-m", "-O", "-Q", "-S", "-W", "-w", "-B"), hostFlags: flagSet("-J"), firstOnly: true},
+	"sftp":    {valueFlags: flagSet("-P", "-i", "-o", "-F", "-b", "-c", "-D", "-l", "-R", "-s", "-S", "-B"), hostFlags: flagSet("-J"), firstOnly: true},
+	"scp":     {valueFlags: flagSet("-P", "-i", "-o", "-F", "-c", "-l", "-S"), hostFlags: flagSet("-J"), remotePaths: true},
+	"rsync":   {valueFlags: flagSet("-e", "--rsh", "--exclude", "--include", "--filter", "-f", "--files-from", "--port", "--password-file", "--log-file"), remotePaths: true},
+	"ftp":     {firstOnly: true},
+	"openssl": {hostFlags: flagSet("-connect")},
+}
+
+func flagSet(flags ...string) map[string]bool {
+	m := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		m[f] = true
+	}
+	return m
+}
+
+// hostLike matches a bare destination such as example.com, 10.0.0.1:8080,
+// or example.com/path.
+var hostLike = regexp.MustCompile(`^(?:[\w-]+@)?(?:\[[0-9a-fA-F:.]+\]|[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)+|localhost)(?::\d+)?(?:/\S*)?$`)
+
+// destinations returns the hosts a network command connects to. Operands
+// whose destination depends on shell expansion yield hostUnknown.
+func destinations(c shellCommand) []string {
+	tool, ok := netTools[c.Name]
+	if !ok {
+		return nil
+	}
+	var hosts []string
+	add := func(arg string, bare bool) {
+		if h := destHost(arg, bare, tool.remotePaths); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+
+	operands := 0
+	for i := 0; i < len(c.Args); i++ {
+		a := c.Args[i]
+		if a == "--" {
+			continue
+		}
+		if strings.HasPrefix(a, "-") && len(a) > 1 {
+			name, value, hasValue := strings.Cut(a, "=")
+			if !strings.HasPrefix(name, "--") && len(name) > 2 {
+				// Combined short flags (-sSo file): only the last may take a value.
+				name = "-" + name[len(name)-1:]
+			}
+			switch {
+			case tool.hostFlags[name]:
+				if !hasValue && i+1 < len(c.Args) {
+					i++
+					value = c.Args[i]
+				}
+				add(value, true)
+			case tool.valueFlags[name] && !hasValue:
+				i++
+			}
+			continue
+		}
+		if c.Name == "socat" {
+			// socat addresses: TCP:host:port, OPENSSL:host:port, ...
+			if _, rest, ok := strings.Cut(a, ":"); ok {
+				host, _, _ := strings.Cut(rest, ":")
+				add(host, true)
+			}
+			continue
+		}
+		if tool.firstOnly && operands > 0 {
+			break
+		}
+		operands++
+		add(a, tool.firstOnly)
+	}
+	return hosts
+}
+
+// destHost extracts the host from one operand. bare accepts a plain host
+// name without a dot (ssh myserver); remotePaths only accepts host:path.
+func destHost(arg string, bare, remotePaths bool) string {
+	if strings.Contains(arg, "$") || strings.Contains(arg, "`") {
+		return hostUnknown
+	}
+	if strings.Contains(arg, "://") {
+		u, err := url.Parse(arg)
+		if err != nil || u.Hostname() == "" {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	if remotePaths {
+		host, _, ok := strings.Cut(arg, ":")
+		if !ok || strings.Contains(host, "/") || host == "" {
+			return ""
+		}
+		return stripUser(host)
+	}
+	if bare {
+		host := stripUser(arg)
+		if h, _, ok := strings.Cut(host, "/"); ok {
+			host = h
+		}
+		return stripPort(host)
+	}
+	if !hostLike.MatchString(arg) {
+		return ""
+	}
+	host, _, _ := strings.Cut(stripUser(arg), "/")
+	return stripPort(host)
+}
+
+func stripUser(s string) string {
+	if _, host, ok := strings.Cut(s, "@"); ok {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(s)
+}
+
+func stripPort(hostport string) string {
+	if strings.HasPrefix(hostport, "[") {
+		if end := strings.IndexByte(hostport, ']'); end > 0 {
+			return hostport[1:end]
+		}
+	}
+	if strings.Count(hostport, ":") == 1 {
+		host, _, _ := strings.Cut(hostport, ":")
+		return host
+	}
+	return hostport
+}
+
+// hostPattern is one allow or deny entry: a domain (matching itself and
+// its subdomains), a *.domain wildcard (subdomains only), an IP, or a CIDR.
+type hostPattern struct {
+	raw      string
+	domain   string
+	wildcard bool
+	prefix   netip.Prefix
+}
+
+func parseHostPattern(s string) (hostPattern, error) {
+	p := hostPattern{raw: s}
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return p, errors.New("empty host pattern")
+	case strings.Contains(s, "/"):
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return p, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		p.prefix = prefix.Masked()
+	default:
+		if addr, err := netip.ParseAddr(s); err == nil {
+			p.prefix = netip.PrefixFrom(addr, addr.BitLen())
+			break
+		}
+		if rest, ok := strings.CutPrefix(s, "*."); ok {
+			p.wildcard, s = true, rest
+		}
+		if strings.ContainsAny(s, "*:@ ") {
+			return p, fmt.Errorf("invalid host pattern %q", p.raw)
+		}
+		p.domain = strings.TrimSuffix(s, ".")
+	}
+	return p, nil
+}
+
+func (p hostPattern) matches(host string) bool {
+	if p.prefix.IsValid() {
+		addr, err := netip.ParseAddr(host)
+		return err == nil && p.prefix.Contains(addr.Unmap())
+	}
+	host = strings.TrimSuffix(host, ".")
+	if strings.HasSuffix(host, "."+p.domain) {
+		return true
+	}
+	return !p.wildcard && host == p.domain
+}
+
+type egressGuardOptions struct {
+	Allow   []string `json:"allow"`   // hosts, *.domains, IPs, and CIDRs that may be contacted
+	Deny    []string `json:"deny"`    // destinations that are always denied
+	Default string   `json:"default"` // for destinations on neither list: "allow" | "ask" | "deny" (default: "ask" with an allow list, else "allow")
+}
+
+// egressGuard checks the destinations of network commands in Bash calls
+// and of WebFetch URLs against allow and deny lists. Names are matched as
+// written; they are not resolved.
+type egressGuard struct {
+	allow, deny []hostPattern
+	unlisted    string
+}
+
+func newEgressGuard(options map[string]any) (Builtin, error) {
+	var opts egressGuardOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	g := &egressGuard{unlisted: "allow"}
+	for _, s := range opts.Allow {
+		p, err := parseHostPattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("allow: %w", err)
+		}
+		g.allow = append(g.allow, p)
+	}
+	for _, s := range opts.Deny {
+		p, err := parseHostPattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("deny: %w", err)
+		}
+		g.deny = append(g.deny, p)
+	}
+	if len(g.allow) > 0 {
+		g.unlisted = "ask"
+	}
+	switch opts.Default {
+	case "":
+	case "allow", "ask", "deny":
+		g.unlisted = opts.Default
+	default:
+		return nil, fmt.Errorf("default %q is not \"allow\", \"ask\", or \"deny\"", opts.Default)
+	}
+	return g, nil
+}
+
+// Check implements Builtin. Only Bash and WebFetch calls are inspected.
+func (g *egressGuard) Check(input hook.Input) (*hook.Output, error) {
+	var hosts []string
+	switch input.ToolName {
+	case "Bash":
+		var ti struct {
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+			return nil, fmt.Errorf("parse Bash input: %w", err)
+		}
+		for _, c := range parseShell(ti.Command) {
+			hosts = append(hosts, destinations(c)...)
+		}
+	case "WebFetch":
+		var ti struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+			return nil, fmt.Errorf("parse WebFetch input: %w", err)
+		}
+		if h := destHost(ti.URL, false, false); h != "" {
+			hosts = append(hosts, h)
+		}
+	default:
+		return nil, nil
+	}
+
+	var unlisted []string
+	for _, h := range hosts {
+		if h != hostUnknown {
+			if p, ok := matchAny(g.deny, h); ok {
+				return decision("deny", RuleEgressDeny,
+					fmt.Sprintf("hook-chain: network access to %s is denied by egress policy (%s)", h, p.raw),
+					map[string]any{"host": h, "pattern": p.raw}), nil
+			}
+			if _, ok := matchAny(g.allow, h); ok {
+				continue
+			}
+		}
+		unlisted = append(unlisted, h)
+	}
+	if len(unlisted) == 0 || g.unlisted == "allow" {
+		return nil, nil
+	}
+	return decision(g.unlisted, RuleEgressUnlisted,
+		fmt.Sprintf("hook-chain: network access to %s is not on the egress allowlist", strings.Join(unlisted, ", ")),
+		map[string]any{"hosts": unlisted}), nil
+}
+
+func matchAny(patterns []hostPattern, host string) (hostPattern, bool) {
+	for _, p := range patterns {
+		if p.matches(host) {
+			return p, true
+		}
+	}
+	return hostPattern{}, false
+}
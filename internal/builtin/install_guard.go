@@ -0,0 +1,416 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// Rule IDs reported by the install guard.
+const (
+	RuleInstallDeny     = "install-guard/deny"
+	RuleInstallAdvisory = "install-guard/advisory"
+	RuleInstallUnknown  = "install-guard/unknown"
+)
+
+// Package ecosystems recognized by the install guard.
+const (
+	EcosystemNPM   = "npm"
+	EcosystemPyPI  = "pypi"
+	EcosystemGo    = "go"
+	EcosystemCrate = "crates"
+)
+
+// pkgRef is one package named on an install command line. Unresolved
+// references (requirement files, git URLs, shell expansions) cannot be
+// checked by name and are treated as unknown.
+type pkgRef struct {
+	Ecosystem  string `json:"ecosystem"`
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	Unresolved bool   `json:"unresolved,omitempty"`
+}
+
+func (p pkgRef) String() string {
+	s := p.Ecosystem + ":" + p.Name
+	if p.Version != "" {
+		s += "@" + p.Version
+	}
+	return s
+}
+
+// installer describes one package manager's install subcommands.
+type installer struct {
+	ecosystem   string
+	subcommands map[string]bool
+	// valueFlags take an argument that is not a package.
+	valueFlags map[string]bool
+	// unresolvedFlags take an argument that installs something that cannot
+	// be checked by name (a requirements file, a git URL, a local path).
+	unresolvedFlags map[string]bool
+}
+
+var npmSubcommands = flagSet("install", "i", "in", "ins", "inst", "insta", "instal", "isnt", "isnta", "isntal", "isntall", "add")
+
+var npmValueFlags = flagSet("--registry", "--prefix", "--tag", "-C", "--cache", "--userconfig", "-w", "--workspace", "--filter")
+
+var pipValueFlags = flagSet("-i", "--index-url", "--extra-index-url", "-t", "--target", "--prefix", "-f", "--find-links",
+	"--platform", "--python-version", "--implementation", "--abi", "--root", "--src", "--upgrade-strategy", "--progress-bar",
+	"--trusted-host", "--cert", "--client-cert", "--proxy", "--retries", "--timeout", "--log", "--python", "-p")
+
+var installers = map[string]installer{
+	"npm":  {ecosystem: EcosystemNPM, subcommands: npmSubcommands, valueFlags: npmValueFlags},
+	"pnpm": {ecosystem: EcosystemNPM, subcommands: npmSubcommands, valueFlags: npmValueFlags},
+	"yarn": {ecosystem: EcosystemNPM, subcommands: flagSet("add"), valueFlags: npmValueFlags},
+	"bun":  {ecosystem: EcosystemNPM, subcommands: flagSet("add", "install", "i"), valueFlags: npmValueFlags},
+	"pip": {ecosystem: EcosystemPyPI, subcommands: flagSet("install"), valueFlags: pipValueFlags,
+		unresolvedFlags: flagSet("-r", "--requirement", "-e", "--editable", "-c", "--constraint")},
+	"pipx": {ecosystem: EcosystemPyPI, subcommands: flagSet("install", "inject"), valueFlags: pipValueFlags},
+	"uv": {ecosystem: EcosystemPyPI, subcommands: flagSet("add"), valueFlags: pipValueFlags,
+		unresolvedFlags: flagSet("-r", "--requirements", "--editable")},
+	"go": {ecosystem: EcosystemGo, subcommands: flagSet("get", "install"), valueFlags: flagSet("-C", "-modfile", "-o", "-tags", "-ldflags", "-gcflags", "-p")},
+	"cargo": {ecosystem: EcosystemCrate, subcommands: flagSet("add", "install"),
+		valueFlags: flagSet("-F", "--features", "--branch", "--tag", "--rev", "--registry", "--rename", "-p", "--package",
+			"--manifest-path", "--target", "--root", "-j", "--jobs", "--profile", "--index", "--bin", "--example", "--vers", "--version"),
+		unresolvedFlags: flagSet("--git", "--path")},
+}
+
+// installPackages returns the packages an install command names, or nil if
+// c is not an install command.
+func installPackages(c shellCommand) []pkgRef {
+	name, args := c.Name, c.Args
+	// Normalize pip3, pip3.12, and `python -m pip` to pip; `uv pip` to pip.
+	switch {
+	case strings.HasPrefix(name, "pip") && name != "pipx":
+		name = "pip"
+	case strings.HasPrefix(name, "python") && len(args) >= 2 && args[0] == "-m" && strings.HasPrefix(args[1], "pip"):
+		name, args = "pip", args[2:]
+	case name == "uv" && len(args) > 0 && args[0] == "pip":
+		name, args = "pip", args[1:]
+	}
+	inst, ok := installers[name]
+	if !ok {
+		return nil
+	}
+
+	// Find the subcommand, skipping global flags.
+	sub := -1
+	for i, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			if inst.subcommands[a] {
+				sub = i
+			}
+			break
+		}
+	}
+	if sub < 0 {
+		return nil
+	}
+
+	var pkgs []pkgRef
+	rest := args[sub+1:]
+	for i := 0; i < len(rest); i++ {
+		a := rest[i]
+		if strings.HasPrefix(a, "-") && len(a) > 1 {
+			flag, value, hasValue := strings.Cut(a, "=")
+			switch {
+			case inst.unresolvedFlags[flag]:
+				if !hasValue && i+1 < len(rest) {
+					i++
+					value = rest[i]
+				}
+				pkgs = append(pkgs, pkgRef{Ecosystem: inst.ecosystem, Name: flag + " " + value, Unresolved: true})
+			case inst.valueFlags[flag] && !hasValue:
+				i++
+			}
+			continue
+		}
+		if p, ok := parsePkgSpec(inst.ecosystem, a); ok {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
+
+// pipSpec splits a requirement like requests[socks]>=2.31 into name and version.
+var pipSpec = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*(?:(==|===|~=|>=|<=|!=|>|<)\s*(\S+))?$`)
+
+// parsePkgSpec parses one package operand. Local paths are skipped; URLs
+// and shell expansions are returned unresolved.
+func parsePkgSpec(ecosystem, spec string) (pkgRef, bool) {
+	ref := pkgRef{Ecosystem: ecosystem, Name: spec}
+	switch {
+	case spec == "" || spec == "." || strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/"):
+		return ref, false
+	case strings.ContainsAny(spec, "$`"), strings.Contains(spec, "://"), strings.HasPrefix(spec, "git+"), strings.HasPrefix(spec, "github:"), strings.HasPrefix(spec, "file:"):
+		ref.Unresolved = true
+		return ref, true
+	}
+
+	switch ecosystem {
+	case EcosystemNPM:
+		// [@scope/]name[@version]
+		at := strings.LastIndexByte(spec, '@')
+		if at > 0 {
+			ref.Name, ref.Version = spec[:at], spec[at+1:]
+		}
+	case EcosystemPyPI:
+		m := pipSpec.FindStringSubmatch(spec)
+		if m == nil {
+			ref.Unresolved = true
+			return ref, true
+		}
+		ref.Name = normalizePyPI(m[1])
+		if m[2] == "==" || m[2] == "===" {
+			ref.Version = m[3]
+		}
+	case EcosystemGo:
+		if spec == "all" || spec == "std" || spec == "cmd" {
+			return ref, false
+		}
+		ref.Name, ref.Version, _ = strings.Cut(spec, "@")
+	case EcosystemCrate:
+		ref.Name, ref.Version, _ = strings.Cut(spec, "@")
+	}
+	return ref, true
+}
+
+// normalizePyPI applies PEP 503 name normalization: case-insensitive, with
+// runs of -, _, and . equivalent.
+func normalizePyPI(name string) string {
+	return strings.ToLower(pypiSeparators.ReplaceAllString(name, "-"))
+}
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// pkgPattern is an allow or deny entry: [ecosystem:]glob, where the glob
+// matches per path segment and ** spans segments.
+type pkgPattern struct {
+	raw, ecosystem, glob string
+}
+
+func parsePkgPattern(s string) (pkgPattern, error) {
+	p := pkgPattern{raw: s, glob: s}
+	if eco, glob, ok := strings.Cut(s, ":"); ok {
+		switch eco {
+		case EcosystemNPM, EcosystemPyPI, EcosystemGo, EcosystemCrate:
+			p.ecosystem, p.glob = eco, glob
+		default:
+			return p, fmt.Errorf("unknown ecosystem %q in %q (want npm, pypi, go, or crates)", eco, s)
+		}
+	}
+	if p.glob == "" {
+		return p, fmt.Errorf("empty package pattern %q", s)
+	}
+	if err := validGlob(p.glob); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (p pkgPattern) matches(ref pkgRef) bool {
+	if p.ecosystem != "" && p.ecosystem != ref.Ecosystem {
+		return false
+	}
+	glob := p.glob
+	if ref.Ecosystem == EcosystemPyPI {
+		glob = normalizePyPI(glob)
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(ref.Name, "/"))
+}
+
+// advisoryDB is the local advisory file: known-bad packages, optionally
+// limited to specific versions.
+type advisoryDB struct {
+	Advisories []advisory `json:"advisories"`
+}
+
+type advisory struct {
+	ID        string   `json:"id"`
+	Ecosystem string   `json:"ecosystem"`
+	Package   string   `json:"package"`
+	Versions  []string `json:"versions,omitempty"` // affected versions; empty = all
+	Summary   string   `json:"summary,omitempty"`
+}
+
+// affects reports whether the advisory covers ref. A reference without a
+// pinned version is only covered by advisories for all versions.
+func (a advisory) affects(ref pkgRef) bool {
+	name := a.Package
+	if ref.Ecosystem == EcosystemPyPI {
+		name = normalizePyPI(name)
+	}
+	if a.Ecosystem != ref.Ecosystem || !strings.EqualFold(name, ref.Name) {
+		return false
+	}
+	if len(a.Versions) == 0 {
+		return true
+	}
+	for _, v := range a.Versions {
+		if ref.Version != "" && strings.TrimPrefix(v, "v") == strings.TrimPrefix(ref.Version, "v") {
+			return true
+		}
+	}
+	return false
+}
+
+func loadAdvisories(path string) ([]advisory, error) {
+	data, err := os.ReadFile(pathutil.Expand(path))
+	if err != nil {
+		return nil, fmt.Errorf("read advisories: %w", err)
+	}
+	var db advisoryDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("parse advisories %s: %w", path, err)
+	}
+	for i, a := range db.Advisories {
+		if a.Ecosystem == "" || a.Package == "" {
+			return nil, fmt.Errorf("advisories %s: entry %d needs ecosystem and package", path, i)
+		}
+	}
+	return db.Advisories, nil
+}
+
+type installGuardOptions struct {
+	Allow      []string `json:"allow"`      // [ecosystem:]glob packages that may be installed
+	Deny       []string `json:"deny"`       // [ecosystem:]glob packages that are always denied
+	Advisories string   `json:"advisories"` // path to a local advisory DB (JSON)
+	Unknown    string   `json:"unknown"`    // packages on neither list: "allow" | "ask" | "deny" (default: "ask" with an allow list, else "allow")
+	Bad        string   `json:"bad"`        // deny-listed or advised packages: "ask" | "deny" (default)
+}
+
+// installGuard checks packages named by npm/yarn/pnpm/bun, pip/uv/pipx, go,
+// and cargo install commands against allow and deny lists and a local
+// advisory DB.
+type installGuard struct {
+	allow, deny []pkgPattern
+	advisories  []advisory
+	unknown     string
+	bad         string
+}
+
+func newInstallGuard(options map[string]any) (Builtin, error) {
+	var opts installGuardOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	g := &installGuard{unknown: "allow", bad: "deny"}
+	for _, s := range opts.Allow {
+		p, err := parsePkgPattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("allow: %w", err)
+		}
+		g.allow = append(g.allow, p)
+	}
+	for _, s := range opts.Deny {
+		p, err := parsePkgPattern(s)
+		if err != nil {
+			return nil, fmt.Errorf("deny: %w", err)
+		}
+		g.deny = append(g.deny, p)
+	}
+	if opts.Advisories != "" {
+		advs, err := loadAdvisories(opts.Advisories)
+		if err != nil {
+			return nil, err
+		}
+		g.advisories = advs
+	}
+	if len(g.allow) > 0 {
+		g.unknown = "ask"
+	}
+	switch opts.Unknown {
+	case "":
+	case "allow", "ask", "deny":
+		g.unknown = opts.Unknown
+	default:
+		return nil, fmt.Errorf("unknown %q is not \"allow\", \"ask\", or \"deny\"", opts.Unknown)
+	}
+	switch opts.Bad {
+	case "":
+	case "ask", "deny":
+		g.bad = opts.Bad
+	default:
+		return nil, fmt.Errorf("bad %q is not \"ask\" or \"deny\"", opts.Bad)
+	}
+	return g, nil
+}
+
+// Check implements Builtin. Only Bash calls are inspected.
+func (g *installGuard) Check(input hook.Input) (*hook.Output, error) {
+	if input.ToolName != "Bash" {
+		return nil, nil
+	}
+	var ti struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+		return nil, fmt.Errorf("parse Bash input: %w", err)
+	}
+
+	var unknown []pkgRef
+	for _, c := range parseShell(ti.Command) {
+		for _, ref := range installPackages(c) {
+			if !ref.Unresolved {
+				if p, ok := g.denied(ref); ok {
+					return decision(g.bad, RuleInstallDeny,
+						fmt.Sprintf("hook-chain: installing %s is blocked by the package deny list (%s)", ref, p.raw),
+						map[string]any{"package": ref, "pattern": p.raw}), nil
+				}
+				if a, ok := g.advised(ref); ok {
+					reason := fmt.Sprintf("hook-chain: %s has a known advisory (%s)", ref, a.ID)
+					if a.Summary != "" {
+						reason += ": " + a.Summary
+					}
+					return decision(g.bad, RuleInstallAdvisory, reason, map[string]any{"package": ref, "advisory": a.ID}), nil
+				}
+				if g.allowed(ref) {
+					continue
+				}
+			}
+			unknown = append(unknown, ref)
+		}
+	}
+	if len(unknown) == 0 || g.unknown == "allow" {
+		return nil, nil
+	}
+	names := make([]string, len(unknown))
+	for i, ref := range unknown {
+		names[i] = ref.String()
+	}
+	return decision(g.unknown, RuleInstallUnknown,
+		fmt.Sprintf("hook-chain: installing %s, which is not on the package allow list", strings.Join(names, ", ")),
+		map[string]any{"packages": unknown}), nil
+}
+
+func (g *installGuard) denied(ref pkgRef) (pkgPattern, bool) {
+	for _, p := range g.deny {
+		if p.matches(ref) {
+			return p, true
+		}
+	}
+	return pkgPattern{}, false
+}
+
+func (g *installGuard) advised(ref pkgRef) (advisory, bool) {
+	for _, a := range g.advisories {
+		if a.affects(ref) {
+			return a, true
+		}
+	}
+	return advisory{}, false
+}
+
+func (g *installGuard) allowed(ref pkgRef) bool {
+	for _, p := range g.allow {
+		if p.matches(ref) {
+			return true
+		}
+	}
+	return false
+}
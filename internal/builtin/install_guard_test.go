@@ -0,0 +1,148 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInstallPackages(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"npm install --save-dev typescript @types/node@20 react@18.2.0", []string{"npm:typescript", "npm:@types/node@20", "npm:react@18.2.0"}},
+		{"npm i -g --registry https://r.example left-pad", []string{"npm:left-pad"}},
+		{"npm install", nil},
+		{"npm run build", nil},
+		{"yarn add lodash", []string{"npm:lodash"}},
+		{"pip install -U Requests[socks]==2.31.0 'flask>=3'", []string{"pypi:requests@2.31.0", "pypi:flask"}},
+		{"python3 -m pip install --index-url https://pypi.example Django_Rest.framework", []string{"pypi:django-rest-framework"}},
+		{"pip install -r requirements.txt", []string{"pypi:-r requirements.txt"}},
+		{"pip install .", nil},
+		{"go get github.com/spf13/cobra@v1.8.0", []string{"go:github.com/spf13/cobra@v1.8.0"}},
+		{"go install ./cmd/...", nil},
+		{"cargo add serde@1 --features derive", []string{"crates:serde@1"}},
+		{"cargo install --git https://github.com/x/y", []string{"crates:--git https://github.com/x/y"}},
+		{"cd web && npm install axios", []string{"npm:axios"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range parseShell(tt.line) {
+			for _, p := range installPackages(c) {
+				got = append(got, p.String())
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("installPackages(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestInstallGuard(t *testing.T) {
+	advisories := filepath.Join(t.TempDir(), "advisories.json")
+	if err := os.WriteFile(advisories, []byte(`{"advisories": [
+		{"id": "MAL-2018-1", "ecosystem": "npm", "package": "event-stream", "versions": ["3.3.6"], "summary": "flatmap-stream backdoor"},
+		{"id": "MAL-2022-9", "ecosystem": "pypi", "package": "Ctx"}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		options    map[string]any
+		command    string
+		wantAction string // "" = pass
+		wantRule   string
+	}{
+		{
+			name:       "deny list",
+			options:    map[string]any{"deny": []any{"npm:left-pad"}},
+			command:    "npm install left-pad",
+			wantAction: "deny",
+			wantRule:   RuleInstallDeny,
+		},
+		{
+			name:       "advisory for pinned version",
+			options:    map[string]any{"advisories": advisories},
+			command:    "npm install event-stream@3.3.6",
+			wantAction: "deny",
+			wantRule:   RuleInstallAdvisory,
+		},
+		{
+			name:    "advisory for other version",
+			options: map[string]any{"advisories": advisories},
+			command: "npm install event-stream@4.0.1",
+		},
+		{
+			name:       "advisory for all versions",
+			options:    map[string]any{"advisories": advisories, "bad": "ask"},
+			command:    "pip install ctx",
+			wantAction: "ask",
+			wantRule:   RuleInstallAdvisory,
+		},
+		{
+			name:    "allow list with globs",
+			options: map[string]any{"allow": []any{"npm:@types/*", "go:github.com/spf13/**", "requests"}},
+			command: "npm i @types/node && go get github.com/spf13/cobra/doc && pip install requests",
+		},
+		{
+			name:       "unknown asks with an allow list",
+			options:    map[string]any{"allow": []any{"npm:react"}},
+			command:    "npm install react reactt",
+			wantAction: "ask",
+			wantRule:   RuleInstallUnknown,
+		},
+		{
+			name:       "unresolved requirements file is unknown",
+			options:    map[string]any{"allow": []any{"pypi:requests"}, "unknown": "deny"},
+			command:    "pip install -r requirements.txt",
+			wantAction: "deny",
+			wantRule:   RuleInstallUnknown,
+		},
+		{
+			name:    "no lists allows",
+			command: "cargo add tokio",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newInstallGuard(tt.options)
+			if err != nil {
+				t.Fatalf("newInstallGuard: %v", err)
+			}
+			out, err := g.Check(toolCall(t, "Bash", "/repo", map[string]any{"command": tt.command}))
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if tt.wantAction == "" {
+				if out != nil {
+					t.Errorf("want pass, got %s", out.HookSpecificOutput.PermissionDecisionReason)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("want %s, got pass", tt.wantAction)
+			}
+			hso := out.HookSpecificOutput
+			if hso.PermissionDecision != tt.wantAction || hso.RuleID != tt.wantRule {
+				t.Errorf("got %s/%s, want %s/%s (%s)", hso.PermissionDecision, hso.RuleID, tt.wantAction, tt.wantRule, hso.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+func TestInstallGuardOptionErrors(t *testing.T) {
+	for _, opts := range []map[string]any{
+		{"allow": []any{"maven:junit"}},
+		{"unknown": "maybe"},
+		{"bad": "allow"},
+		{"advisories": filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if _, err := newInstallGuard(opts); err == nil {
+			t.Errorf("newInstallGuard(%v) succeeded, want error", opts)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/diff"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// RuleLicense is the rule ID reported by the license guard.
+const RuleLicense = "license-guard/provenance"
+
+// Sensitivity levels of the license guard, from fewest to most findings.
+const (
+	SensitivityLow    = "low"    // copyleft licenses only
+	SensitivityMedium = "medium" // any license text or copyright notice
+	SensitivityHigh   = "high"   // also attribution hints ("adapted from", Stack Overflow links)
+)
+
+var sensitivityRank = map[string]int{SensitivityLow: 0, SensitivityMedium: 1, SensitivityHigh: 2}
+
+// provenanceSignal recognizes one kind of license or provenance marker.
+// source names what the match points to; when it is empty the first
+// capture group is used instead.
+type provenanceSignal struct {
+	level  string
+	kind   string
+	re     *regexp.Regexp
+	source string
+}
+
+var provenanceSignals = []provenanceSignal{
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)GNU Affero General Public License`), source: "AGPL"},
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)GNU Lesser General Public License`), source: "LGPL"},
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)GNU General Public License`), source: "GPL"},
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)Mozilla Public License`), source: "MPL"},
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)Eclipse Public License`), source: "EPL"},
+	{level: SensitivityLow, kind: "license", re: regexp.MustCompile(`(?i)Server Side Public License`), source: "SSPL"},
+	{level: SensitivityMedium, kind: "license", re: regexp.MustCompile(`SPDX-License-Identifier:\s*([\w.+\- ()]+?)\s*(?:\*/|-->)?\s*$`)},
+	{level: SensitivityMedium, kind: "license", re: regexp.MustCompile(`(?i)Licensed under the Apache License`), source: "Apache-2.0"},
+	{level: SensitivityMedium, kind: "license", re: regexp.MustCompile(`(?i)Permission is hereby granted, free of charge`), source: "MIT"},
+	{level: SensitivityMedium, kind: "license", re: regexp.MustCompile(`(?i)Redistribution and use in source and binary forms`), source: "BSD"},
+	{level: SensitivityMedium, kind: "license", re: regexp.MustCompile(`(?i)Licensed under the .{1,40} License`)},
+	{level: SensitivityMedium, kind: "copyright", re: regexp.MustCompile(`(?i)\bcopyright\s*(?:\(c\)|©)\s*(.{2,80}?)\s*(?:\*/|-->)?\s*$`)},
+	{level: SensitivityMedium, kind: "copyright", re: regexp.MustCompile(`(?i)(?:\bcopyright|©)\s+(\d{4}(?:\s*[-–,]\s*\d{4})*\s+.{2,80}?)\s*(?:\*/|-->)?\s*$`)},
+	{level: SensitivityHigh, kind: "attribution", re: regexp.MustCompile(`(?i)\b((?:adapted|taken|copied|borrowed|ported) from\b.{0,80}?)\s*(?:\*/|-->)?\s*$`)},
+	{level: SensitivityHigh, kind: "attribution", re: regexp.MustCompile(`(?i)(https?://(?:www\.)?stackoverflow\.com/(?:a|q|questions)/\d+\S*)`)},
+}
+
+// copyleftSPDX are SPDX identifier prefixes that count at low sensitivity.
+var copyleftSPDX = []string{"GPL", "AGPL", "LGPL", "MPL", "EPL", "CDDL", "SSPL", "EUPL", "OSL", "CC-BY-SA"}
+
+// provenanceFinding is one detected marker, reported in the decision metadata.
+type provenanceFinding struct {
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Line   string `json:"line"`
+}
+
+type licenseGuardOptions struct {
+	Sensitivity string   `json:"sensitivity"` // "low" | "medium" (default) | "high"
+	Allow       []string `json:"allow"`       // case-insensitive substrings of findings to ignore (own copyright holder, own license)
+	Action      string   `json:"action"`      // "ask" (default) | "deny"
+}
+
+// licenseGuard flags Write, Edit, and MultiEdit calls that insert license
+// headers or copyright notices, which suggest copy-pasted third-party code.
+// Only added lines are scanned, so rewriting a file that already carries
+// the project's own header does not trigger it.
+type licenseGuard struct {
+	rank   int
+	allow  []string
+	action string
+}
+
+func newLicenseGuard(options map[string]any) (Builtin, error) {
+	var opts licenseGuardOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	g := &licenseGuard{rank: sensitivityRank[SensitivityMedium], action: "ask"}
+	if opts.Sensitivity != "" {
+		rank, ok := sensitivityRank[opts.Sensitivity]
+		if !ok {
+			return nil, fmt.Errorf("sensitivity %q is not %q, %q, or %q", opts.Sensitivity, SensitivityLow, SensitivityMedium, SensitivityHigh)
+		}
+		g.rank = rank
+	}
+	switch opts.Action {
+	case "":
+	case "ask", "deny":
+		g.action = opts.Action
+	default:
+		return nil, fmt.Errorf("action %q is not \"ask\" or \"deny\"", opts.Action)
+	}
+	for _, a := range opts.Allow {
+		if a = strings.TrimSpace(a); a != "" {
+			g.allow = append(g.allow, strings.ToLower(a))
+		}
+	}
+	return g, nil
+}
+
+// Check implements Builtin. Tools that do not edit files pass.
+func (g *licenseGuard) Check(input hook.Input) (*hook.Output, error) {
+	lines, path, ok := addedLines(input)
+	if !ok {
+		return nil, nil
+	}
+	var findings []provenanceFinding
+	for _, line := range lines {
+		if f, ok := g.scan(line); ok {
+			findings = append(findings, f)
+		}
+	}
+	if len(findings) == 0 {
+		return nil, nil
+	}
+
+	sources := make([]string, 0, len(findings))
+	for _, f := range findings {
+		if !slices.Contains(sources, f.Source) {
+			sources = append(sources, f.Source)
+		}
+	}
+	reason := fmt.Sprintf("hook-chain: content inserted into %s may be copied from third-party code (%s); confirm its license allows reuse here", path, strings.Join(sources, "; "))
+	return decision(g.action, RuleLicense, reason, map[string]any{"findings": findings}), nil
+}
+
+// scan returns the first signal on line at or below the guard's sensitivity
+// that is not allowed.
+func (g *licenseGuard) scan(line string) (provenanceFinding, bool) {
+	for _, s := range provenanceSignals {
+		m := s.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		source := s.source
+		if source == "" && len(m) > 1 {
+			source = strings.TrimSpace(m[1])
+		}
+		if source == "" {
+			source = strings.TrimSpace(m[0])
+		}
+		if sensitivityRank[s.level] > g.rank && !(s.source == "" && s.kind == "license" && isCopyleft(source)) {
+			continue
+		}
+		if g.allowed(line) {
+			return provenanceFinding{}, false
+		}
+		return provenanceFinding{Kind: s.kind, Source: source, Line: strings.TrimSpace(line)}, true
+	}
+	return provenanceFinding{}, false
+}
+
+func (g *licenseGuard) allowed(line string) bool {
+	lower := strings.ToLower(line)
+	for _, a := range g.allow {
+		if strings.Contains(lower, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCopyleft(spdx string) bool {
+	upper := strings.ToUpper(spdx)
+	for _, p := range copyleftSPDX {
+		if strings.HasPrefix(upper, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// addedLines returns the lines a Write, Edit, or MultiEdit call adds to its
+// file and the file's path. When the change cannot be replayed against the
+// file on disk, all of the call's new text is returned instead.
+func addedLines(input hook.Input) (lines []string, path string, ok bool) {
+	d, ok, err := diff.ForTool(input.ToolName, input.ToolInput, input.CWD, 0)
+	if !ok {
+		return nil, "", false
+	}
+	var in editText
+	_ = json.Unmarshal(input.ToolInput, &in)
+	if err != nil {
+		return strings.Split(in.text(), "\n"), in.FilePath, true
+	}
+	for i, l := range strings.Split(d, "\n") {
+		// Skip the ---/+++ header.
+		if i >= 2 && strings.HasPrefix(l, "+") {
+			lines = append(lines, l[1:])
+		}
+	}
+	return lines, in.FilePath, true
+}
+
+// editText is the new text of a file-editing tool call.
+type editText struct {
+	FilePath  string `json:"file_path"`
+	Content   string `json:"content"`
+	NewString string `json:"new_string"`
+	Edits     []struct {
+		NewString string `json:"new_string"`
+	} `json:"edits"`
+}
+
+func (e editText) text() string {
+	parts := []string{e.Content, e.NewString}
+	for _, x := range e.Edits {
+		parts = append(parts, x.NewString)
+	}
+	return strings.Join(parts, "\n")
+}
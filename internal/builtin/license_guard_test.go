@@ -0,0 +1,155 @@
+package builtin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+func toolCall(t *testing.T, tool, cwd string, toolInput map[string]any) hook.Input {
+	t.Helper()
+	raw, err := json.Marshal(toolInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hook.Input{HookEventName: "PreToolUse", ToolName: tool, CWD: cwd, ToolInput: raw}
+}
+
+func TestLicenseGuard(t *testing.T) {
+	dir := t.TempDir()
+	existing := "// Copyright 2024 Acme Corp.\n\npackage acme\n"
+	if err := os.WriteFile(filepath.Join(dir, "acme.go"), []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		options    map[string]any
+		tool       string
+		input      map[string]any
+		wantSource string // "" = pass
+	}{
+		{
+			name:       "apache header in new file",
+			tool:       "Write",
+			input:      map[string]any{"file_path": "util.go", "content": "// Licensed under the Apache License, Version 2.0\npackage util\n"},
+			wantSource: "Apache-2.0",
+		},
+		{
+			name:       "copyright notice via edit",
+			tool:       "Edit",
+			input:      map[string]any{"file_path": "acme.go", "old_string": "package acme\n", "new_string": "package acme\n\n// Copyright (c) 2015 Google LLC\nfunc f() {}\n"},
+			wantSource: "2015 Google LLC",
+		},
+		{
+			name:  "rewriting the existing header passes",
+			tool:  "Write",
+			input: map[string]any{"file_path": "acme.go", "content": existing + "\nfunc g() {}\n"},
+		},
+		{
+			name:    "allowed holder",
+			options: map[string]any{"allow": []any{"google"}},
+			tool:    "Write",
+			input:   map[string]any{"file_path": "x.go", "content": "// Copyright 2019 Google Inc.\npackage x\n"},
+		},
+		{
+			name:  "identifier named copyright passes",
+			tool:  "Write",
+			input: map[string]any{"file_path": "x.go", "content": "copyrightYear := 2024\n"},
+		},
+		{
+			name:       "spdx id",
+			tool:       "Write",
+			input:      map[string]any{"file_path": "x.c", "content": "/* SPDX-License-Identifier: BSD-3-Clause */\n"},
+			wantSource: "BSD-3-Clause",
+		},
+		{
+			name:    "low ignores permissive licenses",
+			options: map[string]any{"sensitivity": "low"},
+			tool:    "Write",
+			input:   map[string]any{"file_path": "x.c", "content": "/* SPDX-License-Identifier: MIT */\n// Copyright 2020 Someone\n"},
+		},
+		{
+			name:       "low flags copyleft spdx",
+			options:    map[string]any{"sensitivity": "low"},
+			tool:       "Write",
+			input:      map[string]any{"file_path": "x.c", "content": "// SPDX-License-Identifier: GPL-2.0-only\n"},
+			wantSource: "GPL-2.0-only",
+		},
+		{
+			name:       "low flags gpl text",
+			options:    map[string]any{"sensitivity": "low"},
+			tool:       "Write",
+			input:      map[string]any{"file_path": "x.c", "content": " * under the terms of the GNU General Public License as published by\n"},
+			wantSource: "GPL",
+		},
+		{
+			name:  "medium ignores attribution hints",
+			tool:  "Write",
+			input: map[string]any{"file_path": "x.py", "content": "# adapted from https://example.com/snippet\n"},
+		},
+		{
+			name:       "high flags stack overflow links",
+			options:    map[string]any{"sensitivity": "high"},
+			tool:       "Write",
+			input:      map[string]any{"file_path": "x.py", "content": "# see https://stackoverflow.com/a/12345/678\n"},
+			wantSource: "https://stackoverflow.com/a/12345/678",
+		},
+		{
+			name:  "other tools pass",
+			tool:  "Bash",
+			input: map[string]any{"command": "echo 'Copyright (c) 2020 Someone'"},
+		},
+		{
+			name:       "unreplayable edit scans new_string",
+			tool:       "Edit",
+			input:      map[string]any{"file_path": "acme.go", "old_string": "not in file", "new_string": "Permission is hereby granted, free of charge, to any person"},
+			wantSource: "MIT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newLicenseGuard(tt.options)
+			if err != nil {
+				t.Fatalf("newLicenseGuard: %v", err)
+			}
+			out, err := g.Check(toolCall(t, tt.tool, dir, tt.input))
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if tt.wantSource == "" {
+				if out != nil {
+					t.Errorf("want pass, got %s", out.HookSpecificOutput.PermissionDecisionReason)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("want finding %q, got pass", tt.wantSource)
+			}
+			hso := out.HookSpecificOutput
+			if hso.PermissionDecision != "ask" || hso.RuleID != RuleLicense {
+				t.Errorf("decision = %s/%s, want ask/%s", hso.PermissionDecision, hso.RuleID, RuleLicense)
+			}
+			if !strings.Contains(hso.PermissionDecisionReason, tt.wantSource) {
+				t.Errorf("reason %q does not name source %q", hso.PermissionDecisionReason, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLicenseGuardOptionErrors(t *testing.T) {
+	for _, opts := range []map[string]any{
+		{"sensitivity": "paranoid"},
+		{"action": "block"},
+		{"allowed": []any{"x"}},
+	} {
+		if _, err := newLicenseGuard(opts); err == nil {
+			t.Errorf("newLicenseGuard(%v) succeeded, want error", opts)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package builtin
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+	"github.com/Fuabioo/hook-chain/internal/quota"
+)
+
+// Rule IDs reported by the LLM reviewer.
+const (
+	RuleLLMVerdict       = "llm-review/verdict"
+	RuleLLMLowConfidence = "llm-review/low-confidence"
+	RuleLLMQuota         = "llm-review/quota"
+)
+
+// LLM reviewer providers: the request and response shape they speak.
+const (
+	ProviderOpenAI    = "openai"    // chat completions; also Ollama, vLLM, LiteLLM, and other compatible servers
+	ProviderAnthropic = "anthropic" // messages API
+)
+
+// LLM reviewer defaults.
+const (
+	defaultLLMTimeout       = 10 * time.Second
+	defaultLLMCacheTTL      = time.Hour
+	defaultLLMMaxTokens     = 300
+	defaultLLMMinConfidence = 0.5
+)
+
+var llmEndpoints = map[string]string{
+	ProviderOpenAI:    "https://api.openai.com/v1/chat/completions",
+	ProviderAnthropic: "https://api.anthropic.com/v1/messages",
+}
+
+var llmKeyEnvs = map[string]string{
+	ProviderOpenAI:    "OPENAI_API_KEY",
+	ProviderAnthropic: "ANTHROPIC_API_KEY",
+}
+
+// llmSystemPrompt fixes the reply format, so a custom prompt only has to
+// describe what to review.
+const llmSystemPrompt = `You review tool calls made by an autonomous coding agent before they run. Judge how risky the call is: could it destroy or corrupt data, leak secrets or private code, contact untrusted hosts, or weaken the security of the system or the project?
+Reply with only a JSON object, no other text:
+{"verdict": "safe" | "risky" | "dangerous", "confidence": <number from 0 to 1>, "reason": "<one short sentence>"}`
+
+// defaultLLMPrompt is the review request when options set no prompt.
+const defaultLLMPrompt = `Tool: {{.Tool}}
+Working directory: {{.CWD}}
+Tool input:
+{{.Input}}`
+
+// llmVerdicts are the verdicts the model may return, with their default
+// actions.
+var llmVerdicts = map[string]string{"safe": "pass", "risky": "ask", "dangerous": "deny"}
+
+type llmQuotaOptions struct {
+	Max        int    `json:"max"`
+	Per        string `json:"per"`
+	OnExceeded string `json:"on_exceeded"` // "deny" (default) | "ask" | "skip"
+}
+
+type llmReviewOptions struct {
+	Provider      string            `json:"provider"`       // "openai" (default) | "anthropic"
+	Endpoint      string            `json:"endpoint"`       // default: the provider's public API
+	Model         string            `json:"model"`          // required
+	APIKeyEnv     string            `json:"api_key_env"`    // variable holding the API key (default: OPENAI_API_KEY or ANTHROPIC_API_KEY)
+	Prompt        string            `json:"prompt"`         // text/template with .Tool, .Event, .CWD, .Command, .FilePath, .Input
+	Timeout       string            `json:"timeout"`        // per request (default: 10s)
+	MaxTokens     int               `json:"max_tokens"`     // reply limit (default: 300)
+	CacheTTL      string            `json:"cache_ttl"`      // how long verdicts are reused (default: 1h; "0" disables)
+	CacheDir      string            `json:"cache_dir"`      // default: $XDG_CACHE_HOME/hook-chain/llm-review
+	Verdicts      map[string]string `json:"verdicts"`       // verdict → "pass" | "ask" | "deny"
+	MinConfidence *float64          `json:"min_confidence"` // below it, low_confidence applies (default: 0.5)
+	LowConfidence string            `json:"low_confidence"` // "pass" | "ask" (default) | "deny"
+	Quota         *llmQuotaOptions  `json:"quota"`          // model requests allowed per window; cache hits are free
+}
+
+// llmReview asks a language model for a risk verdict on each tool call and
+// maps the verdict and its confidence to pass, ask, or deny. Verdicts are
+// cached by request, and requests can be capped with a quota shared by
+// every session on the machine. A request that fails or a reply that cannot
+// be read is an error, so the hook's on_error policy applies.
+type llmReview struct {
+	provider      string
+	endpoint      string
+	model         string
+	keyEnv        string
+	prompt        *template.Template
+	timeout       time.Duration
+	maxTokens     int
+	cacheTTL      time.Duration
+	cacheDir      string
+	actions       map[string]string
+	minConfidence float64
+	lowConfidence string
+	quota         *config.QuotaConfig
+}
+
+func newLLMReview(options map[string]any) (Builtin, error) {
+	var opts llmReviewOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	r := &llmReview{
+		provider:      cmp.Or(opts.Provider, ProviderOpenAI),
+		model:         opts.Model,
+		maxTokens:     cmp.Or(opts.MaxTokens, defaultLLMMaxTokens),
+		minConfidence: defaultLLMMinConfidence,
+		lowConfidence: cmp.Or(opts.LowConfidence, "ask"),
+		actions:       map[string]string{},
+	}
+	endpoint, ok := llmEndpoints[r.provider]
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not %q or %q", r.provider, ProviderOpenAI, ProviderAnthropic)
+	}
+	r.endpoint = cmp.Or(opts.Endpoint, endpoint)
+	if err := checkLLMEndpoint(r.endpoint); err != nil {
+		return nil, err
+	}
+	r.keyEnv = cmp.Or(opts.APIKeyEnv, llmKeyEnvs[r.provider])
+	if r.model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	var err error
+	if r.prompt, err = template.New("prompt").Option("missingkey=error").Parse(cmp.Or(opts.Prompt, defaultLLMPrompt)); err != nil {
+		return nil, fmt.Errorf("prompt: %w", err)
+	}
+	if r.timeout, err = optionDuration(opts.Timeout, defaultLLMTimeout); err != nil {
+		return nil, fmt.Errorf("timeout: %w", err)
+	}
+	if r.timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	if r.cacheTTL, err = optionDuration(opts.CacheTTL, defaultLLMCacheTTL); err != nil {
+		return nil, fmt.Errorf("cache_ttl: %w", err)
+	}
+	r.cacheDir = pathutil.Expand(opts.CacheDir)
+	if r.cacheDir == "" {
+		r.cacheDir = defaultLLMCacheDir()
+	}
+
+	for verdict, action := range llmVerdicts {
+		r.actions[verdict] = action
+	}
+	for verdict, action := range opts.Verdicts {
+		if _, ok := llmVerdicts[verdict]; !ok {
+			return nil, fmt.Errorf("verdicts: unknown verdict %q (want safe, risky, or dangerous)", verdict)
+		}
+		if !validReviewAction(action) {
+			return nil, fmt.Errorf("verdicts: %s: action %q is not \"pass\", \"ask\", or \"deny\"", verdict, action)
+		}
+		r.actions[verdict] = action
+	}
+	if c := opts.MinConfidence; c != nil {
+		if *c < 0 || *c > 1 {
+			return nil, fmt.Errorf("min_confidence %v is not between 0 and 1", *c)
+		}
+		r.minConfidence = *c
+	}
+	if !validReviewAction(r.lowConfidence) {
+		return nil, fmt.Errorf("low_confidence %q is not \"pass\", \"ask\", or \"deny\"", r.lowConfidence)
+	}
+
+	if q := opts.Quota; q != nil {
+		per, err := time.ParseDuration(q.Per)
+		if err != nil {
+			return nil, fmt.Errorf("quota: per: %w", err)
+		}
+		r.quota = &config.QuotaConfig{Max: q.Max, Per: per, OnExceeded: q.OnExceeded}
+		if err := r.quota.Validate(); err != nil {
+			return nil, fmt.Errorf("quota: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func validReviewAction(a string) bool {
+	return a == "pass" || a == "ask" || a == "deny"
+}
+
+// optionDuration parses a duration option, returning def when it is unset.
+func optionDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// defaultLLMCacheDir is llm-review under $XDG_CACHE_HOME/hook-chain
+// (default ~/.cache/hook-chain).
+func defaultLLMCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "hook-chain", "llm-review")
+}
+
+// llmVerdict is the model's reply.
+type llmVerdict struct {
+	Verdict    string  `json:"verdict"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// Check implements Builtin.
+func (r *llmReview) Check(input hook.Input) (*hook.Output, error) {
+	return r.CheckContext(context.Background(), input)
+}
+
+// CheckContext implements contextBuiltin. Calls without a tool are let
+// through.
+func (r *llmReview) CheckContext(ctx context.Context, input hook.Input) (*hook.Output, error) {
+	if input.ToolName == "" {
+		return nil, nil
+	}
+	prompt, err := r.render(input)
+	if err != nil {
+		return nil, err
+	}
+	key := r.cacheKey(prompt)
+	v, cached := r.cached(key)
+	if !cached {
+		if r.quota != nil {
+			out, over, err := r.takeQuota()
+			if err != nil || over {
+				return out, err
+			}
+		}
+		if v, err = r.ask(ctx, prompt); err != nil {
+			return nil, err
+		}
+		r.store(key, v)
+	}
+
+	action, ok := r.actions[v.Verdict]
+	if !ok {
+		return nil, fmt.Errorf("model %s replied with unknown verdict %q", r.model, v.Verdict)
+	}
+	rule := RuleLLMVerdict
+	if v.Confidence < r.minConfidence {
+		action, rule = r.lowConfidence, RuleLLMLowConfidence
+	}
+	if action == "pass" {
+		return nil, nil
+	}
+	reason := fmt.Sprintf("hook-chain: LLM review (%s) rated this call %s (confidence %.2f)", r.model, v.Verdict, v.Confidence)
+	if v.Reason != "" {
+		reason += ": " + v.Reason
+	}
+	return decision(action, rule, reason, map[string]any{
+		"model": r.model, "verdict": v.Verdict, "confidence": v.Confidence, "cached": cached,
+	}), nil
+}
+
+// render executes the prompt template for input.
+func (r *llmReview) render(input hook.Input) (string, error) {
+	var ti struct {
+		Command  string `json:"command"`
+		FilePath string `json:"file_path"`
+	}
+	_ = json.Unmarshal(input.ToolInput, &ti)
+	pretty := string(input.ToolInput)
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, input.ToolInput, "", "  "); err == nil {
+		pretty = buf.String()
+	}
+	data := map[string]string{
+		"Tool": input.ToolName, "Event": input.HookEventName, "CWD": input.CWD,
+		"Command": ti.Command, "FilePath": ti.FilePath, "Input": pretty,
+	}
+	var out strings.Builder
+	if err := r.prompt.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render prompt: %w", err)
+	}
+	return out.String(), nil
+}
+
+// takeQuota records a model request against the quota. Over it, it returns
+// the on_exceeded decision (nil for skip) and over true. A quota store that
+// cannot be used is an error, so the hook's on_error applies.
+func (r *llmReview) takeQuota() (out *hook.Output, over bool, err error) {
+	s, err := quota.Open(quota.DefaultPath())
+	if err != nil {
+		return nil, false, fmt.Errorf("quota: %w", err)
+	}
+	defer func() { _ = s.Close() }()
+	ok, err := s.Take("llm-review:"+r.endpoint+":"+r.model, r.quota.Max, r.quota.Per)
+	if err != nil {
+		return nil, false, fmt.Errorf("quota: %w", err)
+	}
+	if ok {
+		return nil, false, nil
+	}
+	action := r.quota.EffectiveOnExceeded()
+	if action == config.QuotaSkip {
+		return nil, true, nil
+	}
+	return decision(action, RuleLLMQuota,
+		fmt.Sprintf("hook-chain: LLM review is over its quota (%s) and could not review this call", r.quota.Label()),
+		map[string]any{"model": r.model, "quota": r.quota.Label()}), true, nil
+}
+
+// checkLLMEndpoint rejects endpoints the API key could leak to: anything
+// but https://, except plain http:// to a loopback address.
+func checkLLMEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("endpoint: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("endpoint %q must use https:// (http:// is only allowed for loopback addresses)", endpoint)
+	}
+	return fmt.Errorf("endpoint %q is not an https:// URL", endpoint)
+}
+
+// ask sends the review request and parses the verdict from the reply.
+func (r *llmReview) ask(ctx context.Context, prompt string) (llmVerdict, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var body any
+	switch r.provider {
+	case ProviderAnthropic:
+		body = map[string]any{
+			"model": r.model, "max_tokens": r.maxTokens, "temperature": 0, "system": llmSystemPrompt,
+			"messages": []map[string]string{{"role": "user", "content": prompt}},
+		}
+	default:
+		body = map[string]any{
+			"model": r.model, "max_tokens": r.maxTokens, "temperature": 0,
+			"messages": []map[string]string{{"role": "system", "content": llmSystemPrompt}, {"role": "user", "content": prompt}},
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return llmVerdict{}, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return llmVerdict{}, fmt.Errorf("request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	key := os.Getenv(r.keyEnv)
+	switch {
+	case r.provider == ProviderAnthropic:
+		req.Header.Set("anthropic-version", "2023-06-01")
+		if key != "" {
+			req.Header.Set("x-api-key", key)
+		}
+	case key != "":
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return llmVerdict{}, fmt.Errorf("request %s: %w", r.endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return llmVerdict{}, fmt.Errorf("read reply: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return llmVerdict{}, fmt.Errorf("%s returned %s: %s", r.endpoint, resp.Status, truncate(string(raw), 200))
+	}
+
+	var reply struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		return llmVerdict{}, fmt.Errorf("parse reply: %w", err)
+	}
+	var text string
+	switch {
+	case len(reply.Choices) > 0:
+		text = reply.Choices[0].Message.Content
+	default:
+		for _, c := range reply.Content {
+			if c.Type == "text" {
+				text += c.Text
+			}
+		}
+	}
+	return parseVerdict(text)
+}
+
+// parseVerdict reads the JSON object in the model's reply, tolerating text
+// or a code fence around it.
+func parseVerdict(text string) (llmVerdict, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return llmVerdict{}, fmt.Errorf("reply has no JSON verdict: %q", truncate(text, 200))
+	}
+	var v llmVerdict
+	if err := json.Unmarshal([]byte(text[start:end+1]), &v); err != nil {
+		return llmVerdict{}, fmt.Errorf("parse verdict %q: %w", truncate(text, 200), err)
+	}
+	v.Verdict = strings.ToLower(strings.TrimSpace(v.Verdict))
+	return v, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
+
+// cacheKey identifies a review request: the same prompt to the same model
+// gets the same verdict.
+func (r *llmReview) cacheKey(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{r.provider, r.endpoint, r.model, llmSystemPrompt, prompt}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// cached returns the verdict stored under key if it is younger than the
+// cache TTL.
+func (r *llmReview) cached(key string) (llmVerdict, bool) {
+	if r.cacheTTL <= 0 {
+		return llmVerdict{}, false
+	}
+	path := filepath.Join(r.cacheDir, key+".json")
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > r.cacheTTL {
+		return llmVerdict{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return llmVerdict{}, false
+	}
+	var v llmVerdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		return llmVerdict{}, false
+	}
+	return v, true
+}
+
+// store caches v under key. Caching is best-effort: a verdict that cannot
+// be written is simply asked for again next time.
+func (r *llmReview) store(key string, v llmVerdict) {
+	if r.cacheTTL <= 0 {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(r.cacheDir, 0o700); err != nil {
+		return
+	}
+	// Write and rename, so concurrent sessions never read half a file.
+	tmp, err := os.CreateTemp(r.cacheDir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), filepath.Join(r.cacheDir, key+".json")) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
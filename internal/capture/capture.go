@@ -0,0 +1,206 @@
+// Package capture writes a debug bundle for one hook-chain invocation: the
+// raw input, a snapshot of the config in effect, every hook's stdin, stdout,
+// and stderr, per-hook timings, and the final output. It is enabled by
+// pointing HOOK_CHAIN_CAPTURE_DIR at a directory, which then gets one
+// timestamped subdirectory per run.
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+// EnvVar names the directory bundles are written to.
+const EnvVar = "HOOK_CHAIN_CAPTURE_DIR"
+
+// Bundle file names.
+const (
+	InputFile   = "input.json"
+	ConfigFile  = "config.yaml"
+	OutputFile  = "output.json"
+	SummaryFile = "summary.json"
+)
+
+// Summary is the bundle's summary.json: the run's timing and outcome and
+// one entry per hook run, in order.
+type Summary struct {
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"`
+	Hooks      []Hook    `json:"hooks"`
+}
+
+// Hook records one hook run. Its stdin, stdout, and stderr are in the files
+// named Files + ".stdin", ".stdout", and ".stderr".
+type Hook struct {
+	Files      string    `json:"files"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Command    string    `json:"command,omitempty"`
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Bundle is the debug bundle of one invocation. Its methods are no-ops on
+// a nil *Bundle, so callers need not check whether capture is enabled.
+type Bundle struct {
+	dir    string
+	output bytes.Buffer
+
+	mu      sync.Mutex
+	summary Summary
+}
+
+// New creates a bundle directory under parent, named after the current
+// time. It returns a nil *Bundle when parent is empty.
+func New(parent string) (*Bundle, error) {
+	if parent == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(parent, 0o700); err != nil {
+		return nil, fmt.Errorf("capture: create %s: %w", parent, err)
+	}
+	now := time.Now()
+	dir, err := os.MkdirTemp(parent, now.UTC().Format("20060102T150405.000Z")+"-")
+	if err != nil {
+		return nil, fmt.Errorf("capture: create bundle: %w", err)
+	}
+	return &Bundle{dir: dir, summary: Summary{Started: now, Hooks: []Hook{}}}, nil
+}
+
+// Dir returns the bundle directory.
+func (b *Bundle) Dir() string {
+	if b == nil {
+		return ""
+	}
+	return b.dir
+}
+
+// WriteFile writes a file into the bundle.
+func (b *Bundle) WriteFile(name string, data []byte) error {
+	if b == nil {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(b.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("capture: write %s: %w", name, err)
+	}
+	return nil
+}
+
+// WriteConfig writes the config in effect, with the project config already
+// layered over the user's.
+func (b *Bundle) WriteConfig(cfg config.Config) error {
+	if b == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("capture: marshal config: %w", err)
+	}
+	return b.WriteFile(ConfigFile, data)
+}
+
+// Output returns a writer for hook-chain's own stdout; what is written to it
+// is saved as the final output by Finish.
+func (b *Bundle) Output() io.Writer {
+	if b == nil {
+		return io.Discard
+	}
+	return &b.output
+}
+
+// Finish writes the final output and summary.json.
+func (b *Bundle) Finish(exitCode int) error {
+	if b == nil {
+		return nil
+	}
+	if err := b.WriteFile(OutputFile, b.output.Bytes()); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.summary.DurationMs = time.Since(b.summary.Started).Milliseconds()
+	b.summary.ExitCode = exitCode
+	data, err := json.MarshalIndent(b.summary, "", "  ")
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("capture: marshal summary: %w", err)
+	}
+	return b.WriteFile(SummaryFile, data)
+}
+
+// Runner wraps next so each hook run is recorded in the bundle. A nil
+// *Bundle returns next unchanged.
+func (b *Bundle) Runner(next runner.Runner) runner.Runner {
+	if b == nil {
+		return next
+	}
+	return captureRunner{b: b, next: next}
+}
+
+type captureRunner struct {
+	b    *Bundle
+	next runner.Runner
+}
+
+// Run runs the hook and records its streams and timing. Failing to write
+// the bundle never fails the hook.
+func (c captureRunner) Run(ctx context.Context, hook config.HookEntry, input []byte) (runner.Result, error) {
+	c.b.mu.Lock()
+	files := fmt.Sprintf("%02d-%s", len(c.b.summary.Hooks)+1, fileName(hook.Name))
+	c.b.summary.Hooks = append(c.b.summary.Hooks, Hook{Files: files, Name: hook.Name})
+	i := len(c.b.summary.Hooks) - 1
+	c.b.mu.Unlock()
+
+	start := time.Now()
+	res, err := c.next.Run(ctx, hook, input)
+	rec := Hook{
+		Files:      files,
+		Name:       hook.Name,
+		Type:       hook.EffectiveType(),
+		Command:    hook.Command,
+		Started:    start,
+		DurationMs: time.Since(start).Milliseconds(),
+		ExitCode:   res.ExitCode,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	_ = c.b.WriteFile(files+".stdin", input)
+	_ = c.b.WriteFile(files+".stdout", res.Stdout)
+	_ = c.b.WriteFile(files+".stderr", []byte(res.Stderr))
+
+	c.b.mu.Lock()
+	c.b.summary.Hooks[i] = rec
+	c.b.mu.Unlock()
+	return res, err
+}
+
+// fileName turns a hook name into a safe file name.
+func fileName(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if clean == "" || strings.Trim(clean, ".") == "" {
+		clean = "hook"
+	}
+	return clean
+}
@@ -0,0 +1,118 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+type fakeRunner struct {
+	res runner.Result
+	err error
+}
+
+func (f fakeRunner) Run(context.Context, config.HookEntry, []byte) (runner.Result, error) {
+	return f.res, f.err
+}
+
+func TestNewDisabled(t *testing.T) {
+	b, err := New("")
+	if err != nil || b != nil {
+		t.Fatalf("New(\"\") = %v, %v; want nil, nil", b, err)
+	}
+	if _, ok := b.Runner(fakeRunner{}).(fakeRunner); !ok {
+		t.Error("nil bundle wrapped the runner")
+	}
+	if err := b.WriteFile(InputFile, []byte("{}")); err != nil {
+		t.Errorf("WriteFile on nil bundle: %v", err)
+	}
+	if err := b.Finish(0); err != nil {
+		t.Errorf("Finish on nil bundle: %v", err)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	parent := filepath.Join(t.TempDir(), "captures")
+	b, err := New(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(b.Dir()) != parent {
+		t.Fatalf("Dir() = %s, want a child of %s", b.Dir(), parent)
+	}
+	if err := b.WriteConfig(config.Config{Chains: []config.ChainEntry{{Events: []string{"PreToolUse"}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := b.Runner(fakeRunner{res: runner.Result{ExitCode: 2, Stdout: []byte("out"), Stderr: "boom"}})
+	if _, err := r.Run(context.Background(), config.HookEntry{Name: "guard/rm", Command: "guard"}, []byte("in")); err != nil {
+		t.Fatal(err)
+	}
+	r = b.Runner(fakeRunner{err: errors.New("not found")})
+	if _, err := r.Run(context.Background(), config.HookEntry{Name: "fmt", Command: "fmt"}, []byte("in2")); err == nil {
+		t.Fatal("runner error was swallowed")
+	}
+	_, _ = b.Output().Write([]byte(`{"decision":"block"}`))
+	if err := b.Finish(2); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(b.Dir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	for name, want := range map[string]string{
+		"01-guard_rm.stdin":  "in",
+		"01-guard_rm.stdout": "out",
+		"01-guard_rm.stderr": "boom",
+		"02-fmt.stdin":       "in2",
+		OutputFile:           `{"decision":"block"}`,
+	} {
+		if got := read(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if read(ConfigFile) == "" {
+		t.Error("config snapshot is empty")
+	}
+
+	var s Summary
+	if err := json.Unmarshal([]byte(read(SummaryFile)), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ExitCode != 2 || len(s.Hooks) != 2 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if h := s.Hooks[0]; h.Name != "guard/rm" || h.Type != config.HookTypeProcess || h.ExitCode != 2 || h.Error != "" {
+		t.Errorf("hook 1 = %+v", h)
+	}
+	if h := s.Hooks[1]; h.Files != "02-fmt" || h.Error != "not found" {
+		t.Errorf("hook 2 = %+v", h)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := map[string]string{
+		"lint":       "lint",
+		"guard/rm":   "guard_rm",
+		"a b.sh":     "a_b.sh",
+		"..":         "hook",
+		"":           "hook",
+		"über-check": "_ber-check",
+	}
+	for in, want := range tests {
+		if got := fileName(in); got != want {
+			t.Errorf("fileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
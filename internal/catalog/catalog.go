@@ -0,0 +1,343 @@
+// Package catalog exports the configured chains in a stable, versioned
+// schema (JSON or YAML) for documentation and policy catalog generators.
+// Unlike the config file format, the schema does not change shape as
+// config aliases come and go: durations are strings ("30s"), field names
+// are snake_case in both formats, and fields are only ever added within a
+// SchemaVersion.
+package catalog
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// SchemaVersion is the version of the exported schema. It changes only when
+// a field is renamed, removed, or changes meaning.
+const SchemaVersion = 1
+
+// Output formats accepted by Write.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Catalog is the exported chain set.
+type Catalog struct {
+	SchemaVersion int     `json:"schema_version" yaml:"schema_version"`
+	Resolved      bool    `json:"resolved" yaml:"resolved"`                         // defaults are filled in
+	Resolution    string  `json:"resolution,omitempty" yaml:"resolution,omitempty"` // "best" or "all"
+	Profile       string  `json:"profile,omitempty" yaml:"profile,omitempty"`       // active profile
+	Chains        []Chain `json:"chains" yaml:"chains"`
+}
+
+// Chain is one configured chain.
+type Chain struct {
+	ID              int               `json:"id" yaml:"id"` // 1-based position, as validate numbers chains
+	Source          string            `json:"source,omitempty" yaml:"source,omitempty"`
+	Profile         string            `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Events          []string          `json:"events" yaml:"events"`
+	Tools           []string          `json:"tools,omitempty" yaml:"tools,omitempty"`
+	ToolsExclude    []string          `json:"tools_exclude,omitempty" yaml:"tools_exclude,omitempty"`
+	MCPServer       string            `json:"mcp_server,omitempty" yaml:"mcp_server,omitempty"`
+	MCPTool         string            `json:"mcp_tool,omitempty" yaml:"mcp_tool,omitempty"`
+	Priority        int               `json:"priority" yaml:"priority"`
+	Disabled        bool              `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	ActiveHours     string            `json:"active_hours,omitempty" yaml:"active_hours,omitempty"`
+	ActiveDays      []string          `json:"active_days,omitempty" yaml:"active_days,omitempty"`
+	Match           *Match            `json:"match,omitempty" yaml:"match,omitempty"`
+	LatencyBudget   string            `json:"latency_budget,omitempty" yaml:"latency_budget,omitempty"`
+	Env             []string          `json:"env,omitempty" yaml:"env,omitempty"`
+	Severity        map[string]string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	ProtectedFields []string          `json:"protected_fields,omitempty" yaml:"protected_fields,omitempty"`
+	OnProtected     string            `json:"on_protected,omitempty" yaml:"on_protected,omitempty"`
+	MaxDuration     string            `json:"max_duration,omitempty" yaml:"max_duration,omitempty"`
+	OnMaxDuration   string            `json:"on_max_duration,omitempty" yaml:"on_max_duration,omitempty"`
+	Quota           *Quota            `json:"quota,omitempty" yaml:"quota,omitempty"`
+	Risk            *Risk             `json:"risk,omitempty" yaml:"risk,omitempty"`
+	Hooks           []Hook            `json:"hooks" yaml:"hooks"`
+	Finally         []Hook            `json:"finally,omitempty" yaml:"finally,omitempty"`
+	OnDeny          []Action          `json:"on_deny,omitempty" yaml:"on_deny,omitempty"`
+	OnDenyAsk       bool              `json:"on_deny_ask,omitempty" yaml:"on_deny_ask,omitempty"` // on_deny: ask
+	OnAllow         []Action          `json:"on_allow,omitempty" yaml:"on_allow,omitempty"`
+}
+
+// Match is a chain's match block.
+type Match struct {
+	CommandRegex   string   `json:"command_regex,omitempty" yaml:"command_regex,omitempty"`
+	FilePathGlob   string   `json:"file_path_glob,omitempty" yaml:"file_path_glob,omitempty"`
+	PermissionMode []string `json:"permission_mode,omitempty" yaml:"permission_mode,omitempty"`
+	CWDGlob        string   `json:"cwd_glob,omitempty" yaml:"cwd_glob,omitempty"`
+}
+
+// Hook is one hook of a chain.
+type Hook struct {
+	Name          string           `json:"name" yaml:"name"`
+	Use           string           `json:"use,omitempty" yaml:"use,omitempty"` // the hook_defs entry it was expanded from
+	Type          string           `json:"type,omitempty" yaml:"type,omitempty"`
+	Command       string           `json:"command,omitempty" yaml:"command,omitempty"`
+	Args          []string         `json:"args,omitempty" yaml:"args,omitempty"`
+	URL           string           `json:"url,omitempty" yaml:"url,omitempty"`
+	Builtin       string           `json:"builtin,omitempty" yaml:"builtin,omitempty"`
+	Options       map[string]any   `json:"options,omitempty" yaml:"options,omitempty"`
+	Variants      []string         `json:"variants,omitempty" yaml:"variants,omitempty"`
+	Timeout       string           `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Env           []string         `json:"env,omitempty" yaml:"env,omitempty"`
+	EnvFile       string           `json:"env_file,omitempty" yaml:"env_file,omitempty"`
+	Workdir       string           `json:"workdir,omitempty" yaml:"workdir,omitempty"`
+	OnError       string           `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+	Retries       int              `json:"retries,omitempty" yaml:"retries,omitempty"`
+	RetryDelay    string           `json:"retry_delay,omitempty" yaml:"retry_delay,omitempty"`
+	ExitCodes     map[int]ExitCode `json:"exit_codes,omitempty" yaml:"exit_codes,omitempty"`
+	Output        string           `json:"output,omitempty" yaml:"output,omitempty"`
+	Severity      string           `json:"severity,omitempty" yaml:"severity,omitempty"`
+	ReportOnly    bool             `json:"report_only,omitempty" yaml:"report_only,omitempty"`
+	Async         bool             `json:"async,omitempty" yaml:"async,omitempty"`
+	Disabled      bool             `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Rollout       string           `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+	Priority      string           `json:"priority,omitempty" yaml:"priority,omitempty"`
+	LatencyBudget string           `json:"latency_budget,omitempty" yaml:"latency_budget,omitempty"`
+	OverBudget    string           `json:"over_budget,omitempty" yaml:"over_budget,omitempty"`
+	Quota         *Quota           `json:"quota,omitempty" yaml:"quota,omitempty"`
+}
+
+// Quota limits how often a hook or chain runs.
+type Quota struct {
+	Max        int    `json:"max" yaml:"max"`
+	Per        string `json:"per" yaml:"per"`
+	OnExceeded string `json:"on_exceeded,omitempty" yaml:"on_exceeded,omitempty"`
+}
+
+// Risk maps a chain's combined riskScore to a decision.
+type Risk struct {
+	Aggregate string   `json:"aggregate,omitempty" yaml:"aggregate,omitempty"`
+	Ask       *float64 `json:"ask,omitempty" yaml:"ask,omitempty"`
+	Deny      *float64 `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// ExitCode maps a hook exit code to a decision.
+type ExitCode struct {
+	Decision string `json:"decision" yaml:"decision"`
+	Reason   string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Action is an on_deny or on_allow action.
+type Action struct {
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Command string   `json:"command,omitempty" yaml:"command,omitempty"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Webhook string   `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Timeout string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Env     []string `json:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// Build exports the chains of cfg, which should be the Effective config as
+// Load returns it: the project config layered in, the active profile
+// applied, and hook_defs expanded. Settings are exported as written, unless
+// resolved is set: then every default hook-chain applies at run time is
+// filled in, such as each hook's type, timeout, on_error, and environment
+// (defaults.env, then the chain's env, then its own).
+func Build(cfg config.Config, resolved bool) Catalog {
+	cat := Catalog{
+		SchemaVersion: SchemaVersion,
+		Resolved:      resolved,
+		Resolution:    cfg.Resolution,
+		Profile:       cfg.ActiveProfile(),
+		Chains:        make([]Chain, 0, len(cfg.Chains)),
+	}
+	if resolved {
+		cat.Resolution = cmp.Or(cfg.Resolution, config.ResolutionBest)
+	}
+	for i, c := range cfg.Chains {
+		cat.Chains = append(cat.Chains, buildChain(cfg, i+1, c, resolved))
+	}
+	return cat
+}
+
+func buildChain(cfg config.Config, id int, c config.ChainEntry, resolved bool) Chain {
+	out := Chain{
+		ID:              id,
+		Source:          c.Source,
+		Profile:         c.Profile,
+		Events:          c.EventNames(),
+		Tools:           c.Tools,
+		ToolsExclude:    c.ToolsExclude,
+		MCPServer:       c.MCPServer,
+		MCPTool:         c.MCPTool,
+		Priority:        c.Priority,
+		Disabled:        c.Disabled,
+		ActiveHours:     c.ActiveHours,
+		ActiveDays:      c.ActiveDays,
+		LatencyBudget:   duration(c.LatencyBudget),
+		Env:             c.Env,
+		Severity:        c.Severity,
+		ProtectedFields: c.ProtectedFields,
+		OnProtected:     c.OnProtected,
+		MaxDuration:     duration(c.MaxDuration),
+		OnMaxDuration:   c.OnMaxDuration,
+		Quota:           buildQuota(c.Quota, resolved),
+		Risk:            buildRisk(c.Risk, resolved),
+	}
+	if m := c.Match; m != nil {
+		out.Match = &Match{CommandRegex: m.CommandRegex, FilePathGlob: m.FilePathGlob, PermissionMode: m.PermissionMode, CWDGlob: m.CWDGlob}
+	}
+	if resolved {
+		// Tools as ResolveChain sees them: "!name" entries moved to the
+		// exclusions, and "*" when only exclusions are listed.
+		out.Tools, out.ToolsExclude = c.ToolPatterns()
+		if c.MCPServer != "" {
+			out.MCPTool = cmp.Or(c.MCPTool, "*")
+		}
+		if len(c.ProtectedFields) > 0 {
+			out.OnProtected = c.EffectiveOnProtected()
+		}
+		if c.MaxDuration > 0 {
+			out.OnMaxDuration = c.EffectiveOnMaxDuration()
+		}
+	}
+	out.Hooks = buildHooks(cfg, c, c.Hooks, resolved)
+	out.Finally = buildHooks(cfg, c, c.Finally, resolved)
+	out.OnDeny = buildActions(cfg, c, "on_deny", c.OnDeny.Actions, resolved)
+	out.OnDenyAsk = c.OnDeny.Ask
+	out.OnAllow = buildActions(cfg, c, "on_allow", c.OnAllow, resolved)
+	return out
+}
+
+func buildHooks(cfg config.Config, c config.ChainEntry, hooks []config.HookEntry, resolved bool) []Hook {
+	if len(hooks) == 0 {
+		return nil
+	}
+	out := make([]Hook, 0, len(hooks))
+	for _, h := range hooks {
+		eh := Hook{
+			Name:          h.Name,
+			Use:           h.Use,
+			Type:          h.Type,
+			Command:       h.Command,
+			Args:          h.Args,
+			URL:           h.URL,
+			Builtin:       h.Builtin,
+			Options:       h.Options,
+			Variants:      h.Variants,
+			Timeout:       duration(h.Timeout),
+			Env:           h.Env,
+			EnvFile:       h.EnvFile,
+			Workdir:       h.EffectiveWorkdir(),
+			OnError:       h.OnError,
+			Retries:       h.Retries,
+			RetryDelay:    duration(h.RetryDelay),
+			Output:        h.Output,
+			Severity:      h.Severity,
+			ReportOnly:    h.ReportOnly,
+			Async:         h.Async,
+			Disabled:      h.Disabled,
+			Rollout:       h.Rollout,
+			Priority:      h.Priority,
+			LatencyBudget: duration(h.LatencyBudget),
+			OverBudget:    h.OverBudget,
+			Quota:         buildQuota(h.Quota, resolved),
+		}
+		if len(h.ExitCodes) > 0 {
+			eh.ExitCodes = make(map[int]ExitCode, len(h.ExitCodes))
+			for code, rule := range h.ExitCodes {
+				eh.ExitCodes[code] = ExitCode{Decision: rule.Decision, Reason: rule.Reason}
+			}
+		}
+		if resolved {
+			eh.Type = h.EffectiveType()
+			eh.Timeout = duration(cfg.HookTimeout(h))
+			eh.Env = cfg.HookEnv(c, h)
+			eh.OnError = h.EffectiveOnError()
+			eh.Retries = h.EffectiveRetries()
+			if eh.Retries > 0 {
+				eh.RetryDelay = duration(h.RetryBackoff(1))
+			}
+			eh.Output = cmp.Or(h.Output, config.OutputJSON)
+			eh.Priority = cmp.Or(h.Priority, config.PriorityNormal)
+			if h.LatencyBudget > 0 {
+				eh.OverBudget = h.EffectiveOverBudget()
+			}
+		}
+		out = append(out, eh)
+	}
+	return out
+}
+
+func buildActions(cfg config.Config, c config.ChainEntry, key string, actions []config.ChainAction, resolved bool) []Action {
+	if len(actions) == 0 {
+		return nil
+	}
+	out := make([]Action, 0, len(actions))
+	for i, a := range actions {
+		ea := Action{Name: a.Name, Command: a.Command, Args: a.Args, Webhook: a.Webhook, Timeout: duration(a.Timeout), Env: a.Env}
+		if resolved {
+			h := a.Hook(key, i)
+			ea.Name = h.Name
+			ea.Timeout = duration(cmp.Or(h.Timeout, config.DefaultHookTimeout))
+			ea.Env = cfg.HookEnv(c, h)
+		}
+		out = append(out, ea)
+	}
+	return out
+}
+
+func buildQuota(q *config.QuotaConfig, resolved bool) *Quota {
+	if q == nil {
+		return nil
+	}
+	out := &Quota{Max: q.Max, Per: duration(q.Per), OnExceeded: q.OnExceeded}
+	if resolved {
+		out.OnExceeded = q.EffectiveOnExceeded()
+	}
+	return out
+}
+
+func buildRisk(r *config.RiskConfig, resolved bool) *Risk {
+	if r == nil {
+		return nil
+	}
+	out := &Risk{Aggregate: r.Aggregate, Ask: r.Ask, Deny: r.Deny}
+	if resolved {
+		out.Aggregate = r.EffectiveAggregate()
+	}
+	return out
+}
+
+// duration formats d like "30s", or "" when it is not set.
+func duration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// Write encodes cat to w in the given format. Map keys are sorted in both
+// formats, so the output of an unchanged config is byte for byte the same.
+func Write(w io.Writer, cat Catalog, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cat); err != nil {
+			return fmt.Errorf("catalog: write json: %w", err)
+		}
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(cat); err != nil {
+			return fmt.Errorf("catalog: write yaml: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("catalog: write yaml: %w", err)
+		}
+	default:
+		return fmt.Errorf("catalog: unknown format %q (want %s or %s)", format, FormatJSON, FormatYAML)
+	}
+	return nil
+}
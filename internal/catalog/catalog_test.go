@@ -0,0 +1,129 @@
+package catalog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+var testConfig = config.Config{
+	Defaults: config.DefaultsConfig{Timeout: 10 * time.Second, Env: []string{"TEAM=sec"}},
+	Chains: []config.ChainEntry{
+		{
+			Event:           "PreToolUse",
+			Tools:           []string{"Bash", "!Read"},
+			Env:             []string{"CHAIN=1"},
+			ProtectedFields: []string{"command"},
+			Hooks: []config.HookEntry{
+				{Name: "guard", Use: "guard", Command: "~/bin/guard", Timeout: 5 * time.Second},
+				{Name: "lint", Command: "lint", OnError: "retry", ExitCodes: map[int]config.ExitCodeRule{3: {Decision: "ask"}}},
+			},
+			OnDeny: config.DenyActions{Actions: []config.ChainAction{{Webhook: "https://example.com/deny"}}},
+		},
+		{Event: "Stop", Hooks: []config.HookEntry{{Name: "cmds", Builtin: "command-guard"}}},
+	},
+}
+
+func TestBuildAsWritten(t *testing.T) {
+	cat := Build(testConfig, false)
+	if cat.SchemaVersion != SchemaVersion || cat.Resolved || cat.Resolution != "" {
+		t.Errorf("header = %d/%v/%q, want as written", cat.SchemaVersion, cat.Resolved, cat.Resolution)
+	}
+	if len(cat.Chains) != 2 || cat.Chains[1].ID != 2 {
+		t.Fatalf("chains = %+v, want 2 numbered from 1", cat.Chains)
+	}
+	c := cat.Chains[0]
+	if strings.Join(c.Tools, ",") != "Bash,!Read" || c.OnProtected != "" {
+		t.Errorf("chain = %+v, want settings as written", c)
+	}
+	lint := c.Hooks[1]
+	if lint.Type != "" || lint.Timeout != "" || lint.Retries != 0 || len(lint.Env) != 0 {
+		t.Errorf("lint = %+v, want no defaults filled in", lint)
+	}
+	if c.OnDeny[0].Name != "" || c.OnDeny[0].Timeout != "" {
+		t.Errorf("on_deny = %+v, want as written", c.OnDeny[0])
+	}
+}
+
+func TestBuildResolved(t *testing.T) {
+	cat := Build(testConfig, true)
+	if !cat.Resolved || cat.Resolution != config.ResolutionBest {
+		t.Errorf("header = %v/%q, want resolved best", cat.Resolved, cat.Resolution)
+	}
+	c := cat.Chains[0]
+	if strings.Join(c.Tools, ",") != "Bash" || strings.Join(c.ToolsExclude, ",") != "Read" {
+		t.Errorf("tools = %v exclude %v, want Bash exclude Read", c.Tools, c.ToolsExclude)
+	}
+	if c.OnProtected != config.ProtectedDeny {
+		t.Errorf("on_protected = %q, want deny", c.OnProtected)
+	}
+
+	tests := []struct {
+		hook                 Hook
+		typ, timeout, onErr  string
+		retries              int
+		retryDelay, priority string
+	}{
+		{c.Hooks[0], "process", "5s", "deny", 0, "", "normal"},
+		{c.Hooks[1], "process", "10s", "retry", 2, "200ms", "normal"},
+		{cat.Chains[1].Hooks[0], "builtin", "10s", "deny", 0, "", "normal"},
+	}
+	for _, tt := range tests {
+		h := tt.hook
+		if h.Type != tt.typ || h.Timeout != tt.timeout || h.OnError != tt.onErr || h.Retries != tt.retries || h.RetryDelay != tt.retryDelay || h.Priority != tt.priority || h.Output != "json" {
+			t.Errorf("hook %s = %+v, want type=%s timeout=%s on_error=%s retries=%d retry_delay=%q", h.Name, h, tt.typ, tt.timeout, tt.onErr, tt.retries, tt.retryDelay)
+		}
+	}
+	if got := strings.Join(c.Hooks[0].Env, ","); got != "TEAM=sec,CHAIN=1" {
+		t.Errorf("env = %s, want defaults then chain", got)
+	}
+	if a := c.OnDeny[0]; a.Name != "on_deny[1]" || a.Timeout != "30s" {
+		t.Errorf("on_deny = %+v, want name on_deny[1] and timeout 30s", a)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cat := Build(testConfig, true)
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var a, b strings.Builder
+			if err := Write(&a, cat, format); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := Write(&b, Build(testConfig, true), format); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if a.String() != b.String() {
+				t.Errorf("output not stable:\n%s\n---\n%s", a.String(), b.String())
+			}
+
+			var back Catalog
+			var err error
+			if format == FormatJSON {
+				err = json.Unmarshal([]byte(a.String()), &back)
+			} else {
+				err = yaml.Unmarshal([]byte(a.String()), &back)
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(back.Chains) != 2 || back.Chains[0].Hooks[1].ExitCodes[3].Decision != "ask" {
+				t.Errorf("round trip = %+v", back)
+			}
+			for _, key := range []string{"schema_version", "tools_exclude", "retry_delay", "exit_codes"} {
+				if !strings.Contains(a.String(), key) {
+					t.Errorf("output lacks %s:\n%s", key, a.String())
+				}
+			}
+		})
+	}
+
+	if err := Write(&strings.Builder{}, cat, "toml"); err == nil {
+		t.Error("Write with unknown format succeeded")
+	}
+}
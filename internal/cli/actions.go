@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// chainResult is the "hook_chain" field passed to on_deny and on_allow
+// actions.
+type chainResult struct {
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	ChainID int64  `json:"chain_id,omitempty"` // audit log ID; absent when audit is disabled
+}
+
+// launchChainActions starts the chain's actions for outcome (see
+// config.ChainEntry.Actions) as detached async-run workers, so they never
+// delay the decision. Each receives the hook input plus a "hook_chain"
+// object with the outcome, reason, and audit chain ID. Failures to launch
+// are logged and never affect the decision.
+func launchChainActions(cfg config.Config, chain config.ChainEntry, input hook.Input, outcome, reason string, chainID int64, logger *slog.Logger) {
+	key, actions := chain.Actions(outcome)
+	if len(actions) == 0 {
+		return
+	}
+	result, err := json.Marshal(chainResult{Outcome: outcome, Reason: reason, ChainID: chainID})
+	if err != nil {
+		logger.Warn("cannot launch chain actions", "err", err)
+		return
+	}
+	payload, err := json.Marshal(input.WithField("hook_chain", result))
+	if err != nil {
+		logger.Warn("cannot launch chain actions", "err", err)
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Warn("cannot launch chain actions", "err", err)
+		return
+	}
+
+	hooks := make([]config.HookEntry, len(actions))
+	for i, a := range actions {
+		hooks[i] = a.Hook(key, i)
+	}
+	for _, h := range cfg.ApplyEnv(chain, hooks) {
+		// No audit path: the worker runs the action without recording it.
+		if err := startAsyncWorker(exe, asyncJob{Hook: h, Input: payload}); err != nil {
+			logger.Warn("failed to launch chain action", "action", h.Name, "err", err)
+		}
+	}
+}
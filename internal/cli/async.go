@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/pipeline"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+// asyncJob is handed to a detached `hook-chain async-run` worker on stdin.
+type asyncJob struct {
+	DBPath  string           `json:"db_path,omitempty"` // empty when audit is disabled
+	ChainID int64            `json:"chain_id,omitempty"`
+	Index   int              `json:"index"`
+	Hook    config.HookEntry `json:"hook"`
+	Input   json.RawMessage  `json:"input"`
+}
+
+// launchAsync starts one detached worker per async hook. The workers outlive
+// this process; failures to launch are logged and never affect the decision.
+func launchAsync(hooks []pipeline.AsyncHook, dbPath string, chainID int64, logger *slog.Logger) {
+	if len(hooks) == 0 {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Warn("cannot launch async hooks", "err", err)
+		return
+	}
+	for _, ah := range hooks {
+		job := asyncJob{DBPath: dbPath, ChainID: chainID, Index: ah.Index, Hook: ah.Hook, Input: ah.Input}
+		if err := startAsyncWorker(exe, job); err != nil {
+			logger.Warn("failed to launch async hook", "hook", ah.Hook.Name, "err", err)
+		}
+	}
+}
+
+func startAsyncWorker(exe string, job asyncJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal async job: %w", err)
+	}
+
+	cmd := exec.Command(exe, "async-run")
+	detach(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start worker: %w", err)
+	}
+	_, writeErr := stdin.Write(data)
+	closeErr := stdin.Close()
+	if err := cmd.Process.Release(); err != nil {
+		return fmt.Errorf("release worker: %w", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("write async job: %w", writeErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("close worker stdin: %w", closeErr)
+	}
+	return nil
+}
+
+func newAsyncRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "async-run",
+		Short:  "Run one async hook (internal; launched by the pipeline)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE:   runAsyncRun,
+	}
+}
+
+// runAsyncRun executes an async hook and records its result in place of the
+// "async" placeholder. Async hooks cannot decide: the result is pass or error.
+func runAsyncRun(cmd *cobra.Command, _ []string) error {
+	logger := newLogger()
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("read async job: %w", err)
+	}
+	var job asyncJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return fmt.Errorf("parse async job: %w", err)
+	}
+
+	start := time.Now()
+	res, runErr := newRunners(nil).Run(context.Background(), job.Hook, job.Input)
+	hr := audit.HookResult{
+		HookIndex:  job.Index,
+		HookName:   job.Hook.Name,
+		ExitCode:   res.ExitCode,
+		Outcome:    audit.HookOutcomePass,
+		DurationMs: time.Since(start).Milliseconds(),
+		Stderr:     res.Stderr,
+		Signal:     res.Signal,
+	}
+	switch {
+	case runErr != nil:
+		hr.ExitCode = -1
+		hr.Outcome = audit.HookOutcomeError
+		hr.Stderr = runErr.Error()
+		hr.ErrorKind = runner.Kind(runErr)
+	case res.ExitCode != 0:
+		hr.Outcome = audit.HookOutcomeError
+	}
+	logger.Debug("async hook finished", "hook", job.Hook.Name, "outcome", hr.Outcome, "duration_ms", hr.DurationMs)
+
+	if job.DBPath == "" || job.ChainID == 0 {
+		return nil
+	}
+	a, err := audit.Open(job.DBPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+	return audit.UpdateHookResult(a.DB(), job.ChainID, hr)
+}
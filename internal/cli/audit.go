@@ -1,11 +1,21 @@
 package cli
 
 import (
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -14,26 +24,101 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/auditpb"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/report"
+	"github.com/Fuabioo/hook-chain/internal/sink"
 	_ "modernc.org/sqlite"
 )
 
-// resolveDBPath returns the audit database path from the --db flag or the default.
-func resolveDBPath(cmd *cobra.Command) string {
-	dbPath, err := cmd.Flags().GetString("db")
-	if err != nil || dbPath == "" {
-		dbPath = audit.DefaultDBPath()
+// resolveDBPath returns the audit database path from the --db flag or the
+// configured one. When --db names several databases it is the first; commands
+// that read them all use resolveDBPaths.
+func resolveDBPath(cmd *cobra.Command) (string, error) {
+	paths, err := resolveDBPaths(cmd)
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// resolveDBPaths returns the audit databases named by --db, which may be
+// repeated and may be a glob, or the configured database. Glob matches are
+// sorted; a glob that matches nothing is an error.
+func resolveDBPaths(cmd *cobra.Command) ([]string, error) {
+	values, err := cmd.Flags().GetStringArray("db")
+	if err != nil || len(values) == 0 {
+		return []string{configuredDBPath()}, nil
+	}
+	var paths []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		matches := []string{v}
+		if strings.ContainsAny(v, "*?[") {
+			if matches, err = filepath.Glob(v); err != nil {
+				return nil, fmt.Errorf("invalid --db %q: %w", v, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("--db %q matches no files", v)
+			}
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// configuredDBPath returns the database hook runs write to under the active
+// config (audit.db_path, see auditDBPath), or the default database when the
+// config does not load or disables auditing, so the log stays readable.
+func configuredDBPath() string {
+	cfg, err := loadAuditConfig()
+	if err != nil {
+		newLogger().Warn("config error, using the default audit database", "err", err)
+		return audit.DefaultDBPath()
+	}
+	return cmp.Or(auditDBPath(cfg), audit.DefaultDBPath())
+}
+
+// loadAuditConfig loads the config for the audit commands. In query-only
+// mode a remote config is only read from its cached copy (see
+// config.LoadCached), so the commands make no network requests or writes.
+func loadAuditConfig() (config.Config, error) {
+	if queryOnly() {
+		return config.LoadCached()
 	}
-	return dbPath
+	return config.Load()
 }
 
 // openAuditDBReadOnly opens an existing audit DB for read-only queries.
-// Returns a clear error if the DB doesn't exist.
+// Returns a clear error if the DB doesn't exist, or if --db names several
+// databases, which only some commands read.
 func openAuditDBReadOnly(cmd *cobra.Command) (*sql.DB, error) {
-	dbPath := resolveDBPath(cmd)
+	paths, err := resolveDBPaths(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > 1 {
+		return nil, fmt.Errorf("%s reads one audit database, but --db names %d", cmd.CommandPath(), len(paths))
+	}
+	return openAuditDBReadOnlyAt(paths[0])
+}
+
+// openAuditDBReadOnlyAt is openAuditDBReadOnly for an explicit path. In
+// query-only mode SQLite itself refuses writes to the connection.
+func openAuditDBReadOnlyAt(dbPath string) (*sql.DB, error) {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("audit database not found at %s (is auditing enabled?)", dbPath)
 	}
-	db, err := sql.Open("sqlite", dbPath)
+	dsn := dbPath
+	if queryOnly() {
+		dsn = "file:" + (&url.URL{Path: dbPath}).EscapedPath() + "?mode=ro"
+	}
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open audit db %q: %w", dbPath, err)
 	}
@@ -50,8 +135,19 @@ func openAuditDBReadOnly(cmd *cobra.Command) (*sql.DB, error) {
 
 // openAuditDBWrite opens (or creates) the audit DB for write operations.
 // It returns the underlying *sql.DB, a cleanup function, and any error.
+// Writes are refused in query-only mode.
 func openAuditDBWrite(cmd *cobra.Command) (*sql.DB, func(), error) {
-	dbPath := resolveDBPath(cmd)
+	if queryOnly() {
+		return nil, nil, fmt.Errorf("%s modifies the audit database, which query-only mode does not allow", cmd.CommandPath())
+	}
+	paths, err := resolveDBPaths(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(paths) > 1 {
+		return nil, nil, fmt.Errorf("%s writes one audit database, but --db names %d", cmd.CommandPath(), len(paths))
+	}
+	dbPath := paths[0]
 	a, err := audit.Open(dbPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open audit db: %w", err)
@@ -64,15 +160,24 @@ func newAuditCmd() *cobra.Command {
 		Use:   "audit",
 		Short: "Query the audit log",
 	}
-	cmd.PersistentFlags().String("db", "", "path to audit database (default: auto-detected)")
+	cmd.PersistentFlags().StringArray("db", nil, "path or glob of an audit database; list and stats accept several (default: auto-detected)")
 	cmd.AddCommand(
 		newAuditListCmd(),
 		newAuditShowCmd(),
 		newAuditTailCmd(),
 		newAuditPruneCmd(),
+		newAuditPurgeCmd(),
 		newAuditStatsCmd(),
 		newAuditDBPathCmd(),
 		newAuditArchivesCmd(),
+		newAuditAnomaliesCmd(),
+		newAuditSessionsCmd(),
+		newAuditTopCmd(),
+		newAuditVariantsCmd(),
+		newAuditReportCmd(),
+		newAuditExportCmd(),
+		newAuditOutboxCmd(),
+		newAuditSchemaCmd(),
 	)
 	return cmd
 }
@@ -88,17 +193,17 @@ func newAuditListCmd() *cobra.Command {
 	cmd.Flags().Int("offset", 0, "skip N entries")
 	cmd.Flags().String("event", "", "filter by event name")
 	cmd.Flags().String("outcome", "", "filter by outcome")
+	cmd.Flags().Bool("modified", false, "only chains whose tool input a hook rewrote")
+	cmd.Flags().Bool("context", false, "only chains where hooks added context")
 	cmd.Flags().Bool("json", false, "output as JSON")
 	return cmd
 }
 
 func runAuditList(cmd *cobra.Command, _ []string) error {
-	db, err := openAuditDBReadOnly(cmd)
+	paths, err := resolveDBPaths(cmd)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = db.Close() }()
-
 	limit, err := cmd.Flags().GetInt("limit")
 	if err != nil {
 		return fmt.Errorf("invalid --limit: %w", err)
@@ -115,12 +220,31 @@ func runAuditList(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("invalid --outcome: %w", err)
 	}
+	modified, err := cmd.Flags().GetBool("modified")
+	if err != nil {
+		return fmt.Errorf("invalid --modified: %w", err)
+	}
+	addedContext, err := cmd.Flags().GetBool("context")
+	if err != nil {
+		return fmt.Errorf("invalid --context: %w", err)
+	}
 	asJSON, err := cmd.Flags().GetBool("json")
 	if err != nil {
 		return fmt.Errorf("invalid --json: %w", err)
 	}
+	filter := audit.ChainFilter{Event: event, Outcome: outcome, Modified: modified, AddedContext: addedContext}
+
+	if len(paths) > 1 {
+		return listAuditDBs(paths, limit, offset, filter, asJSON)
+	}
+
+	db, err := openAuditDBReadOnlyAt(paths[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
 
-	chains, err := audit.ListChains(db, limit, offset, event, outcome)
+	chains, err := audit.ListChainsFilter(db, limit, offset, filter)
 	if err != nil {
 		return fmt.Errorf("list chains: %w", err)
 	}
@@ -128,7 +252,55 @@ func runAuditList(cmd *cobra.Command, _ []string) error {
 	if asJSON {
 		return printJSON(chains)
 	}
-	printChainTable(chains, resolveDBPath(cmd))
+	printChainTable(chains, nil, paths[0])
+	return nil
+}
+
+// sourcedChain is a chain execution and the database it was read from.
+type sourcedChain struct {
+	Source string
+	audit.ChainExecution
+}
+
+// listAuditDBs lists the chain executions of several databases, newest
+// first, each with the database it came from.
+func listAuditDBs(paths []string, limit, offset int, filter audit.ChainFilter, asJSON bool) error {
+	var rows []sourcedChain
+	for _, path := range paths {
+		db, err := openAuditDBReadOnlyAt(path)
+		if err != nil {
+			return err
+		}
+		// Any entry on the requested page is within the first limit+offset
+		// entries of its own database.
+		perDB := 0
+		if limit > 0 {
+			perDB = limit + offset
+		}
+		chains, err := audit.ListChainsFilter(db, perDB, 0, filter)
+		_ = db.Close()
+		if err != nil {
+			return fmt.Errorf("list chains in %s: %w", path, err)
+		}
+		for _, c := range chains {
+			rows = append(rows, sourcedChain{Source: path, ChainExecution: c})
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b sourcedChain) int { return b.Timestamp.Compare(a.Timestamp) })
+	rows = rows[min(offset, len(rows)):]
+	if limit > 0 {
+		rows = rows[:min(limit, len(rows))]
+	}
+
+	if asJSON {
+		return printJSON(rows)
+	}
+	chains := make([]audit.ChainExecution, len(rows))
+	sources := make([]string, len(rows))
+	for i, r := range rows {
+		chains[i], sources[i] = r.ChainExecution, r.Source
+	}
+	printChainTable(chains, sources, "")
 	return nil
 }
 
@@ -177,26 +349,57 @@ func runAuditShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Detail:     %s\n", chain.ToolDetail)
 	}
 	fmt.Printf("  Chain Len:  %d\n", chain.ChainLen)
-	fmt.Printf("  Outcome:    %s\n", chain.Outcome)
+	fmt.Printf("  Outcome:    %s\n", outcomeLabel(*chain))
 	fmt.Printf("  Reason:     %s\n", chain.Reason)
-	fmt.Printf("  Duration:   %dms\n", chain.DurationMs)
+	fmt.Printf("  Duration:   %dms (%dms hook-chain overhead)\n", chain.DurationMs, chain.OverheadMs)
 	fmt.Printf("  Session:    %s\n", chain.SessionID)
+	if chain.RiskScore != nil {
+		fmt.Printf("  Risk:       %s\n", riskLabel(*chain))
+	}
+	if chain.ProtocolVersion != "" {
+		fmt.Printf("  Protocol:   %s\n", chain.ProtocolVersion)
+	}
 
 	if len(chain.Hooks) > 0 {
 		fmt.Printf("\n  Hook Results:\n")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		_, _ = fmt.Fprintln(w, "  IDX\tNAME\tEXIT\tOUTCOME\tDURATION\tSTDERR")
+		_, _ = fmt.Fprintln(w, "  IDX\tNAME\tEXIT\tOUTCOME\tRULE\tSEVERITY\tDURATION\tSTDERR")
 		for _, h := range chain.Hooks {
 			stderr := h.Stderr
 			if len(stderr) > 60 {
 				stderr = stderr[:57] + "..."
 			}
-			_, _ = fmt.Fprintf(w, "  %d\t%s\t%d\t%s\t%dms\t%s\n",
-				h.HookIndex, h.HookName, h.ExitCode, h.Outcome, h.DurationMs, stderr)
+			outcome := h.Outcome
+			if h.ErrorKind != "" {
+				outcome += "/" + h.ErrorKind
+			}
+			name := h.HookName
+			if h.Variant != "" {
+				name += "@" + h.Variant
+			}
+			exit := strconv.Itoa(h.ExitCode)
+			if h.Signal != "" {
+				exit += " (" + h.Signal + ")"
+			}
+			_, _ = fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\t%dms\t%s\n",
+				h.HookIndex, name, exit, outcome, h.RuleID, h.Severity, h.DurationMs, stderr)
 		}
 		if err := w.Flush(); err != nil {
 			return fmt.Errorf("flush tabwriter: %w", err)
 		}
+
+		// updatedInput patches, so a rewritten input can be attributed.
+		header := false
+		for _, h := range chain.Hooks {
+			if h.Patch == "" {
+				continue
+			}
+			if !header {
+				fmt.Printf("\n  Patches:\n")
+				header = true
+			}
+			fmt.Printf("  %d %s: %s\n", h.HookIndex, h.HookName, h.Patch)
+		}
 	}
 
 	return nil
@@ -238,7 +441,11 @@ func runAuditTail(cmd *cobra.Command, _ []string) error {
 	if asJSON {
 		return printJSON(chains)
 	}
-	printChainTable(chains, resolveDBPath(cmd))
+	dbPath, err := resolveDBPath(cmd)
+	if err != nil {
+		return err
+	}
+	printChainTable(chains, nil, dbPath)
 	return nil
 }
 
@@ -282,6 +489,174 @@ func runAuditPrune(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+func newAuditPurgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Hard-delete the entries of a session or matching a pattern, including archives",
+		Long: `Deletes every chain execution of --session, or whose tool detail, reason,
+or hook stderr matches the --matching regular expression (both: entries that
+satisfy both), with its hook results, queued outbox records, and the
+session's anomalies. Deleted rows are overwritten in the database file and
+the write-ahead log is truncated. Matching entries are also removed from the
+rotated archives, which are rewritten in place.
+
+A JSON receipt listing the deleted chain IDs and archive counts, but none of
+the deleted data, is written to --receipt (default: purge-receipts/ next to
+the database). It records the SHA-256 of the --matching pattern, and the
+pattern itself only with --receipt-include-pattern. --dry-run reports what
+would be deleted and changes nothing. Debug bundles (HOOK_CHAIN_CAPTURE_DIR)
+are not purged.`,
+		Args: cobra.NoArgs,
+		RunE: runAuditPurge,
+	}
+	cmd.Flags().String("session", "", "purge every entry of this session ID")
+	cmd.Flags().String("matching", "", "purge entries whose tool detail, reason, or hook stderr matches this regular expression")
+	cmd.Flags().Bool("dry-run", false, "report what would be purged without deleting anything")
+	cmd.Flags().String("receipt", "", "path of the purge receipt (default: purge-receipts/ next to the database)")
+	cmd.Flags().Bool("receipt-include-pattern", false, "record the --matching pattern in the receipt, not only its SHA-256")
+	cmd.Flags().Bool("json", false, "print the receipt as JSON")
+	return cmd
+}
+
+func runAuditPurge(cmd *cobra.Command, _ []string) error {
+	session, err := cmd.Flags().GetString("session")
+	if err != nil {
+		return fmt.Errorf("invalid --session: %w", err)
+	}
+	matching, err := cmd.Flags().GetString("matching")
+	if err != nil {
+		return fmt.Errorf("invalid --matching: %w", err)
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("invalid --dry-run: %w", err)
+	}
+	receiptPath, err := cmd.Flags().GetString("receipt")
+	if err != nil {
+		return fmt.Errorf("invalid --receipt: %w", err)
+	}
+	includePattern, err := cmd.Flags().GetBool("receipt-include-pattern")
+	if err != nil {
+		return fmt.Errorf("invalid --receipt-include-pattern: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	filter := audit.PurgeFilter{SessionID: session}
+	if matching != "" {
+		if filter.Matching, err = regexp.Compile(matching); err != nil {
+			return fmt.Errorf("invalid --matching: %w", err)
+		}
+	}
+	if filter.Empty() {
+		return fmt.Errorf("purge needs --session or --matching")
+	}
+
+	var db *sql.DB
+	if dryRun {
+		if db, err = openAuditDBReadOnly(cmd); err != nil {
+			return err
+		}
+		defer func() { _ = db.Close() }()
+	} else {
+		var cleanup func()
+		if db, cleanup, err = openAuditDBWrite(cmd); err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+	dbPath, err := resolveDBPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	receipt := audit.PurgeReceipt{
+		PurgedAt: time.Now().UTC(),
+		Database: dbPath,
+		Session:  session,
+		DryRun:   dryRun,
+	}
+	if matching != "" {
+		sum := sha256.Sum256([]byte(matching))
+		receipt.MatchingSHA256 = hex.EncodeToString(sum[:])
+		if includePattern {
+			receipt.Matching = matching
+		}
+	}
+	if receipt.ChainIDs, err = audit.PurgeCandidates(db, filter); err != nil {
+		return fmt.Errorf("purge: %w", err)
+	}
+
+	// Archives first: a failure leaves the database entries in place, so the
+	// purge can simply be run again.
+	cfg, err := loadAuditConfig()
+	if err != nil {
+		return fmt.Errorf("purge: load config: %w", err)
+	}
+	archives, err := audit.ListArchives(auditArchiveDir(cfg, dbPath))
+	if err != nil {
+		return fmt.Errorf("purge: list archives: %w", err)
+	}
+	for _, a := range archives {
+		n, err := audit.PurgeArchive(a.Path, filter, dryRun)
+		if err != nil {
+			return fmt.Errorf("purge: %w", err)
+		}
+		if n > 0 {
+			receipt.Archives = append(receipt.Archives, audit.ArchivePurged{Path: a.Path, Removed: n})
+		}
+	}
+
+	if !dryRun {
+		if receipt.Anomalies, err = audit.Purge(db, receipt.ChainIDs, session); err != nil {
+			return fmt.Errorf("purge: %w", err)
+		}
+		if receiptPath == "" {
+			receiptPath = filepath.Join(filepath.Dir(dbPath), "purge-receipts",
+				"purge-"+receipt.PurgedAt.Format("20060102T150405.000000Z")+".json")
+		}
+		if err := writeReceipt(receiptPath, receipt); err != nil {
+			return err
+		}
+	}
+
+	if asJSON {
+		return printJSON(receipt)
+	}
+	archived := 0
+	for _, a := range receipt.Archives {
+		archived += a.Removed
+	}
+	verb := "Purged"
+	if dryRun {
+		verb = "Would purge"
+	}
+	fmt.Printf("%s %d chain execution(s) and %d archived record(s) in %d archive(s).\n",
+		verb, len(receipt.ChainIDs), archived, len(receipt.Archives))
+	if !dryRun {
+		fmt.Printf("Deleted %d anomaly record(s).\n", receipt.Anomalies)
+		fmt.Printf("Receipt: %s\n", receiptPath)
+	}
+	return nil
+}
+
+// writeReceipt saves a purge receipt as indented JSON.
+func writeReceipt(path string, receipt audit.PurgeReceipt) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("purge: create receipt directory: %w", err)
+	}
+	data, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		return fmt.Errorf("purge: marshal receipt: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("purge: write receipt: %w", err)
+	}
+	return nil
+}
+
 func newAuditStatsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "stats",
@@ -294,28 +669,54 @@ func newAuditStatsCmd() *cobra.Command {
 }
 
 func runAuditStats(cmd *cobra.Command, _ []string) error {
-	db, err := openAuditDBReadOnly(cmd)
+	paths, err := resolveDBPaths(cmd)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = db.Close() }()
-
 	asJSON, err := cmd.Flags().GetBool("json")
 	if err != nil {
 		return fmt.Errorf("invalid --json: %w", err)
 	}
 
-	stats, err := audit.Stats(db)
-	if err != nil {
-		return fmt.Errorf("stats: %w", err)
+	perDB := make([]*audit.AuditStats, len(paths))
+	for i, path := range paths {
+		db, err := openAuditDBReadOnlyAt(path)
+		if err != nil {
+			return err
+		}
+		perDB[i], err = audit.Stats(db)
+		_ = db.Close()
+		if err != nil {
+			return fmt.Errorf("stats of %s: %w", path, err)
+		}
+	}
+	stats := perDB[0]
+	if len(paths) > 1 {
+		stats = audit.MergeStats(perDB...)
 	}
 
 	if asJSON {
-		return printJSON(stats)
+		if len(paths) == 1 {
+			return printJSON(stats)
+		}
+		type dbStats struct {
+			Source string
+			Stats  *audit.AuditStats
+		}
+		out := struct {
+			Total     *audit.AuditStats
+			Databases []dbStats
+		}{Total: stats}
+		for i, path := range paths {
+			out.Databases = append(out.Databases, dbStats{path, perDB[i]})
+		}
+		return printJSON(out)
 	}
 
 	fmt.Printf("Total chains:   %d\n", stats.TotalChains)
 	fmt.Printf("Avg duration:   %.1fms\n", stats.AvgDurationMs)
+	fmt.Printf("Avg overhead:   %.1fms (max %dms)\n", stats.AvgOverheadMs, stats.MaxOverheadMs)
+	fmt.Printf("WAL size:       %s\n", formatSize(stats.WALBytes))
 
 	if stats.TotalChains > 0 {
 		fmt.Printf("Oldest entry:   %s\n", stats.OldestEntry.Format(time.RFC3339))
@@ -329,16 +730,57 @@ func runAuditStats(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
+	if len(stats.CountBySeverity) > 0 {
+		fmt.Printf("\nBy severity (hook results):\n")
+		for _, sev := range slices.Backward(config.Severities) {
+			counts, ok := stats.CountBySeverity[sev]
+			if !ok {
+				continue
+			}
+			var parts []string
+			for _, outcome := range slices.Sorted(maps.Keys(counts)) {
+				parts = append(parts, fmt.Sprintf("%s %d", outcome, counts[outcome]))
+			}
+			fmt.Printf("  %-10s %s\n", sev, strings.Join(parts, ", "))
+		}
+	}
+
+	if len(paths) > 1 {
+		fmt.Printf("\nBy database:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for i, path := range paths {
+			_, _ = fmt.Fprintf(w, "  %s\t%d chain(s)\t%s\n", path, perDB[i].TotalChains, formatStatsRange(perDB[i]))
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flush tabwriter: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// formatStatsRange renders the entry range of one database's stats.
+func formatStatsRange(s *audit.AuditStats) string {
+	if s.TotalChains == 0 {
+		return "empty"
+	}
+	return s.OldestEntry.Format(time.RFC3339) + " .. " + s.NewestEntry.Format(time.RFC3339)
+}
+
 func newAuditDBPathCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "db-path",
 		Short: "Print the audit database path",
 		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(resolveDBPath(cmd))
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths, err := resolveDBPaths(cmd)
+			if err != nil {
+				return err
+			}
+			for _, p := range paths {
+				fmt.Println(p)
+			}
+			return nil
 		},
 	}
 }
@@ -355,8 +797,15 @@ func newAuditArchivesCmd() *cobra.Command {
 }
 
 func runAuditArchives(cmd *cobra.Command, _ []string) error {
-	dbPath := resolveDBPath(cmd)
-	archiveDir := filepath.Join(filepath.Dir(dbPath), "archives")
+	cfg, err := loadAuditConfig()
+	if err != nil {
+		return fmt.Errorf("archives: load config: %w", err)
+	}
+	dbPath, err := resolveDBPath(cmd)
+	if err != nil {
+		return err
+	}
+	archiveDir := auditArchiveDir(cfg, dbPath)
 
 	asJSON, err := cmd.Flags().GetBool("json")
 	if err != nil {
@@ -392,6 +841,525 @@ func runAuditArchives(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+func newAuditAnomaliesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "anomalies",
+		Short: "List detected audit anomalies",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditAnomalies,
+	}
+	cmd.Flags().Int("limit", 20, "maximum number of entries")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditAnomalies(cmd *cobra.Command, _ []string) error {
+	db, err := openAuditDBReadOnly(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("invalid --limit: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	anomalies, err := audit.ListAnomalies(db, limit)
+	if err != nil {
+		return fmt.Errorf("list anomalies: %w", err)
+	}
+
+	if asJSON {
+		return printJSON(anomalies)
+	}
+
+	if len(anomalies) == 0 {
+		fmt.Println("No anomalies detected.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tDETECTED\tKIND\tSUBJECT\tDETAIL")
+	for _, a := range anomalies {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			a.ID,
+			a.DetectedAt.Format(time.RFC3339),
+			a.Kind,
+			a.Subject,
+			a.Detail,
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newAuditSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List sessions by rolling risk score",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditSessions,
+	}
+	cmd.Flags().String("since", "24h", "rolling window (e.g., 24h, 7d)")
+	cmd.Flags().Int("limit", 20, "maximum number of sessions")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditSessions(cmd *cobra.Command, _ []string) error {
+	db, err := openAuditDBReadOnly(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	sinceStr, err := cmd.Flags().GetString("since")
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	since, err := parseDuration(sinceStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", sinceStr, err)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("invalid --limit: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	risks, err := audit.SessionRisks(db, time.Now().Add(-since), limit)
+	if err != nil {
+		return fmt.Errorf("session risks: %w", err)
+	}
+
+	if asJSON {
+		return printJSON(risks)
+	}
+
+	if len(risks) == 0 {
+		fmt.Println("No sessions in window.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SESSION\tSCORE\tCHAINS\tDENIES\tASKS\tDESTRUCTIVE\tLAST SEEN")
+	for _, r := range risks {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.SessionID,
+			r.Score,
+			r.Chains,
+			r.Denies,
+			r.Asks,
+			r.Destructive,
+			r.LastSeen.Format(time.RFC3339),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newAuditTopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show the most frequent rules, hooks, or tools",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditTop,
+	}
+	cmd.Flags().String("by", audit.TopByRule, "aggregate by: rule, hook, or tool")
+	cmd.Flags().String("since", "7d", "window to aggregate over (e.g., 24h, 7d)")
+	cmd.Flags().Int("limit", 10, "maximum number of rows")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditTop(cmd *cobra.Command, _ []string) error {
+	db, err := openAuditDBReadOnly(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	by, err := cmd.Flags().GetString("by")
+	if err != nil {
+		return fmt.Errorf("invalid --by: %w", err)
+	}
+	sinceStr, err := cmd.Flags().GetString("since")
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	since, err := parseDuration(sinceStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", sinceStr, err)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("invalid --limit: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	entries, err := audit.Top(db, by, time.Now().Add(-since), limit)
+	if err != nil {
+		return fmt.Errorf("top: %w", err)
+	}
+
+	if asJSON {
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Nothing recorded in window.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\tCOUNT\tDENIES\tLAST SEEN\n", strings.ToUpper(by))
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", e.Key, e.Count, e.Denies, e.LastSeen.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newAuditVariantsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "variants",
+		Short: "Compare enforced and shadow variants of A/B hooks",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditVariants,
+	}
+	cmd.Flags().String("since", "7d", "window to compare over (e.g., 24h, 7d)")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditVariants(cmd *cobra.Command, _ []string) error {
+	db, err := openAuditDBReadOnly(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	sinceStr, err := cmd.Flags().GetString("since")
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	since, err := parseDuration(sinceStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", sinceStr, err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	stats, err := audit.CompareVariants(db, time.Now().Add(-since))
+	if err != nil {
+		return fmt.Errorf("variants: %w", err)
+	}
+
+	if asJSON {
+		return printJSON(stats)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No A/B runs recorded in window.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "HOOK\tRUNS\tA BLOCKS\tB BLOCKS\tDISAGREE\tA AVG\tB AVG")
+	for _, s := range stats {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.0fms\t%.0fms\n",
+			s.Hook, s.Runs, s.PrimaryBlocks, s.ShadowBlocks, s.Disagreements, s.PrimaryAvgMs, s.ShadowAvgMs)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newAuditReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Build the guardrail digest and print or deliver it",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditReport,
+	}
+	cmd.Flags().String("since", "7d", "window to summarize (e.g., 24h, 7d)")
+	cmd.Flags().Bool("email", false, "send the digest by SMTP as configured under report.email")
+	cmd.Flags().String("post-webhook", "", "POST the digest as JSON to this URL (no value: report.webhook from config)")
+	cmd.Flags().Lookup("post-webhook").NoOptDefVal = "config"
+	cmd.Flags().Duration("timeout", 30*time.Second, "webhook request timeout")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditReport(cmd *cobra.Command, _ []string) error {
+	sinceStr, err := cmd.Flags().GetString("since")
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	since, err := parseDuration(sinceStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", sinceStr, err)
+	}
+	email, err := cmd.Flags().GetBool("email")
+	if err != nil {
+		return fmt.Errorf("invalid --email: %w", err)
+	}
+	webhook, err := cmd.Flags().GetString("post-webhook")
+	if err != nil {
+		return fmt.Errorf("invalid --post-webhook: %w", err)
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return fmt.Errorf("invalid --timeout: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	// Check the delivery settings before doing any work.
+	var reportCfg config.ReportConfig
+	if email || webhook != "" {
+		cfg, err := loadAuditConfig()
+		if err != nil {
+			return err
+		}
+		reportCfg = cfg.Report
+	}
+	if email {
+		if err := report.ValidateEmail(reportCfg.Email); err != nil {
+			return err
+		}
+	}
+	if webhook == "config" {
+		if reportCfg.Webhook == "" {
+			return fmt.Errorf("--post-webhook without a URL needs report.webhook in config")
+		}
+		webhook = reportCfg.Webhook
+	}
+
+	db, err := openAuditDBReadOnly(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	digest, err := report.Build(db, now.Add(-since), now)
+	if err != nil {
+		return err
+	}
+
+	if !email && webhook == "" {
+		if asJSON {
+			return printJSON(digest)
+		}
+		fmt.Print(digest.Text())
+		return nil
+	}
+
+	if email {
+		if err := report.SendEmail(reportCfg.Email, digest); err != nil {
+			return err
+		}
+		fmt.Printf("Sent digest to %s.\n", strings.Join(reportCfg.Email.To, ", "))
+	}
+	if webhook != "" {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		if err := report.PostWebhook(ctx, &http.Client{}, webhook, digest); err != nil {
+			return err
+		}
+		fmt.Printf("Posted digest to %s.\n", webhook)
+	}
+	return nil
+}
+
+func newAuditExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Drain audit records into a configured SIEM sink",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditExport,
+	}
+	cmd.Flags().String("sink", "", "name of a sink under audit.sinks in config")
+	cmd.Flags().Int("limit", 0, "maximum records to export (0 = all pending)")
+	cmd.Flags().Bool("reset", false, "re-export from the beginning, ignoring the sink's cursor")
+	if err := cmd.MarkFlagRequired("sink"); err != nil {
+		panic(fmt.Sprintf("mark --sink required: %v", err))
+	}
+	return cmd
+}
+
+func runAuditExport(cmd *cobra.Command, _ []string) error {
+	name, err := cmd.Flags().GetString("sink")
+	if err != nil {
+		return fmt.Errorf("invalid --sink: %w", err)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("invalid --limit: %w", err)
+	}
+	reset, err := cmd.Flags().GetBool("reset")
+	if err != nil {
+		return fmt.Errorf("invalid --reset: %w", err)
+	}
+
+	cfg, err := loadAuditConfig()
+	if err != nil {
+		return err
+	}
+	var sinkCfg *config.SinkConfig
+	if cfg.Audit != nil {
+		for i := range cfg.Audit.Sinks {
+			if cfg.Audit.Sinks[i].Name == name {
+				sinkCfg = &cfg.Audit.Sinks[i]
+				break
+			}
+		}
+	}
+	if sinkCfg == nil {
+		return fmt.Errorf("sink %q not found under audit.sinks in config", name)
+	}
+
+	s, err := sink.New(*sinkCfg)
+	if err != nil {
+		return err
+	}
+
+	db, cleanup, err := openAuditDBWrite(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if reset {
+		if err := audit.SetExportCursor(db, name, 0); err != nil {
+			return err
+		}
+	}
+
+	sent, err := sink.Drain(cmd.Context(), db, name, s, limit)
+	fmt.Printf("Exported %d chain execution(s) to %s.\n", sent, name)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return nil
+}
+
+func newAuditOutboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Show records queued for live sinks",
+		Args:  cobra.NoArgs,
+		RunE:  runAuditOutbox,
+	}
+	cmd.Flags().Bool("flush", false, "deliver queued records to their live sinks now")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runAuditOutbox(cmd *cobra.Command, _ []string) error {
+	flush, err := cmd.Flags().GetBool("flush")
+	if err != nil {
+		return fmt.Errorf("invalid --flush: %w", err)
+	}
+	jsonOut, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	// Only flushing writes (delivery marks records sent).
+	var db *sql.DB
+	if flush {
+		var cleanup func()
+		if db, cleanup, err = openAuditDBWrite(cmd); err != nil {
+			return err
+		}
+		defer cleanup()
+	} else {
+		if db, err = openAuditDBReadOnly(cmd); err != nil {
+			return err
+		}
+		defer func() { _ = db.Close() }()
+	}
+
+	if flush {
+		cfg, err := loadAuditConfig()
+		if err != nil {
+			return err
+		}
+		var flushErrs []error
+		for _, ls := range liveSinks(cfg, newLogger()) {
+			sent, err := sink.FlushOutbox(cmd.Context(), db, ls.name, ls.sink)
+			if !jsonOut {
+				fmt.Printf("Delivered %d chain execution(s) to %s.\n", sent, ls.name)
+			}
+			if err != nil {
+				flushErrs = append(flushErrs, err)
+			}
+		}
+		if err := errors.Join(flushErrs...); err != nil {
+			return fmt.Errorf("flush: %w", err)
+		}
+	}
+
+	stats, err := audit.OutboxStats(db)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return printJSON(stats)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("Outbox is empty.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SINK\tPENDING\tATTEMPTS\tLAST ERROR")
+	for _, st := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", st.Sink, st.Pending, st.MaxAttempts, st.LastError)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newAuditSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the protobuf schema for audit records",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			fmt.Print(auditpb.Schema)
+		},
+	}
+}
+
 // formatSize returns a human-readable file size.
 func formatSize(bytes int64) string {
 	const (
@@ -408,15 +1376,59 @@ func formatSize(bytes int64) string {
 	}
 }
 
-// printChainTable outputs chain executions in a tabwriter table.
+// outcomeLabel is the chain outcome, with what an allow passed on besides
+// the decision and whether delivering it failed, e.g. "allow (modified,
+// context)".
+func outcomeLabel(c audit.ChainExecution) string {
+	var extra []string
+	if c.Modified {
+		extra = append(extra, "modified")
+	}
+	if c.AddedContext {
+		extra = append(extra, "context")
+	}
+	if c.DeliveryFailed {
+		extra = append(extra, "delivery failed")
+	}
+	if len(extra) == 0 {
+		return c.Outcome
+	}
+	return c.Outcome + " (" + strings.Join(extra, ", ") + ")"
+}
+
+// riskLabel is the chain's combined risk score with the hook scores it
+// came from, e.g. "0.8 (guard 0.8, reviewer 0.3)".
+func riskLabel(c audit.ChainExecution) string {
+	var scores []string
+	for _, h := range c.Hooks {
+		if h.RiskScore != nil {
+			scores = append(scores, h.HookName+" "+strconv.FormatFloat(*h.RiskScore, 'f', -1, 64))
+		}
+	}
+	label := strconv.FormatFloat(*c.RiskScore, 'f', -1, 64)
+	if len(scores) == 0 {
+		return label
+	}
+	return label + " (" + strings.Join(scores, ", ") + ")"
+}
+
+// printChainTable outputs chain executions in a tabwriter table, with a
+// SOURCE column when sources holds each row's database.
 // If any rows have a non-allow outcome with a reason, a hint is printed
-// to stderr showing how to query full untruncated reasons via sqlite3.
-func printChainTable(chains []audit.ChainExecution, dbPath string) {
+// to stderr showing how to query full untruncated reasons in dbPath via
+// sqlite3 (not for several databases, where dbPath is "").
+func printChainTable(chains []audit.ChainExecution, sources []string, dbPath string) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if sources != nil {
+		_, _ = fmt.Fprint(w, "SOURCE\t")
+	}
 	_, _ = fmt.Fprintln(w, "ID\tTIMESTAMP\tEVENT\tTOOL\tDETAIL\tHOOKS\tOUTCOME\tREASON\tDURATION")
 
 	hasReasonedNonAllow := false
-	for _, c := range chains {
+	for i, c := range chains {
+		if sources != nil {
+			_, _ = fmt.Fprintf(w, "%s\t", sources[i])
+		}
 		if c.Outcome != audit.OutcomeAllow && c.Reason != "" {
 			hasReasonedNonAllow = true
 		}
@@ -436,7 +1448,7 @@ func printChainTable(chains []audit.ChainExecution, dbPath string) {
 			c.ToolName,
 			detail,
 			c.ChainLen,
-			c.Outcome,
+			outcomeLabel(c),
 			reason,
 			c.DurationMs,
 		)
@@ -445,7 +1457,7 @@ func printChainTable(chains []audit.ChainExecution, dbPath string) {
 		fmt.Fprintf(os.Stderr, "hook-chain: flush table: %v\n", err)
 	}
 
-	if hasReasonedNonAllow {
+	if hasReasonedNonAllow && dbPath != "" {
 		fmt.Fprintf(os.Stderr,
 			"\nTip: to see full denial reasons, run:\n  sqlite3 %s \"SELECT id, reason FROM chain_executions WHERE outcome != 'allow' ORDER BY id DESC LIMIT %d\"\n",
 			dbPath, len(chains),
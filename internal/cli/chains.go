@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/catalog"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/graph"
+)
+
+func newChainsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chains",
+		Short: "Inspect the configured chains",
+	}
+	cmd.AddCommand(newChainsGraphCmd(), newChainsExportCmd())
+	return cmd
+}
+
+func newChainsGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Render the configured chains as a Graphviz or Mermaid diagram",
+		Args:  cobra.NoArgs,
+		RunE:  runChainsGraph,
+	}
+	cmd.Flags().String("format", graph.FormatMermaid, "output format: dot or mermaid")
+	cmd.Flags().String("event", "", "only chains for this event")
+	return cmd
+}
+
+func runChainsGraph(cmd *cobra.Command, _ []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	if format != graph.FormatDOT && format != graph.FormatMermaid {
+		return fmt.Errorf("invalid --format %q: want %s or %s", format, graph.FormatDOT, graph.FormatMermaid)
+	}
+	event, err := cmd.Flags().GetString("event")
+	if err != nil {
+		return fmt.Errorf("invalid --event: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	chains := cfg.Chains
+	if event != "" {
+		chains = nil
+		for _, c := range cfg.Chains {
+			if c.MatchesEvent(event) {
+				chains = append(chains, c)
+			}
+		}
+	}
+	if len(chains) == 0 {
+		fmt.Fprintln(os.Stderr, "hook-chain: no chains configured")
+	}
+	return graph.Render(os.Stdout, chains, format)
+}
+
+func newChainsExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the configured chains as JSON or YAML in a stable schema",
+		Args:  cobra.NoArgs,
+		RunE:  runChainsExport,
+	}
+	cmd.Flags().String("format", catalog.FormatJSON, "output format: json or yaml")
+	cmd.Flags().Bool("resolved", false, "fill in every default, as the chains run")
+	cmd.Flags().String("event", "", "only chains for this event")
+	return cmd
+}
+
+func runChainsExport(cmd *cobra.Command, _ []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	if format != catalog.FormatJSON && format != catalog.FormatYAML {
+		return fmt.Errorf("invalid --format %q: want %s or %s", format, catalog.FormatJSON, catalog.FormatYAML)
+	}
+	resolved, err := cmd.Flags().GetBool("resolved")
+	if err != nil {
+		return fmt.Errorf("invalid --resolved: %w", err)
+	}
+	event, err := cmd.Flags().GetString("event")
+	if err != nil {
+		return fmt.Errorf("invalid --event: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cat := catalog.Build(cfg, resolved)
+	if event != "" {
+		// Filter after building, so chains keep the IDs validate shows.
+		cat.Chains = slices.DeleteFunc(cat.Chains, func(c catalog.Chain) bool {
+			return !cfg.Chains[c.ID-1].MatchesEvent(event)
+		})
+	}
+	return catalog.Write(os.Stdout, cat, format)
+}
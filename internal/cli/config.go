@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/wizard"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Create and edit the hook-chain config",
+	}
+	cmd.AddCommand(newConfigWizardCmd(), newConfigMigrateCmd())
+	return cmd
+}
+
+func newConfigWizardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wizard",
+		Short: "Compose chains interactively, preview the YAML, and write it",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigWizard,
+	}
+	cmd.Flags().String("output", "", "config file to write (default: the active config path)")
+	return cmd
+}
+
+func runConfigWizard(cmd *cobra.Command, _ []string) error {
+	path, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("invalid --output: %w", err)
+	}
+	if path == "" {
+		path = config.DefaultPath()
+	}
+
+	cfg, exists, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	w := wizard.New(cmd.InOrStdin(), out)
+	w.Installed = wizard.Installed(os.Getenv("PATH"))
+	_, _ = fmt.Fprintf(out, "Composing chains for %s\n", path)
+
+	cfg, err = w.Compose(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.Version = config.CurrentVersion
+	data, err := marshalConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("config wizard: %w", err)
+	}
+	_, _ = fmt.Fprintf(out, "\n--- %s ---\n%s\n", path, data)
+
+	errs := wizard.Check(cfg)
+	for _, err := range errs {
+		_, _ = fmt.Fprintf(out, "Problem: %v\n", err)
+	}
+	if exists {
+		_, _ = fmt.Fprintf(out, "The current file will be kept as %s.bak; comments in it are not carried over.\n", path)
+	}
+	ok, err := w.Confirm("Write "+path+"?", len(errs) == 0)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, _ = fmt.Fprintln(out, "Nothing written.")
+		return nil
+	}
+
+	if err := writeConfig(path, data, exists); err != nil {
+		return fmt.Errorf("config wizard: %w", err)
+	}
+	_, _ = fmt.Fprintf(out, "Wrote %s. Run `hook-chain validate` to check it in place.\n", path)
+	return nil
+}
+
+func newConfigMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate [file]...",
+		Short: "Rewrite config files in the current layout version",
+		Long: `Rewrite config files written for an older layout in the current one,
+keeping comments. Each rewritten file is first kept as <file>.bak. Without
+arguments, the user config and the project config for the working directory
+are migrated.`,
+		RunE: runConfigMigrate,
+	}
+	cmd.Flags().Bool("dry-run", false, "show the changes without writing")
+	return cmd
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("invalid --dry-run: %w", err)
+	}
+	files := args
+	if len(files) == 0 {
+		files = configFiles()
+	}
+	if len(files) == 0 {
+		return errors.New("config migrate: no config file found")
+	}
+
+	out := cmd.OutOrStdout()
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("config migrate: %w", err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("config migrate: parse %s: %w", path, err)
+		}
+		from, notes, err := config.Migrate(&doc)
+		if err != nil {
+			return fmt.Errorf("config migrate: %s: %w", path, err)
+		}
+		if from == config.CurrentVersion {
+			_, _ = fmt.Fprintf(out, "%s: already at version %d\n", path, config.CurrentVersion)
+			continue
+		}
+		for _, n := range notes {
+			_, _ = fmt.Fprintf(out, "%s: %s\n", path, n)
+		}
+		if dryRun {
+			_, _ = fmt.Fprintf(out, "%s: would be rewritten from version %d to %d\n", path, from, config.CurrentVersion)
+			continue
+		}
+		migrated, err := encodeYAML(&doc)
+		if err != nil {
+			return fmt.Errorf("config migrate: %s: %w", path, err)
+		}
+		if err := writeConfig(path, migrated, true); err != nil {
+			return fmt.Errorf("config migrate: %w", err)
+		}
+		_, _ = fmt.Fprintf(out, "%s: rewritten at version %d (previous file kept as %s.bak)\n", path, config.CurrentVersion, path)
+	}
+	return nil
+}
+
+// loadConfigFile reads the config at path; a missing file yields an empty
+// config and exists=false.
+func loadConfigFile(path string) (cfg config.Config, exists bool, err error) {
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return config.Config{}, false, nil
+		}
+		return config.Config{}, false, fmt.Errorf("config: %w", err)
+	}
+	cfg, err = config.LoadFrom(path)
+	return cfg, true, err
+}
+
+// marshalConfig renders cfg as YAML with two-space indentation.
+func marshalConfig(cfg config.Config) ([]byte, error) {
+	return encodeYAML(cfg)
+}
+
+// encodeYAML renders v, a value or a *yaml.Node, as YAML with two-space
+// indentation.
+func encodeYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeConfig writes data to path, creating its directory. With backup, the
+// current file is first renamed to path+".bak".
+func writeConfig(path string, data []byte, backup bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if backup {
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return fmt.Errorf("back up %s: %w", path, err)
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/conform"
+)
+
+func newConformCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conform",
+		Short: "Replay the golden hook payload corpus and compare output byte for byte",
+		Long: `Replays every case of the conformance corpus through this hook-chain binary
+(or --binary) in an isolated environment and compares stdout and the exit
+code with the recorded golden output. Exits 1 when any case differs.
+
+The corpus built into hook-chain is used unless --corpus points at a
+directory; --update rewrites that directory's golden files.`,
+		Args: cobra.NoArgs,
+		RunE: runConform,
+	}
+	cmd.Flags().String("corpus", "", "corpus directory (default: the corpus built into hook-chain)")
+	cmd.Flags().String("binary", "", "hook-chain binary to test (default: this one)")
+	cmd.Flags().String("run", "", "only cases whose name matches this regular expression")
+	cmd.Flags().Bool("update", false, "rewrite the golden files in --corpus with the current output")
+	return cmd
+}
+
+func runConform(cmd *cobra.Command, _ []string) error {
+	dir, err := cmd.Flags().GetString("corpus")
+	if err != nil {
+		return fmt.Errorf("invalid --corpus: %w", err)
+	}
+	binary, err := cmd.Flags().GetString("binary")
+	if err != nil {
+		return fmt.Errorf("invalid --binary: %w", err)
+	}
+	pattern, err := cmd.Flags().GetString("run")
+	if err != nil {
+		return fmt.Errorf("invalid --run: %w", err)
+	}
+	update, err := cmd.Flags().GetBool("update")
+	if err != nil {
+		return fmt.Errorf("invalid --update: %w", err)
+	}
+	if update && dir == "" {
+		return fmt.Errorf("--update needs --corpus: the built-in corpus is read-only")
+	}
+	filter, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid --run: %w", err)
+	}
+	if binary == "" {
+		if binary, err = os.Executable(); err != nil {
+			return fmt.Errorf("conform: locate hook-chain binary: %w", err)
+		}
+	}
+
+	corpus := conform.Corpus()
+	if dir != "" {
+		corpus = os.DirFS(dir)
+	}
+	cases, err := conform.Load(corpus)
+	if err != nil {
+		return err
+	}
+
+	failed, ran := 0, 0
+	for _, c := range cases {
+		if !filter.MatchString(c.Name) {
+			continue
+		}
+		ran++
+		r, err := conform.Replay(cmd.Context(), binary, c)
+		if err != nil {
+			return err
+		}
+		if update {
+			if err := conform.Update(dir, c, r); err != nil {
+				return err
+			}
+			fmt.Printf("UPDATE  %s\n", c.Name)
+			continue
+		}
+		if diff := c.Diff(r); diff != "" {
+			failed++
+			fmt.Printf("FAIL    %s\n%s", c.Name, diff)
+			continue
+		}
+		fmt.Printf("PASS    %s\n", c.Name)
+	}
+
+	if ran == 0 {
+		return fmt.Errorf("conform: no cases match %q", pattern)
+	}
+	if !update {
+		fmt.Printf("\n%d/%d cases passed\n", ran-failed, ran)
+	}
+	if failed > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
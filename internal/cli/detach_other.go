@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// detach is a no-op where sessions are not supported.
+func detach(*exec.Cmd) {}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/state"
+)
+
+func newExceptionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exceptions",
+		Short: "Manage exceptions that downgrade matching denials to warnings",
+	}
+	cmd.PersistentFlags().String("state", "", "path to state file (default: auto-detected)")
+	cmd.AddCommand(
+		newExceptionsAddCmd(),
+		newExceptionsRemoveCmd(),
+		newExceptionsListCmd(),
+	)
+	return cmd
+}
+
+func newExceptionsAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Waive denials from a hook for matching tool calls",
+		Args:  cobra.NoArgs,
+		RunE:  runExceptionsAdd,
+	}
+	cmd.Flags().String("hook", "", "hook name whose denials are waived (required)")
+	cmd.Flags().String("pattern", "", "glob matched against file paths or the Bash command (required)")
+	cmd.Flags().String("expires", "7d", "how long the exception lasts (e.g., 24h, 7d)")
+	cmd.Flags().String("reason", "", "reason or ticket recorded with the exception")
+	for _, name := range []string{"hook", "pattern"} {
+		if err := cmd.MarkFlagRequired(name); err != nil {
+			panic(fmt.Sprintf("mark --%s required: %v", name, err))
+		}
+	}
+	return cmd
+}
+
+func runExceptionsAdd(cmd *cobra.Command, _ []string) error {
+	hookName, err := cmd.Flags().GetString("hook")
+	if err != nil {
+		return fmt.Errorf("invalid --hook: %w", err)
+	}
+	pattern, err := cmd.Flags().GetString("pattern")
+	if err != nil {
+		return fmt.Errorf("invalid --pattern: %w", err)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid --pattern %q: %w", pattern, err)
+	}
+	expiresStr, err := cmd.Flags().GetString("expires")
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %w", err)
+	}
+	expires, err := parseDuration(expiresStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", expiresStr, err)
+	}
+	if expires <= 0 {
+		return fmt.Errorf("invalid duration %q: must be positive", expiresStr)
+	}
+	reason, err := cmd.Flags().GetString("reason")
+	if err != nil {
+		return fmt.Errorf("invalid --reason: %w", err)
+	}
+
+	path := resolveStatePath(cmd)
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	st.PruneExpired(now)
+	ex := st.AddException(state.Exception{
+		Hook:      hookName,
+		Pattern:   pattern,
+		Reason:    reason,
+		CreatedAt: now,
+		Until:     now.Add(expires),
+	})
+	if err := state.Save(path, st); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added exception %s: denials from %q matching %q are waived until %s.\n",
+		ex.ID, ex.Hook, ex.Pattern, ex.Until.Format(time.RFC3339))
+	return nil
+}
+
+func newExceptionsRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id>",
+		Short: "Remove an exception",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runExceptionsRemove,
+	}
+}
+
+func runExceptionsRemove(cmd *cobra.Command, args []string) error {
+	path := resolveStatePath(cmd)
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	st.PruneExpired(time.Now().UTC())
+	if !st.RemoveException(args[0]) {
+		fmt.Printf("No active exception %q.\n", args[0])
+		return nil
+	}
+	if err := state.Save(path, st); err != nil {
+		return err
+	}
+	fmt.Printf("Removed exception %s.\n", args[0])
+	return nil
+}
+
+func newExceptionsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List active exceptions",
+		Args:  cobra.NoArgs,
+		RunE:  runExceptionsList,
+	}
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runExceptionsList(cmd *cobra.Command, _ []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	st, err := state.Load(resolveStatePath(cmd))
+	if err != nil {
+		return err
+	}
+	st.PruneExpired(time.Now().UTC())
+
+	if asJSON {
+		return printJSON(st.Exceptions)
+	}
+
+	if len(st.Exceptions) == 0 {
+		fmt.Println("No active exceptions.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tHOOK\tPATTERN\tUNTIL\tREASON")
+	for _, e := range st.Exceptions {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.Hook, e.Pattern, e.Until.Format(time.RFC3339), e.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+// loadExceptions returns the active exceptions from the state file. Load
+// errors are logged and no exceptions apply, so a corrupt state file never
+// weakens the guard set.
+func loadExceptions(logger *slog.Logger) []state.Exception {
+	st, err := state.Load(state.DefaultPath())
+	if err != nil {
+		logger.Warn("failed to load hook state, applying no exceptions", "err", err)
+		return nil
+	}
+	st.PruneExpired(time.Now().UTC())
+	return st.Exceptions
+}
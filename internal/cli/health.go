@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/health"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
+)
+
+func newHealthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Run readiness self-checks (config, audit DB, hook binaries)",
+		Long: `Run readiness self-checks: the config parses, the audit database is
+writable, and every hook command resolves on PATH. Exits 1 when not ready,
+so it can be used directly as a container exec probe.
+
+With --listen, serve /healthz (liveness) and /readyz (readiness, 503 when
+any check fails) over HTTP instead, for running hook-chain as a sidecar.`,
+		Args: cobra.NoArgs,
+		RunE: runHealth,
+	}
+	cmd.Flags().String("listen", "", "serve /healthz and /readyz on this address (e.g. :8080)")
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func newHealthChecker() health.Checker {
+	return health.Checker{
+		LoadConfig:  config.Load,
+		AuditDBPath: auditDBPath,
+		LookPath:    hookdir.LookPath,
+	}
+}
+
+func runHealth(cmd *cobra.Command, _ []string) error {
+	listen, err := cmd.Flags().GetString("listen")
+	if err != nil {
+		return fmt.Errorf("invalid --listen: %w", err)
+	}
+	jsonOut, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	checker := newHealthChecker()
+
+	if listen != "" {
+		srv := &http.Server{
+			Addr:              listen,
+			Handler:           health.Handler(checker),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		fmt.Fprintf(os.Stderr, "hook-chain: serving /healthz and /readyz on %s\n", listen)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("health server: %w", err)
+		}
+		return nil
+	}
+
+	report := checker.Run(cmd.Context())
+	if jsonOut {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+		for _, c := range report.Checks {
+			status := "OK"
+			if !c.OK {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, status, c.Detail)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flush tabwriter: %w", err)
+		}
+	}
+
+	if !report.Ready {
+		return &exitError{code: 1}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/state"
+)
+
+// resolveStatePath returns the state file path from the --state flag or the default.
+func resolveStatePath(cmd *cobra.Command) string {
+	p, err := cmd.Flags().GetString("state")
+	if err != nil || p == "" {
+		p = state.DefaultPath()
+	}
+	return p
+}
+
+func newHooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage runtime hook state (disable/enable)",
+	}
+	cmd.PersistentFlags().String("state", "", "path to state file (default: auto-detected)")
+	cmd.AddCommand(
+		newHooksDisableCmd(),
+		newHooksEnableCmd(),
+		newHooksListCmd(),
+	)
+	return cmd
+}
+
+func newHooksDisableCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "disable <name>",
+		Short: "Disable a hook by name without editing config",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runHooksDisable,
+	}
+	cmd.Flags().String("for", "", "disable duration (e.g., 2h, 1d); default: until re-enabled")
+	cmd.Flags().String("reason", "", "reason recorded with the disable entry")
+	return cmd
+}
+
+func runHooksDisable(cmd *cobra.Command, args []string) error {
+	forStr, err := cmd.Flags().GetString("for")
+	if err != nil {
+		return fmt.Errorf("invalid --for: %w", err)
+	}
+	reason, err := cmd.Flags().GetString("reason")
+	if err != nil {
+		return fmt.Errorf("invalid --reason: %w", err)
+	}
+
+	now := time.Now().UTC()
+	entry := state.DisabledHook{
+		Name:      args[0],
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if forStr != "" {
+		dur, err := parseDuration(forStr)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", forStr, err)
+		}
+		if dur <= 0 {
+			return fmt.Errorf("invalid duration %q: must be positive", forStr)
+		}
+		entry.Until = now.Add(dur)
+	}
+
+	path := resolveStatePath(cmd)
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	st.PruneExpired(now)
+	st.Disable(entry)
+	if err := state.Save(path, st); err != nil {
+		return err
+	}
+
+	if entry.Until.IsZero() {
+		fmt.Printf("Disabled hook %q until re-enabled.\n", entry.Name)
+	} else {
+		fmt.Printf("Disabled hook %q until %s.\n", entry.Name, entry.Until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func newHooksEnableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable <name>",
+		Short: "Re-enable a previously disabled hook",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runHooksEnable,
+	}
+}
+
+func runHooksEnable(cmd *cobra.Command, args []string) error {
+	path := resolveStatePath(cmd)
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+	st.PruneExpired(time.Now().UTC())
+	if !st.Enable(args[0]) {
+		fmt.Printf("Hook %q is not disabled.\n", args[0])
+		return nil
+	}
+	if err := state.Save(path, st); err != nil {
+		return err
+	}
+	fmt.Printf("Enabled hook %q.\n", args[0])
+	return nil
+}
+
+func newHooksListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List disabled hooks",
+		Args:  cobra.NoArgs,
+		RunE:  runHooksList,
+	}
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runHooksList(cmd *cobra.Command, _ []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	st, err := state.Load(resolveStatePath(cmd))
+	if err != nil {
+		return err
+	}
+	st.PruneExpired(time.Now().UTC())
+
+	if asJSON {
+		return printJSON(st.Disabled)
+	}
+
+	if len(st.Disabled) == 0 {
+		fmt.Println("No disabled hooks.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tSINCE\tUNTIL\tREASON")
+	for _, d := range st.Disabled {
+		until := "-"
+		if !d.Until.IsZero() {
+			until = d.Until.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			d.Name, d.CreatedAt.Format(time.RFC3339), until, d.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+// loadDisabled returns the hooks disabled via `hook-chain hooks disable`.
+// State load errors are logged and every configured hook runs, so a corrupt
+// state file never weakens the guard set.
+func loadDisabled(logger *slog.Logger) []state.DisabledHook {
+	st, err := state.Load(state.DefaultPath())
+	if err != nil {
+		logger.Warn("failed to load hook state, running all hooks", "err", err)
+		return nil
+	}
+	return st.Disabled
+}
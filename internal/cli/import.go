@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/settings"
+)
+
+func newImportSettingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-settings",
+		Short: "Convert hooks configured in Claude Code settings.json into chains",
+		Long: `Reads the hooks configured directly in a Claude Code settings.json and
+prints equivalent hook-chain chains, keeping matchers as tool lists and
+timeouts. With --output, the chains are appended to that config file instead
+(the previous file is kept as .bak).`,
+		Args: cobra.NoArgs,
+		RunE: runImportSettings,
+	}
+	cmd.Flags().String("settings", "", "settings file to read (default: ~/.claude/settings.json)")
+	cmd.Flags().String("output", "", "append the chains to this config file instead of printing them")
+	return cmd
+}
+
+func runImportSettings(cmd *cobra.Command, _ []string) error {
+	path, err := cmd.Flags().GetString("settings")
+	if err != nil {
+		return fmt.Errorf("invalid --settings: %w", err)
+	}
+	if path == "" {
+		path = settings.DefaultPath()
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("invalid --output: %w", err)
+	}
+
+	chains, warnings, err := settings.ImportFile(path)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "hook-chain: warning: %s\n", w)
+	}
+	if len(chains) == 0 {
+		fmt.Fprintf(os.Stderr, "hook-chain: no command hooks to import in %s\n", path)
+		return nil
+	}
+
+	var events []string
+	for _, c := range chains {
+		for _, e := range c.EventNames() {
+			if !slices.Contains(events, e) {
+				events = append(events, e)
+			}
+		}
+	}
+	hint := fmt.Sprintf("hook-chain: imported %d chain(s). In %s, replace the hooks of %s with a single `hook-chain` command hook.\n",
+		len(chains), path, strings.Join(events, ", "))
+
+	if output == "" {
+		data, err := marshalConfig(config.Config{Chains: chains})
+		if err != nil {
+			return fmt.Errorf("import-settings: %w", err)
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, hint)
+		return nil
+	}
+
+	cfg, exists, err := loadConfigFile(output)
+	if err != nil {
+		return err
+	}
+	cfg.Chains = append(cfg.Chains, chains...)
+	data, err := marshalConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("import-settings: %w", err)
+	}
+	if err := writeConfig(output, data, exists); err != nil {
+		return fmt.Errorf("import-settings: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "hook-chain: wrote %s\n", output)
+	fmt.Fprint(os.Stderr, hint)
+	return nil
+}
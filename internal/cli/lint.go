@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
+	"github.com/Fuabioo/hook-chain/internal/hooklint"
+)
+
+func newLintHooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint-hooks",
+		Short: "Inspect configured hook scripts for likely runtime failures",
+		Long: `Checks every configured hook command and the script behind it: the
+command resolves on PATH and is executable, scripts have a #! line whose
+interpreter exists, scripts read stdin, and tools they use (jq, python3,
+...) are on PATH. Exits 1 when any error is found (--strict: any warning
+too).
+
+PATH is this shell's; hooks see the agent's environment, which may differ.`,
+		Args: cobra.NoArgs,
+		RunE: runLintHooks,
+	}
+	cmd.Flags().Bool("json", false, "output as JSON")
+	cmd.Flags().Bool("strict", false, "exit 1 on warnings as well as errors")
+	return cmd
+}
+
+func runLintHooks(cmd *cobra.Command, _ []string) error {
+	jsonOut, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return fmt.Errorf("invalid --strict: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	findings := hooklint.Linter{LookPath: hookdir.LookPath}.Config(cfg)
+
+	errs, warnings := 0, 0
+	for _, f := range findings {
+		if f.Severity == hooklint.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	if jsonOut {
+		if findings == nil {
+			findings = []hooklint.Finding{}
+		}
+		if err := printJSON(findings); err != nil {
+			return err
+		}
+	} else if len(findings) == 0 {
+		fmt.Println("No problems found.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "HOOK\tSEVERITY\tCOMMAND\tPROBLEM")
+		for _, f := range findings {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Hook, f.Severity, f.Command, f.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flush tabwriter: %w", err)
+		}
+		fmt.Printf("\n%d errors, %d warnings\n", errs, warnings)
+	}
+
+	if errs > 0 || (strict && warnings > 0) {
+		return &exitError{code: 1}
+	}
+	return nil
+}
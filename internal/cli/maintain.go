@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/sink"
+)
+
+// Names of the units `maintain --install-timer` writes.
+const (
+	maintainUnit  = "hook-chain-maintain"
+	maintainLabel = "io.github.fuabioo.hook-chain.maintain"
+)
+
+// defaultMaintainEvery is the timer interval when --every is not given.
+const defaultMaintainEvery = time.Hour
+
+// minTimerEvery is the shortest --every an installed timer accepts: the
+// unit files count whole seconds, and maintenance more often than this is
+// not worth starting a process for.
+const minTimerEvery = time.Minute
+
+func newMaintainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintain",
+		Short: "Run audit retention tasks outside the pipeline",
+		Long: `Runs the audit retention tasks now: archive and prune entries older than
+audit.retention, scan for anomalies, truncate the write-ahead log, vacuum the
+database, and deliver records queued for live sinks. Pipeline runs do the
+same (except vacuum and delivery retries) at most hourly; set
+audit.maintenance: scheduled to leave it to this command instead.
+
+With --every, keep running and repeat at that interval until interrupted.
+With --install-timer, write a systemd user timer (or a launchd agent on
+macOS) that runs it at the --every interval (default 1h); --print shows the
+files instead of writing them.`,
+		Args: cobra.NoArgs,
+		RunE: runMaintain,
+	}
+	cmd.Flags().Duration("every", 0, "repeat at this interval until interrupted (e.g. 30m, 1h)")
+	cmd.Flags().Bool("install-timer", false, "write a systemd user timer or launchd agent that runs maintain")
+	cmd.Flags().Bool("print", false, "with --install-timer, print the unit files instead of writing them")
+	return cmd
+}
+
+func runMaintain(cmd *cobra.Command, _ []string) error {
+	every, err := cmd.Flags().GetDuration("every")
+	if err != nil {
+		return fmt.Errorf("invalid --every: %w", err)
+	}
+	if every < 0 {
+		return fmt.Errorf("invalid --every: %s is negative", every)
+	}
+	install, err := cmd.Flags().GetBool("install-timer")
+	if err != nil {
+		return fmt.Errorf("invalid --install-timer: %w", err)
+	}
+	printOnly, err := cmd.Flags().GetBool("print")
+	if err != nil {
+		return fmt.Errorf("invalid --print: %w", err)
+	}
+	if printOnly && !install {
+		return errors.New("--print only applies to --install-timer")
+	}
+
+	if install {
+		switch {
+		case every == 0:
+			every = defaultMaintainEvery
+		case every < minTimerEvery:
+			return fmt.Errorf("invalid --every: an installed timer runs at most every %s, not every %s", durationLabel(minTimerEvery), every)
+		}
+		return installMaintainTimer(every, printOnly)
+	}
+
+	logger := newLogger()
+	if every == 0 {
+		return maintainOnce(cmd.Context(), logger)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		// A failed pass is reported and retried at the next tick.
+		if err := maintainOnce(ctx, logger); err != nil {
+			fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// maintainOnce runs one maintenance pass against the configured audit
+// database. The config is loaded on every pass, so a running --every loop
+// picks up edits.
+func maintainOnce(ctx context.Context, logger *slog.Logger) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	dbPath := auditDBPath(cfg)
+	if dbPath == "" {
+		fmt.Println("Auditing is disabled; nothing to maintain.")
+		return nil
+	}
+	a, err := audit.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open audit db: %w", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	bus := newEventBus(cfg, logger)
+	defer func() {
+		if err := bus.Close(); err != nil {
+			logger.Warn("event bus close", "err", err)
+		}
+	}()
+
+	rotCfg := audit.RotationConfig{
+		Retention:   resolveRetention(cfg, logger),
+		ArchiveDir:  auditArchiveDir(cfg, dbPath),
+		ThrottleDir: auditArchiveDir(cfg, dbPath),
+		OnAnomaly: func(an audit.Anomaly) {
+			fmt.Printf("Anomaly: %s %s: %s\n", an.Kind, an.Subject, an.Detail)
+			bus.Publish(anomalyEvent(an))
+		},
+	}
+	errs := []error{audit.Maintain(a.DB(), rotCfg, logger)}
+	for _, ls := range liveSinks(cfg, logger) {
+		sent, err := sink.FlushOutbox(ctx, a.DB(), ls.name, ls.sink)
+		if sent > 0 {
+			fmt.Printf("Delivered %d chain execution(s) to %s.\n", sent, ls.name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", ls.name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("maintain %s: %w", dbPath, err)
+	}
+	fmt.Printf("Maintained %s at %s.\n", dbPath, time.Now().Format(time.RFC3339))
+	return nil
+}
+
+// timerFile is one file of a generated timer.
+type timerFile struct {
+	path    string
+	content string
+}
+
+// installMaintainTimer writes (or prints) the systemd user units, or on
+// macOS the launchd agent, that run `hook-chain maintain` every interval.
+// They run the current executable with the current HOOK_CHAIN_CONFIG.
+func installMaintainTimer(every time.Duration, printOnly bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate hook-chain executable: %w", err)
+	}
+	configPath := os.Getenv(config.ConfigEnv)
+
+	var files []timerFile
+	var enable string
+	if runtime.GOOS == "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("locate home directory: %w", err)
+		}
+		path := filepath.Join(home, "Library", "LaunchAgents", maintainLabel+".plist")
+		agent, err := launchdAgent(exe, configPath, every)
+		if err != nil {
+			return err
+		}
+		files = []timerFile{{path, agent}}
+		enable = "launchctl load -w " + path
+	} else {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return fmt.Errorf("locate config directory: %w", err)
+		}
+		dir = filepath.Join(dir, "systemd", "user")
+		service, timer := systemdUnits(exe, configPath, every)
+		files = []timerFile{
+			{filepath.Join(dir, maintainUnit+".service"), service},
+			{filepath.Join(dir, maintainUnit+".timer"), timer},
+		}
+		enable = "systemctl --user daemon-reload && systemctl --user enable --now " + maintainUnit + ".timer"
+	}
+
+	for _, f := range files {
+		if printOnly {
+			fmt.Printf("# %s\n%s\n", f.path, f.content)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return fmt.Errorf("create %s: %w", filepath.Dir(f.path), err)
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", f.path, err)
+		}
+		fmt.Printf("Wrote %s\n", f.path)
+	}
+	fmt.Printf("Enable it with:\n  %s\n", enable)
+	return nil
+}
+
+// systemdUnits returns a oneshot service running maintain and a timer
+// starting it every interval.
+func systemdUnits(exe, configPath string, every time.Duration) (service, timer string) {
+	var env string
+	if configPath != "" {
+		env = fmt.Sprintf("Environment=%s\n", systemdQuote(config.ConfigEnv+"="+configPath))
+	}
+	service = fmt.Sprintf(`[Unit]
+Description=hook-chain audit maintenance
+
+[Service]
+Type=oneshot
+ExecStart=%s maintain
+%s`, systemdQuote(exe), env)
+	timer = fmt.Sprintf(`[Unit]
+Description=Run hook-chain audit maintenance every %s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%ds
+
+[Install]
+WantedBy=timers.target
+`, durationLabel(every), int(every.Seconds()))
+	return service, timer
+}
+
+// durationLabel prints d without zero units: 1h, not 1h0m0s.
+func durationLabel(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// systemdQuote double-quotes s for a unit file when it contains spaces,
+// quotes, or backslashes.
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// launchdAgent returns a launchd agent running maintain every interval.
+func launchdAgent(exe, configPath string, every time.Duration) (string, error) {
+	exe, err := xmlEscape(exe)
+	if err != nil {
+		return "", err
+	}
+	var env string
+	if configPath != "" {
+		if configPath, err = xmlEscape(configPath); err != nil {
+			return "", err
+		}
+		env = fmt.Sprintf(`	<key>EnvironmentVariables</key>
+	<dict>
+		<key>HOOK_CHAIN_CONFIG</key>
+		<string>%s</string>
+	</dict>
+`, configPath)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>maintain</string>
+	</array>
+%s	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`, maintainLabel, exe, env, int(every.Seconds())), nil
+}
+
+// xmlEscape escapes s for XML character data.
+func xmlEscape(s string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", fmt.Errorf("escape %q for the launchd agent: %w", s, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/mockhook"
+)
+
+func newMockHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mock-hook",
+		Short: "A scriptable hook for testing chain configs and agent settings",
+		Long: `Acts as a hook that answers every call the same way: it reads the hook
+input from stdin, optionally appends it to --record, waits --sleep, writes
+--stderr, prints the decision, rewrite, or context as hook output, and exits
+with --exit. With no options it passes (no output, exit 0).
+
+Every flag has a HOOK_CHAIN_MOCK_* variable (HOOK_CHAIN_MOCK_DECISION,
+_REASON, _UPDATED_INPUT, _CONTEXT, _SLEEP, _EXIT, _STDERR, _RECORD); flags
+win. Use it as a hook command in test configs:
+
+  command: hook-chain mock-hook --decision deny
+  args: [--reason, blocked by mock]`,
+		Args: cobra.NoArgs,
+		RunE: runMockHook,
+	}
+	cmd.Flags().String("decision", "", "pass, allow, deny, or ask (default: pass)")
+	cmd.Flags().String("reason", "", "permissionDecisionReason (needs --decision allow, deny, or ask)")
+	cmd.Flags().String("updated-input", "", "JSON object returned as updatedInput")
+	cmd.Flags().String("context", "", "additionalContext for the agent")
+	cmd.Flags().Duration("sleep", 0, "wait this long before answering (e.g. to trip a timeout)")
+	cmd.Flags().Int("exit", 0, "exit code")
+	cmd.Flags().String("stderr", "", "text to write to stderr")
+	cmd.Flags().String("record", "", "append each input, as one JSON line, to this file")
+	return cmd
+}
+
+func runMockHook(cmd *cobra.Command, _ []string) error {
+	spec, err := mockhook.FromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	textFlags := map[string]*string{
+		"decision":      &spec.Decision,
+		"reason":        &spec.Reason,
+		"updated-input": &spec.UpdatedInput,
+		"context":       &spec.Context,
+		"stderr":        &spec.Stderr,
+		"record":        &spec.Record,
+	}
+	for name, field := range textFlags {
+		if !flags.Changed(name) {
+			continue
+		}
+		if *field, err = flags.GetString(name); err != nil {
+			return fmt.Errorf("invalid --%s: %w", name, err)
+		}
+	}
+	if flags.Changed("sleep") {
+		if spec.Sleep, err = flags.GetDuration("sleep"); err != nil {
+			return fmt.Errorf("invalid --sleep: %w", err)
+		}
+	}
+	if flags.Changed("exit") {
+		if spec.ExitCode, err = flags.GetInt("exit"); err != nil {
+			return fmt.Errorf("invalid --exit: %w", err)
+		}
+	}
+
+	code, err := mockhook.Run(cmd.Context(), spec, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &exitError{code: code}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// queryOnlyEnv turns on query-only mode at run time.
+const queryOnlyEnv = "HOOK_CHAIN_QUERY_ONLY"
+
+// queryOnly reports whether hook-chain runs in query-only mode: built with
+// the queryonly tag, or run with HOOK_CHAIN_QUERY_ONLY=1. In that mode the
+// hook pipeline refuses to run, only the audit, version, and
+// release-manifest commands exist, and the audit database is opened
+// read-only, so the binary is safe to hand to auditors.
+func queryOnly() bool {
+	return queryOnlyBuild || os.Getenv(queryOnlyEnv) == "1"
+}
+
+// runQueryOnly replaces the hook pipeline in query-only mode. It exits 2,
+// so an agent that invokes it as a hook blocks rather than runs unguarded.
+func runQueryOnly(*cobra.Command, []string) error {
+	fmt.Fprintln(os.Stderr, "hook-chain: query-only mode: hooks are not run (only audit queries are available)")
+	return &exitError{code: 2}
+}
@@ -0,0 +1,6 @@
+//go:build !queryonly
+
+package cli
+
+// queryOnlyBuild is set by building with -tags queryonly.
+const queryOnlyBuild = false
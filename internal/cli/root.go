@@ -2,27 +2,50 @@ package cli
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/Fuabioo/hook-chain/internal/adapter"
 	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/budget"
+	"github.com/Fuabioo/hook-chain/internal/buildinfo"
+	"github.com/Fuabioo/hook-chain/internal/builtin"
+	"github.com/Fuabioo/hook-chain/internal/capture"
 	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/diff"
+	"github.com/Fuabioo/hook-chain/internal/events"
 	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
+	"github.com/Fuabioo/hook-chain/internal/messages"
 	"github.com/Fuabioo/hook-chain/internal/pathutil"
 	"github.com/Fuabioo/hook-chain/internal/pipeline"
+	"github.com/Fuabioo/hook-chain/internal/quota"
 	"github.com/Fuabioo/hook-chain/internal/runner"
+	"github.com/Fuabioo/hook-chain/internal/scratch"
+	"github.com/Fuabioo/hook-chain/internal/sink"
+	"github.com/Fuabioo/hook-chain/internal/slots"
+	"github.com/Fuabioo/hook-chain/internal/state"
+	"github.com/Fuabioo/hook-chain/internal/transcript"
 )
 
+// liveFlushTimeout bounds how long a hook invocation spends streaming to live sinks.
+const liveFlushTimeout = 5 * time.Second
+
 var (
 	Version = "dev"
 	Commit  = "unknown"
@@ -44,6 +67,33 @@ func newLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 }
 
+// applyConfigFlag points the config loaders at the --config file, if given.
+// Like --profile it goes through the environment, so it takes precedence
+// over $HOOK_CHAIN_CONFIG and is passed on to hooks. A file path is made
+// absolute because hooks may run in another directory; a URL is fetched by
+// the loaders.
+func applyConfigFlag(cmd *cobra.Command) error {
+	// The root's flag: the test command has a --config of its own.
+	path, err := cmd.Root().PersistentFlags().GetString("config")
+	if err != nil {
+		return fmt.Errorf("invalid --config: %w", err)
+	}
+	if path == "" {
+		return nil
+	}
+	if config.IsRemote(path) {
+		return os.Setenv(config.ConfigEnv, path)
+	}
+	abs, err := filepath.Abs(pathutil.Expand(path))
+	if err != nil {
+		return fmt.Errorf("invalid --config: %w", err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("invalid --config: %w", err)
+	}
+	return os.Setenv(config.ConfigEnv, abs)
+}
+
 func newRootCmd() *cobra.Command {
 	root := &cobra.Command{
 		Use:           "hook-chain",
@@ -52,10 +102,53 @@ func newRootCmd() *cobra.Command {
 		SilenceErrors: true,
 		RunE:          runRoot,
 	}
+	root.PersistentFlags().String("config", "", "config file to use (default: $HOOK_CHAIN_CONFIG, else ~/.config/hook-chain/config.yaml)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		return applyConfigFlag(cmd)
+	}
+	if queryOnly() {
+		root.Short = "Audit log queries for hook-chain (query-only mode)"
+		root.RunE = runQueryOnly
+		root.AddCommand(newVersionCmd(), newReleaseManifestCmd(), newAuditCmd())
+		return root
+	}
+	root.Flags().String("adapter", "", "read hook input in this configured agent format (default: $HOOK_CHAIN_ADAPTER, else auto-detect)")
+	root.PersistentFlags().String("profile", "", "config profile to use (default: $HOOK_CHAIN_PROFILE, else default_profile)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		if err := applyConfigFlag(cmd); err != nil {
+			return err
+		}
+		// The config loaders read the profile from the environment, which
+		// also passes it on to hooks.
+		profile, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			return fmt.Errorf("invalid --profile: %w", err)
+		}
+		if profile != "" {
+			return os.Setenv(config.ProfileEnv, profile)
+		}
+		return nil
+	}
 
 	root.AddCommand(newValidateCmd())
+	root.AddCommand(newLintHooksCmd())
 	root.AddCommand(newVersionCmd())
+	root.AddCommand(newReleaseManifestCmd())
 	root.AddCommand(newAuditCmd())
+	root.AddCommand(newHooksCmd())
+	root.AddCommand(newExceptionsCmd())
+	root.AddCommand(newStateCmd())
+	root.AddCommand(newRulesCmd())
+	root.AddCommand(newChainsCmd())
+	root.AddCommand(newConfigCmd())
+	root.AddCommand(newImportSettingsCmd())
+	root.AddCommand(newHealthCmd())
+	root.AddCommand(newAsyncRunCmd())
+	root.AddCommand(newConformCmd())
+	root.AddCommand(newTestCmd())
+	root.AddCommand(newTelemetryCmd())
+	root.AddCommand(newMaintainCmd())
+	root.AddCommand(newMockHookCmd())
 
 	return root
 }
@@ -63,27 +156,64 @@ func newRootCmd() *cobra.Command {
 // Execute runs the CLI and returns the process exit code.
 func Execute() int {
 	cmd := newRootCmd()
-	if err := cmd.Execute(); err != nil {
-		var ee *exitError
-		if errors.As(err, &ee) {
-			return ee.code
-		}
+	err := cmd.Execute()
+	var ee *exitError
+	if err != nil && !errors.As(err, &ee) {
 		fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
-		return 1
 	}
-	return 0
+	return exitCode(err)
+}
+
+// exitCode returns the process exit code for a command's error.
+func exitCode(err error) int {
+	var ee *exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &ee):
+		return ee.code
+	}
+	return 1
 }
 
 // runRoot is the default command: read stdin, resolve chain, run pipeline.
 func runRoot(cmd *cobra.Command, _ []string) error {
 	logger := newLogger()
 
+	// Record a debug bundle of the run when HOOK_CHAIN_CAPTURE_DIR is set
+	// (best-effort: capturing never changes the decision).
+	bundle, err := capture.New(os.Getenv(capture.EnvVar))
+	if err != nil {
+		logger.Warn("failed to create capture bundle, continuing without", "err", err)
+	}
+	err = runHook(cmd, io.MultiWriter(os.Stdout, bundle.Output()), bundle, logger)
+	if err := bundle.Finish(exitCode(err)); err != nil {
+		logger.Warn("failed to write capture bundle", "dir", bundle.Dir(), "err", err)
+	}
+	return err
+}
+
+// runHook handles one hook invocation, writing the hook protocol output to
+// stdout and recording the run in bundle.
+func runHook(cmd *cobra.Command, stdout io.Writer, bundle *capture.Bundle, logger *slog.Logger) error {
+	// SIGINT or SIGTERM (the agent giving up on the hook) cancels the running
+	// hooks instead of killing hook-chain, so the run is still audited and
+	// the audit database is closed cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Catching SIGPIPE turns writing to an agent that stopped reading into an
+	// error rather than a kill. Unlike ignoring it, catching is not inherited
+	// by the hooks.
+	sigpipe := make(chan os.Signal, 1)
+	signal.Notify(sigpipe, syscall.SIGPIPE)
+	defer signal.Stop(sigpipe)
+
 	// Read all of stdin.
 	data, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		// Fail closed: if we cannot read input, the security chain cannot run.
 		logger.Error("failed to read stdin", "err", err)
-		writeDenyJSON("hook-chain: failed to read stdin")
+		writeDenyJSON(stdout, "hook-chain: failed to read stdin")
 		return &exitError{code: 2}
 	}
 
@@ -91,21 +221,83 @@ func runRoot(cmd *cobra.Command, _ []string) error {
 		logger.Debug("empty stdin, passthrough")
 		return nil
 	}
+	if err := bundle.WriteFile(capture.InputFile, data); err != nil {
+		logger.Warn("failed to capture input", "err", err)
+	}
+
+	// Load config, with the project config for the session's cwd layered
+	// over the user's.
+	cfg, err := config.LoadFor(inputCWD(data))
+	if err != nil {
+		// Config parse error → fail closed (exit 2).
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		auditConfigError(config.Config{}, data, err, logger)
+		return &exitError{code: 2}
+	}
+	if err := bundle.WriteConfig(cfg); err != nil {
+		logger.Warn("failed to capture config", "err", err)
+	}
+	for _, m := range cfg.Migrations {
+		logger.Warn("config uses an older layout, migrated in memory; run `hook-chain config migrate`", "change", m)
+	}
+	if rs := cfg.RemoteStatus; rs != nil && rs.Err != nil {
+		logger.Warn("remote config unavailable, using cached copy", "url", rs.URL, "fetched", rs.Fetched, "err", rs.Err)
+	}
+
+	// Send the daily usage report in the background (opt-in).
+	defer maybeSendTelemetry(cfg, logger)
+
+	// Normalize other agents' payloads into the hook protocol (fail closed).
+	if err := adapter.Validate(cfg.Adapters); err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		auditConfigError(cfg, data, err, logger)
+		return &exitError{code: 2}
+	}
+	adapterName, err := cmd.Flags().GetString("adapter")
+	if err != nil {
+		return fmt.Errorf("invalid --adapter: %w", err)
+	}
+	if adapterName == "" {
+		adapterName = os.Getenv("HOOK_CHAIN_ADAPTER")
+	}
+	if len(cfg.Adapters) > 0 || adapterName != "" {
+		a, ok, err := adapter.Select(cfg.Adapters, adapterName, data)
+		if err == nil && ok {
+			logger.Debug("normalizing input", "adapter", a.Name)
+			data, err = adapter.Normalize(a, data)
+		}
+		if err != nil {
+			logger.Error("failed to adapt hook input", "err", err)
+			writeDenyJSON(stdout, "hook-chain: failed to adapt hook input")
+			return &exitError{code: 2}
+		}
+	}
 
 	// Parse as hook.Input.
 	var input hook.Input
 	if err := json.Unmarshal(data, &input); err != nil {
 		// Fail closed: if we cannot parse input, the security chain cannot run.
 		logger.Error("failed to parse stdin as JSON", "err", err)
-		writeDenyJSON("hook-chain: failed to parse hook input")
+		writeDenyJSON(stdout, "hook-chain: failed to parse hook input")
 		return &exitError{code: 2}
 	}
 
-	// Load config.
-	cfg, err := config.Load()
+	// Warn loudly when the input does not look like the protocol hook-chain
+	// parses; unknown fields are forwarded to hooks as-is.
+	fp := input.Fingerprint()
+	if len(fp.Problems) > 0 {
+		logger.Warn("hook input has an unrecognized shape; fields may be mis-parsed",
+			"protocol", fp.Version, "problems", strings.Join(fp.Problems, "; "))
+	}
+	if len(fp.Unknown) > 0 {
+		logger.Debug("forwarding unknown input fields", "fields", fp.Unknown)
+	}
+
+	// Message overrides are part of the config: a bad template fails closed too.
+	msgs, err := messages.New(cfg.Messages)
 	if err != nil {
-		// Config parse error → fail closed (exit 2).
 		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		auditConfigError(cfg, data, err, logger)
 		return &exitError{code: 2}
 	}
 
@@ -113,14 +305,8 @@ func runRoot(cmd *cobra.Command, _ []string) error {
 	// Audit is enabled by default. Disable with HOOK_CHAIN_AUDIT=0 or audit.disabled: true in config.
 	var auditor audit.Auditor
 	var sqliteAuditor *audit.SQLiteAuditor
-	var dbPath string
-	auditDisabled := os.Getenv("HOOK_CHAIN_AUDIT") == "0" || (cfg.Audit != nil && cfg.Audit.Disabled)
-	if !auditDisabled {
-		if cfg.Audit != nil && cfg.Audit.DBPath != "" {
-			dbPath = cfg.Audit.DBPath
-		} else {
-			dbPath = audit.DefaultDBPath()
-		}
+	dbPath := auditDBPath(cfg)
+	if dbPath != "" {
 		a, err := audit.Open(dbPath)
 		if err != nil {
 			logger.Warn("failed to open audit db, continuing without audit", "err", err)
@@ -131,9 +317,31 @@ func runRoot(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
-	// Resolve chain.
-	hooks := cfg.Resolve(input.HookEventName, input.ToolName)
-	if len(hooks) == 0 {
+	// Queue every recorded chain for live streaming sinks.
+	live := liveSinks(cfg, logger)
+	if sqliteAuditor != nil && len(live) > 0 {
+		names := make([]string, 0, len(live))
+		for _, ls := range live {
+			names = append(names, ls.name)
+		}
+		sqliteAuditor.SetOutboxSinks(names...)
+	}
+
+	// Garbage-collect hook state after the event's own cleanup chain ran:
+	// SessionEnd drops the ended session's keys, and both SessionEnd and
+	// Stop prune sessions that went idle without ending cleanly.
+	switch input.HookEventName {
+	case "SessionEnd":
+		defer collectSessionState(input.SessionID, logger)
+	case "Stop":
+		defer collectSessionState("", logger)
+	}
+
+	// Resolve chain. Hooks disabled via `hook-chain hooks disable` are
+	// skipped (and audited) by the pipeline.
+	chain, _ := cfg.ResolveInput(input)
+	hooks, finally := chain.Hooks, chain.Finally
+	if len(hooks) == 0 && len(finally) == 0 {
 		logger.Debug("no matching chain, passthrough",
 			"event", input.HookEventName, "tool", input.ToolName)
 		return nil
@@ -144,38 +352,171 @@ func runRoot(cmd *cobra.Command, _ []string) error {
 		"tool", input.ToolName,
 		"hooks", len(hooks))
 
-	// Run pipeline.
-	ctx := context.Background()
-	result := pipeline.Run(ctx, &input, hooks, runner.ProcessRunner{}, auditor, logger)
+	// Settle each hook's timeout: its own or defaults.timeout, capped at
+	// limits.max_hook_timeout.
+	hooks = withTimeouts(cfg, hooks, logger)
+	finally = withTimeouts(cfg, finally, logger)
+
+	// Layer defaults.env and the chain's env under each hook's own.
+	hooks = cfg.ApplyEnv(chain, hooks)
+	finally = cfg.ApplyEnv(chain, finally)
+
+	// Point hooks at their per-session state store (`hook-chain state`).
+	hooks = withStateEnv(hooks, input.SessionID)
+	finally = withStateEnv(finally, input.SessionID)
+
+	// Offer the session's rolling risk score to hooks (best-effort).
+	if sqliteAuditor != nil && input.SessionID != "" {
+		risk, err := audit.SessionRiskScore(sqliteAuditor.DB(), input.SessionID, time.Now().Add(-audit.RiskWindow))
+		if err != nil {
+			logger.Warn("failed to compute session risk", "err", err)
+		} else {
+			riskEnv := fmt.Sprintf("HOOK_CHAIN_SESSION_RISK=%d", risk.Score)
+			hooks = withEnv(hooks, riskEnv)
+			finally = withEnv(finally, riskEnv)
+		}
+	}
+
+	// Offer recent transcript activity to context-aware hooks (opt-in, best-effort).
+	if cfg.Transcript.Context > 0 {
+		input = withRecentActivity(input, cfg.Transcript.Context, logger)
+	}
+
+	// Offer the pending file change as a unified diff (opt-in, best-effort).
+	if cfg.Diff.Enabled && input.HookEventName == "PreToolUse" {
+		input = withDiff(input, cfg.Diff.EffectiveContext(), logger)
+	}
 
-	// Write output if present.
+	// Warn about (or demote) hooks that consistently exceed their latency budget.
+	if sqliteAuditor != nil {
+		statuses, err := budget.Check(sqliteAuditor.DB(), hooks)
+		if err != nil {
+			logger.Warn("failed to check latency budgets", "err", err)
+		} else {
+			hooks = budget.Apply(hooks, statuses, logger)
+		}
+	}
+
+	// Queue for one of the machine's pipeline slots; fail closed on timeout.
+	slot, err := slots.Acquire(ctx, slots.DefaultDir(), cfg.Concurrency.Max, cfg.Concurrency.EffectiveTimeout())
+	if err != nil {
+		logger.Error("no free pipeline slot", "max", cfg.Concurrency.Max, "err", err)
+		writeDenyJSON(stdout, fmt.Sprintf("hook-chain: too many concurrent invocations (max %d): %v", cfg.Concurrency.Max, err))
+		return &exitError{code: 2}
+	}
+	defer func() { _ = slot.Release() }()
+
+	// Give every hook run its own temp directory, removed with the chain.
+	var ws *scratch.Workspace
+	if !cfg.Scratch.Disabled {
+		ws, err = scratch.New("", cfg.Scratch.EffectiveQuota())
+		if err != nil {
+			logger.Warn("failed to create hook temp workspace, continuing without", "err", err)
+		} else {
+			defer func() {
+				if err := ws.Remove(); err != nil {
+					logger.Warn("failed to clean up hook temp workspace", "dir", ws.Root(), "err", err)
+				}
+			}()
+		}
+	}
+
+	// Run pipeline, publishing lifecycle events to configured plugins.
+	bus := newEventBus(cfg, logger)
+	defer func() {
+		if err := bus.Close(); err != nil {
+			logger.Warn("plugin delivery failed", "err", err)
+		}
+	}()
+
+	// Quota runs are counted in a store shared by every session, opened
+	// only for chains with quotas.
+	var quotas pipeline.QuotaTaker
+	if chain.HasQuotas() {
+		qs, err := quota.Open(quota.DefaultPath())
+		if err != nil {
+			logger.Warn("quota store unavailable, quotas not enforced", "err", err)
+		} else {
+			defer func() { _ = qs.Close() }()
+			quotas = qs
+		}
+	}
+
+	var asyncHooks []pipeline.AsyncHook
+	var outcome, reason string
+	result := pipeline.Run(ctx, &input, hooks, bundle.Runner(newRunners(ws)), auditor, logger,
+		pipeline.WithEventBus(bus),
+		pipeline.WithFinally(finally),
+		pipeline.WithMessages(msgs),
+		pipeline.WithRunbooks(cfg.Runbooks),
+		pipeline.WithSeverityActions(chain.Severity),
+		pipeline.WithProtectedFields(chain.ProtectedFields, chain.EffectiveOnProtected()),
+		pipeline.WithQuotas(quotas, chain.QuotaKey(), chain.Quota),
+		pipeline.WithDenyAsk(chain.OnDeny.Ask),
+		pipeline.WithRisk(chain.Risk),
+		pipeline.WithMaxDuration(chain.MaxDuration, chain.EffectiveOnMaxDuration()),
+		pipeline.WithExceptions(loadExceptions(logger)),
+		pipeline.WithDisabledHooks(loadDisabled(logger)),
+		pipeline.WithTranscriptNotes(transcriptNotesPath(cfg, input.TranscriptPath, logger)),
+		pipeline.WithAsyncLauncher(func(ah pipeline.AsyncHook) { asyncHooks = append(asyncHooks, ah) }),
+		pipeline.WithDecisionHandler(func(o, r string) { outcome, reason = o, r }),
+	)
+
+	// Write output if present. A decision that never reached the agent is
+	// flagged in the audit log, and the run exits 2 (fail closed) instead of
+	// with the exit code of the undelivered decision.
+	var deliveryErr error
 	if len(result.Output) > 0 {
-		if _, err := os.Stdout.Write(result.Output); err != nil {
-			logger.Error("failed to write output", "err", err)
+		if deliveryErr = hook.WriteOutput(stdout, result.Output); deliveryErr != nil {
+			if errors.Is(deliveryErr, hook.ErrBrokenPipe) {
+				logger.Warn("agent stopped reading, decision not delivered", "outcome", outcome, "err", deliveryErr)
+			} else {
+				logger.Error("failed to write output", "outcome", outcome, "err", deliveryErr)
+			}
+			if id := sqliteAuditor.LastChainID(); id != 0 {
+				if err := audit.MarkDeliveryFailed(sqliteAuditor.DB(), id); err != nil {
+					logger.Warn("failed to record failed delivery", "err", err)
+				}
+			}
 		}
 	}
 
-	// Auto-rotate audit entries after pipeline completes.
+	// Launch async hooks only after the decision is written, so they never delay it.
+	launchAsync(asyncHooks, dbPath, sqliteAuditor.LastChainID(), logger)
+	launchChainActions(cfg, chain, input, outcome, reason, sqliteAuditor.LastChainID(), logger)
+
+	// Stream the outbox to live sinks (fail-open: undelivered records stay queued).
 	if sqliteAuditor != nil {
+		flushLiveSinks(ctx, sqliteAuditor.DB(), live, logger)
+	}
+
+	// Auto-rotate audit entries after pipeline completes, unless
+	// `hook-chain maintain` runs rotation on a schedule instead.
+	if sqliteAuditor != nil && !cfg.Audit.ScheduledMaintenance() {
 		rotCfg := audit.RotationConfig{
 			Retention:   resolveRetention(cfg, logger),
-			ArchiveDir:  filepath.Join(filepath.Dir(dbPath), "archives"),
-			ThrottleDir: filepath.Join(filepath.Dir(dbPath), "archives"),
+			ArchiveDir:  auditArchiveDir(cfg, dbPath),
+			ThrottleDir: auditArchiveDir(cfg, dbPath),
+			OnAnomaly:   func(a audit.Anomaly) { bus.Publish(anomalyEvent(a)) },
 		}
 		audit.MaybeRotate(sqliteAuditor.DB(), rotCfg, logger)
 	}
 
+	if deliveryErr != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: %s decision not delivered: %v\n", outcome, deliveryErr)
+		return &exitError{code: 2}
+	}
 	if result.ExitCode != 0 {
 		return &exitError{code: result.ExitCode}
 	}
 	return nil
 }
 
-// writeDenyJSON writes a deny response to stdout in the hook protocol format.
+// writeDenyJSON writes a deny response to w in the hook protocol format.
 // Used for early failures (stdin read error, JSON parse error) where the
 // security chain cannot run. Errors are logged but not propagated — the
 // caller should also return exitError{code: 2}.
-func writeDenyJSON(reason string) {
+func writeDenyJSON(w io.Writer, reason string) {
 	out := hook.Output{
 		HookSpecificOutput: hook.HookSpecificOutput{
 			PermissionDecision:       "deny",
@@ -187,7 +528,145 @@ func writeDenyJSON(reason string) {
 		// Last resort: hardcoded JSON.
 		data = []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"hook-chain: internal error"}}`)
 	}
-	_, _ = os.Stdout.Write(data)
+	_, _ = w.Write(data)
+}
+
+// auditDBPath returns the audit database path for cfg, or "" when audit is
+// disabled via HOOK_CHAIN_AUDIT=0 or audit.disabled in config.
+func auditDBPath(cfg config.Config) string {
+	if os.Getenv("HOOK_CHAIN_AUDIT") == "0" || (cfg.Audit != nil && cfg.Audit.Disabled) {
+		return ""
+	}
+	if cfg.Audit != nil && cfg.Audit.DBPath != "" {
+		return pathutil.Expand(cfg.Audit.DBPath)
+	}
+	return audit.DefaultDBPath()
+}
+
+// configFiles returns the config files in effect for the current directory:
+// the user config and the project config, when they exist.
+func configFiles() []string {
+	var files []string
+	if p := config.DefaultPath(); p != "" {
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if p := config.FindProject(cwd); p != "" && !slices.Contains(files, p) {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// auditArchiveDir returns the directory rotated audit archives go to:
+// audit.archive_dir, or "archives" next to dbPath.
+func auditArchiveDir(cfg config.Config, dbPath string) string {
+	if cfg.Audit != nil && cfg.Audit.ArchiveDir != "" {
+		return pathutil.Expand(cfg.Audit.ArchiveDir)
+	}
+	return filepath.Join(filepath.Dir(dbPath), "archives")
+}
+
+// inputCWD returns the cwd field of the raw hook input, falling back to the
+// process's working directory when the payload has none.
+func inputCWD(data []byte) string {
+	var in struct {
+		CWD string `json:"cwd"`
+	}
+	if json.Unmarshal(data, &in) == nil && in.CWD != "" {
+		return in.CWD
+	}
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// auditConfigError records a config failure as an error chain so broken
+// configs show up in the audit log, not only on stderr. cfg is the zero
+// Config when the file did not load, which selects the default database.
+// Best-effort: the input may not parse and audit failures are only logged.
+func auditConfigError(cfg config.Config, data []byte, cfgErr error, logger *slog.Logger) {
+	dbPath := auditDBPath(cfg)
+	if dbPath == "" {
+		return
+	}
+	a, err := audit.Open(dbPath)
+	if err != nil {
+		logger.Warn("failed to open audit db, config error not recorded", "err", err)
+		return
+	}
+	defer func() { _ = a.Close() }()
+
+	var input hook.Input
+	_ = json.Unmarshal(data, &input)
+	entry := audit.ChainExecution{
+		Timestamp: time.Now().UTC(),
+		EventName: input.HookEventName,
+		ToolName:  input.ToolName,
+		SessionID: input.SessionID,
+		Outcome:   audit.OutcomeError,
+		Reason:    "config error: " + cfgErr.Error(),
+	}
+	if input.HookEventName != "" {
+		entry.ProtocolVersion = input.Fingerprint().Version
+	}
+	if err := a.RecordChain(entry); err != nil {
+		logger.Warn("failed to record config error", "err", err)
+	}
+}
+
+// transcriptNotesPath returns the file transcript notes are appended to, or
+// "" when notes are off. An invalid mode is logged and disables notes.
+func transcriptNotesPath(cfg config.Config, transcriptPath string, logger *slog.Logger) string {
+	path, err := transcript.NotesPath(cfg.Transcript.Notes, transcriptPath)
+	if err != nil {
+		logger.Warn("invalid transcript notes config, writing no notes", "err", err)
+		return ""
+	}
+	return path
+}
+
+// withRecentActivity adds the last n transcript entries to input as the
+// recent_activity field. Read errors are logged and the field is omitted.
+func withRecentActivity(input hook.Input, n int, logger *slog.Logger) hook.Input {
+	if input.TranscriptPath == "" {
+		return input
+	}
+	recent, err := transcript.Recent(input.TranscriptPath, n)
+	if err != nil {
+		logger.Warn("failed to read transcript, passing no recent activity", "err", err)
+		return input
+	}
+	if recent == nil {
+		recent = []transcript.Activity{}
+	}
+	raw, err := json.Marshal(recent)
+	if err != nil {
+		logger.Warn("failed to marshal recent activity", "err", err)
+		return input
+	}
+	return input.WithField("recent_activity", raw)
+}
+
+// withDiff adds the unified diff an Edit, MultiEdit, or Write call would
+// make as the diff field. Other tools are left alone; failures are logged
+// and the field is omitted.
+func withDiff(input hook.Input, context int, logger *slog.Logger) hook.Input {
+	d, ok, err := diff.ForTool(input.ToolName, input.ToolInput, input.CWD, context)
+	if !ok {
+		return input
+	}
+	if err != nil {
+		logger.Warn("failed to compute file diff, passing none", "tool", input.ToolName, "err", err)
+		return input
+	}
+	raw, err := json.Marshal(d)
+	if err != nil {
+		logger.Warn("failed to marshal file diff", "err", err)
+		return input
+	}
+	return input.WithField("diff", raw)
 }
 
 // resolveRetention returns the audit retention duration from config, defaulting to 7 days.
@@ -204,12 +683,143 @@ func resolveRetention(cfg config.Config, logger *slog.Logger) time.Duration {
 	return d
 }
 
+// newRunners returns the registry hooks run through, by type. Process and
+// shell hooks get a temp directory from ws, if not nil; builtins run
+// in-process.
+func newRunners(ws *scratch.Workspace) *runner.Registry {
+	reg := runner.NewRegistry()
+	reg.Register(config.HookTypeProcess, ws.Runner(runner.ProcessRunner{}))
+	reg.Register(config.HookTypeShell, ws.Runner(runner.ShellRunner{}))
+	builtin.Register(reg)
+	return reg
+}
+
+// withTimeouts applies cfg's default and maximum hook timeouts to hooks,
+// warning about timeouts that the maximum cuts short.
+func withTimeouts(cfg config.Config, hooks []config.HookEntry, logger *slog.Logger) []config.HookEntry {
+	out := cfg.ApplyTimeouts(hooks)
+	for i, h := range hooks {
+		if h.Timeout > out[i].Timeout {
+			logger.Warn("hook timeout exceeds limits.max_hook_timeout, capping",
+				"hook", h.Name, "timeout", h.Timeout, "max", out[i].Timeout)
+		}
+	}
+	return out
+}
+
+// withEnv returns a copy of hooks with vars appended to each hook's Env.
+// Hook-level entries come first so hook-chain's own variables win on conflict.
+func withEnv(hooks []config.HookEntry, vars ...string) []config.HookEntry {
+	out := make([]config.HookEntry, len(hooks))
+	for i, h := range hooks {
+		h.Env = append(slices.Clone(h.Env), vars...)
+		out[i] = h
+	}
+	return out
+}
+
+// newEventBus builds the event bus with one exec'd subscriber per configured plugin.
+func newEventBus(cfg config.Config, logger *slog.Logger) *events.Bus {
+	bus := events.NewBus(logger)
+	for _, p := range cfg.Plugins {
+		kinds := make([]events.Kind, 0, len(p.Events))
+		for _, k := range p.Events {
+			kinds = append(kinds, events.Kind(k))
+		}
+		bus.Subscribe(&events.ExecSubscriber{
+			Name:    p.Name,
+			Command: p.Command,
+			Args:    p.Args,
+			Kinds:   kinds,
+			Timeout: p.Timeout,
+		})
+	}
+	return bus
+}
+
+// namedSink pairs a built sink with its config name (the outbox key).
+type namedSink struct {
+	name string
+	sink sink.Sink
+}
+
+// liveSinks builds the sinks marked live in config. Misconfigured sinks are
+// logged and skipped so they never block the pipeline.
+func liveSinks(cfg config.Config, logger *slog.Logger) []namedSink {
+	if cfg.Audit == nil {
+		return nil
+	}
+	var out []namedSink
+	for _, sc := range cfg.Audit.Sinks {
+		if !sc.Live {
+			continue
+		}
+		s, err := sink.New(sc)
+		if err != nil {
+			logger.Warn("skipping live sink", "sink", sc.Name, "err", err)
+			continue
+		}
+		out = append(out, namedSink{name: sc.Name, sink: s})
+	}
+	return out
+}
+
+// flushLiveSinks delivers queued outbox entries to each live sink within
+// liveFlushTimeout. Failures are logged; entries stay queued for the next run.
+func flushLiveSinks(ctx context.Context, db *sql.DB, live []namedSink, logger *slog.Logger) {
+	if len(live) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, liveFlushTimeout)
+	defer cancel()
+	for _, ls := range live {
+		sent, err := sink.FlushOutbox(ctx, db, ls.name, ls.sink)
+		if err != nil {
+			logger.Warn("live sink delivery failed", "sink", ls.name, "sent", sent, "err", err)
+			continue
+		}
+		logger.Debug("live sink flushed", "sink", ls.name, "sent", sent)
+	}
+}
+
+// anomalyEvent converts a detected anomaly into a bus event for plugins.
+func anomalyEvent(a audit.Anomaly) events.Event {
+	e := events.Event{
+		Kind:    events.KindAnomaly,
+		Outcome: a.Kind,
+		Reason:  a.Detail,
+	}
+	switch a.Kind {
+	case audit.AnomalyLatencyRegression:
+		e.HookName = a.Subject
+	case audit.AnomalyDestructiveSession:
+		e.SessionID = a.Subject
+	}
+	return e
+}
+
 func newVersionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
 		Short: "Print version information",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("hook-chain %s (%s)\n", Version, Commit)
+			m := buildinfo.Read(Version, Commit)
+			mode := ""
+			if queryOnly() {
+				mode = ", query-only"
+			}
+			fmt.Printf("hook-chain %s (%s%s)\n", m.Version, m.Commit, mode)
+		},
+	}
+}
+
+func newReleaseManifestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "release-manifest",
+		Short: "Print build metadata (version, commit, build flags, dependencies) as JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printJSON(buildinfo.Read(Version, Commit))
 		},
 	}
 }
@@ -223,44 +833,438 @@ func newValidateCmd() *cobra.Command {
 }
 
 func runValidate(cmd *cobra.Command, _ []string) error {
+	// The strict pass runs first: it locates the type errors that make Load
+	// fail, and the unknown keys Load ignores.
+	strictIssues := false
+	for _, path := range configFiles() {
+		problems, err := config.Strict(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
+		}
+		for _, p := range problems {
+			fmt.Printf("Config: %s:%d: %s\n", path, p.Line, p.Message)
+			strictIssues = true
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
 		return &exitError{code: 1}
 	}
 
+	if _, err := messages.New(cfg.Messages); err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		return &exitError{code: 1}
+	}
+	if err := adapter.Validate(cfg.Adapters); err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		return &exitError{code: 1}
+	}
+	if _, err := transcript.NotesPath(cfg.Transcript.Notes, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		return &exitError{code: 1}
+	}
+	if err := cfg.Audit.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: config error: %v\n", err)
+		return &exitError{code: 1}
+	}
+
 	if len(cfg.Chains) == 0 {
 		fmt.Println("No chains configured.")
 		return nil
 	}
 
-	hasIssues := false
+	st, err := state.Load(state.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
+	}
+	now := time.Now().UTC()
+
+	// Observed latencies come from the audit log when it exists (best-effort).
+	var auditDB *sql.DB
+	if dbPath := auditDBPath(cfg); dbPath != "" {
+		if db, err := openAuditDBReadOnlyAt(dbPath); err == nil {
+			auditDB = db
+			defer func() { _ = db.Close() }()
+		}
+	}
+
+	hasIssues := strictIssues
+	runners := newRunners(nil)
+	for _, m := range cfg.Migrations {
+		fmt.Printf("Warning: %s (migrated in memory; run `hook-chain config migrate` to rewrite the file)\n", m)
+	}
+	if rs := cfg.RemoteStatus; rs != nil {
+		fmt.Printf("Remote: %s (cached at %s, fetched %s)\n", rs.URL, rs.Path, rs.Fetched.Local().Format(time.RFC3339))
+		if rs.Err != nil {
+			fmt.Printf("  Stale: fetch failed, using the cached copy: %v\n", rs.Err)
+		}
+	}
+	if profile := cfg.ActiveProfile(); profile != "" {
+		fmt.Printf("Profile: %s (%d chain(s))\n", profile, len(cfg.Profiles[profile].Chains))
+	}
+	if err := cfg.ValidateResolution(); err != nil {
+		fmt.Printf("Resolution: %v\n", err)
+		hasIssues = true
+	} else if cfg.Resolution == config.ResolutionAll {
+		fmt.Println("Resolution: all (every matching chain runs, in order)")
+	}
+	for _, err := range cfg.ValidateTimeouts() {
+		fmt.Printf("Timeouts: %v\n", err)
+		hasIssues = true
+	}
+	for _, err := range cfg.ValidateEnv() {
+		fmt.Printf("Env: %v\n", err)
+		hasIssues = true
+	}
+	if order := cfg.ChainOrder(); !slices.IsSorted(order) {
+		labels := make([]string, len(order))
+		for i, n := range order {
+			labels[i] = strconv.Itoa(n + 1)
+		}
+		fmt.Printf("Priority order: chains %s\n", strings.Join(labels, ", "))
+	}
 
 	for i, chain := range cfg.Chains {
-		fmt.Printf("Chain %d: event=%s tools=%v\n", i+1, chain.Event, chain.Tools)
-		for j, h := range chain.Hooks {
-			cmdStr := pathutil.ExpandTilde(h.Command)
-			parts := strings.Fields(cmdStr)
+		priority := ""
+		if chain.Priority != 0 {
+			priority = fmt.Sprintf(" priority=%d", chain.Priority)
+		}
+		tools := ""
+		if len(chain.ToolsExclude) > 0 {
+			tools += fmt.Sprintf(" tools_exclude=%v", chain.ToolsExclude)
+		}
+		if chain.MCPServer != "" {
+			tools += " mcp_server=" + chain.MCPServer
+		}
+		if chain.MCPTool != "" {
+			tools += " mcp_tool=" + chain.MCPTool
+		}
+		disabled := ""
+		if chain.Disabled {
+			disabled = " [DISABLED in config]"
+		}
+		fmt.Printf("Chain %d: event=%s tools=%v%s%s%s\n", i+1, chain.EventLabel(), chain.Tools, tools, priority, disabled)
+		if chain.Source != "" {
+			fmt.Printf("  From: %s\n", chain.Source)
+		}
+		if chain.Profile != "" {
+			fmt.Printf("  Profile: %s\n", chain.Profile)
+		}
+		for _, err := range chain.ValidateEvents() {
+			fmt.Printf("  Events: %v\n", err)
+			hasIssues = true
+		}
+		if errs := chain.ValidateSchedule(); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Printf("  Active: %v\n", err)
+			}
+			hasIssues = true
+		} else if chain.HasSchedule() {
+			state := "inactive now"
+			if chain.ActiveAt(time.Now()) {
+				state = "active now"
+			}
+			fmt.Printf("  Active: %s (%s)\n", chain.ScheduleLabel(), state)
+		}
+		for _, err := range budget.ValidateChain(chain) {
+			fmt.Printf("  Budget: %v\n", err)
+			hasIssues = true
+		}
+		for _, err := range chain.ValidateTools() {
+			fmt.Printf("  Tools: %v\n", err)
+			hasIssues = true
+		}
+		for _, err := range chain.ValidateActions() {
+			fmt.Printf("  Actions: %v\n", err)
+			hasIssues = true
+		}
+		if chain.OnDeny.Ask {
+			fmt.Println("  On deny: ask (denials become asks; hooks are still audited as denials)")
+		}
+		if errs := chain.ValidateMaxDuration(); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Printf("  Max duration: %v\n", err)
+			}
+			hasIssues = true
+		} else if chain.MaxDuration > 0 {
+			fmt.Printf("  Max duration: %s (then %s)\n", chain.MaxDuration, chain.EffectiveOnMaxDuration())
+		}
+		if errs := chain.ValidateRisk(); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Printf("  Risk: %v\n", err)
+			}
+			hasIssues = true
+		} else if chain.Risk != nil {
+			fmt.Printf("  Risk: %s\n", chain.Risk.Label())
+		}
+		if m := chain.Match; m != nil {
+			var conds []string
+			if m.CommandRegex != "" {
+				conds = append(conds, "command_regex="+m.CommandRegex)
+			}
+			if m.FilePathGlob != "" {
+				conds = append(conds, "file_path_glob="+m.FilePathGlob)
+			}
+			if len(m.PermissionMode) > 0 {
+				conds = append(conds, "permission_mode="+strings.Join(m.PermissionMode, ","))
+			}
+			if m.CWDGlob != "" {
+				conds = append(conds, "cwd_glob="+m.CWDGlob)
+			}
+			fmt.Printf("  Match: %s\n", strings.Join(conds, " "))
+			if err := m.Validate(); err != nil {
+				fmt.Printf("  Match: %v\n", err)
+				hasIssues = true
+			}
+		}
+		for _, err := range chain.ValidateSeverity() {
+			fmt.Printf("  Severity: %v\n", err)
+			hasIssues = true
+		}
+		for _, err := range chain.ValidateProtected() {
+			fmt.Printf("  Protected: %v\n", err)
+			hasIssues = true
+		}
+		if len(chain.ProtectedFields) > 0 {
+			fmt.Printf("  Protected: %s (on_protected=%s)\n", strings.Join(chain.ProtectedFields, ", "), chain.EffectiveOnProtected())
+		}
+		for _, err := range chain.ValidateQuotas() {
+			fmt.Printf("  Quota: %v\n", err)
+			hasIssues = true
+		}
+		if q := chain.Quota; q != nil && q.Validate() == nil {
+			fmt.Printf("  Quota: %s (on_exceeded=%s)\n", q.Label(), q.EffectiveOnExceeded())
+		}
+		if len(chain.Severity) > 0 {
+			var mapping []string
+			for _, sev := range config.Severities {
+				if a, ok := chain.Severity[sev]; ok {
+					mapping = append(mapping, sev+"="+a)
+				}
+			}
+			fmt.Printf("  Severity: %s\n", strings.Join(mapping, " "))
+		}
+		overBudget := map[string]budget.Status{}
+		if auditDB != nil {
+			statuses, err := budget.Check(auditDB, chain.Hooks)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
+			}
+			for _, st := range statuses {
+				if st.Over {
+					overBudget[st.Hook] = st
+				}
+			}
+		}
+		for j, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			label, n := "Hook", j+1
+			if j >= len(chain.Hooks) {
+				label, n = "Finally", j-len(chain.Hooks)+1
+			}
 			status := "OK"
-			if len(parts) == 0 {
-				status = "EMPTY COMMAND"
+			commands := []string{h.Command}
+			if err := h.ValidateType(); err != nil {
+				fmt.Printf("  Type: %v\n", err)
+				status = "INVALID TYPE"
+				hasIssues = true
+			} else if _, ok := runners.Lookup(h.EffectiveType()); !ok {
+				fmt.Printf("  Type: hook %q: unknown type %q (known: %s)\n", h.Name, h.EffectiveType(), strings.Join(runners.Types(), ", "))
+				status = "UNKNOWN TYPE"
+				hasIssues = true
+			}
+			if status != "OK" || h.EffectiveType() == config.HookTypeHTTP {
+				// Nothing to look up on PATH.
+				commands = nil
+			} else if h.Builtin != "" {
+				commands = nil
+				if err := builtin.Validate(h); err != nil {
+					fmt.Printf("  Builtin: %v\n", err)
+					status = "INVALID BUILTIN"
+					hasIssues = true
+				}
+			} else if len(h.Variants) > 0 {
+				commands = h.Variants
+				if err := h.ValidateVariants(); err != nil {
+					fmt.Printf("  Variants: %v\n", err)
+					status = "INVALID VARIANTS"
+					hasIssues = true
+				} else if label == "Finally" {
+					fmt.Printf("  Variants: hook %q: variants are not supported on finally hooks\n", h.Name)
+					status = "INVALID VARIANTS"
+					hasIssues = true
+				}
+			}
+			for _, c := range commands {
+				parts := pathutil.Fields(c)
+				if len(parts) == 0 {
+					status = "EMPTY COMMAND"
+					hasIssues = true
+				} else if _, err := hookdir.LookPath(parts[0]); err != nil {
+					status = fmt.Sprintf("NOT FOUND: %s", parts[0])
+					hasIssues = true
+				}
+			}
+			if err := h.ValidateWorkdir(); err != nil {
+				fmt.Printf("  Workdir: %v\n", err)
+				status += ", INVALID WORKDIR"
+				hasIssues = true
+			} else if dir := h.EffectiveWorkdir(); dir != "" {
+				if info, err := os.Stat(pathutil.Expand(dir)); err != nil || !info.IsDir() {
+					fmt.Printf("  Workdir: hook %q: %s is not a directory\n", h.Name, pathutil.Expand(dir))
+					status += ", NO WORKDIR"
+					hasIssues = true
+				}
+			}
+			if _, err := h.ReadEnvFile(); err != nil {
+				fmt.Printf("  Env file: %v\n", err)
+				status += ", INVALID ENV FILE"
+				hasIssues = true
+			}
+			if len(h.Variants) == 2 {
+				status += ", A/B (b in shadow)"
+			}
+			if h.Use != "" {
+				status += ", USE " + h.Use
+			}
+			if h.Severity != "" {
+				status += ", SEVERITY " + h.Severity
+			}
+			switch {
+			case h.Retries < 0 || h.RetryDelay < 0:
+				fmt.Printf("  Retries: hook %q: retries and retry_delay must not be negative\n", h.Name)
+				status += ", INVALID RETRIES"
+				hasIssues = true
+			case h.EffectiveRetries() > 0:
+				status += fmt.Sprintf(", RETRIES %d (from %s)", h.EffectiveRetries(), h.RetryBackoff(1))
+			}
+			if err := h.ValidateOutput(); err != nil {
+				fmt.Printf("  Output: %v\n", err)
+				status += ", INVALID OUTPUT"
+				hasIssues = true
+			} else if h.Output != "" && h.Output != config.OutputJSON {
+				status += ", OUTPUT " + h.Output
+			}
+			if errs := h.ValidateArgs(); len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Printf("  Args: %v\n", err)
+				}
+				status += ", INVALID ARGS"
 				hasIssues = true
-			} else if _, err := exec.LookPath(parts[0]); err != nil {
-				status = fmt.Sprintf("NOT FOUND: %s", parts[0])
+			}
+			if errs := h.ValidateExitCodes(); len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Printf("  Exit codes: %v\n", err)
+				}
+				status += ", INVALID EXIT CODES"
 				hasIssues = true
+			} else if len(h.ExitCodes) > 0 {
+				var codes []string
+				for _, code := range slices.Sorted(maps.Keys(h.ExitCodes)) {
+					codes = append(codes, fmt.Sprintf("%d=%s", code, h.ExitCodes[code].Decision))
+				}
+				status += ", EXIT CODES " + strings.Join(codes, " ")
 			}
 
-			timeout := h.Timeout.String()
-			if h.Timeout == 0 {
-				timeout = "30s (default)"
+			timeout := cfg.HookTimeout(h).String()
+			switch {
+			case h.Timeout == 0:
+				timeout += " (default)"
+			case h.Timeout > cfg.HookTimeout(h):
+				timeout += fmt.Sprintf(" (capped from %s)", h.Timeout)
 			}
 			onError := h.EffectiveOnError()
 
-			fmt.Printf("  Hook %d: name=%s command=%q timeout=%s on_error=%s [%s]\n",
-				j+1, h.Name, h.Command, timeout, onError, status)
+			if h.Disabled {
+				status += ", DISABLED in config"
+			} else if d, ok := st.DisabledEntry(h.Name, now); ok {
+				if d.Until.IsZero() {
+					status += ", DISABLED"
+				} else {
+					status += fmt.Sprintf(", DISABLED until %s", d.Until.Format(time.RFC3339))
+				}
+			}
+
+			if h.ReportOnly {
+				status += ", REPORT-ONLY"
+			}
+			if q := h.Quota; q != nil && j < len(chain.Hooks) {
+				if q.Validate() != nil {
+					status += ", INVALID QUOTA"
+				} else {
+					status += fmt.Sprintf(", QUOTA %s (%s)", q.Label(), q.EffectiveOnExceeded())
+				}
+			}
+			if h.Rollout != "" {
+				if pct, err := h.RolloutPercent(); err != nil {
+					status += ", INVALID ROLLOUT"
+					fmt.Printf("  Rollout: %v\n", err)
+					hasIssues = true
+				} else {
+					status += fmt.Sprintf(", ROLLOUT %g%%", pct)
+				}
+			}
+			switch h.Priority {
+			case "", config.PriorityNormal:
+			case config.PriorityLow:
+				status += ", LOW PRIORITY"
+			default:
+				status += ", INVALID PRIORITY"
+				fmt.Printf("  Priority: %q is not %q or %q\n", h.Priority, config.PriorityNormal, config.PriorityLow)
+				hasIssues = true
+			}
+			if st, ok := overBudget[h.Name]; ok {
+				status += fmt.Sprintf(", OVER BUDGET (median %s > %s, action=%s)", st.Median(), st.Budget, st.Action)
+				if st.Action == config.OverBudgetFailValidate {
+					hasIssues = true
+				}
+			}
+
+			cmdDesc := fmt.Sprintf("command=%q", h.Command)
+			switch {
+			case h.Builtin != "":
+				cmdDesc = fmt.Sprintf("builtin=%s", h.Builtin)
+			case len(h.Variants) > 0:
+				cmdDesc = fmt.Sprintf("variants=%q", h.Variants)
+			case h.URL != "":
+				cmdDesc = fmt.Sprintf("url=%q", h.URL)
+			}
+			if h.Type != "" && h.Type != config.HookTypeBuiltin {
+				cmdDesc = "type=" + h.Type + " " + cmdDesc
+			}
+			fmt.Printf("  %s %d: name=%s %s timeout=%s on_error=%s [%s]\n",
+				label, n, h.Name, cmdDesc, timeout, onError, status)
+		}
+		for _, key := range []string{"deny", "allow"} {
+			key, actions := chain.Actions(key)
+			for i, a := range actions {
+				h := a.Hook(key, i)
+				status, desc := "OK", fmt.Sprintf("url=%q", h.URL)
+				if a.Command != "" {
+					desc = fmt.Sprintf("command=%q", h.Command)
+					if parts := pathutil.Fields(h.Command); len(parts) > 0 {
+						if _, err := hookdir.LookPath(parts[0]); err != nil {
+							status = fmt.Sprintf("NOT FOUND: %s", parts[0])
+							hasIssues = true
+						}
+					}
+				}
+				fmt.Printf("  %s %d: name=%s %s [%s]\n", key, i+1, h.Name, desc, status)
+			}
 		}
 	}
 
+	// Executables in the managed hooks directory that no hook runs are
+	// reported, but are not an issue.
+	orphans, err := hookdir.Orphans(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: hooks directory: %v\n", err)
+	}
+	if len(orphans) > 0 {
+		fmt.Printf("Hooks directory %s: not used by any hook: %s\n", hookdir.Dir(), strings.Join(orphans, ", "))
+	}
+
 	if hasIssues {
 		return &exitError{code: 1}
 	}
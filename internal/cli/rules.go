@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/rules"
+)
+
+func newRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manage the dangerous-command ruleset used by the command-guard builtin",
+	}
+	cmd.PersistentFlags().String("path", "", "installed ruleset path (default: $HOOK_CHAIN_RULES or XDG data dir)")
+	cmd.AddCommand(newRulesListCmd())
+	cmd.AddCommand(newRulesUpdateCmd())
+	return cmd
+}
+
+func resolveRulesPath(cmd *cobra.Command) string {
+	if p, _ := cmd.Flags().GetString("path"); p != "" {
+		return p
+	}
+	return rules.DefaultPath()
+}
+
+func newRulesListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show the active ruleset version and its rules",
+		Args:  cobra.NoArgs,
+		RunE:  runRulesList,
+	}
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runRulesList(cmd *cobra.Command, _ []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+
+	set, err := rules.Load(resolveRulesPath(cmd))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: %v (using embedded ruleset)\n", err)
+	}
+	if asJSON {
+		return printJSON(set)
+	}
+
+	fmt.Printf("Ruleset %s (%s), %d rule(s)\n", set.Version, set.Source, len(set.Rules))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tACTION\tSEVERITY\tCOMMAND\tREASON")
+	for _, r := range set.Rules {
+		command := r.Command
+		if r.Subcommand != "" {
+			command += " " + r.Subcommand
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.Action, r.Severity, command, r.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newRulesUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Install the latest ruleset if it is newer than the active one",
+		Args:  cobra.NoArgs,
+		RunE:  runRulesUpdate,
+	}
+	cmd.Flags().String("url", rules.DefaultURL, "ruleset URL")
+	cmd.Flags().String("file", "", "install from a local file instead of downloading")
+	cmd.Flags().Bool("force", false, "install even if the version is not newer")
+	cmd.Flags().Duration("timeout", 30*time.Second, "download timeout")
+	return cmd
+}
+
+func runRulesUpdate(cmd *cobra.Command, _ []string) error {
+	url, err := cmd.Flags().GetString("url")
+	if err != nil {
+		return fmt.Errorf("invalid --url: %w", err)
+	}
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return fmt.Errorf("invalid --file: %w", err)
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return fmt.Errorf("invalid --force: %w", err)
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return fmt.Errorf("invalid --timeout: %w", err)
+	}
+
+	var data []byte
+	if file != "" {
+		if data, err = os.ReadFile(file); err != nil {
+			return fmt.Errorf("read %s: %w", file, err)
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if data, err = rules.Fetch(ctx, &http.Client{}, url); err != nil {
+			return err
+		}
+	}
+
+	candidate, err := rules.Parse(data)
+	if err != nil {
+		return fmt.Errorf("rules: %w", err)
+	}
+	path := resolveRulesPath(cmd)
+	current, err := rules.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook-chain: %v\n", err)
+	}
+	if !force && rules.CompareVersions(candidate.Version, current.Version) <= 0 {
+		fmt.Printf("Ruleset is up to date (active %s from %s, candidate %s).\n", current.Version, current.Source, candidate.Version)
+		return nil
+	}
+
+	installed, err := rules.Install(data, path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed ruleset %s (%d rules) to %s (was %s).\n", installed.Version, len(installed.Rules), path, current.Version)
+	return nil
+}
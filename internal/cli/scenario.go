@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/scenario"
+)
+
+func newTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test <scenario.yaml>...",
+		Short: "Run scripted scenarios against the chain config, without real hook binaries",
+		Long: `Runs each scenario through the pipeline with its hooks answered by
+scripts (exit code, stdout, stderr, latency, or a runner error) and checks
+the expected decision. Builtin hooks run for real. A scenario's config:
+field names the config to test; otherwise --config or the active config is
+used.
+Exits 1 when any scenario fails.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runTest,
+	}
+	cmd.Flags().String("config", "", "config for scenarios without a config: field (default: the active config)")
+	cmd.Flags().String("run", "", "only scenarios whose name matches this regular expression")
+	return cmd
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	defaultConfig, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return fmt.Errorf("invalid --config: %w", err)
+	}
+	pattern, err := cmd.Flags().GetString("run")
+	if err != nil {
+		return fmt.Errorf("invalid --run: %w", err)
+	}
+	filter, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid --run: %w", err)
+	}
+
+	configs := map[string]config.Config{}
+	loadConfig := func(path string) (config.Config, error) {
+		if cfg, ok := configs[path]; ok {
+			return cfg, nil
+		}
+		var cfg config.Config
+		var err error
+		if path == "" {
+			cfg, err = config.Load()
+		} else {
+			if cfg, err = config.LoadFrom(path); err == nil {
+				cfg, err = cfg.Effective()
+			}
+		}
+		if err != nil {
+			return config.Config{}, err
+		}
+		configs[path] = cfg
+		return cfg, nil
+	}
+
+	failed, ran := 0, 0
+	for _, file := range args {
+		scenarios, err := scenario.LoadFile(file)
+		if err != nil {
+			return err
+		}
+		for _, sc := range scenarios {
+			if !filter.MatchString(sc.Name) {
+				continue
+			}
+			ran++
+			path := sc.ConfigPath()
+			if path == "" {
+				path = defaultConfig
+			}
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return fmt.Errorf("scenario %q: %w", sc.Name, err)
+			}
+			o, err := scenario.Run(cmd.Context(), sc, cfg)
+			if err != nil {
+				return err
+			}
+			fails := sc.Check(o)
+			if len(fails) == 0 {
+				fmt.Printf("PASS    %s\n", sc.Name)
+				continue
+			}
+			failed++
+			fmt.Printf("FAIL    %s\n", sc.Name)
+			for _, f := range fails {
+				fmt.Printf("          %s\n", f)
+			}
+		}
+	}
+
+	if ran == 0 {
+		return fmt.Errorf("test: no scenarios match %q", pattern)
+	}
+	fmt.Printf("\n%d/%d scenarios passed\n", ran-failed, ran)
+	if failed > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
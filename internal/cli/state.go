@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/kv"
+)
+
+// kvIdleRetention is how long a session's hook state survives without
+// writes when its SessionEnd event never arrives.
+const kvIdleRetention = 7 * 24 * time.Hour
+
+func newStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Read and write per-session hook state",
+		Long: `Read and write per-session key-value state from hooks.
+
+Hooks run by hook-chain receive HOOK_CHAIN_SESSION_ID, HOOK_CHAIN_HOOK, and
+HOOK_CHAIN_KV_DB, so these commands need no flags inside a hook. Keys are
+scoped to the calling hook unless --scope is given, and are deleted when the
+session ends.`,
+	}
+	cmd.PersistentFlags().String("session", "", "session ID (default: $HOOK_CHAIN_SESSION_ID)")
+	cmd.PersistentFlags().String("scope", "", "key scope (default: $HOOK_CHAIN_HOOK, the calling hook)")
+	cmd.PersistentFlags().String("db", "", "path to the state database (default: $HOOK_CHAIN_KV_DB or auto-detected)")
+	cmd.AddCommand(
+		newStateGetCmd(),
+		newStateSetCmd(),
+		newStateIncrCmd(),
+		newStateDeleteCmd(),
+		newStateListCmd(),
+		newStateClearCmd(),
+		newStateGCCmd(),
+	)
+	return cmd
+}
+
+// stateTarget resolves the session, scope, and database for a state command.
+func stateTarget(cmd *cobra.Command) (session, scope, dbPath string, err error) {
+	if session, err = cmd.Flags().GetString("session"); err != nil {
+		return "", "", "", fmt.Errorf("invalid --session: %w", err)
+	}
+	if session == "" {
+		session = os.Getenv("HOOK_CHAIN_SESSION_ID")
+	}
+	if session == "" {
+		return "", "", "", errors.New("no session: pass --session or run from a hook")
+	}
+	if scope, err = cmd.Flags().GetString("scope"); err != nil {
+		return "", "", "", fmt.Errorf("invalid --scope: %w", err)
+	}
+	if !cmd.Flags().Changed("scope") {
+		scope = os.Getenv("HOOK_CHAIN_HOOK")
+	}
+	if dbPath, err = cmd.Flags().GetString("db"); err != nil {
+		return "", "", "", fmt.Errorf("invalid --db: %w", err)
+	}
+	if dbPath == "" {
+		dbPath = kv.DefaultPath()
+	}
+	return session, scope, dbPath, nil
+}
+
+func newStateGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a key's value (exit 1 if unset)",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runStateGet,
+	}
+}
+
+func runStateGet(cmd *cobra.Command, args []string) error {
+	session, scope, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	value, ok, err := store.Get(session, scope, args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &exitError{code: 1}
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func newStateSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key's value",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runStateSet,
+	}
+}
+
+func runStateSet(cmd *cobra.Command, args []string) error {
+	session, scope, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	return store.Set(session, scope, args[0], args[1])
+}
+
+func newStateIncrCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "incr <key>",
+		Short: "Add to an integer key and print the new value",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runStateIncr,
+	}
+	cmd.Flags().Int64("by", 1, "amount to add (may be negative)")
+	return cmd
+}
+
+func runStateIncr(cmd *cobra.Command, args []string) error {
+	by, err := cmd.Flags().GetInt64("by")
+	if err != nil {
+		return fmt.Errorf("invalid --by: %w", err)
+	}
+	session, scope, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	n, err := store.Incr(session, scope, args[0], by)
+	if err != nil {
+		return err
+	}
+	fmt.Println(strconv.FormatInt(n, 10))
+	return nil
+}
+
+func newStateDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <key>",
+		Short: "Delete a key",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runStateDelete,
+	}
+}
+
+func runStateDelete(cmd *cobra.Command, args []string) error {
+	session, scope, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	_, err = store.Delete(session, scope, args[0])
+	return err
+}
+
+func newStateListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List keys in the session and scope",
+		Args:  cobra.NoArgs,
+		RunE:  runStateList,
+	}
+	cmd.Flags().Bool("json", false, "output as JSON")
+	return cmd
+}
+
+func runStateList(cmd *cobra.Command, _ []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("invalid --json: %w", err)
+	}
+	session, scope, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	entries, err := store.List(session, scope)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No keys set.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KEY\tVALUE\tUPDATED")
+	for _, e := range entries {
+		value := e.Value
+		if len(value) > 60 {
+			value = value[:57] + "..."
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, value, e.UpdatedAt.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flush tabwriter: %w", err)
+	}
+	return nil
+}
+
+func newStateClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Delete every key of the session, in all scopes",
+		Args:  cobra.NoArgs,
+		RunE:  runStateClear,
+	}
+}
+
+func runStateClear(cmd *cobra.Command, _ []string) error {
+	session, _, dbPath, err := stateTarget(cmd)
+	if err != nil {
+		return err
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	n, err := store.DeleteSession(session)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %d key(s) of session %s.\n", n, session)
+	return nil
+}
+
+func newStateGCCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete the state of sessions with no recent writes",
+		Args:  cobra.NoArgs,
+		RunE:  runStateGC,
+	}
+	cmd.Flags().String("idle", "7d", "delete sessions with no writes for this long (e.g., 24h, 7d)")
+	return cmd
+}
+
+func runStateGC(cmd *cobra.Command, _ []string) error {
+	idleStr, err := cmd.Flags().GetString("idle")
+	if err != nil {
+		return fmt.Errorf("invalid --idle: %w", err)
+	}
+	idle, err := parseDuration(idleStr)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", idleStr, err)
+	}
+	dbPath, err := cmd.Flags().GetString("db")
+	if err != nil {
+		return fmt.Errorf("invalid --db: %w", err)
+	}
+	if dbPath == "" {
+		dbPath = kv.DefaultPath()
+	}
+	store, err := kv.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	n, err := store.PruneIdle(time.Now().Add(-idle))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %d key(s) of sessions idle for %s.\n", n, idleStr)
+	return nil
+}
+
+// withStateEnv points every hook at the per-session state store, scoped to
+// the hook's own name.
+func withStateEnv(hooks []config.HookEntry, sessionID string) []config.HookEntry {
+	if sessionID == "" {
+		return hooks
+	}
+	out := withEnv(hooks, "HOOK_CHAIN_SESSION_ID="+sessionID, "HOOK_CHAIN_KV_DB="+kv.DefaultPath())
+	for i := range out {
+		out[i].Env = append(out[i].Env, "HOOK_CHAIN_HOOK="+out[i].Name)
+	}
+	return out
+}
+
+// collectSessionState garbage-collects hook state: it deletes the keys of
+// endedSession (if not empty) and prunes sessions idle past kvIdleRetention.
+// A store that was never created is left alone; errors are logged only.
+func collectSessionState(endedSession string, logger *slog.Logger) {
+	path := kv.DefaultPath()
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	store, err := kv.Open(path)
+	if err != nil {
+		logger.Warn("failed to open hook state store", "err", err)
+		return
+	}
+	defer func() { _ = store.Close() }()
+
+	if endedSession != "" {
+		if n, err := store.DeleteSession(endedSession); err != nil {
+			logger.Warn("failed to delete session hook state", "err", err)
+		} else if n > 0 {
+			logger.Debug("deleted session hook state", "session", endedSession, "keys", n)
+		}
+	}
+	if n, err := store.PruneIdle(time.Now().Add(-kvIdleRetention)); err != nil {
+		logger.Warn("failed to prune idle hook state", "err", err)
+	} else if n > 0 {
+		logger.Debug("pruned idle hook state", "keys", n)
+	}
+}
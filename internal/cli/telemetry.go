@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Fuabioo/hook-chain/internal/buildinfo"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/telemetry"
+)
+
+func newTelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage opt-in anonymous usage metrics",
+		Long: `Telemetry is off unless you turn it on. When enabled and telemetry.endpoint
+is set in the user config, hook-chain posts one report a day with chain
+execution counts by outcome, its version, OS, and architecture, tagged with a
+random ID. No hook names, commands, paths, tool input, or session IDs are
+sent. DO_NOT_TRACK=1 or HOOK_CHAIN_TELEMETRY=0 blocks it regardless.`,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show whether telemetry is enabled and the report that would be sent",
+			Args:  cobra.NoArgs,
+			RunE:  runTelemetryStatus,
+		},
+		&cobra.Command{
+			Use:   "enable",
+			Short: "Opt in to anonymous usage metrics",
+			Args:  cobra.NoArgs,
+			RunE:  runTelemetryEnable,
+		},
+		&cobra.Command{
+			Use:   "disable",
+			Short: "Opt out and forget the telemetry ID",
+			Args:  cobra.NoArgs,
+			RunE:  runTelemetryDisable,
+		},
+		newTelemetrySendCmd(),
+	)
+	return cmd
+}
+
+func newTelemetrySendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send the pending report now",
+		Args:  cobra.NoArgs,
+		RunE:  runTelemetrySend,
+	}
+	cmd.Flags().Bool("dry-run", false, "print the report instead of sending it")
+	return cmd
+}
+
+func runTelemetryStatus(_ *cobra.Command, _ []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	path := telemetry.DefaultPath()
+	s, err := telemetry.Load(path)
+	if err != nil {
+		return err
+	}
+
+	state := "disabled"
+	if s.Enabled {
+		state = "enabled"
+	}
+	if env := telemetry.Blocked(); env != "" {
+		state += " (blocked by " + env + ")"
+	}
+	fmt.Printf("Telemetry: %s\n", state)
+	fmt.Printf("Settings:  %s\n", path)
+	endpoint := cfg.Telemetry.Endpoint
+	if endpoint == "" {
+		endpoint = "(not set; nothing is sent)"
+	}
+	fmt.Printf("Endpoint:  %s\n", endpoint)
+	if !s.Enabled {
+		return nil
+	}
+	fmt.Printf("ID:        %s\n", s.ID)
+	if !s.LastSent.IsZero() {
+		fmt.Printf("Last sent: %s\n", s.LastSent.Local().Format(time.DateTime))
+	}
+
+	r, err := buildTelemetryReport(cfg, s)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nNext report:")
+	return printJSON(r)
+}
+
+func runTelemetryEnable(_ *cobra.Command, _ []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	path := telemetry.DefaultPath()
+	s, err := telemetry.Load(path)
+	if err != nil {
+		return err
+	}
+	if s.Enabled {
+		fmt.Println("Telemetry is already enabled.")
+		return nil
+	}
+	s, err = telemetry.Enable(time.Now())
+	if err != nil {
+		return err
+	}
+	if err := telemetry.Save(path, s); err != nil {
+		return err
+	}
+	fmt.Println("Telemetry enabled. Once a day hook-chain sends chain counts by outcome,")
+	fmt.Println("its version, OS, and architecture; see `hook-chain telemetry status`.")
+	if cfg.Telemetry.Endpoint == "" {
+		fmt.Println("Note: telemetry.endpoint is not set in your config, so nothing is sent yet.")
+	}
+	if env := telemetry.Blocked(); env != "" {
+		fmt.Printf("Note: %s is set, so nothing is sent while it is.\n", env)
+	}
+	return nil
+}
+
+func runTelemetryDisable(_ *cobra.Command, _ []string) error {
+	if err := telemetry.Save(telemetry.DefaultPath(), telemetry.Settings{}); err != nil {
+		return err
+	}
+	fmt.Println("Telemetry disabled.")
+	return nil
+}
+
+func runTelemetrySend(cmd *cobra.Command, _ []string) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("invalid --dry-run: %w", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	path := telemetry.DefaultPath()
+	s, err := telemetry.Load(path)
+	if err != nil {
+		return err
+	}
+	if !s.Enabled {
+		return fmt.Errorf("telemetry is disabled (enable it with `hook-chain telemetry enable`)")
+	}
+
+	r, err := buildTelemetryReport(cfg, s)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return printJSON(r)
+	}
+	if env := telemetry.Blocked(); env != "" {
+		return fmt.Errorf("telemetry is blocked by %s", env)
+	}
+	if cfg.Telemetry.Endpoint == "" {
+		return fmt.Errorf("telemetry.endpoint is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+	if err := telemetry.Send(ctx, &http.Client{}, cfg.Telemetry.Endpoint, r); err != nil {
+		return err
+	}
+	s.LastSent = r.Until
+	return telemetry.Save(path, s)
+}
+
+// buildTelemetryReport collects the pending report, counting chains in the
+// audit database when there is one.
+func buildTelemetryReport(cfg config.Config, s telemetry.Settings) (telemetry.Report, error) {
+	var db *sql.DB
+	if dbPath := auditDBPath(cfg); dbPath != "" {
+		if _, err := os.Stat(dbPath); err == nil {
+			if db, err = openAuditDBReadOnlyAt(dbPath); err != nil {
+				return telemetry.Report{}, err
+			}
+			defer func() { _ = db.Close() }()
+		}
+	}
+	return telemetry.Build(db, s, buildinfo.Read(Version, Commit).Version, time.Now())
+}
+
+// maybeSendTelemetry starts a detached `hook-chain telemetry send` when
+// telemetry is enabled, configured, not blocked, and a report is due. It
+// never delays or fails the hook run.
+func maybeSendTelemetry(cfg config.Config, logger *slog.Logger) {
+	if cfg.Telemetry.Endpoint == "" || telemetry.Blocked() != "" {
+		return
+	}
+	path := telemetry.DefaultPath()
+	s, err := telemetry.Load(path)
+	if err != nil || !s.Due(time.Now()) {
+		return
+	}
+	// Record the attempt first so concurrent hook runs do not all send.
+	s.LastAttempt = time.Now().UTC()
+	if err := telemetry.Save(path, s); err != nil {
+		logger.Debug("failed to save telemetry settings", "err", err)
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Debug("failed to locate hook-chain binary", "err", err)
+		return
+	}
+	sender := exec.Command(exe, "telemetry", "send")
+	detach(sender)
+	if err := sender.Start(); err != nil {
+		logger.Debug("failed to start telemetry sender", "err", err)
+		return
+	}
+	_ = sender.Process.Release()
+}
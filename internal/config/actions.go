@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChainAction is an on_deny or on_allow action of a chain: a command run
+// with the chain result on stdin, or a webhook the result is POSTed to. Actions
+// run in the background after the decision is written and can never change
+// it.
+type ChainAction struct {
+	Name    string        `yaml:"name,omitempty"`
+	Command string        `yaml:"command,omitempty"` // run as a process, like a hook's command
+	Args    []string      `yaml:"args,omitempty"`
+	Webhook string        `yaml:"webhook,omitempty"` // POST the result here instead
+	Timeout time.Duration `yaml:"timeout,omitempty"` // default: DefaultHookTimeout
+	Env     []string      `yaml:"env,omitempty"`
+}
+
+// Hook returns the hook that carries out the action: a process hook for a
+// command, an http hook for a webhook. Unnamed actions are named after the
+// outcome key and their position, e.g. "on_deny[1]".
+func (a ChainAction) Hook(key string, i int) HookEntry {
+	h := HookEntry{
+		Name:    a.Name,
+		Command: a.Command,
+		Args:    a.Args,
+		Timeout: a.Timeout,
+		Env:     a.Env,
+	}
+	if h.Name == "" {
+		h.Name = fmt.Sprintf("%s[%d]", key, i+1)
+	}
+	if a.Webhook != "" {
+		h.Type, h.URL = HookTypeHTTP, a.Webhook
+	}
+	return h
+}
+
+// DenyAsk is the on_deny value that turns a chain's denials into asks.
+const DenyAsk = "ask"
+
+// DenyActions is a chain's on_deny: either a list of actions run when the
+// chain denies, or DenyAsk, which turns the chain's denials of PreToolUse
+// calls into asks so the user can still approve the call. The hooks that
+// denied are still audited as denials. A chain that ends in an error (a hook
+// that cannot run or prints invalid output) still denies.
+type DenyActions struct {
+	Ask     bool
+	Actions []ChainAction
+}
+
+// UnmarshalYAML reads on_deny as "ask" or as a list of actions.
+func (d *DenyActions) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		if n.Value != DenyAsk {
+			// A TypeError, so that Strict reports it with the others.
+			return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: on_deny must be %q or a list of actions, not %q", n.Line, DenyAsk, n.Value)}}
+		}
+		*d = DenyActions{Ask: true}
+		return nil
+	}
+	*d = DenyActions{}
+	return n.Decode(&d.Actions)
+}
+
+// MarshalYAML writes on_deny back in the form it was read in.
+func (d DenyActions) MarshalYAML() (any, error) {
+	if d.Ask {
+		return DenyAsk, nil
+	}
+	return d.Actions, nil
+}
+
+// IsZero reports an on_deny that is not set, for omitempty.
+func (d DenyActions) IsZero() bool {
+	return !d.Ask && len(d.Actions) == 0
+}
+
+// Actions returns the actions the chain runs for a chain outcome: OnDeny
+// for "deny" and for "error" (which also blocks), OnAllow for "allow". The
+// key names the list, for naming unnamed actions.
+func (c ChainEntry) Actions(outcome string) (key string, actions []ChainAction) {
+	switch outcome {
+	case "deny", "error":
+		return "on_deny", c.OnDeny.Actions
+	case "allow":
+		return "on_allow", c.OnAllow
+	}
+	return "", nil
+}
+
+// ValidateActions reports on_deny and on_allow actions that set neither or
+// both of command and webhook, and on_deny: ask on a chain that never sees
+// PreToolUse, the only event whose calls can be asked about.
+func (c ChainEntry) ValidateActions() []error {
+	var errs []error
+	if c.OnDeny.Ask && !slices.ContainsFunc(c.EventNames(), func(e string) bool { return e == "PreToolUse" || e == AnyEvent }) {
+		errs = append(errs, errors.New("config: on_deny: ask only applies to PreToolUse chains"))
+	}
+	for _, list := range []struct {
+		key     string
+		actions []ChainAction
+	}{{"on_deny", c.OnDeny.Actions}, {"on_allow", c.OnAllow}} {
+		for i, a := range list.actions {
+			switch {
+			case a.Command == "" && a.Webhook == "":
+				errs = append(errs, fmt.Errorf("config: %s action %d: needs command or webhook", list.key, i+1))
+			case a.Command != "" && a.Webhook != "":
+				errs = append(errs, fmt.Errorf("config: %s action %d: set command or webhook, not both", list.key, i+1))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestChainActions(t *testing.T) {
+	c := ChainEntry{
+		OnDeny:  DenyActions{Actions: []ChainAction{{Name: "ticket", Command: "~/bin/ticket"}, {Webhook: "https://hooks.example.com/x"}}},
+		OnAllow: []ChainAction{{Command: "log"}},
+	}
+	tests := []struct {
+		outcome string
+		key     string
+		n       int
+	}{
+		{"deny", "on_deny", 2},
+		{"error", "on_deny", 2},
+		{"allow", "on_allow", 1},
+		{"ask", "", 0},
+	}
+	for _, tt := range tests {
+		key, actions := c.Actions(tt.outcome)
+		if key != tt.key || len(actions) != tt.n {
+			t.Errorf("Actions(%q) = %q, %d actions; want %q, %d", tt.outcome, key, len(actions), tt.key, tt.n)
+		}
+	}
+
+	if h := c.OnDeny.Actions[0].Hook("on_deny", 0); h.Name != "ticket" || h.EffectiveType() != HookTypeProcess || h.Command != "~/bin/ticket" {
+		t.Errorf("command action hook = %+v", h)
+	}
+	h := c.OnDeny.Actions[1].Hook("on_deny", 1)
+	if h.Name != "on_deny[2]" || h.EffectiveType() != HookTypeHTTP || h.URL != "https://hooks.example.com/x" {
+		t.Errorf("webhook action hook = %+v", h)
+	}
+	if err := h.ValidateType(); err != nil {
+		t.Errorf("webhook action hook: %v", err)
+	}
+}
+
+func TestDenyActionsYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    DenyActions
+		wantErr bool
+	}{
+		{name: "ask", in: "on_deny: ask", want: DenyActions{Ask: true}},
+		{name: "actions", in: "on_deny: [{command: ticket}]", want: DenyActions{Actions: []ChainAction{{Command: "ticket"}}}},
+		{name: "unset", in: "event: Stop"},
+		{name: "other word", in: "on_deny: allow", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c ChainEntry
+			err := yaml.Unmarshal([]byte(tt.in), &c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.OnDeny.Ask != tt.want.Ask || len(c.OnDeny.Actions) != len(tt.want.Actions) {
+				t.Fatalf("on_deny = %+v, want %+v", c.OnDeny, tt.want)
+			}
+			out, err := yaml.Marshal(c)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var back ChainEntry
+			if err := yaml.Unmarshal(out, &back); err != nil || back.OnDeny.Ask != c.OnDeny.Ask || len(back.OnDeny.Actions) != len(c.OnDeny.Actions) {
+				t.Errorf("round trip of %q = %+v (%v)", out, back.OnDeny, err)
+			}
+		})
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	c := ChainEntry{
+		OnDeny:  DenyActions{Actions: []ChainAction{{Command: "a"}, {}, {Command: "b", Webhook: "https://x"}}},
+		OnAllow: []ChainAction{{Webhook: "https://x"}},
+	}
+	errs := c.ValidateActions()
+	if len(errs) != 2 {
+		t.Fatalf("ValidateActions = %v, want 2 errors", errs)
+	}
+	if got := errs[0].Error(); got != "config: on_deny action 2: needs command or webhook" {
+		t.Errorf("errs[0] = %q", got)
+	}
+
+	for _, tt := range []struct {
+		events  []string
+		wantErr bool
+	}{
+		{[]string{"PreToolUse"}, false},
+		{[]string{AnyEvent}, false},
+		{[]string{"PostToolUse", "PreToolUse"}, false},
+		{[]string{"Stop"}, true},
+	} {
+		c := ChainEntry{Events: tt.events, OnDeny: DenyActions{Ask: true}}
+		if errs := c.ValidateActions(); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("ValidateActions(on_deny: ask, events %v) = %v, wantErr %v", tt.events, errs, tt.wantErr)
+		}
+	}
+}
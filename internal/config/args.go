@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// argPlaceholder matches an input placeholder in a hook arg: the dotted
+// path of a field in the hook input, e.g. {{.session_id}} or
+// {{ .tool_input.file_path }}.
+var argPlaceholder = regexp.MustCompile(`\{\{\s*\.([A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*)\s*\}\}`)
+
+// ValidateArgs reports args with a "{{" that is not an input placeholder,
+// such as a template pipeline, which RenderArgs would pass on verbatim. On
+// Windows it also reports input placeholders in shell hooks (see
+// HasInputPlaceholder).
+func (h HookEntry) ValidateArgs() []error {
+	return h.validateArgs(runtime.GOOS)
+}
+
+func (h HookEntry) validateArgs(goos string) []error {
+	var errs []error
+	for i, a := range h.Args {
+		switch {
+		case strings.Contains(argPlaceholder.ReplaceAllString(a, ""), "{{"):
+			errs = append(errs, fmt.Errorf("config: hook %q: args[%d] %q: placeholders are {{.field}} or {{.field.subfield}}", h.Name, i, a))
+		case goos == "windows" && h.EffectiveType() == HookTypeShell && argPlaceholder.MatchString(a):
+			errs = append(errs, fmt.Errorf("config: hook %q: args[%d] %q: input placeholders are not supported in shell hooks on Windows; use a process hook", h.Name, i, a))
+		}
+	}
+	return errs
+}
+
+// HasInputPlaceholder reports whether any of args contains an input
+// placeholder. Shell hooks refuse them on Windows: cmd /C re-parses the
+// whole line, args included, so an input value such as "x&calc" would run
+// a command.
+func HasInputPlaceholder(args []string) bool {
+	for _, a := range args {
+		if argPlaceholder.MatchString(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderArgs fills the input placeholders in args from input, the hook
+// input as JSON. A string field is inserted as is and any other value as
+// compact JSON; a field the input lacks, or null, renders as "". Args
+// without placeholders are returned unchanged, as is everything when input
+// is not a JSON object.
+func RenderArgs(args []string, input []byte) []string {
+	if !strings.Contains(strings.Join(args, "\x00"), "{{") {
+		return args
+	}
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return args
+	}
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = argPlaceholder.ReplaceAllStringFunc(a, func(m string) string {
+			return inputField(fields, argPlaceholder.FindStringSubmatch(m)[1])
+		})
+	}
+	return out
+}
+
+// inputField renders the field at the dotted path in fields.
+func inputField(fields map[string]any, path string) string {
+	var v any = fields
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderArgs(t *testing.T) {
+	input := []byte(`{"session_id":"s-1","tool_name":"Write","tool_input":{"file_path":"/a b.go","limit":3,"flags":{"x":true},"none":null}}`)
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"--file", "--file"},
+		{"{{.tool_input.file_path}}", "/a b.go"},
+		{"--session={{ .session_id }}", "--session=s-1"},
+		{"{{.tool_name}}:{{.session_id}}", "Write:s-1"},
+		{"{{.tool_input.limit}}", "3"},
+		{"{{.tool_input.flags}}", `{"x":true}`},
+		{"{{.tool_input.none}}", ""},
+		{"{{.tool_input.missing}}", ""},
+		{"{{.session_id.deeper}}", ""},
+		{"$HOME {{.tool_name | printf}}", "$HOME {{.tool_name | printf}}"},
+	}
+	for _, tt := range tests {
+		if got := RenderArgs([]string{tt.arg}, input); !slices.Equal(got, []string{tt.want}) {
+			t.Errorf("RenderArgs(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+	if got := RenderArgs([]string{"{{.session_id}}"}, []byte("not json")); got[0] != "{{.session_id}}" {
+		t.Errorf("RenderArgs(invalid input) = %q, want the arg unchanged", got)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantErrs int
+	}{
+		{nil, 0},
+		{[]string{"--file", "{{.tool_input.file_path}}", "{{ .session_id }}"}, 0},
+		{[]string{"{{.tool_input.command | quote}}"}, 1},
+		{[]string{"{{tool_name}}", "{{.cwd}}", "{{"}, 2},
+	}
+	for _, tt := range tests {
+		if errs := (HookEntry{Name: "h", Args: tt.args}).ValidateArgs(); len(errs) != tt.wantErrs {
+			t.Errorf("ValidateArgs(%q) = %v, want %d errors", tt.args, errs, tt.wantErrs)
+		}
+	}
+
+	// On Windows, cmd /C would re-parse a shell hook's filled-in args.
+	args := []string{"--file", "{{.tool_input.file_path}}"}
+	shell := HookEntry{Name: "h", Type: HookTypeShell, Args: args}
+	if errs := shell.validateArgs("windows"); len(errs) != 1 {
+		t.Errorf("windows shell hook: %v, want 1 error", errs)
+	}
+	if errs := shell.validateArgs("linux"); len(errs) != 0 {
+		t.Errorf("linux shell hook: %v, want none", errs)
+	}
+	if errs := (HookEntry{Name: "h", Args: args}).validateArgs("windows"); len(errs) != 0 {
+		t.Errorf("windows process hook: %v, want none", errs)
+	}
+}
@@ -1,45 +1,818 @@
 package config
 
 import (
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
 )
 
 // Config is the top-level hook-chain configuration.
 type Config struct {
-	Chains []ChainEntry `yaml:"chains"`
-	Audit  *AuditConfig `yaml:"audit,omitempty"`
+	// Version is the layout of the file (see CurrentVersion); older layouts
+	// are migrated when the file is loaded. ConfigVersion is an alias.
+	Version       int          `yaml:"version,omitempty"`
+	ConfigVersion int          `yaml:"config_version,omitempty"`
+	Chains        []ChainEntry `yaml:"chains"`
+	// HookDefs are named hook definitions that hooks reference with `use:`
+	// instead of repeating them (see ExpandHookDefs).
+	HookDefs map[string]HookEntry `yaml:"hook_defs,omitempty"`
+	// Profiles are named chain sets. The active one ($HOOK_CHAIN_PROFILE,
+	// else DefaultProfile) runs ahead of Chains (see ActiveProfile).
+	Profiles       map[string]ProfileConfig `yaml:"profiles,omitempty"`
+	DefaultProfile string                   `yaml:"default_profile,omitempty"`
+	// Resolution is how chains are picked for an event: "best" (default)
+	// runs the most specific matching chain, "all" runs every matching
+	// chain (see ResolveChain).
+	Resolution string        `yaml:"resolution,omitempty"`
+	Audit      *AuditConfig  `yaml:"audit,omitempty"`
+	Plugins    []PluginEntry `yaml:"plugins,omitempty"`
+	// Messages overrides hook-chain's own deny/ask phrasing, keyed by
+	// message name (see internal/messages); values are text/template strings.
+	Messages    map[string]string `yaml:"messages,omitempty"`
+	Runbooks    Runbooks          `yaml:"runbooks,omitempty"`
+	Transcript  TranscriptConfig  `yaml:"transcript,omitempty"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty"`
+	Scratch     ScratchConfig     `yaml:"scratch,omitempty"`
+	Diff        DiffConfig        `yaml:"diff,omitempty"`
+	Report      ReportConfig      `yaml:"report,omitempty"`
+	Telemetry   TelemetryConfig   `yaml:"telemetry,omitempty"`
+	Adapters    []AdapterConfig   `yaml:"adapters,omitempty"`
+	Defaults    DefaultsConfig    `yaml:"defaults,omitempty"`
+	Limits      LimitsConfig      `yaml:"limits,omitempty"`
+	// Remote (config_url and friends) makes the file a bootstrap for a
+	// config fetched over HTTPS (see RemoteConfig); RemoteStatus describes
+	// the copy that was loaded.
+	Remote       RemoteConfig  `yaml:",inline"`
+	RemoteStatus *RemoteStatus `yaml:"-"`
+	// Migrations are the changes made in memory to bring the loaded files
+	// up to CurrentVersion, each prefixed with its file; `config migrate`
+	// makes them for good.
+	Migrations []string `yaml:"-"`
+}
+
+// DefaultHookTimeout bounds hooks when neither the hook nor
+// defaults.timeout sets a timeout.
+const DefaultHookTimeout = 30 * time.Second
+
+// DefaultsConfig holds settings that hooks inherit unless they set their own.
+type DefaultsConfig struct {
+	Timeout time.Duration `yaml:"timeout,omitempty"` // hook timeout (default: 30s)
+	Env     []string      `yaml:"env,omitempty"`     // environment of every hook (see HookEnv)
+}
+
+// LimitsConfig holds hard limits on what hooks may configure.
+type LimitsConfig struct {
+	MaxHookTimeout time.Duration `yaml:"max_hook_timeout,omitempty"` // cap on any hook's timeout (0 = no cap)
+}
+
+// HookTimeout returns the timeout h runs with: its own, else
+// defaults.timeout, else DefaultHookTimeout, capped at
+// limits.max_hook_timeout.
+func (c Config) HookTimeout(h HookEntry) time.Duration {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = c.Defaults.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	if c.Limits.MaxHookTimeout > 0 {
+		timeout = min(timeout, c.Limits.MaxHookTimeout)
+	}
+	return timeout
+}
+
+// ApplyTimeouts returns a copy of hooks with each Timeout set to the one it
+// runs with (see HookTimeout).
+func (c Config) ApplyTimeouts(hooks []HookEntry) []HookEntry {
+	out := make([]HookEntry, len(hooks))
+	for i, h := range hooks {
+		h.Timeout = c.HookTimeout(h)
+		out[i] = h
+	}
+	return out
+}
+
+// HookEnv returns the environment entries a hook of chain runs with:
+// defaults.env, then the chain's env, then the hook's own, applied in that
+// order over hook-chain's environment. "NAME=value" sets a variable and
+// "-NAME" removes it, so a later level can drop what an earlier one or
+// hook-chain's own environment provides.
+func (c Config) HookEnv(chain ChainEntry, h HookEntry) []string {
+	return slices.Concat(c.Defaults.Env, chain.Env, h.Env)
+}
+
+// ApplyEnv returns a copy of hooks with each Env set to HookEnv.
+func (c Config) ApplyEnv(chain ChainEntry, hooks []HookEntry) []HookEntry {
+	out := make([]HookEntry, len(hooks))
+	for i, h := range hooks {
+		h.Env = c.HookEnv(chain, h)
+		out[i] = h
+	}
+	return out
+}
+
+// ValidateEnv reports env entries at any level that are neither
+// "NAME=value" nor "-NAME".
+func (c Config) ValidateEnv() []error {
+	var errs []error
+	check := func(where string, env []string) {
+		for _, e := range env {
+			name, _, ok := strings.Cut(strings.TrimPrefix(e, "-"), "=")
+			switch {
+			case name == "",
+				strings.HasPrefix(e, "-") && ok,
+				!strings.HasPrefix(e, "-") && !ok:
+				errs = append(errs, fmt.Errorf("config: %s: env entry %q is not NAME=value or -NAME", where, e))
+			}
+		}
+	}
+	check("defaults", c.Defaults.Env)
+	for i, chain := range c.Chains {
+		check(fmt.Sprintf("chain %d", i+1), chain.Env)
+		for _, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			check(fmt.Sprintf("hook %q", h.Name), h.Env)
+		}
+	}
+	return errs
+}
+
+// ValidateTimeouts reports negative timeouts and timeouts above
+// limits.max_hook_timeout, which are capped at run time.
+func (c Config) ValidateTimeouts() []error {
+	var errs []error
+	max := c.Limits.MaxHookTimeout
+	switch {
+	case max < 0:
+		errs = append(errs, fmt.Errorf("config: limits.max_hook_timeout %s is negative", max))
+	case c.Defaults.Timeout < 0:
+		errs = append(errs, fmt.Errorf("config: defaults.timeout %s is negative", c.Defaults.Timeout))
+	case max > 0 && c.Defaults.Timeout > max:
+		errs = append(errs, fmt.Errorf("config: defaults.timeout %s exceeds limits.max_hook_timeout %s", c.Defaults.Timeout, max))
+	}
+	for _, chain := range c.Chains {
+		for _, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			switch {
+			case h.Timeout < 0:
+				errs = append(errs, fmt.Errorf("config: hook %q: timeout %s is negative", h.Name, h.Timeout))
+			case max > 0 && h.Timeout > max:
+				errs = append(errs, fmt.Errorf("config: hook %q: timeout %s exceeds limits.max_hook_timeout %s", h.Name, h.Timeout, max))
+			}
+		}
+	}
+	return errs
+}
+
+// AdapterConfig maps another agent CLI's hook payload onto the Claude Code
+// hook protocol, so the same chains guard every agent on the machine.
+// Paths are dotted keys into the payload ("tool.args", "items.0").
+type AdapterConfig struct {
+	Name   string            `yaml:"name"`
+	Detect string            `yaml:"detect,omitempty"` // path whose presence identifies the agent's payloads
+	Fields map[string]string `yaml:"fields"`           // hook input field (session_id, tool_name, ...) → payload path
+	Events map[string]string `yaml:"events,omitempty"` // agent event name → hook-chain event name
+	Tools  map[string]string `yaml:"tools,omitempty"`  // agent tool name → hook-chain tool name
+}
+
+// ReportConfig controls delivery of the guardrail digest built by
+// `hook-chain audit report`.
+type ReportConfig struct {
+	Email   EmailConfig `yaml:"email,omitempty"`   // used by --email
+	Webhook string      `yaml:"webhook,omitempty"` // default URL for --post-webhook
+}
+
+// TelemetryConfig configures opt-in usage metrics (see internal/telemetry).
+// Nothing is sent unless `hook-chain telemetry enable` was run and Endpoint
+// is set.
+type TelemetryConfig struct {
+	Endpoint string `yaml:"endpoint,omitempty"` // URL the daily report is POSTed to
+}
+
+// EmailConfig describes how to send the digest by SMTP. STARTTLS is used
+// when the server offers it.
+type EmailConfig struct {
+	SMTP        string   `yaml:"smtp"` // host:port
+	Username    string   `yaml:"username,omitempty"`
+	Password    string   `yaml:"password,omitempty"`
+	PasswordEnv string   `yaml:"password_env,omitempty"` // read the password from this variable instead
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+	Subject     string   `yaml:"subject,omitempty"` // default: "hook-chain guardrail digest"
+}
+
+// DiffConfig controls the unified diff passed to hooks for PreToolUse
+// Edit, MultiEdit, and Write calls, as the diff input field.
+type DiffConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"` // compute and pass the diff
+	Context int  `yaml:"context,omitempty"` // context lines around changes (default: 3)
+}
+
+// EffectiveContext returns the number of context lines, defaulting to 3.
+func (d DiffConfig) EffectiveContext() int {
+	if d.Context <= 0 {
+		return 3
+	}
+	return d.Context
+}
+
+// ScratchConfig controls the temp directory each hook run gets
+// (HOOK_CHAIN_TMPDIR), removed when the chain finishes.
+type ScratchConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"` // do not provision temp directories
+	QuotaMB  int  `yaml:"quota_mb,omitempty"` // per-hook limit in MiB (default: 100, -1 = unlimited)
+}
+
+// EffectiveQuota returns the per-hook quota in bytes, defaulting to
+// 100 MiB. A negative QuotaMB means unlimited and returns 0.
+func (s ScratchConfig) EffectiveQuota() int64 {
+	switch {
+	case s.QuotaMB < 0:
+		return 0
+	case s.QuotaMB == 0:
+		return 100 << 20
+	}
+	return int64(s.QuotaMB) << 20
+}
+
+// ConcurrencyConfig caps how many pipelines run at once on the machine, so
+// a burst of parallel tool calls cannot fork hundreds of hook processes.
+type ConcurrencyConfig struct {
+	Max     int           `yaml:"max,omitempty"`     // concurrent pipelines (0 = unlimited)
+	Timeout time.Duration `yaml:"timeout,omitempty"` // how long to queue for a free slot (default: 30s)
+}
+
+// EffectiveTimeout returns the queueing timeout, defaulting to 30s.
+func (c ConcurrencyConfig) EffectiveTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return c.Timeout
+}
+
+// TranscriptConfig controls how hook-chain uses the session transcript: the
+// notes it appends so guardrail interventions are visible when reviewing the
+// session, and the recent activity it reads and passes to hooks.
+type TranscriptConfig struct {
+	Notes   string `yaml:"notes,omitempty"`   // "off" (default) | "sidecar" | "transcript"
+	Context int    `yaml:"context,omitempty"` // recent entries passed to hooks as recent_activity (0 = off)
+}
+
+// Runbooks maps rule IDs and hook names to documentation URLs that are
+// appended to denials, so users see how to proceed or request an exception.
+type Runbooks struct {
+	Rules map[string]string `yaml:"rules,omitempty"`
+	Hooks map[string]string `yaml:"hooks,omitempty"`
+}
+
+// URLFor returns the runbook for ruleID, falling back to the one for
+// hookName. It returns "" when neither is configured.
+func (r Runbooks) URLFor(ruleID, hookName string) string {
+	if u := r.Rules[ruleID]; ruleID != "" && u != "" {
+		return u
+	}
+	return r.Hooks[hookName]
+}
+
+// PluginEntry describes an exec'd event-bus subscriber. The command receives
+// the invocation's lifecycle events as newline-delimited JSON on stdin.
+type PluginEntry struct {
+	Name    string        `yaml:"name"`
+	Command string        `yaml:"command"`
+	Args    []string      `yaml:"args,omitempty"`
+	Events  []string      `yaml:"events,omitempty"` // default: all event kinds
+	Timeout time.Duration `yaml:"timeout,omitempty"`
 }
 
 // AuditConfig controls the audit logging subsystem.
 type AuditConfig struct {
-	Disabled  bool   `yaml:"disabled"` // default: false (audit enabled)
-	DBPath    string `yaml:"db_path,omitempty"`
-	Retention string `yaml:"retention,omitempty"` // e.g. "7d", "30d"
+	Disabled    bool         `yaml:"disabled"` // default: false (audit enabled)
+	DBPath      string       `yaml:"db_path,omitempty"`
+	ArchiveDir  string       `yaml:"archive_dir,omitempty"` // default: "archives" next to the database
+	Retention   string       `yaml:"retention,omitempty"`   // e.g. "7d", "30d"
+	Maintenance string       `yaml:"maintenance,omitempty"` // "inline" (default) | "scheduled"
+	Sinks       []SinkConfig `yaml:"sinks,omitempty"`
+}
+
+// Values for AuditConfig.Maintenance: when the retention tasks (archive and
+// prune, anomaly scan, WAL checkpoint) run.
+const (
+	MaintenanceInline    = "inline"    // after pipeline runs, at most hourly
+	MaintenanceScheduled = "scheduled" // only from `hook-chain maintain`
+)
+
+// MaintenanceModes lists the valid maintenance values.
+var MaintenanceModes = []string{MaintenanceInline, MaintenanceScheduled}
+
+// ScheduledMaintenance reports whether retention runs only from
+// `hook-chain maintain`, so pipeline runs skip it. A nil config is inline.
+func (a *AuditConfig) ScheduledMaintenance() bool {
+	return a != nil && a.Maintenance == MaintenanceScheduled
+}
+
+// Validate reports an unknown maintenance mode. A nil config is valid.
+func (a *AuditConfig) Validate() error {
+	if a != nil && a.Maintenance != "" && !slices.Contains(MaintenanceModes, a.Maintenance) {
+		return fmt.Errorf("config: audit: maintenance %q is not one of %s", a.Maintenance, strings.Join(MaintenanceModes, ", "))
+	}
+	return nil
+}
+
+// SinkConfig describes an external destination for audit records. Sinks are
+// drained with `hook-chain audit export --sink <name>`; live sinks are also
+// fed through the SQLite outbox after every pipeline run.
+type SinkConfig struct {
+	Name       string        `yaml:"name"`
+	Type       string        `yaml:"type"` // "splunk_hec" | "elastic_bulk" | "nats" | "kafka_rest"
+	URL        string        `yaml:"url"`
+	Live       bool          `yaml:"live,omitempty"`
+	Token      string        `yaml:"token,omitempty"`
+	Index      string        `yaml:"index,omitempty"`
+	Topic      string        `yaml:"topic,omitempty"`       // NATS subject / Kafka topic
+	Encoding   string        `yaml:"encoding,omitempty"`    // "json" (default) | "protojson" | "protobuf"
+	SourceType string        `yaml:"sourcetype,omitempty"`  // Splunk only
+	BatchSize  int           `yaml:"batch_size,omitempty"`  // default: 100
+	MaxRetries int           `yaml:"max_retries,omitempty"` // default: 3
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // per request, default: 10s
 }
 
 // ChainEntry maps an event+tool pattern to a sequence of hooks.
 type ChainEntry struct {
-	Event string      `yaml:"event"`
-	Tools []string    `yaml:"tools"`
-	Hooks []HookEntry `yaml:"hooks"`
+	Event         string        `yaml:"event,omitempty"`         // an event name, or AnyEvent
+	Events        []string      `yaml:"events,omitempty"`        // several events sharing one chain; instead of Event
+	Tools         []string      `yaml:"tools"`                   // names or globs; "!name" exempts a tool
+	ToolsExclude  []string      `yaml:"tools_exclude,omitempty"` // tools the chain never matches (see ToolPatterns)
+	MCPServer     string        `yaml:"mcp_server,omitempty"`    // glob for the server of mcp__<server>__<tool> tools
+	MCPTool       string        `yaml:"mcp_tool,omitempty"`      // glob for the tool of mcp__<server>__<tool> tools
+	Hooks         []HookEntry   `yaml:"hooks"`
+	Finally       []HookEntry   `yaml:"finally,omitempty"`        // run after the decision, whatever it is
+	OnDeny        DenyActions   `yaml:"on_deny,omitempty"`        // run in the background when the chain denies, or "ask" (see DenyActions)
+	OnAllow       []ChainAction `yaml:"on_allow,omitempty"`       // run in the background when the chain allows
+	LatencyBudget time.Duration `yaml:"latency_budget,omitempty"` // total for all hook budgets; checked by validate
+	Priority      int           `yaml:"priority,omitempty"`       // higher wins (or runs first with resolution: all); default 0
+	Disabled      bool          `yaml:"disabled,omitempty"`       // never matches, as if deleted; validate still lists it
+	ActiveHours   string        `yaml:"active_hours,omitempty"`   // "HH:MM-HH:MM" in local time; only matches inside it
+	ActiveDays    []string      `yaml:"active_days,omitempty"`    // weekdays or ranges ("mon-fri"); only matches on them
+	Env           []string      `yaml:"env,omitempty"`            // environment of every hook in the chain (see HookEnv)
+	Match         *MatchConfig  `yaml:"match,omitempty"`          // only tool calls whose tool_input matches
+	// Severity maps the severity of a hook's decision to the action taken
+	// ("context", "ask", or "deny"), e.g. {info: context, warn: ask}.
+	// Severities that are not listed keep the hook's own decision.
+	Severity map[string]string `yaml:"severity,omitempty"`
+	// ProtectedFields are tool_input keys no hook's updatedInput may
+	// change; OnProtected says what happens when one tries: "deny"
+	// (default) blocks the call, "strip" drops those keys from the patch.
+	ProtectedFields []string `yaml:"protected_fields,omitempty"`
+	OnProtected     string   `yaml:"on_protected,omitempty"`
+	// MaxDuration caps the chain's total run time: once it has passed, no
+	// further hook is started and OnMaxDuration decides: "deny" (default),
+	// "allow" with what the hooks that ran returned, or "ask".
+	MaxDuration   time.Duration `yaml:"max_duration,omitempty"`
+	OnMaxDuration string        `yaml:"on_max_duration,omitempty"`
+	// Quota limits how often the chain runs (see QuotaConfig).
+	Quota *QuotaConfig `yaml:"quota,omitempty"`
+	// Risk maps the risk scores of the chain's hooks to a decision (see
+	// RiskConfig).
+	Risk *RiskConfig `yaml:"risk,omitempty"`
+	// Source is the config file the chain was loaded from.
+	Source string `yaml:"-"`
+	// Project marks a chain from a project config, which adds to the user's
+	// chains rather than replacing them (see ResolveInput).
+	Project bool `yaml:"-"`
+	// Profile is the profile the chain came from ("" for top-level chains).
+	Profile string `yaml:"-"`
+}
+
+// MatchConfig restricts a chain by the content of a hook input: its
+// tool_input and its session context. Every condition that is set must
+// hold, and an input that lacks the field does not match. Invalid patterns
+// never match; validate reports them.
+type MatchConfig struct {
+	CommandRegex   string   `yaml:"command_regex,omitempty"`   // RE2 regexp searched for in tool_input.command
+	FilePathGlob   string   `yaml:"file_path_glob,omitempty"`  // path.Match glob for tool_input.file_path; without a "/", for its base name
+	PermissionMode []string `yaml:"permission_mode,omitempty"` // any of these permission modes
+	CWDGlob        string   `yaml:"cwd_glob,omitempty"`        // path.Match glob for cwd or any directory above it
+
+	compileOnce sync.Once
+	commandRe   *regexp.Regexp // CommandRegex compiled once; nil when invalid
+}
+
+// commandRegexp returns CommandRegex compiled, or nil when it is invalid.
+// It is compiled on first use and kept, so a long-lived Config does not
+// recompile it per input.
+func (m *MatchConfig) commandRegexp() *regexp.Regexp {
+	m.compileOnce.Do(func() {
+		m.commandRe, _ = regexp.Compile(m.CommandRegex)
+	})
+	return m.commandRe
+}
+
+// PermissionModes are the permission modes a hook input can report.
+var PermissionModes = []string{"default", "acceptEdits", "plan", "bypassPermissions"}
+
+// Matches reports whether in satisfies every condition of m. A nil m
+// matches everything.
+func (m *MatchConfig) Matches(in hook.Input) bool {
+	if m == nil {
+		return true
+	}
+	if len(m.PermissionMode) > 0 && !slices.Contains(m.PermissionMode, in.PermissionMode) {
+		return false
+	}
+	if m.CWDGlob != "" && !matchDirGlob(pathutil.Expand(m.CWDGlob), in.CWD) {
+		return false
+	}
+
+	var fields struct {
+		Command  *string `json:"command"`
+		FilePath *string `json:"file_path"`
+	}
+	if len(in.ToolInput) > 0 && json.Unmarshal(in.ToolInput, &fields) != nil {
+		return false
+	}
+	if m.CommandRegex != "" {
+		re := m.commandRegexp()
+		if re == nil || fields.Command == nil || !re.MatchString(*fields.Command) {
+			return false
+		}
+	}
+	if m.FilePathGlob != "" {
+		if fields.FilePath == nil {
+			return false
+		}
+		name := filepath.ToSlash(*fields.FilePath)
+		if !strings.Contains(m.FilePathGlob, "/") {
+			name = path.Base(name)
+		}
+		if ok, err := path.Match(m.FilePathGlob, name); err != nil || !ok {
+			return false
+		}
+	}
+	return true
 }
 
+// matchDirGlob reports whether dir or any directory above it matches the
+// glob pattern.
+func matchDirGlob(pattern, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	pattern, dir = filepath.ToSlash(pattern), filepath.ToSlash(filepath.Clean(dir))
+	for {
+		if ok, err := path.Match(pattern, dir); err == nil && ok {
+			return true
+		}
+		parent := path.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// Validate reports an invalid regexp or glob, an unknown permission mode,
+// or a match block that sets no condition.
+func (m *MatchConfig) Validate() error {
+	if m == nil {
+		return nil
+	}
+	if m.CommandRegex == "" && m.FilePathGlob == "" && len(m.PermissionMode) == 0 && m.CWDGlob == "" {
+		return errors.New("config: match sets no condition (command_regex, file_path_glob, permission_mode, cwd_glob)")
+	}
+	for _, mode := range m.PermissionMode {
+		if !slices.Contains(PermissionModes, mode) {
+			return fmt.Errorf("config: match.permission_mode %q is not one of %s", mode, strings.Join(PermissionModes, ", "))
+		}
+	}
+	if m.CWDGlob != "" {
+		if _, err := path.Match(filepath.ToSlash(m.CWDGlob), ""); err != nil {
+			return fmt.Errorf("config: match.cwd_glob %q: %w", m.CWDGlob, err)
+		}
+	}
+	if m.CommandRegex != "" {
+		if _, err := regexp.Compile(m.CommandRegex); err != nil {
+			return fmt.Errorf("config: match.command_regex: %w", err)
+		}
+	}
+	if m.FilePathGlob != "" {
+		if _, err := path.Match(m.FilePathGlob, ""); err != nil {
+			return fmt.Errorf("config: match.file_path_glob %q: %w", m.FilePathGlob, err)
+		}
+	}
+	return nil
+}
+
+// AnyEvent as a chain's event matches every hook event.
+const AnyEvent = "*"
+
+// EventNames returns the events the chain applies to: Event and Events.
+func (c ChainEntry) EventNames() []string {
+	if c.Event == "" {
+		return c.Events
+	}
+	return append([]string{c.Event}, c.Events...)
+}
+
+// EventLabel describes the chain's events for display.
+func (c ChainEntry) EventLabel() string {
+	return strings.Join(c.EventNames(), ",")
+}
+
+// MatchesEvent reports whether the chain applies to eventName.
+func (c ChainEntry) MatchesEvent(eventName string) bool {
+	return c.eventRank(eventName) >= 0
+}
+
+// eventRank reports how specifically the chain matches eventName: -1 for no
+// match, 0 for AnyEvent, 1 for the event named outright.
+func (c ChainEntry) eventRank(eventName string) int {
+	rank := -1
+	for _, e := range c.EventNames() {
+		switch e {
+		case eventName:
+			return 1
+		case AnyEvent:
+			rank = 0
+		}
+	}
+	return rank
+}
+
+// ValidateEvents reports a chain with no events, one that sets both event
+// and events, and empty or repeated entries in events.
+func (c ChainEntry) ValidateEvents() []error {
+	switch {
+	case c.Event == "" && len(c.Events) == 0:
+		return []error{errors.New("config: chain has no event")}
+	case c.Event != "" && len(c.Events) > 0:
+		return []error{errors.New("config: chain sets both event and events")}
+	}
+	var errs []error
+	seen := map[string]bool{}
+	for _, e := range c.Events {
+		switch {
+		case e == "":
+			errs = append(errs, errors.New("config: empty entry in events"))
+		case seen[e]:
+			errs = append(errs, fmt.Errorf("config: event %q listed twice", e))
+		}
+		seen[e] = true
+	}
+	return errs
+}
+
+// ToolEvents carry a tool name, so their chains list the tools they match.
+var ToolEvents = []string{"PreToolUse", "PostToolUse"}
+
+// Severity levels a hook can attach to its decision, lowest first.
+const (
+	SeverityInfo     = "info"
+	SeverityWarn     = "warn"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severity levels, lowest first.
+var Severities = []string{SeverityInfo, SeverityWarn, SeverityHigh, SeverityCritical}
+
+// Actions a chain can map a severity to.
+const (
+	SeverityActionContext = "context" // pass the reason to the model as additionalContext
+	SeverityActionAsk     = "ask"
+	SeverityActionDeny    = "deny"
+)
+
+// Actions for ChainEntry.OnProtected.
+const (
+	ProtectedDeny  = "deny"
+	ProtectedStrip = "strip"
+)
+
+// EffectiveOnProtected returns the on_protected action, defaulting to "deny".
+func (c ChainEntry) EffectiveOnProtected() string {
+	return cmp.Or(c.OnProtected, ProtectedDeny)
+}
+
+// ValidateProtected reports an unknown on_protected action, an on_protected
+// without protected_fields, and empty field names.
+func (c ChainEntry) ValidateProtected() []error {
+	var errs []error
+	if c.OnProtected != "" && c.OnProtected != ProtectedDeny && c.OnProtected != ProtectedStrip {
+		errs = append(errs, fmt.Errorf("config: on_protected %q is not %q or %q", c.OnProtected, ProtectedDeny, ProtectedStrip))
+	}
+	if c.OnProtected != "" && len(c.ProtectedFields) == 0 {
+		errs = append(errs, errors.New("config: on_protected is set but protected_fields is empty"))
+	}
+	if slices.Contains(c.ProtectedFields, "") {
+		errs = append(errs, errors.New("config: protected_fields has an empty name"))
+	}
+	return errs
+}
+
+// Actions for ChainEntry.OnMaxDuration.
+const (
+	MaxDurationDeny  = "deny"
+	MaxDurationAllow = "allow"
+	MaxDurationAsk   = "ask"
+)
+
+// MaxDurationActions lists the valid on_max_duration values.
+var MaxDurationActions = []string{MaxDurationDeny, MaxDurationAllow, MaxDurationAsk}
+
+// EffectiveOnMaxDuration returns the on_max_duration action, defaulting to
+// "deny".
+func (c ChainEntry) EffectiveOnMaxDuration() string {
+	return cmp.Or(c.OnMaxDuration, MaxDurationDeny)
+}
+
+// ValidateMaxDuration reports a negative max_duration, an unknown
+// on_max_duration action, and an on_max_duration without max_duration.
+func (c ChainEntry) ValidateMaxDuration() []error {
+	var errs []error
+	if c.MaxDuration < 0 {
+		errs = append(errs, fmt.Errorf("config: max_duration %s is negative", c.MaxDuration))
+	}
+	if c.OnMaxDuration != "" && !slices.Contains(MaxDurationActions, c.OnMaxDuration) {
+		errs = append(errs, fmt.Errorf("config: on_max_duration %q is not one of %s", c.OnMaxDuration, strings.Join(MaxDurationActions, ", ")))
+	}
+	if c.OnMaxDuration != "" && c.MaxDuration <= 0 {
+		errs = append(errs, errors.New("config: on_max_duration is set but max_duration is not"))
+	}
+	return errs
+}
+
+// ValidSeverity reports whether s is a known severity level.
+func ValidSeverity(s string) bool {
+	return slices.Contains(Severities, s)
+}
+
+// ValidateSeverity reports unknown severities and actions in the chain's
+// severity mapping and in its hooks' default severities.
+func (c ChainEntry) ValidateSeverity() []error {
+	var errs []error
+	for _, sev := range slices.Sorted(maps.Keys(c.Severity)) {
+		if !ValidSeverity(sev) {
+			errs = append(errs, fmt.Errorf("config: severity %q is not one of %v", sev, Severities))
+		}
+		switch a := c.Severity[sev]; a {
+		case SeverityActionContext, SeverityActionAsk, SeverityActionDeny:
+		default:
+			errs = append(errs, fmt.Errorf("config: severity %s: action %q is not %q, %q, or %q", sev, a, SeverityActionContext, SeverityActionAsk, SeverityActionDeny))
+		}
+	}
+	for _, h := range slices.Concat(c.Hooks, c.Finally) {
+		if h.Severity != "" && !ValidSeverity(h.Severity) {
+			errs = append(errs, fmt.Errorf("config: hook %q: severity %q is not one of %v", h.Name, h.Severity, Severities))
+		}
+	}
+	return errs
+}
+
+// Over-budget actions for HookEntry.OverBudget.
+const (
+	OverBudgetWarn         = "warn"
+	OverBudgetReportOnly   = "report_only"
+	OverBudgetFailValidate = "fail_validate"
+)
+
 // HookEntry describes a single hook command to execute.
 type HookEntry struct {
-	Name    string        `yaml:"name"`
-	Command string        `yaml:"command"`
-	Args    []string      `yaml:"args,omitempty"`
-	Timeout time.Duration `yaml:"timeout,omitempty"`
-	Env     []string      `yaml:"env,omitempty"`
-	OnError string        `yaml:"on_error,omitempty"` // "deny" (default) | "skip"
+	Name          string               `yaml:"name"`
+	Use           string               `yaml:"use,omitempty"`  // start from this hook_defs entry (see Config.ExpandHookDefs)
+	Type          string               `yaml:"type,omitempty"` // runner: "process" (default), "shell", "http", "builtin", or one an embedder registered
+	Command       string               `yaml:"command,omitempty"`
+	URL           string               `yaml:"url,omitempty"` // endpoint of an http hook
+	Args          []string             `yaml:"args,omitempty"`
+	Timeout       time.Duration        `yaml:"timeout,omitempty"`
+	Env           []string             `yaml:"env,omitempty"`
+	EnvFile       string               `yaml:"env_file,omitempty"`       // dotenv file of variables, read at each run; env entries win
+	Workdir       string               `yaml:"workdir,omitempty"`        // working directory of the hook process (default: hook-chain's)
+	WorkingDir    string               `yaml:"working_dir,omitempty"`    // alias of workdir
+	OnError       string               `yaml:"on_error,omitempty"`       // "deny" (default) | "skip" | "allow" | "retry"
+	Retries       int                  `yaml:"retries,omitempty"`        // re-runs after a transient failure (on_error: retry defaults to DefaultRetries)
+	RetryDelay    time.Duration        `yaml:"retry_delay,omitempty"`    // wait before the first re-run, doubling after each (default: DefaultRetryDelay)
+	ExitCodes     map[int]ExitCodeRule `yaml:"exit_codes,omitempty"`     // non-zero exit code → decision, replacing 2 = deny, other = error
+	ReportOnly    bool                 `yaml:"report_only,omitempty"`    // run and audit, but never enforce the hook's decision
+	Async         bool                 `yaml:"async,omitempty"`          // fire-and-forget: launched in the background, never decides
+	LatencyBudget time.Duration        `yaml:"latency_budget,omitempty"` // expected upper bound on typical run time
+	OverBudget    string               `yaml:"over_budget,omitempty"`    // "warn" (default) | "report_only" | "fail_validate"
+	Rollout       string               `yaml:"rollout,omitempty"`        // e.g. "10%": enforce for that share of sessions, report-only for the rest
+	Variants      []string             `yaml:"variants,omitempty"`       // [primary, candidate]: enforce the first, run the second in shadow
+	Priority      string               `yaml:"priority,omitempty"`       // "normal" (default) | "low": lower CPU and I/O priority
+	Builtin       string               `yaml:"builtin,omitempty"`        // run a hook built into hook-chain instead of command
+	Options       map[string]any       `yaml:"options,omitempty"`        // builtin settings
+	Severity      string               `yaml:"severity,omitempty"`       // severity of decisions that do not declare one
+	Disabled      bool                 `yaml:"disabled,omitempty"`       // never run; validate still lists it
+	Output        string               `yaml:"output,omitempty"`         // stdout format: "json" (default) | "text" (context) | "text-deny" (deny reason)
+	Quota         *QuotaConfig         `yaml:"quota,omitempty"`          // at most max runs per window (see QuotaConfig)
+}
+
+// Hook types for HookEntry.Type, each run by the runner registered for it.
+const (
+	HookTypeProcess = "process" // run command as a process, split on whitespace
+	HookTypeShell   = "shell"   // run command with sh -c
+	HookTypeHTTP    = "http"    // POST the input to url
+	HookTypeBuiltin = "builtin" // run the builtin named by builtin
+)
+
+// HookTypes lists the hook types hook-chain itself runs.
+var HookTypes = []string{HookTypeProcess, HookTypeShell, HookTypeHTTP, HookTypeBuiltin}
+
+// EffectiveType returns the hook's type: "builtin" for hooks that name a
+// builtin, otherwise Type, defaulting to "process".
+func (h HookEntry) EffectiveType() string {
+	switch {
+	case h.Type != "":
+		return h.Type
+	case h.Builtin != "":
+		return HookTypeBuiltin
+	}
+	return HookTypeProcess
+}
+
+// ValidateType reports settings that do not fit the hook's type: an http
+// hook without a url or with a command, a builtin hook of another type, and a
+// url on a hook that is not http. Unknown types are left to the runner
+// registry.
+func (h HookEntry) ValidateType() error {
+	typ := h.EffectiveType()
+	switch {
+	case h.Builtin != "" && typ != HookTypeBuiltin:
+		return fmt.Errorf("config: hook %q: builtin is set but type is %q", h.Name, typ)
+	case typ == HookTypeBuiltin && h.Builtin == "":
+		return fmt.Errorf("config: hook %q: type builtin needs builtin", h.Name)
+	case typ == HookTypeHTTP && h.URL == "":
+		return fmt.Errorf("config: hook %q: type http needs url", h.Name)
+	case typ == HookTypeHTTP && (h.Command != "" || len(h.Variants) > 0):
+		return fmt.Errorf("config: hook %q: type http takes url, not command", h.Name)
+	case typ != HookTypeHTTP && h.URL != "":
+		return fmt.Errorf("config: hook %q: url is only used by type http", h.Name)
+	}
+	return nil
+}
+
+// Stdout formats for HookEntry.Output.
+const (
+	OutputJSON     = "json"      // the hook protocol's JSON output
+	OutputText     = "text"      // plain text, passed to the model as additionalContext
+	OutputTextDeny = "text-deny" // plain text; any output denies with it as the reason
+)
+
+// Outputs lists the valid output formats.
+var Outputs = []string{OutputJSON, OutputText, OutputTextDeny}
+
+// ValidateOutput reports an unknown output format.
+func (h HookEntry) ValidateOutput() error {
+	if h.Output != "" && !slices.Contains(Outputs, h.Output) {
+		return fmt.Errorf("config: hook %q: output %q is not one of %s", h.Name, h.Output, strings.Join(Outputs, ", "))
+	}
+	return nil
+}
+
+// EffectiveWorkdir returns the hook's working directory as written, from
+// working_dir or its alias workdir; "" runs the hook in hook-chain's.
+func (h HookEntry) EffectiveWorkdir() string {
+	return cmp.Or(h.WorkingDir, h.Workdir)
+}
+
+// ValidateWorkdir reports a hook that sets working_dir and workdir to
+// different directories.
+func (h HookEntry) ValidateWorkdir() error {
+	if h.WorkingDir != "" && h.Workdir != "" && h.WorkingDir != h.Workdir {
+		return fmt.Errorf("config: hook %q: working_dir %q and workdir %q disagree; set one", h.Name, h.WorkingDir, h.Workdir)
+	}
+	return nil
 }
 
+// Hook priorities for HookEntry.Priority.
+const (
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Variant labels recorded in the audit log for hooks with variants.
+const (
+	VariantPrimary = "a"
+	VariantShadow  = "b"
+)
+
 // EffectiveOnError returns the on_error policy, defaulting to "deny".
 func (h HookEntry) EffectiveOnError() string {
 	if h.OnError == "" {
@@ -48,71 +821,774 @@ func (h HookEntry) EffectiveOnError() string {
 	return h.OnError
 }
 
-// Load searches for the config file in standard locations and parses it.
+// Retry defaults for hooks with on_error: retry or retries set.
+const (
+	DefaultRetries    = 2
+	DefaultRetryDelay = 200 * time.Millisecond
+	maxRetryDelay     = 5 * time.Second
+)
+
+// EffectiveRetries returns how many times a transient failure of the hook
+// is retried: Retries if set, DefaultRetries for on_error: retry, else none.
+// Once retries run out, on_error applies as usual; "retry" then denies.
+func (h HookEntry) EffectiveRetries() int {
+	switch {
+	case h.Retries > 0:
+		return h.Retries
+	case h.OnError == "retry":
+		return DefaultRetries
+	}
+	return 0
+}
+
+// RetryBackoff returns the wait before retry attempt n (1-based): the retry
+// delay doubled for each earlier retry, capped at five seconds.
+func (h HookEntry) RetryBackoff(n int) time.Duration {
+	d := h.RetryDelay
+	if d <= 0 {
+		d = DefaultRetryDelay
+	}
+	for range n - 1 {
+		if d >= maxRetryDelay {
+			break
+		}
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// EffectiveOverBudget returns the over_budget action, defaulting to "warn".
+func (h HookEntry) EffectiveOverBudget() string {
+	if h.OverBudget == "" {
+		return OverBudgetWarn
+	}
+	return h.OverBudget
+}
+
+// RolloutPercent parses Rollout ("10%", "12.5%", or a bare number) into a
+// percentage in [0, 100]. An empty Rollout means 100 (always enforced).
+func (h HookEntry) RolloutPercent() (float64, error) {
+	if h.Rollout == "" {
+		return 100, nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(h.Rollout), "%")), 64)
+	if err != nil {
+		return 0, fmt.Errorf("config: hook %q: invalid rollout %q: %w", h.Name, h.Rollout, err)
+	}
+	if v < 0 || v > 100 {
+		return 0, fmt.Errorf("config: hook %q: rollout %q out of range 0-100%%", h.Name, h.Rollout)
+	}
+	return v, nil
+}
+
+// InRollout reports whether the hook is enforced for sessionID. Sessions are
+// bucketed deterministically by a hash of the hook name and session ID, so a
+// session stays in or out of the canary for its whole lifetime and different
+// hooks pick independent subsets. An invalid Rollout fails closed (enforced).
+func (h HookEntry) InRollout(sessionID string) bool {
+	pct, err := h.RolloutPercent()
+	if err != nil || pct >= 100 {
+		return true
+	}
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(h.Name + "\x00" + sessionID))
+	return float64(f.Sum32()%10000) < pct*100
+}
+
+// SplitVariants returns the enforced and shadow entries of a hook with
+// variants: both keep the hook's name and settings, with Command set to the
+// first and second variant. ok is false unless exactly two variants are set.
+func (h HookEntry) SplitVariants() (primary, shadow HookEntry, ok bool) {
+	if len(h.Variants) != 2 {
+		return h, HookEntry{}, false
+	}
+	primary = h
+	primary.Command = h.Variants[0]
+	primary.Variants = nil
+	shadow = primary
+	shadow.Command = h.Variants[1]
+	shadow.ReportOnly = true
+	return primary, shadow, true
+}
+
+// ValidateVariants reports a misconfigured variants list: it must hold
+// exactly two commands and replaces command, so both cannot be set.
+func (h HookEntry) ValidateVariants() error {
+	switch {
+	case len(h.Variants) == 0:
+		return nil
+	case len(h.Variants) != 2:
+		return fmt.Errorf("config: hook %q: variants needs exactly 2 commands, got %d", h.Name, len(h.Variants))
+	case h.Command != "":
+		return fmt.Errorf("config: hook %q: set either command or variants, not both", h.Name)
+	case h.Async:
+		return fmt.Errorf("config: hook %q: variants are not supported on async hooks", h.Name)
+	}
+	return nil
+}
+
+// ProjectFile is the name of a project-local config layered over the
+// user-level one (see FindProject).
+const ProjectFile = ".hook-chain.yaml"
+
+// Load loads the config for the current working directory (see LoadFor).
+func Load() (Config, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+	return LoadFor(cwd)
+}
+
+// LoadFor searches for the user-level config file in standard locations,
+// parses it, layers the project config for cwd over it, and returns its
+// Effective form.
 // Search order: $HOOK_CHAIN_CONFIG → $XDG_CONFIG_HOME/hook-chain/config.yaml
 // → ~/.config/hook-chain/config.yaml.
-// Returns zero-value Config if no file is found. Returns error if file exists
-// but contains invalid YAML.
-func Load() (Config, error) {
+// $HOOK_CHAIN_CONFIG may be an https:// URL, and a file with config_url is
+// a bootstrap; either way the config is fetched (see RemoteConfig).
+// Returns zero-value Config if no file is found. Returns error if a file
+// exists but contains invalid YAML.
+func LoadFor(cwd string) (Config, error) {
+	return loadFor(cwd, false)
+}
+
+// LoadCached is Load without network access or writes: a remote config is
+// read from the cached copy, however old, and never refreshed. Without a
+// cached copy it is an error.
+func LoadCached() (Config, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+	return loadFor(cwd, true)
+}
+
+func loadFor(cwd string, cachedOnly bool) (Config, error) {
 	path, err := findConfigPath()
 	if err != nil {
 		return Config{}, err
 	}
-	if path == "" {
-		return Config{}, nil
+
+	var cfg Config
+	switch {
+	case IsRemote(path):
+		cfg, err = loadRemote(RemoteConfig{URL: path, PublicKey: os.Getenv(ConfigKeyEnv)}, cachedOnly)
+	case path != "":
+		if cfg, err = LoadFrom(path); err == nil && cfg.Remote.URL != "" {
+			cfg, err = loadRemote(cfg.Remote, cachedOnly)
+		}
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	if project := FindProject(cwd); project != "" && !sameFile(project, path) {
+		if cfg, err = layerProject(cfg, project); err != nil {
+			return Config{}, err
+		}
 	}
+	return cfg.Effective()
+}
 
-	data, err := os.ReadFile(path)
+// Effective returns the config as hooks run with it: the active profile's
+// chains ahead of the top-level chains (see ActiveProfile), and hook
+// definitions expanded (see ExpandHookDefs).
+func (c Config) Effective() (Config, error) {
+	c, err := c.applyProfile()
 	if err != nil {
-		return Config{}, fmt.Errorf("config: read %s: %w", path, err)
+		return Config{}, err
 	}
+	return c.ExpandHookDefs()
+}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("config: parse %s: %w", path, err)
+// FindProject returns the ProjectFile nearest cwd. Inside a git work tree
+// it looks in cwd and each parent up to the repository root; elsewhere only
+// in cwd. Returns "" when there is none or HOOK_CHAIN_PROJECT_CONFIG=0.
+func FindProject(cwd string) string {
+	if cwd == "" || os.Getenv("HOOK_CHAIN_PROJECT_CONFIG") == "0" {
+		return ""
+	}
+	dir, err := filepath.Abs(cwd)
+	if err != nil {
+		return ""
 	}
 
-	return cfg, nil
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Not in a git work tree.
+			dirs = dirs[:1]
+			break
+		}
+		dir = parent
+	}
+
+	for _, d := range dirs {
+		p := filepath.Join(d, ProjectFile)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
 }
 
-// LoadFrom parses a config from the given file path.
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// LoadFrom parses a config from the given file path, as written: no
+// profile is applied and hook definitions are not expanded (see Effective).
+// An older layout is migrated in memory (see Migrate and Config.Migrations).
 // Returns error if the file cannot be read or contains invalid YAML.
 func LoadFrom(path string) (Config, error) {
-	data, err := os.ReadFile(path)
+	doc, notes, err := parseFile(path)
 	if err != nil {
-		return Config{}, fmt.Errorf("config: read %s: %w", path, err)
+		return Config{}, err
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("config: parse %s: %w", path, err)
+	if err := decodeFile(doc, path, &cfg); err != nil {
+		return Config{}, err
 	}
+	setSource(&cfg, path)
+	cfg.Version = cmp.Or(cfg.Version, cfg.ConfigVersion)
+	cfg.Migrations = notes
 
 	return cfg, nil
 }
 
-// Resolve returns the hooks from the first matching chain entry where
-// eventName matches AND toolName is in the Tools list.
-// Uses exact string matching. Returns nil if no chain matches.
+// parseFile reads and parses the config file at path and migrates it to
+// CurrentVersion, returning the changes prefixed with path.
+func parseFile(path string) (*yaml.Node, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	_, notes, err := Migrate(&doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	for i, n := range notes {
+		notes[i] = path + ": " + n
+	}
+	return &doc, notes, nil
+}
+
+// decodeFile decodes a parsed config file into cfg; an empty file leaves cfg
+// as it is.
+func decodeFile(doc *yaml.Node, path string, cfg *Config) error {
+	if doc.Kind == 0 {
+		return nil
+	}
+	if err := doc.Decode(cfg); err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// layerProject merges the project config at path over user. The project
+// adds chains, after the user's and marked Project, and the same to each
+// profile; plugins and adapters, listed first; and messages and hook_defs
+// the user does not define.
+// Every other setting stays the user's: a repository must not be able to
+// weaken the user's chains, turn off auditing, raise limits, or redirect
+// the user's usage reports or policy.
+func layerProject(user Config, path string) (Config, error) {
+	doc, notes, err := parseFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var project Config
+	if err := decodeFile(doc, path, &project); err != nil {
+		return Config{}, err
+	}
+	setSource(&project, path)
+	markProject(project.Chains)
+	for _, p := range project.Profiles {
+		markProject(p.Chains)
+	}
+
+	merged := user
+	merged.Chains = slices.Concat(user.Chains, project.Chains)
+	merged.Plugins = slices.Concat(project.Plugins, user.Plugins)
+	merged.Adapters = slices.Concat(project.Adapters, user.Adapters)
+	merged.Messages = addMissing(user.Messages, project.Messages)
+	merged.HookDefs = addMissing(user.HookDefs, project.HookDefs)
+	if len(project.Profiles) > 0 {
+		merged.Profiles = maps.Clone(user.Profiles)
+		if merged.Profiles == nil {
+			merged.Profiles = make(map[string]ProfileConfig, len(project.Profiles))
+		}
+		for name, p := range project.Profiles {
+			p.Chains = slices.Concat(merged.Profiles[name].Chains, p.Chains)
+			merged.Profiles[name] = p
+		}
+	}
+	merged.Migrations = slices.Concat(user.Migrations, notes)
+	return merged, nil
+}
+
+// addMissing returns a copy of dst with the keys of src it lacks.
+func addMissing[M ~map[K]V, K comparable, V any](dst, src M) M {
+	if len(src) == 0 {
+		return dst
+	}
+	out := maps.Clone(dst)
+	if out == nil {
+		out = make(M, len(src))
+	}
+	for k, v := range src {
+		if _, ok := out[k]; !ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func markProject(chains []ChainEntry) {
+	for i := range chains {
+		chains[i].Project = true
+	}
+}
+
+func setSource(cfg *Config, path string) {
+	for i := range cfg.Chains {
+		cfg.Chains[i].Source = path
+	}
+	for _, p := range cfg.Profiles {
+		for i := range p.Chains {
+			p.Chains[i].Source = path
+		}
+	}
+}
+
+// Chain resolution modes for Config.Resolution.
+const (
+	ResolutionBest = "best"
+	ResolutionAll  = "all"
+)
+
+// ValidateResolution reports an unknown resolution mode.
+func (c Config) ValidateResolution() error {
+	switch c.Resolution {
+	case "", ResolutionBest, ResolutionAll:
+		return nil
+	}
+	return fmt.Errorf("config: resolution %q is not %q or %q", c.Resolution, ResolutionBest, ResolutionAll)
+}
+
+// Resolve returns the hooks of the chain entry whose events include
+// eventName and whose Tools match toolName. Events that carry no tool
+// (SessionEnd, Stop, ...) match chains that list no tools. Returns nil if no
+// chain matches.
+//
+// A chain's event may be AnyEvent, and tools may be globs ("*", "mcp__*",
+// "Bash*"; see path.Match). When several chains match, the one with the
+// highest priority wins; among equal priorities the most specific wins: a
+// chain naming the event beats an AnyEvent chain; then an exact tool name
+// beats any glob, and a glob with more literal characters beats one with
+// fewer. Ties go to the chain listed first.
+//
+// The user's chains and a project config's are resolved apart, so a
+// project cannot outrank the user's chain: when both have a match, the
+// project chain's hooks are added to the user's chain (see addProject).
+//
+// With resolution "all", the hooks of every matching chain run instead (see
+// ResolveChain).
 func (c Config) Resolve(eventName, toolName string) []HookEntry {
-	for _, chain := range c.Chains {
-		if chain.Event != eventName {
+	chain, ok := c.ResolveChain(eventName, toolName)
+	if !ok {
+		return nil
+	}
+	return chain.Hooks
+}
+
+// ResolveChain returns the matching chain entry (see Resolve).
+//
+// With resolution "all", it combines every matching chain in ChainOrder:
+// their hooks and finally hooks are concatenated, skipping any hook
+// whose name an earlier chain already contributed, and their severity
+// mappings are merged with earlier chains winning. Protected fields are
+// combined, denying if any chain with protected fields denies. Each chain's
+// env is folded into its own hooks. The combined chain has no latency budget,
+// env, or quota of its own; hook quotas still apply.
+//
+// It has no tool_input to test, so chains with a match block never match;
+// the hook handler uses ResolveInput.
+func (c Config) ResolveChain(eventName, toolName string) (ChainEntry, bool) {
+	return c.ResolveInput(hook.Input{HookEventName: eventName, ToolName: toolName})
+}
+
+// ResolveInput returns the chain entry for a parsed hook input, as
+// ResolveChain does for its event and tool. Chains with a match block are
+// also tested against the input (see MatchConfig), and one that matches
+// outranks chains without a match block after the event and before the tool
+// are compared.
+func (c Config) ResolveInput(in hook.Input) (ChainEntry, bool) {
+	if c.Resolution == ResolutionAll {
+		return c.resolveAll(in)
+	}
+	user, project := c.bestChain(in, false), c.bestChain(in, true)
+	switch {
+	case user < 0 && project < 0:
+		return ChainEntry{}, false
+	case project < 0:
+		return c.Chains[user], true
+	case user < 0:
+		return c.Chains[project], true
+	}
+	return addProject(c.Chains[user], c.Chains[project]), true
+}
+
+// bestChain returns the index of the best chain for in among the user's
+// chains, or the project's, or -1 when none matches.
+func (c Config) bestChain(in hook.Input, project bool) int {
+	best := -1
+	var bestRank [3]int
+	for i, chain := range c.Chains {
+		if chain.Project != project {
+			continue
+		}
+		rank, ok := chain.rank(in)
+		if !ok {
+			continue
+		}
+		switch {
+		case best < 0,
+			chain.Priority > c.Chains[best].Priority,
+			chain.Priority == c.Chains[best].Priority && slices.Compare(rank[:], bestRank[:]) > 0:
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+// addProject returns the user's chain with the project chain's hooks and
+// finally hooks after its own, skipping any whose name the user's chain
+// already has. The user chain's settings stand; the project's protected
+// fields, actions, and severities it lacks are added, and the tighter
+// max_duration applies. Each chain's env is folded into its own hooks.
+func addProject(user, project ChainEntry) ChainEntry {
+	c := user
+	c.Env = nil
+	c.Hooks = withChainEnv(user.Env, user.Hooks)
+	c.Finally = withChainEnv(user.Env, user.Finally)
+	seen := map[string]bool{}
+	for _, h := range slices.Concat(c.Hooks, c.Finally) {
+		seen[h.Name] = true
+	}
+	for _, h := range withChainEnv(project.Env, project.Hooks) {
+		if !seen[h.Name] && !h.Disabled {
+			seen[h.Name] = true
+			c.Hooks = append(c.Hooks, h)
+		}
+	}
+	for _, h := range withChainEnv(project.Env, project.Finally) {
+		if !seen[h.Name] && !h.Disabled {
+			seen[h.Name] = true
+			c.Finally = append(c.Finally, h)
+		}
+	}
+	c.OnDeny.Actions = slices.Concat(user.OnDeny.Actions, project.OnDeny.Actions)
+	c.OnAllow = slices.Concat(user.OnAllow, project.OnAllow)
+	c.ProtectedFields = slices.Clone(user.ProtectedFields)
+	for _, f := range project.ProtectedFields {
+		if !slices.Contains(c.ProtectedFields, f) {
+			c.ProtectedFields = append(c.ProtectedFields, f)
+		}
+	}
+	if len(project.ProtectedFields) > 0 && (len(user.ProtectedFields) == 0 || project.EffectiveOnProtected() == ProtectedDeny) {
+		c.OnProtected = project.EffectiveOnProtected()
+	}
+	if project.MaxDuration > 0 && (c.MaxDuration == 0 || project.MaxDuration < c.MaxDuration) {
+		c.MaxDuration, c.OnMaxDuration = project.MaxDuration, project.OnMaxDuration
+	}
+	if c.Risk == nil {
+		c.Risk = project.Risk
+	}
+	c.Severity = addMissing(user.Severity, project.Severity)
+	return c
+}
+
+// withChainEnv returns a copy of hooks with env ahead of each hook's own.
+func withChainEnv(env []string, hooks []HookEntry) []HookEntry {
+	out := make([]HookEntry, len(hooks))
+	for i, h := range hooks {
+		h.Env = slices.Concat(env, h.Env)
+		out[i] = h
+	}
+	return out
+}
+
+// ChainOrder returns the indexes of c.Chains by descending priority, ties in
+// declaration order, with a project config's chains after all of the
+// user's. It is the order resolution "all" runs chains in, so a project
+// hook cannot take the place of a user hook of the same name.
+func (c Config) ChainOrder() []int {
+	order := make([]int, len(c.Chains))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if pa, pb := c.Chains[a].Project, c.Chains[b].Project; pa != pb {
+			if pa {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(c.Chains[b].Priority, c.Chains[a].Priority)
+	})
+	return order
+}
+
+// resolveAll combines every chain matching the input.
+func (c Config) resolveAll(in hook.Input) (ChainEntry, bool) {
+	var combined ChainEntry
+	var sources []string
+	seen := map[string]bool{}
+	found := false
+	// Denials become asks only when every matching chain says so.
+	askAll := true
+	for _, i := range c.ChainOrder() {
+		chain := c.Chains[i]
+		if _, ok := chain.rank(in); !ok {
 			continue
 		}
-		for _, t := range chain.Tools {
-			if t == toolName {
-				return chain.Hooks
+		if !found {
+			combined.Event, combined.Tools, combined.ToolsExclude = in.HookEventName, chain.Tools, chain.ToolsExclude
+			combined.MCPServer, combined.MCPTool = chain.MCPServer, chain.MCPTool
+			found = true
+		}
+		for _, h := range chain.Hooks {
+			if !seen[h.Name] && !h.Disabled {
+				seen[h.Name] = true
+				h.Env = slices.Concat(chain.Env, h.Env)
+				combined.Hooks = append(combined.Hooks, h)
+			}
+		}
+		for _, h := range chain.Finally {
+			if !seen[h.Name] && !h.Disabled {
+				seen[h.Name] = true
+				h.Env = slices.Concat(chain.Env, h.Env)
+				combined.Finally = append(combined.Finally, h)
+			}
+		}
+		combined.OnDeny.Actions = append(combined.OnDeny.Actions, chain.OnDeny.Actions...)
+		askAll = askAll && chain.OnDeny.Ask
+		// Protected fields add up; one chain that denies makes the
+		// combined chain deny.
+		for _, f := range chain.ProtectedFields {
+			if !slices.Contains(combined.ProtectedFields, f) {
+				combined.ProtectedFields = append(combined.ProtectedFields, f)
 			}
 		}
+		if len(chain.ProtectedFields) > 0 && (combined.OnProtected == "" || chain.EffectiveOnProtected() == ProtectedDeny) {
+			combined.OnProtected = chain.EffectiveOnProtected()
+		}
+		combined.OnAllow = append(combined.OnAllow, chain.OnAllow...)
+		// The tightest max_duration applies, with its action.
+		if chain.MaxDuration > 0 && (combined.MaxDuration == 0 || chain.MaxDuration < combined.MaxDuration) {
+			combined.MaxDuration, combined.OnMaxDuration = chain.MaxDuration, chain.OnMaxDuration
+		}
+		// The first chain with thresholds sets them, like severity.
+		if combined.Risk == nil {
+			combined.Risk = chain.Risk
+		}
+		for sev, action := range chain.Severity {
+			if combined.Severity == nil {
+				combined.Severity = map[string]string{}
+			}
+			if _, ok := combined.Severity[sev]; !ok {
+				combined.Severity[sev] = action
+			}
+		}
+		if chain.Source != "" && !slices.Contains(sources, chain.Source) {
+			sources = append(sources, chain.Source)
+		}
 	}
-	return nil
+	combined.Source = strings.Join(sources, ", ")
+	combined.OnDeny.Ask = found && askAll
+	return combined, found
+}
+
+// rank reports how specifically the chain matches a hook input: the event
+// rank (see eventRank), 1 when a match block matched the input (0 without
+// one), and the tool rank (see toolRank). ok is false when the
+// chain does not match, is disabled, or is outside its active window.
+func (c ChainEntry) rank(in hook.Input) (rank [3]int, ok bool) {
+	event := c.eventRank(in.HookEventName)
+	if event < 0 || c.Disabled || !c.ActiveAt(time.Now()) {
+		return rank, false
+	}
+	include, exclude := c.ToolPatterns()
+	tool := -1
+	if in.ToolName == "" {
+		if len(include) == 0 && !c.HasMCP() {
+			tool = 0
+		}
+	} else if !slices.ContainsFunc(exclude, func(t string) bool { return toolRank(t, in.ToolName) >= 0 }) {
+		tool = c.mcpRank(in.ToolName)
+		for _, t := range include {
+			tool = max(tool, toolRank(t, in.ToolName))
+		}
+	}
+	if tool < 0 {
+		return rank, false
+	}
+	content := 0
+	if c.Match != nil {
+		if !c.Match.Matches(in) {
+			return rank, false
+		}
+		content = 1
+	}
+	return [3]int{event, content, tool}, true
+}
+
+// exactToolRank outranks every glob.
+const exactToolRank = math.MaxInt32
+
+// toolRank reports how specifically pattern matches toolName: -1 for no
+// match, exactToolRank for an exact name, otherwise the number of literal
+// characters in the glob.
+func toolRank(pattern, toolName string) int {
+	if !IsToolGlob(pattern) {
+		if pattern == toolName {
+			return exactToolRank
+		}
+		return -1
+	}
+	if ok, err := path.Match(pattern, toolName); err != nil || !ok {
+		return -1
+	}
+	literal := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+		case '\\':
+			i++
+			literal++
+		case '[':
+			// A character class matches one character, like a literal.
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				i += end
+			}
+			literal++
+		default:
+			literal++
+		}
+	}
+	return literal
+}
+
+// IsToolGlob reports whether a Tools entry is a glob rather than a tool name.
+func IsToolGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[\\")
+}
+
+// ToolPatterns splits the chain's tool patterns into the tools it matches
+// and the tools it exempts: ToolsExclude and the "!"-prefixed entries of
+// Tools (without the "!"). A chain that only exempts tools, and sets no
+// mcp_server or mcp_tool, matches every other tool, as if Tools were ["*"].
+func (c ChainEntry) ToolPatterns() (include, exclude []string) {
+	for _, t := range c.Tools {
+		if name, ok := strings.CutPrefix(t, "!"); ok {
+			exclude = append(exclude, name)
+		} else {
+			include = append(include, t)
+		}
+	}
+	exclude = append(exclude, c.ToolsExclude...)
+	if len(include) == 0 && len(exclude) > 0 && !c.HasMCP() {
+		include = []string{"*"}
+	}
+	return include, exclude
+}
+
+// ToolLabel describes the chain's tools for display, e.g. "Bash, Read",
+// "* except Bash", or "MCP github/*". It is "" for a chain without tools.
+func (c ChainEntry) ToolLabel() string {
+	include, exclude := c.ToolPatterns()
+	if mcp := c.mcpLabel(); mcp != "" {
+		include = append(include, mcp)
+	}
+	label := strings.Join(include, ", ")
+	if len(exclude) > 0 {
+		label += " except " + strings.Join(exclude, ", ")
+	}
+	return label
+}
+
+// ValidateTools reports malformed tool, mcp_server, and mcp_tool globs and
+// empty exclusions.
+func (c ChainEntry) ValidateTools() []error {
+	errs := c.validateMCP()
+	include, exclude := c.ToolPatterns()
+	for _, t := range slices.Concat(include, exclude) {
+		if t == "" {
+			errs = append(errs, errors.New("config: empty tool exclusion"))
+			continue
+		}
+		if _, err := path.Match(t, ""); err != nil {
+			errs = append(errs, fmt.Errorf("config: tool pattern %q: %w", t, err))
+		}
+	}
+	return errs
+}
+
+// ConfigEnv names the config file to use instead of the standard locations.
+// The --config flag sets it, so hooks and nested hook-chain runs inherit it.
+const ConfigEnv = "HOOK_CHAIN_CONFIG"
+
+// DefaultPath returns the config file Load reads or, when there is none, where
+// a new one belongs: $HOOK_CHAIN_CONFIG, $XDG_CONFIG_HOME/hook-chain/config.yaml,
+// or ~/.config/hook-chain/config.yaml.
+func DefaultPath() string {
+	if p, err := findConfigPath(); err == nil && p != "" {
+		return p
+	}
+	if p := os.Getenv(ConfigEnv); p != "" {
+		return p
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "hook-chain", "config.yaml")
 }
 
 // findConfigPath returns the path to the first config file found,
 // or empty string if none exists.
 func findConfigPath() (string, error) {
 	// 1. Explicit env var.
-	if p := os.Getenv("HOOK_CHAIN_CONFIG"); p != "" {
+	if p := os.Getenv(ConfigEnv); p != "" {
+		if IsRemote(p) {
+			return p, nil
+		}
 		if _, err := os.Stat(p); err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				return "", fmt.Errorf("config: $HOOK_CHAIN_CONFIG points to %s which does not exist", p)
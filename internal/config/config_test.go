@@ -1,10 +1,16 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
 )
 
 func TestLoadFromYAML(t *testing.T) {
@@ -121,6 +127,35 @@ func TestResolveMiss(t *testing.T) {
 	}
 }
 
+func TestResolveToolless(t *testing.T) {
+	cfg := Config{
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Hooks: []HookEntry{{Name: "no-tools", Command: "a"}}},
+			{Event: "SessionEnd", Hooks: []HookEntry{{Name: "cleanup", Command: "b"}}},
+		},
+	}
+
+	tests := []struct {
+		event, tool string
+		want        string
+	}{
+		{"SessionEnd", "", "cleanup"},
+		{"PreToolUse", "", "no-tools"},
+		{"PreToolUse", "Bash", ""},
+		{"Stop", "", ""},
+	}
+	for _, tt := range tests {
+		hooks := cfg.Resolve(tt.event, tt.tool)
+		got := ""
+		if len(hooks) > 0 {
+			got = hooks[0].Name
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.event, tt.tool, got, tt.want)
+		}
+	}
+}
+
 func TestResolveFirstMatch(t *testing.T) {
 	cfg := Config{
 		Chains: []ChainEntry{
@@ -161,6 +196,306 @@ func TestEffectiveOnError(t *testing.T) {
 	}
 }
 
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       HookEntry
+		retries int
+		backoff []time.Duration
+	}{
+		{"none", HookEntry{}, 0, []time.Duration{DefaultRetryDelay}},
+		{"on_error retry", HookEntry{OnError: "retry"}, DefaultRetries, []time.Duration{200 * time.Millisecond, 400 * time.Millisecond}},
+		{"explicit", HookEntry{OnError: "skip", Retries: 4, RetryDelay: time.Second}, 4, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.EffectiveRetries(); got != tt.retries {
+				t.Errorf("EffectiveRetries = %d, want %d", got, tt.retries)
+			}
+			for i, want := range tt.backoff {
+				if got := tt.h.RetryBackoff(i + 1); got != want {
+					t.Errorf("RetryBackoff(%d) = %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	for _, output := range []string{"", OutputJSON, OutputText, OutputTextDeny} {
+		if err := (HookEntry{Name: "h", Output: output}).ValidateOutput(); err != nil {
+			t.Errorf("ValidateOutput(%q) = %v", output, err)
+		}
+	}
+	err := HookEntry{Name: "h", Output: "txt"}.ValidateOutput()
+	if err == nil || !strings.Contains(err.Error(), `output "txt" is not one of json, text, text-deny`) {
+		t.Errorf("ValidateOutput(txt) = %v", err)
+	}
+}
+
+func TestWorkdir(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       HookEntry
+		want    string
+		wantErr bool
+	}{
+		{"unset", HookEntry{}, "", false},
+		{"workdir", HookEntry{Workdir: "~/src/a"}, "~/src/a", false},
+		{"working_dir", HookEntry{WorkingDir: "$REPO"}, "$REPO", false},
+		{"both agree", HookEntry{Workdir: "/tmp", WorkingDir: "/tmp"}, "/tmp", false},
+		{"both disagree", HookEntry{Workdir: "/tmp", WorkingDir: "/var"}, "/var", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.EffectiveWorkdir(); got != tt.want {
+				t.Errorf("EffectiveWorkdir() = %q, want %q", got, tt.want)
+			}
+			if err := tt.h.ValidateWorkdir(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorkdir() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEffectiveOverBudget(t *testing.T) {
+	tests := []struct {
+		overBudget string
+		want       string
+	}{
+		{"", OverBudgetWarn},
+		{OverBudgetReportOnly, OverBudgetReportOnly},
+		{OverBudgetFailValidate, OverBudgetFailValidate},
+	}
+
+	for _, tt := range tests {
+		h := HookEntry{OverBudget: tt.overBudget}
+		if got := h.EffectiveOverBudget(); got != tt.want {
+			t.Errorf("EffectiveOverBudget(%q) = %q, want %q", tt.overBudget, got, tt.want)
+		}
+	}
+}
+
+func TestRolloutPercent(t *testing.T) {
+	tests := []struct {
+		rollout string
+		want    float64
+		wantErr bool
+	}{
+		{"", 100, false},
+		{"10%", 10, false},
+		{"12.5%", 12.5, false},
+		{" 50 % ", 50, false},
+		{"0%", 0, false},
+		{"150%", 0, true},
+		{"-1%", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		h := HookEntry{Name: "guard", Rollout: tt.rollout}
+		got, err := h.RolloutPercent()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RolloutPercent(%q) err = %v, wantErr %v", tt.rollout, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RolloutPercent(%q) = %v, want %v", tt.rollout, got, tt.want)
+		}
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	tests := []struct {
+		rollout string
+		wantMin int
+		wantMax int
+	}{
+		{"", 1000, 1000},
+		{"100%", 1000, 1000},
+		{"0%", 0, 0},
+		{"10%", 60, 140},
+		{"bogus", 1000, 1000}, // invalid fails closed: always enforced
+	}
+
+	for _, tt := range tests {
+		h := HookEntry{Name: "guard", Rollout: tt.rollout}
+		in := 0
+		for i := range 1000 {
+			session := fmt.Sprintf("session-%d", i)
+			got := h.InRollout(session)
+			if got != h.InRollout(session) {
+				t.Fatalf("InRollout(%q) not deterministic", session)
+			}
+			if got {
+				in++
+			}
+		}
+		if in < tt.wantMin || in > tt.wantMax {
+			t.Errorf("rollout %q: %d/1000 sessions enforced, want %d-%d", tt.rollout, in, tt.wantMin, tt.wantMax)
+		}
+	}
+}
+
+func TestSplitVariants(t *testing.T) {
+	h := HookEntry{Name: "guard", Variants: []string{"guard-v1", "guard-v2"}, Timeout: 5 * time.Second}
+	primary, shadow, ok := h.SplitVariants()
+	if !ok {
+		t.Fatal("SplitVariants ok = false, want true")
+	}
+	if primary.Command != "guard-v1" || primary.ReportOnly || primary.Variants != nil {
+		t.Errorf("primary = %+v, want enforced guard-v1", primary)
+	}
+	if shadow.Command != "guard-v2" || !shadow.ReportOnly || shadow.Name != "guard" || shadow.Timeout != h.Timeout {
+		t.Errorf("shadow = %+v, want report-only guard-v2 with the hook's settings", shadow)
+	}
+
+	if _, _, ok := (HookEntry{Name: "plain", Command: "plain"}).SplitVariants(); ok {
+		t.Error("SplitVariants ok = true for hook without variants")
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    HookEntry
+		wantErr bool
+	}{
+		{"no variants", HookEntry{Name: "a", Command: "a"}, false},
+		{"two variants", HookEntry{Name: "a", Variants: []string{"v1", "v2"}}, false},
+		{"one variant", HookEntry{Name: "a", Variants: []string{"v1"}}, true},
+		{"three variants", HookEntry{Name: "a", Variants: []string{"v1", "v2", "v3"}}, true},
+		{"command and variants", HookEntry{Name: "a", Command: "a", Variants: []string{"v1", "v2"}}, true},
+		{"async", HookEntry{Name: "a", Async: true, Variants: []string{"v1", "v2"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hook.ValidateVariants(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVariants() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    ChainEntry
+		wantErrs int
+	}{
+		{"none", ChainEntry{}, 0},
+		{"valid", ChainEntry{Severity: map[string]string{"info": "context", "warn": "ask", "critical": "deny"}, Hooks: []HookEntry{{Name: "a", Severity: "high"}}}, 0},
+		{"unknown severity", ChainEntry{Severity: map[string]string{"medium": "ask"}}, 1},
+		{"unknown action", ChainEntry{Severity: map[string]string{"high": "block"}}, 1},
+		{"unknown hook severity", ChainEntry{Finally: []HookEntry{{Name: "a", Severity: "severe"}}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.chain.ValidateSeverity(); len(errs) != tt.wantErrs {
+				t.Errorf("ValidateSeverity() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidateProtected(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    ChainEntry
+		wantErrs int
+	}{
+		{"none", ChainEntry{}, 0},
+		{"fields only", ChainEntry{ProtectedFields: []string{"command"}}, 0},
+		{"strip", ChainEntry{ProtectedFields: []string{"command", "file_path"}, OnProtected: "strip"}, 0},
+		{"unknown action", ChainEntry{ProtectedFields: []string{"command"}, OnProtected: "ignore"}, 1},
+		{"action without fields", ChainEntry{OnProtected: "deny"}, 1},
+		{"empty name", ChainEntry{ProtectedFields: []string{""}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.chain.ValidateProtected(); len(errs) != tt.wantErrs {
+				t.Errorf("ValidateProtected() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+	if got := (ChainEntry{}).EffectiveOnProtected(); got != ProtectedDeny {
+		t.Errorf("EffectiveOnProtected() = %q, want deny by default", got)
+	}
+}
+
+func TestValidateMaxDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    ChainEntry
+		wantErrs int
+	}{
+		{"none", ChainEntry{}, 0},
+		{"limit only", ChainEntry{MaxDuration: 2 * time.Second}, 0},
+		{"allow", ChainEntry{MaxDuration: time.Second, OnMaxDuration: "allow"}, 0},
+		{"negative", ChainEntry{MaxDuration: -time.Second}, 1},
+		{"unknown action", ChainEntry{MaxDuration: time.Second, OnMaxDuration: "skip"}, 1},
+		{"action without limit", ChainEntry{OnMaxDuration: "ask"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.chain.ValidateMaxDuration(); len(errs) != tt.wantErrs {
+				t.Errorf("ValidateMaxDuration() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+	if got := (ChainEntry{}).EffectiveOnMaxDuration(); got != MaxDurationDeny {
+		t.Errorf("EffectiveOnMaxDuration() = %q, want deny by default", got)
+	}
+}
+
+func TestAuditMaintenance(t *testing.T) {
+	tests := []struct {
+		name          string
+		audit         *AuditConfig
+		wantScheduled bool
+		wantErr       bool
+	}{
+		{"no audit block", nil, false, false},
+		{"default", &AuditConfig{}, false, false},
+		{"inline", &AuditConfig{Maintenance: "inline"}, false, false},
+		{"scheduled", &AuditConfig{Maintenance: "scheduled"}, true, false},
+		{"unknown", &AuditConfig{Maintenance: "nightly"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.audit.ScheduledMaintenance(); got != tt.wantScheduled {
+				t.Errorf("ScheduledMaintenance() = %v, want %v", got, tt.wantScheduled)
+			}
+			if err := tt.audit.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunbooksURLFor(t *testing.T) {
+	r := Runbooks{
+		Rules: map[string]string{"no-rm": "https://wiki/no-rm"},
+		Hooks: map[string]string{"guard": "https://wiki/guard"},
+	}
+	tests := []struct {
+		ruleID string
+		hook   string
+		want   string
+	}{
+		{"no-rm", "guard", "https://wiki/no-rm"},
+		{"other", "guard", "https://wiki/guard"},
+		{"", "guard", "https://wiki/guard"},
+		{"", "lint", ""},
+	}
+
+	for _, tt := range tests {
+		if got := r.URLFor(tt.ruleID, tt.hook); got != tt.want {
+			t.Errorf("URLFor(%q, %q) = %q, want %q", tt.ruleID, tt.hook, got, tt.want)
+		}
+	}
+}
+
 func TestLoadMissingFileReturnsEmpty(t *testing.T) {
 	// Point to a nonexistent directory so no config is found.
 	// HOME must also be overridden to prevent the ~/.config fallback
@@ -219,3 +554,736 @@ chains:
 		t.Errorf("hook name = %q, want %q", cfg.Chains[0].Hooks[0].Name, "custom-hook")
 	}
 }
+
+func TestResolveToolGlobs(t *testing.T) {
+	chain := func(name string, tools ...string) ChainEntry {
+		return ChainEntry{Event: "PreToolUse", Tools: tools, Hooks: []HookEntry{{Name: name, Command: name}}}
+	}
+	tests := []struct {
+		name   string
+		chains []ChainEntry
+		tool   string
+		want   string
+	}{
+		{"star matches anything", []ChainEntry{chain("all", "*")}, "Read", "all"},
+		{"prefix glob", []ChainEntry{chain("mcp", "mcp__*")}, "mcp__github__create_issue", "mcp"},
+		{"prefix glob miss", []ChainEntry{chain("mcp", "mcp__*")}, "Bash", ""},
+		{"question mark", []ChainEntry{chain("edit", "?dit")}, "Edit", "edit"},
+		{"character class", []ChainEntry{chain("write", "[WN]*Edit")}, "NotebookEdit", "write"},
+		{"exact beats earlier star", []ChainEntry{chain("all", "*"), chain("bash", "Bash")}, "Bash", "bash"},
+		{"exact beats earlier glob", []ChainEntry{chain("glob", "Bash*"), chain("bash", "Bash")}, "Bash", "bash"},
+		{"longer glob beats shorter", []ChainEntry{chain("mcp", "mcp__*"), chain("github", "mcp__github__*")}, "mcp__github__create_issue", "github"},
+		{"specific glob beats earlier star", []ChainEntry{chain("all", "*"), chain("bashes", "Bash*")}, "BashOutput", "bashes"},
+		{"equal globs: first listed wins", []ChainEntry{chain("one", "Bash*"), chain("two", "*tput")}, "BashOutput", "one"},
+		{"equal exact: first listed wins", []ChainEntry{chain("one", "Bash"), chain("two", "Read", "Bash")}, "Bash", "one"},
+		{"fallback to star", []ChainEntry{chain("bash", "Bash"), chain("all", "*")}, "Read", "all"},
+		{"malformed glob never matches", []ChainEntry{chain("bad", "[Bash")}, "[Bash", ""},
+		{"star does not match toolless events", []ChainEntry{chain("all", "*")}, "", ""},
+		{"negated tool is exempt", []ChainEntry{chain("all", "*", "!Bash")}, "Bash", ""},
+		{"negated tool: others match", []ChainEntry{chain("all", "*", "!Bash")}, "Read", "all"},
+		{"negated glob", []ChainEntry{chain("all", "*", "!mcp__*")}, "mcp__github__create_issue", ""},
+		{"only negations match the rest", []ChainEntry{chain("all", "!Bash")}, "Read", "all"},
+		{"only negations: not toolless events", []ChainEntry{chain("all", "!Bash")}, "", ""},
+		{"exemption falls through", []ChainEntry{chain("all", "*", "!Bash"), chain("fallback", "*")}, "Bash", "fallback"},
+		{"tools_exclude", []ChainEntry{{Event: "PreToolUse", Tools: []string{"*"}, ToolsExclude: []string{"Read", "Glob"}, Hooks: []HookEntry{{Name: "x", Command: "x"}}}}, "Glob", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Chains: tt.chains}
+			got := ""
+			if hooks := cfg.Resolve("PreToolUse", tt.tool); len(hooks) > 0 {
+				got = hooks[0].Name
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTools(t *testing.T) {
+	c := ChainEntry{Tools: []string{"Bash", "mcp__*", "[Edit", `Write\`}}
+	if errs := c.ValidateTools(); len(errs) != 2 {
+		t.Errorf("ValidateTools = %v, want 2 errors", errs)
+	}
+	c = ChainEntry{Tools: []string{"*", "!", "![Bash"}, ToolsExclude: []string{"Read"}}
+	if errs := c.ValidateTools(); len(errs) != 2 {
+		t.Errorf("ValidateTools = %v, want 2 errors", errs)
+	}
+}
+
+func TestToolLabel(t *testing.T) {
+	tests := []struct {
+		chain ChainEntry
+		want  string
+	}{
+		{ChainEntry{}, ""},
+		{ChainEntry{Tools: []string{"Bash", "Read"}}, "Bash, Read"},
+		{ChainEntry{Tools: []string{"*", "!Bash"}}, "* except Bash"},
+		{ChainEntry{Tools: []string{"!Bash"}, ToolsExclude: []string{"Read"}}, "* except Bash, Read"},
+	}
+	for _, tt := range tests {
+		if got := tt.chain.ToolLabel(); got != tt.want {
+			t.Errorf("ToolLabel(%v, %v) = %q, want %q", tt.chain.Tools, tt.chain.ToolsExclude, got, tt.want)
+		}
+	}
+}
+
+func TestResolveEvents(t *testing.T) {
+	hooks := func(name string) []HookEntry { return []HookEntry{{Name: name, Command: name}} }
+	tests := []struct {
+		name   string
+		chains []ChainEntry
+		event  string
+		tool   string
+		want   string
+	}{
+		{"events list", []ChainEntry{{Events: []string{"PreToolUse", "PostToolUse"}, Tools: []string{"Bash"}, Hooks: hooks("both")}}, "PostToolUse", "Bash", "both"},
+		{"events list miss", []ChainEntry{{Events: []string{"PreToolUse", "PostToolUse"}, Tools: []string{"Bash"}, Hooks: hooks("both")}}, "PermissionRequest", "Bash", ""},
+		{"any event, no tool", []ChainEntry{{Event: AnyEvent, Hooks: hooks("any")}}, "Stop", "", "any"},
+		{"any event with tools", []ChainEntry{{Event: AnyEvent, Tools: []string{"*"}, Hooks: hooks("any")}}, "PreToolUse", "Read", "any"},
+		{"named event beats earlier any", []ChainEntry{{Event: AnyEvent, Hooks: hooks("any")}, {Event: "Stop", Hooks: hooks("stop")}}, "Stop", "", "stop"},
+		{"named event beats exact tool on any", []ChainEntry{{Event: AnyEvent, Tools: []string{"Bash"}, Hooks: hooks("any")}, {Event: "PreToolUse", Tools: []string{"*"}, Hooks: hooks("pre")}}, "PreToolUse", "Bash", "pre"},
+		{"any in events list", []ChainEntry{{Events: []string{"Stop", AnyEvent}, Hooks: hooks("any")}}, "SessionEnd", "", "any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Chains: tt.chains}
+			got := ""
+			if hooks := cfg.Resolve(tt.event, tt.tool); len(hooks) > 0 {
+				got = hooks[0].Name
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q, %q) = %q, want %q", tt.event, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain ChainEntry
+		want  int
+	}{
+		{"event", ChainEntry{Event: "PreToolUse"}, 0},
+		{"any", ChainEntry{Event: AnyEvent}, 0},
+		{"events", ChainEntry{Events: []string{"PreToolUse", "PostToolUse"}}, 0},
+		{"none", ChainEntry{}, 1},
+		{"both", ChainEntry{Event: "Stop", Events: []string{"PreToolUse"}}, 1},
+		{"empty and repeated entries", ChainEntry{Events: []string{"Stop", "", "Stop"}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.chain.ValidateEvents(); len(errs) != tt.want {
+				t.Errorf("ValidateEvents = %v, want %d errors", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "chains:\n  - events: [PreToolUse, PostToolUse]\n    tools: [Bash]\n    hooks:\n      - name: log\n        command: log\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Chains[0].EventLabel(); got != "PreToolUse,PostToolUse" {
+		t.Errorf("EventLabel = %q", got)
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "")
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "a", "b")
+	write(filepath.Join(root, ProjectFile), "chains: []\n") // above the repo: ignored
+	write(filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindProject(sub); got != "" {
+		t.Errorf("no project file in repo: FindProject = %q, want none", got)
+	}
+	write(filepath.Join(repo, ProjectFile), "chains: []\n")
+	if got, want := FindProject(sub), filepath.Join(repo, ProjectFile); got != want {
+		t.Errorf("FindProject(subdir) = %q, want the repo root's %q", got, want)
+	}
+	write(filepath.Join(repo, "a", ProjectFile), "chains: []\n")
+	if got, want := FindProject(sub), filepath.Join(repo, "a", ProjectFile); got != want {
+		t.Errorf("FindProject = %q, want the nearest %q", got, want)
+	}
+
+	outside := filepath.Join(root, "plain", "dir")
+	if err := os.MkdirAll(outside, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProject(outside); got != "" {
+		t.Errorf("outside a repo, parents are not searched: FindProject = %q", got)
+	}
+	if got := FindProject(root); got != filepath.Join(root, ProjectFile) {
+		t.Errorf("outside a repo, cwd is searched: FindProject = %q", got)
+	}
+
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "0")
+	if got := FindProject(sub); got != "" {
+		t.Errorf("HOOK_CHAIN_PROJECT_CONFIG=0: FindProject = %q, want none", got)
+	}
+}
+
+func TestLoadForLayersProject(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "user.yaml")
+	user := `chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks: [{name: user-bash, command: u}]
+audit:
+  retention: 72h
+limits:
+  max_hook_timeout: 1m
+telemetry:
+  endpoint: https://user.example/metrics
+messages:
+  hook_timeout: user timeout
+  hook_failed: user failed
+`
+	project := `chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks: [{name: project-bash, command: p}]
+messages:
+  hook_failed: project failed
+  hook_denied: project denied
+audit:
+  disabled: true
+limits:
+  max_hook_timeout: 1h
+resolution: all
+telemetry:
+  endpoint: https://project.example/metrics
+`
+	if err := os.WriteFile(userPath, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectDir := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(projectDir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	projectPath := filepath.Join(projectDir, ProjectFile)
+	if err := os.WriteFile(projectPath, []byte(project), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOOK_CHAIN_CONFIG", userPath)
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "")
+
+	cfg, err := LoadFor(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Chains) != 2 {
+		t.Fatalf("got %d chains, want 2", len(cfg.Chains))
+	}
+	if c := cfg.Chains[0]; c.Hooks[0].Name != "user-bash" || c.Source != userPath || c.Project {
+		t.Errorf("chain 0 = %s from %s (project %t), want user-bash from %s", c.Hooks[0].Name, c.Source, c.Project, userPath)
+	}
+	if c := cfg.Chains[1]; c.Hooks[0].Name != "project-bash" || c.Source != projectPath || !c.Project {
+		t.Errorf("chain 1 = %s from %s (project %t), want project-bash from %s", c.Hooks[0].Name, c.Source, c.Project, projectPath)
+	}
+	if got := hookNames(cfg.Resolve("PreToolUse", "Bash")); got != "user-bash,project-bash" {
+		t.Errorf("Resolve = %s, want the project hook added to the user's", got)
+	}
+	if cfg.Messages["hook_failed"] != "user failed" || cfg.Messages["hook_denied"] != "project denied" {
+		t.Errorf("Messages = %v, want the user's keys plus the project's new ones", cfg.Messages)
+	}
+	if cfg.Audit == nil || cfg.Audit.Retention != "72h" || cfg.Audit.Disabled {
+		t.Errorf("Audit = %+v, want the user's section, not the project's audit.disabled", cfg.Audit)
+	}
+	if cfg.Limits.MaxHookTimeout != time.Minute || cfg.Resolution != "" {
+		t.Errorf("Limits = %+v, Resolution = %q; want the user's", cfg.Limits, cfg.Resolution)
+	}
+	if cfg.Telemetry.Endpoint != "https://user.example/metrics" {
+		t.Errorf("Telemetry.Endpoint = %q, want the user's", cfg.Telemetry.Endpoint)
+	}
+
+	if cfg, err := LoadFor(dir); err != nil || len(cfg.Chains) != 1 {
+		t.Errorf("LoadFor without project = %d chains, %v; want the user config alone", len(cfg.Chains), err)
+	}
+
+	if err := os.WriteFile(projectPath, []byte("chains: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFor(projectDir); err == nil {
+		t.Error("invalid project config: want error")
+	}
+}
+
+func TestResolveProjectAddsToUserChain(t *testing.T) {
+	guard := ChainEntry{Event: "PreToolUse", Tools: []string{"Bash"}, Env: []string{"A=user"}, Hooks: []HookEntry{
+		{Name: "guard", Command: "exit 2"},
+	}}
+	tests := []struct {
+		name    string
+		project ChainEntry
+		want    string
+	}{
+		{"same specificity", ChainEntry{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{{Name: "noop", Command: "true"}}}, "guard,noop"},
+		{"higher priority", ChainEntry{Event: "PreToolUse", Tools: []string{"Bash"}, Priority: 100, Hooks: []HookEntry{{Name: "noop", Command: "true"}}}, "guard,noop"},
+		{"more specific", ChainEntry{Event: "PreToolUse", Tools: []string{"Bash"}, Match: &MatchConfig{CommandRegex: "rm"}, Hooks: []HookEntry{{Name: "noop", Command: "true"}}}, "guard,noop"},
+		{"same hook name", ChainEntry{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{{Name: "guard", Command: "true"}}}, "guard"},
+	}
+	for _, tt := range tests {
+		for _, resolution := range []string{ResolutionBest, ResolutionAll} {
+			t.Run(tt.name+"/"+resolution, func(t *testing.T) {
+				tt.project.Project = true
+				cfg := Config{Resolution: resolution, Chains: []ChainEntry{guard, tt.project}}
+				chain, ok := cfg.ResolveInput(hook.Input{HookEventName: "PreToolUse", ToolName: "Bash", ToolInput: []byte(`{"command":"rm -rf /"}`)})
+				if !ok || hookNames(chain.Hooks) != tt.want {
+					t.Fatalf("hooks = %s (%t), want %s", hookNames(chain.Hooks), ok, tt.want)
+				}
+				if h := chain.Hooks[0]; h.Command != "exit 2" || !slices.Contains(cfg.HookEnv(chain, h), "A=user") {
+					t.Errorf("user hook = %+v, env %v; want the user's guard with its chain env", h, cfg.HookEnv(chain, h))
+				}
+			})
+		}
+	}
+}
+
+func hookNames(hooks []HookEntry) string {
+	names := make([]string, len(hooks))
+	for i, h := range hooks {
+		names[i] = h.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestHookTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults time.Duration
+		max      time.Duration
+		hook     time.Duration
+		want     time.Duration
+	}{
+		{"built-in default", 0, 0, 0, DefaultHookTimeout},
+		{"hook's own", 0, 0, 5 * time.Second, 5 * time.Second},
+		{"defaults.timeout", 10 * time.Second, 0, 0, 10 * time.Second},
+		{"hook beats defaults", 10 * time.Second, 0, 2 * time.Second, 2 * time.Second},
+		{"capped", 0, time.Minute, 30 * time.Minute, time.Minute},
+		{"default capped", 0, 5 * time.Second, 0, 5 * time.Second},
+		{"under the cap", 0, time.Minute, 20 * time.Second, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Defaults: DefaultsConfig{Timeout: tt.defaults}, Limits: LimitsConfig{MaxHookTimeout: tt.max}}
+			if got := cfg.HookTimeout(HookEntry{Timeout: tt.hook}); got != tt.want {
+				t.Errorf("HookTimeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTimeouts(t *testing.T) {
+	chains := []ChainEntry{{
+		Event:   "PreToolUse",
+		Hooks:   []HookEntry{{Name: "ok", Timeout: time.Minute}, {Name: "slow", Timeout: 30 * time.Minute}},
+		Finally: []HookEntry{{Name: "neg", Timeout: -time.Second}},
+	}}
+	tests := []struct {
+		name string
+		cfg  Config
+		want int
+	}{
+		{"no cap", Config{Chains: chains}, 1},
+		{"cap", Config{Chains: chains, Limits: LimitsConfig{MaxHookTimeout: 5 * time.Minute}}, 2},
+		{"default over cap", Config{Defaults: DefaultsConfig{Timeout: time.Hour}, Limits: LimitsConfig{MaxHookTimeout: 5 * time.Minute}}, 1},
+		{"clean", Config{Defaults: DefaultsConfig{Timeout: time.Minute}, Limits: LimitsConfig{MaxHookTimeout: 5 * time.Minute}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.cfg.ValidateTimeouts(); len(errs) != tt.want {
+				t.Errorf("ValidateTimeouts = %v, want %d errors", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateType(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    HookEntry
+		typ     string
+		wantErr bool
+	}{
+		{"process default", HookEntry{Command: "x"}, HookTypeProcess, false},
+		{"builtin implied", HookEntry{Builtin: "command-guard"}, HookTypeBuiltin, false},
+		{"shell", HookEntry{Type: HookTypeShell, Command: "x | y"}, HookTypeShell, false},
+		{"http", HookEntry{Type: HookTypeHTTP, URL: "http://localhost/hook"}, HookTypeHTTP, false},
+		{"custom", HookEntry{Type: "wasm", Command: "x"}, "wasm", false},
+		{"http without url", HookEntry{Type: HookTypeHTTP}, HookTypeHTTP, true},
+		{"http with command", HookEntry{Type: HookTypeHTTP, URL: "http://x", Command: "x"}, HookTypeHTTP, true},
+		{"url on process", HookEntry{Command: "x", URL: "http://x"}, HookTypeProcess, true},
+		{"builtin with other type", HookEntry{Type: HookTypeShell, Builtin: "command-guard"}, HookTypeShell, true},
+		{"builtin type without builtin", HookEntry{Type: HookTypeBuiltin}, HookTypeBuiltin, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hook.EffectiveType(); got != tt.typ {
+				t.Errorf("EffectiveType = %q, want %q", got, tt.typ)
+			}
+			if err := tt.hook.ValidateType(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateType = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	hook := func(name string) HookEntry { return HookEntry{Name: name, Command: name} }
+	cfg := Config{
+		Resolution: ResolutionAll,
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"*"}, Hooks: []HookEntry{hook("log"), hook("secrets")}, Severity: map[string]string{"info": "context"}, ProtectedFields: []string{"command"}, OnProtected: ProtectedStrip},
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{hook("bash-guard"), hook("secrets")}, Finally: []HookEntry{hook("notify")}, Severity: map[string]string{"info": "ask", "warn": "ask"}, ProtectedFields: []string{"command", "timeout"}},
+			{Event: "PostToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{hook("post")}},
+			{Event: AnyEvent, Hooks: []HookEntry{hook("trace")}},
+		},
+	}
+
+	chain, ok := cfg.ResolveChain("PreToolUse", "Bash")
+	if !ok {
+		t.Fatal("no chain resolved")
+	}
+	var names []string
+	for _, h := range chain.Hooks {
+		names = append(names, h.Name)
+	}
+	if got := strings.Join(names, ","); got != "log,secrets,bash-guard" {
+		t.Errorf("hooks = %s, want log,secrets,bash-guard", got)
+	}
+	if len(chain.Finally) != 1 || chain.Finally[0].Name != "notify" {
+		t.Errorf("finally = %v, want notify", chain.Finally)
+	}
+	if chain.Severity["info"] != "context" || chain.Severity["warn"] != "ask" {
+		t.Errorf("severity = %v, want earlier chains to win", chain.Severity)
+	}
+	if got := strings.Join(chain.ProtectedFields, ","); got != "command,timeout" || chain.OnProtected != ProtectedDeny {
+		t.Errorf("protected = %s (%s), want command,timeout (deny)", got, chain.OnProtected)
+	}
+
+	if hooks := cfg.Resolve("PreToolUse", "Read"); len(hooks) != 2 {
+		t.Errorf("Read: %d hooks, want the 2 of the global chain", len(hooks))
+	}
+	if hooks := cfg.Resolve("Stop", ""); len(hooks) != 1 || hooks[0].Name != "trace" {
+		t.Errorf("Stop: %v, want trace", hooks)
+	}
+	if _, ok := cfg.ResolveChain("SessionStart", "Bash"); ok {
+		t.Error("SessionStart/Bash resolved a chain, want none")
+	}
+
+	cfg.Resolution = ""
+	if hooks := cfg.Resolve("PreToolUse", "Bash"); len(hooks) != 2 || hooks[0].Name != "bash-guard" {
+		t.Errorf("default resolution: %v, want only the Bash chain", hooks)
+	}
+}
+
+func TestResolveAllDenyAsk(t *testing.T) {
+	ask := DenyActions{Ask: true}
+	cfg := Config{
+		Resolution: ResolutionAll,
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"Write"}, OnDeny: ask, Hooks: []HookEntry{{Name: "size", Command: "a"}}},
+			{Event: "PreToolUse", Tools: []string{"Write", "Edit"}, OnDeny: ask, Hooks: []HookEntry{{Name: "lint", Command: "b"}}},
+			{Event: "PreToolUse", Tools: []string{"Bash", "Edit"}, Hooks: []HookEntry{{Name: "guard", Command: "c"}}},
+		},
+	}
+	tests := []struct {
+		tool string
+		want bool
+	}{
+		{"Write", true}, // every matching chain asks
+		{"Edit", false}, // the guard chain still denies
+		{"Bash", false},
+	}
+	for _, tt := range tests {
+		chain, ok := cfg.ResolveChain("PreToolUse", tt.tool)
+		if !ok {
+			t.Fatalf("%s: no chain resolved", tt.tool)
+		}
+		if chain.OnDeny.Ask != tt.want {
+			t.Errorf("%s: on_deny ask = %v, want %v", tt.tool, chain.OnDeny.Ask, tt.want)
+		}
+	}
+}
+
+func TestResolveAllMaxDuration(t *testing.T) {
+	cfg := Config{
+		Resolution: ResolutionAll,
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"*"}, MaxDuration: 5 * time.Second, Hooks: []HookEntry{{Name: "log", Command: "a"}}},
+			{Event: "PreToolUse", Tools: []string{"Bash"}, MaxDuration: time.Second, OnMaxDuration: "allow", Hooks: []HookEntry{{Name: "guard", Command: "b"}}},
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{{Name: "lint", Command: "c"}}},
+		},
+	}
+	chain, ok := cfg.ResolveChain("PreToolUse", "Bash")
+	if !ok {
+		t.Fatal("no chain resolved")
+	}
+	if chain.MaxDuration != time.Second || chain.OnMaxDuration != "allow" {
+		t.Errorf("max_duration = %s (%s), want the tightest: 1s (allow)", chain.MaxDuration, chain.OnMaxDuration)
+	}
+}
+
+func TestResolveDisabled(t *testing.T) {
+	cfg := Config{
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Disabled: true, Hooks: []HookEntry{{Name: "off", Command: "a"}}},
+			{Event: "PreToolUse", Tools: []string{"*"}, Hooks: []HookEntry{{Name: "guard", Command: "b", Disabled: true}, {Name: "log", Command: "c"}}},
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{{Name: "guard", Command: "d"}}},
+		},
+	}
+
+	// The disabled Bash chain is passed over for the next best match.
+	if hooks := cfg.Resolve("PreToolUse", "Bash"); len(hooks) != 1 || hooks[0].Command != "d" {
+		t.Errorf("Resolve = %v, want the enabled Bash chain", hooks)
+	}
+
+	// With resolution all, a disabled hook neither runs nor hides a later
+	// hook of the same name.
+	cfg.Resolution = ResolutionAll
+	var names []string
+	for _, h := range cfg.Resolve("PreToolUse", "Bash") {
+		names = append(names, h.Name+"="+h.Command)
+	}
+	if got := strings.Join(names, ","); got != "log=c,guard=d" {
+		t.Errorf("resolution all: hooks = %s, want log=c,guard=d", got)
+	}
+}
+
+func TestValidateResolution(t *testing.T) {
+	for _, mode := range []string{"", ResolutionBest, ResolutionAll} {
+		if err := (Config{Resolution: mode}).ValidateResolution(); err != nil {
+			t.Errorf("%q: %v", mode, err)
+		}
+	}
+	if err := (Config{Resolution: "first"}).ValidateResolution(); err == nil {
+		t.Error("unknown mode: want error")
+	}
+}
+
+func TestResolvePriority(t *testing.T) {
+	hook := func(name string) HookEntry { return HookEntry{Name: name, Command: name} }
+	cfg := Config{Chains: []ChainEntry{
+		{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{hook("bash")}},
+		{Event: "PreToolUse", Tools: []string{"*"}, Hooks: []HookEntry{hook("global")}, Priority: 10},
+		{Event: "PreToolUse", Tools: []string{"Bash*"}, Hooks: []HookEntry{hook("bash-glob")}, Priority: 10},
+		{Event: "PreToolUse", Tools: []string{"Read"}, Hooks: []HookEntry{hook("read")}, Priority: -1},
+	}}
+
+	tests := []struct {
+		tool string
+		want string
+	}{
+		{"Bash", "bash-glob"}, // highest priority, then most specific
+		{"Write", "global"},   // the only match
+		{"Read", "global"},    // priority beats an exact tool name
+	}
+	for _, tt := range tests {
+		hooks := cfg.Resolve("PreToolUse", tt.tool)
+		if len(hooks) != 1 || hooks[0].Name != tt.want {
+			t.Errorf("%s: %v, want %s", tt.tool, hooks, tt.want)
+		}
+	}
+
+	if got := fmt.Sprint(cfg.ChainOrder()); got != "[1 2 0 3]" {
+		t.Errorf("ChainOrder = %s, want [1 2 0 3]", got)
+	}
+
+	cfg.Resolution = ResolutionAll
+	var names []string
+	for _, h := range cfg.Resolve("PreToolUse", "Bash") {
+		names = append(names, h.Name)
+	}
+	if got := strings.Join(names, ","); got != "global,bash-glob,bash" {
+		t.Errorf("all: %s, want global,bash-glob,bash", got)
+	}
+}
+
+func TestHookEnv(t *testing.T) {
+	cfg := Config{
+		Defaults: DefaultsConfig{Env: []string{"POLICY_LEVEL=strict", "-AWS_PROFILE"}},
+		Chains: []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"*"}, Env: []string{"PROJECT_ROOT=/src"}, Hooks: []HookEntry{{Name: "a", Command: "a", Env: []string{"POLICY_LEVEL=lax"}}}},
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Env: []string{"SHELL_ONLY=1"}, Hooks: []HookEntry{{Name: "b", Command: "b"}}},
+		},
+	}
+
+	chain := cfg.Chains[0]
+	got := strings.Join(cfg.ApplyEnv(chain, chain.Hooks)[0].Env, " ")
+	if want := "POLICY_LEVEL=strict -AWS_PROFILE PROJECT_ROOT=/src POLICY_LEVEL=lax"; got != want {
+		t.Errorf("env = %q, want %q", got, want)
+	}
+	if len(chain.Hooks[0].Env) != 1 {
+		t.Errorf("ApplyEnv modified the config's hook: %v", chain.Hooks[0].Env)
+	}
+
+	// With resolution: all, each chain's env stays with its own hooks.
+	cfg.Resolution = ResolutionAll
+	combined, _ := cfg.ResolveChain("PreToolUse", "Bash")
+	hooks := cfg.ApplyEnv(combined, combined.Hooks)
+	if got := strings.Join(hooks[1].Env, " "); got != "POLICY_LEVEL=strict -AWS_PROFILE SHELL_ONLY=1" {
+		t.Errorf("b env = %q", got)
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	cfg := Config{
+		Defaults: DefaultsConfig{Env: []string{"A=1", "-B", "C"}},
+		Chains: []ChainEntry{{
+			Env:   []string{"-D=1", "=x"},
+			Hooks: []HookEntry{{Name: "h", Env: []string{"E=", "-"}}},
+		}},
+	}
+	errs := cfg.ValidateEnv()
+	if len(errs) != 4 {
+		t.Fatalf("got %d errors, want 4: %v", len(errs), errs)
+	}
+	for i, want := range []string{`defaults: env entry "C"`, `chain 1: env entry "-D=1"`, `chain 1: env entry "=x"`, `hook "h": env entry "-"`} {
+		if !strings.Contains(errs[i].Error(), want) {
+			t.Errorf("errs[%d] = %v, want %s", i, errs[i], want)
+		}
+	}
+}
+
+func TestResolveInputMatch(t *testing.T) {
+	entry := func(name string) HookEntry { return HookEntry{Name: name, Command: name} }
+	cfg := Config{Chains: []ChainEntry{
+		{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{entry("light")}},
+		{Event: "PreToolUse", Tools: []string{"*"}, Match: &MatchConfig{CommandRegex: `\b(rm|sudo)\b`}, Hooks: []HookEntry{entry("strict")}},
+		{Event: "PreToolUse", Tools: []string{"Write", "Edit"}, Match: &MatchConfig{FilePathGlob: "*.env"}, Hooks: []HookEntry{entry("env-guard")}},
+		{Event: "PreToolUse", Tools: []string{"Write"}, Match: &MatchConfig{FilePathGlob: "/etc/*"}, Hooks: []HookEntry{entry("etc-guard")}},
+	}}
+
+	tests := []struct {
+		tool  string
+		input string
+		want  string
+	}{
+		{"Bash", `{"command":"ls -la"}`, "light"},
+		{"Bash", `{"command":"sudo rm -rf build"}`, "strict"},
+		{"Bash", `{"command":"grep -r firmware ."}`, "light"},
+		{"Bash", ``, "light"},
+		{"Write", `{"file_path":"/src/app/.env"}`, "env-guard"},
+		{"Write", `{"file_path":"/etc/hosts"}`, "etc-guard"},
+		{"Write", `{"file_path":"/etc/sub/hosts"}`, ""},
+		{"Edit", `{"file_path":"main.go"}`, ""},
+		{"Read", `{"command":"rm x"}`, "strict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.input, func(t *testing.T) {
+			in := hook.Input{HookEventName: "PreToolUse", ToolName: tt.tool, ToolInput: json.RawMessage(tt.input)}
+			chain, ok := cfg.ResolveInput(in)
+			got := ""
+			if ok {
+				got = chain.Hooks[0].Name
+			}
+			if got != tt.want {
+				t.Errorf("resolved %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without tool_input, chains with a match block never match.
+	if hooks := cfg.Resolve("PreToolUse", "Read"); hooks != nil {
+		t.Errorf("Resolve without input: %v, want none", hooks)
+	}
+}
+
+func TestMatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   *MatchConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"regex", &MatchConfig{CommandRegex: `^git push`}, false},
+		{"glob", &MatchConfig{FilePathGlob: "*.pem"}, false},
+		{"empty", &MatchConfig{}, true},
+		{"bad regex", &MatchConfig{CommandRegex: `(`}, true},
+		{"bad glob", &MatchConfig{FilePathGlob: "[a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.match.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchSessionContext(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	m := &MatchConfig{PermissionMode: []string{"bypassPermissions", "acceptEdits"}, CWDGlob: "~/clients/*"}
+	tests := []struct {
+		name string
+		mode string
+		cwd  string
+		want bool
+	}{
+		{"mode and dir", "bypassPermissions", "/home/alice/clients/acme", true},
+		{"below the dir", "acceptEdits", "/home/alice/clients/acme/src/api", true},
+		{"other mode", "default", "/home/alice/clients/acme", false},
+		{"no mode", "", "/home/alice/clients/acme", false},
+		{"other dir", "bypassPermissions", "/home/alice/oss/tool", false},
+		{"dir itself only", "bypassPermissions", "/home/alice/clients", false},
+		{"no cwd", "bypassPermissions", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := hook.Input{PermissionMode: tt.mode, CWD: tt.cwd}
+			if got := m.Matches(in); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := (&MatchConfig{PermissionMode: []string{"yolo"}}).Validate(); err == nil {
+		t.Error("unknown permission mode: want error")
+	}
+	if err := (&MatchConfig{CWDGlob: "/src/[x"}).Validate(); err == nil {
+		t.Error("bad cwd_glob: want error")
+	}
+}
+
+func TestMatchCompilesOnce(t *testing.T) {
+	m := &MatchConfig{CommandRegex: `^rm\b`}
+	in := hook.Input{ToolInput: json.RawMessage(`{"command":"rm -rf x"}`)}
+	if !m.Matches(in) {
+		t.Fatal("first match failed")
+	}
+	re := m.commandRe
+	if re == nil || !m.Matches(in) || m.commandRe != re {
+		t.Error("command_regex was not compiled once and reused")
+	}
+
+	bad := &MatchConfig{CommandRegex: `(`}
+	if bad.Matches(in) || bad.Matches(in) {
+		t.Error("invalid command_regex matched")
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// ReadEnvFile reads the hook's env_file and returns its variables as
+// "NAME=value" entries, in file order; it returns nil when env_file is unset.
+// The file is read each time, so a hook run picks up edits without a config
+// change. The path is expanded like workdir.
+func (h HookEntry) ReadEnvFile() ([]string, error) {
+	if h.EnvFile == "" {
+		return nil, nil
+	}
+	path := pathutil.Expand(h.EnvFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: hook %q: read env_file: %w", h.Name, err)
+	}
+	env, err := ParseEnvFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("config: hook %q: env_file %s: %w", h.Name, path, err)
+	}
+	return env, nil
+}
+
+// ParseEnvFile parses dotenv-style data: one NAME=value per line, with
+// blank lines and lines starting with # ignored and an optional "export "
+// prefix. A value may be quoted: single quotes keep it as written, double
+// quotes also understand \n, \", and \\. An unquoted value ends at " #",
+// which starts a comment. Values are not expanded.
+func ParseEnvFile(data []byte) ([]string, error) {
+	var env []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("line %d: not NAME=value", n)
+		}
+		value, err := envValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// envValue unquotes one env file value.
+func envValue(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	switch q := v[0]; q {
+	case '\'', '"':
+		end := strings.LastIndexByte(v, q)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated %c quote", q)
+		}
+		if rest := strings.TrimSpace(v[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		v = v[1:end]
+		if q == '"' {
+			v = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(v)
+		}
+		return v, nil
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v, nil
+}
+
+// validEnvName reports whether name is a shell variable name.
+func validEnvName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', 'A' <= r && r <= 'Z', 'a' <= r && r <= 'z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr string
+	}{
+		{"empty", "", nil, ""},
+		{"plain", "A=1\nB=two words\n", []string{"A=1", "B=two words"}, ""},
+		{"comments and blanks", "# header\n\nA=1 # note\n  # indented\n", []string{"A=1"}, ""},
+		{"export prefix", "export TOKEN=abc\n", []string{"TOKEN=abc"}, ""},
+		{"empty value", "EMPTY=\n", []string{"EMPTY="}, ""},
+		{"single quotes", `A='x # not a comment \n'`, []string{`A=x # not a comment \n`}, ""},
+		{"double quotes", `A="line1\nline2 \"q\" \\" # note`, []string{"A=line1\nline2 \"q\" \\"}, ""},
+		{"equals in value", "URL=https://x.test/?a=b\n", []string{"URL=https://x.test/?a=b"}, ""},
+		{"no equals", "A=1\nJUSTNAME\n", nil, "line 2: not NAME=value"},
+		{"bad name", "1A=x\n", nil, "line 1: not NAME=value"},
+		{"unterminated quote", `A="open`, nil, "line 1: unterminated \" quote"},
+		{"trailing text", `A='x' y`, nil, `line 1: unexpected "y" after quoted value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEnvFile([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseEnvFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEnvFile: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseEnvFile = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ENV_TEST_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "hook.env"), []byte("API_TOKEN=s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if env, err := (HookEntry{Name: "h"}).ReadEnvFile(); env != nil || err != nil {
+		t.Errorf("ReadEnvFile without env_file = %v, %v; want nil, nil", env, err)
+	}
+	env, err := HookEntry{Name: "h", EnvFile: "$ENV_TEST_DIR/hook.env"}.ReadEnvFile()
+	if err != nil || !slices.Equal(env, []string{"API_TOKEN=s3cret"}) {
+		t.Errorf("ReadEnvFile = %v, %v", env, err)
+	}
+	_, err = HookEntry{Name: "h", EnvFile: "$ENV_TEST_DIR/missing.env"}.ReadEnvFile()
+	if err == nil || !strings.Contains(err.Error(), `hook "h": read env_file`) {
+		t.Errorf("ReadEnvFile(missing) = %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Decisions an exit_codes entry maps an exit code to.
+const (
+	ExitPass = "pass" // continue the chain, as for exit 0 with no output
+	ExitDeny = "deny" // block, as for exit 2
+	ExitAsk  = "ask"  // escalate to the user
+	ExitSkip = "skip" // ignore the hook, as for on_error: skip
+)
+
+// ExitDecisions lists the valid exit_codes decisions.
+var ExitDecisions = []string{ExitPass, ExitDeny, ExitAsk, ExitSkip}
+
+// ExitCodeRule is the decision a hook's exit code stands for.
+type ExitCodeRule struct {
+	Decision string `yaml:"decision"`
+	// Reason is a text/template rendered with the hook's message data
+	// (.Hook, .ExitCode, ...; .Reason is the hook's stderr). It defaults to
+	// the stderr. For pass it is shown as a warning when set.
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// ExitCodeRule returns the rule exit_codes has for code, if any.
+func (h HookEntry) ExitCodeRule(code int) (ExitCodeRule, bool) {
+	rule, ok := h.ExitCodes[code]
+	return rule, ok
+}
+
+// ValidateExitCodes reports exit_codes entries with a code outside 1-255
+// (exit 0 always speaks the JSON protocol), an unknown decision, or a
+// reason that does not parse.
+func (h HookEntry) ValidateExitCodes() []error {
+	var errs []error
+	for _, code := range slices.Sorted(maps.Keys(h.ExitCodes)) {
+		rule := h.ExitCodes[code]
+		if code < 1 || code > 255 {
+			errs = append(errs, fmt.Errorf("config: hook %q: exit_codes: code %d is not between 1 and 255", h.Name, code))
+		}
+		if !slices.Contains(ExitDecisions, rule.Decision) {
+			errs = append(errs, fmt.Errorf("config: hook %q: exit_codes %d: decision %q is not one of %s", h.Name, code, rule.Decision, strings.Join(ExitDecisions, ", ")))
+		}
+		if _, err := template.New("").Parse(rule.Reason); err != nil {
+			errs = append(errs, fmt.Errorf("config: hook %q: exit_codes %d: reason: %w", h.Name, code, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExitCodesYAML(t *testing.T) {
+	var h HookEntry
+	src := "name: legacy\ncommand: lint\nexit_codes:\n  1: {decision: pass, reason: 'warning: {{.Reason}}'}\n  3: {decision: deny}\n"
+	if err := yaml.Unmarshal([]byte(src), &h); err != nil {
+		t.Fatal(err)
+	}
+	if rule, ok := h.ExitCodeRule(1); !ok || rule.Decision != ExitPass || rule.Reason != "warning: {{.Reason}}" {
+		t.Errorf("ExitCodeRule(1) = %+v, %v", rule, ok)
+	}
+	if rule, ok := h.ExitCodeRule(3); !ok || rule.Decision != ExitDeny {
+		t.Errorf("ExitCodeRule(3) = %+v, %v", rule, ok)
+	}
+	if _, ok := h.ExitCodeRule(2); ok {
+		t.Error("ExitCodeRule(2): want unmapped")
+	}
+	if errs := h.ValidateExitCodes(); len(errs) != 0 {
+		t.Errorf("ValidateExitCodes = %v", errs)
+	}
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes map[int]ExitCodeRule
+		want  string
+	}{
+		{"zero", map[int]ExitCodeRule{0: {Decision: ExitDeny}}, "code 0 is not between 1 and 255"},
+		{"too big", map[int]ExitCodeRule{256: {Decision: ExitDeny}}, "code 256 is not between 1 and 255"},
+		{"decision", map[int]ExitCodeRule{1: {Decision: "warn"}}, `decision "warn" is not one of pass, deny, ask, skip`},
+		{"template", map[int]ExitCodeRule{1: {Decision: ExitAsk, Reason: "{{.Hook"}}, "exit_codes 1: reason:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := HookEntry{Name: "h", ExitCodes: tt.codes}.ValidateExitCodes()
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.want) {
+				t.Errorf("ValidateExitCodes = %v, want one error containing %q", errs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExpandHookDefs returns c with every hook that names a definition with
+// `use:` replaced by that hook_defs entry, overlaid with the fields the hook
+// sets itself: those replace the definition's, except options, which are
+// merged key by key. The hook's name defaults to the definition's name, then
+// to its key. Use is kept, so expanding twice changes nothing.
+func (c Config) ExpandHookDefs() (Config, error) {
+	for _, name := range slices.Sorted(maps.Keys(c.HookDefs)) {
+		if use := c.HookDefs[name].Use; use != "" {
+			return Config{}, fmt.Errorf("config: hook_defs %q uses %q: definitions cannot use other definitions", name, use)
+		}
+	}
+	chains := slices.Clone(c.Chains)
+	for i := range chains {
+		hooks, err := c.expandHooks(chains[i].Hooks)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: chain %d: %w", i+1, err)
+		}
+		finally, err := c.expandHooks(chains[i].Finally)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: chain %d finally: %w", i+1, err)
+		}
+		chains[i].Hooks, chains[i].Finally = hooks, finally
+	}
+	c.Chains = chains
+	return c, nil
+}
+
+// expandHooks expands the hooks that use a definition, copying the slice
+// only when one does.
+func (c Config) expandHooks(hooks []HookEntry) ([]HookEntry, error) {
+	if !slices.ContainsFunc(hooks, func(h HookEntry) bool { return h.Use != "" }) {
+		return hooks, nil
+	}
+	out := slices.Clone(hooks)
+	for i, h := range out {
+		if h.Use == "" {
+			continue
+		}
+		def, ok := c.HookDefs[h.Use]
+		if !ok {
+			return nil, fmt.Errorf("hook %d uses %q, which is not in hook_defs", i+1, h.Use)
+		}
+		expanded, err := overlayHook(def, h)
+		if err != nil {
+			return nil, fmt.Errorf("hook %d (use %q): %w", i+1, h.Use, err)
+		}
+		out[i] = expanded
+	}
+	return out, nil
+}
+
+// overlayHook decodes the fields h sets over a copy of def. Every HookEntry
+// field but name is omitted when empty, so only what h sets is decoded.
+func overlayHook(def, h HookEntry) (HookEntry, error) {
+	data, err := yaml.Marshal(h)
+	if err != nil {
+		return HookEntry{}, err
+	}
+	out := def
+	out.Options = maps.Clone(def.Options)
+	// workdir and working_dir are one setting: the hook's replaces either.
+	if h.EffectiveWorkdir() != "" {
+		out.Workdir, out.WorkingDir = "", ""
+	}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return HookEntry{}, err
+	}
+	out.Name = cmp.Or(h.Name, def.Name, h.Use)
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandHookDefs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `hook_defs:
+  secret-scan:
+    command: secret-scan --strict
+    timeout: 5s
+    on_error: skip
+    workdir: /srv/repo
+  paths:
+    name: path-guard
+    builtin: write-guard
+    options: {deny: ["/etc/**"], allow: ["/tmp/**"]}
+chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks:
+      - use: secret-scan
+      - use: secret-scan
+        name: scan-slow
+        timeout: 20s
+        working_dir: ~/repo
+    finally:
+      - use: paths
+        options: {allow: ["/var/tmp/**"]}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOOK_CHAIN_CONFIG", path)
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "0")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := cfg.Resolve("PreToolUse", "Bash")
+	if len(hooks) != 2 {
+		t.Fatalf("got %d hooks, want 2", len(hooks))
+	}
+	if h := hooks[0]; h.Name != "secret-scan" || h.Command != "secret-scan --strict" || h.Timeout != 5*time.Second || h.OnError != "skip" || h.Use != "secret-scan" {
+		t.Errorf("hook 1 = %+v, want the definition named after its key", h)
+	}
+	if h := hooks[1]; h.Name != "scan-slow" || h.Command != "secret-scan --strict" || h.Timeout != 20*time.Second {
+		t.Errorf("hook 2 = %+v, want the definition with name and timeout overridden", h)
+	}
+	if dir, err := hooks[1].EffectiveWorkdir(), hooks[1].ValidateWorkdir(); dir != "~/repo" || err != nil {
+		t.Errorf("hook 2 workdir = %q, %v; want working_dir to replace the definition's workdir", dir, err)
+	}
+	f := cfg.Chains[0].Finally[0]
+	if f.Name != "path-guard" || f.Builtin != "write-guard" {
+		t.Errorf("finally = %+v, want the definition's own name", f)
+	}
+	if deny, allow := f.Options["deny"], f.Options["allow"]; deny == nil || len(allow.([]any)) != 1 || allow.([]any)[0] != "/var/tmp/**" {
+		t.Errorf("options = %v, want deny kept and allow replaced", f.Options)
+	}
+	if _, ok := cfg.HookDefs["paths"].Options["allow"].([]any); !ok || cfg.HookDefs["paths"].Options["allow"].([]any)[0] != "/tmp/**" {
+		t.Errorf("definition options changed: %v", cfg.HookDefs["paths"].Options)
+	}
+
+	again, err := cfg.ExpandHookDefs()
+	if err != nil || again.Chains[0].Hooks[1].Timeout != 20*time.Second {
+		t.Errorf("second expansion = %+v, %v; want no change", again.Chains[0].Hooks[1], err)
+	}
+
+	raw, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Chains[0].Hooks[0].Command != "" {
+		t.Error("LoadFrom expanded a hook definition")
+	}
+}
+
+func TestExpandHookDefsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			"missing definition",
+			Config{Chains: []ChainEntry{{Hooks: []HookEntry{{Name: "a", Command: "a"}, {Use: "nope"}}}}},
+			`chain 1: hook 2 uses "nope", which is not in hook_defs`,
+		},
+		{
+			"missing in finally",
+			Config{Chains: []ChainEntry{{Finally: []HookEntry{{Use: "nope"}}}}},
+			"chain 1 finally: hook 1",
+		},
+		{
+			"definition uses another",
+			Config{HookDefs: map[string]HookEntry{"a": {Use: "b"}, "b": {Command: "b"}}},
+			`hook_defs "a" uses "b"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.ExpandHookDefs()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MCPToolPrefix starts the tool name of every MCP tool:
+// mcp__<server>__<tool>.
+const MCPToolPrefix = "mcp__"
+
+// SplitMCPTool splits an MCP tool name into its server and tool. ok is false
+// for tools that are not MCP tools.
+func SplitMCPTool(name string) (server, tool string, ok bool) {
+	rest, ok := strings.CutPrefix(name, MCPToolPrefix)
+	if !ok {
+		return "", "", false
+	}
+	server, tool, ok = strings.Cut(rest, "__")
+	if !ok || server == "" || tool == "" {
+		return "", "", false
+	}
+	return server, tool, true
+}
+
+// HasMCP reports whether the chain matches MCP tools by server or tool
+// (mcp_server, mcp_tool).
+func (c ChainEntry) HasMCP() bool {
+	return c.MCPServer != "" || c.MCPTool != ""
+}
+
+// mcpPattern is the tool glob equivalent to the chain's mcp_server and
+// mcp_tool; either defaults to "*".
+func (c ChainEntry) mcpPattern() string {
+	return MCPToolPrefix + cmp.Or(c.MCPServer, "*") + "__" + cmp.Or(c.MCPTool, "*")
+}
+
+// mcpRank reports how specifically mcp_server and mcp_tool match toolName,
+// ranked like the equivalent tools glob (see toolRank): -1 when the chain
+// has neither, toolName is not an MCP tool, or either does not match.
+func (c ChainEntry) mcpRank(toolName string) int {
+	if !c.HasMCP() {
+		return -1
+	}
+	server, tool, ok := SplitMCPTool(toolName)
+	if !ok {
+		return -1
+	}
+	for _, m := range [][2]string{{c.MCPServer, server}, {c.MCPTool, tool}} {
+		if m[0] == "" {
+			continue
+		}
+		if ok, err := path.Match(m[0], m[1]); err != nil || !ok {
+			return -1
+		}
+	}
+	return toolRank(c.mcpPattern(), toolName)
+}
+
+// mcpLabel describes mcp_server and mcp_tool for display, e.g.
+// "MCP github/*"; "" when the chain sets neither.
+func (c ChainEntry) mcpLabel() string {
+	if !c.HasMCP() {
+		return ""
+	}
+	return "MCP " + cmp.Or(c.MCPServer, "*") + "/" + cmp.Or(c.MCPTool, "*")
+}
+
+// validateMCP reports malformed mcp_server and mcp_tool globs.
+func (c ChainEntry) validateMCP() []error {
+	var errs []error
+	for _, f := range []struct{ key, pattern string }{{"mcp_server", c.MCPServer}, {"mcp_tool", c.MCPTool}} {
+		if f.pattern == "" {
+			continue
+		}
+		if _, err := path.Match(f.pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("config: %s pattern %q: %w", f.key, f.pattern, err))
+		}
+	}
+	return errs
+}
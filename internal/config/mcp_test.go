@@ -0,0 +1,78 @@
+package config
+
+import "testing"
+
+func TestSplitMCPTool(t *testing.T) {
+	tests := []struct {
+		name, server, tool string
+		ok                 bool
+	}{
+		{"mcp__github__create_issue", "github", "create_issue", true},
+		{"mcp__my_server__list__all", "my_server", "list__all", true},
+		{"mcp__github", "", "", false},
+		{"mcp____tool", "", "", false},
+		{"Bash", "", "", false},
+	}
+	for _, tt := range tests {
+		server, tool, ok := SplitMCPTool(tt.name)
+		if server != tt.server || tool != tt.tool || ok != tt.ok {
+			t.Errorf("SplitMCPTool(%q) = %q, %q, %v; want %q, %q, %v", tt.name, server, tool, ok, tt.server, tt.tool, tt.ok)
+		}
+	}
+}
+
+func TestResolveMCP(t *testing.T) {
+	hooks := func(name string) []HookEntry { return []HookEntry{{Name: name, Command: name}} }
+	tests := []struct {
+		name   string
+		chains []ChainEntry
+		tool   string
+		want   string
+	}{
+		{"server", []ChainEntry{{Event: "PreToolUse", MCPServer: "github", Hooks: hooks("gh")}}, "mcp__github__create_issue", "gh"},
+		{"other server", []ChainEntry{{Event: "PreToolUse", MCPServer: "github", Hooks: hooks("gh")}}, "mcp__slack__post", ""},
+		{"not an MCP tool", []ChainEntry{{Event: "PreToolUse", MCPServer: "*", Hooks: hooks("any")}}, "Bash", ""},
+		{"server glob", []ChainEntry{{Event: "PreToolUse", MCPServer: "git*", Hooks: hooks("git")}}, "mcp__gitlab__merge", "git"},
+		{"tool on any server", []ChainEntry{{Event: "PreToolUse", MCPTool: "delete_*", Hooks: hooks("del")}}, "mcp__drive__delete_file", "del"},
+		{"server and tool", []ChainEntry{{Event: "PreToolUse", MCPServer: "github", MCPTool: "merge_*", Hooks: hooks("merge")}}, "mcp__github__create_issue", ""},
+		{"exact tool beats server", []ChainEntry{
+			{Event: "PreToolUse", MCPServer: "github", Hooks: hooks("gh")},
+			{Event: "PreToolUse", MCPServer: "github", MCPTool: "merge_pull_request", Hooks: hooks("merge")},
+		}, "mcp__github__merge_pull_request", "merge"},
+		{"server beats star", []ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"*"}, Hooks: hooks("all")},
+			{Event: "PreToolUse", MCPServer: "github", Hooks: hooks("gh")},
+		}, "mcp__github__create_issue", "gh"},
+		{"with tools", []ChainEntry{{Event: "PreToolUse", Tools: []string{"Bash"}, MCPServer: "github", Hooks: hooks("both")}}, "Bash", "both"},
+		{"exclusion", []ChainEntry{{Event: "PreToolUse", Tools: []string{"!mcp__github__get_*"}, MCPServer: "github", Hooks: hooks("gh")}}, "mcp__github__get_issue", ""},
+		{"exclusion leaves MCP only", []ChainEntry{{Event: "PreToolUse", Tools: []string{"!mcp__github__get_*"}, MCPServer: "github", Hooks: hooks("gh")}}, "Bash", ""},
+		{"not toolless events", []ChainEntry{{Event: "PreToolUse", MCPServer: "github", Hooks: hooks("gh")}}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Chains: tt.chains}
+			got := ""
+			if hooks := cfg.Resolve("PreToolUse", tt.tool); len(hooks) > 0 {
+				got = hooks[0].Name
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPLabelAndValidate(t *testing.T) {
+	c := ChainEntry{MCPServer: "github"}
+	if got := c.ToolLabel(); got != "MCP github/*" {
+		t.Errorf("ToolLabel = %q", got)
+	}
+	c = ChainEntry{Tools: []string{"Bash"}, MCPTool: "delete_*"}
+	if got := c.ToolLabel(); got != "Bash, MCP */delete_*" {
+		t.Errorf("ToolLabel = %q", got)
+	}
+	c = ChainEntry{MCPServer: "[git", MCPTool: "ok"}
+	if errs := c.ValidateTools(); len(errs) != 1 {
+		t.Errorf("ValidateTools = %v, want 1 error", errs)
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config layout this hook-chain reads natively and
+// writes. A file without `version:` (or its alias `config_version:`) has the
+// original layout, version 1.
+const CurrentVersion = 2
+
+// migration upgrades a config document from version from to from+1, in
+// place, and describes each change it made.
+type migration struct {
+	from  int
+	apply func(root *yaml.Node) []string
+}
+
+// migrations run in order; each one's from is the previous one's from+1.
+var migrations = []migration{
+	{from: 1, apply: renameWorkdir},
+}
+
+// Migrate upgrades a parsed config file to CurrentVersion in place and sets
+// its version, under config_version when the file uses that spelling. It
+// returns the version the file had and the changes made ("line 12: workdir
+// is deprecated, renamed to working_dir"). A file that is already
+// current is left alone, and so is an empty one, reported as current. A
+// version newer than CurrentVersion is an error: the file was written for a
+// newer hook-chain, which this one would misread.
+func Migrate(doc *yaml.Node) (from int, notes []string, err error) {
+	root := docRoot(doc)
+	if root.Kind != yaml.MappingNode {
+		return CurrentVersion, nil, nil
+	}
+	version := 1
+	v, alias := mapValue(root, "version"), mapValue(root, "config_version")
+	switch {
+	case v == nil:
+		v = alias
+	case alias != nil && alias.Value != v.Value:
+		return 0, nil, fmt.Errorf("line %d: config_version %q and version %q disagree; set one", alias.Line, alias.Value, v.Value)
+	}
+	if v != nil {
+		n, err := strconv.Atoi(v.Value)
+		if err != nil || n < 1 {
+			return 0, nil, fmt.Errorf("line %d: version %q is not a positive number", v.Line, v.Value)
+		}
+		version = n
+	}
+	if version > CurrentVersion {
+		return 0, nil, fmt.Errorf("version %d is newer than this hook-chain supports (%d); upgrade hook-chain", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return version, nil, nil
+	}
+
+	for _, m := range migrations {
+		if m.from >= version {
+			notes = append(notes, m.apply(root)...)
+		}
+	}
+	if v == nil {
+		// First key, so the version heads the file, below any comment
+		// that opens it.
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		v = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int"}
+		if len(root.Content) > 0 {
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		root.Content = append([]*yaml.Node{key, v}, root.Content...)
+	}
+	v.Value = strconv.Itoa(CurrentVersion)
+	if alias != nil {
+		alias.Value = v.Value
+	}
+	return version, notes, nil
+}
+
+// hookNodes returns every hook mapping of the document: the hooks and
+// finally hooks of top-level and profile chains, and hook_defs entries.
+func hookNodes(root *yaml.Node) []*yaml.Node {
+	chains := seq(mapValue(root, "chains"))
+	if profiles := mapValue(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 1; i < len(profiles.Content); i += 2 {
+			chains = slices.Concat(chains, seq(mapValue(profiles.Content[i], "chains")))
+		}
+	}
+	var hooks []*yaml.Node
+	for _, chain := range chains {
+		hooks = append(hooks, seq(mapValue(chain, "hooks"))...)
+		hooks = append(hooks, seq(mapValue(chain, "finally"))...)
+	}
+	if defs := mapValue(root, "hook_defs"); defs != nil && defs.Kind == yaml.MappingNode {
+		for i := 1; i < len(defs.Content); i += 2 {
+			hooks = append(hooks, defs.Content[i])
+		}
+	}
+	return hooks
+}
+
+// renameWorkdir (1 → 2) renames the hook setting workdir to working_dir. A
+// hook that sets both keeps them, for validate to report if they differ;
+// when they agree, workdir is dropped.
+func renameWorkdir(root *yaml.Node) []string {
+	var notes []string
+	for _, h := range hookNodes(root) {
+		if h.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(h.Content); i += 2 {
+			key := h.Content[i]
+			if key.Value != "workdir" {
+				continue
+			}
+			other := mapValue(h, "working_dir")
+			switch {
+			case other == nil:
+				key.Value = "working_dir"
+				notes = append(notes, fmt.Sprintf("line %d: workdir is deprecated, renamed to working_dir", key.Line))
+			case other.Value == h.Content[i+1].Value:
+				h.Content = append(h.Content[:i], h.Content[i+2:]...)
+				notes = append(notes, fmt.Sprintf("line %d: workdir is deprecated, removed as the same as working_dir", key.Line))
+			}
+			break
+		}
+	}
+	return notes
+}
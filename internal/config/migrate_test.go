@@ -0,0 +1,127 @@
+package config
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		wantFrom  int
+		wantNotes []string
+		want      string // "" = unchanged
+		wantErr   bool
+	}{
+		{
+			name:      "workdir renamed",
+			in:        "chains:\n  - event: Stop\n    hooks:\n      - name: a\n        workdir: /src\n",
+			wantFrom:  1,
+			wantNotes: []string{"line 5: workdir is deprecated, renamed to working_dir"},
+			want:      "version: 2\nchains:\n  - event: Stop\n    hooks:\n      - name: a\n        working_dir: /src\n",
+		},
+		{
+			name:      "duplicate workdir dropped",
+			in:        "hook_defs:\n  a:\n    workdir: /src\n    working_dir: /src\n",
+			wantFrom:  1,
+			wantNotes: []string{"line 3: workdir is deprecated, removed as the same as working_dir"},
+			want:      "version: 2\nhook_defs:\n  a:\n    working_dir: /src\n",
+		},
+		{
+			name:     "conflicting workdir kept for validate",
+			in:       "profiles:\n  ci:\n    chains:\n      - event: Stop\n        finally:\n          - {name: a, workdir: /x, working_dir: /y}\n",
+			wantFrom: 1,
+			want:     "version: 2\nprofiles:\n  ci:\n    chains:\n      - event: Stop\n        finally:\n          - {name: a, workdir: /x, working_dir: /y}\n",
+		},
+		{
+			name:     "head comment stays on top",
+			in:       "# hooks\nchains: []\n",
+			wantFrom: 1,
+			want:     "# hooks\nversion: 2\nchains: []\n",
+		},
+		{name: "current", in: "version: 2\nchains:\n  - {event: Stop, hooks: [{name: a, workdir: /x}]}\n", wantFrom: 2},
+		{
+			name:      "config_version alias",
+			in:        "config_version: 1\nhook_defs:\n  a: {workdir: /x}\n",
+			wantFrom:  1,
+			wantNotes: []string{"line 3: workdir is deprecated, renamed to working_dir"},
+			want:      "config_version: 2\nhook_defs:\n  a: {working_dir: /x}\n",
+		},
+		{name: "config_version current", in: "config_version: 2\n", wantFrom: 2},
+		{name: "both agree", in: "version: 2\nconfig_version: 2\n", wantFrom: 2},
+		{name: "both migrated", in: "version: 1\nconfig_version: 1\n", wantFrom: 1, want: "version: 2\nconfig_version: 2\n"},
+		{name: "both disagree", in: "version: 2\nconfig_version: 1\n", wantErr: true},
+		{name: "empty", in: "", wantFrom: CurrentVersion},
+		{name: "newer", in: "version: 3\n", wantErr: true},
+		{name: "not a number", in: "version: two\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.in), &doc); err != nil {
+				t.Fatal(err)
+			}
+			from, notes, err := Migrate(&doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Migrate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if from != tt.wantFrom || strings.Join(notes, "|") != strings.Join(tt.wantNotes, "|") {
+				t.Errorf("Migrate = %d, %q, want %d, %q", from, notes, tt.wantFrom, tt.wantNotes)
+			}
+			if doc.Kind == 0 {
+				return
+			}
+			var out strings.Builder
+			enc := yaml.NewEncoder(&out)
+			enc.SetIndent(2)
+			if err := enc.Encode(&doc); err != nil {
+				t.Fatal(err)
+			}
+			want := cmp.Or(tt.want, tt.in)
+			if out.String() != want {
+				t.Errorf("migrated file:\n%s\nwant:\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestLoadFromMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "chains:\n  - event: Stop\n    hooks:\n      - name: a\n        command: a\n        workdir: /src\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+	if h := cfg.Chains[0].Hooks[0]; h.WorkingDir != "/src" || h.Workdir != "" {
+		t.Errorf("hook = %+v, want working_dir /src", h)
+	}
+	if len(cfg.Migrations) != 1 || !strings.HasPrefix(cfg.Migrations[0], path+": line 6:") {
+		t.Errorf("Migrations = %q, want one change in %s", cfg.Migrations, path)
+	}
+	if got, _ := os.ReadFile(path); string(got) != data {
+		t.Error("LoadFrom rewrote the file")
+	}
+
+	if err := os.WriteFile(path, []byte("version: 99\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFrom(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("LoadFrom of a newer version = %v, want an error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ProfileEnv selects the active profile, overriding default_profile.
+const ProfileEnv = "HOOK_CHAIN_PROFILE"
+
+// ProfileConfig is a named set of chains under `profiles:`.
+type ProfileConfig struct {
+	Chains []ChainEntry `yaml:"chains"`
+}
+
+// ActiveProfile returns the name of the active profile: $HOOK_CHAIN_PROFILE,
+// else default_profile, else "" for none.
+func (c Config) ActiveProfile() string {
+	return cmp.Or(os.Getenv(ProfileEnv), c.DefaultProfile)
+}
+
+// applyProfile puts the active profile's chains ahead of c.Chains, so on
+// equal specificity a profile chain wins. Naming a profile that is not
+// defined is an error.
+func (c Config) applyProfile() (Config, error) {
+	name := c.ActiveProfile()
+	if name == "" {
+		return c, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		defined := "none are defined"
+		if len(c.Profiles) > 0 {
+			defined = "defined: " + strings.Join(slices.Sorted(maps.Keys(c.Profiles)), ", ")
+		}
+		return Config{}, fmt.Errorf("config: profile %q is not defined (%s)", name, defined)
+	}
+	chains := slices.Clone(p.Chains)
+	for i := range chains {
+		chains[i].Profile = name
+	}
+	c.Chains = slices.Concat(chains, c.Chains)
+	return c, nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "user.yaml")
+	user := `default_profile: strict
+profiles:
+  strict:
+    chains:
+      - event: PreToolUse
+        tools: [Bash]
+        hooks: [{name: strict-guard, command: s}]
+  dev:
+    chains:
+      - event: PreToolUse
+        tools: [Bash]
+        hooks: [{name: dev-guard, command: d}]
+chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks: [{name: base, command: b}]
+`
+	if err := os.WriteFile(userPath, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectDir := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(projectDir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	projectPath := filepath.Join(projectDir, ProjectFile)
+	project := `profiles:
+  dev:
+    chains:
+      - event: PreToolUse
+        tools: [Bash]
+        hooks: [{name: project-dev, command: p}]
+`
+	if err := os.WriteFile(projectPath, []byte(project), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOOK_CHAIN_CONFIG", userPath)
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		cwd     string
+		want    []string // hook names of the chains, in order
+		profile string
+		source  string
+	}{
+		{"default profile", "", dir, []string{"strict-guard", "base"}, "strict", userPath},
+		{"env selects", "dev", dir, []string{"dev-guard", "base"}, "dev", userPath},
+		{"project adds to profile", "dev", projectDir, []string{"dev-guard", "project-dev", "base"}, "dev", userPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProfileEnv, tt.env)
+			cfg, err := LoadFor(tt.cwd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range cfg.Chains {
+				got = append(got, c.Hooks[0].Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("chains = %v, want %v", got, tt.want)
+			}
+			if c := cfg.Chains[0]; c.Profile != tt.profile || c.Source != tt.source {
+				t.Errorf("chain 1 profile %q from %s, want %q from %s", c.Profile, c.Source, tt.profile, tt.source)
+			}
+			if c := cfg.Chains[len(cfg.Chains)-1]; c.Profile != "" {
+				t.Errorf("top-level chain has profile %q", c.Profile)
+			}
+			if got := cfg.Resolve("PreToolUse", "Bash")[0].Name; got != tt.want[0] {
+				t.Errorf("Resolve = %s, want the profile chain", got)
+			}
+		})
+	}
+
+	t.Setenv(ProfileEnv, "nope")
+	if _, err := LoadFor(dir); err == nil || !strings.Contains(err.Error(), "defined: dev, strict") {
+		t.Errorf("unknown profile: err = %v", err)
+	}
+}
+
+func TestNoProfile(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	cfg := Config{Chains: []ChainEntry{{Event: "Stop"}}}
+	got, err := cfg.Effective()
+	if err != nil || len(got.Chains) != 1 {
+		t.Errorf("Effective without profiles = %d chains, %v", len(got.Chains), err)
+	}
+	t.Setenv(ProfileEnv, "dev")
+	if _, err := cfg.Effective(); err == nil || !strings.Contains(err.Error(), "none are defined") {
+		t.Errorf("profile without profiles: err = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QuotaConfig limits how often a hook or chain runs: at most Max times in
+// any window of Per, across every session on the machine.
+type QuotaConfig struct {
+	Max        int           `yaml:"max"`                   // runs allowed per window
+	Per        time.Duration `yaml:"per"`                   // the window, e.g. 1m or 1h
+	OnExceeded string        `yaml:"on_exceeded,omitempty"` // "deny" (default) | "ask" | "skip"
+}
+
+// Actions for QuotaConfig.OnExceeded.
+const (
+	QuotaDeny = "deny" // block the tool call
+	QuotaAsk  = "ask"  // ask the user
+	QuotaSkip = "skip" // do not run the hook (or chain) and go on as if it passed
+)
+
+// QuotaActions lists the valid on_exceeded values.
+var QuotaActions = []string{QuotaDeny, QuotaAsk, QuotaSkip}
+
+// EffectiveOnExceeded returns the on_exceeded action, defaulting to "deny".
+func (q QuotaConfig) EffectiveOnExceeded() string {
+	return cmp.Or(q.OnExceeded, QuotaDeny)
+}
+
+// Label describes the quota, e.g. "30 per 1h".
+func (q QuotaConfig) Label() string {
+	per := q.Per.String()
+	// Drop the zero units time.Duration prints: 1h0m0s is 1h.
+	if strings.HasSuffix(per, "m0s") {
+		per = per[:len(per)-2]
+	}
+	if strings.HasSuffix(per, "h0m") {
+		per = per[:len(per)-2]
+	}
+	return fmt.Sprintf("%d per %s", q.Max, per)
+}
+
+// Validate reports a quota without a positive max and window, or with an
+// unknown on_exceeded action.
+func (q QuotaConfig) Validate() error {
+	switch {
+	case q.Max <= 0:
+		return errors.New("max must be at least 1")
+	case q.Per <= 0:
+		return errors.New("per must be a positive duration such as 1m or 1h")
+	case q.OnExceeded != "" && !slices.Contains(QuotaActions, q.OnExceeded):
+		return fmt.Errorf("on_exceeded %q is not one of %s", q.OnExceeded, strings.Join(QuotaActions, ", "))
+	}
+	return nil
+}
+
+// QuotaKey identifies the hook's quota: hooks of the same name share one,
+// wherever they are configured.
+func (h HookEntry) QuotaKey() string {
+	return "hook:" + h.Name
+}
+
+// QuotaKey identifies the chain's quota by its file, events, and tools.
+func (c ChainEntry) QuotaKey() string {
+	return "chain:" + c.Source + ":" + c.EventLabel() + ":" + c.ToolLabel()
+}
+
+// ValidateQuotas reports invalid quotas on the chain and its hooks, and
+// quotas on finally hooks, which always run.
+func (c ChainEntry) ValidateQuotas() []error {
+	var errs []error
+	if c.Quota != nil {
+		if err := c.Quota.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("config: chain quota: %w", err))
+		}
+	}
+	for _, h := range c.Hooks {
+		if h.Quota == nil {
+			continue
+		}
+		if err := h.Quota.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("config: hook %q: quota: %w", h.Name, err))
+		}
+	}
+	for _, h := range c.Finally {
+		if h.Quota != nil {
+			errs = append(errs, fmt.Errorf("config: finally hook %q: quota is only supported on hooks", h.Name))
+		}
+	}
+	return errs
+}
+
+// HasQuotas reports whether the chain or any of its hooks has a quota.
+func (c ChainEntry) HasQuotas() bool {
+	return c.Quota != nil || slices.ContainsFunc(c.Hooks, func(h HookEntry) bool { return h.Quota != nil })
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateQuotas(t *testing.T) {
+	hourly := &QuotaConfig{Max: 30, Per: time.Hour}
+	tests := []struct {
+		name     string
+		chain    ChainEntry
+		wantErrs int
+	}{
+		{"none", ChainEntry{}, 0},
+		{"chain and hook", ChainEntry{Quota: hourly, Hooks: []HookEntry{{Name: "review", Quota: hourly}}}, 0},
+		{"action", ChainEntry{Quota: &QuotaConfig{Max: 1, Per: time.Minute, OnExceeded: "skip"}}, 0},
+		{"zero max", ChainEntry{Quota: &QuotaConfig{Per: time.Hour}}, 1},
+		{"no window", ChainEntry{Hooks: []HookEntry{{Name: "review", Quota: &QuotaConfig{Max: 1}}}}, 1},
+		{"unknown action", ChainEntry{Quota: &QuotaConfig{Max: 1, Per: time.Hour, OnExceeded: "wait"}}, 1},
+		{"finally hook", ChainEntry{Finally: []HookEntry{{Name: "notify", Quota: hourly}}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.chain.ValidateQuotas(); len(errs) != tt.wantErrs {
+				t.Errorf("ValidateQuotas() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+	if got := (QuotaConfig{}).EffectiveOnExceeded(); got != QuotaDeny {
+		t.Errorf("EffectiveOnExceeded() = %q, want deny by default", got)
+	}
+}
+
+func TestQuotaLabel(t *testing.T) {
+	tests := []struct {
+		per  time.Duration
+		want string
+	}{
+		{time.Hour, "5 per 1h"},
+		{10 * time.Minute, "5 per 10m"},
+		{90 * time.Minute, "5 per 1h30m"},
+		{30 * time.Second, "5 per 30s"},
+		{24 * time.Hour, "5 per 24h"},
+	}
+	for _, tt := range tests {
+		if got := (QuotaConfig{Max: 5, Per: tt.per}).Label(); got != tt.want {
+			t.Errorf("Label(%s) = %q, want %q", tt.per, got, tt.want)
+		}
+	}
+}
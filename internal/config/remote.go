@@ -0,0 +1,345 @@
+package config
+
+import (
+	"cmp"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RemoteConfig turns a config file into a bootstrap: the config is fetched
+// from URL over HTTPS and cached, and the file's other settings are
+// ignored. Every load checks the copy in use against SHA256 and PublicKey.
+type RemoteConfig struct {
+	URL       string        `yaml:"config_url,omitempty"`
+	SHA256    string        `yaml:"config_sha256,omitempty"`     // hex digest the config must have
+	PublicKey string        `yaml:"config_public_key,omitempty"` // base64 Ed25519 key signing <config_url>.sig
+	Refresh   time.Duration `yaml:"config_refresh,omitempty"`    // reuse a fetched copy this long (default: 5m)
+}
+
+// RemoteStatus describes the copy of a remote config that was loaded.
+type RemoteStatus struct {
+	URL     string
+	Path    string    // the cached copy
+	Fetched time.Time // when the server last confirmed it
+	Err     error     // the failed fetch, when a cached copy stood in
+}
+
+// ConfigKeyEnv holds the Ed25519 public key (base64) that signs a config
+// named by URL in $HOOK_CHAIN_CONFIG, which has no bootstrap file to hold
+// config_public_key.
+const ConfigKeyEnv = "HOOK_CHAIN_CONFIG_PUBLIC_KEY"
+
+// DefaultConfigRefresh is how long a fetched remote config is used before
+// the server is asked again.
+const DefaultConfigRefresh = 5 * time.Minute
+
+// remoteTimeout bounds one fetch, so an unreachable server delays a hook
+// run by at most this long before the cached copy is used.
+const remoteTimeout = 5 * time.Second
+
+// remoteClient fetches remote configs; tests swap it for a TLS test client.
+var remoteClient = &http.Client{Timeout: remoteTimeout}
+
+// IsRemote reports whether path is a URL rather than a file.
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// remoteMeta is the cache bookkeeping stored next to a cached config.
+// A failed refresh records Attempted and Error, so the cached copy is
+// served without retrying until the next refresh interval.
+type remoteMeta struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	Fetched   time.Time `json:"fetched"`
+	Attempted time.Time `json:"attempted,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Validate reports a URL that is not HTTPS, a malformed checksum or public
+// key, and a negative refresh interval.
+func (r RemoteConfig) Validate() error {
+	switch {
+	case !strings.HasPrefix(r.URL, "https://"):
+		return fmt.Errorf("config: config_url %q must be an https:// URL", r.URL)
+	case r.SHA256 != "" && !isSHA256(r.SHA256):
+		return fmt.Errorf("config: config_sha256 %q is not a hex SHA-256 digest", r.SHA256)
+	case r.Refresh < 0:
+		return fmt.Errorf("config: config_refresh %s is negative", r.Refresh)
+	}
+	if r.PublicKey != "" {
+		if _, err := r.publicKey(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publicKey decodes PublicKey: the raw 32-byte key or its DER form (as
+// `openssl pkey -pubout -outform DER` writes it), base64-encoded.
+func (r RemoteConfig) publicKey() (ed25519.PublicKey, error) {
+	errKey := errors.New("config: config_public_key is not a base64 Ed25519 public key")
+	key, err := decodeBase64(r.PublicKey)
+	if err != nil {
+		return nil, errKey
+	}
+	if len(key) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(key), nil
+	}
+	if pub, err := x509.ParsePKIXPublicKey(key); err == nil {
+		if k, ok := pub.(ed25519.PublicKey); ok {
+			return k, nil
+		}
+	}
+	return nil, errKey
+}
+
+// loadRemote fetches the remote config (see fetchRemote) and loads it. A
+// remote config cannot point at another one.
+func loadRemote(r RemoteConfig, cachedOnly bool) (Config, error) {
+	status, err := fetchRemote(r, cachedOnly)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := LoadFrom(status.Path)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.Remote.URL != "" {
+		return Config{}, fmt.Errorf("config: remote config %s sets config_url; it must be the config itself", r.URL)
+	}
+	cfg.RemoteStatus = &status
+	return cfg, nil
+}
+
+// fetchRemote returns the cached copy of the remote config, refreshed from
+// the server once Refresh has passed. The refresh is conditional on the
+// ETag of the cached copy. When the server cannot be reached, or serves a
+// config that fails verification, the cached copy is used and the failure
+// reported in RemoteStatus.Err; without a cached copy it is an error. With
+// cachedOnly the cached copy is used as it is, without asking the server
+// or touching the cache.
+func fetchRemote(r RemoteConfig, cachedOnly bool) (RemoteStatus, error) {
+	if err := r.Validate(); err != nil {
+		return RemoteStatus{}, err
+	}
+	dir := remoteCacheDir()
+	sum := sha256.Sum256([]byte(r.URL))
+	base := filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	status := RemoteStatus{URL: r.URL, Path: base + ".yaml"}
+
+	var meta remoteMeta
+	cached := false
+	if data, err := os.ReadFile(base + ".json"); err == nil && json.Unmarshal(data, &meta) == nil && meta.URL == r.URL {
+		_, err := os.Stat(status.Path)
+		cached = err == nil
+	}
+	if cachedOnly {
+		if !cached {
+			return RemoteStatus{}, fmt.Errorf("config: %s has no cached copy, and it is not fetched in read-only mode", r.URL)
+		}
+		status.Fetched = meta.Fetched
+		if meta.Error != "" {
+			status.Err = errors.New(meta.Error)
+		}
+		return status, r.verifyCached(base)
+	}
+	// A fresh copy that no longer verifies was cached under another
+	// config_sha256 or key; ask the server for the current one.
+	lastTry := meta.Fetched
+	if meta.Attempted.After(lastTry) {
+		lastTry = meta.Attempted
+	}
+	if cached && time.Since(lastTry) < cmp.Or(r.Refresh, DefaultConfigRefresh) && r.verifyCached(base) == nil {
+		status.Fetched = meta.Fetched
+		if meta.Error != "" {
+			status.Err = errors.New(meta.Error)
+		}
+		return status, nil
+	}
+
+	etag := ""
+	if cached {
+		etag = meta.ETag
+	}
+	fetchErr := r.refresh(base, etag)
+	if fetchErr == nil {
+		if data, err := os.ReadFile(base + ".json"); err == nil && json.Unmarshal(data, &meta) == nil {
+			status.Fetched = meta.Fetched
+		}
+		return status, r.verifyCached(base)
+	}
+	if !cached {
+		return RemoteStatus{}, fmt.Errorf("config: fetch %s (no cached copy): %w", r.URL, fetchErr)
+	}
+	// Back off until the next refresh interval rather than making every
+	// load wait on a server that is down.
+	meta.Attempted, meta.Error = time.Now().UTC(), fetchErr.Error()
+	if err := writeMeta(base, meta); err != nil {
+		fetchErr = errors.Join(fetchErr, err)
+	}
+	status.Fetched, status.Err = meta.Fetched, fetchErr
+	return status, r.verifyCached(base)
+}
+
+// refresh asks the server for the config, with If-None-Match when etag is
+// set, and replaces the cache files under base with a new copy that passes
+// verification. A 304 only renews the fetch time.
+func (r RemoteConfig) refresh(base, etag string) error {
+	body, newTag, modified, err := httpGet(r.URL, etag)
+	if err != nil {
+		return err
+	}
+	meta := remoteMeta{URL: r.URL, ETag: etag, Fetched: time.Now().UTC()}
+	if modified {
+		var sig []byte
+		if r.PublicKey != "" {
+			if sig, _, _, err = httpGet(r.URL+".sig", ""); err != nil {
+				return fmt.Errorf("signature: %w", err)
+			}
+		}
+		if err := r.verify(body, sig); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			return fmt.Errorf("create cache dir: %w", err)
+		}
+		if err := writeAtomic(base+".sig", sig); err != nil {
+			return err
+		}
+		if err := writeAtomic(base+".yaml", body); err != nil {
+			return err
+		}
+		meta.ETag = newTag
+	}
+	return writeMeta(base, meta)
+}
+
+// writeMeta replaces the cache metadata under base.
+func writeMeta(base string, meta remoteMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encode cache metadata: %w", err)
+	}
+	return writeAtomic(base+".json", data)
+}
+
+// verifyCached checks the cached copy under base, so a changed
+// config_sha256 or config_public_key applies to it too.
+func (r RemoteConfig) verifyCached(base string) error {
+	body, err := os.ReadFile(base + ".yaml")
+	if err != nil {
+		return fmt.Errorf("config: read cached %s: %w", r.URL, err)
+	}
+	sig, _ := os.ReadFile(base + ".sig")
+	if err := r.verify(body, sig); err != nil {
+		return fmt.Errorf("config: cached %s: %w", r.URL, err)
+	}
+	return nil
+}
+
+// verify checks body against the pinned digest and, when a public key is
+// set, sig: the base64 Ed25519 signature of body's SHA-256 digest.
+func (r RemoteConfig) verify(body, sig []byte) error {
+	digest := sha256.Sum256(body)
+	if r.SHA256 != "" && !strings.EqualFold(hex.EncodeToString(digest[:]), r.SHA256) {
+		return fmt.Errorf("checksum %x does not match config_sha256 %s", digest, r.SHA256)
+	}
+	if r.PublicKey == "" {
+		return nil
+	}
+	key, err := r.publicKey()
+	if err != nil {
+		return err
+	}
+	raw, err := decodeBase64(string(sig))
+	if err != nil || !ed25519.Verify(key, digest[:], raw) {
+		return errors.New("signature does not verify against config_public_key")
+	}
+	return nil
+}
+
+// httpGet fetches url. With etag set, modified is false when the server
+// answers 304 Not Modified.
+func httpGet(url, etag string) (body []byte, newTag string, modified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := remoteClient.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, etag, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", false, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("GET %s: %w", url, err)
+	}
+	if len(body) > maxRemoteSize {
+		return nil, "", false, fmt.Errorf("GET %s: larger than %d bytes", url, maxRemoteSize)
+	}
+	return body, resp.Header.Get("ETag"), true, nil
+}
+
+// maxRemoteSize caps a fetched config or signature.
+const maxRemoteSize = 4 << 20
+
+// writeAtomic replaces path with data through a temp file and rename.
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// remoteCacheDir is where fetched configs are kept:
+// $XDG_CACHE_HOME/hook-chain/remote (default ~/.cache/hook-chain/remote).
+func remoteCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "hook-chain", "remote")
+}
+
+// decodeBase64 decodes s, ignoring whitespace such as the line breaks the
+// base64 tool inserts.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+// isSHA256 reports whether s is a hex SHA-256 digest.
+func isSHA256(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size
+}
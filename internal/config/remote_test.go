@@ -0,0 +1,297 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const remoteYAML = `chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks:
+      - name: central-policy
+        command: policy
+`
+
+// policyServer serves body (and sig at .sig) with an ETag, counting full,
+// not-modified, and failed responses. Setting down makes it answer 503.
+type policyServer struct {
+	*httptest.Server
+	body, sig           atomic.Value
+	down                atomic.Bool
+	full, saved, failed atomic.Int32
+}
+
+func newPolicyServer(t *testing.T, body string) *policyServer {
+	t.Helper()
+	ps := &policyServer{}
+	ps.body.Store(body)
+	ps.sig.Store("")
+	ps.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ps.down.Load() {
+			ps.failed.Add(1)
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			_, _ = w.Write([]byte(ps.sig.Load().(string)))
+			return
+		}
+		body := ps.body.Load().(string)
+		sum := sha256.Sum256([]byte(body))
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			ps.saved.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		ps.full.Add(1)
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ps.Close)
+
+	client := remoteClient
+	remoteClient = ps.Client()
+	t.Cleanup(func() { remoteClient = client })
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOOK_CHAIN_PROJECT_CONFIG", "0")
+	return ps
+}
+
+func digest(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoadRemoteEnvURL(t *testing.T) {
+	ps := newPolicyServer(t, remoteYAML)
+	t.Setenv(ConfigEnv, ps.URL+"/policy.yaml")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Chains) != 1 || cfg.Chains[0].Hooks[0].Name != "central-policy" {
+		t.Fatalf("chains = %+v, want the remote policy", cfg.Chains)
+	}
+	if rs := cfg.RemoteStatus; rs == nil || rs.Err != nil || rs.Fetched.IsZero() {
+		t.Errorf("RemoteStatus = %+v, want a fresh fetch", rs)
+	}
+
+	// Within the refresh interval the cached copy is used without asking.
+	if _, err := Load(); err != nil {
+		t.Fatalf("second Load: %v", err)
+	}
+	if n := ps.full.Load() + ps.saved.Load(); n != 1 {
+		t.Errorf("%d requests, want 1 (cached)", n)
+	}
+}
+
+func TestLoadRemoteBootstrap(t *testing.T) {
+	ps := newPolicyServer(t, remoteYAML)
+	bootstrap := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(extra string) {
+		t.Helper()
+		data := "config_url: " + ps.URL + "/policy.yaml\nconfig_refresh: 1ns\n" + extra
+		if err := os.WriteFile(bootstrap, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config_sha256: " + digest(remoteYAML) + "\n")
+	t.Setenv(ConfigEnv, bootstrap)
+
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// Past the refresh interval, the refetch is conditional.
+	if _, err := Load(); err != nil {
+		t.Fatalf("refresh Load: %v", err)
+	}
+	if full, saved := ps.full.Load(), ps.saved.Load(); full != 1 || saved != 1 {
+		t.Errorf("full = %d, not modified = %d; want 1 and 1", full, saved)
+	}
+
+	// Offline: the cached copy stands in and the failure is reported.
+	ps.down.Store(true)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("offline Load: %v", err)
+	}
+	if rs := cfg.RemoteStatus; rs == nil || rs.Err == nil || len(cfg.Chains) != 1 {
+		t.Errorf("offline: RemoteStatus = %+v, chains = %d; want the cached copy and the error", rs, len(cfg.Chains))
+	}
+
+	// A tampered config fails the pin; the verified cached copy is kept.
+	ps.down.Store(false)
+	ps.body.Store(strings.Replace(remoteYAML, "central-policy", "evil", 1))
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("tampered Load: %v", err)
+	}
+	if rs := cfg.RemoteStatus; rs == nil || rs.Err == nil || !strings.Contains(rs.Err.Error(), "config_sha256") {
+		t.Errorf("tampered: RemoteStatus = %+v, want a checksum error", rs)
+	}
+	if cfg.Chains[0].Hooks[0].Name != "central-policy" {
+		t.Errorf("tampered config was loaded: %+v", cfg.Chains[0].Hooks)
+	}
+
+	// A pin the cached copy does not match is a config error when the
+	// server cannot supply a matching one.
+	write("config_sha256: " + digest("something else") + "\n")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an unmatched pin succeeded, want an error")
+	}
+}
+
+func TestLoadRemoteBackoff(t *testing.T) {
+	ps := newPolicyServer(t, remoteYAML)
+	t.Setenv(ConfigEnv, ps.URL+"/policy.yaml")
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Age the cached copy past the refresh interval, then take the server down.
+	metas, err := filepath.Glob(filepath.Join(remoteCacheDir(), "*.json"))
+	if err != nil || len(metas) != 1 {
+		t.Fatalf("cache metadata = %v (%v), want one file", metas, err)
+	}
+	data, err := os.ReadFile(metas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta remoteMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	meta.Fetched = meta.Fetched.Add(-time.Hour)
+	if err := writeMeta(strings.TrimSuffix(metas[0], ".json"), meta); err != nil {
+		t.Fatal(err)
+	}
+	ps.down.Store(true)
+
+	// The first load tries the server; later ones serve the cached copy
+	// until the next refresh interval, still reporting the failure.
+	for i := range 3 {
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("offline Load %d: %v", i, err)
+		}
+		if rs := cfg.RemoteStatus; rs == nil || rs.Err == nil {
+			t.Errorf("offline Load %d: RemoteStatus = %+v, want the fetch error", i, rs)
+		}
+	}
+	if n := ps.failed.Load(); n != 1 {
+		t.Errorf("%d failed requests, want 1 (backed off)", n)
+	}
+}
+
+func TestLoadCachedRemote(t *testing.T) {
+	ps := newPolicyServer(t, remoteYAML)
+	t.Setenv(ConfigEnv, ps.URL+"/policy.yaml")
+	if _, err := LoadCached(); err == nil || !strings.Contains(err.Error(), "no cached copy") {
+		t.Errorf("LoadCached without a cache = %v, want an error", err)
+	}
+	if _, err := os.Stat(remoteCacheDir()); !os.IsNotExist(err) {
+		t.Errorf("LoadCached created the cache: %v", err)
+	}
+
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg, err := LoadCached()
+	if err != nil || len(cfg.Chains) != 1 {
+		t.Fatalf("LoadCached = %d chains, %v; want the cached copy", len(cfg.Chains), err)
+	}
+	if n := ps.full.Load() + ps.saved.Load() + ps.failed.Load(); n != 1 {
+		t.Errorf("%d requests, want 1 (LoadCached never fetches)", n)
+	}
+}
+
+func TestLoadRemoteSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := newPolicyServer(t, remoteYAML)
+	sum := sha256.Sum256([]byte(remoteYAML))
+	ps.sig.Store(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sum[:])) + "\n")
+	t.Setenv(ConfigEnv, ps.URL+"/policy.yaml")
+
+	// The DER form, as openssl writes it.
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigKeyEnv, base64.StdEncoding.EncodeToString(der))
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load with a valid signature: %v", err)
+	}
+
+	// Another key: the cache no longer verifies and neither does the server.
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigKeyEnv, base64.StdEncoding.EncodeToString(other))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Load with the wrong key = %v, want a signature error", err)
+	}
+}
+
+func TestLoadRemoteErrors(t *testing.T) {
+	ps := newPolicyServer(t, "config_url: https://elsewhere.example/policy.yaml\n")
+	tests := []struct {
+		name string
+		env  string
+		down bool
+		want string
+	}{
+		{name: "plain http", env: "http://policy.example/config.yaml", want: "https://"},
+		{name: "chained remote", env: ps.URL + "/policy.yaml", want: "sets config_url"},
+		{name: "server down, no cache", env: ps.URL + "/other.yaml", down: true, want: "no cached copy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ConfigEnv, tt.env)
+			ps.down.Store(tt.down)
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  RemoteConfig
+		wantErr bool
+	}{
+		{"url only", RemoteConfig{URL: "https://policy.example/c.yaml"}, false},
+		{"not https", RemoteConfig{URL: "ftp://policy.example/c.yaml"}, true},
+		{"bad digest", RemoteConfig{URL: "https://p.example/c", SHA256: "abc"}, true},
+		{"bad key", RemoteConfig{URL: "https://p.example/c", PublicKey: "bm90IGEga2V5"}, true},
+		{"negative refresh", RemoteConfig{URL: "https://p.example/c", Refresh: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.remote.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RiskConfig maps the risk scores hooks report (riskScore in their output)
+// to a decision. The scores of a chain's hooks are combined with Aggregate;
+// a combined score at or above Deny denies, at or above Ask asks, and below
+// both allows. It only applies to calls the hooks themselves allowed.
+type RiskConfig struct {
+	Aggregate string   `yaml:"aggregate,omitempty"` // "max" (default) | "avg" | "sum"
+	Ask       *float64 `yaml:"ask,omitempty"`       // lowest score that asks
+	Deny      *float64 `yaml:"deny,omitempty"`      // lowest score that denies
+}
+
+// Ways of combining the risk scores of a chain's hooks.
+const (
+	RiskMax = "max"
+	RiskAvg = "avg"
+	RiskSum = "sum"
+)
+
+// RiskAggregates lists the valid aggregate values.
+var RiskAggregates = []string{RiskMax, RiskAvg, RiskSum}
+
+// EffectiveAggregate returns the aggregate, defaulting to "max". A nil
+// config aggregates with "max" too, so scores are recorded for tuning
+// before any thresholds are set.
+func (r *RiskConfig) EffectiveAggregate() string {
+	if r == nil {
+		return RiskMax
+	}
+	return cmp.Or(r.Aggregate, RiskMax)
+}
+
+// Combine aggregates scores. It reports false when there are none.
+func (r *RiskConfig) Combine(scores []float64) (float64, bool) {
+	if len(scores) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, s := range scores {
+		sum += s
+	}
+	switch r.EffectiveAggregate() {
+	case RiskAvg:
+		return sum / float64(len(scores)), true
+	case RiskSum:
+		return sum, true
+	}
+	return slices.Max(scores), true
+}
+
+// Decision returns "deny", "ask", or "allow" for a combined score, and the
+// threshold it reached ("" for allow).
+func (r *RiskConfig) Decision(score float64) (decision, threshold string) {
+	switch {
+	case r == nil:
+	case r.Deny != nil && score >= *r.Deny:
+		return "deny", formatScore(*r.Deny)
+	case r.Ask != nil && score >= *r.Ask:
+		return "ask", formatScore(*r.Ask)
+	}
+	return "allow", ""
+}
+
+// Label describes the thresholds, e.g. "max: ask ≥ 0.5, deny ≥ 0.8".
+func (r *RiskConfig) Label() string {
+	var parts []string
+	if r.Ask != nil {
+		parts = append(parts, "ask ≥ "+formatScore(*r.Ask))
+	}
+	if r.Deny != nil {
+		parts = append(parts, "deny ≥ "+formatScore(*r.Deny))
+	}
+	return r.EffectiveAggregate() + ": " + strings.Join(parts, ", ")
+}
+
+// Validate reports an unknown aggregate, a risk block without thresholds,
+// and an ask threshold that is not below the deny threshold.
+func (r *RiskConfig) Validate() error {
+	switch {
+	case r.Aggregate != "" && !slices.Contains(RiskAggregates, r.Aggregate):
+		return fmt.Errorf("aggregate %q is not one of %s", r.Aggregate, strings.Join(RiskAggregates, ", "))
+	case r.Ask == nil && r.Deny == nil:
+		return errors.New("set an ask or deny threshold")
+	case r.Ask != nil && r.Deny != nil && *r.Ask >= *r.Deny:
+		return fmt.Errorf("ask threshold %s must be below deny threshold %s", formatScore(*r.Ask), formatScore(*r.Deny))
+	}
+	return nil
+}
+
+// ValidateRisk reports an invalid risk block on the chain.
+func (c ChainEntry) ValidateRisk() []error {
+	if c.Risk == nil {
+		return nil
+	}
+	if err := c.Risk.Validate(); err != nil {
+		return []error{fmt.Errorf("config: risk: %w", err)}
+	}
+	return nil
+}
+
+// formatScore prints a score without trailing zeros.
+func formatScore(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
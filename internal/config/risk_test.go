@@ -0,0 +1,79 @@
+package config
+
+import "testing"
+
+func score(f float64) *float64 { return &f }
+
+func TestRiskCombine(t *testing.T) {
+	scores := []float64{0.2, 0.8, 0.5}
+	tests := []struct {
+		aggregate string
+		want      float64
+	}{
+		{"", 0.8},
+		{RiskMax, 0.8},
+		{RiskAvg, 0.5},
+		{RiskSum, 1.5},
+	}
+	for _, tt := range tests {
+		r := &RiskConfig{Aggregate: tt.aggregate}
+		if got, ok := r.Combine(scores); !ok || got != tt.want {
+			t.Errorf("Combine(%q) = %v, %v; want %v", tt.aggregate, got, ok, tt.want)
+		}
+	}
+	var none *RiskConfig
+	if got, ok := none.Combine(scores); !ok || got != 0.8 {
+		t.Errorf("nil Combine = %v, %v; want max", got, ok)
+	}
+	if _, ok := none.Combine(nil); ok {
+		t.Error("Combine(nil) reported a score")
+	}
+}
+
+func TestRiskDecision(t *testing.T) {
+	r := &RiskConfig{Ask: score(0.5), Deny: score(0.8)}
+	tests := []struct {
+		score         float64
+		want, reached string
+	}{
+		{0.1, "allow", ""},
+		{0.5, "ask", "0.5"},
+		{0.79, "ask", "0.5"},
+		{0.8, "deny", "0.8"},
+		{3, "deny", "0.8"},
+	}
+	for _, tt := range tests {
+		if got, threshold := r.Decision(tt.score); got != tt.want || threshold != tt.reached {
+			t.Errorf("Decision(%v) = %s, %q; want %s, %q", tt.score, got, threshold, tt.want, tt.reached)
+		}
+	}
+	var none *RiskConfig
+	if got, _ := none.Decision(100); got != "allow" {
+		t.Errorf("nil Decision = %s, want allow", got)
+	}
+	if got := r.Label(); got != "max: ask ≥ 0.5, deny ≥ 0.8" {
+		t.Errorf("Label() = %q", got)
+	}
+}
+
+func TestValidateRisk(t *testing.T) {
+	tests := []struct {
+		name     string
+		risk     *RiskConfig
+		wantErrs int
+	}{
+		{"none", nil, 0},
+		{"ask only", &RiskConfig{Ask: score(0.5)}, 0},
+		{"both", &RiskConfig{Aggregate: RiskSum, Ask: score(1), Deny: score(2)}, 0},
+		{"no thresholds", &RiskConfig{Aggregate: RiskAvg}, 1},
+		{"unknown aggregate", &RiskConfig{Aggregate: "median", Deny: score(1)}, 1},
+		{"ask above deny", &RiskConfig{Ask: score(0.9), Deny: score(0.8)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := (ChainEntry{Risk: tt.risk}).ValidateRisk(); len(errs) != tt.wantErrs {
+				t.Errorf("ValidateRisk() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
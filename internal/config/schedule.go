@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// weekdays are the active_days names, in time.Weekday order. Full names
+// ("monday") are accepted too.
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// HasSchedule reports whether the chain only applies during a time window
+// (active_hours or active_days).
+func (c ChainEntry) HasSchedule() bool {
+	return c.ActiveHours != "" || len(c.ActiveDays) > 0
+}
+
+// ActiveAt reports whether the chain's time window includes now, in now's
+// location. A chain without active_hours or active_days is always active.
+// An overnight window ("22:00-06:00") belongs to the day it starts on, so
+// with active_days [fri] it covers Saturday 01:00. An invalid window is
+// never active; validate reports it.
+func (c ChainEntry) ActiveAt(now time.Time) bool {
+	day := now.Weekday()
+	if c.ActiveHours != "" {
+		start, end, err := parseActiveHours(c.ActiveHours)
+		if err != nil {
+			return false
+		}
+		clock := now.Hour()*60 + now.Minute()
+		switch {
+		case start < end:
+			if clock < start || clock >= end {
+				return false
+			}
+		case clock >= start:
+		case clock < end:
+			day = (day + 6) % 7 // the window started the day before
+		default:
+			return false
+		}
+	}
+	if len(c.ActiveDays) > 0 {
+		days, err := parseActiveDays(c.ActiveDays)
+		if err != nil || !days[day] {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateSchedule reports an active_hours that is not "HH:MM-HH:MM" and
+// active_days entries that name no weekday.
+func (c ChainEntry) ValidateSchedule() []error {
+	var errs []error
+	if c.ActiveHours != "" {
+		if _, _, err := parseActiveHours(c.ActiveHours); err != nil {
+			errs = append(errs, fmt.Errorf("config: active_hours %q: %w", c.ActiveHours, err))
+		}
+	}
+	if _, err := parseActiveDays(c.ActiveDays); err != nil {
+		errs = append(errs, fmt.Errorf("config: active_days: %w", err))
+	}
+	return errs
+}
+
+// parseActiveHours parses "HH:MM-HH:MM" into minutes since midnight. The
+// end may be 24:00; an end before the start wraps past midnight.
+func parseActiveHours(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, errors.New(`not "HH:MM-HH:MM"`)
+	}
+	if start, err = parseClock(strings.TrimSpace(from)); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseClock(strings.TrimSpace(to)); err != nil {
+		return 0, 0, err
+	}
+	if start == 24*60 {
+		return 0, 0, errors.New("start 24:00 is not a time of day")
+	}
+	if start == end {
+		return 0, 0, errors.New("start and end are equal")
+	}
+	return start, end, nil
+}
+
+// parseClock parses "HH:MM" (00:00 to 24:00) into minutes since midnight.
+func parseClock(s string) (int, error) {
+	if len(s) != 5 || s[2] != ':' || !isDigits(s[:2]) || !isDigits(s[3:]) {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	h := int(s[0]-'0')*10 + int(s[1]-'0')
+	m := int(s[3]-'0')*10 + int(s[4]-'0')
+	if h > 24 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return h*60 + m, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only.
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// parseActiveDays returns the weekdays named by days: names like "mon" or
+// "monday", or ranges like "mon-fri" (which may wrap, as "fri-mon").
+func parseActiveDays(days []string) ([7]bool, error) {
+	var set [7]bool
+	for _, d := range days {
+		from, to, isRange := strings.Cut(d, "-")
+		first, ok := weekday(from)
+		last := first
+		if isRange && ok {
+			last, ok = weekday(to)
+		}
+		if !ok {
+			return set, fmt.Errorf("%q is not a weekday or a range like mon-fri", d)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			set[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return set, nil
+}
+
+// weekday looks up a day name, abbreviated or in full, in any case.
+func weekday(name string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, short := range weekdays {
+		if name == short || name == strings.ToLower(time.Weekday(i).String()) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ScheduleLabel describes the chain's window, e.g. "09:00-17:00 mon-fri".
+func (c ChainEntry) ScheduleLabel() string {
+	parts := slices.DeleteFunc([]string{c.ActiveHours, strings.Join(c.ActiveDays, ",")}, func(s string) bool { return s == "" })
+	return strings.Join(parts, " ")
+}
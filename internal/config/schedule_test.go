@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveAt(t *testing.T) {
+	// 2025-06-13 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		hours string
+		days  []string
+		now   time.Time
+		want  bool
+	}{
+		{"no schedule", "", nil, at(13, 3, 0), true},
+		{"inside hours", "09:00-17:00", nil, at(13, 9, 0), true},
+		{"end is exclusive", "09:00-17:00", nil, at(13, 17, 0), false},
+		{"before hours", "09:00-17:00", nil, at(13, 8, 59), false},
+		{"until midnight", "18:00-24:00", nil, at(13, 23, 59), true},
+		{"overnight late", "22:00-06:00", nil, at(13, 23, 0), true},
+		{"overnight early", "22:00-06:00", nil, at(14, 5, 59), true},
+		{"overnight outside", "22:00-06:00", nil, at(13, 12, 0), false},
+		{"weekday listed", "", []string{"fri"}, at(13, 12, 0), true},
+		{"weekday not listed", "", []string{"mon", "tue"}, at(13, 12, 0), false},
+		{"full name any case", "", []string{"Friday"}, at(13, 12, 0), true},
+		{"range", "", []string{"mon-fri"}, at(13, 12, 0), true},
+		{"range excludes weekend", "", []string{"mon-fri"}, at(14, 12, 0), false},
+		{"wrapping range", "", []string{"fri-mon"}, at(15, 12, 0), true},
+		{"hours and days", "09:00-17:00", []string{"mon-fri"}, at(13, 10, 0), true},
+		{"overnight counts for its start day", "22:00-06:00", []string{"fri"}, at(14, 1, 0), true},
+		{"overnight after excluded day", "22:00-06:00", []string{"fri"}, at(13, 1, 0), false},
+		{"invalid hours never active", "9-17", nil, at(13, 12, 0), false},
+		{"invalid day never active", "", []string{"someday"}, at(13, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ChainEntry{ActiveHours: tt.hours, ActiveDays: tt.days}
+			if got := c.ActiveAt(tt.now); got != tt.want {
+				t.Errorf("ActiveAt(%s) = %v, want %v", tt.now.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		hours   string
+		days    []string
+		wantErr int
+	}{
+		{"none", "", nil, 0},
+		{"valid", "08:30-18:00", []string{"mon-fri", "sun"}, 0},
+		{"overnight", "22:00-06:00", nil, 0},
+		{"no dash", "09:00", nil, 1},
+		{"single digit hour", "9:00-17:00", nil, 1},
+		{"out of range", "09:00-25:00", nil, 1},
+		{"start 24:00", "24:00-06:00", nil, 1},
+		{"empty window", "09:00-09:00", nil, 1},
+		{"unknown day", "", []string{"mon", "funday"}, 1},
+		{"both wrong", "x", []string{"mon-x"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ChainEntry{ActiveHours: tt.hours, ActiveDays: tt.days}.ValidateSchedule()
+			if len(errs) != tt.wantErr {
+				t.Errorf("ValidateSchedule() = %v, want %d errors", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveSkipsInactiveChain(t *testing.T) {
+	today := time.Now().Weekday()
+	cfg := Config{Chains: []ChainEntry{
+		{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []HookEntry{{Name: "always"}}},
+		{
+			Event: "PreToolUse", Tools: []string{"Bash"}, Priority: 10,
+			ActiveDays: []string{weekdays[(today+1)%7]},
+			Hooks:      []HookEntry{{Name: "freeze"}},
+		},
+	}}
+	if hooks := cfg.Resolve("PreToolUse", "Bash"); len(hooks) != 1 || hooks[0].Name != "always" {
+		t.Errorf("Resolve = %+v, want the chain without a window while the freeze is inactive", hooks)
+	}
+
+	cfg.Chains[1].ActiveDays = []string{weekdays[today]}
+	if hooks := cfg.Resolve("PreToolUse", "Bash"); len(hooks) != 1 || hooks[0].Name != "freeze" {
+		t.Errorf("Resolve = %+v, want the active higher-priority chain", hooks)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a config problem found by Strict, at a line of the file.
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// OnErrorPolicies are the valid on_error values.
+var OnErrorPolicies = []string{"deny", "skip", "allow", "retry"}
+
+// typeErrorLine matches one yaml.v3 unmarshal error.
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// Strict checks the config file at path more strictly than LoadFrom, which
+// ignores keys it does not know: it reports unknown keys (typos such as
+// on_erorr), values of the wrong type (such as a timeout that is not a
+// duration), on_error values other than OnErrorPolicies (which act as
+// deny), and process or shell hooks without a command, each with its line.
+// The error is for a file that cannot be read or is not YAML at all.
+func Strict(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	var problems []Problem
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		var te *yaml.TypeError
+		if !errors.As(err, &te) {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("config: parse %s: %w", path, err)
+		}
+		for _, e := range te.Errors {
+			p := Problem{Message: e}
+			if m := typeErrorLine.FindStringSubmatch(e); m != nil {
+				p.Line, _ = strconv.Atoi(m[1])
+				p.Message = m[2]
+			}
+			problems = append(problems, p)
+		}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	chains := seq(mapValue(docRoot(&root), "chains"))
+	if profiles := mapValue(docRoot(&root), "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 1; i < len(profiles.Content); i += 2 {
+			chains = slices.Concat(chains, seq(mapValue(profiles.Content[i], "chains")))
+		}
+	}
+	for _, chain := range chains {
+		for _, key := range []string{"hooks", "finally"} {
+			for _, h := range seq(mapValue(chain, key)) {
+				problems = append(problems, checkHook(h, "")...)
+			}
+		}
+	}
+	if defs := mapValue(docRoot(&root), "hook_defs"); defs != nil && defs.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(defs.Content); i += 2 {
+			problems = append(problems, checkHook(defs.Content[i+1], defs.Content[i].Value)...)
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b Problem) int { return a.Line - b.Line })
+	return problems, nil
+}
+
+// checkHook checks one hook mapping; a hook definition's name defaults to
+// its key in hook_defs.
+func checkHook(n *yaml.Node, def string) []Problem {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	var problems []Problem
+	var h HookEntry
+	_ = n.Decode(&h) // type errors are reported by the strict decode
+	if h.Name == "" {
+		h.Name = def
+	}
+
+	if v := mapValue(n, "on_error"); v != nil && !slices.Contains(OnErrorPolicies, v.Value) {
+		problems = append(problems, Problem{v.Line, fmt.Sprintf("hook %q: on_error %q is not one of %s (it acts as deny)", h.Name, v.Value, strings.Join(OnErrorPolicies, ", "))})
+	}
+	if typ := h.EffectiveType(); typ == HookTypeProcess || typ == HookTypeShell {
+		if strings.TrimSpace(h.Command) == "" && len(h.Variants) == 0 && h.Use == "" {
+			problems = append(problems, Problem{n.Line, fmt.Sprintf("hook %q has no command", h.Name)})
+		}
+	}
+	return problems
+}
+
+// docRoot returns the top-level node of a document node.
+func docRoot(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		return n.Content[0]
+	}
+	return n
+}
+
+// mapValue returns the value of key in mapping n, or nil.
+func mapValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// seq returns the items of sequence n, or nil.
+func seq(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	yaml := `chains:
+  - event: PreToolUse
+    tools: [Bash]
+    hooks:
+      - name: typo
+        command: guard
+        on_erorr: skip
+      - name: policy
+        command: guard
+        on_error: skp
+      - name: empty
+        timeout: 5x
+      - name: remote
+        type: http
+        url: http://127.0.0.1/hook
+      - name: builtin
+        builtin: write-guard
+    finally:
+      - name: notify
+        command: "  "
+    latency_budgte: 3s
+hook_defs:
+  scan:
+    on_error: nope
+profiles:
+  dev:
+    chains:
+      - event: Stop
+        hooks: [{name: p, command: a, on_error: x}]
+        on_deny: maybe
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Strict(path)
+	if err != nil {
+		t.Fatalf("Strict: %v", err)
+	}
+	want := []Problem{
+		{7, "field on_erorr not found in type config.HookEntry"},
+		{10, `hook "policy": on_error "skp" is not one of deny, skip, allow, retry (it acts as deny)`},
+		{11, `hook "empty" has no command`},
+		{12, "cannot unmarshal !!str `5x` into time.Duration"},
+		{19, `hook "notify" has no command`},
+		{21, "field latency_budgte not found in type config.ChainEntry"},
+		{24, `hook "scan": on_error "nope" is not one of deny, skip, allow, retry (it acts as deny)`},
+		{24, `hook "scan" has no command`},
+		{29, `hook "p": on_error "x" is not one of deny, skip, allow, retry (it acts as deny)`},
+		{30, `on_deny must be "ask" or a list of actions, not "maybe"`},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("problem %d = %v, want %v", i, problems[i], want[i])
+		}
+	}
+}
+
+func TestStrictClean(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"empty.yaml": "",
+		"clean.yaml": "chains:\n  - event: Stop\n    hooks: [{name: a, command: a, on_error: skip, timeout: 5s}]\n",
+		"uses.yaml":  "hook_defs:\n  a: {command: a}\nchains:\n  - event: Stop\n    hooks: [{use: a}]\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if problems, err := Strict(path); err != nil || len(problems) > 0 {
+			t.Errorf("%s: %v, %v; want no problems", name, problems, err)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("chains: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Strict(bad); err == nil {
+		t.Error("malformed YAML: want error")
+	}
+}
@@ -0,0 +1,192 @@
+// Package conform replays a golden corpus of hook payloads through a
+// hook-chain binary and compares its stdout and exit code byte for byte.
+// It backs `hook-chain conform` and the Conformance test, so protocol
+// regressions across refactors are caught mechanically.
+//
+// Each case is a directory holding:
+//
+//	config.yaml  chains to run (hooks are builtins or `sh -c` one-liners)
+//	input.json   stdin exactly as the agent sends it
+//	stdout       expected stdout, byte for byte (may be empty)
+//	exit_code    expected exit code (default 0)
+package conform
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed testdata/corpus
+var embedded embed.FS
+
+// Case files.
+const (
+	ConfigFile   = "config.yaml"
+	InputFile    = "input.json"
+	StdoutFile   = "stdout"
+	ExitCodeFile = "exit_code"
+)
+
+// caseTimeout bounds one replay.
+const caseTimeout = 30 * time.Second
+
+// Case is one golden recording.
+type Case struct {
+	Name     string
+	Config   []byte
+	Input    []byte
+	Stdout   []byte
+	ExitCode int
+}
+
+// Result is what a binary produced for a case.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Corpus returns the corpus built into hook-chain.
+func Corpus() fs.FS {
+	sub, err := fs.Sub(embedded, "testdata/corpus")
+	if err != nil {
+		panic(fmt.Sprintf("conform: embedded corpus: %v", err))
+	}
+	return sub
+}
+
+// Load reads every case directory in fsys, sorted by name.
+func Load(fsys fs.FS) ([]Case, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("conform: read corpus: %w", err)
+	}
+	var cases []Case
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		c := Case{Name: e.Name()}
+		if c.Config, err = fs.ReadFile(fsys, path.Join(c.Name, ConfigFile)); err != nil {
+			return nil, fmt.Errorf("conform: case %s: %w", c.Name, err)
+		}
+		if c.Input, err = fs.ReadFile(fsys, path.Join(c.Name, InputFile)); err != nil {
+			return nil, fmt.Errorf("conform: case %s: %w", c.Name, err)
+		}
+		if c.Stdout, err = fs.ReadFile(fsys, path.Join(c.Name, StdoutFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("conform: case %s: %w", c.Name, err)
+		}
+		code, err := fs.ReadFile(fsys, path.Join(c.Name, ExitCodeFile))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("conform: case %s: %w", c.Name, err)
+		default:
+			if c.ExitCode, err = strconv.Atoi(strings.TrimSpace(string(code))); err != nil {
+				return nil, fmt.Errorf("conform: case %s: invalid %s: %w", c.Name, ExitCodeFile, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Replay runs binary on the case's input in an isolated environment: a
+// fresh HOME and XDG directories, audit off, and no installed ruleset, so
+// only the case's config and hook-chain's built-in defaults apply.
+func Replay(ctx context.Context, binary string, c Case) (Result, error) {
+	dir, err := os.MkdirTemp("", "hook-chain-conform-")
+	if err != nil {
+		return Result{}, fmt.Errorf("conform: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	configPath := filepath.Join(dir, ConfigFile)
+	if err := os.WriteFile(configPath, c.Config, 0o644); err != nil {
+		return Result{}, fmt.Errorf("conform: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, caseTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(c.Input)
+	cmd.Env = isolatedEnv(dir, configPath)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	r := Result{}
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		r.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return Result{}, fmt.Errorf("conform: case %s: run %s: %w", c.Name, binary, err)
+	}
+	r.Stdout, r.Stderr = stdout.Bytes(), stderr.Bytes()
+	return r, nil
+}
+
+// isolatedEnv keeps PATH (hooks run sh) and points every hook-chain path
+// into dir.
+func isolatedEnv(dir, configPath string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"XDG_CONFIG_HOME=" + filepath.Join(dir, "config"),
+		"XDG_DATA_HOME=" + filepath.Join(dir, "data"),
+		"XDG_RUNTIME_DIR=" + filepath.Join(dir, "run"),
+		"HOOK_CHAIN_CONFIG=" + configPath,
+		"HOOK_CHAIN_AUDIT=0",
+		"HOOK_CHAIN_RULES=" + filepath.Join(dir, "no-rules.yaml"),
+	}
+}
+
+// Diff describes how r differs from the case's golden output, or returns
+// "" when they match byte for byte.
+func (c Case) Diff(r Result) string {
+	var b strings.Builder
+	if r.ExitCode != c.ExitCode {
+		fmt.Fprintf(&b, "exit code: got %d, want %d\n", r.ExitCode, c.ExitCode)
+	}
+	if !bytes.Equal(r.Stdout, c.Stdout) {
+		fmt.Fprintf(&b, "stdout:\n  got:  %q\n  want: %q\n", r.Stdout, c.Stdout)
+	}
+	if b.Len() > 0 && len(r.Stderr) > 0 {
+		fmt.Fprintf(&b, "stderr:\n  %s\n", strings.TrimSpace(string(r.Stderr)))
+	}
+	return b.String()
+}
+
+// Update rewrites the golden files of the case in dir/<name> with r.
+func Update(dir string, c Case, r Result) error {
+	caseDir := filepath.Join(dir, c.Name)
+	if err := os.WriteFile(filepath.Join(caseDir, StdoutFile), r.Stdout, 0o644); err != nil {
+		return fmt.Errorf("conform: update %s: %w", c.Name, err)
+	}
+	codePath := filepath.Join(caseDir, ExitCodeFile)
+	if r.ExitCode == 0 {
+		if err := os.Remove(codePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("conform: update %s: %w", c.Name, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(codePath, []byte(strconv.Itoa(r.ExitCode)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("conform: update %s: %w", c.Name, err)
+	}
+	return nil
+}
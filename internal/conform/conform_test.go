@@ -0,0 +1,116 @@
+package conform
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/corpus")
+
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the hook-chain binary")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+	binary := filepath.Join(t.TempDir(), "hook-chain")
+	build := exec.Command(goBin, "build", "-o", binary, "github.com/Fuabioo/hook-chain")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build hook-chain: %v\n%s", err, out)
+	}
+
+	cases, err := Load(Corpus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("corpus is empty")
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			r, err := Replay(context.Background(), binary, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := Update(filepath.Join("testdata", "corpus"), c, r); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if diff := c.Diff(r); diff != "" {
+				t.Errorf("output differs from golden (rerun with -update if intended):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"allow/config.yaml": {Data: []byte("chains: []\n")},
+		"allow/input.json":  {Data: []byte("{}")},
+		"deny/config.yaml":  {Data: []byte("chains: []\n")},
+		"deny/input.json":   {Data: []byte("{}")},
+		"deny/stdout":       {Data: []byte(`{"decision":"block"}`)},
+		"deny/exit_code":    {Data: []byte("2\n")},
+		"README":            {Data: []byte("not a case")},
+	}
+	cases, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("got %d cases, want 2", len(cases))
+	}
+	if c := cases[0]; c.Name != "allow" || c.ExitCode != 0 || len(c.Stdout) != 0 {
+		t.Errorf("allow = %+v", c)
+	}
+	if c := cases[1]; c.Name != "deny" || c.ExitCode != 2 || string(c.Stdout) != `{"decision":"block"}` {
+		t.Errorf("deny = %+v", c)
+	}
+
+	delete(fsys, "allow/input.json")
+	if _, err := Load(fsys); err == nil {
+		t.Error("Load without input.json: want error")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	c := Case{Stdout: []byte("{}\n"), ExitCode: 2}
+	if d := c.Diff(Result{Stdout: []byte("{}\n"), ExitCode: 2}); d != "" {
+		t.Errorf("matching result: diff = %q", d)
+	}
+	if d := c.Diff(Result{Stdout: []byte("{}"), ExitCode: 2}); d == "" {
+		t.Error("trailing newline difference not reported")
+	}
+	if d := c.Diff(Result{Stdout: []byte("{}\n")}); d == "" {
+		t.Error("exit code difference not reported")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	c := Case{Name: "x"}
+	if err := os.Mkdir(filepath.Join(dir, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(dir, c, Result{Stdout: []byte("out"), ExitCode: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "x", ExitCodeFile)); string(b) != "2\n" {
+		t.Errorf("exit_code = %q", b)
+	}
+	if err := Update(dir, c, Result{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x", ExitCodeFile)); !os.IsNotExist(err) {
+		t.Errorf("exit_code kept for a zero exit: %v", err)
+	}
+}
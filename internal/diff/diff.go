@@ -0,0 +1,208 @@
+// Package diff renders unified diffs of file edits, so hooks that review
+// changes (secret scanners, license checkers) get one diff instead of each
+// reconstructing it from the tool input.
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxEdits bounds the Myers search. Inputs that differ by more lines are
+// rendered as a full replacement rather than a minimal diff.
+const maxEdits = 4000
+
+// op is one line of an edit script: ' ' kept, '-' deleted, '+' inserted.
+// a and b count the old and new lines that precede it.
+type op struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// Unified returns the unified diff turning old into new, with context lines
+// around each change. oldName and newName label the --- and +++ headers.
+// It returns "" when old and new are equal.
+func Unified(oldName, newName, old, new string, context int) string {
+	if old == new {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+	ops := editScript(splitLines(old), splitLines(new))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := max(0, i-context)
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next < len(ops) && next-end <= 2*context {
+				end = next
+				continue
+			}
+			end = min(end+context, next)
+			break
+		}
+		writeHunk(&sb, ops[start:end])
+		i = end
+	}
+	return sb.String()
+}
+
+// writeHunk writes one @@ hunk covering ops.
+func writeHunk(sb *strings.Builder, ops []op) {
+	var aCount, bCount int
+	for _, o := range ops {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].a, aCount), hunkRange(ops[0].b, bCount))
+	for _, o := range ops {
+		sb.WriteByte(o.kind)
+		sb.WriteString(o.text)
+		if !strings.HasSuffix(o.text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's start line and length the way diff -u does:
+// an empty range starts at the line before it, and a length of 1 is omitted.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// splitLines splits s into lines, each keeping its trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns a shortest edit script from a to b (Myers' O(ND)
+// algorithm), or a full replacement when they differ by more than maxEdits.
+func editScript(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v[-d..d] as it was before round d.
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return replacement(a, b)
+}
+
+// backtrack walks trace from the end of both inputs back to the start and
+// returns the edit script in forward order.
+func backtrack(a, b []string, trace [][]int) []op {
+	x, y := len(a), len(b)
+	var rev []op
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, op{kind: ' ', text: a[x]})
+		}
+		if x == prevX {
+			y--
+			rev = append(rev, op{kind: '+', text: b[y]})
+		} else {
+			x--
+			rev = append(rev, op{kind: '-', text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, op{kind: ' ', text: a[x]})
+	}
+	slices.Reverse(rev)
+	return number(rev)
+}
+
+// replacement deletes all of a and inserts all of b.
+func replacement(a, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+	for _, l := range a {
+		ops = append(ops, op{kind: '-', text: l})
+	}
+	for _, l := range b {
+		ops = append(ops, op{kind: '+', text: l})
+	}
+	return number(ops)
+}
+
+// number fills in the old and new line counts preceding each op.
+func number(ops []op) []op {
+	var a, b int
+	for i := range ops {
+		ops[i].a, ops[i].b = a, b
+		if ops[i].kind != '+' {
+			a++
+		}
+		if ops[i].kind != '-' {
+			b++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,104 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		context  int
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name:    "change in middle",
+			old:     "a\nb\nc\nd\ne\n",
+			new:     "a\nb\nX\nd\ne\n",
+			context: 1,
+			want:    "--- a/f\n+++ b/f\n@@ -2,3 +2,3 @@\n b\n-c\n+X\n d\n",
+		},
+		{
+			name:    "new file",
+			old:     "",
+			new:     "one\ntwo\n",
+			context: 3,
+			want:    "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name:    "single line hunks",
+			old:     "a\n",
+			new:     "b\n",
+			context: 3,
+			want:    "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n",
+		},
+		{
+			name:    "missing final newline",
+			old:     "a\nb",
+			new:     "a\nb\n",
+			context: 0,
+			want:    "--- a/f\n+++ b/f\n@@ -2 +2 @@\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:    "distant changes make two hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:     "X\n2\n3\n4\n5\n6\n7\nY\n",
+			context: 1,
+			want:    "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n-1\n+X\n 2\n@@ -7,2 +7,2 @@\n 7\n-8\n+Y\n",
+		},
+		{
+			name:    "close changes merge",
+			old:     "1\n2\n3\n4\n",
+			new:     "X\n2\n3\nY\n",
+			context: 1,
+			want:    "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n-4\n+Y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/f", "b/f", tt.old, tt.new, tt.context)
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditScriptIsMinimal(t *testing.T) {
+	a := splitLines("a\nb\nc\na\nb\nb\na\n")
+	b := splitLines("c\nb\na\nb\na\nc\n")
+	changes := 0
+	for _, o := range editScript(a, b) {
+		if o.kind != ' ' {
+			changes++
+		}
+	}
+	// The classic Myers example: the shortest edit script has 5 edits.
+	if changes != 5 {
+		t.Errorf("edit script has %d changes, want 5", changes)
+	}
+}
+
+func TestEditScriptFallsBackToReplacement(t *testing.T) {
+	var a, b strings.Builder
+	for i := range maxEdits {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	ops := editScript(splitLines(a.String()), splitLines(b.String()))
+	if len(ops) != 2*maxEdits {
+		t.Fatalf("len(ops) = %d, want %d", len(ops), 2*maxEdits)
+	}
+	if ops[0].kind != '-' || ops[len(ops)-1].kind != '+' {
+		t.Errorf("want deletions then insertions, got %q ... %q", ops[0].kind, ops[len(ops)-1].kind)
+	}
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxFileSize is the largest file, before or after the edit, that is diffed.
+const MaxFileSize = 1 << 20
+
+// ErrTooLarge is returned when a file exceeds MaxFileSize.
+var ErrTooLarge = errors.New("diff: file too large")
+
+// editInput is the tool_input of Edit, MultiEdit, and Write tool calls.
+type editInput struct {
+	FilePath   string      `json:"file_path"`
+	OldString  string      `json:"old_string"`
+	NewString  string      `json:"new_string"`
+	ReplaceAll bool        `json:"replace_all"`
+	Edits      []editInput `json:"edits"`
+	Content    *string     `json:"content"`
+}
+
+// ForTool returns the unified diff a file-editing tool call would make to
+// the file on disk. Relative paths are resolved against cwd. ok is false
+// for tools that do not edit files; an error means the diff could not be
+// computed, for example because old_string does not occur in the file.
+func ForTool(toolName string, toolInput json.RawMessage, cwd string, context int) (diff string, ok bool, err error) {
+	switch toolName {
+	case "Edit", "MultiEdit", "Write":
+	default:
+		return "", false, nil
+	}
+
+	var in editInput
+	if err := json.Unmarshal(toolInput, &in); err != nil {
+		return "", true, fmt.Errorf("diff: parse %s input: %w", toolName, err)
+	}
+	if in.FilePath == "" {
+		return "", true, fmt.Errorf("diff: %s input has no file_path", toolName)
+	}
+	path := in.FilePath
+	if !filepath.IsAbs(path) && cwd != "" {
+		path = filepath.Join(cwd, path)
+	}
+
+	old, exists, err := readFile(path)
+	if err != nil {
+		return "", true, err
+	}
+
+	var updated string
+	switch toolName {
+	case "Write":
+		if in.Content == nil {
+			return "", true, errors.New("diff: Write input has no content")
+		}
+		updated = *in.Content
+	case "Edit":
+		if updated, err = applyEdit(old, in); err != nil {
+			return "", true, err
+		}
+	case "MultiEdit":
+		updated = old
+		for i, e := range in.Edits {
+			if updated, err = applyEdit(updated, e); err != nil {
+				return "", true, fmt.Errorf("edit %d: %w", i, err)
+			}
+		}
+	}
+	if len(updated) > MaxFileSize {
+		return "", true, fmt.Errorf("%w: %s after edit", ErrTooLarge, in.FilePath)
+	}
+
+	oldName := "a/" + strings.TrimPrefix(in.FilePath, "/")
+	if !exists {
+		oldName = "/dev/null"
+	}
+	newName := "b/" + strings.TrimPrefix(in.FilePath, "/")
+	return Unified(oldName, newName, old, updated, context), true, nil
+}
+
+// readFile returns the file's content and whether it exists.
+func readFile(path string) (string, bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("diff: stat %s: %w", path, err)
+	}
+	if info.Size() > MaxFileSize {
+		return "", true, fmt.Errorf("%w: %s", ErrTooLarge, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", true, fmt.Errorf("diff: read %s: %w", path, err)
+	}
+	return string(data), true, nil
+}
+
+// applyEdit performs one old_string → new_string replacement the way the
+// Edit tool does: the first occurrence, or all of them with replace_all.
+func applyEdit(content string, e editInput) (string, error) {
+	if e.OldString == "" {
+		// Creating a file with Edit: old_string is empty and the file is new.
+		if content == "" {
+			return e.NewString, nil
+		}
+		return "", errors.New("diff: empty old_string on a non-empty file")
+	}
+	if !strings.Contains(content, e.OldString) {
+		return "", errors.New("diff: old_string not found in file")
+	}
+	if e.ReplaceAll {
+		return strings.ReplaceAll(content, e.OldString, e.NewString), nil
+	}
+	return strings.Replace(content, e.OldString, e.NewString, 1), nil
+}
@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		input   map[string]any
+		wantOK  bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "not a file tool",
+			tool:   "Bash",
+			input:  map[string]any{"command": "ls"},
+			wantOK: false,
+		},
+		{
+			name:   "edit relative path",
+			tool:   "Edit",
+			input:  map[string]any{"file_path": "main.go", "old_string": "func main() {}", "new_string": "func main() {\n}"},
+			wantOK: true,
+			want:   "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,4 @@\n package main\n \n-func main() {}\n+func main() {\n+}\n",
+		},
+		{
+			name:    "edit old_string missing",
+			tool:    "Edit",
+			input:   map[string]any{"file_path": "main.go", "old_string": "nope", "new_string": "x"},
+			wantOK:  true,
+			wantErr: true,
+		},
+		{
+			name: "multi edit",
+			tool: "MultiEdit",
+			input: map[string]any{"file_path": "main.go", "edits": []map[string]any{
+				{"old_string": "main() {}", "new_string": "main() { run() }"},
+				{"old_string": "package main\n", "new_string": "package app\n"},
+			}},
+			wantOK: true,
+			want:   "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n-package main\n+package app\n \n-func main() {}\n+func main() { run() }\n",
+		},
+		{
+			name:   "write new file",
+			tool:   "Write",
+			input:  map[string]any{"file_path": filepath.Join(dir, "new.txt"), "content": "hello\n"},
+			wantOK: true,
+			want:   "--- /dev/null\n+++ b/" + filepath.Join(dir, "new.txt")[1:] + "\n@@ -0,0 +1 @@\n+hello\n",
+		},
+		{
+			name:   "write unchanged",
+			tool:   "Write",
+			input:  map[string]any{"file_path": "main.go", "content": "package main\n\nfunc main() {}\n"},
+			wantOK: true,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok, err := ForTool(tt.tool, raw, dir, 3)
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("diff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForToolTooLarge(t *testing.T) {
+	content := string(make([]byte, MaxFileSize+1))
+	raw, err := json.Marshal(map[string]any{"file_path": filepath.Join(t.TempDir(), "big"), "content": content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ForTool("Write", raw, "", 3); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
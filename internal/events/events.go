@@ -0,0 +1,127 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Kind identifies a pipeline lifecycle event.
+type Kind string
+
+// Lifecycle event kinds, in the order a chain emits them.
+const (
+	KindChainStart Kind = "chain_start"
+	KindHookStart  Kind = "hook_start"
+	KindHookEnd    Kind = "hook_end"
+	KindDecision   Kind = "decision"
+	KindChainEnd   Kind = "chain_end"
+
+	// KindAnomaly is published outside the chain lifecycle when the
+	// rotation-time analysis pass flags an audit anomaly.
+	KindAnomaly Kind = "anomaly"
+)
+
+// AllKinds lists every event kind hook-chain publishes.
+var AllKinds = []Kind{KindChainStart, KindHookStart, KindHookEnd, KindDecision, KindChainEnd, KindAnomaly}
+
+// Event is a single lifecycle notification published by the pipeline.
+// Hook-scoped fields are only set for hook_start/hook_end; Outcome and
+// Reason are set for hook_end, decision, and chain_end. Anomaly events carry
+// the anomaly kind in Outcome and its description in Reason.
+type Event struct {
+	Kind       Kind      `json:"kind"`
+	Time       time.Time `json:"time"`
+	EventName  string    `json:"event_name"`
+	ToolName   string    `json:"tool_name"`
+	SessionID  string    `json:"session_id,omitempty"`
+	ChainLen   int       `json:"chain_len"`
+	HookIndex  int       `json:"hook_index"`
+	HookName   string    `json:"hook_name,omitempty"`
+	ExitCode   int       `json:"exit_code"`
+	Outcome    string    `json:"outcome,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Handler receives published events. Implementations must not block for
+// long: handlers run synchronously on the pipeline goroutine.
+type Handler interface {
+	Handle(e Event)
+}
+
+// HandlerFunc adapts a plain function to the Handler interface.
+type HandlerFunc func(e Event)
+
+// Handle calls f(e).
+func (f HandlerFunc) Handle(e Event) { f(e) }
+
+// Closer is implemented by handlers that need to flush or release resources
+// when the bus is closed (e.g. exec'd plugins delivering buffered events).
+type Closer interface {
+	Close() error
+}
+
+// Bus fans out lifecycle events to subscribed handlers.
+// A nil *Bus is valid and discards all events.
+type Bus struct {
+	handlers []Handler
+	logger   *slog.Logger
+}
+
+// NewBus returns an empty bus. Handler panics and close errors are logged to logger.
+func NewBus(logger *slog.Logger) *Bus {
+	return &Bus{logger: logger}
+}
+
+// Subscribe registers h to receive every subsequently published event.
+func (b *Bus) Subscribe(h Handler) {
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish delivers e to all handlers in subscription order. A panicking
+// handler is logged and skipped — plugins must never break the pipeline.
+// Nil receiver is a no-op.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	for _, h := range b.handlers {
+		b.deliver(h, e)
+	}
+}
+
+func (b *Bus) deliver(h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil && b.logger != nil {
+			b.logger.Warn("event handler panicked", "kind", e.Kind, "panic", r)
+		}
+	}()
+	h.Handle(e)
+}
+
+// Close closes every handler implementing Closer and returns the joined errors.
+// Nil receiver is a no-op.
+func (b *Bus) Close() error {
+	if b == nil {
+		return nil
+	}
+	var errs []error
+	for _, h := range b.handlers {
+		c, ok := h.(Closer)
+		if !ok {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("events: close handlers: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+type closingHandler struct {
+	got    []Event
+	closed bool
+	err    error
+}
+
+func (h *closingHandler) Handle(e Event) { h.got = append(h.got, e) }
+
+func (h *closingHandler) Close() error {
+	h.closed = true
+	return h.err
+}
+
+func TestBusPublishOrder(t *testing.T) {
+	bus := NewBus(testLogger())
+	var order []string
+	bus.Subscribe(HandlerFunc(func(e Event) { order = append(order, "a:"+string(e.Kind)) }))
+	bus.Subscribe(HandlerFunc(func(e Event) { order = append(order, "b:"+string(e.Kind)) }))
+
+	bus.Publish(Event{Kind: KindChainStart})
+	bus.Publish(Event{Kind: KindChainEnd})
+
+	want := []string{"a:chain_start", "b:chain_start", "a:chain_end", "b:chain_end"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("order[%d] = %q, want %q", i, order[i], want[i])
+		}
+	}
+}
+
+func TestBusPublishSetsTime(t *testing.T) {
+	bus := NewBus(testLogger())
+	h := &closingHandler{}
+	bus.Subscribe(h)
+
+	bus.Publish(Event{Kind: KindHookStart})
+	if len(h.got) != 1 || h.got[0].Time.IsZero() {
+		t.Errorf("got = %+v, want one event with Time set", h.got)
+	}
+}
+
+func TestBusHandlerPanicRecovered(t *testing.T) {
+	bus := NewBus(testLogger())
+	h := &closingHandler{}
+	bus.Subscribe(HandlerFunc(func(Event) { panic("boom") }))
+	bus.Subscribe(h)
+
+	bus.Publish(Event{Kind: KindDecision})
+	if len(h.got) != 1 {
+		t.Errorf("len(got) = %d, want 1 (handler after panicking one still runs)", len(h.got))
+	}
+}
+
+func TestBusNilSafe(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Kind: KindChainStart})
+	if err := bus.Close(); err != nil {
+		t.Errorf("Close on nil bus: %v", err)
+	}
+}
+
+func TestBusCloseJoinsErrors(t *testing.T) {
+	bus := NewBus(testLogger())
+	ok := &closingHandler{}
+	bad := &closingHandler{err: errors.New("flush failed")}
+	bus.Subscribe(ok)
+	bus.Subscribe(bad)
+	bus.Subscribe(HandlerFunc(func(Event) {}))
+
+	err := bus.Close()
+	if err == nil {
+		t.Fatal("expected error from Close, got nil")
+	}
+	if !ok.closed || !bad.closed {
+		t.Errorf("closed = %v/%v, want both true", ok.closed, bad.closed)
+	}
+}
+
+func TestExecSubscriberDeliversNDJSON(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events.ndjson")
+	s := &ExecSubscriber{
+		Name:    "capture",
+		Command: "sh",
+		Args:    []string{"-c", "cat > " + out},
+		Kinds:   []Kind{KindHookEnd, KindChainEnd},
+	}
+
+	bus := NewBus(testLogger())
+	bus.Subscribe(s)
+	bus.Publish(Event{Kind: KindChainStart})
+	bus.Publish(Event{Kind: KindHookEnd, HookName: "guard", Outcome: "pass"})
+	bus.Publish(Event{Kind: KindChainEnd, Outcome: "allow"})
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var kinds []Kind
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("Unmarshal %q: %v", sc.Text(), err)
+		}
+		kinds = append(kinds, e.Kind)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(kinds) != 2 || kinds[0] != KindHookEnd || kinds[1] != KindChainEnd {
+		t.Errorf("kinds = %v, want [hook_end chain_end]", kinds)
+	}
+}
+
+func TestExecSubscriberNoEventsNoProcess(t *testing.T) {
+	s := &ExecSubscriber{Name: "missing", Command: "/nonexistent/binary/xyz"}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close with no events: %v", err)
+	}
+}
+
+func TestExecSubscriberCommandFailure(t *testing.T) {
+	s := &ExecSubscriber{Name: "fail", Command: "false"}
+	s.Handle(Event{Kind: KindChainEnd})
+	if err := s.Close(); err == nil {
+		t.Fatal("expected error for failing plugin, got nil")
+	}
+}
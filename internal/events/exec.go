@@ -0,0 +1,79 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+const defaultPluginTimeout = 5 * time.Second
+
+// ExecSubscriber buffers events and delivers them to an external command
+// as newline-delimited JSON on stdin when the bus is closed. Batching keeps
+// plugin cost at one process per hook-chain invocation.
+type ExecSubscriber struct {
+	Name    string
+	Command string
+	Args    []string
+	Kinds   []Kind // empty = all kinds
+	Timeout time.Duration
+
+	buf []Event
+}
+
+// Handle buffers e if its kind is subscribed.
+func (s *ExecSubscriber) Handle(e Event) {
+	if len(s.Kinds) > 0 && !slices.Contains(s.Kinds, e.Kind) {
+		return
+	}
+	s.buf = append(s.buf, e)
+}
+
+// Close runs the plugin command with all buffered events. No events, no process.
+func (s *ExecSubscriber) Close() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	var stdin bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	for _, e := range s.buf {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("events: plugin %q: encode event: %w", s.Name, err)
+		}
+	}
+	s.buf = nil
+
+	parts := pathutil.Fields(s.Command)
+	if len(parts) == 0 {
+		return fmt.Errorf("events: plugin %q: empty command", s.Name)
+	}
+	args := parts[1:]
+	for _, a := range s.Args {
+		args = append(args, pathutil.Expand(a))
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], args...)
+	cmd.Stdin = &stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("events: plugin %q: %w (stderr: %s)", s.Name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Package graph renders configured chains as diagrams (Graphviz DOT or
+// Mermaid) for documentation and review: each chain's event and tools,
+// its hook sequence with the settings that change how a hook decides, and
+// its finally hooks.
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// node is one box in the diagram.
+type node struct {
+	id    string
+	lines []string
+	shape string // "event", "hook", "decision"
+}
+
+// edge connects two nodes, optionally labeled.
+type edge struct {
+	from, to, label string
+	dashed          bool
+}
+
+// cluster is one chain.
+type cluster struct {
+	id    string
+	title string
+	nodes []node
+	edges []edge
+}
+
+// Render writes a diagram of chains in the given format.
+func Render(w io.Writer, chains []config.ChainEntry, format string) error {
+	clusters := make([]cluster, 0, len(chains))
+	for i, c := range chains {
+		clusters = append(clusters, build(i, c))
+	}
+	var err error
+	switch format {
+	case FormatDOT:
+		err = writeDOT(w, clusters)
+	case FormatMermaid:
+		err = writeMermaid(w, clusters)
+	default:
+		return fmt.Errorf("graph: unknown format %q (want %s or %s)", format, FormatDOT, FormatMermaid)
+	}
+	if err != nil {
+		return fmt.Errorf("graph: write %s: %w", format, err)
+	}
+	return nil
+}
+
+// build lays out one chain: event → hooks in order → decision → finally.
+func build(i int, c config.ChainEntry) cluster {
+	cl := cluster{id: fmt.Sprintf("c%d", i), title: fmt.Sprintf("Chain %d: %s", i+1, c.EventLabel())}
+	if c.Disabled {
+		cl.title += " (disabled)"
+	}
+	tools := cmp.Or(c.ToolLabel(), "(no tool)")
+	event := node{id: cl.id + "_event", lines: []string{c.EventLabel(), tools}, shape: "event"}
+	if c.HasSchedule() {
+		event.lines = append(event.lines, "active "+c.ScheduleLabel())
+	}
+	if c.LatencyBudget > 0 {
+		event.lines = append(event.lines, "budget "+c.LatencyBudget.String())
+	}
+	if c.MaxDuration > 0 {
+		event.lines = append(event.lines, "max "+c.MaxDuration.String()+" then "+c.EffectiveOnMaxDuration())
+	}
+	if c.Quota != nil {
+		event.lines = append(event.lines, "quota "+c.Quota.Label())
+	}
+	if len(c.Severity) > 0 {
+		var m []string
+		for _, sev := range config.Severities {
+			if a, ok := c.Severity[sev]; ok {
+				m = append(m, sev+"→"+a)
+			}
+		}
+		event.lines = append(event.lines, "severity "+strings.Join(m, " "))
+	}
+	cl.nodes = append(cl.nodes, event)
+
+	prev := event.id
+	label := ""
+	for j, h := range c.Hooks {
+		n := node{id: fmt.Sprintf("%s_h%d", cl.id, j), lines: hookLines(h), shape: "hook"}
+		cl.nodes = append(cl.nodes, n)
+		cl.edges = append(cl.edges, edge{from: prev, to: n.id, label: label})
+		// Async hooks never decide, so the chain moves on unconditionally.
+		prev, label = n.id, "pass"
+		if h.Async {
+			label = ""
+		}
+	}
+
+	decision := node{id: cl.id + "_decision", lines: []string{"decision"}, shape: "decision"}
+	if c.OnDeny.Ask {
+		decision.lines = append(decision.lines, "deny → ask")
+	}
+	if c.Risk != nil {
+		decision.lines = append(decision.lines, "risk "+c.Risk.Label())
+	}
+	cl.nodes = append(cl.nodes, decision)
+	cl.edges = append(cl.edges, edge{from: prev, to: decision.id, label: label})
+	prev = decision.id
+	for j, h := range c.Finally {
+		n := node{id: fmt.Sprintf("%s_f%d", cl.id, j), lines: append([]string{"finally"}, hookLines(h)...), shape: "hook"}
+		cl.nodes = append(cl.nodes, n)
+		cl.edges = append(cl.edges, edge{from: prev, to: n.id, dashed: true})
+		prev = n.id
+	}
+	return cl
+}
+
+// hookLines describes a hook: its name, what it runs, and every setting
+// that changes whether or how its decision is enforced.
+func hookLines(h config.HookEntry) []string {
+	lines := []string{h.Name}
+	switch {
+	case h.Builtin != "":
+		lines = append(lines, "builtin "+h.Builtin)
+	case len(h.Variants) > 0:
+		lines = append(lines, "A/B "+strings.Join(h.Variants, " | "))
+	case h.URL != "":
+		lines = append(lines, "POST "+h.URL)
+	default:
+		lines = append(lines, strings.TrimSpace(h.Command+" "+strings.Join(h.Args, " ")))
+	}
+
+	var attrs []string
+	if !h.Async {
+		attrs = append(attrs, "on_error="+h.EffectiveOnError())
+	}
+	if h.Timeout > 0 {
+		attrs = append(attrs, "timeout="+h.Timeout.String())
+	}
+	if h.Output != "" && h.Output != config.OutputJSON {
+		attrs = append(attrs, "output="+h.Output)
+	}
+	if len(attrs) > 0 {
+		lines = append(lines, strings.Join(attrs, " "))
+	}
+
+	var conds []string
+	if h.Disabled {
+		conds = append(conds, "disabled")
+	}
+	if h.Async {
+		conds = append(conds, "async")
+	}
+	if h.ReportOnly {
+		conds = append(conds, "report-only")
+	}
+	if h.Rollout != "" {
+		conds = append(conds, "rollout "+h.Rollout)
+	}
+	if h.Quota != nil {
+		conds = append(conds, "quota "+h.Quota.Label())
+	}
+	if h.Severity != "" {
+		conds = append(conds, "severity "+h.Severity)
+	}
+	if h.Priority == config.PriorityLow {
+		conds = append(conds, "low priority")
+	}
+	if len(conds) > 0 {
+		lines = append(lines, strings.Join(conds, ", "))
+	}
+	return lines
+}
+
+func writeDOT(w io.Writer, clusters []cluster) error {
+	var b strings.Builder
+	b.WriteString("digraph hookchain {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, cl := range clusters {
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n", cl.id)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(cl.title))
+		for _, n := range cl.nodes {
+			shape := ""
+			switch n.shape {
+			case "event":
+				shape = ", shape=oval"
+			case "decision":
+				shape = ", shape=diamond"
+			}
+			fmt.Fprintf(&b, "    %s [label=%s%s];\n", n.id, dotQuote(strings.Join(n.lines, "\n")), shape)
+		}
+		for _, e := range cl.edges {
+			var attrs []string
+			if e.label != "" {
+				attrs = append(attrs, "label="+dotQuote(e.label))
+			}
+			if e.dashed {
+				attrs = append(attrs, "style=dashed")
+			}
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, "    %s -> %s [%s];\n", e.from, e.to, strings.Join(attrs, ", "))
+			} else {
+				fmt.Fprintf(&b, "    %s -> %s;\n", e.from, e.to)
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes s as a DOT string; newlines become centered line breaks.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func writeMermaid(w io.Writer, clusters []cluster) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, cl := range clusters {
+		fmt.Fprintf(&b, "  subgraph %s[%s]\n", cl.id, mermaidQuote([]string{cl.title}))
+		for _, n := range cl.nodes {
+			label := mermaidQuote(n.lines)
+			switch n.shape {
+			case "event":
+				fmt.Fprintf(&b, "    %s([%s])\n", n.id, label)
+			case "decision":
+				fmt.Fprintf(&b, "    %s{%s}\n", n.id, label)
+			default:
+				fmt.Fprintf(&b, "    %s[%s]\n", n.id, label)
+			}
+		}
+		for _, e := range cl.edges {
+			arrow := "-->"
+			if e.dashed {
+				arrow = "-.->"
+			}
+			if e.label != "" {
+				fmt.Fprintf(&b, "    %s %s|%s| %s\n", e.from, arrow, e.label, e.to)
+			} else {
+				fmt.Fprintf(&b, "    %s %s %s\n", e.from, arrow, e.to)
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidQuote quotes label lines for Mermaid, escaping characters that
+// would end the label or be read as markup.
+func mermaidQuote(lines []string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = r.Replace(l)
+	}
+	return `"` + strings.Join(escaped, "<br/>") + `"`
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+var testChains = []config.ChainEntry{
+	{
+		Event:    "PreToolUse",
+		Tools:    []string{"Bash"},
+		Severity: map[string]string{"warn": "ask"},
+		Hooks: []config.HookEntry{
+			{Name: "guard", Command: "~/hooks/guard", Args: []string{"--strict"}, Timeout: 5 * time.Second},
+			{Name: "cmds", Builtin: "command-guard", OnError: "skip", Rollout: "10%"},
+			{Name: "telemetry", Command: `log "x"`, Async: true},
+		},
+		Finally: []config.HookEntry{{Name: "notify", Command: "notify"}},
+	},
+	{Event: "SessionEnd", Hooks: []config.HookEntry{{Name: "cleanup", Command: "cleanup"}}},
+}
+
+func TestRenderDOT(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, testChains, FormatDOT); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"digraph hookchain {",
+		`label="Chain 1: PreToolUse";`,
+		`c0_event [label="PreToolUse\nBash\nseverity warn→ask", shape=oval];`,
+		`c0_h0 [label="guard\n~/hooks/guard --strict\non_error=deny timeout=5s"];`,
+		`c0_h1 [label="cmds\nbuiltin command-guard\non_error=skip\nrollout 10%"];`,
+		`c0_h2 [label="telemetry\nlog \"x\"\nasync"];`,
+		`c0_h0 -> c0_h1 [label="pass"];`,
+		"c0_h2 -> c0_decision;",
+		"c0_decision -> c0_f0 [style=dashed];",
+		`c1_event [label="SessionEnd\n(no tool)", shape=oval];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DOT output missing %s\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, testChains, FormatMermaid); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"flowchart LR\n",
+		`  subgraph c0["Chain 1: PreToolUse"]`,
+		`    c0_event(["PreToolUse<br/>Bash<br/>severity warn→ask"])`,
+		`    c0_h2["telemetry<br/>log #quot;x#quot;<br/>async"]`,
+		`    c0_decision{"decision"}`,
+		"    c0_h0 -->|pass| c0_h1",
+		"    c0_decision -.-> c0_f0",
+		"  end\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %s\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if err := Render(&strings.Builder{}, testChains, "svg"); err == nil {
+		t.Error("Render(svg) succeeded, want error")
+	}
+}
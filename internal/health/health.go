@@ -0,0 +1,177 @@
+// Package health implements hook-chain's readiness self-checks: the config
+// parses, the audit database is writable, and every hook command resolves.
+// The checks back `hook-chain health` and the /healthz and /readyz endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/adapter"
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/builtin"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/messages"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// Check names reported in a Report.
+const (
+	CheckConfig  = "config"
+	CheckAuditDB = "audit_db"
+	CheckHooks   = "hooks"
+)
+
+// Check is the result of one self-check.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the outcome of a full readiness run.
+type Report struct {
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+}
+
+// Checker runs the readiness checks. Dependencies are injected so the
+// checks can be exercised without a real config file or PATH.
+type Checker struct {
+	// LoadConfig loads the active config.
+	LoadConfig func() (config.Config, error)
+	// AuditDBPath returns the audit database path for cfg, or "" when audit is disabled.
+	AuditDBPath func(cfg config.Config) string
+	// LookPath resolves a command name, like exec.LookPath.
+	LookPath func(file string) (string, error)
+}
+
+// Run executes every check. A config failure still checks the audit DB
+// (at the path derived from an empty config) but skips hook resolution.
+func (c Checker) Run(ctx context.Context) Report {
+	var r Report
+
+	cfg, err := c.LoadConfig()
+	if err == nil {
+		// Bad message templates and adapters make the pipeline fail closed,
+		// like bad YAML.
+		_, err = messages.New(cfg.Messages)
+	}
+	if err == nil {
+		err = adapter.Validate(cfg.Adapters)
+	}
+	if err != nil {
+		r.Checks = append(r.Checks, Check{Name: CheckConfig, Detail: err.Error()})
+	} else {
+		r.Checks = append(r.Checks, Check{Name: CheckConfig, OK: true, Detail: fmt.Sprintf("%d chain(s)", len(cfg.Chains))})
+	}
+
+	r.Checks = append(r.Checks, c.checkAuditDB(ctx, cfg))
+	if err == nil {
+		r.Checks = append(r.Checks, c.checkHooks(cfg))
+	}
+
+	r.Ready = true
+	for _, ch := range r.Checks {
+		if !ch.OK {
+			r.Ready = false
+		}
+	}
+	return r
+}
+
+// checkAuditDB opens (creating and migrating if needed) the audit database
+// and takes a write lock, so a read-only mount or a locked file fails.
+func (c Checker) checkAuditDB(ctx context.Context, cfg config.Config) Check {
+	dbPath := c.AuditDBPath(cfg)
+	if dbPath == "" {
+		return Check{Name: CheckAuditDB, OK: true, Detail: "audit disabled"}
+	}
+
+	// Measure the write-ahead log before opening adds to it.
+	wal := audit.WALSize(dbPath)
+	a, err := audit.Open(dbPath)
+	if err != nil {
+		return Check{Name: CheckAuditDB, Detail: err.Error()}
+	}
+	defer func() { _ = a.Close() }()
+
+	conn, err := a.DB().Conn(ctx)
+	if err != nil {
+		return Check{Name: CheckAuditDB, Detail: fmt.Sprintf("connect %s: %v", dbPath, err)}
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return Check{Name: CheckAuditDB, Detail: fmt.Sprintf("%s not writable: %v", dbPath, err)}
+	}
+	if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		return Check{Name: CheckAuditDB, Detail: fmt.Sprintf("rollback write probe on %s: %v", dbPath, err)}
+	}
+	return Check{Name: CheckAuditDB, OK: true, Detail: fmt.Sprintf("%s (WAL %.1f MiB)", dbPath, float64(wal)/(1<<20))}
+}
+
+// checkHooks resolves the executable of every configured hook, including
+// both commands of hooks with variants, and validates builtin hooks.
+func (c Checker) checkHooks(cfg config.Config) Check {
+	var missing []string
+	total := 0
+	for _, chain := range cfg.Chains {
+		for _, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			total++
+			if h.Builtin != "" {
+				if err := builtin.Validate(h); err != nil {
+					missing = append(missing, fmt.Sprintf("%s (%v)", h.Name, err))
+				}
+				continue
+			}
+			commands := []string{h.Command}
+			if len(h.Variants) > 0 {
+				commands = h.Variants
+			}
+			for _, cmd := range commands {
+				parts := pathutil.Fields(cmd)
+				if len(parts) == 0 {
+					missing = append(missing, h.Name+" (empty command)")
+					continue
+				}
+				if _, err := c.LookPath(parts[0]); err != nil {
+					missing = append(missing, fmt.Sprintf("%s (%s)", h.Name, parts[0]))
+				}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return Check{Name: CheckHooks, Detail: "unresolved: " + strings.Join(missing, ", ")}
+	}
+	return Check{Name: CheckHooks, OK: true, Detail: fmt.Sprintf("%d hook(s) resolved", total)}
+}
+
+// Handler serves /healthz (liveness: the process answers) and /readyz
+// (readiness: every check passes; 503 with the report otherwise).
+func Handler(c Checker) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status line is already sent; an encode error can only be a broken connection.
+	_ = json.NewEncoder(w).Encode(v)
+}
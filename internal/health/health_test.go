@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func testConfig() config.Config {
+	return config.Config{
+		Chains: []config.ChainEntry{
+			{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []config.HookEntry{
+				{Name: "guard", Command: "guard --strict"},
+				{Name: "logger", Command: "~/bin/logger"},
+			}},
+		},
+	}
+}
+
+func newChecker(t *testing.T, cfgErr error, dbPath string, known ...string) Checker {
+	t.Helper()
+	return Checker{
+		LoadConfig: func() (config.Config, error) {
+			if cfgErr != nil {
+				return config.Config{}, cfgErr
+			}
+			return testConfig(), nil
+		},
+		AuditDBPath: func(config.Config) string { return dbPath },
+		LookPath: func(file string) (string, error) {
+			for _, k := range known {
+				if filepath.Base(file) == k {
+					return file, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+	}
+}
+
+func checkByName(r Report, name string) (Check, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	// A regular file where the DB directory should be makes Open fail.
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		cfgErr    error
+		dbPath    string
+		known     []string
+		wantReady bool
+		wantFail  []string
+	}{
+		{"all ok", nil, filepath.Join(dir, "ok.db"), []string{"guard", "logger"}, true, nil},
+		{"audit disabled", nil, "", []string{"guard", "logger"}, true, nil},
+		{"missing hook", nil, "", []string{"guard"}, false, []string{CheckHooks}},
+		{"bad config", errors.New("yaml: line 3"), "", nil, false, []string{CheckConfig}},
+		{"db unusable", nil, filepath.Join(blocker, "audit.db"), []string{"guard", "logger"}, false, []string{CheckAuditDB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newChecker(t, tt.cfgErr, tt.dbPath, tt.known...).Run(context.Background())
+			if r.Ready != tt.wantReady {
+				t.Errorf("Ready = %v, want %v: %+v", r.Ready, tt.wantReady, r.Checks)
+			}
+			for _, name := range tt.wantFail {
+				c, ok := checkByName(r, name)
+				if !ok || c.OK {
+					t.Errorf("check %s = %+v (present=%v), want failure", name, c, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestRunSkipsHooksOnConfigError(t *testing.T) {
+	r := newChecker(t, errors.New("bad"), "").Run(context.Background())
+	if _, ok := checkByName(r, CheckHooks); ok {
+		t.Errorf("hooks check ran despite config error: %+v", r.Checks)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		known      []string
+		wantStatus int
+	}{
+		{"healthz", "/healthz", nil, http.StatusOK},
+		{"ready", "/readyz", []string{"guard", "logger"}, http.StatusOK},
+		{"not ready", "/readyz", nil, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Handler(newChecker(t, nil, "", tt.known...))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.path == "/readyz" {
+				var r Report
+				if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+					t.Fatalf("decode report: %v", err)
+				}
+				if r.Ready != (tt.wantStatus == http.StatusOK) {
+					t.Errorf("report.Ready = %v inconsistent with status %d", r.Ready, rec.Code)
+				}
+			}
+		})
+	}
+}
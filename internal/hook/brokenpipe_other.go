@@ -0,0 +1,13 @@
+//go:build !windows
+
+package hook
+
+import (
+	"errors"
+	"syscall"
+)
+
+// brokenPipe reports whether err is a write to a pipe nobody reads.
+func brokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package hook
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errNoData is ERROR_NO_DATA, returned for writes to a pipe whose reader
+// has closed it.
+const errNoData syscall.Errno = 232
+
+// brokenPipe reports whether err is a write to a pipe nobody reads.
+func brokenPipe(err error) bool {
+	return errors.Is(err, syscall.ERROR_BROKEN_PIPE) || errors.Is(err, errNoData) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,47 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+)
+
+// ErrBrokenPipe reports that nobody reads the output any more: the agent
+// gave up on the hook and closed its end of stdout.
+var ErrBrokenPipe = errors.New("reader is gone")
+
+// writeAttempts bounds how often WriteOutput tries a write that keeps
+// failing with a transient error.
+const writeAttempts = 3
+
+// writeRetryDelay is the pause before retrying a transient write error.
+const writeRetryDelay = 10 * time.Millisecond
+
+// WriteOutput writes data to w in full. Interrupted and would-block writes
+// are retried a few times, continuing after what was already written. A
+// broken pipe is not retried, since the reader is gone for good, and is
+// reported as ErrBrokenPipe.
+func WriteOutput(w io.Writer, data []byte) error {
+	var err error
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		var n int
+		n, err = w.Write(data)
+		data = data[n:]
+		switch {
+		case err == nil && len(data) == 0:
+			return nil
+		case err == nil:
+			err = io.ErrShortWrite
+		case brokenPipe(err):
+			return fmt.Errorf("hook: write output: %w: %w", ErrBrokenPipe, err)
+		case !errors.Is(err, syscall.EINTR) && !errors.Is(err, syscall.EAGAIN):
+			return fmt.Errorf("hook: write output: %w", err)
+		}
+		if attempt < writeAttempts {
+			time.Sleep(writeRetryDelay)
+		}
+	}
+	return fmt.Errorf("hook: write output: gave up after %d attempts: %w", writeAttempts, err)
+}
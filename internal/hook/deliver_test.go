@@ -0,0 +1,77 @@
+package hook
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// flakyWriter fails its first writes with errs, writing half the data
+// alongside each failure, then writes normally.
+type flakyWriter struct {
+	bytes.Buffer
+	errs []error
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if len(f.errs) == 0 {
+		return f.Buffer.Write(p)
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	n, _ := f.Buffer.Write(p[:len(p)/2])
+	return n, err
+}
+
+func TestWriteOutput(t *testing.T) {
+	data := []byte(`{"hookSpecificOutput":{"permissionDecision":"deny"}}`)
+	tests := []struct {
+		name       string
+		errs       []error
+		wantErr    bool
+		brokenPipe bool
+	}{
+		{"ok", nil, false, false},
+		{"interrupted then ok", []error{syscall.EINTR}, false, false},
+		{"would block twice then ok", []error{syscall.EAGAIN, syscall.EAGAIN}, false, false},
+		{"transient until gave up", []error{syscall.EAGAIN, syscall.EAGAIN, syscall.EAGAIN}, true, false},
+		{"broken pipe", []error{syscall.EPIPE}, true, true},
+		{"other error", []error{errors.New("disk full")}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &flakyWriter{errs: tt.errs}
+			err := WriteOutput(w, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteOutput error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrBrokenPipe); got != tt.brokenPipe {
+				t.Errorf("errors.Is(ErrBrokenPipe) = %v, want %v (err %v)", got, tt.brokenPipe, err)
+			}
+			if !tt.wantErr && !bytes.Equal(w.Bytes(), data) {
+				t.Errorf("written = %q, want %q once, resuming after partial writes", w.Bytes(), data)
+			}
+			if tt.brokenPipe && len(w.errs) != 0 {
+				t.Errorf("broken pipe retried")
+			}
+		})
+	}
+}
+
+func TestWriteOutputClosedPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteOutput(w, []byte(`{}`))
+	if !errors.Is(err, ErrBrokenPipe) {
+		t.Errorf("WriteOutput to a closed pipe = %v, want ErrBrokenPipe", err)
+	}
+}
@@ -0,0 +1,81 @@
+package hook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Malformed payloads must fail to parse, never panic, and anything that
+// parses must serialize again.
+
+func FuzzInputUnmarshal(f *testing.F) {
+	for _, seed := range []string{
+		`{"session_id":"s","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`,
+		`{"hook_event_name":"UserPromptSubmit","prompt":"hi","future_field":[1,2,3]}`,
+		`{"hookEventName":"PreToolUse","protocol_version":2}`,
+		`{"tool_input":null}`,
+		`{"tool_input":"{\"command\":\"ls\"}"}`,
+		`null`,
+		`[]`,
+		`{`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var inp Input
+		if err := json.Unmarshal(data, &inp); err != nil {
+			return
+		}
+		_ = inp.Fingerprint()
+		out, err := json.Marshal(inp)
+		if err != nil {
+			t.Fatalf("Marshal parsed input: %v", err)
+		}
+		var again Input
+		if err := json.Unmarshal(out, &again); err != nil {
+			t.Fatalf("re-parse %s: %v", out, err)
+		}
+		if again.HookEventName != inp.HookEventName || again.ToolName != inp.ToolName || again.SessionID != inp.SessionID {
+			t.Fatalf("round trip changed known fields: %+v -> %+v", inp, again)
+		}
+		if _, err := json.Marshal(inp.WithToolInput(json.RawMessage(`{"command":"ls"}`))); err != nil {
+			t.Fatalf("Marshal after WithToolInput: %v", err)
+		}
+	})
+}
+
+func FuzzOutputUnmarshal(f *testing.F) {
+	for _, seed := range []string{
+		`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no"}}`,
+		`{"hookSpecificOutput":{"updatedInput":{"command":"ls"},"additionalContext":"x"}}`,
+		`{"decision":"block","reason":"r","stopReason":"s","hookSpecificOutput":{"updatedPermissions":[]}}`,
+		`{"hookSpecificOutput":null}`,
+		`{"hookSpecificOutput":"deny"}`,
+		`{"continue":false,"suppressOutput":true}`,
+		`null`,
+		`{`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var out Output
+		if err := json.Unmarshal(data, &out); err != nil {
+			return
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			t.Fatalf("Marshal parsed output: %v", err)
+		}
+		var again Output
+		if err := json.Unmarshal(b, &again); err != nil {
+			t.Fatalf("re-parse %s: %v", b, err)
+		}
+		if again.HookSpecificOutput.PermissionDecision != out.HookSpecificOutput.PermissionDecision {
+			t.Fatalf("round trip changed the decision: %s", b)
+		}
+		if string(again.HookSpecificOutput.UpdatedInput) != string(out.HookSpecificOutput.UpdatedInput) {
+			t.Fatalf("round trip changed updatedInput: %s", b)
+		}
+	})
+}
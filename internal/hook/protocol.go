@@ -0,0 +1,133 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Protocol versions recorded by Fingerprint. Claude Code payloads carry no
+// version of their own, so the shape hook-chain was written against is
+// called claude-1.
+const (
+	ProtocolClaude  = "claude-1"
+	ProtocolUnknown = "unknown"
+	// ProtocolAdapterPrefix marks input normalized by an adapter.
+	ProtocolAdapterPrefix = "adapter/"
+)
+
+// coreFields are parsed into Input's struct fields.
+var coreFields = []string{
+	"session_id", "transcript_path", "cwd", "permission_mode",
+	"hook_event_name", "tool_name", "tool_use_id", "tool_input",
+}
+
+// eventFields are event-specific fields Claude Code sends, and fields
+// hook-chain adds itself. They are forwarded without being parsed.
+var eventFields = []string{
+	"tool_response", "prompt", "message", "title", "notification_type",
+	"stop_hook_active", "trigger", "custom_instructions", "source", "reason",
+	"model", "agent", "recent_activity", "diff", "hook_chain",
+}
+
+// versionFields may declare the payload's protocol version explicitly.
+var versionFields = []string{"protocol_version", "schema_version"}
+
+// toolEvents must name a tool.
+var toolEvents = []string{"PreToolUse", "PostToolUse"}
+
+// Fingerprint describes how well a payload matches the protocol hook-chain
+// parses.
+type Fingerprint struct {
+	// Version is the declared protocol_version or schema_version, then
+	// adapter/<name> for adapted input, then claude-1, or unknown when the
+	// shape has problems.
+	Version string
+	// Unknown lists top-level fields hook-chain does not know. They are
+	// forwarded to hooks unchanged.
+	Unknown []string
+	// Problems describe shape mismatches that risk a silent mis-parse, such
+	// as a known field in a different casing.
+	Problems []string
+}
+
+// Fingerprint inspects the payload Input was parsed from.
+func (inp Input) Fingerprint() Fingerprint {
+	var fp Fingerprint
+
+	// Adapted input keeps the agent's own fields next to the mapped ones,
+	// so only the mapped ones are checked.
+	var agent string
+	if raw, ok := inp.rawFields["agent"]; ok {
+		_ = json.Unmarshal(raw, &agent)
+	}
+	if agent == "" {
+		fp.Unknown, fp.Problems = checkFields(inp.rawFields)
+	}
+	if inp.HookEventName == "" {
+		fp.Problems = append(fp.Problems, "no hook_event_name")
+	} else if slices.Contains(toolEvents, inp.HookEventName) && inp.ToolName == "" {
+		fp.Problems = append(fp.Problems, inp.HookEventName+" without tool_name")
+	}
+
+	declared := declaredVersion(inp.rawFields)
+	if declared != "" && declared != ProtocolClaude {
+		fp.Problems = append(fp.Problems, fmt.Sprintf("declared protocol version %q is not %s", declared, ProtocolClaude))
+	}
+
+	switch {
+	case declared != "":
+		fp.Version = declared
+	case agent != "":
+		fp.Version = ProtocolAdapterPrefix + agent
+	case len(fp.Problems) > 0:
+		fp.Version = ProtocolUnknown
+	default:
+		fp.Version = ProtocolClaude
+	}
+	return fp
+}
+
+// checkFields sorts top-level fields hook-chain does not know into unknown
+// ones and known ones in a different casing.
+func checkFields(raw map[string]json.RawMessage) (unknown, problems []string) {
+	known := map[string]string{} // normalized name → field
+	for _, f := range slices.Concat(coreFields, eventFields, versionFields) {
+		known[normalizeField(f)] = f
+	}
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		field, ok := known[normalizeField(key)]
+		switch {
+		case !ok:
+			unknown = append(unknown, key)
+		case field != key:
+			problems = append(problems, fmt.Sprintf("field %q looks like %q in a different casing", key, field))
+		}
+	}
+	return unknown, problems
+}
+
+// declaredVersion returns an explicit protocol version field, if any.
+func declaredVersion(raw map[string]json.RawMessage) string {
+	for _, f := range versionFields {
+		v, ok := raw[f]
+		if !ok {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil && s != "" {
+			return s
+		}
+		// Numeric versions are recorded as written.
+		return strings.Trim(string(v), `"`)
+	}
+	return ""
+}
+
+// normalizeField folds casing and separators: "sessionId", "SessionID", and
+// "session-id" all become "sessionid".
+func normalizeField(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+}
@@ -146,6 +146,7 @@ func (inp Input) MarshalJSON() ([]byte, error) {
 
 // WithToolInput returns a copy of the Input with ToolInput replaced.
 // The copy shares the same rawFields reference but updates the tool_input key.
+// An empty merged leaves tool_input out, as for events that carry no tool.
 func (inp Input) WithToolInput(merged json.RawMessage) Input {
 	cp := inp
 
@@ -154,8 +155,22 @@ func (inp Input) WithToolInput(merged json.RawMessage) Input {
 	maps.Copy(cp.rawFields, inp.rawFields)
 
 	cp.ToolInput = merged
-	cp.rawFields["tool_input"] = merged
+	if len(merged) == 0 {
+		delete(cp.rawFields, "tool_input")
+	} else {
+		cp.rawFields["tool_input"] = merged
+	}
+
+	return cp
+}
 
+// WithField returns a copy of the Input with an extra top-level field set.
+// Known fields set on the struct take precedence over a key of the same name.
+func (inp Input) WithField(key string, value json.RawMessage) Input {
+	cp := inp
+	cp.rawFields = make(map[string]json.RawMessage, len(inp.rawFields)+1)
+	maps.Copy(cp.rawFields, inp.rawFields)
+	cp.rawFields[key] = value
 	return cp
 }
 
@@ -166,6 +181,26 @@ type HookSpecificOutput struct {
 	PermissionDecisionReason string          `json:"permissionDecisionReason,omitempty"`
 	UpdatedInput             json.RawMessage `json:"updatedInput,omitempty"`
 	AdditionalContext        string          `json:"additionalContext,omitempty"`
+	RuleID                   string          `json:"ruleId,omitempty"`
+	// Severity grades the decision: info, warn, high, or critical. Chains
+	// can map severities to actions; it is recorded in the audit log and
+	// never forwarded.
+	Severity string `json:"severity,omitempty"`
+	// RiskScore rates how risky the tool call is, on a scale chains set
+	// thresholds in (conventionally 0 to 1). Chains combine the scores of
+	// their hooks and map them to a decision; scores are recorded in the
+	// audit log and never forwarded.
+	RiskScore *float64 `json:"riskScore,omitempty"`
+	// Metadata is an arbitrary object (rule IDs, scores, matched patterns)
+	// recorded with the hook's audit result. It is never forwarded.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	// TranscriptNote is appended to the session's transcript notes when
+	// they are enabled. It is never forwarded.
+	TranscriptNote string `json:"transcriptNote,omitempty"`
+
+	// Extra holds fields hook-chain does not know, preserved so newer
+	// protocol fields can be forwarded.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Output represents the JSON payload a hook writes to stdout.
@@ -174,4 +209,96 @@ type Output struct {
 	Continue           *bool              `json:"continue,omitempty"`
 	SuppressOutput     *bool              `json:"suppressOutput,omitempty"`
 	SystemMessage      string             `json:"systemMessage,omitempty"`
+
+	// Extra holds top-level fields hook-chain does not know (see
+	// HookSpecificOutput.Extra).
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// specificFields and outputFields are the keys HookSpecificOutput and Output
+// parse; anything else lands in Extra.
+var (
+	specificFields = []string{
+		"hookEventName", "permissionDecision", "permissionDecisionReason", "updatedInput",
+		"additionalContext", "ruleId", "severity", "riskScore", "metadata", "transcriptNote",
+	}
+	outputFields = []string{"hookSpecificOutput", "continue", "suppressOutput", "systemMessage"}
+)
+
+// UnmarshalJSON implements custom unmarshaling that preserves unknown fields.
+func (h *HookSpecificOutput) UnmarshalJSON(data []byte) error {
+	type plain HookSpecificOutput
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, specificFields)
+	if err != nil {
+		return fmt.Errorf("hook.HookSpecificOutput unmarshal: %w", err)
+	}
+	*h = HookSpecificOutput(p)
+	h.Extra = extra
+	return nil
+}
+
+// MarshalJSON implements custom marshaling that includes unknown fields.
+func (h HookSpecificOutput) MarshalJSON() ([]byte, error) {
+	type plain HookSpecificOutput
+	return withExtra(plain(h), h.Extra)
+}
+
+// UnmarshalJSON implements custom unmarshaling that preserves unknown fields.
+func (o *Output) UnmarshalJSON(data []byte) error {
+	type plain Output
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, outputFields)
+	if err != nil {
+		return fmt.Errorf("hook.Output unmarshal: %w", err)
+	}
+	*o = Output(p)
+	o.Extra = extra
+	return nil
+}
+
+// MarshalJSON implements custom marshaling that includes unknown fields.
+func (o Output) MarshalJSON() ([]byte, error) {
+	type plain Output
+	return withExtra(plain(o), o.Extra)
+}
+
+// extraFields returns the fields of the JSON object data not named in known,
+// or nil if there are none.
+func extraFields(data []byte, known []string) (map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		delete(raw, k)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// withExtra marshals v and adds the extra fields it does not already set.
+func withExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	for k, raw := range extra {
+		if _, ok := out[k]; !ok {
+			out[k] = raw
+		}
+	}
+	return json.Marshal(out)
 }
@@ -2,6 +2,8 @@ package hook
 
 import (
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -108,6 +110,57 @@ func TestWithToolInput(t *testing.T) {
 	}
 }
 
+func TestWithToolInputEmpty(t *testing.T) {
+	var inp Input
+	if err := json.Unmarshal([]byte(`{"hook_event_name":"SessionEnd","session_id":"abc"}`), &inp); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	out, err := json.Marshal(inp.WithToolInput(inp.ToolInput))
+	if err != nil {
+		t.Fatalf("Marshal copy: %v", err)
+	}
+	var cpMap map[string]json.RawMessage
+	if err := json.Unmarshal(out, &cpMap); err != nil {
+		t.Fatalf("Unmarshal copy map: %v", err)
+	}
+	if v, ok := cpMap["tool_input"]; ok {
+		t.Errorf("tool_input = %s, want omitted for a tool-less event", v)
+	}
+}
+
+func TestWithField(t *testing.T) {
+	var inp Input
+	if err := json.Unmarshal([]byte(`{"session_id":"abc","extraField":true}`), &inp); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	cp := inp.WithField("hook_chain", json.RawMessage(`{"outcome":"deny"}`))
+
+	out, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("Marshal copy: %v", err)
+	}
+	var cpMap map[string]json.RawMessage
+	if err := json.Unmarshal(out, &cpMap); err != nil {
+		t.Fatalf("Unmarshal copy map: %v", err)
+	}
+	if string(cpMap["hook_chain"]) != `{"outcome":"deny"}` {
+		t.Errorf("hook_chain = %s, want the added field", cpMap["hook_chain"])
+	}
+	if _, ok := cpMap["extraField"]; !ok {
+		t.Error("extraField lost in WithField copy")
+	}
+
+	orig, err := json.Marshal(inp)
+	if err != nil {
+		t.Fatalf("Marshal original: %v", err)
+	}
+	if strings.Contains(string(orig), "hook_chain") {
+		t.Errorf("original mutated: %s", orig)
+	}
+}
+
 func TestOutputMarshal(t *testing.T) {
 	cont := true
 	out := Output{
@@ -136,3 +189,86 @@ func TestOutputMarshal(t *testing.T) {
 		t.Error("Continue should be true")
 	}
 }
+
+func TestOutputExtraRoundTrip(t *testing.T) {
+	data := []byte(`{"hookSpecificOutput":{"permissionDecision":"ask","updatedPermissions":[{"tool":"Bash"}]},"stopReason":"later","systemMessage":"hi"}`)
+	var out Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got := string(out.Extra["stopReason"]); got != `"later"` {
+		t.Errorf("Extra[stopReason] = %s, want \"later\"", got)
+	}
+	if got := string(out.HookSpecificOutput.Extra["updatedPermissions"]); got != `[{"tool":"Bash"}]` {
+		t.Errorf("HookSpecificOutput.Extra[updatedPermissions] = %s", got)
+	}
+	if _, ok := out.Extra["systemMessage"]; ok {
+		t.Error("known field systemMessage kept in Extra")
+	}
+
+	again, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var a, b any
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(again, &b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("round trip = %s, want %s", again, data)
+	}
+
+	// Known fields win over extras of the same name.
+	out = Output{SystemMessage: "real", Extra: map[string]json.RawMessage{"systemMessage": json.RawMessage(`"fake"`)}}
+	got, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(got), `"systemMessage":"real"`) {
+		t.Errorf("Marshal = %s, want the struct's systemMessage", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		version      string
+		unknown      []string
+		problemMatch string
+	}{
+		{"claude", `{"hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{},"session_id":"s"}`, ProtocolClaude, nil, ""},
+		{"event fields", `{"hook_event_name":"PostToolUse","tool_name":"Bash","tool_response":{},"recent_activity":[]}`, ProtocolClaude, nil, ""},
+		{"unknown field", `{"hook_event_name":"Stop","stop_hook_active":true,"effort":"high"}`, ProtocolClaude, []string{"effort"}, ""},
+		{"camel case", `{"hookEventName":"PreToolUse","toolName":"Bash"}`, ProtocolUnknown, nil, `"hookEventName" looks like "hook_event_name"`},
+		{"tool event without tool", `{"hook_event_name":"PreToolUse"}`, ProtocolUnknown, nil, "PreToolUse without tool_name"},
+		{"declared", `{"hook_event_name":"Stop","protocol_version":"claude-1"}`, "claude-1", nil, ""},
+		{"declared newer", `{"hook_event_name":"Stop","schema_version":2}`, "2", nil, `declared protocol version "2"`},
+		{"adapter", `{"hook_event_name":"PreToolUse","tool_name":"Bash","agent":"acme","acmeVersion":3}`, "adapter/acme", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inp Input
+			if err := json.Unmarshal([]byte(tt.payload), &inp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			fp := inp.Fingerprint()
+			if fp.Version != tt.version {
+				t.Errorf("Version = %q, want %q", fp.Version, tt.version)
+			}
+			if !reflect.DeepEqual(fp.Unknown, tt.unknown) {
+				t.Errorf("Unknown = %v, want %v", fp.Unknown, tt.unknown)
+			}
+			problems := strings.Join(fp.Problems, "\n")
+			if tt.problemMatch == "" && problems != "" {
+				t.Errorf("Problems = %q, want none", problems)
+			}
+			if tt.problemMatch != "" && !strings.Contains(problems, tt.problemMatch) {
+				t.Errorf("Problems = %q, want %q", problems, tt.problemMatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package hookdir resolves hook commands against the managed hooks
+// directory, where hook executables can be installed once and referenced by
+// bare name from any config, wherever hook-chain itself was installed.
+package hookdir
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// Dir returns the managed hooks directory.
+// It checks $HOOK_CHAIN_HOOKS_DIR, then $XDG_DATA_HOME/hook-chain/hooks,
+// then falls back to ~/.local/share/hook-chain/hooks.
+func Dir() string {
+	if p := os.Getenv("HOOK_CHAIN_HOOKS_DIR"); p != "" {
+		return p
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "hooks")
+}
+
+// Command returns the path of name in the hooks directory when name is a
+// bare command name with an executable there, and name unchanged otherwise.
+func Command(name string) string {
+	if strings.ContainsAny(name, `/\`) || name == "" {
+		return name
+	}
+	p := filepath.Join(Dir(), name)
+	if !isExecutable(p) {
+		return name
+	}
+	return p
+}
+
+// LookPath resolves a command name like exec.LookPath, trying the hooks
+// directory before $PATH.
+func LookPath(name string) (string, error) {
+	if p := Command(name); p != name {
+		return p, nil
+	}
+	return exec.LookPath(name)
+}
+
+// PathEnv returns a PATH entry with the hooks directory prepended, for
+// hooks whose command line is resolved by a shell. It is "" when the
+// directory does not exist.
+func PathEnv() string {
+	dir := Dir()
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return "PATH=" + dir + string(os.PathListSeparator) + os.Getenv("PATH")
+}
+
+// Orphans returns the executables in the hooks directory that no hook of
+// cfg runs, sorted by name. A missing directory has none.
+func Orphans(cfg config.Config) ([]string, error) {
+	dir := Dir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for _, chain := range cfg.Chains {
+		for _, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			commands := append([]string{h.Command}, h.Variants...)
+			for _, c := range commands {
+				words := pathutil.Fields(c)
+				if h.EffectiveType() == config.HookTypeProcess && len(words) > 0 {
+					// Only the first word of a process hook is run.
+					words = words[:1]
+				}
+				for _, w := range words {
+					if filepath.Dir(w) == "." || filepath.Clean(filepath.Dir(w)) == filepath.Clean(dir) {
+						used[filepath.Base(w)] = true
+					}
+				}
+			}
+		}
+	}
+
+	var orphans []string
+	for _, e := range entries {
+		if !used[e.Name()] && isExecutable(filepath.Join(dir, e.Name())) {
+			orphans = append(orphans, e.Name())
+		}
+	}
+	return orphans, nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0
+}
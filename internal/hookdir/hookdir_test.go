@@ -0,0 +1,108 @@
+package hookdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func writeExec(t *testing.T, dir, name string, mode os.FileMode) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte("#!/bin/sh\nexit 0\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", "")
+	t.Setenv("XDG_DATA_HOME", "/xdg")
+	if got, want := Dir(), filepath.Join("/xdg", "hook-chain", "hooks"); got != want {
+		t.Errorf("Dir = %q, want %q", got, want)
+	}
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", "/managed")
+	if got := Dir(); got != "/managed" {
+		t.Errorf("Dir with override = %q, want /managed", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", dir)
+	scan := writeExec(t, dir, "secret-scan", 0o755)
+	writeExec(t, dir, "not-exec", 0o644)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"secret-scan", scan},
+		{"not-exec", "not-exec"},
+		{"missing", "missing"},
+		{"./secret-scan", "./secret-scan"},
+		{"/usr/bin/secret-scan", "/usr/bin/secret-scan"},
+	}
+	for _, tt := range tests {
+		if got := Command(tt.name); got != tt.want {
+			t.Errorf("Command(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if p, err := LookPath("secret-scan"); err != nil || p != scan {
+		t.Errorf("LookPath = %q, %v; want %q", p, err, scan)
+	}
+	if _, err := LookPath("hook-chain-no-such-hook"); err == nil {
+		t.Error("LookPath of a missing command: want error")
+	}
+}
+
+func TestPathEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", dir)
+	t.Setenv("PATH", "/usr/bin")
+	if got, want := PathEnv(), "PATH="+dir+string(os.PathListSeparator)+"/usr/bin"; got != want {
+		t.Errorf("PathEnv = %q, want %q", got, want)
+	}
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", filepath.Join(dir, "missing"))
+	if got := PathEnv(); got != "" {
+		t.Errorf("PathEnv without the directory = %q, want empty", got)
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", dir)
+	for _, name := range []string{"secret-scan", "by-path", "guard-v2", "in-shell", "unused-b", "unused-a"} {
+		writeExec(t, dir, name, 0o755)
+	}
+	writeExec(t, dir, "README", 0o644)
+
+	cfg := config.Config{Chains: []config.ChainEntry{{
+		Hooks: []config.HookEntry{
+			{Name: "scan", Command: "secret-scan --strict"},
+			{Name: "path", Command: filepath.Join(dir, "by-path")},
+			{Name: "ab", Variants: []string{"guard-v1", "guard-v2"}},
+			{Name: "arg", Command: "sh unused-b"},
+		},
+		Finally: []config.HookEntry{
+			{Name: "sh", Type: config.HookTypeShell, Command: "in-shell | tee /dev/null"},
+		},
+	}}}
+
+	orphans, err := Orphans(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(orphans, ","); got != "unused-a,unused-b" {
+		t.Errorf("Orphans = %s, want unused-a,unused-b", got)
+	}
+
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", filepath.Join(dir, "missing"))
+	if orphans, err := Orphans(cfg); err != nil || orphans != nil {
+		t.Errorf("missing directory: %v, %v; want none", orphans, err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package hooklint statically inspects configured hook commands and the
+// scripts behind them for problems that would only surface as a deny in a
+// live session: missing or non-executable commands, scripts without a
+// shebang or with a missing interpreter, scripts that never read stdin, and
+// tools such as jq or python3 that a script uses but PATH lacks.
+package hooklint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// Finding severities. Errors are expected to fail the hook at run time;
+// warnings are likely mistakes.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// maxScriptSize bounds how much of a script is read.
+const maxScriptSize = 1 << 20
+
+// Finding is one problem with a hook.
+type Finding struct {
+	Hook     string `json:"hook"`
+	Command  string `json:"command"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Interpreters are commands that run a script named in their arguments.
+var Interpreters = []string{"sh", "bash", "dash", "zsh", "python", "python3", "node", "deno", "bun", "ruby", "perl"}
+
+// Tools are external commands whose use in a script is checked against PATH.
+var Tools = []string{"jq", "yq", "python", "python3", "node", "ruby", "perl", "curl", "gh", "git"}
+
+// stdinPattern matches the usual ways a script reads its stdin.
+var stdinPattern = regexp.MustCompile(`(?i)stdin|\bcat\b|\bread\b|\bjq\b|\byq\b|\binput\(|fileinput|<>`)
+
+// toolPatterns match a tool used as a command word.
+var toolPatterns = func() map[string]*regexp.Regexp {
+	m := map[string]*regexp.Regexp{}
+	for _, t := range Tools {
+		m[t] = regexp.MustCompile(`(^|[\s;|&(` + "`" + `])` + regexp.QuoteMeta(t) + `(\s|$)`)
+	}
+	return m
+}()
+
+// Linter checks hooks. LookPath resolves command names, as exec.LookPath.
+type Linter struct {
+	LookPath func(file string) (string, error)
+}
+
+// Config checks every hook and finally hook of every chain, once per
+// distinct command.
+func (l Linter) Config(cfg config.Config) []Finding {
+	var findings []Finding
+	seen := map[string]bool{}
+	for _, chain := range cfg.Chains {
+		for _, h := range slices.Concat(chain.Hooks, chain.Finally) {
+			key := h.Name + "\x00" + h.Command + "\x00" + strings.Join(h.Args, "\x00") + "\x00" + strings.Join(h.Variants, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, l.Hook(h)...)
+		}
+	}
+	return findings
+}
+
+// Hook checks one hook. Only process hooks are checked: builtin and http
+// hooks run no script, and a shell hook's command line is shell syntax.
+func (l Linter) Hook(h config.HookEntry) []Finding {
+	if h.EffectiveType() != config.HookTypeProcess {
+		return nil
+	}
+	commands := []string{h.Command}
+	if len(h.Variants) > 0 {
+		commands = h.Variants
+	}
+	var findings []Finding
+	for _, c := range commands {
+		for _, f := range l.command(c, h.Args) {
+			f.Hook, f.Command = h.Name, c
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// command checks one command line and the script it runs.
+func (l Linter) command(command string, extraArgs []string) []Finding {
+	parts := pathutil.Fields(command)
+	if len(parts) == 0 {
+		return []Finding{errorf("empty command")}
+	}
+	args := parts[1:]
+	for _, a := range extraArgs {
+		args = append(args, pathutil.Expand(a))
+	}
+
+	path, err := l.LookPath(parts[0])
+	if err != nil {
+		if info, statErr := os.Stat(parts[0]); statErr == nil && !info.IsDir() && info.Mode()&0o111 == 0 {
+			return []Finding{errorf("%s is not executable (chmod +x %s)", parts[0], parts[0])}
+		}
+		return []Finding{errorf("command %q not found on PATH", parts[0])}
+	}
+
+	// An interpreter runs an inline program or a script file from its args;
+	// neither needs a shebang or the executable bit.
+	if slices.Contains(Interpreters, filepath.Base(parts[0])) {
+		if i := slices.Index(args, "-c"); i >= 0 && i+1 < len(args) {
+			return l.content([]byte(strings.Join(args[i+1:], " ")))
+		}
+		if i := slices.IndexFunc(args, func(a string) bool { return !strings.HasPrefix(a, "-") }); i >= 0 {
+			script := args[i]
+			data, err := readScript(script)
+			if err != nil {
+				return []Finding{errorf("script %s: %v", script, err)}
+			}
+			return l.content(data)
+		}
+		return nil
+	}
+
+	data, err := readScript(path)
+	if err != nil {
+		return []Finding{errorf("read %s: %v", path, err)}
+	}
+	if isBinary(data) {
+		return nil
+	}
+	findings := l.shebang(path, data)
+	return append(findings, l.content(data)...)
+}
+
+// shebang checks the interpreter line of a script that is executed directly.
+func (l Linter) shebang(path string, data []byte) []Finding {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return []Finding{errorf("%s has no #! line; running it fails with \"exec format error\"", path)}
+	}
+	if bytes.HasSuffix(line, []byte("\r")) {
+		return []Finding{errorf("%s has a CRLF #! line; the kernel looks for an interpreter ending in \\r", path)}
+	}
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return []Finding{errorf("%s has an empty #! line", path)}
+	}
+	interp := fields[0]
+	if filepath.Base(interp) == "env" {
+		// #!/usr/bin/env [-S] name
+		names := slices.DeleteFunc(fields[1:], func(f string) bool { return strings.HasPrefix(f, "-") })
+		if len(names) == 0 {
+			return []Finding{errorf("%s: #!%s names no interpreter", path, interp)}
+		}
+		if _, err := l.LookPath(names[0]); err != nil {
+			return []Finding{errorf("%s: interpreter %q is not on PATH", path, names[0])}
+		}
+		return nil
+	}
+	info, err := os.Stat(interp)
+	switch {
+	case err != nil:
+		return []Finding{errorf("%s: interpreter %s does not exist", path, interp)}
+	case info.Mode()&0o111 == 0:
+		return []Finding{errorf("%s: interpreter %s is not executable", path, interp)}
+	}
+	return nil
+}
+
+// content checks the body of a script or inline program.
+func (l Linter) content(data []byte) []Finding {
+	var findings []Finding
+	var code strings.Builder
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), maxScriptSize)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		code.WriteString(line)
+		code.WriteByte('\n')
+	}
+	body := code.String()
+
+	if !stdinPattern.MatchString(body) {
+		findings = append(findings, warningf("never appears to read stdin, so it does not see the tool call"))
+	}
+	for _, t := range Tools {
+		if !toolPatterns[t].MatchString(body) {
+			continue
+		}
+		if _, err := l.LookPath(t); err != nil {
+			findings = append(findings, errorf("uses %s, which is not on PATH", t))
+		}
+	}
+	if bytes.Contains(data, []byte("\r\n")) {
+		findings = append(findings, warningf("has CRLF line endings"))
+	}
+	return findings
+}
+
+// readScript reads up to maxScriptSize bytes of a script.
+func readScript(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.New("does not exist")
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(io.LimitReader(f, maxScriptSize))
+}
+
+// isBinary reports whether data looks like a compiled executable rather than
+// a script.
+func isBinary(data []byte) bool {
+	head := data[:min(len(data), 512)]
+	return bytes.IndexByte(head, 0) >= 0
+}
+
+func errorf(format string, args ...any) Finding {
+	return Finding{Severity: SeverityError, Message: fmt.Sprintf(format, args...)}
+}
+
+func warningf(format string, args ...any) Finding {
+	return Finding{Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)}
+}
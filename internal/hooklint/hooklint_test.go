@@ -0,0 +1,96 @@
+package hooklint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func TestHook(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	good := write("good.sh", "#!/bin/sh\ninput=$(cat)\necho \"$input\" | jq -r .tool_name\n", 0o755)
+	noShebang := write("noshebang.sh", "cat\n", 0o755)
+	crlf := write("crlf.sh", "#!/bin/sh\r\ncat\r\n", 0o755)
+	missingInterp := write("py.sh", "#!/usr/bin/env python9\nimport sys; sys.stdin.read()\n", 0o755)
+	noStdin := write("nostdin.sh", "#!/bin/sh\n# cat is only mentioned here\necho ok\n", 0o755)
+	usesYq := write("yq.sh", "#!/bin/sh\ncat | yq .tool_input\n", 0o755)
+	notExec := write("notexec.sh", "#!/bin/sh\ncat\n", 0o644)
+	binary := write("bin", "\x7fELF\x00\x00", 0o755)
+	pyScript := write("check.py", "import json, sys\njson.load(sys.stdin)\n", 0o644)
+
+	onPath := map[string]string{"sh": "/bin/sh", "jq": "/usr/bin/jq", "python3": "/usr/bin/python3"}
+	l := Linter{LookPath: func(file string) (string, error) {
+		if strings.Contains(file, "/") {
+			info, err := os.Stat(file)
+			if err != nil || info.Mode()&0o111 == 0 {
+				return "", errors.New("not executable")
+			}
+			return file, nil
+		}
+		if p, ok := onPath[file]; ok {
+			return p, nil
+		}
+		return "", errors.New("not found")
+	}}
+
+	tests := []struct {
+		name string
+		hook config.HookEntry
+		want []string // "severity: message substring"
+	}{
+		{"good script", config.HookEntry{Command: good}, nil},
+		{"builtin", config.HookEntry{Builtin: "command-guard"}, nil},
+		{"binary", config.HookEntry{Command: binary}, nil},
+		{"missing command", config.HookEntry{Command: "nope --flag"}, []string{"error: not found on PATH"}},
+		{"not executable", config.HookEntry{Command: notExec}, []string{"error: not executable"}},
+		{"no shebang", config.HookEntry{Command: noShebang}, []string{"error: no #! line"}},
+		{"crlf shebang", config.HookEntry{Command: crlf}, []string{"error: CRLF #! line", "warning: CRLF line endings"}},
+		{"missing interpreter", config.HookEntry{Command: missingInterp}, []string{`error: interpreter "python9"`}},
+		{"no stdin", config.HookEntry{Command: noStdin}, []string{"warning: never appears to read stdin"}},
+		{"missing tool", config.HookEntry{Command: usesYq}, []string{"error: uses yq"}},
+		{"interpreter script", config.HookEntry{Command: "python3", Args: []string{"-u", pyScript}}, nil},
+		{"interpreter missing script", config.HookEntry{Command: "python3 " + filepath.Join(dir, "gone.py")}, []string{"error: does not exist"}},
+		{"inline reads stdin", config.HookEntry{Command: "sh", Args: []string{"-c", "cat >/dev/null; exit 0"}}, nil},
+		{"inline ignores stdin", config.HookEntry{Command: "sh", Args: []string{"-c", "echo hi"}}, []string{"warning: never appears to read stdin"}},
+		{"variants", config.HookEntry{Variants: []string{good, "nope"}}, []string{"error: not found on PATH"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.hook.Name = "h"
+			got := l.Hook(tt.hook)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findings = %+v, want %v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				sev, msg, _ := strings.Cut(w, ": ")
+				if got[i].Severity != sev || !strings.Contains(got[i].Message, msg) || got[i].Hook != "h" {
+					t.Errorf("finding %d = %+v, want %s", i, got[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigDeduplicates(t *testing.T) {
+	h := config.HookEntry{Name: "missing", Command: "nope"}
+	cfg := config.Config{Chains: []config.ChainEntry{
+		{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []config.HookEntry{h}},
+		{Event: "PreToolUse", Tools: []string{"Write"}, Hooks: []config.HookEntry{h}, Finally: []config.HookEntry{{Name: "other", Command: "nope"}}},
+	}}
+	l := Linter{LookPath: func(string) (string, error) { return "", errors.New("not found") }}
+	if got := l.Config(cfg); len(got) != 2 {
+		t.Errorf("findings = %+v, want one per distinct hook", got)
+	}
+}
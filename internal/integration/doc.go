@@ -0,0 +1,5 @@
+// Package integration runs the scenarios in testdata/scenarios against the
+// chains in testdata/config.yaml through the real pipeline, with hooks
+// answered by the scripted scenario runner. Its tests double as worked
+// examples of the scenario format used by `hook-chain test`.
+package integration
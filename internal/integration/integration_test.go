@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/scenario"
+)
+
+func TestScenarios(t *testing.T) {
+	cfg, err := config.LoadFrom(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no scenario files")
+	}
+	for _, file := range files {
+		scenarios, err := scenario.LoadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sc := range scenarios {
+			t.Run(sc.Name, func(t *testing.T) {
+				o, err := scenario.Run(context.Background(), sc, cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fails := sc.Check(o); len(fails) > 0 {
+					t.Errorf("%s\noutput: %s", strings.Join(fails, "\n"), o.Output)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package kv is a small SQLite-backed key-value store that hooks use to keep
+// per-session state (counters, prior approvals) across invocations. Keys are
+// scoped by session and by hook, and a session's keys are deleted when the
+// session ends.
+package kv
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// MaxValueLen bounds the size of a stored value.
+const MaxValueLen = 64 << 10
+
+const schema = `
+CREATE TABLE IF NOT EXISTS kv (
+    session_id TEXT NOT NULL,
+    scope      TEXT NOT NULL,
+    key        TEXT NOT NULL,
+    value      TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (session_id, scope, key)
+);
+CREATE INDEX IF NOT EXISTS idx_kv_updated ON kv(updated_at);
+`
+
+const timeLayout = "2006-01-02T15:04:05.000"
+
+// Entry is one stored key.
+type Entry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store is an open key-value database.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath returns the store location: $HOOK_CHAIN_KV_DB, or kv.db under
+// $XDG_DATA_HOME/hook-chain (default ~/.local/share/hook-chain).
+func DefaultPath() string {
+	if p := os.Getenv("HOOK_CHAIN_KV_DB"); p != "" {
+		return p
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "kv.db")
+}
+
+// Open opens (or creates) the store at path with WAL mode and a 5-second
+// busy timeout, since several hooks may write concurrently.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("kv: create directory for %q: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("kv: open database %q: %w", path, err)
+	}
+	for _, stmt := range []string{"PRAGMA journal_mode=WAL", "PRAGMA busy_timeout=5000", schema} {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("kv: init %q: %w", path, err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database. Nil receiver is a no-op.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("kv: close: %w", err)
+	}
+	return nil
+}
+
+// Get returns the value of key, and whether it exists.
+func (s *Store) Get(session, scope, key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM kv WHERE session_id = ? AND scope = ? AND key = ?", session, scope, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("kv: get %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, replacing any previous value.
+func (s *Store) Set(session, scope, key, value string) error {
+	if err := checkKey(session, key); err != nil {
+		return err
+	}
+	if len(value) > MaxValueLen {
+		return fmt.Errorf("kv: value for %q is %d bytes, over the %d byte limit", key, len(value), MaxValueLen)
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO kv (session_id, scope, key, value, updated_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (session_id, scope, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		session, scope, key, value, now(),
+	)
+	if err != nil {
+		return fmt.Errorf("kv: set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Incr adds delta to the integer stored under key (a missing key counts as
+// 0) and returns the new value. It fails if the stored value is not an
+// integer.
+func (s *Store) Incr(session, scope, key string, delta int64) (int64, error) {
+	if err := checkKey(session, key); err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("kv: incr %q: begin: %w", key, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var n int64
+	var cur string
+	err = tx.QueryRow("SELECT value FROM kv WHERE session_id = ? AND scope = ? AND key = ?", session, scope, key).Scan(&cur)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return 0, fmt.Errorf("kv: incr %q: %w", key, err)
+	default:
+		if n, err = strconv.ParseInt(cur, 10, 64); err != nil {
+			return 0, fmt.Errorf("kv: incr %q: value %q is not an integer", key, cur)
+		}
+	}
+	n += delta
+
+	_, err = tx.Exec(
+		`INSERT INTO kv (session_id, scope, key, value, updated_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (session_id, scope, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		session, scope, key, strconv.FormatInt(n, 10), now(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("kv: incr %q: %w", key, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("kv: incr %q: commit: %w", key, err)
+	}
+	return n, nil
+}
+
+// Delete removes key and reports whether it existed.
+func (s *Store) Delete(session, scope, key string) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM kv WHERE session_id = ? AND scope = ? AND key = ?", session, scope, key)
+	if err != nil {
+		return false, fmt.Errorf("kv: delete %q: %w", key, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("kv: delete %q: %w", key, err)
+	}
+	return n > 0, nil
+}
+
+// List returns every key in the session and scope, ordered by key.
+func (s *Store) List(session, scope string) ([]Entry, error) {
+	rows, err := s.db.Query("SELECT key, value, updated_at FROM kv WHERE session_id = ? AND scope = ? ORDER BY key", session, scope)
+	if err != nil {
+		return nil, fmt.Errorf("kv: list: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var ts string
+		if err := rows.Scan(&e.Key, &e.Value, &ts); err != nil {
+			return nil, fmt.Errorf("kv: scan entry: %w", err)
+		}
+		if e.UpdatedAt, err = time.Parse(timeLayout, ts); err != nil {
+			return nil, fmt.Errorf("kv: parse timestamp %q: %w", ts, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("kv: iterate entries: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteSession removes every key of the session, in all scopes, and
+// returns how many were deleted.
+func (s *Store) DeleteSession(session string) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM kv WHERE session_id = ?", session)
+	if err != nil {
+		return 0, fmt.Errorf("kv: delete session %q: %w", session, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("kv: delete session %q: %w", session, err)
+	}
+	return n, nil
+}
+
+// PruneIdle removes the keys of sessions with no write since before, for
+// sessions that never reported their end. It returns how many were deleted.
+func (s *Store) PruneIdle(before time.Time) (int64, error) {
+	res, err := s.db.Exec(
+		`DELETE FROM kv WHERE session_id IN (
+			SELECT session_id FROM kv GROUP BY session_id HAVING MAX(updated_at) < ?)`,
+		before.UTC().Format(timeLayout),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("kv: prune idle sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("kv: prune idle sessions: %w", err)
+	}
+	return n, nil
+}
+
+func checkKey(session, key string) error {
+	if session == "" {
+		return fmt.Errorf("kv: no session ID")
+	}
+	if key == "" {
+		return fmt.Errorf("kv: empty key")
+	}
+	return nil
+}
+
+func now() string {
+	return time.Now().UTC().Format(timeLayout)
+}
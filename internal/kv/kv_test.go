@@ -0,0 +1,158 @@
+package kv
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "kv.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSetGetDelete(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.Set("s1", "guard", "approved", "rm /tmp/x"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set("s1", "guard", "approved", "rm /tmp/y"); err != nil {
+		t.Fatalf("Set (replace): %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		session string
+		scope   string
+		want    string
+		wantOK  bool
+	}{
+		{"same session and scope", "s1", "guard", "rm /tmp/y", true},
+		{"other scope", "s1", "lint", "", false},
+		{"other session", "s2", "guard", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := s.Get(tt.session, tt.scope, "approved")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Get = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if ok, err := s.Delete("s1", "guard", "approved"); err != nil || !ok {
+		t.Errorf("Delete = %v, %v; want true, nil", ok, err)
+	}
+	if ok, err := s.Delete("s1", "guard", "approved"); err != nil || ok {
+		t.Errorf("Delete (again) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	s := openTestStore(t)
+	tests := []struct {
+		name    string
+		session string
+		key     string
+		value   string
+	}{
+		{"no session", "", "k", "v"},
+		{"empty key", "s1", "", "v"},
+		{"value too large", "s1", "k", strings.Repeat("x", MaxValueLen+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Set(tt.session, "", tt.key, tt.value); err == nil {
+				t.Error("Set err = nil, want error")
+			}
+		})
+	}
+}
+
+func TestIncr(t *testing.T) {
+	s := openTestStore(t)
+	for i, want := range []int64{1, 2, 5} {
+		delta := int64(1)
+		if i == 2 {
+			delta = 3
+		}
+		got, err := s.Incr("s1", "guard", "denies", delta)
+		if err != nil {
+			t.Fatalf("Incr: %v", err)
+		}
+		if got != want {
+			t.Errorf("Incr #%d = %d, want %d", i, got, want)
+		}
+	}
+
+	if err := s.Set("s1", "guard", "name", "bob"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := s.Incr("s1", "guard", "name", 1); err == nil {
+		t.Error("Incr(non-integer) err = nil, want error")
+	}
+}
+
+func TestListAndSessionCleanup(t *testing.T) {
+	s := openTestStore(t)
+	for _, kv := range [][3]string{{"s1", "guard", "b"}, {"s1", "guard", "a"}, {"s1", "lint", "c"}, {"s2", "guard", "d"}} {
+		if err := s.Set(kv[0], kv[1], kv[2], "v"); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	entries, err := s.List("s1", "guard")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Key != "a" || entries[1].Key != "b" || entries[0].UpdatedAt.IsZero() {
+		t.Errorf("List = %+v, want keys a, b with timestamps", entries)
+	}
+
+	if n, err := s.DeleteSession("s1"); err != nil || n != 3 {
+		t.Errorf("DeleteSession = %d, %v; want 3, nil", n, err)
+	}
+	if _, ok, _ := s.Get("s2", "guard", "d"); !ok {
+		t.Error("DeleteSession removed another session's key")
+	}
+}
+
+func TestPruneIdle(t *testing.T) {
+	s := openTestStore(t)
+	for _, session := range []string{"old", "active"} {
+		for _, key := range []string{"a", "b"} {
+			if err := s.Set(session, "", key, "v"); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+		}
+	}
+	old := time.Now().Add(-10 * 24 * time.Hour).UTC().Format(timeLayout)
+	if _, err := s.db.Exec("UPDATE kv SET updated_at = ? WHERE session_id = 'old'", old); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+	// One stale key in an otherwise active session keeps the session.
+	if _, err := s.db.Exec("UPDATE kv SET updated_at = ? WHERE session_id = 'active' AND key = 'a'", old); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+
+	n, err := s.PruneIdle(time.Now().Add(-7 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PruneIdle: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("PruneIdle = %d, want 2", n)
+	}
+	if entries, _ := s.List("active", ""); len(entries) != 2 {
+		t.Errorf("active session entries = %d, want 2", len(entries))
+	}
+}
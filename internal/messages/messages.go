@@ -0,0 +1,160 @@
+// Package messages renders the user-facing text hook-chain generates itself
+// (deny and ask framing) from text/template strings, so organizations can
+// localize or rephrase it via the config's messages map.
+package messages
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Message keys accepted in the config's messages map.
+const (
+	HookDenied       = "hook_denied"       // hook exited 2 without stderr
+	HookFailed       = "hook_failed"       // hook exited non-zero without stderr
+	CommandNotFound  = "command_not_found" // hook command missing
+	PermissionDenied = "permission_denied" // hook command not executable
+	HookTimeout      = "hook_timeout"      // hook exceeded its timeout
+	RunnerError      = "runner_error"      // any other runner failure
+	InvalidJSON      = "invalid_json"      // hook stdout was not valid JSON
+	MergeFailed      = "merge_failed"      // updatedInput could not be merged
+	ProtectedField   = "protected_field"   // updatedInput changed a protected tool_input field
+	QuotaExceeded    = "quota_exceeded"    // a hook or chain ran out of its quota
+	RiskScore        = "risk_score"        // the chain's combined riskScore reached a risk threshold
+	MaxDuration      = "max_duration"      // the chain ran past its max_duration before a hook could start
+	RuleReason       = "rule_reason"       // reason of a decision that carries a ruleId
+	RunbookReason    = "runbook_reason"    // deny reason with a runbook link appended
+	RunbookSystem    = "runbook_system"    // systemMessage shown alongside a deny with a runbook
+	ExceptionApplied = "exception_applied" // warning shown when an exception waives a deny
+)
+
+// Data is the value every template is executed with.
+type Data struct {
+	Hook      string // hook name
+	Command   string // hook command
+	Event     string // hook event name, e.g. PreToolUse
+	Tool      string // tool name
+	ExitCode  int
+	Signal    string // signal that killed the hook, e.g. SIGKILL (HookFailed only)
+	Error     string // runner or parse error text
+	Reason    string // the decision reason (RuleReason, Runbook*)
+	RuleID    string // the hook's ruleId (RuleReason, Runbook*)
+	URL       string // runbook link (Runbook* only)
+	Fields    string // protected fields the hook tried to change (ProtectedField only)
+	Quota     string // the quota, e.g. "30 per 1h"; Hook is "" for a chain quota (QuotaExceeded only)
+	Score     string // combined risk score; Hook is the top scorer (RiskScore only)
+	Threshold string // the threshold the score reached (RiskScore only)
+	Limit     string // the chain's max_duration; Hook is the first hook not run (MaxDuration only)
+	Elapsed   string // how long the chain had run (MaxDuration only)
+
+	Exception       string // exception ID (ExceptionApplied only)
+	ExceptionReason string // reason recorded with the exception (ExceptionApplied only)
+}
+
+// defaults is the built-in English phrasing.
+var defaults = map[string]string{
+	HookDenied:       `hook "{{.Hook}}" denied (exit 2)`,
+	HookFailed:       `hook "{{.Hook}}" failed ({{if .Signal}}killed by {{.Signal}}{{else}}exit {{.ExitCode}}{{end}})`,
+	CommandNotFound:  `hook-chain: hook "{{.Hook}}" could not start: command "{{.Command}}" not found`,
+	PermissionDenied: `hook-chain: hook "{{.Hook}}" could not start: permission denied executing "{{.Command}}"`,
+	HookTimeout:      `hook-chain: hook "{{.Hook}}" timed out: {{.Error}}`,
+	RunnerError:      `hook-chain: hook "{{.Hook}}" failed: {{.Error}}`,
+	InvalidJSON:      `hook-chain: hook "{{.Hook}}" returned invalid JSON: {{.Error}}`,
+	MergeFailed:      `hook-chain: failed to merge updatedInput from hook "{{.Hook}}": {{.Error}}`,
+	ProtectedField:   `hook-chain: hook "{{.Hook}}" tried to change protected tool_input fields: {{.Fields}}`,
+	QuotaExceeded:    `hook-chain: {{if .Hook}}hook "{{.Hook}}"{{else}}this chain{{end}} is over its quota ({{.Quota}}); try again later`,
+	RiskScore:        `hook-chain: risk score {{.Score}} reached the threshold {{.Threshold}}{{if .Hook}} (highest from hook "{{.Hook}}"){{end}}`,
+	MaxDuration:      `hook-chain: this chain ran for {{.Elapsed}}, past its max_duration of {{.Limit}}; hook "{{.Hook}}" and the hooks after it did not run`,
+	RuleReason:       `[{{.RuleID}}]{{if .Reason}} {{.Reason}}{{end}}`,
+	RunbookReason:    `{{.Reason}} (runbook: {{.URL}})`,
+	RunbookSystem:    `hook-chain: denied by "{{.Hook}}". See {{.URL}} for how to proceed or request an exception.`,
+	ExceptionApplied: `hook-chain: deny from "{{.Hook}}" waived by exception {{.Exception}}{{if .ExceptionReason}} ({{.ExceptionReason}}){{end}}: {{.Reason}}`,
+}
+
+// defaultCatalog renders the built-in phrasing; used by a nil *Catalog.
+var defaultCatalog = mustParse(defaults)
+
+// Catalog holds parsed templates for every message key.
+type Catalog struct {
+	tmpls map[string]*template.Template
+}
+
+// New builds a catalog from the built-in defaults with overrides applied.
+// It returns an error for unknown keys or templates that do not parse.
+func New(overrides map[string]string) (*Catalog, error) {
+	merged := maps.Clone(defaults)
+	for _, key := range slices.Sorted(maps.Keys(overrides)) {
+		if _, ok := defaults[key]; !ok {
+			return nil, fmt.Errorf("messages: unknown key %q (want one of %s)", key, strings.Join(Keys(), ", "))
+		}
+		merged[key] = overrides[key]
+	}
+	return parse(merged)
+}
+
+// Keys returns every accepted message key, sorted.
+func Keys() []string {
+	return slices.Sorted(maps.Keys(defaults))
+}
+
+// Render executes the template for key. A nil catalog renders the defaults.
+// If an override fails at execution time, the default phrasing is used so a
+// bad template never swallows a denial reason.
+func (c *Catalog) Render(key string, d Data) string {
+	if c == nil {
+		c = defaultCatalog
+	}
+	if s, err := execute(c.tmpls[key], d); err == nil {
+		return s
+	}
+	s, err := execute(defaultCatalog.tmpls[key], d)
+	if err != nil {
+		return fmt.Sprintf("hook-chain: hook %q: %s", d.Hook, key)
+	}
+	return s
+}
+
+// Format renders a one-off template, such as a hook's exit_codes reason,
+// with the same options as catalog templates.
+func Format(src string, d Data) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("messages: parse: %w", err)
+	}
+	return execute(t, d)
+}
+
+func parse(src map[string]string) (*Catalog, error) {
+	c := &Catalog{tmpls: make(map[string]*template.Template, len(src))}
+	for _, key := range slices.Sorted(maps.Keys(src)) {
+		t, err := template.New(key).Option("missingkey=error").Parse(src[key])
+		if err != nil {
+			return nil, fmt.Errorf("messages: parse %q: %w", key, err)
+		}
+		c.tmpls[key] = t
+	}
+	return c, nil
+}
+
+func mustParse(src map[string]string) *Catalog {
+	c, err := parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func execute(t *template.Template, d Data) (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("messages: no template")
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,67 @@
+package messages
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	data := Data{Hook: "guard", Command: "guard-bin", ExitCode: 3, Error: "boom", RuleID: "R1", Reason: "no rm"}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		key       string
+		want      string
+	}{
+		{"default denied", nil, HookDenied, `hook "guard" denied (exit 2)`},
+		{"default failed", nil, HookFailed, `hook "guard" failed (exit 3)`},
+		{"default not found", nil, CommandNotFound, `hook-chain: hook "guard" could not start: command "guard-bin" not found`},
+		{"default rule reason", nil, RuleReason, `[R1] no rm`},
+		{"override", map[string]string{HookDenied: `Bloqueado por {{.Hook}}. Ver https://wiki.example/{{.Hook}}`}, HookDenied, `Bloqueado por guard. Ver https://wiki.example/guard`},
+		{"override leaves others", map[string]string{HookDenied: `x`}, HookFailed, `hook "guard" failed (exit 3)`},
+		{"bad field falls back to default", map[string]string{RuleReason: `{{.Nope}}`}, RuleReason, `[R1] no rm`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.overrides)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := c.Render(tt.key, data); got != tt.want {
+				t.Errorf("Render(%s) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	got, err := Format(`{{.Hook}} blocked (exit {{.ExitCode}}): {{.Reason}}`, Data{Hook: "lint", ExitCode: 3, Reason: "bad"})
+	if err != nil || got != "lint blocked (exit 3): bad" {
+		t.Errorf("Format = %q, %v", got, err)
+	}
+	if _, err := Format(`{{.Hook`, Data{}); err == nil {
+		t.Error("Format with a bad template: want error")
+	}
+}
+
+func TestRenderNilCatalog(t *testing.T) {
+	var c *Catalog
+	if got, want := c.Render(HookTimeout, Data{Hook: "slow", Error: "deadline"}), `hook-chain: hook "slow" timed out: deadline`; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+	}{
+		{"unknown key", map[string]string{"hook_denid": "x"}},
+		{"parse error", map[string]string{HookDenied: "{{.Hook"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.overrides); err == nil {
+				t.Error("New() err = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package mockhook is a scriptable test double for hooks. `hook-chain
+// mock-hook` answers every call the same way, set by flags or by
+// HOOK_CHAIN_MOCK_* variables, so chain configs and agent settings can be
+// tested end to end without writing hook scripts.
+package mockhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/hook"
+)
+
+// Decisions a mock hook can return. Pass writes no output.
+const (
+	DecisionPass  = "pass"
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionAsk   = "ask"
+)
+
+// Decisions lists the valid decisions.
+var Decisions = []string{DecisionPass, DecisionAllow, DecisionDeny, DecisionAsk}
+
+// Environment variables read by FromEnv, one per Spec field.
+const (
+	EnvDecision     = "HOOK_CHAIN_MOCK_DECISION"
+	EnvReason       = "HOOK_CHAIN_MOCK_REASON"
+	EnvUpdatedInput = "HOOK_CHAIN_MOCK_UPDATED_INPUT"
+	EnvContext      = "HOOK_CHAIN_MOCK_CONTEXT"
+	EnvSleep        = "HOOK_CHAIN_MOCK_SLEEP"
+	EnvExit         = "HOOK_CHAIN_MOCK_EXIT"
+	EnvStderr       = "HOOK_CHAIN_MOCK_STDERR"
+	EnvRecord       = "HOOK_CHAIN_MOCK_RECORD"
+)
+
+// Spec is how a mock hook answers.
+type Spec struct {
+	Decision     string        // "pass" (default) | "allow" | "deny" | "ask"
+	Reason       string        // permissionDecisionReason
+	UpdatedInput string        // JSON object returned as updatedInput
+	Context      string        // additionalContext
+	Sleep        time.Duration // delay before answering, e.g. to trip a timeout
+	ExitCode     int           // process exit code
+	Stderr       string        // written to stderr
+	Record       string        // file each input is appended to, one JSON line per call
+}
+
+// FromEnv reads a Spec from the HOOK_CHAIN_MOCK_* variables through getenv.
+func FromEnv(getenv func(string) string) (Spec, error) {
+	s := Spec{
+		Decision:     getenv(EnvDecision),
+		Reason:       getenv(EnvReason),
+		UpdatedInput: getenv(EnvUpdatedInput),
+		Context:      getenv(EnvContext),
+		Stderr:       getenv(EnvStderr),
+		Record:       getenv(EnvRecord),
+	}
+	if v := getenv(EnvSleep); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Spec{}, fmt.Errorf("mockhook: $%s: %w", EnvSleep, err)
+		}
+		s.Sleep = d
+	}
+	if v := getenv(EnvExit); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Spec{}, fmt.Errorf("mockhook: $%s: %w", EnvExit, err)
+		}
+		s.ExitCode = n
+	}
+	return s, nil
+}
+
+// Validate reports an unknown decision, a reason without a decision to
+// carry it, an updated input that is not a JSON object, a negative sleep,
+// and an exit code out of range.
+func (s Spec) Validate() error {
+	switch {
+	case s.Decision != "" && !slices.Contains(Decisions, s.Decision):
+		return fmt.Errorf("mockhook: decision %q is not one of %s", s.Decision, strings.Join(Decisions, ", "))
+	case s.Reason != "" && (s.Decision == "" || s.Decision == DecisionPass):
+		return fmt.Errorf("mockhook: reason %q needs an allow, deny, or ask decision", s.Reason)
+	case s.UpdatedInput != "" && !isObject(s.UpdatedInput):
+		return fmt.Errorf("mockhook: updated input %q is not a JSON object", s.UpdatedInput)
+	case s.Sleep < 0:
+		return fmt.Errorf("mockhook: sleep %s is negative", s.Sleep)
+	case s.ExitCode < 0 || s.ExitCode > 255:
+		return fmt.Errorf("mockhook: exit code %d is not between 0 and 255", s.ExitCode)
+	}
+	return nil
+}
+
+// Output returns the JSON the mock writes to stdout for an input of event,
+// or nil when it has nothing to say.
+func (s Spec) Output(event string) ([]byte, error) {
+	decision := s.Decision
+	if decision == DecisionPass {
+		decision = ""
+	}
+	if decision == "" && s.UpdatedInput == "" && s.Context == "" {
+		return nil, nil
+	}
+	out := hook.Output{HookSpecificOutput: hook.HookSpecificOutput{
+		HookEventName:            event,
+		PermissionDecision:       decision,
+		PermissionDecisionReason: s.Reason,
+		AdditionalContext:        s.Context,
+	}}
+	if s.UpdatedInput != "" {
+		out.HookSpecificOutput.UpdatedInput = json.RawMessage(s.UpdatedInput)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("mockhook: encode output: %w", err)
+	}
+	return data, nil
+}
+
+// Run answers one hook call: it reads the input from stdin, records it,
+// sleeps, writes the stderr text and the output, and returns the exit code.
+// Input that is not JSON is recorded, then reported as an error; empty
+// input answers with no hookEventName. A cancelled ctx cuts the sleep short
+// with ctx's error.
+func Run(ctx context.Context, s Spec, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if err := s.Validate(); err != nil {
+		return 0, err
+	}
+	input, err := io.ReadAll(stdin)
+	if err != nil {
+		return 0, fmt.Errorf("mockhook: read input: %w", err)
+	}
+	if s.Record != "" {
+		if err := record(s.Record, input); err != nil {
+			return 0, err
+		}
+	}
+	var in struct {
+		HookEventName string `json:"hook_event_name"`
+	}
+	if len(bytes.TrimSpace(input)) > 0 {
+		if err := json.Unmarshal(input, &in); err != nil {
+			return 0, fmt.Errorf("mockhook: parse input: %w", err)
+		}
+	}
+
+	if s.Sleep > 0 {
+		t := time.NewTimer(s.Sleep)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	if s.Stderr != "" {
+		if _, err := fmt.Fprintln(stderr, s.Stderr); err != nil {
+			return 0, fmt.Errorf("mockhook: write stderr: %w", err)
+		}
+	}
+	data, err := s.Output(in.HookEventName)
+	if err != nil {
+		return 0, err
+	}
+	if data != nil {
+		if _, err := stdout.Write(append(data, '\n')); err != nil {
+			return 0, fmt.Errorf("mockhook: write output: %w", err)
+		}
+	}
+	return s.ExitCode, nil
+}
+
+// record appends input to path as one line, compacted when it is JSON.
+func record(path string, input []byte) error {
+	var line bytes.Buffer
+	if err := json.Compact(&line, input); err != nil {
+		line.Reset()
+		line.Write(bytes.TrimSpace(input))
+	}
+	line.WriteByte('\n')
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("mockhook: record input: %w", err)
+	}
+	if _, err := f.Write(line.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("mockhook: record input: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("mockhook: record input: %w", err)
+	}
+	return nil
+}
+
+// isObject reports whether s is a JSON object.
+func isObject(s string) bool {
+	var v map[string]json.RawMessage
+	return json.Unmarshal([]byte(s), &v) == nil && v != nil
+}
@@ -0,0 +1,170 @@
+package mockhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const input = `{"hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"command": "ls"}}`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       Spec
+		wantStdout string
+		wantStderr string
+		wantExit   int
+	}{
+		{name: "pass by default"},
+		{name: "explicit pass", spec: Spec{Decision: "pass"}},
+		{
+			name:       "deny",
+			spec:       Spec{Decision: "deny", Reason: "no"},
+			wantStdout: `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"no"}}` + "\n",
+		},
+		{
+			name:       "rewrite",
+			spec:       Spec{UpdatedInput: `{"command":"ls -la"}`},
+			wantStdout: `{"hookSpecificOutput":{"hookEventName":"PreToolUse","updatedInput":{"command":"ls -la"}}}` + "\n",
+		},
+		{
+			name:       "allow with context",
+			spec:       Spec{Decision: "allow", Context: "checked"},
+			wantStdout: `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow","additionalContext":"checked"}}` + "\n",
+		},
+		{
+			name:       "exit 2 with stderr",
+			spec:       Spec{ExitCode: 2, Stderr: "blocked"},
+			wantStderr: "blocked\n",
+			wantExit:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code, err := Run(context.Background(), tt.spec, strings.NewReader(input), &stdout, &stderr)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if code != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", code, tt.wantExit)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunRecordsInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	for range 2 {
+		if _, err := Run(context.Background(), Spec{Record: path}, strings.NewReader(input), &bytes.Buffer{}, &bytes.Buffer{}); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read record: %v", err)
+	}
+	line := `{"hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}` + "\n"
+	if string(data) != line+line {
+		t.Errorf("record = %q, want two compact lines", data)
+	}
+}
+
+func TestRunInput(t *testing.T) {
+	spec := Spec{Decision: "ask"}
+	var stdout bytes.Buffer
+	if _, err := Run(context.Background(), spec, strings.NewReader(""), &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Run with empty input: %v", err)
+	}
+	if want := `{"hookSpecificOutput":{"permissionDecision":"ask"}}` + "\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	spec.Record = path
+	stdout.Reset()
+	if _, err := Run(context.Background(), spec, strings.NewReader("not json"), &stdout, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "parse input") {
+		t.Errorf("Run with invalid input = %v, want a parse error", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want nothing for invalid input", stdout.String())
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "not json\n" {
+		t.Errorf("record = %q, %v; want the invalid input", data, err)
+	}
+}
+
+func TestRunSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var stdout bytes.Buffer
+	_, err := Run(ctx, Spec{Decision: "deny", Sleep: time.Minute}, strings.NewReader(input), &stdout, &bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run error = %v, want deadline exceeded", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want nothing after cancellation", stdout.String())
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{
+		EnvDecision:     "ask",
+		EnvReason:       "why",
+		EnvUpdatedInput: `{"command":"true"}`,
+		EnvSleep:        "150ms",
+		EnvExit:         "1",
+	}
+	s, err := FromEnv(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("FromEnv: %v", err)
+	}
+	want := Spec{Decision: "ask", Reason: "why", UpdatedInput: `{"command":"true"}`, Sleep: 150 * time.Millisecond, ExitCode: 1}
+	if s != want {
+		t.Errorf("FromEnv = %+v, want %+v", s, want)
+	}
+
+	for _, k := range []string{EnvSleep, EnvExit} {
+		bad := map[string]string{k: "soon"}
+		if _, err := FromEnv(func(k string) string { return bad[k] }); err == nil {
+			t.Errorf("FromEnv with bad %s succeeded, want an error", k)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    Spec
+		wantErr bool
+	}{
+		{"zero", Spec{}, false},
+		{"ask", Spec{Decision: "ask"}, false},
+		{"reason without decision", Spec{Reason: "why"}, true},
+		{"reason with pass", Spec{Decision: "pass", Reason: "why"}, true},
+		{"unknown decision", Spec{Decision: "block"}, true},
+		{"updated input not an object", Spec{UpdatedInput: `["ls"]`}, true},
+		{"updated input null", Spec{UpdatedInput: `null`}, true},
+		{"negative sleep", Spec{Sleep: -time.Second}, true},
+		{"exit out of range", Spec{ExitCode: 256}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Package pathutil expands the paths and command words found in hook-chain
+// config: a leading ~ or ~user, $VAR and ${VAR}, and %VAR% on Windows.
+package pathutil
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// windows selects the Windows expansion rules; a variable so tests can cover
+// them on any platform.
+var windows = runtime.GOOS == "windows"
+
+// xdgDefaults are the XDG base directories, relative to the home directory,
+// that $XDG_* placeholders fall back to when the variable is unset.
+var xdgDefaults = map[string]string{
+	"XDG_CONFIG_HOME": ".config",
+	"XDG_DATA_HOME":   filepath.Join(".local", "share"),
+	"XDG_STATE_HOME":  filepath.Join(".local", "state"),
+	"XDG_CACHE_HOME":  ".cache",
+}
+
+var (
+	unixVar    = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	windowsVar = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%`)
+)
+
+// Expand expands environment variables in s (see ExpandEnv), then a leading
+// tilde (see ExpandTilde). It is applied to every path and command word that
+// hook-chain reads from config.
+func Expand(s string) string {
+	return ExpandTilde(ExpandEnv(s))
+}
+
+// ExpandEnv replaces $VAR and ${VAR}, and on Windows %VAR%, with the
+// variable's value. Unset XDG base directory variables fall back to their
+// defaults under the home directory. Any other unset or empty variable is
+// left in place, so a literal $ or % survives.
+func ExpandEnv(s string) string {
+	if strings.Contains(s, "$") {
+		s = unixVar.ReplaceAllStringFunc(s, func(m string) string {
+			sub := unixVar.FindStringSubmatch(m)
+			if v, ok := lookupEnv(sub[1] + sub[2]); ok {
+				return v
+			}
+			return m
+		})
+	}
+	if windows && strings.Contains(s, "%") {
+		s = windowsVar.ReplaceAllStringFunc(s, func(m string) string {
+			if v, ok := lookupEnv(m[1 : len(m)-1]); ok {
+				return v
+			}
+			return m
+		})
+	}
+	return s
+}
+
+// Fields splits a command line on whitespace and expands each field, so a
+// path in any argument is expanded and a variable's value is never split.
+func Fields(command string) []string {
+	fields := strings.Fields(command)
+	for i, f := range fields {
+		fields[i] = Expand(f)
+	}
+	return fields
+}
+
+// lookupEnv returns the non-empty value of the named variable, or the XDG
+// default for an unset XDG base directory.
+func lookupEnv(name string) (string, bool) {
+	if v := os.Getenv(name); v != "" {
+		return v, true
+	}
+	rel, ok := xdgDefaults[name]
+	if !ok {
+		return "", false
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return "", false
+	}
+	return filepath.Join(home, rel), true
+}
@@ -0,0 +1,67 @@
+package pathutil
+
+import (
+	"os/user"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows bool
+		input   string
+		want    string
+	}{
+		{"dollar var", false, "$HC_DIR/hook", "/opt/hc/hook"},
+		{"braced var", false, "${HC_DIR}x/hook", "/opt/hcx/hook"},
+		{"unset var left alone", false, "$HC_UNSET/hook", "$HC_UNSET/hook"},
+		{"lone dollar", false, "cost$", "cost$"},
+		{"xdg set", false, "$XDG_CONFIG_HOME/hc", "/xdg/config/hc"},
+		{"xdg default", false, "${XDG_DATA_HOME}/hc", "/home/alice/.local/share/hc"},
+		{"percent ignored off windows", false, "%HC_DIR%\\hook", "%HC_DIR%\\hook"},
+		{"percent on windows", true, "%HC_DIR%\\hook", "/opt/hc\\hook"},
+		{"unset percent left alone", true, "%HC_UNSET%", "%HC_UNSET%"},
+		{"date format survives", true, "+%Y-%m-%d", "+%Y-%m-%d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", "/home/alice")
+			t.Setenv("HC_DIR", "/opt/hc")
+			t.Setenv("HC_UNSET", "")
+			t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+			t.Setenv("XDG_DATA_HOME", "")
+			defer func(w bool) { windows = w }(windows)
+			windows = tt.windows
+
+			if got := ExpandEnv(tt.input); got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTildeUser(t *testing.T) {
+	u, err := user.Current()
+	if err != nil || u.Username == "" || u.HomeDir == "" {
+		t.Skip("current user unknown")
+	}
+	if got, want := ExpandTilde("~"+u.Username+"/bin"), u.HomeDir+"/bin"; got != want {
+		t.Errorf("ExpandTilde(~%s/bin) = %q, want %q", u.Username, got, want)
+	}
+}
+
+func TestFields(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	t.Setenv("HC_ARG", "a b")
+	got := Fields("~/bin/guard --rules ~/rules.yaml $HC_ARG")
+	want := []string{"/home/alice/bin/guard", "--rules", "/home/alice/rules.yaml", "a b"}
+	if len(got) != len(want) {
+		t.Fatalf("Fields = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -2,19 +2,43 @@ package pathutil
 
 import (
 	"os"
+	"os/user"
 	"strings"
 )
 
-// ExpandTilde replaces a leading ~/ with the user's home directory.
-// Paths like ~user/... are left unchanged (only current user's ~ is expanded).
-// If $HOME is not set, the path is returned as-is.
+// ExpandTilde replaces a leading ~ with the user's home directory and a
+// leading ~name with the home directory of user name. The tilde must be the
+// whole path or be followed by a separator (/, or \ on Windows). Paths are
+// returned as-is when the home directory cannot be determined.
 func ExpandTilde(path string) string {
-	if path != "~" && !strings.HasPrefix(path, "~/") {
+	if !strings.HasPrefix(path, "~") {
 		return path
 	}
-	home := os.Getenv("HOME")
+	name, rest := path[1:], ""
+	if i := strings.IndexFunc(name, isSeparator); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	var home string
+	if name == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		home = h
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil || u.HomeDir == "" {
+			return path
+		}
+		home = u.HomeDir
+	}
 	if home == "" {
 		return path
 	}
-	return home + path[1:]
+	return home + rest
+}
+
+func isSeparator(r rune) bool {
+	return r == '/' || (windows && r == '\\')
 }
@@ -15,7 +15,7 @@ func TestExpandTilde(t *testing.T) {
 		{"relative path unchanged", "/home/alice", "foo", "foo"},
 		{"tilde slash expands", "/home/alice", "~/bin/foo", "/home/alice/bin/foo"},
 		{"bare tilde expands", "/home/alice", "~", "/home/alice"},
-		{"tilde-user left alone", "/home/alice", "~bob/bin/foo", "~bob/bin/foo"},
+		{"unknown tilde-user left alone", "/home/alice", "~no-such-hc-user/bin/foo", "~no-such-hc-user/bin/foo"},
 		{"empty HOME no expansion", "", "~/bin/foo", "~/bin/foo"},
 		{"empty string unchanged", "/home/alice", "", ""},
 	}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+func FuzzShallowMergeJSON(f *testing.F) {
+	for _, seed := range [][2]string{
+		{`{"command":"ls","timeout":5}`, `{"command":"ls -la"}`},
+		{`{"a":{"b":1}}`, `{"a":{"c":2}}`},
+		{`{"a":1}`, ``},
+		{``, `{"a":1}`},
+		{`null`, `{"a":1}`},
+		{`{"a":1}`, `null`},
+		{`[1,2]`, `{"a":1}`},
+		{`{"a":1}`, `"x"`},
+		{`{`, `}`},
+	} {
+		f.Add([]byte(seed[0]), []byte(seed[1]))
+	}
+	f.Fuzz(func(t *testing.T, base, patch []byte) {
+		merged, err := shallowMergeJSON(base, patch)
+		if err != nil || len(base) == 0 || len(patch) == 0 {
+			return
+		}
+		var got map[string]json.RawMessage
+		if err := json.Unmarshal(merged, &got); err != nil {
+			t.Fatalf("merged %s is not an object: %v", merged, err)
+		}
+		var p map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &p); err != nil {
+			t.Fatalf("merge accepted invalid patch %q", patch)
+		}
+		for k := range p {
+			if _, ok := got[k]; !ok {
+				t.Fatalf("patch key %q missing from %s", k, merged)
+			}
+		}
+	})
+}
+
+// FuzzHookOutput runs a chain whose first hook writes arbitrary stdout. The
+// pipeline must reach a decision (allow or deny) and emit valid JSON, never
+// panic.
+func FuzzHookOutput(f *testing.F) {
+	for _, seed := range []struct {
+		stdout   string
+		exitCode int
+	}{
+		{``, 0},
+		{`{"hookSpecificOutput":{"updatedInput":{"command":"ls -la"}}}`, 0},
+		{`{"hookSpecificOutput":{"updatedInput":null}}`, 0},
+		{`{"hookSpecificOutput":{"updatedInput":[1]}}`, 0},
+		{`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no"}}`, 0},
+		{`{"hookSpecificOutput":{"permissionDecision":"ask","ruleId":"R1","severity":"high"}}`, 0},
+		{`{"hookSpecificOutput":{"permissionDecision":"sure"}}`, 0},
+		{`{"hookSpecificOutput":{"additionalContext":"x","metadata":"y"},"stopReason":"z"}`, 0},
+		{`{"hookSpecificOutput":"deny"}`, 0},
+		{`not json`, 0},
+		{`blocked`, 2},
+		{`{}`, 1},
+	} {
+		f.Add([]byte(seed.stdout), seed.exitCode)
+	}
+	hooks := []config.HookEntry{{Name: "fuzzed", Command: "fuzzed"}, {Name: "next", Command: "next"}}
+	logger := slog.New(slog.DiscardHandler)
+	f.Fuzz(func(t *testing.T, stdout []byte, exitCode int) {
+		m := &mockRunner{results: []mockResult{
+			{result: runner.Result{Stdout: stdout, ExitCode: exitCode}},
+			{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"updatedInput":{"description":"x"}}}`)}},
+		}}
+		result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, &mockAuditor{}, logger)
+		if result.ExitCode != 0 && result.ExitCode != 2 {
+			t.Fatalf("ExitCode = %d, want 0 or 2", result.ExitCode)
+		}
+		if len(result.Output) > 0 && !json.Valid(result.Output) {
+			t.Fatalf("output is not JSON: %q", result.Output)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"slices"
 )
 
 // shallowMergeJSON merges patch keys into base at the top level.
@@ -24,6 +26,10 @@ func shallowMergeJSON(base, patch json.RawMessage) (json.RawMessage, error) {
 	if err := json.Unmarshal(base, &baseMap); err != nil {
 		return nil, fmt.Errorf("shallowMergeJSON base: %w", err)
 	}
+	// A null base (tool_input: null) merges like an empty object.
+	if baseMap == nil {
+		baseMap = map[string]json.RawMessage{}
+	}
 
 	var patchMap map[string]json.RawMessage
 	if err := json.Unmarshal(patch, &patchMap); err != nil {
@@ -41,3 +47,54 @@ func shallowMergeJSON(base, patch json.RawMessage) (json.RawMessage, error) {
 
 	return result, nil
 }
+
+// protectedChanges returns the protected keys whose value patch changes in
+// base, sorted, and patch without them (nil when nothing is left). Setting a
+// protected key to the value it already has is not a change, but adding one
+// that base lacks is.
+func protectedChanges(base, patch json.RawMessage, protected []string) ([]string, json.RawMessage, error) {
+	var patchMap map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &patchMap); err != nil {
+		return nil, nil, fmt.Errorf("protectedChanges patch: %w", err)
+	}
+	var baseMap map[string]json.RawMessage
+	if len(base) > 0 {
+		if err := json.Unmarshal(base, &baseMap); err != nil {
+			return nil, nil, fmt.Errorf("protectedChanges base: %w", err)
+		}
+	}
+
+	var changed []string
+	for k, v := range patchMap {
+		if !slices.Contains(protected, k) {
+			continue
+		}
+		if old, ok := baseMap[k]; ok && sameJSON(old, v) {
+			continue
+		}
+		changed = append(changed, k)
+		delete(patchMap, k)
+	}
+	if len(changed) == 0 {
+		return nil, patch, nil
+	}
+	slices.Sort(changed)
+	if len(patchMap) == 0 {
+		return changed, nil, nil
+	}
+	rest, err := json.Marshal(patchMap)
+	if err != nil {
+		return nil, nil, fmt.Errorf("protectedChanges marshal: %w", err)
+	}
+	return changed, rest, nil
+}
+
+// sameJSON reports whether a and b encode the same value, whatever their
+// key order and spacing.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -6,39 +6,477 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Fuabioo/hook-chain/internal/audit"
 	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/events"
 	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/messages"
 	"github.com/Fuabioo/hook-chain/internal/runner"
+	"github.com/Fuabioo/hook-chain/internal/state"
+	"github.com/Fuabioo/hook-chain/internal/transcript"
 )
 
+// maxMetadataLen caps the hook metadata stored per audit record.
+const maxMetadataLen = 4096
+
 // Result holds the final outcome of executing a hook chain.
 type Result struct {
 	ExitCode int
 	Output   []byte // JSON to write to stdout (nil = nothing to write)
 }
 
+// Option customizes a pipeline run.
+type Option func(*options)
+
+type options struct {
+	bus        *events.Bus
+	launch     func(AsyncHook)
+	finally    []config.HookEntry
+	msgs       *messages.Catalog
+	runbooks   config.Runbooks
+	exceptions state.State
+	disabled   state.State
+	notesPath  string
+	severity   map[string]string
+	decided    func(outcome, reason string)
+	protected  []string
+	stripProt  bool
+	quotas     QuotaTaker
+	chainKey   string
+	chainQuota *config.QuotaConfig
+	denyAsk    bool
+	risk       *config.RiskConfig
+	maxDur     time.Duration
+	onMaxDur   string
+}
+
+// QuotaTaker records runs against quotas (see quota.Store).
+type QuotaTaker interface {
+	// Take records a run of key unless max runs were recorded in the last
+	// per, and reports whether it did.
+	Take(key string, max int, per time.Duration) (bool, error)
+}
+
+// AsyncHook is an async hook handed to the launcher instead of being run inline.
+type AsyncHook struct {
+	Index int
+	Hook  config.HookEntry
+	Input []byte // sub-hook input with the toolInput accumulated so far
+}
+
+// WithEventBus publishes lifecycle events (chain_start, hook_start, hook_end,
+// decision, chain_end) to bus during the run.
+func WithEventBus(bus *events.Bus) Option {
+	return func(o *options) { o.bus = bus }
+}
+
+// WithFinally runs hooks after the decision is made, whatever it is. They
+// receive the input plus a "hook_chain" object holding the final outcome and
+// reason; their results are audited but can never change the decision.
+func WithFinally(hooks []config.HookEntry) Option {
+	return func(o *options) { o.finally = hooks }
+}
+
+// WithMessages renders hook-chain's own deny/ask phrasing from msgs instead
+// of the built-in defaults.
+func WithMessages(msgs *messages.Catalog) Option {
+	return func(o *options) { o.msgs = msgs }
+}
+
+// WithRunbooks appends the runbook configured for a denying hook (or the
+// rule it reported) to the deny reason and systemMessage.
+func WithRunbooks(runbooks config.Runbooks) Option {
+	return func(o *options) { o.runbooks = runbooks }
+}
+
+// WithExceptions downgrades denials covered by an active exception (see
+// `hook-chain exceptions add`) to warnings: the hook is audited as
+// "waived", the chain continues, and the warning is shown as systemMessage.
+func WithExceptions(exceptions []state.Exception) Option {
+	return func(o *options) { o.exceptions = state.State{Exceptions: exceptions} }
+}
+
+// WithDisabledHooks skips hooks disabled via `hook-chain hooks disable`.
+// Each one still active is audited with outcome "skip" and, as stderr,
+// until when and why it is disabled.
+func WithDisabledHooks(disabled []state.DisabledHook) Option {
+	return func(o *options) { o.disabled = state.State{Disabled: disabled} }
+}
+
+// WithTranscriptNotes appends the chain's notes (non-allow decisions, waived
+// denials, and hook-supplied transcriptNote text) to path as JSON lines.
+// Write failures are logged and never affect the decision.
+func WithTranscriptNotes(path string) Option {
+	return func(o *options) { o.notesPath = path }
+}
+
+// WithSeverityActions maps the severity of hook decisions to the action
+// taken (see config.ChainEntry.Severity): "context" passes the reason to
+// the model and continues, "ask" escalates, and "deny" blocks.
+func WithSeverityActions(actions map[string]string) Option {
+	return func(o *options) { o.severity = actions }
+}
+
+// WithProtectedFields keeps hooks from changing the given tool_input keys
+// through updatedInput (see config.ChainEntry.ProtectedFields). With action
+// "strip" the keys are dropped from the patch and the rest is merged;
+// otherwise the hook's patch denies the call.
+func WithProtectedFields(fields []string, action string) Option {
+	return func(o *options) {
+		o.protected = fields
+		o.stripProt = action == config.ProtectedStrip
+	}
+}
+
+// finalDecision is the "hook_chain" field passed to finally hooks.
+type finalDecision struct {
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// WithQuotas enforces the quotas of the hooks and, when chain is not nil,
+// the chain's own quota under chainKey, counting runs in q. A hook over its
+// quota is not run; its on_exceeded action skips it, asks, or denies. A
+// chain over its quota runs no hooks. Without this option quotas are not
+// checked.
+func WithQuotas(q QuotaTaker, chainKey string, chain *config.QuotaConfig) Option {
+	return func(o *options) {
+		o.quotas = q
+		o.chainKey = chainKey
+		o.chainQuota = chain
+	}
+}
+
+// WithDenyAsk turns the chain's denials of PreToolUse calls into asks
+// (on_deny: ask). The hooks that denied are audited as denials; the chain
+// is audited as an ask.
+func WithDenyAsk(ask bool) Option {
+	return func(o *options) { o.denyAsk = ask }
+}
+
+// WithRisk combines the riskScore of the hooks with risk and, when every
+// hook allowed, asks or denies at its thresholds. Without this option scores
+// are still combined (with max) for the audit log, but never decide.
+func WithRisk(risk *config.RiskConfig) Option {
+	return func(o *options) { o.risk = risk }
+}
+
+// WithMaxDuration stops the chain from starting hooks once it has run for
+// d; action ("deny", "allow", or "ask"; see config.ChainEntry.MaxDuration)
+// decides the call. The first hook not started is audited with outcome
+// "budget". A d of zero sets no limit.
+func WithMaxDuration(d time.Duration, action string) Option {
+	return func(o *options) {
+		o.maxDur = d
+		o.onMaxDur = action
+	}
+}
+
+// WithDecisionHandler calls decided with the chain's final outcome and
+// reason once the chain is recorded in the audit log, before Run returns.
+func WithDecisionHandler(decided func(outcome, reason string)) Option {
+	return func(o *options) { o.decided = decided }
+}
+
+// WithAsyncLauncher hands async hooks to launch. Without a launcher, async
+// hooks are recorded as skipped.
+func WithAsyncLauncher(launch func(AsyncHook)) Option {
+	return func(o *options) { o.launch = launch }
+}
+
 // Run executes hooks sequentially, threading accumulated toolInput state
 // through the chain. It implements the fold/reduce algorithm described in
-// the hook-chain spec.
-func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r runner.Runner, auditor audit.Auditor, logger *slog.Logger) Result {
+// the hook-chain spec. When ctx is cancelled the chain stops with outcome
+// "cancelled" and an empty Result, without running the finally hooks.
+func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r runner.Runner, auditor audit.Auditor, logger *slog.Logger, opts ...Option) (res Result) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	chainStart := time.Now()
 	hookResults := make([]audit.HookResult, 0, len(hooks))
+	// inHooks is the time spent inside hooks; the rest of the chain's
+	// duration is hook-chain's own overhead.
+	var inHooks time.Duration
+	r = timedRunner{Runner: r, spent: &inHooks}
+	// modified and addedContext record what an allow passed on besides the
+	// decision: a rewritten tool input and context for the model.
+	var modified, addedContext bool
+
+	base := events.Event{
+		EventName: input.HookEventName,
+		ToolName:  input.ToolName,
+		SessionID: input.SessionID,
+		ChainLen:  len(hooks),
+	}
+	// notes collects hook-supplied transcript notes.
+	var notes []transcript.Note
+	// variantOf labels the enforced variant of hooks with variants, by index.
+	variantOf := map[int]string{}
+	// signalOf is the signal that terminated each killed hook, by index.
+	signalOf := map[int]string{}
+	// scoreOf is the riskScore each enforced hook reported, by index.
+	scoreOf := map[int]float64{}
+	// riskScore combines the scores so far; top is the index of the highest.
+	riskScore := func() (score float64, top int, ok bool) {
+		var scores []float64
+		for _, i := range slices.Sorted(maps.Keys(scoreOf)) {
+			if len(scores) == 0 || scoreOf[i] > scoreOf[top] {
+				top = i
+			}
+			scores = append(scores, scoreOf[i])
+		}
+		score, ok = o.risk.Combine(scores)
+		return score, top, ok
+	}
+	// record appends a hook result and publishes its hook_end event.
+	record := func(hr audit.HookResult) {
+		if hr.Variant == "" {
+			hr.Variant = variantOf[hr.HookIndex]
+		}
+		if hr.Signal == "" {
+			hr.Signal = signalOf[hr.HookIndex]
+		}
+		if s, ok := scoreOf[hr.HookIndex]; ok && hr.RiskScore == nil {
+			hr.RiskScore = &s
+		}
+		hookResults = append(hookResults, hr)
+		e := base
+		e.Kind = events.KindHookEnd
+		e.HookIndex = hr.HookIndex
+		e.HookName = hr.HookName
+		e.ExitCode = hr.ExitCode
+		e.Outcome = hr.Outcome
+		e.Reason = hr.Stderr
+		e.DurationMs = hr.DurationMs
+		o.bus.Publish(e)
+	}
+
+	// runFinally runs the finally hooks with the final decision; failures
+	// are recorded and logged only.
+	runFinally := func(outcome, reason string) {
+		if len(o.finally) == 0 {
+			return
+		}
+		if ctx.Err() != nil {
+			logger.Debug("chain cancelled, not running finally hooks")
+			return
+		}
+		decision, err := json.Marshal(finalDecision{Outcome: outcome, Reason: reason})
+		if err != nil {
+			logger.Error("marshal final decision", "err", err)
+			return
+		}
+		inputBytes, err := json.Marshal(input.WithField("hook_chain", decision))
+		if err != nil {
+			logger.Error("marshal finally input", "err", err)
+			return
+		}
+		for k, h := range o.finally {
+			if h.Disabled {
+				continue
+			}
+			idx := len(hooks) + k
+			if hr, ok := o.disabledResult(idx, h); ok {
+				logger.Info("hook disabled via state, skipping", "hook", h.Name, "stderr", hr.Stderr)
+				record(hr)
+				continue
+			}
+			hs := base
+			hs.Kind = events.KindHookStart
+			hs.HookIndex = idx
+			hs.HookName = h.Name
+			o.bus.Publish(hs)
+
+			hookStart := time.Now()
+			runRes, err := r.Run(ctx, h, inputBytes)
+			hr := audit.HookResult{
+				HookIndex:  idx,
+				HookName:   h.Name,
+				ExitCode:   runRes.ExitCode,
+				Outcome:    audit.HookOutcomePass,
+				DurationMs: time.Since(hookStart).Milliseconds(),
+				Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
+				Signal:     runRes.Signal,
+			}
+			switch {
+			case err != nil:
+				hr.ExitCode = -1
+				hr.Outcome = audit.HookOutcomeError
+				hr.Stderr = audit.TruncateStderr(err.Error(), 512)
+				hr.ErrorKind = runner.Kind(err)
+			case runRes.ExitCode != 0:
+				hr.Outcome = audit.HookOutcomeError
+			}
+			if hr.Outcome == audit.HookOutcomeError {
+				logger.Warn("finally hook failed", "hook", h.Name, "exitCode", hr.ExitCode, "stderr", hr.Stderr)
+			}
+			record(hr)
+		}
+	}
+
+	// downgraded is set when on_deny: ask turned the chain's denial into an
+	// ask; the deny result being returned is turned into one too.
+	downgraded := false
+	defer func() {
+		if downgraded {
+			res = askInstead(res)
+		}
+	}()
+
+	// finish runs the finally hooks, records the chain in the audit log, and
+	// publishes the final decision and chain_end events.
+	finish := func(outcome, reason string) {
+		if outcome == "deny" && o.denyAsk && input.HookEventName == "PreToolUse" {
+			logger.Info("chain denial turned into ask (on_deny: ask)", "reason", reason)
+			outcome, downgraded = "ask", true
+		}
+		runFinally(outcome, reason)
+		var chainScore *float64
+		if score, _, ok := riskScore(); ok {
+			chainScore = &score
+		}
+		recordAudit(auditor, input, len(hooks), outcome, reason, modified, addedContext, chainScore, chainStart, inHooks, hookResults, logger)
+		if o.decided != nil {
+			o.decided(outcome, reason)
+		}
+		o.writeNotes(input, len(hooks), outcome, reason, hookResults, notes, logger)
+		e := base
+		e.Outcome = outcome
+		e.Reason = reason
+		e.DurationMs = time.Since(chainStart).Milliseconds()
+		e.Kind = events.KindDecision
+		o.bus.Publish(e)
+		e.Kind = events.KindChainEnd
+		o.bus.Publish(e)
+	}
+
+	start := base
+	start.Kind = events.KindChainStart
+	o.bus.Publish(start)
 
 	if len(hooks) == 0 {
-		recordAudit(auditor, input, 0, "allow", "", chainStart, hookResults, logger)
+		finish("allow", "")
 		return Result{ExitCode: 0}
 	}
 
+	// A chain over its quota runs none of its hooks.
+	if q := o.chainQuota; q != nil && !o.takeQuota(o.chainKey, *q, logger) {
+		md := messageData(input, config.HookEntry{})
+		md.Quota = q.Label()
+		reason := o.msgs.Render(messages.QuotaExceeded, md)
+		logger.Warn("chain over quota", "quota", md.Quota, "on_exceeded", q.EffectiveOnExceeded())
+		switch q.EffectiveOnExceeded() {
+		case config.QuotaSkip:
+			finish("allow", reason)
+			return Result{ExitCode: 0}
+		case config.QuotaAsk:
+			finish("ask", reason)
+			return buildDecisionResult(input.HookEventName, "ask", reason, "")
+		default:
+			finish("deny", reason)
+			return denyResult(input.HookEventName, reason)
+		}
+	}
+
 	originalToolInput := input.ToolInput
 	accumulated := input.ToolInput
 	var contextParts []string
+	var warnings []string
+	// extra and specificExtra collect output fields hook-chain does not know,
+	// forwarded in the final output (later hooks win).
+	extra := map[string]json.RawMessage{}
+	specificExtra := map[string]json.RawMessage{}
+	// failedOpen is set when a hook with on_error: allow fails, or the
+	// chain's max_duration passes with on_max_duration: allow: the
+	// remaining hooks are skipped and the chain allows with what it has.
+	var failedOpen string
 
 	for i, h := range hooks {
+		// Hooks disabled in the config are left out entirely.
+		if h.Disabled {
+			logger.Debug("hook disabled in config, skipping", "index", i, "name", h.Name)
+			continue
+		}
+		if hr, ok := o.disabledResult(i, h); ok {
+			logger.Info("hook disabled via state, skipping", "hook", h.Name, "stderr", hr.Stderr)
+			record(hr)
+			continue
+		}
+		if ctx.Err() != nil {
+			logger.Warn("chain cancelled", "before", h.Name)
+			finish(audit.OutcomeCancelled, fmt.Sprintf("cancelled before hook %q", h.Name))
+			return Result{}
+		}
 		logger.Debug("running hook", "index", i, "name", h.Name)
+		hs := base
+		hs.Kind = events.KindHookStart
+		hs.HookIndex = i
+		hs.HookName = h.Name
+		o.bus.Publish(hs)
+
+		// Past the chain's max_duration no further hook starts. Unlike a
+		// hook timeout, nothing is killed: the hook is never run.
+		if elapsed := time.Since(chainStart); o.maxDur > 0 && elapsed >= o.maxDur {
+			md := messageData(input, h)
+			md.Limit = o.maxDur.String()
+			md.Elapsed = elapsed.Round(time.Millisecond).String()
+			reason := o.msgs.Render(messages.MaxDuration, md)
+			logger.Warn("chain over max_duration", "before", h.Name, "elapsed", elapsed, "max_duration", o.maxDur, "on_max_duration", o.onMaxDur)
+			record(audit.HookResult{
+				HookIndex: i,
+				HookName:  h.Name,
+				Outcome:   audit.HookOutcomeBudget,
+				Stderr:    fmt.Sprintf("not started: chain had run %s of max_duration %s", md.Elapsed, md.Limit),
+			})
+			if o.onMaxDur == config.MaxDurationAllow {
+				failedOpen = reason
+				break
+			}
+			if o.onMaxDur == config.MaxDurationAsk {
+				finish("ask", reason)
+				return buildDecisionResult(input.HookEventName, "ask", reason, "")
+			}
+			finish("deny", reason)
+			return denyResult(input.HookEventName, reason)
+		}
+
+		// A hook over its quota is not run. A report-only hook is only
+		// skipped, since it never decides.
+		if q := h.Quota; q != nil && !o.takeQuota(h.QuotaKey(), *q, logger) {
+			md := messageData(input, h)
+			md.Quota = q.Label()
+			reason := o.msgs.Render(messages.QuotaExceeded, md)
+			hr := audit.HookResult{HookIndex: i, HookName: h.Name, Outcome: audit.HookOutcomeQuota, Stderr: "over quota: " + md.Quota}
+			action := q.EffectiveOnExceeded()
+			if h.ReportOnly {
+				action = config.QuotaSkip
+			}
+			logger.Warn("hook over quota", "hook", h.Name, "quota", md.Quota, "on_exceeded", action)
+			switch action {
+			case config.QuotaSkip:
+				record(hr)
+				continue
+			case config.QuotaAsk:
+				hr.Outcome = audit.HookOutcomeAsk
+				record(hr)
+				finish("ask", reason)
+				return buildDecisionResult(input.HookEventName, "ask", reason, "")
+			default:
+				hr.Outcome = audit.HookOutcomeDeny
+				record(hr)
+				res, reason := o.hookDeny(input, h, "", reason)
+				finish("deny", reason)
+				return res
+			}
+		}
 
 		// Build sub-hook input with accumulated toolInput.
 		subInput := input.WithToolInput(accumulated)
@@ -46,49 +484,209 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 		if err != nil {
 			logger.Error("marshal sub-hook input", "hook", h.Name, "err", err)
 			res := denyResult(input.HookEventName, fmt.Sprintf("hook-chain: failed to marshal input for hook %q: %v", h.Name, err))
-			recordAudit(auditor, input, len(hooks), "error", fmt.Sprintf("marshal input for hook %q: %v", h.Name, err), chainStart, hookResults, logger)
+			finish("error", fmt.Sprintf("marshal input for hook %q: %v", h.Name, err))
 			return res
 		}
 
-		// Execute the hook.
+		// Async hooks never block or decide; the launcher runs them later.
+		if h.Async {
+			hr := audit.HookResult{HookIndex: i, HookName: h.Name, Outcome: audit.HookOutcomeAsync}
+			if o.launch == nil {
+				hr.Outcome = audit.HookOutcomeSkip
+				hr.Stderr = "async hook not launched: no launcher"
+			} else {
+				o.launch(AsyncHook{Index: i, Hook: h, Input: inputBytes})
+			}
+			record(hr)
+			continue
+		}
+
+		// Canary rollout: sessions outside the rollout run the hook report-only.
+		if !h.ReportOnly && !h.InRollout(input.SessionID) {
+			logger.Debug("session outside hook rollout, running report-only", "hook", h.Name, "rollout", h.Rollout)
+			h.ReportOnly = true
+		}
+
+		// A/B variants: run the candidate in shadow first, then enforce the
+		// primary in its place.
+		if primary, shadow, ok := h.SplitVariants(); ok {
+			shadowStart := time.Now()
+			shadowRes, shadowErr := r.Run(ctx, shadow, inputBytes)
+			hr := reportOnlyResult(o.msgs, input, i, shadow, shadowRes, shadowErr, time.Since(shadowStart), logger)
+			hr.Variant = config.VariantShadow
+			record(hr)
+			h = primary
+			variantOf[i] = config.VariantPrimary
+		}
+
+		// Execute the hook, re-running transient failures it has retries
+		// for. Each failed attempt is audited on its own.
 		hookStart := time.Now()
 		runRes, err := r.Run(ctx, h, inputBytes)
+		for n := 1; n <= h.EffectiveRetries() && retryable(h, runRes, err); n++ {
+			hr := audit.HookResult{
+				HookIndex:  i,
+				HookName:   h.Name,
+				ExitCode:   runRes.ExitCode,
+				Outcome:    audit.HookOutcomeRetry,
+				DurationMs: time.Since(hookStart).Milliseconds(),
+				Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
+				Signal:     runRes.Signal,
+			}
+			if err != nil {
+				hr.ExitCode = -1
+				hr.Stderr = audit.TruncateStderr(err.Error(), 512)
+				hr.ErrorKind = runner.Kind(err)
+			}
+			record(hr)
+			delay := h.RetryBackoff(n)
+			logger.Warn("retrying hook", "hook", h.Name, "attempt", n+1, "delay", delay, "exitCode", hr.ExitCode, "stderr", hr.Stderr)
+			if sleepCtx(ctx, delay) != nil {
+				break
+			}
+			hookStart = time.Now()
+			runRes, err = r.Run(ctx, h, inputBytes)
+		}
+		if runRes.Signal != "" {
+			signalOf[i] = runRes.Signal
+		}
+
+		// The agent gave up on the chain: whatever the hook returned, it was
+		// interrupted rather than failing, so the chain stops without a
+		// decision and records how long the hook had been running.
+		if ctx.Err() != nil {
+			elapsed := time.Since(hookStart)
+			logger.Warn("chain cancelled", "hook", h.Name, "elapsed", elapsed)
+			record(audit.HookResult{
+				HookIndex:  i,
+				HookName:   h.Name,
+				ExitCode:   -1,
+				Outcome:    audit.HookOutcomeCancelled,
+				DurationMs: elapsed.Milliseconds(),
+				Stderr:     audit.TruncateStderr(context.Cause(ctx).Error(), 512),
+			})
+			finish(audit.OutcomeCancelled, fmt.Sprintf("cancelled while hook %q ran (%s)", h.Name, elapsed.Round(time.Millisecond)))
+			return Result{}
+		}
+
+		// Report-only hooks are audited but never enforced or merged.
+		if h.ReportOnly {
+			record(reportOnlyResult(o.msgs, input, i, h, runRes, err, time.Since(hookStart), logger))
+			continue
+		}
+
 		if err != nil {
 			// Runner-level error (binary not found, timeout, etc.).
 			logger.Warn("runner error", "hook", h.Name, "err", err)
 			if h.EffectiveOnError() == "skip" {
 				logger.Warn("skipping hook due to on_error=skip", "hook", h.Name)
-				hookResults = append(hookResults, audit.HookResult{
+				record(audit.HookResult{
 					HookIndex:  i,
 					HookName:   h.Name,
 					ExitCode:   -1,
 					Outcome:    "skip",
 					DurationMs: time.Since(hookStart).Milliseconds(),
 					Stderr:     audit.TruncateStderr(err.Error(), 512),
+					ErrorKind:  runner.Kind(err),
 				})
 				continue
 			}
-			hookResults = append(hookResults, audit.HookResult{
+			if h.EffectiveOnError() == "allow" {
+				logger.Warn("allowing chain due to on_error=allow", "hook", h.Name)
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   -1,
+					Outcome:    audit.HookOutcomeFailOpen,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(err.Error(), 512),
+					ErrorKind:  runner.Kind(err),
+				})
+				failedOpen = fmt.Sprintf("hook %q runner error: %v", h.Name, err)
+				break
+			}
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   -1,
 				Outcome:    "error",
 				DurationMs: time.Since(hookStart).Milliseconds(),
 				Stderr:     audit.TruncateStderr(err.Error(), 512),
+				ErrorKind:  runner.Kind(err),
 			})
-			res := denyResult(input.HookEventName, fmt.Sprintf("hook-chain: hook %q failed: %v", h.Name, err))
-			recordAudit(auditor, input, len(hooks), "error", fmt.Sprintf("hook %q runner error: %v", h.Name, err), chainStart, hookResults, logger)
+			res, _ := o.hookDeny(input, h, "", runnerErrorReason(o.msgs, input, h, err))
+			finish("error", fmt.Sprintf("hook %q runner error: %v", h.Name, err))
 			return res
 		}
 
+		// exit_codes decides first for the codes it maps.
+		if rule, ok := h.ExitCodeRule(runRes.ExitCode); ok {
+			reason := o.exitCodeReason(input, h, rule, runRes, logger)
+			hr := audit.HookResult{
+				HookIndex:  i,
+				HookName:   h.Name,
+				ExitCode:   runRes.ExitCode,
+				DurationMs: time.Since(hookStart).Milliseconds(),
+				Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
+			}
+			switch rule.Decision {
+			case config.ExitPass:
+				logger.Debug("hook exit code mapped to pass", "hook", h.Name, "exitCode", runRes.ExitCode)
+				hr.Outcome = audit.HookOutcomePass
+				if rule.Reason != "" {
+					warnings = append(warnings, reason)
+				}
+				record(hr)
+				continue
+			case config.ExitSkip:
+				logger.Warn("skipping hook due to exit_codes", "hook", h.Name, "exitCode", runRes.ExitCode)
+				hr.Outcome = audit.HookOutcomeSkip
+				record(hr)
+				continue
+			case config.ExitAsk:
+				logger.Info("hook ask escalation (exit code)", "hook", h.Name, "exitCode", runRes.ExitCode, "reason", reason)
+				hr.Outcome = audit.HookOutcomeAsk
+				record(hr)
+				res := buildDecisionResult(input.HookEventName, "ask", reason, "")
+				finish("ask", reason)
+				return res
+			case config.ExitDeny:
+				logger.Info("hook denied (exit code)", "hook", h.Name, "exitCode", runRes.ExitCode, "reason", reason)
+				if warning, ok := o.waive(input, h, accumulated, reason, logger); ok {
+					hr.Outcome = audit.HookOutcomeWaived
+					hr.Stderr = audit.TruncateStderr(warning, 512)
+					record(hr)
+					warnings = append(warnings, warning)
+					continue
+				}
+				hr.Outcome = audit.HookOutcomeDeny
+				record(hr)
+				res, reason := o.hookDeny(input, h, "", reason)
+				finish("deny", reason)
+				return res
+			}
+		}
+
 		// Exit code 2 always denies, regardless of on_error.
 		if runRes.ExitCode == 2 {
 			logger.Info("hook denied (exit 2)", "hook", h.Name, "stderr", runRes.Stderr)
-			reason := fmt.Sprintf("hook %q denied (exit 2)", h.Name)
+			reason := o.msgs.Render(messages.HookDenied, messageData(input, h))
 			if runRes.Stderr != "" {
 				reason = runRes.Stderr
 			}
-			hookResults = append(hookResults, audit.HookResult{
+			if warning, ok := o.waive(input, h, accumulated, reason, logger); ok {
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   2,
+					Outcome:    audit.HookOutcomeWaived,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(warning, 512),
+				})
+				warnings = append(warnings, warning)
+				continue
+			}
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   2,
@@ -96,8 +694,8 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 				DurationMs: time.Since(hookStart).Milliseconds(),
 				Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
 			})
-			res := denyResult(input.HookEventName, reason)
-			recordAudit(auditor, input, len(hooks), "deny", reason, chainStart, hookResults, logger)
+			res, reason := o.hookDeny(input, h, "", reason)
+			finish("deny", reason)
 			return res
 		}
 
@@ -106,7 +704,7 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 			logger.Warn("hook non-zero exit", "hook", h.Name, "exitCode", runRes.ExitCode, "stderr", runRes.Stderr)
 			if h.EffectiveOnError() == "skip" {
 				logger.Warn("skipping hook due to on_error=skip", "hook", h.Name)
-				hookResults = append(hookResults, audit.HookResult{
+				record(audit.HookResult{
 					HookIndex:  i,
 					HookName:   h.Name,
 					ExitCode:   runRes.ExitCode,
@@ -116,11 +714,39 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 				})
 				continue
 			}
-			reason := fmt.Sprintf("hook %q failed (exit %d)", h.Name, runRes.ExitCode)
+			if h.EffectiveOnError() == "allow" {
+				logger.Warn("allowing chain due to on_error=allow", "hook", h.Name)
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   runRes.ExitCode,
+					Outcome:    audit.HookOutcomeFailOpen,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
+				})
+				failedOpen = fmt.Sprintf("hook %q exited %d", h.Name, runRes.ExitCode)
+				break
+			}
+			md := messageData(input, h)
+			md.ExitCode = runRes.ExitCode
+			md.Signal = runRes.Signal
+			reason := o.msgs.Render(messages.HookFailed, md)
 			if runRes.Stderr != "" {
 				reason = runRes.Stderr
 			}
-			hookResults = append(hookResults, audit.HookResult{
+			if warning, ok := o.waive(input, h, accumulated, reason, logger); ok {
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   runRes.ExitCode,
+					Outcome:    audit.HookOutcomeWaived,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(warning, 512),
+				})
+				warnings = append(warnings, warning)
+				continue
+			}
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   runRes.ExitCode,
@@ -128,8 +754,8 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 				DurationMs: time.Since(hookStart).Milliseconds(),
 				Stderr:     audit.TruncateStderr(runRes.Stderr, 512),
 			})
-			res := denyResult(input.HookEventName, reason)
-			recordAudit(auditor, input, len(hooks), "deny", reason, chainStart, hookResults, logger)
+			res, reason := o.hookDeny(input, h, "", reason)
+			finish("deny", reason)
 			return res
 		}
 
@@ -137,7 +763,7 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 		stdout := bytes.TrimSpace(runRes.Stdout)
 		if len(stdout) == 0 {
 			logger.Debug("hook passthrough (empty stdout)", "hook", h.Name)
-			hookResults = append(hookResults, audit.HookResult{
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   0,
@@ -147,12 +773,53 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 			continue
 		}
 
+		// Plain-text hooks print a message instead of JSON.
+		switch h.Output {
+		case config.OutputText:
+			logger.Debug("hook text output passed as context", "hook", h.Name)
+			contextParts = append(contextParts, string(stdout))
+			record(audit.HookResult{
+				HookIndex:  i,
+				HookName:   h.Name,
+				ExitCode:   0,
+				Outcome:    audit.HookOutcomeContext,
+				DurationMs: time.Since(hookStart).Milliseconds(),
+			})
+			continue
+		case config.OutputTextDeny:
+			reason := string(stdout)
+			logger.Info("hook denied (text output)", "hook", h.Name, "reason", reason)
+			if warning, ok := o.waive(input, h, accumulated, reason, logger); ok {
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   0,
+					Outcome:    audit.HookOutcomeWaived,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(warning, 512),
+				})
+				warnings = append(warnings, warning)
+				continue
+			}
+			record(audit.HookResult{
+				HookIndex:  i,
+				HookName:   h.Name,
+				ExitCode:   0,
+				Outcome:    audit.HookOutcomeDeny,
+				DurationMs: time.Since(hookStart).Milliseconds(),
+				Stderr:     audit.TruncateStderr(reason, 512),
+			})
+			res, reason := o.hookDeny(input, h, "", reason)
+			finish("deny", reason)
+			return res
+		}
+
 		// Parse hook output JSON.
 		var output hook.Output
 		if err := json.Unmarshal(stdout, &output); err != nil {
 			logger.Warn("failed to parse hook stdout as JSON", "hook", h.Name, "err", err)
 			if h.EffectiveOnError() == "skip" {
-				hookResults = append(hookResults, audit.HookResult{
+				record(audit.HookResult{
 					HookIndex:  i,
 					HookName:   h.Name,
 					ExitCode:   0,
@@ -162,7 +829,20 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 				})
 				continue
 			}
-			hookResults = append(hookResults, audit.HookResult{
+			if h.EffectiveOnError() == "allow" {
+				logger.Warn("allowing chain due to on_error=allow", "hook", h.Name)
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   0,
+					Outcome:    audit.HookOutcomeFailOpen,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(err.Error(), 512),
+				})
+				failedOpen = fmt.Sprintf("hook %q invalid JSON: %v", h.Name, err)
+				break
+			}
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   0,
@@ -170,61 +850,159 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 				DurationMs: time.Since(hookStart).Milliseconds(),
 				Stderr:     audit.TruncateStderr(err.Error(), 512),
 			})
-			res := denyResult(input.HookEventName, fmt.Sprintf("hook-chain: hook %q returned invalid JSON: %v", h.Name, err))
-			recordAudit(auditor, input, len(hooks), "error", fmt.Sprintf("hook %q invalid JSON: %v", h.Name, err), chainStart, hookResults, logger)
+			md := messageData(input, h)
+			md.Error = err.Error()
+			res, _ := o.hookDeny(input, h, "", o.msgs.Render(messages.InvalidJSON, md))
+			finish("error", fmt.Sprintf("hook %q invalid JSON: %v", h.Name, err))
 			return res
 		}
 
 		hso := output.HookSpecificOutput
+		maps.Copy(extra, output.Extra)
+		maps.Copy(specificExtra, hso.Extra)
+		if hso.RiskScore != nil {
+			scoreOf[i] = *hso.RiskScore
+		}
+		metadata := hookMetadata(h.Name, hso.Metadata, logger)
+		severity := hookSeverity(h, hso.Severity, logger)
+		decision := o.severityDecision(hso, severity)
+		if note := strings.TrimSpace(hso.TranscriptNote); note != "" {
+			notes = append(notes, transcript.Note{Hook: h.Name, Kind: transcript.KindHook, RuleID: hso.RuleID, Text: note})
+		}
 
 		// Explicit deny always short-circuits.
-		if hso.PermissionDecision == "deny" {
-			logger.Info("hook denied (explicit)", "hook", h.Name, "reason", hso.PermissionDecisionReason)
-			hookResults = append(hookResults, audit.HookResult{
+		if decision == "deny" {
+			logger.Info("hook denied (explicit)", "hook", h.Name, "rule", hso.RuleID, "severity", severity, "reason", hso.PermissionDecisionReason)
+			reason := withRuleID(o.msgs, input, h, hso.RuleID, hso.PermissionDecisionReason)
+			if warning, ok := o.waive(input, h, accumulated, reason, logger); ok {
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   0,
+					Outcome:    audit.HookOutcomeWaived,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(warning, 512),
+					Metadata:   metadata,
+					RuleID:     hso.RuleID,
+					Severity:   severity,
+				})
+				warnings = append(warnings, warning)
+				continue
+			}
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   0,
 				Outcome:    "deny",
 				DurationMs: time.Since(hookStart).Milliseconds(),
+				Metadata:   metadata,
+				RuleID:     hso.RuleID,
+				Severity:   severity,
 			})
-			res := buildDecisionResult(input.HookEventName, "deny", hso.PermissionDecisionReason)
-			recordAudit(auditor, input, len(hooks), "deny", hso.PermissionDecisionReason, chainStart, hookResults, logger)
+			res, reason := o.hookDeny(input, h, hso.RuleID, reason)
+			finish("deny", reason)
 			return res
 		}
 
 		// Ask escalation always short-circuits.
-		if hso.PermissionDecision == "ask" {
-			logger.Info("hook ask escalation", "hook", h.Name, "reason", hso.PermissionDecisionReason)
-			hookResults = append(hookResults, audit.HookResult{
+		if decision == "ask" {
+			logger.Info("hook ask escalation", "hook", h.Name, "rule", hso.RuleID, "severity", severity, "reason", hso.PermissionDecisionReason)
+			record(audit.HookResult{
 				HookIndex:  i,
 				HookName:   h.Name,
 				ExitCode:   0,
 				Outcome:    "ask",
 				DurationMs: time.Since(hookStart).Milliseconds(),
+				Metadata:   metadata,
+				RuleID:     hso.RuleID,
+				Severity:   severity,
 			})
-			res := buildDecisionResult(input.HookEventName, "ask", hso.PermissionDecisionReason)
-			recordAudit(auditor, input, len(hooks), "ask", hso.PermissionDecisionReason, chainStart, hookResults, logger)
+			reason := withRuleID(o.msgs, input, h, hso.RuleID, hso.PermissionDecisionReason)
+			res := buildDecisionResult(input.HookEventName, "ask", reason, "")
+			finish("ask", reason)
 			return res
 		}
 
 		// Determine hook-level outcome for audit.
 		hookOutcome := "pass"
+		var patch, note string
 
-		// Merge updatedInput if present.
-		if len(hso.UpdatedInput) > 0 {
-			merged, err := shallowMergeJSON(accumulated, hso.UpdatedInput)
+		// Protected fields never change: the hook denies, or they are
+		// stripped from its patch. The patch is audited as the hook sent it.
+		updated := hso.UpdatedInput
+		if len(updated) > 0 && len(o.protected) > 0 {
+			changed, rest, err := protectedChanges(accumulated, updated, o.protected)
+			if err != nil {
+				// A patch that cannot be checked is not merged unchecked.
+				logger.Error("check protected fields", "hook", h.Name, "err", err)
+				record(audit.HookResult{
+					HookIndex:  i,
+					HookName:   h.Name,
+					ExitCode:   0,
+					Outcome:    audit.HookOutcomeError,
+					DurationMs: time.Since(hookStart).Milliseconds(),
+					Stderr:     audit.TruncateStderr(err.Error(), 512),
+					Metadata:   metadata,
+					Severity:   severity,
+					Patch:      audit.RedactPatch(updated),
+				})
+				md := messageData(input, h)
+				md.Error = err.Error()
+				res, _ := o.hookDeny(input, h, "", o.msgs.Render(messages.MergeFailed, md))
+				finish("error", fmt.Sprintf("check protected fields in updatedInput from hook %q: %v", h.Name, err))
+				return res
+			}
+			if len(changed) > 0 {
+				fields := strings.Join(changed, ", ")
+				if o.stripProt {
+					logger.Warn("stripped protected fields from updatedInput", "hook", h.Name, "fields", fields)
+					note = "stripped protected fields from updatedInput: " + fields
+					patch = audit.RedactPatch(updated)
+					updated = rest
+				} else {
+					logger.Warn("hook tried to change protected fields", "hook", h.Name, "fields", fields)
+					record(audit.HookResult{
+						HookIndex:  i,
+						HookName:   h.Name,
+						ExitCode:   0,
+						Outcome:    audit.HookOutcomeDeny,
+						DurationMs: time.Since(hookStart).Milliseconds(),
+						Stderr:     "updatedInput changes protected fields: " + fields,
+						Metadata:   metadata,
+						RuleID:     hso.RuleID,
+						Severity:   severity,
+						Patch:      audit.RedactPatch(updated),
+					})
+					md := messageData(input, h)
+					md.Fields = fields
+					res, reason := o.hookDeny(input, h, "", o.msgs.Render(messages.ProtectedField, md))
+					finish("deny", reason)
+					return res
+				}
+			}
+		}
+
+		// Merge updatedInput if present, keeping the patch for the audit log.
+		if len(updated) > 0 {
+			patch = audit.RedactPatch(hso.UpdatedInput)
+			merged, err := shallowMergeJSON(accumulated, updated)
 			if err != nil {
 				logger.Error("merge updatedInput", "hook", h.Name, "err", err)
-				hookResults = append(hookResults, audit.HookResult{
+				record(audit.HookResult{
 					HookIndex:  i,
 					HookName:   h.Name,
 					ExitCode:   0,
 					Outcome:    "error",
 					DurationMs: time.Since(hookStart).Milliseconds(),
 					Stderr:     audit.TruncateStderr(err.Error(), 512),
+					Metadata:   metadata,
+					Severity:   severity,
+					Patch:      patch,
 				})
-				res := denyResult(input.HookEventName, fmt.Sprintf("hook-chain: failed to merge updatedInput from hook %q: %v", h.Name, err))
-				recordAudit(auditor, input, len(hooks), "error", fmt.Sprintf("merge updatedInput from hook %q: %v", h.Name, err), chainStart, hookResults, logger)
+				md := messageData(input, h)
+				md.Error = err.Error()
+				res, _ := o.hookDeny(input, h, "", o.msgs.Render(messages.MergeFailed, md))
+				finish("error", fmt.Sprintf("merge updatedInput from hook %q: %v", h.Name, err))
 				return res
 			}
 			accumulated = merged
@@ -240,22 +1018,57 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 			}
 		}
 
-		hookResults = append(hookResults, audit.HookResult{
+		// A decision downgraded by severity reaches the model as context.
+		if decision == config.SeverityActionContext && hso.PermissionDecisionReason != "" {
+			logger.Info("hook decision passed as context", "hook", h.Name, "rule", hso.RuleID, "severity", severity)
+			contextParts = append(contextParts, withRuleID(o.msgs, input, h, hso.RuleID, hso.PermissionDecisionReason))
+			if hookOutcome == "pass" {
+				hookOutcome = "context"
+			}
+		}
+
+		record(audit.HookResult{
 			HookIndex:  i,
 			HookName:   h.Name,
 			ExitCode:   0,
 			Outcome:    hookOutcome,
 			DurationMs: time.Since(hookStart).Milliseconds(),
+			Stderr:     note,
+			Metadata:   metadata,
+			RuleID:     hso.RuleID,
+			Severity:   severity,
+			Patch:      patch,
 		})
 	}
 
+	// Every hook allowed: the risk thresholds decide on the combined score.
+	if score, top, ok := riskScore(); ok {
+		if decision, threshold := o.risk.Decision(score); decision != "allow" {
+			md := messageData(input, hooks[top])
+			md.Score = strconv.FormatFloat(score, 'f', -1, 64)
+			md.Threshold = threshold
+			reason := o.msgs.Render(messages.RiskScore, md)
+			logger.Info("risk threshold reached", "score", score, "threshold", threshold, "decision", decision, "top", hooks[top].Name)
+			if decision == "ask" {
+				finish("ask", reason)
+				return buildDecisionResult(input.HookEventName, "ask", reason, "")
+			}
+			finish("deny", reason)
+			return denyResult(input.HookEventName, reason)
+		}
+	}
+
 	// After all hooks: determine if anything changed.
 	changed := !bytes.Equal(normalizeJSON(accumulated), normalizeJSON(originalToolInput))
 	hasContext := len(contextParts) > 0
+	modified, addedContext = changed, hasContext
+	warning := strings.Join(warnings, "\n")
+	// The audit reason of a chain that failed open names the failure.
+	reason := strings.Join(slices.DeleteFunc([]string{failedOpen, warning}, func(s string) bool { return s == "" }), "\n")
 
-	if !changed && !hasContext {
+	if !changed && !hasContext && warning == "" && len(extra) == 0 && len(specificExtra) == 0 {
 		logger.Debug("all hooks passed through, no changes")
-		recordAudit(auditor, input, len(hooks), "allow", "", chainStart, hookResults, logger)
+		finish("allow", reason)
 		return Result{ExitCode: 0}
 	}
 
@@ -263,7 +1076,10 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 	out := hook.Output{
 		HookSpecificOutput: hook.HookSpecificOutput{
 			HookEventName: input.HookEventName,
+			Extra:         specificExtra,
 		},
+		SystemMessage: warning,
+		Extra:         extra,
 	}
 
 	if changed {
@@ -278,14 +1094,250 @@ func Run(ctx context.Context, input *hook.Input, hooks []config.HookEntry, r run
 	if err != nil {
 		logger.Error("marshal final output", "err", err)
 		res := denyResult(input.HookEventName, fmt.Sprintf("hook-chain: failed to marshal final output: %v", err))
-		recordAudit(auditor, input, len(hooks), "error", fmt.Sprintf("marshal final output: %v", err), chainStart, hookResults, logger)
+		finish("error", fmt.Sprintf("marshal final output: %v", err))
 		return res
 	}
 
-	recordAudit(auditor, input, len(hooks), "allow", "", chainStart, hookResults, logger)
+	finish("allow", reason)
 	return Result{ExitCode: 0, Output: data}
 }
 
+// reportOnlyResult classifies a report-only hook's run without enforcing it.
+// Anything that would have blocked the tool call (runner error, non-zero
+// exit, invalid JSON, deny, ask) is recorded as "report" with the would-be
+// verdict; everything else is recorded as "pass" since its output is dropped.
+func reportOnlyResult(msgs *messages.Catalog, input *hook.Input, i int, h config.HookEntry, runRes runner.Result, err error, elapsed time.Duration, logger *slog.Logger) audit.HookResult {
+	hr := audit.HookResult{
+		HookIndex:  i,
+		HookName:   h.Name,
+		ExitCode:   runRes.ExitCode,
+		Outcome:    audit.HookOutcomePass,
+		DurationMs: elapsed.Milliseconds(),
+		Signal:     runRes.Signal,
+	}
+
+	var verdict string
+	rule, mapped := h.ExitCodeRule(runRes.ExitCode)
+	switch {
+	case err == nil && mapped:
+		switch rule.Decision {
+		case config.ExitDeny, config.ExitAsk:
+			verdict = fmt.Sprintf("would %s (exit %d): %s", rule.Decision, runRes.ExitCode, runRes.Stderr)
+		default:
+			return hr
+		}
+	case err != nil:
+		hr.ExitCode = -1
+		hr.ErrorKind = runner.Kind(err)
+		verdict = fmt.Sprintf("would error: %v", err)
+	case runRes.ExitCode == 2:
+		verdict = "would deny (exit 2): " + runRes.Stderr
+	case runRes.ExitCode != 0:
+		verdict = fmt.Sprintf("would fail (exit %d): %s", runRes.ExitCode, runRes.Stderr)
+	case len(bytes.TrimSpace(runRes.Stdout)) == 0, h.Output == config.OutputText:
+		return hr
+	case h.Output == config.OutputTextDeny:
+		verdict = "would deny: " + string(bytes.TrimSpace(runRes.Stdout))
+	default:
+		stdout := bytes.TrimSpace(runRes.Stdout)
+		var output hook.Output
+		if err := json.Unmarshal(stdout, &output); err != nil {
+			verdict = fmt.Sprintf("would error: invalid JSON: %v", err)
+			break
+		}
+		hr.Metadata = hookMetadata(h.Name, output.HookSpecificOutput.Metadata, logger)
+		hr.RuleID = output.HookSpecificOutput.RuleID
+		hr.Severity = hookSeverity(h, output.HookSpecificOutput.Severity, logger)
+		hr.RiskScore = output.HookSpecificOutput.RiskScore
+		switch d := output.HookSpecificOutput.PermissionDecision; d {
+		case "deny", "ask":
+			verdict = fmt.Sprintf("would %s: %s", d, withRuleID(msgs, input, h, hr.RuleID, output.HookSpecificOutput.PermissionDecisionReason))
+		default:
+			return hr
+		}
+	}
+
+	logger.Info("report-only hook not enforced", "hook", h.Name, "verdict", verdict)
+	hr.Outcome = audit.HookOutcomeReport
+	hr.Stderr = audit.TruncateStderr(strings.TrimSpace(verdict), 512)
+	return hr
+}
+
+// hookMetadata validates the metadata a hook returned for the audit log.
+// Anything other than a JSON object, or an object over maxMetadataLen bytes
+// once compacted, is dropped with a warning rather than failing the hook.
+func hookMetadata(name string, raw json.RawMessage, logger *slog.Logger) json.RawMessage {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	if raw[0] != '{' {
+		logger.Warn("ignoring hook metadata: not a JSON object", "hook", name)
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		logger.Warn("ignoring hook metadata", "hook", name, "err", err)
+		return nil
+	}
+	if buf.Len() > maxMetadataLen {
+		logger.Warn("ignoring hook metadata: too large", "hook", name, "bytes", buf.Len(), "max", maxMetadataLen)
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// hookSeverity returns the severity a hook declared for its decision,
+// falling back to the hook's configured default. Unknown levels are
+// ignored with a warning.
+func hookSeverity(h config.HookEntry, declared string, logger *slog.Logger) string {
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if declared != "" {
+		if config.ValidSeverity(declared) {
+			return declared
+		}
+		logger.Warn("ignoring unknown hook severity", "hook", h.Name, "severity", declared)
+	}
+	return h.Severity
+}
+
+// severityDecision applies the chain's severity mapping to a hook's
+// decision. A mapped severity replaces a deny or ask, and also applies to
+// a reason returned without a decision. Otherwise the hook's own decision
+// stands.
+func (o *options) severityDecision(hso hook.HookSpecificOutput, severity string) string {
+	action := ""
+	if severity != "" {
+		action = o.severity[severity]
+	}
+	switch {
+	case action == "":
+		return hso.PermissionDecision
+	case hso.PermissionDecision == "deny", hso.PermissionDecision == "ask":
+		return action
+	case hso.PermissionDecision == "" && hso.PermissionDecisionReason != "":
+		return action
+	}
+	return hso.PermissionDecision
+}
+
+// disabledResult returns the skip result audited for h when it is disabled
+// via `hook-chain hooks disable`.
+func (o *options) disabledResult(i int, h config.HookEntry) (audit.HookResult, bool) {
+	d, ok := o.disabled.DisabledEntry(h.Name, time.Now().UTC())
+	if !ok {
+		return audit.HookResult{}, false
+	}
+	until := "re-enabled"
+	if !d.Until.IsZero() {
+		until = d.Until.Format(time.RFC3339)
+	}
+	stderr := "disabled until " + until
+	if d.Reason != "" {
+		stderr += ": " + d.Reason
+	}
+	return audit.HookResult{HookIndex: i, HookName: h.Name, Outcome: audit.HookOutcomeSkip, Stderr: stderr}, true
+}
+
+// takeQuota records a run against quota q under key and reports whether it
+// was within the quota. Quotas bound cost, not access, so without a store,
+// or when the store fails, the run is allowed.
+func (o *options) takeQuota(key string, q config.QuotaConfig, logger *slog.Logger) bool {
+	if o.quotas == nil {
+		return true
+	}
+	ok, err := o.quotas.Take(key, q.Max, q.Per)
+	if err != nil {
+		logger.Warn("quota check failed, running anyway", "quota", key, "err", err)
+		return true
+	}
+	return ok
+}
+
+// messageData fills the template fields common to every message.
+func messageData(input *hook.Input, h config.HookEntry) messages.Data {
+	return messages.Data{
+		Hook:    h.Name,
+		Command: h.Command,
+		Event:   input.HookEventName,
+		Tool:    input.ToolName,
+	}
+}
+
+// retryable reports whether a hook run failed in a way worth retrying: a
+// runner error that is not permanent (see runner.Retryable), or an exit
+// other than 0 and 2 that exit_codes does not map.
+func retryable(h config.HookEntry, runRes runner.Result, err error) bool {
+	if err != nil {
+		return runner.Retryable(err)
+	}
+	if _, mapped := h.ExitCodeRule(runRes.ExitCode); mapped {
+		return false
+	}
+	return runRes.ExitCode != 0 && runRes.ExitCode != 2
+}
+
+// sleepCtx waits for d, or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// exitCodeReason renders the reason of an exit_codes rule. Without a reason
+// template it is the hook's stderr, else the hook_failed message; a template
+// that fails to render falls back the same way.
+func (o *options) exitCodeReason(input *hook.Input, h config.HookEntry, rule config.ExitCodeRule, runRes runner.Result, logger *slog.Logger) string {
+	md := messageData(input, h)
+	md.ExitCode = runRes.ExitCode
+	md.Reason = strings.TrimSpace(runRes.Stderr)
+	if rule.Reason != "" {
+		reason, err := messages.Format(rule.Reason, md)
+		if err == nil {
+			return reason
+		}
+		logger.Warn("exit_codes reason failed to render", "hook", h.Name, "err", err)
+	}
+	if runRes.Stderr != "" {
+		return runRes.Stderr
+	}
+	return o.msgs.Render(messages.HookFailed, md)
+}
+
+// withRuleID frames a decision reason with the rule that produced it, so
+// users and policy owners can tell which specific rule fired.
+func withRuleID(msgs *messages.Catalog, input *hook.Input, h config.HookEntry, ruleID, reason string) string {
+	if ruleID == "" {
+		return reason
+	}
+	md := messageData(input, h)
+	md.RuleID = ruleID
+	md.Reason = reason
+	return msgs.Render(messages.RuleReason, md)
+}
+
+// runnerErrorReason builds the deny reason shown to the user when a hook
+// could not be run, phrased by failure class so the fix is obvious.
+func runnerErrorReason(msgs *messages.Catalog, input *hook.Input, h config.HookEntry, err error) string {
+	md := messageData(input, h)
+	md.Error = err.Error()
+	switch runner.Kind(err) {
+	case runner.KindNotFound:
+		return msgs.Render(messages.CommandNotFound, md)
+	case runner.KindPermission:
+		return msgs.Render(messages.PermissionDenied, md)
+	case runner.KindTimeout:
+		return msgs.Render(messages.HookTimeout, md)
+	default:
+		return msgs.Render(messages.RunnerError, md)
+	}
+}
+
 // extractToolDetail extracts a human-readable summary from tool_input for audit display.
 // Supports Bash (command), Read (file path), Write (file path + line count),
 // and Edit (file path + lines removed/added). Returns empty string for
@@ -359,28 +1411,139 @@ func countLines(s string) int {
 	return strings.Count(s, "\n") + 1
 }
 
+// timedRunner adds the time each hook run takes to spent.
+type timedRunner struct {
+	runner.Runner
+	spent *time.Duration
+}
+
+func (t timedRunner) Run(ctx context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
+	start := time.Now()
+	res, err := t.Runner.Run(ctx, h, input)
+	*t.spent += time.Since(start)
+	return res, err
+}
+
 // recordAudit sends a chain execution record to the auditor. Errors are logged
 // but never affect the pipeline return value.
-func recordAudit(auditor audit.Auditor, input *hook.Input, chainLen int, outcome string, reason string, chainStart time.Time, hookResults []audit.HookResult, logger *slog.Logger) {
+func recordAudit(auditor audit.Auditor, input *hook.Input, chainLen int, outcome string, reason string, modified, addedContext bool, riskScore *float64, chainStart time.Time, inHooks time.Duration, hookResults []audit.HookResult, logger *slog.Logger) {
 	if auditor == nil {
 		return
 	}
+	elapsed := time.Since(chainStart)
 	entry := audit.ChainExecution{
-		EventName:  input.HookEventName,
-		ToolName:   input.ToolName,
-		ToolDetail: extractToolDetail(input),
-		ChainLen:   chainLen,
-		Outcome:    outcome,
-		Reason:     reason,
-		DurationMs: time.Since(chainStart).Milliseconds(),
-		SessionID:  input.SessionID,
-		Hooks:      hookResults,
+		EventName:       input.HookEventName,
+		ToolName:        input.ToolName,
+		ToolDetail:      extractToolDetail(input),
+		ChainLen:        chainLen,
+		Outcome:         outcome,
+		Reason:          reason,
+		DurationMs:      elapsed.Milliseconds(),
+		SessionID:       input.SessionID,
+		Hooks:           hookResults,
+		ProtocolVersion: input.Fingerprint().Version,
+		OverheadMs:      max(elapsed-inHooks, 0).Milliseconds(),
+		Modified:        modified,
+		AddedContext:    addedContext,
+		RiskScore:       riskScore,
 	}
 	if err := auditor.RecordChain(entry); err != nil {
 		logger.Warn("audit record failed", "err", err)
 	}
 }
 
+// writeNotes appends the chain's transcript notes: hook-supplied notes,
+// waived denials, and the decision itself unless the chain allowed.
+func (o *options) writeNotes(input *hook.Input, chainLen int, outcome, reason string, hookResults []audit.HookResult, hookNotes []transcript.Note, logger *slog.Logger) {
+	if o.notesPath == "" {
+		return
+	}
+
+	notes := slices.Clone(hookNotes)
+	var decider audit.HookResult
+	for _, hr := range hookResults {
+		switch {
+		case hr.Outcome == audit.HookOutcomeWaived:
+			notes = append(notes, transcript.Note{Hook: hr.HookName, Kind: transcript.KindWaived, RuleID: hr.RuleID, Text: hr.Stderr})
+		case hr.HookIndex < chainLen && hr.Outcome == outcome:
+			decider = hr
+		}
+	}
+	switch outcome {
+	case transcript.KindDeny, transcript.KindAsk, transcript.KindError:
+		notes = append(notes, transcript.Note{Hook: decider.HookName, Kind: outcome, RuleID: decider.RuleID, Text: reason})
+	}
+
+	now := time.Now().UTC()
+	for i := range notes {
+		notes[i].Timestamp = now
+		notes[i].SessionID = input.SessionID
+		notes[i].ToolUseID = input.ToolUseID
+		notes[i].Event = input.HookEventName
+		notes[i].Tool = input.ToolName
+	}
+	if err := transcript.Append(o.notesPath, notes); err != nil {
+		logger.Warn("failed to write transcript notes", "err", err)
+	}
+}
+
+// hookDeny builds the deny Result for a denial attributed to hook h. When a
+// runbook is configured for ruleID or h, its link is appended to the reason
+// and surfaced as the systemMessage. It returns the reason as shown.
+func (o *options) hookDeny(input *hook.Input, h config.HookEntry, ruleID, reason string) (Result, string) {
+	url := o.runbooks.URLFor(ruleID, h.Name)
+	if url == "" {
+		return denyResult(input.HookEventName, reason), reason
+	}
+	md := messageData(input, h)
+	md.RuleID = ruleID
+	md.Reason = strings.TrimSpace(reason)
+	md.URL = url
+	reason = o.msgs.Render(messages.RunbookReason, md)
+	return buildDecisionResult(input.HookEventName, "deny", reason, o.msgs.Render(messages.RunbookSystem, md)), reason
+}
+
+// waive reports whether a denial from h is covered by an active exception
+// for the tool call's target, returning the warning shown in its place.
+func (o *options) waive(input *hook.Input, h config.HookEntry, toolInput json.RawMessage, reason string, logger *slog.Logger) (string, bool) {
+	if len(o.exceptions.Exceptions) == 0 {
+		return "", false
+	}
+	ex, ok := o.exceptions.MatchException(h.Name, exceptionTargets(toolInput), input.CWD, time.Now().UTC())
+	if !ok {
+		return "", false
+	}
+	logger.Warn("hook denial waived by exception", "hook", h.Name, "exception", ex.ID, "pattern", ex.Pattern, "reason", reason)
+	md := messageData(input, h)
+	md.Reason = strings.TrimSpace(reason)
+	md.Exception = ex.ID
+	md.ExceptionReason = ex.Reason
+	return o.msgs.Render(messages.ExceptionApplied, md), true
+}
+
+// exceptionTargetFields are the tool_input fields exceptions are matched against.
+type exceptionTargetFields struct {
+	FilePath     string `json:"file_path"`
+	NotebookPath string `json:"notebook_path"`
+	Path         string `json:"path"`
+	Command      string `json:"command"`
+}
+
+// exceptionTargets extracts the file paths and Bash command from tool_input.
+func exceptionTargets(toolInput json.RawMessage) []string {
+	var f exceptionTargetFields
+	if err := json.Unmarshal(toolInput, &f); err != nil {
+		return nil
+	}
+	var targets []string
+	for _, t := range []string{f.FilePath, f.NotebookPath, f.Path, f.Command} {
+		if t != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
 // denyResult builds a deny Result with exit code 2.
 func denyResult(eventName, reason string) Result {
 	out := hook.Output{
@@ -401,14 +1564,27 @@ func denyResult(eventName, reason string) Result {
 	return Result{ExitCode: 2, Output: data}
 }
 
-// buildDecisionResult builds a Result for a specific permission decision.
-func buildDecisionResult(eventName, decision, reason string) Result {
+// askInstead turns a deny Result into an ask with the same reason and
+// systemMessage.
+func askInstead(res Result) Result {
+	var out hook.Output
+	if err := json.Unmarshal(res.Output, &out); err != nil {
+		return res
+	}
+	hso := out.HookSpecificOutput
+	return buildDecisionResult(hso.HookEventName, "ask", hso.PermissionDecisionReason, out.SystemMessage)
+}
+
+// buildDecisionResult builds a Result for a specific permission decision,
+// with an optional systemMessage shown to the user.
+func buildDecisionResult(eventName, decision, reason, systemMessage string) Result {
 	out := hook.Output{
 		HookSpecificOutput: hook.HookSpecificOutput{
 			HookEventName:            eventName,
 			PermissionDecision:       decision,
 			PermissionDecisionReason: reason,
 		},
+		SystemMessage: systemMessage,
 	}
 	data, err := json.Marshal(out)
 	if err != nil {
@@ -6,14 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Fuabioo/hook-chain/internal/audit"
 	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/events"
 	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/messages"
 	"github.com/Fuabioo/hook-chain/internal/runner"
+	"github.com/Fuabioo/hook-chain/internal/state"
+	"github.com/Fuabioo/hook-chain/internal/transcript"
 )
 
 // mockRunner implements runner.Runner for testing.
@@ -30,11 +38,12 @@ type mockResult struct {
 
 type mockCall struct {
 	hookName string
+	command  string
 	input    []byte
 }
 
 func (m *mockRunner) Run(_ context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
-	m.calls = append(m.calls, mockCall{hookName: h.Name, input: input})
+	m.calls = append(m.calls, mockCall{hookName: h.Name, command: h.Command, input: input})
 	if m.callIdx >= len(m.results) {
 		return runner.Result{}, nil
 	}
@@ -310,6 +319,1041 @@ func TestOnErrorSkipForRunnerError(t *testing.T) {
 	}
 }
 
+func TestOnErrorAllow(t *testing.T) {
+	tests := []struct {
+		name   string
+		result mockResult
+		reason string
+	}{
+		{"runner error", mockResult{err: errors.New("binary not found")}, `hook "advisor" runner error: binary not found`},
+		{"non-zero exit", mockResult{result: runner.Result{ExitCode: 1, Stderr: "boom"}}, `hook "advisor" exited 1`},
+		{"invalid JSON", mockResult{result: runner.Result{Stdout: []byte("not json")}}, `hook "advisor" invalid JSON`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{
+				{Name: "ctx", Command: "ctx"},
+				{Name: "advisor", Command: "advisor", OnError: "allow"},
+				{Name: "never", Command: "never"},
+			}
+			m := &mockRunner{results: []mockResult{
+				{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"additionalContext":"note"}}`)}},
+				tt.result,
+				{result: runner.Result{ExitCode: 2, Stderr: "would deny"}},
+			}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if result.ExitCode != 0 {
+				t.Fatalf("ExitCode = %d, want 0", result.ExitCode)
+			}
+			if len(m.calls) != 2 {
+				t.Errorf("calls = %d, want 2 (later hooks skipped)", len(m.calls))
+			}
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if out.HookSpecificOutput.AdditionalContext != "note" {
+				t.Errorf("additionalContext = %q, want earlier hook's context kept", out.HookSpecificOutput.AdditionalContext)
+			}
+
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			e := aud.entries[0]
+			if e.Outcome != audit.OutcomeAllow || !strings.HasPrefix(e.Reason, tt.reason) {
+				t.Errorf("audit = %s %q, want allow %q", e.Outcome, e.Reason, tt.reason)
+			}
+			if len(e.Hooks) != 2 || e.Hooks[1].Outcome != audit.HookOutcomeFailOpen {
+				t.Errorf("hook results = %+v, want advisor recorded as %s", e.Hooks, audit.HookOutcomeFailOpen)
+			}
+		})
+	}
+}
+
+func TestAuditModifiedAndContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		wantMod     bool
+		wantContext bool
+	}{
+		{"plain allow", `{}`, false, false},
+		{"rewrite", `{"hookSpecificOutput":{"updatedInput":{"command":"ls -la"}}}`, true, false},
+		{"context", `{"hookSpecificOutput":{"additionalContext":"note"}}`, false, true},
+		{"rewrite and context", `{"hookSpecificOutput":{"updatedInput":{"command":"ls -la"},"additionalContext":"note"}}`, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "h", Command: "h"}}
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte(tt.stdout)}}}}
+			aud := &mockAuditor{}
+
+			Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			e := aud.entries[0]
+			if e.Outcome != audit.OutcomeAllow || e.Modified != tt.wantMod || e.AddedContext != tt.wantContext {
+				t.Errorf("audit = %s modified=%v context=%v, want allow modified=%v context=%v",
+					e.Outcome, e.Modified, e.AddedContext, tt.wantMod, tt.wantContext)
+			}
+		})
+	}
+}
+
+// cancellingRunner cancels the run's context during its at-th call, as the
+// agent giving up on a slow hook would.
+type cancellingRunner struct {
+	*mockRunner
+	cancel context.CancelFunc
+	at     int
+}
+
+func (c cancellingRunner) Run(ctx context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
+	res, err := c.mockRunner.Run(ctx, h, input)
+	if len(c.calls) == c.at {
+		c.cancel()
+	}
+	return res, err
+}
+
+func TestCancelledChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		at         int // call that cancels; 0 cancels before the chain runs
+		wantCalls  int
+		wantHooks  []string
+		wantReason string
+	}{
+		{"while hook runs", 2, 2, []string{audit.HookOutcomePass, audit.HookOutcomeCancelled}, `cancelled while hook "slow" ran`},
+		{"before chain", 0, 0, nil, `cancelled before hook "first"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.at == 0 {
+				cancel()
+			}
+			hooks := []config.HookEntry{
+				{Name: "first", Command: "first"},
+				{Name: "slow", Command: "slow"},
+				{Name: "never", Command: "never"},
+			}
+			m := &mockRunner{results: []mockResult{
+				{result: runner.Result{}},
+				{result: runner.Result{ExitCode: -1, Signal: "SIGKILL"}, err: errors.New("signal: killed")},
+			}}
+			r := cancellingRunner{mockRunner: m, cancel: cancel, at: tt.at}
+			aud := &mockAuditor{}
+			var outcome string
+
+			result := Run(ctx, makeInput(`{"command":"ls"}`), hooks, r, aud, testLogger(),
+				WithFinally([]config.HookEntry{{Name: "notify", Command: "notify"}}),
+				WithDecisionHandler(func(o, _ string) { outcome = o }))
+			if result.ExitCode != 0 || result.Output != nil {
+				t.Errorf("result = %d %s, want no decision", result.ExitCode, result.Output)
+			}
+			if len(m.calls) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d (no later or finally hooks)", len(m.calls), tt.wantCalls)
+			}
+			if outcome != audit.OutcomeCancelled {
+				t.Errorf("decided outcome = %q, want %q", outcome, audit.OutcomeCancelled)
+			}
+
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			e := aud.entries[0]
+			if e.Outcome != audit.OutcomeCancelled || !strings.HasPrefix(e.Reason, tt.wantReason) {
+				t.Errorf("audit = %s %q, want %s %q", e.Outcome, e.Reason, audit.OutcomeCancelled, tt.wantReason)
+			}
+			var got []string
+			for _, hr := range e.Hooks {
+				got = append(got, hr.Outcome)
+			}
+			if !slices.Equal(got, tt.wantHooks) {
+				t.Errorf("hook outcomes = %v, want %v", got, tt.wantHooks)
+			}
+			if n := len(e.Hooks); n > 0 && (e.Hooks[n-1].HookName != "slow" || e.Hooks[n-1].Signal != "SIGKILL") {
+				t.Errorf("in-flight hook = %+v, want slow with its signal", e.Hooks[n-1])
+			}
+		})
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	codes := map[int]config.ExitCodeRule{
+		1: {Decision: config.ExitPass, Reason: "{{.Hook}} warns: {{.Reason}}"},
+		2: {Decision: config.ExitSkip},
+		3: {Decision: config.ExitDeny},
+		4: {Decision: config.ExitAsk, Reason: "{{.Hook}} wants a human (exit {{.ExitCode}})"},
+	}
+	tests := []struct {
+		name        string
+		exit        int
+		wantExit    int
+		wantOutcome string
+		wantHook    string
+		wantReason  string
+		wantCalls   int
+	}{
+		{"pass with warning", 1, 0, audit.OutcomeAllow, audit.HookOutcomePass, "legacy warns: careful", 2},
+		{"2 remapped to skip", 2, 0, audit.OutcomeAllow, audit.HookOutcomeSkip, "", 2},
+		{"deny", 3, 2, audit.OutcomeDeny, audit.HookOutcomeDeny, "careful", 1},
+		{"ask", 4, 0, audit.OutcomeAsk, audit.HookOutcomeAsk, "legacy wants a human (exit 4)", 1},
+		{"unmapped keeps on_error", 5, 2, audit.OutcomeDeny, audit.HookOutcomeDeny, "careful", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{
+				{Name: "legacy", Command: "legacy", ExitCodes: codes},
+				{Name: "next", Command: "next"},
+			}
+			m := &mockRunner{results: []mockResult{{result: runner.Result{ExitCode: tt.exit, Stderr: "careful"}}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			if len(m.calls) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", len(m.calls), tt.wantCalls)
+			}
+			e := aud.entries[0]
+			if e.Outcome != tt.wantOutcome || e.Reason != tt.wantReason {
+				t.Errorf("chain = %s %q, want %s %q", e.Outcome, e.Reason, tt.wantOutcome, tt.wantReason)
+			}
+			if e.Hooks[0].Outcome != tt.wantHook {
+				t.Errorf("hook outcome = %s, want %s", e.Hooks[0].Outcome, tt.wantHook)
+			}
+		})
+	}
+}
+
+func TestOnErrorRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		hook      config.HookEntry
+		results   []mockResult
+		wantExit  int
+		wantCalls int
+		wantHooks []string
+		wantChain string
+	}{
+		{
+			name:      "succeeds on retry",
+			hook:      config.HookEntry{Name: "flaky", Command: "flaky", OnError: "retry", RetryDelay: time.Millisecond},
+			results:   []mockResult{{result: runner.Result{ExitCode: 1, Stderr: "locked"}}, {result: runner.Result{ExitCode: 0}}},
+			wantCalls: 2,
+			wantHooks: []string{audit.HookOutcomeRetry, audit.HookOutcomePass},
+			wantChain: audit.OutcomeAllow,
+		},
+		{
+			name:      "retries exhausted deny",
+			hook:      config.HookEntry{Name: "flaky", Command: "flaky", OnError: "retry", RetryDelay: time.Millisecond},
+			results:   []mockResult{{err: fmt.Errorf("runner: %w", runner.ErrTimeout)}, {err: fmt.Errorf("runner: %w", runner.ErrTimeout)}, {result: runner.Result{ExitCode: 1}}},
+			wantExit:  2,
+			wantCalls: 3,
+			wantHooks: []string{audit.HookOutcomeRetry, audit.HookOutcomeRetry, audit.HookOutcomeDeny},
+			wantChain: audit.OutcomeDeny,
+		},
+		{
+			name:      "retries then skip",
+			hook:      config.HookEntry{Name: "flaky", Command: "flaky", OnError: "skip", Retries: 1, RetryDelay: time.Millisecond},
+			results:   []mockResult{{result: runner.Result{ExitCode: 1}}, {result: runner.Result{ExitCode: 1}}},
+			wantCalls: 2,
+			wantHooks: []string{audit.HookOutcomeRetry, audit.HookOutcomeSkip},
+			wantChain: audit.OutcomeAllow,
+		},
+		{
+			name:      "not found is not retried",
+			hook:      config.HookEntry{Name: "flaky", Command: "flaky", OnError: "retry", RetryDelay: time.Millisecond},
+			results:   []mockResult{{err: fmt.Errorf("runner: %w", runner.ErrNotFound)}},
+			wantExit:  2,
+			wantCalls: 1,
+			wantHooks: []string{audit.HookOutcomeError},
+			wantChain: audit.OutcomeError,
+		},
+		{
+			name:      "deny is not retried",
+			hook:      config.HookEntry{Name: "flaky", Command: "flaky", OnError: "retry", RetryDelay: time.Millisecond},
+			results:   []mockResult{{result: runner.Result{ExitCode: 2}}},
+			wantExit:  2,
+			wantCalls: 1,
+			wantHooks: []string{audit.HookOutcomeDeny},
+			wantChain: audit.OutcomeDeny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockRunner{results: tt.results}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), []config.HookEntry{tt.hook}, m, aud, testLogger())
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			if len(m.calls) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", len(m.calls), tt.wantCalls)
+			}
+			e := aud.entries[0]
+			if e.Outcome != tt.wantChain {
+				t.Errorf("chain outcome = %s, want %s", e.Outcome, tt.wantChain)
+			}
+			var got []string
+			for _, hr := range e.Hooks {
+				got = append(got, hr.Outcome)
+				if hr.HookIndex != 0 {
+					t.Errorf("hook index = %d, want 0 for every attempt", hr.HookIndex)
+				}
+			}
+			if !slices.Equal(got, tt.wantHooks) {
+				t.Errorf("hook outcomes = %v, want %v", got, tt.wantHooks)
+			}
+		})
+	}
+}
+
+func TestDisabledHooksSkipped(t *testing.T) {
+	hooks := []config.HookEntry{
+		{Name: "off", Command: "off", Disabled: true},
+		{Name: "on", Command: "on"},
+	}
+	m := &mockRunner{results: []mockResult{{result: runner.Result{ExitCode: 0}}, {result: runner.Result{ExitCode: 0}}}}
+	aud := &mockAuditor{}
+
+	result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger(),
+		WithFinally([]config.HookEntry{{Name: "notify-off", Command: "n", Disabled: true}}))
+	if result.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	if len(m.calls) != 1 || m.calls[0].hookName != "on" {
+		t.Errorf("calls = %v, want only the enabled hook", m.calls)
+	}
+	if e := aud.entries[0]; len(e.Hooks) != 1 || e.Hooks[0].HookName != "on" {
+		t.Errorf("audited hooks = %+v, want only the enabled hook", e.Hooks)
+	}
+}
+
+func TestTextOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		reportOnly  bool
+		wantExit    int
+		wantContext string
+		wantOutcome string
+	}{
+		{"text is context", config.OutputText, false, 0, "line 3: trailing space", audit.HookOutcomeContext},
+		{"text-deny denies", config.OutputTextDeny, false, 2, "", audit.HookOutcomeDeny},
+		{"text-deny report-only", config.OutputTextDeny, true, 0, "", audit.HookOutcomeReport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "lint", Command: "lint", Output: tt.output, ReportOnly: tt.reportOnly}}
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte("line 3: trailing space\n")}}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if result.ExitCode != tt.wantExit {
+				t.Fatalf("ExitCode = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			var out hook.Output
+			if len(result.Output) > 0 {
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+			}
+			if got := out.HookSpecificOutput.AdditionalContext; got != tt.wantContext {
+				t.Errorf("additionalContext = %q, want %q", got, tt.wantContext)
+			}
+			if tt.output == config.OutputTextDeny && !tt.reportOnly && out.HookSpecificOutput.PermissionDecisionReason != "line 3: trailing space" {
+				t.Errorf("deny reason = %q", out.HookSpecificOutput.PermissionDecisionReason)
+			}
+			if got := aud.entries[0].Hooks[0].Outcome; got != tt.wantOutcome {
+				t.Errorf("hook outcome = %s, want %s", got, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestPatchAudited(t *testing.T) {
+	hooks := []config.HookEntry{
+		{Name: "rewrite", Command: "rewrite"},
+		{Name: "inject", Command: "inject"},
+	}
+	m := &mockRunner{results: []mockResult{
+		{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"updatedInput":{"command":"ls -la"}}}`)}},
+		{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"updatedInput":{"env":{"GITHUB_TOKEN":"ghp_x"}}}}`)}},
+	}}
+	aud := &mockAuditor{}
+
+	Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+	e := aud.entries[0]
+	if got := e.Hooks[0].Patch; got != `{"command":"ls -la"}` {
+		t.Errorf("rewrite patch = %s", got)
+	}
+	if got := e.Hooks[1].Patch; got != `{"env":{"GITHUB_TOKEN":"[REDACTED]"}}` {
+		t.Errorf("inject patch = %s, want the token redacted", got)
+	}
+}
+
+func TestRunnerErrorKindRecorded(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantKind   string
+		wantReason string
+	}{
+		{"not found", fmt.Errorf("runner: %w", runner.ErrNotFound), runner.KindNotFound, "not found"},
+		{"permission", fmt.Errorf("runner: %w", runner.ErrPermission), runner.KindPermission, "permission denied"},
+		{"timeout", fmt.Errorf("runner: %w", runner.ErrTimeout), runner.KindTimeout, "timed out"},
+		{"other", errors.New("pipe broke"), runner.KindOther, "failed: pipe broke"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "broken", Command: "broken"}}
+			m := &mockRunner{results: []mockResult{{err: tt.err}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if result.ExitCode != 2 {
+				t.Fatalf("ExitCode = %d, want 2", result.ExitCode)
+			}
+
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if reason := out.HookSpecificOutput.PermissionDecisionReason; !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want it to contain %q", reason, tt.wantReason)
+			}
+
+			if len(aud.entries) != 1 || len(aud.entries[0].Hooks) != 1 {
+				t.Fatalf("expected 1 audited chain with 1 hook, got %+v", aud.entries)
+			}
+			if got := aud.entries[0].Hooks[0].ErrorKind; got != tt.wantKind {
+				t.Errorf("ErrorKind = %q, want %q", got, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestHookMetadataRecorded(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   string
+	}{
+		{"deny with metadata", `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no","metadata":{"ruleId": "R1", "score": 0.9}}}`, `{"ruleId":"R1","score":0.9}`},
+		{"pass with metadata", `{"hookSpecificOutput":{"metadata":{"matched":["rm"]}}}`, `{"matched":["rm"]}`},
+		{"non-object dropped", `{"hookSpecificOutput":{"metadata":["rm"]}}`, ``},
+		{"no metadata", `{"hookSpecificOutput":{}}`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}}
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte(tt.stdout)}}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+			if strings.Contains(string(result.Output), "metadata") {
+				t.Errorf("metadata leaked into output: %s", result.Output)
+			}
+			if len(aud.entries) != 1 || len(aud.entries[0].Hooks) != 1 {
+				t.Fatalf("expected 1 audited chain with 1 hook, got %+v", aud.entries)
+			}
+			if got := string(aud.entries[0].Hooks[0].Metadata); got != tt.want {
+				t.Errorf("Metadata = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleIDInDecisionReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		stdout     string
+		wantCode   int
+		wantReason string
+		wantRule   string
+	}{
+		{"deny with rule", `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"rm is blocked","ruleId":"no-rm"}}`, 2, "[no-rm] rm is blocked", "no-rm"},
+		{"ask with rule", `{"hookSpecificOutput":{"permissionDecision":"ask","permissionDecisionReason":"confirm push","ruleId":"git-push"}}`, 0, "[git-push] confirm push", "git-push"},
+		{"deny without rule", `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"nope"}}`, 2, "nope", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}}
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte(tt.stdout)}}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"rm -rf /"}`), hooks, m, aud, testLogger())
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if got := out.HookSpecificOutput.PermissionDecisionReason; got != tt.wantReason {
+				t.Errorf("reason = %q, want %q", got, tt.wantReason)
+			}
+			if len(aud.entries) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(aud.entries))
+			}
+			if got := aud.entries[0].Reason; got != tt.wantReason {
+				t.Errorf("audit Reason = %q, want %q", got, tt.wantReason)
+			}
+			if got := aud.entries[0].Hooks[0].RuleID; got != tt.wantRule {
+				t.Errorf("audit RuleID = %q, want %q", got, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestSeverityActions(t *testing.T) {
+	actions := map[string]string{"info": "context", "warn": "ask", "critical": "deny"}
+	tests := []struct {
+		name         string
+		hook         config.HookEntry
+		stdout       string
+		wantCode     int
+		wantDecision string // "" = allow
+		wantContext  string
+		wantOutcome  string
+		wantSeverity string
+	}{
+		{
+			name:         "warn deny downgraded to ask",
+			stdout:       `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"risky","severity":"warn"}}`,
+			wantDecision: "ask", wantOutcome: "ask", wantSeverity: "warn",
+		},
+		{
+			name:        "info deny passed as context",
+			stdout:      `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"style nit","ruleId":"R1","severity":"info"}}`,
+			wantContext: "[R1] style nit", wantOutcome: "context", wantSeverity: "info",
+		},
+		{
+			name:         "critical reason without decision denies",
+			stdout:       `{"hookSpecificOutput":{"permissionDecisionReason":"secret found","severity":"CRITICAL"}}`,
+			wantCode:     2,
+			wantDecision: "deny", wantOutcome: "deny", wantSeverity: "critical",
+		},
+		{
+			name:         "unmapped severity keeps decision",
+			stdout:       `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no","severity":"high"}}`,
+			wantCode:     2,
+			wantDecision: "deny", wantOutcome: "deny", wantSeverity: "high",
+		},
+		{
+			name:         "hook default severity",
+			hook:         config.HookEntry{Severity: "warn"},
+			stdout:       `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no"}}`,
+			wantDecision: "ask", wantOutcome: "ask", wantSeverity: "warn",
+		},
+		{
+			name:         "unknown severity ignored",
+			stdout:       `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no","severity":"medium"}}`,
+			wantCode:     2,
+			wantDecision: "deny", wantOutcome: "deny",
+		},
+		{
+			name:        "plain pass",
+			stdout:      `{"hookSpecificOutput":{"severity":"critical"}}`,
+			wantOutcome: "pass", wantSeverity: "critical",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.hook
+			h.Name, h.Command = "guard", "guard"
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte(tt.stdout)}}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), []config.HookEntry{h}, m, aud, testLogger(), WithSeverityActions(actions))
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			var out hook.Output
+			if len(result.Output) > 0 {
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+			}
+			hso := out.HookSpecificOutput
+			if hso.PermissionDecision != tt.wantDecision {
+				t.Errorf("decision = %q, want %q", hso.PermissionDecision, tt.wantDecision)
+			}
+			if hso.AdditionalContext != tt.wantContext {
+				t.Errorf("additionalContext = %q, want %q", hso.AdditionalContext, tt.wantContext)
+			}
+			if hso.Severity != "" {
+				t.Errorf("severity leaked into output: %s", result.Output)
+			}
+			hr := aud.entries[0].Hooks[0]
+			if hr.Outcome != tt.wantOutcome || hr.Severity != tt.wantSeverity {
+				t.Errorf("audited %s/%q, want %s/%q", hr.Outcome, hr.Severity, tt.wantOutcome, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestProtectedFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		stdout      string
+		wantCode    int
+		wantUpdated string // "" = no updatedInput
+		wantOutcome string
+		wantStderr  string
+	}{
+		{
+			name:        "deny on changed field",
+			stdout:      `{"hookSpecificOutput":{"updatedInput":{"command":"rm -rf /","timeout":5}}}`,
+			wantCode:    2,
+			wantOutcome: "deny", wantStderr: "updatedInput changes protected fields: command",
+		},
+		{
+			name:        "strip keeps the rest",
+			action:      config.ProtectedStrip,
+			stdout:      `{"hookSpecificOutput":{"updatedInput":{"command":"rm -rf /","timeout":5}}}`,
+			wantUpdated: `{"command":"ls","timeout":5}`,
+			wantOutcome: "merge", wantStderr: "stripped protected fields from updatedInput: command",
+		},
+		{
+			name:        "strip everything",
+			action:      config.ProtectedStrip,
+			stdout:      `{"hookSpecificOutput":{"updatedInput":{"command":"pwd"}}}`,
+			wantOutcome: "pass", wantStderr: "stripped protected fields from updatedInput: command",
+		},
+		{
+			name:        "patch that cannot be checked",
+			action:      config.ProtectedStrip,
+			stdout:      `{"hookSpecificOutput":{"updatedInput":["rm","-rf","/"]}}`,
+			wantCode:    2,
+			wantOutcome: audit.HookOutcomeError, wantStderr: "protectedChanges patch: ", // then the decoder's message
+		},
+		{
+			name:        "same value is no change",
+			stdout:      `{"hookSpecificOutput":{"updatedInput":{"command":"ls","timeout":5}}}`,
+			wantUpdated: `{"command":"ls","timeout":5}`,
+			wantOutcome: "merge",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockRunner{results: []mockResult{{result: runner.Result{Stdout: []byte(tt.stdout)}}}}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{{Name: "rewriter", Command: "rewriter"}}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger(), WithProtectedFields([]string{"command"}, tt.action))
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			var out hook.Output
+			if len(result.Output) > 0 {
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+			}
+			if tt.wantOutcome == "deny" && !strings.Contains(out.HookSpecificOutput.PermissionDecisionReason, "protected tool_input fields: command") {
+				t.Errorf("reason = %q, want the protected field named", out.HookSpecificOutput.PermissionDecisionReason)
+			}
+			if got := string(out.HookSpecificOutput.UpdatedInput); got != tt.wantUpdated {
+				t.Errorf("updatedInput = %s, want %s", got, tt.wantUpdated)
+			}
+			hr := aud.entries[0].Hooks[0]
+			stderrOK := hr.Stderr == tt.wantStderr || tt.wantOutcome == audit.HookOutcomeError && strings.HasPrefix(hr.Stderr, tt.wantStderr)
+			if hr.Outcome != tt.wantOutcome || !stderrOK {
+				t.Errorf("audited %s/%q, want %s/%q", hr.Outcome, hr.Stderr, tt.wantOutcome, tt.wantStderr)
+			}
+		})
+	}
+}
+
+// fakeQuotas grants the keys in allow and refuses every other key.
+type fakeQuotas struct {
+	allow map[string]bool
+	err   error
+	taken []string
+}
+
+func (f *fakeQuotas) Take(key string, _ int, _ time.Duration) (bool, error) {
+	f.taken = append(f.taken, key)
+	return f.allow[key], f.err
+}
+
+func TestStateDisabledHooksAudited(t *testing.T) {
+	until := time.Now().Add(time.Hour).UTC()
+	disabled := []state.DisabledHook{
+		{Name: "guard", Reason: "false positive", Until: until},
+		{Name: "notify", CreatedAt: time.Now()},
+		{Name: "lint", Until: time.Now().Add(-time.Minute)}, // expired
+	}
+	hooks := []config.HookEntry{{Name: "guard", Command: "guard"}, {Name: "lint", Command: "lint"}}
+	m := &mockRunner{}
+	aud := &mockAuditor{}
+
+	result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger(),
+		WithFinally([]config.HookEntry{{Name: "notify", Command: "n"}}), WithDisabledHooks(disabled))
+	if result.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	if len(m.calls) != 1 || m.calls[0].hookName != "lint" {
+		t.Errorf("calls = %v, want only lint", m.calls)
+	}
+	got := aud.entries[0].Hooks
+	want := []audit.HookResult{
+		{HookIndex: 0, HookName: "guard", Outcome: audit.HookOutcomeSkip, Stderr: "disabled until " + until.Format(time.RFC3339) + ": false positive"},
+		{HookIndex: 1, HookName: "lint", Outcome: audit.HookOutcomePass},
+		{HookIndex: 2, HookName: "notify", Outcome: audit.HookOutcomeSkip, Stderr: "disabled until re-enabled"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("audited hooks = %+v, want %d", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.HookIndex != w.HookIndex || g.HookName != w.HookName || g.Outcome != w.Outcome || g.Stderr != w.Stderr {
+			t.Errorf("hook %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	hourly := &config.QuotaConfig{Max: 1, Per: time.Hour}
+	tests := []struct {
+		name        string
+		reportOnly  bool
+		action      string
+		chain       bool // quota on the chain instead of the hook
+		storeErr    error
+		wantCode    int
+		wantCalls   []string
+		wantOutcome string // the reviewer's audited outcome, "" = no result
+		wantFinal   string
+	}{
+		{name: "deny", wantCode: 2, wantOutcome: "deny", wantFinal: "deny"},
+		{name: "ask", action: config.QuotaAsk, wantOutcome: "ask", wantFinal: "ask"},
+		{name: "skip", action: config.QuotaSkip, wantCalls: []string{"lint"}, wantOutcome: "quota", wantFinal: "allow"},
+		{name: "report-only only skipped", reportOnly: true, wantCalls: []string{"lint"}, wantOutcome: "quota", wantFinal: "allow"},
+		{name: "store error runs the hook", storeErr: errors.New("locked"), wantCalls: []string{"review", "lint"}, wantOutcome: "pass", wantFinal: "allow"},
+		{name: "chain deny", chain: true, wantCode: 2, wantFinal: "deny"},
+		{name: "chain skip", chain: true, action: config.QuotaSkip, wantFinal: "allow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := *hourly
+			q.OnExceeded = tt.action
+			review := config.HookEntry{Name: "review", Command: "review", ReportOnly: tt.reportOnly}
+			var chainQuota *config.QuotaConfig
+			if tt.chain {
+				chainQuota = &q
+			} else {
+				review.Quota = &q
+			}
+			hooks := []config.HookEntry{review, {Name: "lint", Command: "lint"}}
+			quotas := &fakeQuotas{err: tt.storeErr}
+			m := &mockRunner{}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger(), WithQuotas(quotas, "chain:x", chainQuota))
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			var calls []string
+			for _, c := range m.calls {
+				calls = append(calls, c.hookName)
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("ran %v, want %v", calls, tt.wantCalls)
+			}
+			entry := aud.entries[0]
+			if entry.Outcome != tt.wantFinal {
+				t.Errorf("chain outcome = %q, want %q", entry.Outcome, tt.wantFinal)
+			}
+			var got string
+			if len(entry.Hooks) > 0 && entry.Hooks[0].HookName == "review" {
+				got = entry.Hooks[0].Outcome
+			}
+			if got != tt.wantOutcome {
+				t.Errorf("review outcome = %q, want %q", got, tt.wantOutcome)
+			}
+			if tt.wantCode == 2 {
+				var out hook.Output
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+				if !strings.Contains(out.HookSpecificOutput.PermissionDecisionReason, "over its quota (1 per 1h)") {
+					t.Errorf("reason = %q, want the quota named", out.HookSpecificOutput.PermissionDecisionReason)
+				}
+			}
+		})
+	}
+}
+
+func TestDenyAsk(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       string
+		result      runner.Result
+		err         error
+		wantCode    int
+		wantDecide  string
+		wantOutcome string // chain outcome
+		wantHook    string // hook outcome
+	}{
+		{name: "exit 2 asks", event: "PreToolUse", result: runner.Result{ExitCode: 2, Stderr: "too big"}, wantDecide: "ask", wantOutcome: "ask", wantHook: "deny"},
+		{name: "json deny asks", event: "PreToolUse", result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"too big"}}`)}, wantDecide: "ask", wantOutcome: "ask", wantHook: "deny"},
+		{name: "runner error still denies", event: "PreToolUse", err: errors.New("boom"), wantCode: 2, wantDecide: "deny", wantOutcome: "error", wantHook: "error"},
+		{name: "other events still deny", event: "PostToolUse", result: runner.Result{ExitCode: 2, Stderr: "too big"}, wantCode: 2, wantDecide: "deny", wantOutcome: "deny", wantHook: "deny"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockRunner{results: []mockResult{{result: tt.result, err: tt.err}}}
+			aud := &mockAuditor{}
+			input := makeInput(`{"file_path":"/x"}`)
+			input.HookEventName = tt.event
+
+			result := Run(context.Background(), input, []config.HookEntry{{Name: "size", Command: "size"}}, m, aud, testLogger(), WithDenyAsk(true))
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if got := out.HookSpecificOutput.PermissionDecision; got != tt.wantDecide {
+				t.Errorf("decision = %q, want %q", got, tt.wantDecide)
+			}
+			if tt.wantDecide == "ask" && out.HookSpecificOutput.PermissionDecisionReason != "too big" {
+				t.Errorf("reason = %q, want the hook's", out.HookSpecificOutput.PermissionDecisionReason)
+			}
+			entry := aud.entries[0]
+			if entry.Outcome != tt.wantOutcome || entry.Hooks[0].Outcome != tt.wantHook {
+				t.Errorf("audited chain %s, hook %s; want %s, %s", entry.Outcome, entry.Hooks[0].Outcome, tt.wantOutcome, tt.wantHook)
+			}
+		})
+	}
+}
+
+func TestRiskScores(t *testing.T) {
+	ask, deny := 0.5, 0.8
+	scored := func(score string) mockResult {
+		return mockResult{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"riskScore":` + score + `}}`)}}
+	}
+	tests := []struct {
+		name       string
+		risk       *config.RiskConfig
+		results    []mockResult
+		reportOnly bool // the second hook is report-only
+		wantDecide string
+		wantScore  float64
+	}{
+		{name: "below thresholds allows", risk: &config.RiskConfig{Ask: &ask, Deny: &deny}, results: []mockResult{scored("0.2"), scored("0.4")}, wantScore: 0.4},
+		{name: "max asks", risk: &config.RiskConfig{Ask: &ask, Deny: &deny}, results: []mockResult{scored("0.6"), scored("0.1")}, wantDecide: "ask", wantScore: 0.6},
+		{name: "sum denies", risk: &config.RiskConfig{Aggregate: config.RiskSum, Ask: &ask, Deny: &deny}, results: []mockResult{scored("0.4"), scored("0.45")}, wantDecide: "deny", wantScore: 0.85},
+		{name: "avg allows", risk: &config.RiskConfig{Aggregate: config.RiskAvg, Ask: &ask}, results: []mockResult{scored("0.9"), scored("0")}, wantScore: 0.45},
+		{name: "report-only not combined", risk: &config.RiskConfig{Ask: &ask}, results: []mockResult{scored("0.1"), scored("0.9")}, reportOnly: true, wantScore: 0.1},
+		{name: "no thresholds records only", results: []mockResult{scored("0.9"), {}}, wantScore: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockRunner{results: tt.results}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}, {Name: "review", Command: "review", ReportOnly: tt.reportOnly}}
+
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger(), WithRisk(tt.risk))
+			decision := ""
+			if result.Output != nil {
+				var out hook.Output
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+				decision = out.HookSpecificOutput.PermissionDecision
+				if !strings.Contains(out.HookSpecificOutput.PermissionDecisionReason, "risk score") {
+					t.Errorf("reason = %q, want the risk score named", out.HookSpecificOutput.PermissionDecisionReason)
+				}
+			}
+			if decision != tt.wantDecide {
+				t.Errorf("decision = %q, want %q", decision, tt.wantDecide)
+			}
+			entry := aud.entries[0]
+			if entry.RiskScore == nil || math.Abs(*entry.RiskScore-tt.wantScore) > 1e-9 {
+				t.Errorf("audited risk score = %v, want %v", entry.RiskScore, tt.wantScore)
+			}
+			if h := entry.Hooks[0]; h.RiskScore == nil {
+				t.Error("hook risk score not audited")
+			}
+		})
+	}
+}
+
+func TestWithMessagesOverridesPhrasing(t *testing.T) {
+	msgs, err := messages.New(map[string]string{
+		messages.HookDenied: `{{.Hook}} blocked {{.Tool}}`,
+		messages.RuleReason: `{{.Reason}} (rule {{.RuleID}})`,
+	})
+	if err != nil {
+		t.Fatalf("messages.New: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		result runner.Result
+		want   string
+	}{
+		{"exit 2 without stderr", runner.Result{ExitCode: 2}, "guard blocked Bash"},
+		{"deny with rule", runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"no rm","ruleId":"R1"}}`)}, "no rm (rule R1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}}
+			m := &mockRunner{results: []mockResult{{result: tt.result}}}
+
+			result := Run(context.Background(), makeInput(`{"command":"rm -rf /"}`), hooks, m, nil, testLogger(), WithMessages(msgs))
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if got := out.HookSpecificOutput.PermissionDecisionReason; got != tt.want {
+				t.Errorf("reason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunbookAppendedToDenials(t *testing.T) {
+	runbooks := config.Runbooks{
+		Rules: map[string]string{"no-rm": "https://wiki.example/no-rm"},
+		Hooks: map[string]string{"guard": "https://wiki.example/guard"},
+	}
+
+	tests := []struct {
+		name       string
+		result     runner.Result
+		wantReason string
+		wantSystem string
+	}{
+		{
+			"rule runbook wins",
+			runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"rm blocked","ruleId":"no-rm"}}`)},
+			"[no-rm] rm blocked (runbook: https://wiki.example/no-rm)",
+			`hook-chain: denied by "guard". See https://wiki.example/no-rm for how to proceed or request an exception.`,
+		},
+		{
+			"hook runbook for exit 2",
+			runner.Result{ExitCode: 2, Stderr: "forbidden"},
+			"forbidden (runbook: https://wiki.example/guard)",
+			`hook-chain: denied by "guard". See https://wiki.example/guard for how to proceed or request an exception.`,
+		},
+		{
+			"ask is untouched",
+			runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"ask","permissionDecisionReason":"sure?"}}`)},
+			"sure?",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}}
+			m := &mockRunner{results: []mockResult{{result: tt.result}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(`{"command":"rm -rf /"}`), hooks, m, aud, testLogger(), WithRunbooks(runbooks))
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if got := out.HookSpecificOutput.PermissionDecisionReason; got != tt.wantReason {
+				t.Errorf("reason = %q, want %q", got, tt.wantReason)
+			}
+			if out.SystemMessage != tt.wantSystem {
+				t.Errorf("systemMessage = %q, want %q", out.SystemMessage, tt.wantSystem)
+			}
+			if len(aud.entries) != 1 || aud.entries[0].Reason != tt.wantReason {
+				t.Errorf("audit entries = %+v, want reason %q", aud.entries, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestExceptionWaivesDenial(t *testing.T) {
+	exceptions := []state.Exception{
+		{ID: "ex-1", Hook: "secret-scan", Pattern: "docs/*.md", Reason: "JIRA-123", Until: time.Now().Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		toolInput   string
+		result      runner.Result
+		wantCode    int
+		wantOutcome string
+	}{
+		{"exit 2 waived", `{"file_path":"docs/a.md"}`, runner.Result{ExitCode: 2, Stderr: "secret found"}, 0, audit.HookOutcomeWaived},
+		{"explicit deny waived", `{"file_path":"docs/a.md"}`, runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"secret found"}}`)}, 0, audit.HookOutcomeWaived},
+		{"non-matching path still denied", `{"file_path":"src/a.go"}`, runner.Result{ExitCode: 2, Stderr: "secret found"}, 2, audit.HookOutcomeDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "secret-scan", Command: "scan"}, {Name: "after", Command: "after"}}
+			m := &mockRunner{results: []mockResult{{result: tt.result}, {}}}
+			aud := &mockAuditor{}
+
+			result := Run(context.Background(), makeInput(tt.toolInput), hooks, m, aud, testLogger(), WithExceptions(exceptions))
+			if result.ExitCode != tt.wantCode {
+				t.Fatalf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			if len(aud.entries) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(aud.entries))
+			}
+			if got := aud.entries[0].Hooks[0].Outcome; got != tt.wantOutcome {
+				t.Errorf("hook outcome = %q, want %q", got, tt.wantOutcome)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+
+			if len(m.calls) != 2 {
+				t.Errorf("calls = %d, want 2 (chain continues after waiver)", len(m.calls))
+			}
+			var out hook.Output
+			if err := json.Unmarshal(result.Output, &out); err != nil {
+				t.Fatalf("Unmarshal output: %v", err)
+			}
+			if !strings.Contains(out.SystemMessage, "ex-1") || !strings.Contains(out.SystemMessage, "JIRA-123") {
+				t.Errorf("systemMessage = %q, want exception ID and reason", out.SystemMessage)
+			}
+			if !strings.Contains(aud.entries[0].Hooks[0].Stderr, "ex-1") {
+				t.Errorf("audited stderr = %q, want exception ID", aud.entries[0].Hooks[0].Stderr)
+			}
+		})
+	}
+}
+
+func TestKilledHookSignalRecorded(t *testing.T) {
+	hooks := []config.HookEntry{{Name: "oom", Command: "oom"}}
+	m := &mockRunner{results: []mockResult{
+		{result: runner.Result{ExitCode: -1, Signal: "SIGKILL"}},
+	}}
+	aud := &mockAuditor{}
+
+	result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+	if result.ExitCode != 2 {
+		t.Fatalf("ExitCode = %d, want 2", result.ExitCode)
+	}
+	if !strings.Contains(string(result.Output), "killed by SIGKILL") {
+		t.Errorf("output = %s, want the signal in the reason", result.Output)
+	}
+	if len(aud.entries) != 1 || aud.entries[0].Hooks[0].Signal != "SIGKILL" || aud.entries[0].Hooks[0].ExitCode != -1 {
+		t.Errorf("audited hooks = %+v, want exit -1 with SIGKILL", aud.entries)
+	}
+}
+
 func TestOnErrorSkipForNonZeroExit(t *testing.T) {
 	inp := makeInput(`{"command":"ls"}`)
 	hooks := []config.HookEntry{
@@ -648,6 +1692,83 @@ func TestAuditRecording(t *testing.T) {
 	}
 }
 
+// slowRunner passes every hook through after sleeping for d.
+type slowRunner struct{ d time.Duration }
+
+func (s slowRunner) Run(context.Context, config.HookEntry, []byte) (runner.Result, error) {
+	time.Sleep(s.d)
+	return runner.Result{}, nil
+}
+
+func TestAuditOverheadExcludesHookTime(t *testing.T) {
+	hooks := []config.HookEntry{{Name: "a", Command: "a"}, {Name: "b", Command: "b"}}
+	a := &mockAuditor{}
+	Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, slowRunner{d: 20 * time.Millisecond}, a, testLogger())
+
+	if len(a.entries) != 1 {
+		t.Fatalf("audit entries = %d, want 1", len(a.entries))
+	}
+	e := a.entries[0]
+	if e.DurationMs < 40 {
+		t.Errorf("DurationMs = %d, want at least the 40ms spent in hooks", e.DurationMs)
+	}
+	if e.OverheadMs < 0 || e.OverheadMs > e.DurationMs-40 {
+		t.Errorf("OverheadMs = %d with DurationMs %d, want the time outside the 40ms of hooks", e.OverheadMs, e.DurationMs)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	tests := []struct {
+		action      string
+		wantCode    int
+		wantDecide  string // "" = allow without output
+		wantOutcome string
+	}{
+		{action: config.MaxDurationDeny, wantCode: 2, wantDecide: "deny", wantOutcome: audit.OutcomeDeny},
+		{action: config.MaxDurationAsk, wantDecide: "ask", wantOutcome: audit.OutcomeAsk},
+		{action: config.MaxDurationAllow, wantOutcome: audit.OutcomeAllow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			hooks := []config.HookEntry{{Name: "a", Command: "a"}, {Name: "b", Command: "b"}, {Name: "c", Command: "c"}}
+			aud := &mockAuditor{}
+			result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, slowRunner{d: 20 * time.Millisecond}, aud, testLogger(),
+				WithMaxDuration(10*time.Millisecond, tt.action))
+
+			if result.ExitCode != tt.wantCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantCode)
+			}
+			decision := ""
+			if result.Output != nil {
+				var out hook.Output
+				if err := json.Unmarshal(result.Output, &out); err != nil {
+					t.Fatalf("Unmarshal output: %v", err)
+				}
+				decision = out.HookSpecificOutput.PermissionDecision
+			}
+			if decision != tt.wantDecide {
+				t.Errorf("decision = %q, want %q", decision, tt.wantDecide)
+			}
+			entry := aud.entries[0]
+			if entry.Outcome != tt.wantOutcome || !strings.Contains(entry.Reason, "max_duration of 10ms") {
+				t.Errorf("audited %s (%q), want %s naming max_duration", entry.Outcome, entry.Reason, tt.wantOutcome)
+			}
+			// Only the first hook ran; the second is recorded as not
+			// started, and the third not at all.
+			if len(entry.Hooks) != 2 || entry.Hooks[0].Outcome != audit.HookOutcomePass || entry.Hooks[1].Outcome != audit.HookOutcomeBudget {
+				t.Errorf("hooks = %+v, want a pass then b budget", entry.Hooks)
+			}
+		})
+	}
+
+	// Without a limit every hook runs.
+	aud := &mockAuditor{}
+	Run(context.Background(), makeInput(`{"command":"ls"}`), []config.HookEntry{{Name: "a", Command: "a"}, {Name: "b", Command: "b"}}, slowRunner{d: time.Millisecond}, aud, testLogger(), WithMaxDuration(0, config.MaxDurationDeny))
+	if entry := aud.entries[0]; entry.Outcome != audit.OutcomeAllow || len(entry.Hooks) != 2 {
+		t.Errorf("no limit: %s with %d hooks, want allow with 2", entry.Outcome, len(entry.Hooks))
+	}
+}
+
 func TestAuditErrorDoesNotBlockPipeline(t *testing.T) {
 	// Mock auditor returns error from RecordChain.
 	// Verify pipeline still returns correct result (fail-open).
@@ -679,6 +1800,53 @@ func TestAuditErrorDoesNotBlockPipeline(t *testing.T) {
 	}
 }
 
+func TestEventBusLifecycle(t *testing.T) {
+	inp := makeInput(`{"command":"ls"}`)
+	hooks := []config.HookEntry{
+		{Name: "hook1", Command: "hook1"},
+		{Name: "hook2", Command: "hook2"},
+	}
+
+	denyOutput := `{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"nope"}}`
+	m := &mockRunner{
+		results: []mockResult{
+			{result: runner.Result{ExitCode: 0}},
+			{result: runner.Result{ExitCode: 0, Stdout: []byte(denyOutput)}},
+		},
+	}
+
+	bus := events.NewBus(testLogger())
+	var got []events.Event
+	bus.Subscribe(events.HandlerFunc(func(e events.Event) { got = append(got, e) }))
+
+	Run(context.Background(), inp, hooks, m, nil, testLogger(), WithEventBus(bus))
+
+	want := []events.Kind{
+		events.KindChainStart,
+		events.KindHookStart, events.KindHookEnd,
+		events.KindHookStart, events.KindHookEnd,
+		events.KindDecision, events.KindChainEnd,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, k := range want {
+		if got[i].Kind != k {
+			t.Errorf("event[%d].Kind = %q, want %q", i, got[i].Kind, k)
+		}
+	}
+
+	if got[4].HookName != "hook2" || got[4].Outcome != "deny" {
+		t.Errorf("hook_end[1] = %+v, want hook2/deny", got[4])
+	}
+	if got[5].Outcome != "deny" || got[5].Reason != "nope" {
+		t.Errorf("decision = %+v, want deny/nope", got[5])
+	}
+	if got[0].ChainLen != 2 || got[0].ToolName != "Bash" {
+		t.Errorf("chain_start = %+v, want ChainLen=2 ToolName=Bash", got[0])
+	}
+}
+
 func TestExtractToolDetail_BashCommand(t *testing.T) {
 	inp := makeInput(`{"command":"ls -la /tmp"}`)
 	got := extractToolDetail(inp)
@@ -783,3 +1951,394 @@ func TestExtractToolDetail_EditTool(t *testing.T) {
 		t.Errorf("extractToolDetail = %q, want %q", got, want)
 	}
 }
+
+func TestReportOnlyHookNotEnforced(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      mockResult
+		wantOutcome string
+	}{
+		{"exit 2 deny", mockResult{result: runner.Result{ExitCode: 2, Stderr: "blocked"}}, audit.HookOutcomeReport},
+		{"explicit deny", mockResult{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"nope"}}`)}}, audit.HookOutcomeReport},
+		{"ask", mockResult{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"ask"}}`)}}, audit.HookOutcomeReport},
+		{"runner error", mockResult{err: errors.New("not found")}, audit.HookOutcomeReport},
+		{"invalid json", mockResult{result: runner.Result{Stdout: []byte(`{bad`)}}, audit.HookOutcomeReport},
+		{"updatedInput dropped", mockResult{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"updatedInput":{"command":"rm"}}}`)}}, audit.HookOutcomePass},
+		{"passthrough", mockResult{}, audit.HookOutcomePass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inp := makeInput(`{"command":"ls"}`)
+			m := &mockRunner{results: []mockResult{tt.result, {}}}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{
+				{Name: "shadow", Command: "shadow", ReportOnly: true},
+				{Name: "next", Command: "next"},
+			}
+
+			result := Run(context.Background(), inp, hooks, m, aud, testLogger())
+			if result.ExitCode != 0 || len(result.Output) != 0 {
+				t.Errorf("result = %d %s, want clean allow", result.ExitCode, result.Output)
+			}
+			if len(m.calls) != 2 {
+				t.Errorf("calls = %d, want 2 (chain continues past report-only hook)", len(m.calls))
+			}
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			entry := aud.entries[0]
+			if entry.Outcome != audit.OutcomeAllow {
+				t.Errorf("chain outcome = %q, want allow", entry.Outcome)
+			}
+			if got := entry.Hooks[0].Outcome; got != tt.wantOutcome {
+				t.Errorf("hook outcome = %q, want %q", got, tt.wantOutcome)
+			}
+			if tt.wantOutcome == audit.HookOutcomeReport && !strings.HasPrefix(entry.Hooks[0].Stderr, "would ") {
+				t.Errorf("hook stderr = %q, want would-be verdict", entry.Hooks[0].Stderr)
+			}
+		})
+	}
+}
+
+func TestRolloutDemotesToReportOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		rollout     string
+		wantExit    int
+		wantOutcome string
+	}{
+		{"outside rollout", "0%", 0, audit.HookOutcomeReport},
+		{"inside rollout", "100%", 2, audit.HookOutcomeDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inp := makeInput(`{"command":"rm -rf /"}`)
+			m := &mockRunner{results: []mockResult{{result: runner.Result{ExitCode: 2, Stderr: "blocked"}}, {}}}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{
+				{Name: "canary", Command: "canary", Rollout: tt.rollout},
+				{Name: "next", Command: "next"},
+			}
+
+			result := Run(context.Background(), inp, hooks, m, aud, testLogger())
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			if got := aud.entries[0].Hooks[0].Outcome; got != tt.wantOutcome {
+				t.Errorf("hook outcome = %q, want %q", got, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestVariantsShadowRecordedPrimaryEnforced(t *testing.T) {
+	tests := []struct {
+		name          string
+		shadow        mockResult
+		primary       mockResult
+		wantExit      int
+		wantPrimary   string
+		wantShadow    string
+		wantNextCalls int
+	}{
+		{"shadow would deny, primary passes", mockResult{result: runner.Result{ExitCode: 2, Stderr: "v2 blocked"}}, mockResult{}, 0, audit.HookOutcomePass, audit.HookOutcomeReport, 1},
+		{"primary denies, shadow passes", mockResult{}, mockResult{result: runner.Result{ExitCode: 2, Stderr: "v1 blocked"}}, 2, audit.HookOutcomeDeny, audit.HookOutcomePass, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inp := makeInput(`{"command":"rm -rf /"}`)
+			m := &mockRunner{results: []mockResult{tt.shadow, tt.primary, {}}}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{
+				{Name: "guard", Variants: []string{"guard-v1", "guard-v2"}},
+				{Name: "next", Command: "next"},
+			}
+
+			result := Run(context.Background(), inp, hooks, m, aud, testLogger())
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			if len(m.calls) != 2+tt.wantNextCalls {
+				t.Fatalf("calls = %d, want %d", len(m.calls), 2+tt.wantNextCalls)
+			}
+			if m.calls[0].command != "guard-v2" || m.calls[1].command != "guard-v1" {
+				t.Errorf("commands = %q, %q; want shadow guard-v2 then primary guard-v1", m.calls[0].command, m.calls[1].command)
+			}
+
+			if len(aud.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(aud.entries))
+			}
+			hrs := aud.entries[0].Hooks
+			if len(hrs) < 2 {
+				t.Fatalf("hook results = %d, want at least 2", len(hrs))
+			}
+			if hrs[0].Variant != config.VariantShadow || hrs[0].Outcome != tt.wantShadow {
+				t.Errorf("shadow result = %s/%s, want %s/%s", hrs[0].Variant, hrs[0].Outcome, config.VariantShadow, tt.wantShadow)
+			}
+			if hrs[1].Variant != config.VariantPrimary || hrs[1].Outcome != tt.wantPrimary {
+				t.Errorf("primary result = %s/%s, want %s/%s", hrs[1].Variant, hrs[1].Outcome, config.VariantPrimary, tt.wantPrimary)
+			}
+			for _, hr := range hrs[2:] {
+				if hr.Variant != "" {
+					t.Errorf("hook %q variant = %q, want none", hr.HookName, hr.Variant)
+				}
+			}
+		})
+	}
+}
+
+func TestTranscriptNotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []mockResult
+		wantKinds []string
+		wantHooks []string
+	}{
+		{
+			"allow writes nothing",
+			[]mockResult{{}, {}},
+			nil,
+			nil,
+		},
+		{
+			"hook note then deny",
+			[]mockResult{
+				{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"transcriptNote":"scanned 3 paths"}}`)}},
+				{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"permissionDecision":"deny","permissionDecisionReason":"nope","ruleId":"R1"}}`)}},
+			},
+			[]string{transcript.KindHook, transcript.KindDeny},
+			[]string{"first", "second"},
+		},
+		{
+			"runner error",
+			[]mockResult{{err: errors.New("boom")}},
+			[]string{transcript.KindError},
+			[]string{"first"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.hook-chain.jsonl")
+			inp := makeInput(`{"command":"rm -rf /"}`)
+			m := &mockRunner{results: tt.results}
+			hooks := []config.HookEntry{
+				{Name: "first", Command: "first"},
+				{Name: "second", Command: "second"},
+			}
+
+			Run(context.Background(), inp, hooks, m, nil, testLogger(), WithTranscriptNotes(path))
+
+			data, err := os.ReadFile(path)
+			if tt.wantKinds == nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("notes file exists (err=%v), want none: %s", err, data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("read notes: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != len(tt.wantKinds) {
+				t.Fatalf("notes = %d lines, want %d:\n%s", len(lines), len(tt.wantKinds), data)
+			}
+			for i, line := range lines {
+				var n transcript.Note
+				if err := json.Unmarshal([]byte(line), &n); err != nil {
+					t.Fatalf("note %d: %v", i, err)
+				}
+				if n.Kind != tt.wantKinds[i] || n.Hook != tt.wantHooks[i] {
+					t.Errorf("note %d = %s/%s, want %s/%s", i, n.Kind, n.Hook, tt.wantKinds[i], tt.wantHooks[i])
+				}
+				if n.Tool != "Bash" || n.Text == "" {
+					t.Errorf("note %d = %+v, want tool and text set", i, n)
+				}
+			}
+		})
+	}
+}
+
+func TestAsyncHookLaunchedNotRun(t *testing.T) {
+	inp := makeInput(`{"command":"ls"}`)
+	m := &mockRunner{results: []mockResult{
+		{result: runner.Result{Stdout: []byte(`{"hookSpecificOutput":{"updatedInput":{"command":"ls -la"}}}`)}},
+		{},
+	}}
+	aud := &mockAuditor{}
+	hooks := []config.HookEntry{
+		{Name: "rewrite", Command: "rewrite"},
+		{Name: "telemetry", Command: "telemetry", Async: true},
+		{Name: "final", Command: "final"},
+	}
+
+	var launched []AsyncHook
+	result := Run(context.Background(), inp, hooks, m, aud, testLogger(),
+		WithAsyncLauncher(func(ah AsyncHook) { launched = append(launched, ah) }))
+
+	if result.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	if len(m.calls) != 2 || m.calls[1].hookName != "final" {
+		t.Errorf("runner calls = %+v, want rewrite and final only", m.calls)
+	}
+	if len(launched) != 1 || launched[0].Index != 1 || launched[0].Hook.Name != "telemetry" {
+		t.Fatalf("launched = %+v, want telemetry at index 1", launched)
+	}
+	// The async hook sees the input accumulated before it.
+	if !strings.Contains(string(launched[0].Input), `"ls -la"`) {
+		t.Errorf("async input = %s, want accumulated toolInput", launched[0].Input)
+	}
+	if got := aud.entries[0].Hooks[1].Outcome; got != audit.HookOutcomeAsync {
+		t.Errorf("async hook outcome = %q, want %q", got, audit.HookOutcomeAsync)
+	}
+}
+
+func TestAsyncHookWithoutLauncherSkipped(t *testing.T) {
+	inp := makeInput(`{"command":"ls"}`)
+	m := &mockRunner{}
+	aud := &mockAuditor{}
+	hooks := []config.HookEntry{{Name: "telemetry", Command: "telemetry", Async: true}}
+
+	Run(context.Background(), inp, hooks, m, aud, testLogger())
+
+	if len(m.calls) != 0 {
+		t.Errorf("runner calls = %d, want 0", len(m.calls))
+	}
+	if got := aud.entries[0].Hooks[0].Outcome; got != audit.HookOutcomeSkip {
+		t.Errorf("outcome = %q, want %q", got, audit.HookOutcomeSkip)
+	}
+}
+
+// finallyInput is the part of a finally hook's input the tests inspect.
+type finallyInput struct {
+	HookChain finalDecision `json:"hook_chain"`
+}
+
+func TestFinallyHooksRunAfterDecision(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []mockResult // the finally hook's (failing) result comes last
+		wantOutcome string
+		wantExit    int
+	}{
+		{"after allow", []mockResult{{}, {}, {result: runner.Result{ExitCode: 1, Stderr: "notify failed"}}}, "allow", 0},
+		{"after deny short-circuit", []mockResult{{result: runner.Result{ExitCode: 2, Stderr: "blocked"}}, {result: runner.Result{ExitCode: 1, Stderr: "notify failed"}}}, "deny", 2},
+		{"after runner error", []mockResult{{err: errors.New("not found")}, {result: runner.Result{ExitCode: 1, Stderr: "notify failed"}}}, "error", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inp := makeInput(`{"command":"ls"}`)
+			// The finally hook fails; that must not change the decision.
+			m := &mockRunner{results: tt.results}
+			aud := &mockAuditor{}
+			hooks := []config.HookEntry{{Name: "guard", Command: "guard"}, {Name: "second", Command: "second"}}
+			finally := []config.HookEntry{{Name: "notify", Command: "notify"}}
+
+			result := Run(context.Background(), inp, hooks, m, aud, testLogger(), WithFinally(finally))
+
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantExit)
+			}
+			last := m.calls[len(m.calls)-1]
+			if last.hookName != "notify" {
+				t.Fatalf("last call = %q, want notify", last.hookName)
+			}
+			var got finallyInput
+			if err := json.Unmarshal(last.input, &got); err != nil {
+				t.Fatalf("unmarshal finally input: %v", err)
+			}
+			if got.HookChain.Outcome != tt.wantOutcome {
+				t.Errorf("finally input outcome = %q, want %q", got.HookChain.Outcome, tt.wantOutcome)
+			}
+
+			entry := aud.entries[0]
+			if entry.Outcome != tt.wantOutcome {
+				t.Errorf("audit outcome = %q, want %q", entry.Outcome, tt.wantOutcome)
+			}
+			fin := entry.Hooks[len(entry.Hooks)-1]
+			if fin.HookName != "notify" || fin.HookIndex != len(hooks) || fin.Outcome != audit.HookOutcomeError {
+				t.Errorf("finally result = %+v, want notify at index %d with error outcome", fin, len(hooks))
+			}
+		})
+	}
+}
+
+func TestFinallyHooksWithEmptyChain(t *testing.T) {
+	inp := makeInput(`{"command":"ls"}`)
+	m := &mockRunner{}
+	finally := []config.HookEntry{{Name: "notify", Command: "notify"}}
+
+	result := Run(context.Background(), inp, nil, m, nil, testLogger(), WithFinally(finally))
+	if result.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	if len(m.calls) != 1 || m.calls[0].hookName != "notify" {
+		t.Errorf("calls = %+v, want notify only", m.calls)
+	}
+}
+
+func TestDecisionHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []mockResult
+		wantOutcome string
+		wantReason  string
+	}{
+		{"allow", []mockResult{{}}, "allow", ""},
+		{"deny", []mockResult{{result: runner.Result{ExitCode: 2, Stderr: "blocked"}}}, "deny", "blocked"},
+		{"runner error", []mockResult{{err: errors.New("not found")}}, "error", `hook "guard" runner error: not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aud := &mockAuditor{}
+			var outcome, reason string
+			calls := 0
+			Run(context.Background(), makeInput(`{"command":"ls"}`), []config.HookEntry{{Name: "guard", Command: "guard"}},
+				&mockRunner{results: tt.results}, aud, testLogger(),
+				WithDecisionHandler(func(o, r string) {
+					calls++
+					outcome, reason = o, r
+					if len(aud.entries) != 1 {
+						t.Error("decision handler called before the chain was audited")
+					}
+				}))
+			if calls != 1 || outcome != tt.wantOutcome || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("decision handler: %d call(s), last %q %q; want one call with %q %q", calls, outcome, reason, tt.wantOutcome, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestUnknownOutputFieldsForwarded(t *testing.T) {
+	hooks := []config.HookEntry{{Name: "a", Command: "a"}, {Name: "b", Command: "b"}}
+	m := &mockRunner{results: []mockResult{
+		{result: runner.Result{Stdout: []byte(`{"stopReason":"first","hookSpecificOutput":{"updatedPermissions":["x"]}}`)}},
+		{result: runner.Result{Stdout: []byte(`{"stopReason":"second","hookSpecificOutput":{"ruleId":"R1"}}`)}},
+	}}
+	aud := &mockAuditor{}
+
+	result := Run(context.Background(), makeInput(`{"command":"ls"}`), hooks, m, aud, testLogger())
+	if result.ExitCode != 0 {
+		t.Fatalf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(result.Output, &out); err != nil {
+		t.Fatalf("output is not JSON: %v (%s)", err, result.Output)
+	}
+	if out["stopReason"] != "second" {
+		t.Errorf("stopReason = %v, want second (later hooks win)", out["stopReason"])
+	}
+	hso, _ := out["hookSpecificOutput"].(map[string]any)
+	if _, ok := hso["updatedPermissions"]; !ok {
+		t.Errorf("hookSpecificOutput.updatedPermissions not forwarded: %s", result.Output)
+	}
+	if _, ok := hso["ruleId"]; ok {
+		t.Errorf("known field ruleId forwarded: %s", result.Output)
+	}
+	if len(aud.entries) != 1 || aud.entries[0].ProtocolVersion != hook.ProtocolClaude {
+		t.Errorf("audited protocol version = %+v, want %s", aud.entries, hook.ProtocolClaude)
+	}
+}
@@ -0,0 +1,108 @@
+// Package quota counts hook and chain runs in a sliding time window, so an
+// expensive hook (an LLM reviewer, a paid API) runs at most a set number of
+// times per window however many agent sessions call it. Runs are kept in a
+// small SQLite database shared by every hook-chain process on the machine.
+package quota
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+    key TEXT NOT NULL,
+    at  INTEGER NOT NULL -- Unix nanoseconds
+);
+CREATE INDEX IF NOT EXISTS idx_runs_key_at ON runs(key, at);
+`
+
+// Store is an open quota database.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath returns the store location: $HOOK_CHAIN_QUOTA_DB, or quota.db
+// under $XDG_DATA_HOME/hook-chain (default ~/.local/share/hook-chain).
+func DefaultPath() string {
+	if p := os.Getenv("HOOK_CHAIN_QUOTA_DB"); p != "" {
+		return p
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "quota.db")
+}
+
+// Open opens (or creates) the store at path with WAL mode and a 5-second
+// busy timeout, since concurrent sessions take from the same quotas.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("quota: create directory for %q: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("quota: open database %q: %w", path, err)
+	}
+	// One connection, so the busy timeout covers every statement; it comes
+	// first, since switching to WAL may wait for another process too.
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA busy_timeout=5000", "PRAGMA journal_mode=WAL", schema} {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("quota: init %q: %w", path, err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database. Nil receiver is a no-op.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("quota: close: %w", err)
+	}
+	return nil
+}
+
+// Take records a run of key if fewer than max runs were recorded in the
+// last per, and reports whether it did. A run that is refused is not
+// recorded, so a caller over quota gets through again as soon as the
+// window has room.
+func (s *Store) Take(key string, max int, per time.Duration) (bool, error) {
+	return s.take(key, max, per, time.Now())
+}
+
+func (s *Store) take(key string, max int, per time.Duration, now time.Time) (bool, error) {
+	since := now.Add(-per).UnixNano()
+	if _, err := s.db.Exec("DELETE FROM runs WHERE key = ? AND at <= ?", key, since); err != nil {
+		return false, fmt.Errorf("quota: expire %q: %w", key, err)
+	}
+	// One statement, so the count and the insert happen under the same
+	// write lock and concurrent callers cannot both take the last run.
+	res, err := s.db.Exec(
+		`INSERT INTO runs (key, at) SELECT ?, ?
+		 WHERE (SELECT COUNT(*) FROM runs WHERE key = ? AND at > ?) < ?`,
+		key, now.UnixNano(), key, since, max,
+	)
+	if err != nil {
+		return false, fmt.Errorf("quota: take %q: %w", key, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("quota: take %q: %w", key, err)
+	}
+	return n == 1, nil
+}
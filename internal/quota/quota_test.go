@@ -0,0 +1,83 @@
+package quota
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "quota.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestTake(t *testing.T) {
+	s := openTestStore(t)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		key  string
+		at   time.Duration // after start
+		want bool
+	}{
+		{"first", "hook:review", 0, true},
+		{"second", "hook:review", 10 * time.Second, true},
+		{"over quota", "hook:review", 20 * time.Second, false},
+		{"other key", "hook:lint", 20 * time.Second, true},
+		{"still over, refusals not counted", "hook:review", 59 * time.Second, false},
+		{"first run expired", "hook:review", 61 * time.Second, true},
+		{"full again", "hook:review", 62 * time.Second, false},
+	}
+	for _, tt := range tests {
+		got, err := s.take(tt.key, 2, time.Minute, start.Add(tt.at))
+		if err != nil {
+			t.Fatalf("%s: take: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: take = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTakeConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.db")
+	const callers, max = 8, 3
+
+	var mu sync.Mutex
+	taken := 0
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A store per caller, as separate hook-chain processes have.
+			s, err := Open(path)
+			if err != nil {
+				t.Errorf("Open: %v", err)
+				return
+			}
+			defer func() { _ = s.Close() }()
+			ok, err := s.Take("hook:review", max, time.Hour)
+			if err != nil {
+				t.Errorf("Take: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				taken++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if taken != max {
+		t.Errorf("%d callers took a run, want %d", taken, max)
+	}
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// DefaultSubject is the email subject when report.email.subject is unset.
+const DefaultSubject = "hook-chain guardrail digest"
+
+// sendMail is smtp.SendMail, replaced in tests.
+var sendMail = smtp.SendMail
+
+// ValidateEmail reports missing settings in an email config.
+func ValidateEmail(cfg config.EmailConfig) error {
+	switch {
+	case cfg.SMTP == "":
+		return errors.New("report: email.smtp (host:port) is required")
+	case cfg.From == "":
+		return errors.New("report: email.from is required")
+	case len(cfg.To) == 0:
+		return errors.New("report: email.to needs at least one recipient")
+	}
+	if _, _, err := net.SplitHostPort(cfg.SMTP); err != nil {
+		return fmt.Errorf("report: email.smtp %q: %w", cfg.SMTP, err)
+	}
+	return nil
+}
+
+// SendEmail mails the digest as plain text.
+func SendEmail(cfg config.EmailConfig, d Digest) error {
+	if err := ValidateEmail(cfg); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		password := cfg.Password
+		if cfg.PasswordEnv != "" {
+			password = os.Getenv(cfg.PasswordEnv)
+		}
+		host, _, _ := net.SplitHostPort(cfg.SMTP)
+		auth = smtp.PlainAuth("", cfg.Username, password, host)
+	}
+	subject := cfg.Subject
+	if subject == "" {
+		subject = DefaultSubject
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(d.Text(), "\n", "\r\n"))
+
+	if err := sendMail(cfg.SMTP, auth, cfg.From, cfg.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("report: send email via %s: %w", cfg.SMTP, err)
+	}
+	return nil
+}
+
+// webhookPayload is the JSON posted by PostWebhook. The rendered digest is
+// in "text", which chat webhooks (Slack, Mattermost, ...) display as is.
+type webhookPayload struct {
+	Text   string `json:"text"`
+	Digest Digest `json:"digest"`
+}
+
+// PostWebhook posts the digest as JSON to url.
+func PostWebhook(ctx context.Context, client *http.Client, url string, d Digest) error {
+	body, err := json.Marshal(webhookPayload{Text: d.Text(), Digest: d})
+	if err != nil {
+		return fmt.Errorf("report: marshal digest: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("report: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("report: post webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("report: post webhook: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
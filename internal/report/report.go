@@ -0,0 +1,161 @@
+// Package report builds the periodic guardrail digest from the audit log
+// (outcomes, severities, top rules and hooks, anomalies, risky sessions)
+// and delivers it by email or webhook.
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// topLimit caps the rules, hooks, and sessions listed in a digest.
+const topLimit = 5
+
+// Digest summarizes guardrail activity over a window.
+type Digest struct {
+	Since         time.Time                   `json:"since"`
+	Until         time.Time                   `json:"until"`
+	Chains        int64                       `json:"chains"`
+	Outcomes      map[string]int64            `json:"outcomes"`
+	Severity      map[string]map[string]int64 `json:"severity"` // hook results by severity, then outcome
+	TopRules      []audit.TopEntry            `json:"top_rules"`
+	TopHooks      []audit.TopEntry            `json:"top_hooks"`
+	Anomalies     []audit.Anomaly             `json:"anomalies"`
+	RiskySessions []audit.SessionRisk         `json:"risky_sessions"`
+}
+
+// Build collects the digest for activity since the given time.
+func Build(db *sql.DB, since, until time.Time) (Digest, error) {
+	d := Digest{Since: since.UTC(), Until: until.UTC()}
+	var err error
+	if d.Outcomes, err = audit.OutcomesSince(db, since); err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	for _, n := range d.Outcomes {
+		d.Chains += n
+	}
+	if d.Severity, err = audit.SeverityOutcomes(db, since); err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	if d.TopRules, err = audit.Top(db, audit.TopByRule, since, topLimit); err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	if d.TopHooks, err = audit.Top(db, audit.TopByHook, since, topLimit); err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	anomalies, err := audit.ListAnomalies(db, 0)
+	if err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	for _, a := range anomalies {
+		if !a.DetectedAt.Before(since.UTC()) {
+			d.Anomalies = append(d.Anomalies, a)
+		}
+	}
+	risks, err := audit.SessionRisks(db, since, topLimit)
+	if err != nil {
+		return Digest{}, fmt.Errorf("report: %w", err)
+	}
+	for _, r := range risks {
+		if r.Score > 0 {
+			d.RiskySessions = append(d.RiskySessions, r)
+		}
+	}
+	return d, nil
+}
+
+// Title names the digest and its window.
+func (d Digest) Title() string {
+	return fmt.Sprintf("hook-chain guardrail digest, %s to %s", d.Since.Format("2006-01-02"), d.Until.Format("2006-01-02"))
+}
+
+// Text renders the digest as plain text.
+func (d Digest) Text() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n\n", d.Title())
+	fmt.Fprintf(&b, "Chains: %d", d.Chains)
+	if len(d.Outcomes) > 0 {
+		fmt.Fprintf(&b, " (%s)", formatCounts(d.Outcomes, []string{audit.OutcomeAllow, audit.OutcomeAsk, audit.OutcomeDeny, audit.OutcomeError}))
+	}
+	b.WriteString("\n")
+
+	if len(d.Severity) > 0 {
+		b.WriteString("\nBy severity (hook results):\n")
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, sev := range slices.Backward(config.Severities) {
+			if counts, ok := d.Severity[sev]; ok {
+				_, _ = fmt.Fprintf(w, "  %s\t%s\n", sev, formatCounts(counts, []string{audit.HookOutcomeDeny, audit.HookOutcomeAsk, audit.HookOutcomeContext, audit.HookOutcomeWaived}))
+			}
+		}
+		_ = w.Flush()
+	}
+
+	section := func(title string, rows [][]string) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, r := range rows {
+			_, _ = fmt.Fprintf(w, "  %s\n", strings.Join(r, "\t"))
+		}
+		_ = w.Flush()
+	}
+	var rows [][]string
+	for _, e := range d.TopRules {
+		rows = append(rows, []string{e.Key, fmt.Sprintf("fired %d", e.Count), fmt.Sprintf("denied %d", e.Denies)})
+	}
+	section("Top rules", rows)
+	rows = nil
+	for _, e := range d.TopHooks {
+		rows = append(rows, []string{e.Key, fmt.Sprintf("ran %d", e.Count), fmt.Sprintf("denied %d", e.Denies)})
+	}
+	section("Top hooks", rows)
+	rows = nil
+	for _, a := range d.Anomalies {
+		rows = append(rows, []string{a.DetectedAt.Format(time.RFC3339), a.Kind, a.Subject, a.Detail})
+	}
+	section("Anomalies", rows)
+	rows = nil
+	for _, r := range d.RiskySessions {
+		rows = append(rows, []string{r.SessionID, fmt.Sprintf("score %d", r.Score), fmt.Sprintf("denied %d, asked %d", r.Denies, r.Asks)})
+	}
+	section("Riskiest sessions", rows)
+
+	if d.Chains == 0 {
+		b.WriteString("\nNo tool calls were checked in this window.\n")
+	}
+	return b.String()
+}
+
+// formatCounts renders counts as "deny 3, ask 1", listing the keys in
+// order first and any others after them alphabetically. Zero counts are
+// left out.
+func formatCounts(counts map[string]int64, order []string) string {
+	keys := slices.Clone(order)
+	for _, k := range slices.Sorted(maps.Keys(counts)) {
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	var b bytes.Buffer
+	for _, k := range keys {
+		if counts[k] == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s %d", k, counts[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func testDigest(t *testing.T) Digest {
+	t.Helper()
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+
+	now := time.Now().UTC()
+	chains := []audit.ChainExecution{
+		{Timestamp: now.Add(-time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeDeny, SessionID: "s1",
+			Hooks: []audit.HookResult{{HookName: "command-guard", Outcome: audit.HookOutcomeDeny, RuleID: "command-guard/rm-root", Severity: "critical"}}},
+		{Timestamp: now.Add(-2 * time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeAsk, SessionID: "s1",
+			Hooks: []audit.HookResult{{HookName: "command-guard", Outcome: audit.HookOutcomeAsk, RuleID: "command-guard/git-force-push", Severity: "warn"}}},
+		{Timestamp: now.Add(-3 * time.Hour), EventName: "PreToolUse", ToolName: "Write", Outcome: audit.OutcomeAllow, SessionID: "s2",
+			Hooks: []audit.HookResult{{HookName: "lint", Outcome: audit.HookOutcomePass}}},
+		{Timestamp: now.Add(-30 * 24 * time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeDeny, SessionID: "old"},
+	}
+	for _, c := range chains {
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	d, err := Build(a.DB(), now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	return d
+}
+
+func TestBuild(t *testing.T) {
+	d := testDigest(t)
+	if d.Chains != 3 {
+		t.Errorf("Chains = %d, want 3", d.Chains)
+	}
+	if d.Severity["critical"]["deny"] != 1 || d.Severity["warn"]["ask"] != 1 {
+		t.Errorf("Severity = %v", d.Severity)
+	}
+	if len(d.TopRules) != 2 {
+		t.Errorf("TopRules = %+v, want 2 entries", d.TopRules)
+	}
+	if len(d.RiskySessions) != 1 || d.RiskySessions[0].SessionID != "s1" {
+		t.Errorf("RiskySessions = %+v, want s1 only", d.RiskySessions)
+	}
+
+	text := d.Text()
+	for _, want := range []string{
+		"Chains: 3 (allow 1, ask 1, deny 1)",
+		"By severity (hook results):",
+		"critical  deny 1",
+		"command-guard/rm-root",
+		"Riskiest sessions:",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "critical") > strings.Index(text, "warn ") {
+		t.Errorf("severities not listed highest first:\n%s", text)
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	d := testDigest(t)
+	cfg := config.EmailConfig{SMTP: "mail.example.com:587", Username: "bot", PasswordEnv: "TEST_SMTP_PASSWORD", From: "bot@example.com", To: []string{"sec@example.com", "lead@example.com"}}
+	t.Setenv("TEST_SMTP_PASSWORD", "s3cret")
+
+	var gotAddr string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotTo, gotMsg = addr, a, to, msg
+		return nil
+	}
+	t.Cleanup(func() { sendMail = smtp.SendMail })
+
+	if err := SendEmail(cfg, d); err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if gotAddr != cfg.SMTP || len(gotTo) != 2 || gotAuth == nil {
+		t.Errorf("sent to %s %v (auth %v)", gotAddr, gotTo, gotAuth)
+	}
+	msg := string(gotMsg)
+	for _, want := range []string{"Subject: " + DefaultSubject + "\r\n", "To: sec@example.com, lead@example.com\r\n", "\r\n\r\nhook-chain guardrail digest, "} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	for _, bad := range []config.EmailConfig{
+		{From: "a@b", To: []string{"c@d"}},
+		{SMTP: "mail:25", To: []string{"c@d"}},
+		{SMTP: "mail:25", From: "a@b"},
+		{SMTP: "mail", From: "a@b", To: []string{"c@d"}},
+	} {
+		if err := SendEmail(bad, d); err == nil {
+			t.Errorf("SendEmail(%+v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	d := testDigest(t)
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	if err := PostWebhook(context.Background(), srv.Client(), srv.URL+"/ok", d); err != nil {
+		t.Fatalf("PostWebhook: %v", err)
+	}
+	if !strings.HasPrefix(got.Text, "hook-chain guardrail digest") || got.Digest.Chains != 3 {
+		t.Errorf("payload = %+v", got)
+	}
+	if err := PostWebhook(context.Background(), srv.Client(), srv.URL+"/fail", d); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("PostWebhook(/fail) err = %v, want 403", err)
+	}
+}
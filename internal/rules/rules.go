@@ -0,0 +1,406 @@
+// Package rules holds the dangerous-command knowledge base used by the
+// command-guard builtin: a data-driven list of command and flag
+// combinations (rm -rf /, chmod -R 777, git push --force, ...). A copy is
+// embedded in the binary; `hook-chain rules update` installs newer ones,
+// which are versioned independently of hook-chain.
+package rules
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+//go:embed dangerous.yaml
+var embedded []byte
+
+// Actions a rule can take.
+const (
+	ActionDeny = "deny"
+	ActionAsk  = "ask"
+)
+
+// Set is a versioned ruleset.
+type Set struct {
+	Version string `yaml:"version" json:"version"`
+	Rules   []Rule `yaml:"rules" json:"rules"`
+	// Source is where the set was loaded from ("embedded" or a file path).
+	Source string `yaml:"-" json:"source"`
+}
+
+// Rule matches one dangerous command and flag combination.
+type Rule struct {
+	ID         string   `yaml:"id" json:"id"`
+	Command    string   `yaml:"command" json:"command"`
+	Subcommand string   `yaml:"subcommand,omitempty" json:"subcommand,omitempty"`
+	Flags      []string `yaml:"flags,omitempty" json:"flags,omitempty"`
+	Args       []string `yaml:"args,omitempty" json:"args,omitempty"`
+	IgnoreCase bool     `yaml:"ignore_case,omitempty" json:"ignore_case,omitempty"`
+	Action     string   `yaml:"action" json:"action"`
+	Reason     string   `yaml:"reason" json:"reason"`
+	Severity   string   `yaml:"severity,omitempty" json:"severity,omitempty"`
+
+	command    []*regexp.Regexp
+	subcommand []*regexp.Regexp
+	args       []*regexp.Regexp
+}
+
+// Embedded returns the ruleset built into the binary.
+func Embedded() Set {
+	s, err := Parse(embedded)
+	if err != nil {
+		panic(fmt.Sprintf("rules: embedded ruleset is invalid: %v", err))
+	}
+	s.Source = "embedded"
+	return s
+}
+
+// DefaultPath returns where `hook-chain rules update` installs rulesets:
+// $HOOK_CHAIN_RULES, or $XDG_DATA_HOME/hook-chain/rules/dangerous.yaml
+// (default ~/.local/share).
+func DefaultPath() string {
+	if p := os.Getenv("HOOK_CHAIN_RULES"); p != "" {
+		return p
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "rules", "dangerous.yaml")
+}
+
+// Load returns the newer of the embedded ruleset and the one installed at
+// path. A missing file is not an error. An unreadable or invalid file is
+// reported alongside the embedded set, so callers can warn and carry on.
+func Load(path string) (Set, error) {
+	builtin := Embedded()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return builtin, nil
+	}
+	if err != nil {
+		return builtin, fmt.Errorf("rules: read %s: %w", path, err)
+	}
+	installed, err := Parse(data)
+	if err != nil {
+		return builtin, fmt.Errorf("rules: %s: %w", path, err)
+	}
+	installed.Source = path
+	if CompareVersions(installed.Version, builtin.Version) < 0 {
+		return builtin, nil
+	}
+	return installed, nil
+}
+
+// Parse decodes and validates a ruleset.
+func Parse(data []byte) (Set, error) {
+	var s Set
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Set{}, fmt.Errorf("parse ruleset: %w", err)
+	}
+	if _, err := parseVersion(s.Version); err != nil {
+		return Set{}, err
+	}
+	seen := map[string]bool{}
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		if r.ID == "" {
+			return Set{}, fmt.Errorf("rule %d: id is required", i)
+		}
+		if seen[r.ID] {
+			return Set{}, fmt.Errorf("rule %s: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+		if err := r.compile(); err != nil {
+			return Set{}, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return s, nil
+}
+
+// compile validates r and prepares its matchers.
+func (r *Rule) compile() error {
+	if r.Command == "" {
+		return errors.New("command is required")
+	}
+	switch r.Action {
+	case ActionDeny, ActionAsk:
+	default:
+		return fmt.Errorf("action %q is not %q or %q", r.Action, ActionDeny, ActionAsk)
+	}
+	if r.Severity != "" && !config.ValidSeverity(r.Severity) {
+		return fmt.Errorf("severity %q is not one of %v", r.Severity, config.Severities)
+	}
+	var err error
+	if r.command, err = compileGlobs(strings.Split(r.Command, "|"), false); err != nil {
+		return err
+	}
+	if r.Subcommand != "" {
+		if r.subcommand, err = compileGlobs(strings.Split(r.Subcommand, "|"), false); err != nil {
+			return err
+		}
+	}
+	for _, f := range r.Flags {
+		if f == "" || strings.Contains("|"+f+"|", "||") {
+			return fmt.Errorf("empty alternative in flags entry %q", f)
+		}
+		for _, alt := range strings.Split(f, "|") {
+			if !strings.HasPrefix(alt, "-") {
+				return fmt.Errorf("flag %q does not start with -", alt)
+			}
+		}
+	}
+	r.args, err = compileGlobs(r.Args, r.IgnoreCase)
+	return err
+}
+
+// Match reports whether the command name with args (as parsed from the
+// shell, wrappers removed) matches the rule.
+func (r Rule) Match(name string, args []string) bool {
+	if !anyMatch(r.command, name) {
+		return false
+	}
+	var flags, operands []string
+	for _, a := range args {
+		switch {
+		case a == "--":
+		case strings.HasPrefix(a, "-") && len(a) > 1:
+			flags = append(flags, a)
+		default:
+			operands = append(operands, a)
+		}
+	}
+	if r.subcommand != nil {
+		if len(operands) == 0 || !anyMatch(r.subcommand, operands[0]) {
+			return false
+		}
+		operands = operands[1:]
+	}
+	for _, f := range r.Flags {
+		if !hasFlag(flags, strings.Split(f, "|")) {
+			return false
+		}
+	}
+	if r.args == nil {
+		return true
+	}
+	for _, o := range operands {
+		if o != "/" {
+			o = strings.TrimRight(o, "/")
+		}
+		if anyMatch(r.args, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the first rule in s matching the command.
+func (s Set) Match(name string, args []string) (Rule, bool) {
+	for _, r := range s.Rules {
+		if r.Match(name, args) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Merge returns s with the rules of other added; a rule in other replaces
+// the rule in s with the same ID.
+func (s Set) Merge(other Set) Set {
+	out := s
+	out.Rules = nil
+	replaced := map[string]bool{}
+	byID := map[string]Rule{}
+	for _, r := range other.Rules {
+		byID[r.ID] = r
+	}
+	for _, r := range s.Rules {
+		if o, ok := byID[r.ID]; ok {
+			r = o
+			replaced[r.ID] = true
+		}
+		out.Rules = append(out.Rules, r)
+	}
+	for _, r := range other.Rules {
+		if !replaced[r.ID] {
+			out.Rules = append(out.Rules, r)
+		}
+	}
+	return out
+}
+
+// Without returns s without the rules whose IDs are listed.
+func (s Set) Without(ids []string) Set {
+	out := s
+	out.Rules = nil
+	for _, r := range s.Rules {
+		if !slices.Contains(ids, r.ID) {
+			out.Rules = append(out.Rules, r)
+		}
+	}
+	return out
+}
+
+// hasFlag reports whether any of alts is among flags. A single-letter
+// short flag also matches inside combined short flags (-r in -rf).
+func hasFlag(flags, alts []string) bool {
+	for _, alt := range alts {
+		for _, f := range flags {
+			if f == alt || strings.HasPrefix(f, alt+"=") {
+				return true
+			}
+			if len(alt) == 2 && !strings.HasPrefix(f, "--") && isShortCombo(f) && strings.IndexByte(f[1:], alt[1]) >= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isShortCombo reports whether f looks like combined short flags (-rfv).
+func isShortCombo(f string) bool {
+	if len(f) < 3 {
+		return false
+	}
+	for _, c := range f[1:] {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func anyMatch(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlobs(globs []string, ignoreCase bool) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, g := range globs {
+		re, err := compileGlob(g, ignoreCase)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// compileGlob translates a glob into an anchored regexp: * and ? stay
+// within a path segment, ** crosses segments, [...] is a character class,
+// and \ escapes the next character.
+func compileGlob(glob string, ignoreCase bool) (*regexp.Regexp, error) {
+	if glob == "" {
+		return nil, errors.New("empty pattern")
+	}
+	var sb strings.Builder
+	if ignoreCase {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in pattern %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", glob, err)
+	}
+	return re, nil
+}
+
+// CompareVersions compares dotted numeric versions such as 2026.10.1,
+// returning -1, 0, or 1. Unparsable versions sort first.
+func CompareVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := 0; i < max(len(va), len(vb)); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) ([]int, error) {
+	if v == "" {
+		return nil, errors.New("ruleset version is required")
+	}
+	parts := strings.Split(v, ".")
+	out := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid ruleset version %q (want dotted numbers, e.g. 2026.10.1)", v)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
@@ -0,0 +1,160 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedMatch(t *testing.T) {
+	set := Embedded()
+	tests := []struct {
+		name string
+		args []string
+		want string // "" = no match
+	}{
+		{"rm", []string{"-rf", "/"}, "rm-root"},
+		{"rm", []string{"-r", "-f", "/*"}, "rm-root"},
+		{"rm", []string{"--recursive", "--force", "~/"}, "rm-root"},
+		{"rm", []string{"-rf", "./build"}, ""},
+		{"rm", []string{"-f", "/"}, ""},
+		{"rm", []string{"-r", "/etc"}, "rm-system-dir"},
+		{"rm", []string{"-r", "/usr/local"}, "rm-system-dir"},
+		{"rm", []string{"-r", "/usr/local/share"}, ""},
+		{"git", []string{"push", "-f", "origin", "main"}, "git-force-push"},
+		{"git", []string{"push", "--force-with-lease"}, ""},
+		{"git", []string{"reset", "--hard", "HEAD~1"}, "git-reset-hard"},
+		{"git", []string{"clean", "-fdx"}, "git-clean-force"},
+		{"git", []string{"clean", "-n"}, ""},
+		{"dd", []string{"if=x.img", "of=/dev/sda"}, "dd-device"},
+		{"dd", []string{"if=/dev/zero", "of=./disk.img"}, ""},
+		{"mkfs.ext4", []string{"/dev/sdb1"}, "mkfs"},
+		{"chmod", []string{"-R", "777", "."}, "chmod-777-recursive"},
+		{"terraform", []string{"destroy"}, "terraform-destroy"},
+		{"psql", []string{"-c", "DROP TABLE users"}, "sql-drop"},
+		{"psql", []string{"-c", "select 1"}, ""},
+		{"ls", []string{"-rf", "/"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+strings.Join(tt.args, " "), func(t *testing.T) {
+			r, ok := set.Match(tt.name, tt.args)
+			if got := r.ID; !ok {
+				got = ""
+				if tt.want != "" {
+					t.Errorf("no match, want %s", tt.want)
+				}
+			} else if got != tt.want {
+				t.Errorf("matched %s, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2026.10.1", "2026.10.1", 0},
+		{"2026.10.2", "2026.10.1", 1},
+		{"2026.9.5", "2026.10.1", -1},
+		{"2026.10", "2026.10.0", 0},
+		{"2027", "2026.12.31", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad version":    "version: latest\nrules: []\n",
+		"missing id":     "version: 1\nrules:\n  - command: rm\n    action: deny\n",
+		"duplicate id":   "version: 1\nrules:\n  - {id: a, command: rm, action: deny}\n  - {id: a, command: rm, action: deny}\n",
+		"bad action":     "version: 1\nrules:\n  - {id: a, command: rm, action: block}\n",
+		"missing cmd":    "version: 1\nrules:\n  - {id: a, action: deny}\n",
+		"not yaml":       "version: [",
+		"unclosed class": "version: 1\nrules:\n  - {id: a, command: 'rm[', action: deny}\n",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: Parse succeeded, want error", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	embedded := Embedded()
+
+	set, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil || set.Source != "embedded" {
+		t.Fatalf("missing file: got %s, %v; want embedded", set.Source, err)
+	}
+
+	newer := filepath.Join(dir, "newer.yaml")
+	writeFile(t, newer, "version: 9999.1.1\nrules:\n  - {id: only, command: rm, action: deny, reason: test}\n")
+	set, err = Load(newer)
+	if err != nil || set.Source != newer || len(set.Rules) != 1 {
+		t.Fatalf("newer file: got %s (%d rules), %v", set.Source, len(set.Rules), err)
+	}
+
+	older := filepath.Join(dir, "older.yaml")
+	writeFile(t, older, "version: 2000.1.1\nrules: []\n")
+	if set, _ = Load(older); set.Version != embedded.Version {
+		t.Errorf("older file: got version %s, want embedded %s", set.Version, embedded.Version)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	writeFile(t, invalid, "version: [")
+	set, err = Load(invalid)
+	if err == nil || set.Source != "embedded" {
+		t.Errorf("invalid file: got %s, %v; want embedded and an error", set.Source, err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules", "dangerous.yaml")
+	if _, err := Install([]byte("version: ["), path); err == nil {
+		t.Fatal("Install accepted an invalid ruleset")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("invalid ruleset was written: %v", err)
+	}
+	set, err := Install([]byte("version: 9999.1\nrules: []\n"), path)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if set.Source != path || set.Version != "9999.1" {
+		t.Errorf("got %s from %s", set.Version, set.Source)
+	}
+}
+
+func TestMergeWithout(t *testing.T) {
+	base, err := Parse([]byte("version: 1\nrules:\n  - {id: a, command: rm, action: deny}\n  - {id: b, command: dd, action: deny}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, err := Parse([]byte("version: 1\nrules:\n  - {id: b, command: dd, action: ask}\n  - {id: c, command: mkfs, action: deny}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := base.Merge(extra).Without([]string{"a"})
+	var got []string
+	for _, r := range merged.Rules {
+		got = append(got, r.ID+"="+r.Action)
+	}
+	if want := "b=ask c=deny"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
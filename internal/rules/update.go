@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// DefaultURL is where `hook-chain rules update` fetches the latest ruleset.
+const DefaultURL = "https://raw.githubusercontent.com/Fuabioo/hook-chain/main/internal/rules/dangerous.yaml"
+
+// maxRulesetSize caps downloaded rulesets.
+const maxRulesetSize = 1 << 20
+
+// Fetch downloads a ruleset from url.
+func Fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rules: build request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rules: fetch %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rules: fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRulesetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("rules: read %s: %w", url, err)
+	}
+	if len(data) > maxRulesetSize {
+		return nil, fmt.Errorf("rules: %s is larger than %d bytes", url, maxRulesetSize)
+	}
+	return data, nil
+}
+
+// Install validates data as a ruleset and atomically writes it to path.
+func Install(data []byte, path string) (Set, error) {
+	s, err := Parse(data)
+	if err != nil {
+		return Set{}, fmt.Errorf("rules: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Set{}, fmt.Errorf("rules: create directory for %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dangerous-*.yaml")
+	if err != nil {
+		return Set{}, fmt.Errorf("rules: create temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return Set{}, fmt.Errorf("rules: write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Set{}, fmt.Errorf("rules: close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Set{}, fmt.Errorf("rules: install %s: %w", path, err)
+	}
+	s.Source = path
+	return s, nil
+}
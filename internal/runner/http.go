@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// maxHTTPResponse bounds how much of an http hook's response is read.
+const maxHTTPResponse = 16 << 20
+
+// HTTPRunner runs hooks of type http: the input is POSTed to the hook's url
+// as application/json and the response body is the hook's stdout. A 2xx
+// response exits 0; any other status is a runner error, so the hook's
+// on_error policy applies.
+type HTTPRunner struct {
+	// Client sends the requests; nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Run implements Runner.
+func (r HTTPRunner) Run(ctx context.Context, hook config.HookEntry, input []byte) (Result, error) {
+	if hook.URL == "" {
+		return Result{}, fmt.Errorf("runner: empty url for hook %q", hook.Name)
+	}
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(input))
+	if err != nil {
+		return Result{}, fmt.Errorf("runner: hook %q: %w", hook.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hook-Chain-Hook", hook.Name)
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Result{}, fmt.Errorf("runner: hook %q %w after %s: %w", hook.Name, ErrTimeout, timeout, ctx.Err())
+		}
+		return Result{}, fmt.Errorf("runner: hook %q: %w", hook.Name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Result{}, fmt.Errorf("runner: hook %q %w after %s: %w", hook.Name, ErrTimeout, timeout, ctx.Err())
+		}
+		return Result{}, fmt.Errorf("runner: hook %q: read response: %w", hook.Name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Result{Stderr: string(body)}, fmt.Errorf("runner: hook %q: %s returned %s", hook.Name, hook.URL, resp.Status)
+	}
+	return Result{Stdout: body}, nil
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassBE    = 2
+	ioprioClassShift = 13
+	ioprioLowestBE   = 7
+)
+
+// lowerPriority renices the process to lowNice and moves it to the lowest
+// best-effort I/O priority. Threads and children started afterwards inherit
+// both.
+func lowerPriority(pid int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, lowNice); err != nil {
+		return fmt.Errorf("runner: set niceness of %d: %w", pid, err)
+	}
+	prio := ioprioClassBE<<ioprioClassShift | ioprioLowestBE
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio)); errno != 0 {
+		return fmt.Errorf("runner: set I/O priority of %d: %w", pid, errno)
+	}
+	return nil
+}
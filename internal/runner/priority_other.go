@@ -0,0 +1,6 @@
+//go:build !unix
+
+package runner
+
+// lowerPriority is a no-op where process priorities are not supported.
+func lowerPriority(int) error { return nil }
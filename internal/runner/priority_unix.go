@@ -0,0 +1,17 @@
+//go:build unix && !linux
+
+package runner
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// lowerPriority renices the process to lowNice. I/O priority has no
+// portable equivalent outside Linux and is left unchanged.
+func lowerPriority(pid int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, lowNice); err != nil {
+		return fmt.Errorf("runner: set niceness of %d: %w", pid, err)
+	}
+	return nil
+}
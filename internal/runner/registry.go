@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// Registry picks the runner for each hook by its type (see
+// config.HookEntry.EffectiveType). It is itself a Runner, so it slots in
+// wherever a single runner did. Embedders add their own hook types with
+// Register.
+type Registry struct {
+	runners map[string]Runner
+}
+
+// NewRegistry returns a registry with the process, shell, and http runners.
+// The builtin type lives in the builtin package (see builtin.Register).
+func NewRegistry() *Registry {
+	r := &Registry{runners: map[string]Runner{}}
+	r.Register(config.HookTypeProcess, ProcessRunner{})
+	r.Register(config.HookTypeShell, ShellRunner{})
+	r.Register(config.HookTypeHTTP, HTTPRunner{})
+	return r
+}
+
+// Register makes run the runner for hooks of type typ, replacing any
+// runner registered for it before.
+func (r *Registry) Register(typ string, run Runner) {
+	r.runners[typ] = run
+}
+
+// Lookup returns the runner registered for typ.
+func (r *Registry) Lookup(typ string) (Runner, bool) {
+	run, ok := r.runners[typ]
+	return run, ok
+}
+
+// Types returns the registered hook types, sorted.
+func (r *Registry) Types() []string {
+	return slices.Sorted(maps.Keys(r.runners))
+}
+
+// Run runs hook with the runner registered for its type. A type with no
+// runner fails with ErrUnknownType.
+func (r *Registry) Run(ctx context.Context, hook config.HookEntry, input []byte) (Result, error) {
+	typ := hook.EffectiveType()
+	run, ok := r.runners[typ]
+	if !ok {
+		return Result{}, fmt.Errorf("runner: hook %q: %w %q", hook.Name, ErrUnknownType, typ)
+	}
+	return run.Run(ctx, hook, input)
+}
@@ -5,20 +5,67 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
 	"github.com/Fuabioo/hook-chain/internal/pathutil"
 )
 
+// Error classes for runner failures. Runner errors wrap exactly one of these
+// (test with errors.Is) when the cause is known.
+var (
+	ErrNotFound   = errors.New("command not found")
+	ErrPermission = errors.New("permission denied")
+	ErrTimeout    = errors.New("timed out")
+	// ErrUnknownType is returned for a hook whose type has no runner.
+	ErrUnknownType = errors.New("unknown hook type")
+)
+
+// Error kinds recorded in the audit log for runner failures.
+const (
+	KindNotFound   = "not_found"
+	KindPermission = "permission"
+	KindTimeout    = "timeout"
+	KindOther      = "other"
+)
+
+// Kind returns the audit error kind for a runner error ("" for nil).
+func Kind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound):
+		return KindNotFound
+	case errors.Is(err, ErrPermission):
+		return KindPermission
+	case errors.Is(err, ErrTimeout):
+		return KindTimeout
+	default:
+		return KindOther
+	}
+}
+
+// Retryable reports whether running the hook again might succeed. A missing
+// binary, a permission problem, or an unknown hook type will fail the same
+// way every time.
+func Retryable(err error) bool {
+	return err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrPermission) && !errors.Is(err, ErrUnknownType)
+}
+
 // Result holds the output from executing a hook process.
 type Result struct {
 	ExitCode int
 	Stdout   []byte
 	Stderr   string
+	// Signal names the signal that terminated the process ("SIGKILL"), or
+	// is "" if it exited on its own. ExitCode is -1 when it is set. It is
+	// also set alongside a timeout error, for the kill that ended the run.
+	Signal string
 }
 
 // Runner executes a hook command with the given input on stdin.
@@ -29,55 +76,93 @@ type Runner interface {
 // ProcessRunner executes hooks as OS processes.
 type ProcessRunner struct{}
 
-const defaultTimeout = 30 * time.Second
+// lowNice is the niceness given to hooks with priority: low.
+const lowNice = 10
 
 // Run executes the hook command, feeding input via stdin.
-// It captures stdout and stderr separately.
+// It captures stdout and stderr separately. The command's words and args
+// are expanded with pathutil.Expand, input placeholders in args are then
+// filled in (see config.RenderArgs), and a bare command name is looked up in
+// the managed hooks directory before $PATH (see hookdir).
 //
 // Limitation: the command string is split with strings.Fields,
 // so commands containing paths with spaces must use Args instead.
 func (pr ProcessRunner) Run(ctx context.Context, hook config.HookEntry, input []byte) (Result, error) {
-	cmdStr := pathutil.ExpandTilde(hook.Command)
-	parts := strings.Fields(cmdStr)
+	parts := pathutil.Fields(hook.Command)
 	if len(parts) == 0 {
 		return Result{}, fmt.Errorf("runner: empty command for hook %q", hook.Name)
 	}
 
-	args := parts[1:]
-	if len(hook.Args) > 0 {
-		args = append(args, hook.Args...)
+	args := make([]string, len(hook.Args))
+	for i, a := range hook.Args {
+		args[i] = pathutil.Expand(a)
 	}
+	args = append(parts[1:], config.RenderArgs(args, input)...)
+	return runProcess(ctx, hook, hookdir.Command(parts[0]), args, input)
+}
 
+// runProcess runs name with args as the hook's process: input on stdin,
+// the hook's env_file and env, workdir, and priority, bounded by its timeout.
+func runProcess(ctx context.Context, hook config.HookEntry, name string, args []string, input []byte) (Result, error) {
 	timeout := hook.Timeout
-	if timeout == 0 {
-		timeout = defaultTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeout
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, parts[0], args...)
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Stdin = bytes.NewReader(input)
+	if dir := hook.EffectiveWorkdir(); dir != "" {
+		cmd.Dir = pathutil.Expand(dir)
+		if info, err := os.Stat(cmd.Dir); err != nil || !info.IsDir() {
+			return Result{}, fmt.Errorf("runner: hook %q: workdir %s is not a directory", hook.Name, cmd.Dir)
+		}
+	}
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if len(hook.Env) > 0 {
-		cmd.Env = append(os.Environ(), hook.Env...)
+	// The env_file is read at each run; env entries are applied over it.
+	fileEnv, err := hook.ReadEnvFile()
+	if err != nil {
+		return Result{}, fmt.Errorf("runner: %w", err)
+	}
+	if env := slices.Concat(fileEnv, hook.Env); len(env) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), env)
 	}
 
-	err := cmd.Run()
+	err = cmd.Start()
+	if err == nil {
+		if hook.Priority == config.PriorityLow {
+			// Best-effort: a hook that cannot be deprioritized still runs.
+			_ = lowerPriority(cmd.Process.Pid)
+		}
+		err = cmd.Wait()
+	}
 	if err != nil {
+		// A killed process surfaces as an ExitError, so check the deadline first.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Result{Signal: exitSignal(cmd.ProcessState)}, fmt.Errorf("runner: hook %q %w after %s: %w", hook.Name, ErrTimeout, timeout, ctx.Err())
+		}
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			return Result{
 				ExitCode: exitErr.ExitCode(),
 				Stdout:   stdout.Bytes(),
 				Stderr:   stderr.String(),
+				Signal:   exitSignal(exitErr.ProcessState),
 			}, nil
 		}
+		switch {
+		case errors.Is(err, exec.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+			return Result{}, fmt.Errorf("runner: hook %q: %w: %w", hook.Name, ErrNotFound, err)
+		case errors.Is(err, fs.ErrPermission):
+			return Result{}, fmt.Errorf("runner: hook %q: %w: %w", hook.Name, ErrPermission, err)
+		}
 		return Result{}, fmt.Errorf("runner: execute hook %q: %w", hook.Name, err)
 	}
 
@@ -87,3 +172,19 @@ func (pr ProcessRunner) Run(ctx context.Context, hook config.HookEntry, input []
 		Stderr:   stderr.String(),
 	}, nil
 }
+
+// mergeEnv applies env entries to base in order: "NAME=value" sets NAME and
+// "-NAME" removes it (see config.HookEnv).
+func mergeEnv(base, entries []string) []string {
+	env := slices.Clone(base)
+	for _, e := range entries {
+		name, _, _ := strings.Cut(strings.TrimPrefix(e, "-"), "=")
+		env = slices.DeleteFunc(env, func(kv string) bool {
+			return strings.HasPrefix(kv, name+"=")
+		})
+		if !strings.HasPrefix(e, "-") {
+			env = append(env, e)
+		}
+	}
+	return env
+}
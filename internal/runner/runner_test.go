@@ -2,7 +2,18 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Fuabioo/hook-chain/internal/config"
 )
@@ -77,6 +88,77 @@ func TestProcessRunnerWithArgs(t *testing.T) {
 	}
 }
 
+func TestRunnersRenderArgPlaceholders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses printf and sh")
+	}
+	input := []byte(`{"session_id":"s-1","tool_input":{"file_path":"/src/a b.go"}}`)
+	args := []string{"--file", "{{.tool_input.file_path}}", "--session={{ .session_id }}"}
+	want := "--file|/src/a b.go|--session=s-1|"
+
+	res, err := ProcessRunner{}.Run(context.Background(), config.HookEntry{Name: "p", Command: "printf %s|", Args: args}, input)
+	if err != nil || string(res.Stdout) != want {
+		t.Errorf("process: %q, %v; want %q", res.Stdout, err, want)
+	}
+	res, err = ShellRunner{}.Run(context.Background(), config.HookEntry{Name: "s", Command: `printf '%s|' "$@"`, Args: args}, input)
+	if err != nil || string(res.Stdout) != want {
+		t.Errorf("shell: %q, %v; want %q", res.Stdout, err, want)
+	}
+}
+
+func TestShellRunnerHostileArgValue(t *testing.T) {
+	hostile := `x&calc;touch pwned$(id)|"'`
+	input := []byte(`{"tool_input":{"file_path":` + strconv.Quote(hostile) + `}}`)
+	hook := config.HookEntry{Name: "s", Type: config.HookTypeShell, Command: `printf '%s' "$1"`, Args: []string{"{{.tool_input.file_path}}"}}
+
+	// cmd /C would re-parse the filled-in arg, so Windows refuses it.
+	if _, _, err := shellCommand("windows", hook, input); err == nil {
+		t.Error("windows: shellCommand accepted an input placeholder, want an error")
+	}
+	name, argv, err := shellCommand("windows", config.HookEntry{Name: "s", Command: "echo", Args: []string{"--plain"}}, input)
+	if err != nil || name != "cmd" || !slices.Equal(argv, []string{"/C", "echo", "--plain"}) {
+		t.Errorf("windows without placeholders: %s %q, %v", name, argv, err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	dir := t.TempDir()
+	hook.Workdir = dir
+	res, err := ShellRunner{}.Run(context.Background(), hook, input)
+	if err != nil || string(res.Stdout) != hostile {
+		t.Errorf("sh: %q, %v; want the value verbatim", res.Stdout, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("the value ran as a command: %v", entries)
+	}
+}
+
+func TestProcessRunnerLowPriority(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("niceness check relies on Linux nice(1) output")
+	}
+	pr := ProcessRunner{}
+	hook := config.HookEntry{
+		Name:     "low-priority",
+		Command:  "sh",
+		Args:     []string{"-c", "sleep 0.2; nice"},
+		Priority: config.PriorityLow,
+	}
+
+	result, err := pr.Run(context.Background(), hook, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	niceness, err := strconv.Atoi(strings.TrimSpace(string(result.Stdout)))
+	if err != nil {
+		t.Fatalf("parse nice output %q: %v", result.Stdout, err)
+	}
+	if niceness < lowNice {
+		t.Errorf("niceness = %d, want >= %d", niceness, lowNice)
+	}
+}
+
 func TestProcessRunnerWithEnv(t *testing.T) {
 	pr := ProcessRunner{}
 	hook := config.HookEntry{
@@ -95,3 +177,275 @@ func TestProcessRunnerWithEnv(t *testing.T) {
 		t.Errorf("Stdout = %q, want %q", got, "test_value\n")
 	}
 }
+
+func TestProcessRunnerEnvRemoval(t *testing.T) {
+	t.Setenv("HOOK_TEST_INHERITED", "parent")
+	t.Setenv("HOOK_TEST_KEPT", "kept")
+	hook := config.HookEntry{
+		Name:    "env-removal",
+		Command: "sh",
+		Args:    []string{"-c", `echo "${HOOK_TEST_INHERITED-unset} ${HOOK_TEST_KEPT} ${HOOK_TEST_SET}"`},
+		Env:     []string{"HOOK_TEST_SET=a", "-HOOK_TEST_INHERITED", "HOOK_TEST_SET=b"},
+	}
+
+	result, err := ProcessRunner{}.Run(context.Background(), hook, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := string(result.Stdout), "unset kept b\n"; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+}
+
+func TestProcessRunnerEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.env")
+	if err := os.WriteFile(path, []byte("HOOK_TEST_FILE=from-file\nHOOK_TEST_SET=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hook := config.HookEntry{
+		Name:    "env-file",
+		Command: "sh",
+		Args:    []string{"-c", `echo "$HOOK_TEST_FILE $HOOK_TEST_SET"`},
+		Env:     []string{"HOOK_TEST_SET=env"},
+		EnvFile: path,
+	}
+
+	result, err := ProcessRunner{}.Run(context.Background(), hook, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := string(result.Stdout), "from-file env\n"; got != want {
+		t.Errorf("Stdout = %q, want %q (env entries win)", got, want)
+	}
+
+	hook.EnvFile = path + ".missing"
+	if _, err := (ProcessRunner{}).Run(context.Background(), hook, nil); err == nil || !strings.Contains(err.Error(), "env_file") {
+		t.Errorf("Run with missing env_file = %v, want an env_file error", err)
+	}
+}
+
+func TestProcessRunnerWorkdirAndExpansion(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOOK_TEST_DIR", dir)
+	hook := config.HookEntry{
+		Name:    "workdir-test",
+		Command: "sh",
+		Args:    []string{"-c", `pwd; echo "$0"`, "$HOOK_TEST_DIR/x"},
+		Workdir: "${HOOK_TEST_DIR}",
+	}
+
+	result, err := ProcessRunner{}.Run(context.Background(), hook, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := dir + "\n" + dir + "/x\n"
+	if got := string(result.Stdout); got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+}
+
+func TestProcessRunnerWorkingDirTilde(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(filepath.Join(home, "repo"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hook := config.HookEntry{Name: "working-dir", Command: "pwd", WorkingDir: "~/repo"}
+
+	result, err := ProcessRunner{}.Run(context.Background(), hook, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(result.Stdout)), filepath.Join(home, "repo"); got != want {
+		t.Errorf("pwd = %q, want %q", got, want)
+	}
+
+	hook.WorkingDir = "~/missing"
+	if _, err := (ProcessRunner{}).Run(context.Background(), hook, nil); err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("Run with missing working_dir = %v, want not a directory", err)
+	}
+}
+
+func TestRunnersUseHooksDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOOK_CHAIN_HOOKS_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "hc-managed-hook"), []byte("#!/bin/sh\necho managed\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []Runner{ProcessRunner{}, ShellRunner{}} {
+		result, err := r.Run(context.Background(), config.HookEntry{Name: "m", Command: "hc-managed-hook"}, nil)
+		if err != nil {
+			t.Fatalf("%T: %v", r, err)
+		}
+		if got := string(result.Stdout); got != "managed\n" {
+			t.Errorf("%T: Stdout = %q, want managed", r, got)
+		}
+	}
+}
+
+func TestProcessRunnerErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	noExec := filepath.Join(dir, "noexec.sh")
+	if err := os.WriteFile(noExec, []byte("#!/bin/sh\nexit 0\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		hook      config.HookEntry
+		wantErr   error
+		wantKind  string
+		retryable bool
+	}{
+		{"absolute path missing", config.HookEntry{Name: "a", Command: "/nonexistent/binary/xyz"}, ErrNotFound, KindNotFound, false},
+		{"not on PATH", config.HookEntry{Name: "b", Command: "hook-chain-no-such-binary"}, ErrNotFound, KindNotFound, false},
+		{"not executable", config.HookEntry{Name: "c", Command: noExec}, ErrPermission, KindPermission, false},
+		{"timeout", config.HookEntry{Name: "d", Command: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond}, ErrTimeout, KindTimeout, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ProcessRunner{}.Run(context.Background(), tt.hook, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want errors.Is %v", err, tt.wantErr)
+			}
+			if got := Kind(err); got != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", got, tt.wantKind)
+			}
+			if got := Retryable(err); got != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestProcessRunnerSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no signals on windows")
+	}
+	tests := []struct {
+		name       string
+		hook       config.HookEntry
+		wantSignal string
+		wantErr    error
+	}{
+		{"killed", config.HookEntry{Name: "k", Command: "sh", Args: []string{"-c", "kill -KILL $$"}}, "SIGKILL", nil},
+		{"terminated", config.HookEntry{Name: "t", Command: "sh", Args: []string{"-c", "kill -TERM $$"}}, "SIGTERM", nil},
+		{"exited", config.HookEntry{Name: "e", Command: "sh", Args: []string{"-c", "exit 3"}}, "", nil},
+		{"timed out", config.HookEntry{Name: "d", Command: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond}, "SIGKILL", ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ProcessRunner{}.Run(context.Background(), tt.hook, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if res.Signal != tt.wantSignal {
+				t.Errorf("Signal = %q, want %q", res.Signal, tt.wantSignal)
+			}
+			if tt.wantErr == nil && tt.wantSignal != "" && res.ExitCode != -1 {
+				t.Errorf("ExitCode = %d, want -1", res.ExitCode)
+			}
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	if got := Kind(nil); got != "" {
+		t.Errorf("Kind(nil) = %q, want empty", got)
+	}
+	if got := Kind(errors.New("boom")); got != KindOther {
+		t.Errorf("Kind(other) = %q, want %q", got, KindOther)
+	}
+	if Retryable(nil) {
+		t.Error("Retryable(nil) = true, want false")
+	}
+}
+
+func TestShellRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	hook := config.HookEntry{
+		Name:    "shell-test",
+		Command: `tr a-z A-Z | sed "s/$/ $1/"; exit 3`,
+		Args:    []string{"arg one"},
+	}
+	res, err := ShellRunner{}.Run(context.Background(), hook, []byte("in\n"))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", res.ExitCode)
+	}
+	if got := string(res.Stdout); got != "IN arg one\n" {
+		t.Errorf("Stdout = %q, want %q", got, "IN arg one\n")
+	}
+}
+
+func TestHTTPRunner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/echo":
+			_, _ = w.Write(body)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := HTTPRunner{}.Run(context.Background(), config.HookEntry{Name: "h", URL: srv.URL + "/echo"}, []byte(`{"a":1}`))
+	if err != nil || res.ExitCode != 0 || string(res.Stdout) != `{"a":1}` {
+		t.Errorf("echo: res %+v, err %v", res, err)
+	}
+
+	res, err = HTTPRunner{}.Run(context.Background(), config.HookEntry{Name: "h", URL: srv.URL + "/fail"}, nil)
+	if err == nil || !strings.Contains(res.Stderr, "nope") {
+		t.Errorf("500: res %+v, err %v; want an error with the body as stderr", res, err)
+	}
+
+	_, err = HTTPRunner{}.Run(context.Background(), config.HookEntry{Name: "h", URL: srv.URL + "/slow", Timeout: 20 * time.Millisecond}, nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("slow: err = %v, want ErrTimeout", err)
+	}
+}
+
+type staticRunner string
+
+func (s staticRunner) Run(context.Context, config.HookEntry, []byte) (Result, error) {
+	return Result{Stdout: []byte(s)}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("custom", staticRunner("custom"))
+	reg.Register(config.HookTypeProcess, staticRunner("process"))
+
+	tests := []struct {
+		hook config.HookEntry
+		want string
+	}{
+		{config.HookEntry{Name: "default", Command: "x"}, "process"},
+		{config.HookEntry{Name: "custom", Type: "custom"}, "custom"},
+	}
+	for _, tt := range tests {
+		res, err := reg.Run(context.Background(), tt.hook, nil)
+		if err != nil || string(res.Stdout) != tt.want {
+			t.Errorf("hook %s: stdout %q, err %v; want %q", tt.hook.Name, res.Stdout, err, tt.want)
+		}
+	}
+
+	_, err := reg.Run(context.Background(), config.HookEntry{Name: "odd", Type: "wasm"}, nil)
+	if !errors.Is(err, ErrUnknownType) || Retryable(err) {
+		t.Errorf("unknown type: err = %v, want non-retryable ErrUnknownType", err)
+	}
+	if got := strings.Join(reg.Types(), ","); got != "custom,http,process,shell" {
+		t.Errorf("Types = %s", got)
+	}
+}
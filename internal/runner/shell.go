@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// ShellRunner runs hooks of type shell: the command is a shell script line,
+// so quoting, pipes, and redirections work. Args become the positional
+// parameters $1, $2, ... ($0 is the hook name). The shell expands the line
+// itself, with the managed hooks directory first on its PATH; args are
+// expanded with pathutil.Expand and their input placeholders filled in (see
+// config.RenderArgs), never passing through the shell. On Windows the line is
+// run with cmd /C and args are appended, so cmd re-parses them; input
+// placeholders are refused there (see config.HasInputPlaceholder).
+type ShellRunner struct{}
+
+// Run implements Runner.
+func (ShellRunner) Run(ctx context.Context, hook config.HookEntry, input []byte) (Result, error) {
+	if strings.TrimSpace(hook.Command) == "" {
+		return Result{}, fmt.Errorf("runner: empty command for hook %q", hook.Name)
+	}
+	if env := hookdir.PathEnv(); env != "" {
+		// First, so the hook's own env can still set PATH.
+		hook.Env = append([]string{env}, hook.Env...)
+	}
+	name, argv, err := shellCommand(runtime.GOOS, hook, input)
+	if err != nil {
+		return Result{}, err
+	}
+	return runProcess(ctx, hook, name, argv, input)
+}
+
+// shellCommand returns the shell and its arguments that run hook on goos.
+func shellCommand(goos string, hook config.HookEntry, input []byte) (string, []string, error) {
+	args := make([]string, len(hook.Args))
+	for i, a := range hook.Args {
+		args[i] = pathutil.Expand(a)
+	}
+	if goos == "windows" {
+		if config.HasInputPlaceholder(args) {
+			return "", nil, fmt.Errorf("runner: hook %q: input placeholders are not supported in shell hooks on Windows", hook.Name)
+		}
+		return "cmd", append([]string{"/C", hook.Command}, args...), nil
+	}
+	args = config.RenderArgs(args, input)
+	return "sh", append([]string{"-c", hook.Command, hook.Name}, args...), nil
+}
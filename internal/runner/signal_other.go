@@ -0,0 +1,8 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// exitSignal returns "": processes are not terminated by signals here.
+func exitSignal(*os.ProcessState) string { return "" }
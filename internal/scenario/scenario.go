@@ -0,0 +1,353 @@
+// Package scenario tests chain configs without real hook binaries. A
+// scenario is a YAML document giving a hook input, a script for each hook
+// (exit code, stdout, stderr, latency, a killing signal, or a runner error),
+// and the expected decision. Run feeds the input through the real pipeline
+// with a scripted Runner in place of the process runner, so results are
+// deterministic.
+//
+//	name: secrets are redacted before writing
+//	config: config.yaml
+//	input:
+//	  hook_event_name: PreToolUse
+//	  tool_name: Write
+//	  tool_input: {file_path: .env, content: "KEY=1"}
+//	hooks:
+//	  redact:
+//	    stdout: '{"hookSpecificOutput":{"updatedInput":{"content":"KEY=***"}}}'
+//	  scanner:
+//	    latency: 1m
+//	expect:
+//	  decision: deny
+//	  reason: timed out
+//	  ran: [redact, scanner]
+//
+// A file may hold several scenarios as separate YAML documents.
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Fuabioo/hook-chain/internal/builtin"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hook"
+	"github.com/Fuabioo/hook-chain/internal/messages"
+	"github.com/Fuabioo/hook-chain/internal/pipeline"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+// Decisions a scenario can expect.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionAsk   = "ask"
+)
+
+// Scenario is one scripted pipeline run and its expected outcome.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Config is the chain config to test, relative to the scenario file.
+	// Empty means the caller's config.
+	Config string            `yaml:"config,omitempty"`
+	Input  map[string]any    `yaml:"input"`
+	Hooks  map[string]Script `yaml:"hooks,omitempty"`
+	Expect Expect            `yaml:"expect"`
+
+	// File is the file the scenario was loaded from.
+	File string `yaml:"-"`
+}
+
+// Script is what a scripted hook does when run.
+type Script struct {
+	ExitCode int    `yaml:"exit_code,omitempty"`
+	Stdout   string `yaml:"stdout,omitempty"`
+	Stderr   string `yaml:"stderr,omitempty"`
+	// Latency is how long the hook takes. It is not slept: a latency at or
+	// over the hook's timeout fails the run with a timeout error.
+	Latency time.Duration `yaml:"latency,omitempty"`
+	// Error fails the run with a runner error instead: not_found,
+	// permission, timeout, or any other text.
+	Error string `yaml:"error,omitempty"`
+	// Signal kills the hook with that signal (e.g. SIGKILL): exit -1.
+	Signal string `yaml:"signal,omitempty"`
+}
+
+// Expect is the outcome a scenario asserts. Unset fields are not checked.
+type Expect struct {
+	Decision string `yaml:"decision,omitempty"` // allow | deny | ask
+	ExitCode *int   `yaml:"exit_code,omitempty"`
+	// Reason and Context must be substrings of permissionDecisionReason and
+	// additionalContext.
+	Reason  string `yaml:"reason,omitempty"`
+	Context string `yaml:"context,omitempty"`
+	// UpdatedInput lists top-level tool_input keys and their expected values.
+	UpdatedInput map[string]any `yaml:"updated_input,omitempty"`
+	// Ran is the exact sequence of hooks that ran, builtins included.
+	Ran []string `yaml:"ran,omitempty"`
+}
+
+// Outcome is what a scenario run produced.
+type Outcome struct {
+	ExitCode     int
+	Output       []byte
+	Decision     string
+	Reason       string
+	Context      string
+	UpdatedInput json.RawMessage
+	Ran          []string
+}
+
+// LoadFile reads every scenario in a YAML file.
+func LoadFile(path string) ([]Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("scenario: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var scenarios []Scenario
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	for {
+		var sc Scenario
+		err := dec.Decode(&sc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("scenario: parse %s: %w", path, err)
+		}
+		sc.File = path
+		if sc.Name == "" {
+			sc.Name = fmt.Sprintf("%s#%d", filepath.Base(path), len(scenarios)+1)
+		}
+		if err := sc.validate(); err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, sc)
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("scenario: %s holds no scenarios", path)
+	}
+	return scenarios, nil
+}
+
+func (sc Scenario) validate() error {
+	if len(sc.Input) == 0 {
+		return fmt.Errorf("scenario %q: input is required", sc.Name)
+	}
+	switch sc.Expect.Decision {
+	case "", DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		return fmt.Errorf("scenario %q: expect.decision %q is not allow, deny, or ask", sc.Name, sc.Expect.Decision)
+	}
+	return nil
+}
+
+// ConfigPath resolves Config against the scenario file, or returns "".
+func (sc Scenario) ConfigPath() string {
+	if sc.Config == "" || filepath.IsAbs(sc.Config) || sc.File == "" {
+		return sc.Config
+	}
+	return filepath.Join(filepath.Dir(sc.File), sc.Config)
+}
+
+// Run feeds the scenario's input through the chain cfg resolves for it.
+// Builtin hooks run for real; every other hook is answered by its script.
+// Scripts for hooks that are not in the chain, or that are builtins, are
+// errors, so typos do not pass silently.
+func Run(ctx context.Context, sc Scenario, cfg config.Config) (Outcome, error) {
+	data, err := json.Marshal(sc.Input)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("scenario %q: encode input: %w", sc.Name, err)
+	}
+	var input hook.Input
+	if err := json.Unmarshal(data, &input); err != nil {
+		return Outcome{}, fmt.Errorf("scenario %q: %w", sc.Name, err)
+	}
+
+	chain, _ := cfg.ResolveInput(input)
+	hooks := slices.Concat(chain.Hooks, chain.Finally)
+	for _, name := range slices.Sorted(maps.Keys(sc.Hooks)) {
+		i := slices.IndexFunc(hooks, func(h config.HookEntry) bool { return h.Name == name })
+		switch {
+		case i < 0:
+			return Outcome{}, fmt.Errorf("scenario %q: script for hook %q, which is not in the %s chain", sc.Name, name, input.HookEventName)
+		case hooks[i].Builtin != "":
+			return Outcome{}, fmt.Errorf("scenario %q: hook %q is builtin %s, which runs for real and cannot be scripted", sc.Name, name, hooks[i].Builtin)
+		}
+	}
+
+	msgs, err := messages.New(cfg.Messages)
+	if err != nil {
+		return Outcome{}, fmt.Errorf("scenario %q: %w", sc.Name, err)
+	}
+
+	fake := &Runner{Scripts: sc.Hooks}
+	rec := &recorder{next: builtin.Runner{Next: fake}}
+	logger := slog.New(slog.DiscardHandler)
+	result := pipeline.Run(ctx, &input, cfg.ApplyTimeouts(chain.Hooks), rec, nil, logger,
+		pipeline.WithFinally(cfg.ApplyTimeouts(chain.Finally)),
+		pipeline.WithMessages(msgs),
+		pipeline.WithRunbooks(cfg.Runbooks),
+		pipeline.WithSeverityActions(chain.Severity),
+		pipeline.WithProtectedFields(chain.ProtectedFields, chain.EffectiveOnProtected()),
+		pipeline.WithDenyAsk(chain.OnDeny.Ask),
+		pipeline.WithRisk(chain.Risk),
+		pipeline.WithMaxDuration(chain.MaxDuration, chain.EffectiveOnMaxDuration()),
+	)
+
+	o := Outcome{ExitCode: result.ExitCode, Output: result.Output, Decision: DecisionAllow, Ran: rec.ran}
+	if len(result.Output) > 0 {
+		var out hook.Output
+		if err := json.Unmarshal(result.Output, &out); err != nil {
+			return Outcome{}, fmt.Errorf("scenario %q: parse pipeline output: %w", sc.Name, err)
+		}
+		hso := out.HookSpecificOutput
+		if hso.PermissionDecision != "" {
+			o.Decision = hso.PermissionDecision
+		}
+		o.Reason = hso.PermissionDecisionReason
+		o.Context = hso.AdditionalContext
+		o.UpdatedInput = hso.UpdatedInput
+	}
+	if result.ExitCode == 2 {
+		o.Decision = DecisionDeny
+	}
+	return o, nil
+}
+
+// Check compares an outcome with the scenario's expectations and returns
+// one line per mismatch.
+func (sc Scenario) Check(o Outcome) []string {
+	var fails []string
+	e := sc.Expect
+	if e.Decision != "" && o.Decision != e.Decision {
+		fails = append(fails, fmt.Sprintf("decision: got %s, want %s", o.Decision, e.Decision))
+	}
+	if e.ExitCode != nil && o.ExitCode != *e.ExitCode {
+		fails = append(fails, fmt.Sprintf("exit code: got %d, want %d", o.ExitCode, *e.ExitCode))
+	}
+	if e.Reason != "" && !strings.Contains(o.Reason, e.Reason) {
+		fails = append(fails, fmt.Sprintf("reason: %q does not contain %q", o.Reason, e.Reason))
+	}
+	if e.Context != "" && !strings.Contains(o.Context, e.Context) {
+		fails = append(fails, fmt.Sprintf("context: %q does not contain %q", o.Context, e.Context))
+	}
+	if len(e.UpdatedInput) > 0 {
+		var got map[string]json.RawMessage
+		_ = json.Unmarshal(o.UpdatedInput, &got)
+		for _, k := range slices.Sorted(maps.Keys(e.UpdatedInput)) {
+			want, _ := json.Marshal(e.UpdatedInput[k])
+			if !jsonEqual(got[k], want) {
+				fails = append(fails, fmt.Sprintf("updated_input.%s: got %s, want %s", k, orNone(got[k]), want))
+			}
+		}
+	}
+	if e.Ran != nil && !slices.Equal(o.Ran, e.Ran) {
+		fails = append(fails, fmt.Sprintf("ran: got %v, want %v", o.Ran, e.Ran))
+	}
+	return fails
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return bytes.Equal(xs, ys)
+}
+
+func orNone(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "(none)"
+	}
+	return string(raw)
+}
+
+// Runner is a runner.Runner that answers hooks from scripts keyed by hook
+// name. Hooks without a script pass: exit 0, no output. It never starts a
+// process or sleeps.
+type Runner struct {
+	Scripts map[string]Script
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Call is one recorded Runner.Run.
+type Call struct {
+	Hook  string
+	Input []byte
+}
+
+// Run implements runner.Runner.
+func (r *Runner) Run(_ context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, Call{Hook: h.Name, Input: input})
+	r.mu.Unlock()
+
+	s, ok := r.Scripts[h.Name]
+	if !ok {
+		return runner.Result{}, nil
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeout
+	}
+	if s.Latency >= timeout {
+		return runner.Result{}, fmt.Errorf("scenario: hook %q %w after %s", h.Name, runner.ErrTimeout, timeout)
+	}
+	switch s.Error {
+	case "":
+	case runner.KindNotFound:
+		return runner.Result{}, fmt.Errorf("scenario: hook %q: %w", h.Name, runner.ErrNotFound)
+	case runner.KindPermission:
+		return runner.Result{}, fmt.Errorf("scenario: hook %q: %w", h.Name, runner.ErrPermission)
+	case runner.KindTimeout:
+		return runner.Result{}, fmt.Errorf("scenario: hook %q %w after %s", h.Name, runner.ErrTimeout, timeout)
+	default:
+		return runner.Result{}, fmt.Errorf("scenario: hook %q: %s", h.Name, s.Error)
+	}
+	if s.Signal != "" {
+		return runner.Result{ExitCode: -1, Stdout: []byte(s.Stdout), Stderr: s.Stderr, Signal: s.Signal}, nil
+	}
+	return runner.Result{ExitCode: s.ExitCode, Stdout: []byte(s.Stdout), Stderr: s.Stderr}, nil
+}
+
+// Calls returns the runs so far, in order.
+func (r *Runner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// recorder notes the name of every hook run, builtins included.
+type recorder struct {
+	next runner.Runner
+	mu   sync.Mutex
+	ran  []string
+}
+
+func (r *recorder) Run(ctx context.Context, h config.HookEntry, input []byte) (runner.Result, error) {
+	r.mu.Lock()
+	r.ran = append(r.ran, h.Name)
+	r.mu.Unlock()
+	return r.next.Run(ctx, h, input)
+}
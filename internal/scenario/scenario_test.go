@@ -0,0 +1,135 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+func TestRunnerScripts(t *testing.T) {
+	r := &Runner{Scripts: map[string]Script{
+		"deny":    {ExitCode: 2, Stderr: "no"},
+		"slow":    {Latency: 2 * time.Second},
+		"missing": {Error: runner.KindNotFound},
+		"broken":  {Error: "boom"},
+	}}
+	tests := []struct {
+		hook     config.HookEntry
+		wantCode int
+		wantErr  error
+	}{
+		{config.HookEntry{Name: "deny"}, 2, nil},
+		{config.HookEntry{Name: "unscripted"}, 0, nil},
+		{config.HookEntry{Name: "slow", Timeout: time.Second}, 0, runner.ErrTimeout},
+		{config.HookEntry{Name: "slow"}, 0, nil},
+		{config.HookEntry{Name: "missing"}, 0, runner.ErrNotFound},
+	}
+	for _, tt := range tests {
+		res, err := r.Run(context.Background(), tt.hook, []byte(`{}`))
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.hook.Name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || res.ExitCode != tt.wantCode {
+			t.Errorf("%s: got %d, %v; want %d", tt.hook.Name, res.ExitCode, err, tt.wantCode)
+		}
+	}
+	if _, err := r.Run(context.Background(), config.HookEntry{Name: "broken"}, nil); err == nil || runner.Kind(err) != runner.KindOther {
+		t.Errorf("broken: err = %v, want a runner error of kind other", err)
+	}
+	if got := len(r.Calls()); got != 6 {
+		t.Errorf("recorded %d calls, want 6", got)
+	}
+}
+
+func TestRunAndCheck(t *testing.T) {
+	cfg := config.Config{Chains: []config.ChainEntry{{
+		Event: "PreToolUse",
+		Tools: []string{"Bash"},
+		Hooks: []config.HookEntry{
+			{Name: "rewrite", Command: "rewrite"},
+			{Name: "guard", Builtin: "command-guard"},
+			{Name: "policy", Command: "policy"},
+		},
+	}}}
+	input := map[string]any{
+		"hook_event_name": "PreToolUse",
+		"tool_name":       "Bash",
+		"tool_input":      map[string]any{"command": "ls"},
+	}
+
+	sc := Scenario{
+		Name:  "guard denies the rewritten command",
+		Input: input,
+		Hooks: map[string]Script{
+			"rewrite": {Stdout: `{"hookSpecificOutput":{"updatedInput":{"command":"rm -rf /"}}}`},
+		},
+		Expect: Expect{Decision: DecisionDeny, Reason: "rm-root", Ran: []string{"rewrite", "guard"}},
+	}
+	o, err := Run(context.Background(), sc, cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fails := sc.Check(o); len(fails) > 0 {
+		t.Errorf("Check: %v (outcome %+v)", fails, o)
+	}
+
+	sc.Expect = Expect{Decision: DecisionAllow, UpdatedInput: map[string]any{"command": "ls -la"}}
+	fails := sc.Check(o)
+	if len(fails) != 2 {
+		t.Errorf("Check = %v, want decision and updated_input mismatches", fails)
+	}
+
+	for name, script := range map[string]string{"typo": "not in the", "guard": "cannot be scripted"} {
+		sc.Hooks = map[string]Script{name: {}}
+		if _, err := Run(context.Background(), sc, cfg); err == nil || !strings.Contains(err.Error(), script) {
+			t.Errorf("script for %s: err = %v, want %q", name, err, script)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.yaml")
+	data := `name: first
+config: chains.yaml
+input: {hook_event_name: Stop}
+expect: {decision: allow}
+---
+input: {hook_event_name: Stop}
+expect: {exit_code: 0}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scenarios, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if len(scenarios) != 2 {
+		t.Fatalf("got %d scenarios, want 2", len(scenarios))
+	}
+	if got, want := scenarios[0].ConfigPath(), filepath.Join(filepath.Dir(path), "chains.yaml"); got != want {
+		t.Errorf("ConfigPath = %q, want %q", got, want)
+	}
+	if scenarios[1].Name != "s.yaml#2" || scenarios[1].Expect.ExitCode == nil {
+		t.Errorf("second scenario = %+v", scenarios[1])
+	}
+
+	for _, bad := range []string{"expect: {decision: maybe}\ninput: {a: 1}\n", "input: {a: 1}\nexpected: {}\n", "name: x\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile(%q): want error", bad)
+		}
+	}
+}
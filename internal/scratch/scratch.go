@@ -0,0 +1,138 @@
+// Package scratch gives every hook run its own temporary directory, exposed
+// as HOOK_CHAIN_TMPDIR. All directories of one invocation live under a
+// single workspace that is removed when the chain finishes, so hooks neither
+// litter the temp directory nor collide on scratch file names.
+package scratch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+// EnvVar is the variable that points a hook at its temp directory.
+const EnvVar = "HOOK_CHAIN_TMPDIR"
+
+// ErrQuota is wrapped by the error returned when a hook leaves more than the
+// quota in its temp directory.
+var ErrQuota = errors.New("temp dir quota exceeded")
+
+// Workspace is the per-invocation parent of the hooks' temp directories.
+type Workspace struct {
+	root  string
+	quota int64
+}
+
+// New creates a workspace under parent (the system temp directory if
+// empty). quota is the most bytes a single hook may leave in its directory;
+// 0 means unlimited.
+func New(parent string, quota int64) (*Workspace, error) {
+	root, err := os.MkdirTemp(parent, "hook-chain-")
+	if err != nil {
+		return nil, fmt.Errorf("scratch: create workspace: %w", err)
+	}
+	return &Workspace{root: root, quota: quota}, nil
+}
+
+// Root returns the workspace directory.
+func (w *Workspace) Root() string {
+	if w == nil {
+		return ""
+	}
+	return w.root
+}
+
+// Remove deletes the workspace and everything the hooks left in it.
+// It is a no-op on a nil *Workspace.
+func (w *Workspace) Remove() error {
+	if w == nil {
+		return nil
+	}
+	if err := os.RemoveAll(w.root); err != nil {
+		return fmt.Errorf("scratch: remove workspace: %w", err)
+	}
+	return nil
+}
+
+// Runner wraps next so each hook run gets a fresh directory in the
+// workspace. A nil *Workspace returns next unchanged.
+func (w *Workspace) Runner(next runner.Runner) runner.Runner {
+	if w == nil {
+		return next
+	}
+	return quotaRunner{ws: w, next: next}
+}
+
+type quotaRunner struct {
+	ws   *Workspace
+	next runner.Runner
+}
+
+// Run creates the hook's directory, runs the hook with EnvVar pointing at
+// it, and checks the quota once the hook exits. A hook over quota fails
+// like a crashed hook, so its on_error policy applies.
+func (q quotaRunner) Run(ctx context.Context, hook config.HookEntry, input []byte) (runner.Result, error) {
+	dir, err := os.MkdirTemp(q.ws.root, dirPrefix(hook.Name))
+	if err != nil {
+		return runner.Result{}, fmt.Errorf("scratch: create temp dir for hook %q: %w", hook.Name, err)
+	}
+
+	hook.Env = append(hook.Env[:len(hook.Env):len(hook.Env)], EnvVar+"="+dir)
+	res, err := q.next.Run(ctx, hook, input)
+	if err != nil || q.ws.quota <= 0 {
+		return res, err
+	}
+
+	used, err := Usage(dir)
+	if err != nil {
+		return res, fmt.Errorf("scratch: measure temp dir of hook %q: %w", hook.Name, err)
+	}
+	if used > q.ws.quota {
+		// Free the space now rather than at the end of the chain.
+		_ = os.RemoveAll(dir)
+		return runner.Result{}, fmt.Errorf("scratch: hook %q left %d bytes in %s: %w (%d bytes)", hook.Name, used, EnvVar, ErrQuota, q.ws.quota)
+	}
+	return res, nil
+}
+
+// Usage returns the total size of the regular files under dir.
+func Usage(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+// dirPrefix turns a hook name into a safe directory name prefix.
+func dirPrefix(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if clean == "" || strings.Trim(clean, ".") == "" {
+		clean = "hook"
+	}
+	return clean + "-"
+}
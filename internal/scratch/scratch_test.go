@@ -0,0 +1,111 @@
+package scratch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/runner"
+)
+
+func TestRunnerProvidesTempDir(t *testing.T) {
+	ws, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := ws.Runner(runner.ProcessRunner{})
+	h := config.HookEntry{
+		Name:    "lint/go",
+		Command: "sh",
+		Args:    []string{"-c", `echo scratch > "$HOOK_CHAIN_TMPDIR/out" && echo "$HOOK_CHAIN_TMPDIR"`},
+	}
+
+	var dirs []string
+	for range 2 {
+		res, err := r.Run(context.Background(), h, nil)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		dir := strings.TrimSpace(string(res.Stdout))
+		if filepath.Dir(dir) != ws.Root() {
+			t.Fatalf("temp dir %q not under workspace %q", dir, ws.Root())
+		}
+		if !strings.HasPrefix(filepath.Base(dir), "lint_go-") {
+			t.Errorf("temp dir %q does not carry the sanitized hook name", dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	if dirs[0] == dirs[1] {
+		t.Errorf("runs shared temp dir %q", dirs[0])
+	}
+
+	if err := ws.Remove(); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(ws.Root()); !os.IsNotExist(err) {
+		t.Errorf("workspace still exists after Remove: %v", err)
+	}
+}
+
+func TestRunnerQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   int64
+		size    string
+		wantErr bool
+	}{
+		{"under quota", 4096, "1024", false},
+		{"over quota", 4096, "8192", true},
+		{"unlimited", 0, "8192", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := New(t.TempDir(), tt.quota)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			h := config.HookEntry{
+				Name:    "writer",
+				Command: "sh",
+				Args:    []string{"-c", `head -c ` + tt.size + ` /dev/zero > "$HOOK_CHAIN_TMPDIR/blob"`},
+			}
+			_, err = ws.Runner(runner.ProcessRunner{}).Run(context.Background(), h, nil)
+			if got := errors.Is(err, ErrQuota); got != tt.wantErr {
+				t.Errorf("quota error = %v (err %v), want %v", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNilWorkspace(t *testing.T) {
+	var ws *Workspace
+	next := runner.ProcessRunner{}
+	if ws.Runner(next) != runner.Runner(next) {
+		t.Error("nil workspace should return the wrapped runner unchanged")
+	}
+	if err := ws.Remove(); err != nil {
+		t.Errorf("Remove on nil workspace: %v", err)
+	}
+}
+
+func TestDirPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"guard", "guard-"},
+		{"lint/go vet", "lint_go_vet-"},
+		{"..", "hook-"},
+		{"", "hook-"},
+	}
+	for _, tt := range tests {
+		if got := dirPrefix(tt.name); got != tt.want {
+			t.Errorf("dirPrefix(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
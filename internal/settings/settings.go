@@ -0,0 +1,236 @@
+// Package settings converts hooks configured directly in a Claude Code
+// settings.json into hook-chain chains (`hook-chain import-settings`).
+//
+// Claude Code runs every matcher group whose matcher fits the tool, while
+// hook-chain runs only the first chain that lists it. Import therefore
+// gathers, for each event and tool, the hooks of all matching groups in
+// order, and emits one chain per distinct hook sequence.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// KnownTools are the built-in Claude Code tools that wildcard and regex
+// matchers are expanded against. MCP tools must be listed explicitly.
+var KnownTools = []string{
+	"Bash", "Edit", "Glob", "Grep", "MultiEdit", "NotebookEdit", "Read",
+	"Task", "TodoWrite", "WebFetch", "WebSearch", "Write",
+}
+
+// plainMatcher is a "|"-separated list of exact tool names.
+var plainMatcher = regexp.MustCompile(`^[A-Za-z0-9_-]+(\|[A-Za-z0-9_-]+)*$`)
+
+// file is the part of settings.json that Import reads.
+type file struct {
+	Hooks map[string][]group `json:"hooks"`
+}
+
+// group is one matcher group of an event.
+type group struct {
+	Matcher matcher `json:"matcher"`
+	Hooks   []entry `json:"hooks"`
+}
+
+// entry is one hook of a matcher group. Timeout is in seconds.
+type entry struct {
+	Type    string  `json:"type"`
+	Command string  `json:"command"`
+	Timeout float64 `json:"timeout"`
+}
+
+// matcher accepts the string form ("Bash|Write") and the object form
+// ({"tool_name": "Bash"}).
+type matcher string
+
+func (m *matcher) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = matcher(s)
+		return nil
+	}
+	var obj struct {
+		ToolName string `json:"tool_name"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("settings: matcher must be a string or {\"tool_name\": ...}: %w", err)
+	}
+	*m = matcher(obj.ToolName)
+	return nil
+}
+
+// DefaultPath returns the user-level Claude Code settings file,
+// ~/.claude/settings.json.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".claude", "settings.json")
+}
+
+// ImportFile reads the settings file at path and converts its hooks.
+func ImportFile(path string) (chains []config.ChainEntry, warnings []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("settings: read %s: %w", path, err)
+	}
+	return Import(data)
+}
+
+// Import converts the hooks in settings.json content into chains, keeping
+// matchers as tool lists and timeouts. Events are visited in the order
+// config.ToolEvents, then alphabetically. Warnings describe anything that
+// could not be carried over exactly; hooks that already run hook-chain are
+// dropped.
+func Import(data []byte) (chains []config.ChainEntry, warnings []string, err error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("settings: parse: %w", err)
+	}
+
+	var events []string
+	for _, e := range config.ToolEvents {
+		if _, ok := f.Hooks[e]; ok {
+			events = append(events, e)
+		}
+	}
+	for _, e := range slices.Sorted(maps.Keys(f.Hooks)) {
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	for _, event := range events {
+		c, w := importEvent(event, f.Hooks[event])
+		chains = append(chains, c...)
+		warnings = append(warnings, w...)
+	}
+	return chains, warnings, nil
+}
+
+// importEvent converts the matcher groups of one event.
+func importEvent(event string, groups []group) (chains []config.ChainEntry, warnings []string) {
+	toolEvent := slices.Contains(config.ToolEvents, event)
+
+	// hooks[tool] collects the hooks of every group matching tool, in order;
+	// tools keeps the order in which tools first appear.
+	var tools []string
+	hooks := map[string][]config.HookEntry{}
+	for i, g := range groups {
+		var entries []config.HookEntry
+		for _, e := range g.Hooks {
+			h, warning := convert(e)
+			if warning != "" {
+				warnings = append(warnings, fmt.Sprintf("%s group %d: %s", event, i+1, warning))
+			}
+			if h.Command != "" {
+				entries = append(entries, h)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		matched := []string{""}
+		if toolEvent {
+			var warning string
+			matched, warning = expand(string(g.Matcher))
+			if warning != "" {
+				warnings = append(warnings, fmt.Sprintf("%s group %d: %s", event, i+1, warning))
+			}
+		} else if g.Matcher != "" && g.Matcher != "*" {
+			warnings = append(warnings, fmt.Sprintf("%s group %d: matcher %q dropped; hook-chain matches %s chains by event only", event, i+1, g.Matcher, event))
+		}
+		for _, t := range matched {
+			if _, ok := hooks[t]; !ok {
+				tools = append(tools, t)
+			}
+			hooks[t] = append(hooks[t], entries...)
+		}
+	}
+
+	for _, t := range tools {
+		idx := slices.IndexFunc(chains, func(c config.ChainEntry) bool {
+			return slices.EqualFunc(c.Hooks, hooks[t], sameHook)
+		})
+		if idx < 0 {
+			chains = append(chains, config.ChainEntry{Event: event, Hooks: nameHooks(hooks[t])})
+			idx = len(chains) - 1
+		}
+		if t != "" {
+			chains[idx].Tools = append(chains[idx].Tools, t)
+		}
+	}
+	return chains, warnings
+}
+
+// convert turns one settings hook into a hook entry. A zero entry with a
+// warning means the hook was skipped.
+func convert(e entry) (config.HookEntry, string) {
+	if e.Type != "" && e.Type != "command" {
+		return config.HookEntry{}, fmt.Sprintf("skipped hook of type %q; only command hooks can be chained", e.Type)
+	}
+	fields := strings.Fields(e.Command)
+	if len(fields) == 0 {
+		return config.HookEntry{}, "skipped hook with an empty command"
+	}
+	if filepath.Base(fields[0]) == "hook-chain" {
+		return config.HookEntry{}, ""
+	}
+	return config.HookEntry{
+		Name:    filepath.Base(fields[0]),
+		Command: e.Command,
+		Timeout: time.Duration(e.Timeout * float64(time.Second)),
+	}, ""
+}
+
+// expand turns a tool-event matcher into tool names. Plain "A|B" lists map
+// exactly; wildcards and regexes are expanded against KnownTools.
+func expand(m string) (tools []string, warning string) {
+	if plainMatcher.MatchString(m) {
+		return strings.Split(m, "|"), ""
+	}
+	if m == "" || m == "*" {
+		return KnownTools, fmt.Sprintf("matcher %q expanded to the built-in tools; add MCP tools by hand", m)
+	}
+	re, err := regexp.Compile("^(?:" + m + ")$")
+	if err != nil {
+		return nil, fmt.Sprintf("skipped matcher %q: %v", m, err)
+	}
+	for _, t := range KnownTools {
+		if re.MatchString(t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, fmt.Sprintf("regex matcher %q expanded to %v; tools added later will not match", m, tools)
+}
+
+// sameHook compares hooks by what they run, ignoring names.
+func sameHook(a, b config.HookEntry) bool {
+	return a.Command == b.Command && a.Timeout == b.Timeout
+}
+
+// nameHooks copies hooks, suffixing repeated names so each is unique.
+func nameHooks(hooks []config.HookEntry) []config.HookEntry {
+	out := make([]config.HookEntry, len(hooks))
+	seen := map[string]int{}
+	for i, h := range hooks {
+		seen[h.Name]++
+		if n := seen[h.Name]; n > 1 {
+			h.Name = fmt.Sprintf("%s-%d", h.Name, n)
+		}
+		out[i] = h
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func TestImport(t *testing.T) {
+	data := []byte(`{
+  "permissions": {"allow": ["Bash(ls:*)"]},
+  "hooks": {
+    "Stop": [
+      {"hooks": [{"type": "command", "command": "~/hooks/notify.sh"}]}
+    ],
+    "PreToolUse": [
+      {"matcher": "Bash", "hooks": [
+        {"type": "command", "command": "~/hooks/guard.sh --strict", "timeout": 5},
+        {"type": "command", "command": "hook-chain"}
+      ]},
+      {"matcher": "Bash|Write", "hooks": [{"type": "command", "command": "/usr/bin/audit-hook", "timeout": 1.5}]},
+      {"matcher": {"tool_name": "Write"}, "hooks": [{"type": "prompt", "prompt": "check"}]}
+    ],
+    "SessionStart": [
+      {"matcher": "startup", "hooks": [{"type": "command", "command": "warmup"}]}
+    ]
+  }
+}`)
+
+	chains, warnings, err := Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	want := []config.ChainEntry{
+		{Event: "PreToolUse", Tools: []string{"Bash"}, Hooks: []config.HookEntry{
+			{Name: "guard.sh", Command: "~/hooks/guard.sh --strict", Timeout: 5 * time.Second},
+			{Name: "audit-hook", Command: "/usr/bin/audit-hook", Timeout: 1500 * time.Millisecond},
+		}},
+		{Event: "PreToolUse", Tools: []string{"Write"}, Hooks: []config.HookEntry{
+			{Name: "audit-hook", Command: "/usr/bin/audit-hook", Timeout: 1500 * time.Millisecond},
+		}},
+		{Event: "SessionStart", Hooks: []config.HookEntry{{Name: "warmup", Command: "warmup"}}},
+		{Event: "Stop", Hooks: []config.HookEntry{{Name: "notify.sh", Command: "~/hooks/notify.sh"}}},
+	}
+	if len(chains) != len(want) {
+		t.Fatalf("got %d chains, want %d: %+v", len(chains), len(want), chains)
+	}
+	for i := range want {
+		got := chains[i]
+		if got.Event != want[i].Event || !slices.Equal(got.Tools, want[i].Tools) || !slices.EqualFunc(got.Hooks, want[i].Hooks, func(a, b config.HookEntry) bool {
+			return a.Name == b.Name && sameHook(a, b)
+		}) {
+			t.Errorf("chain %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, w := range []string{
+		`PreToolUse group 3: skipped hook of type "prompt"`,
+		`SessionStart group 1: matcher "startup" dropped`,
+	} {
+		if !strings.Contains(joined, w) {
+			t.Errorf("warnings missing %q:\n%s", w, joined)
+		}
+	}
+	if len(warnings) != 2 {
+		t.Errorf("got %d warnings, want 2:\n%s", len(warnings), joined)
+	}
+}
+
+func TestImportSharedSequence(t *testing.T) {
+	// Tools with the same hooks share one chain.
+	data := []byte(`{"hooks": {"PostToolUse": [
+	  {"matcher": "Edit|Write", "hooks": [{"command": "fmt-hook"}, {"command": "fmt-hook --check"}]}
+	]}}`)
+	chains, _, err := Import(data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(chains) != 1 || !slices.Equal(chains[0].Tools, []string{"Edit", "Write"}) {
+		t.Fatalf("chains = %+v, want one chain for Edit and Write", chains)
+	}
+	var names []string
+	for _, h := range chains[0].Hooks {
+		names = append(names, h.Name)
+	}
+	if !slices.Equal(names, []string{"fmt-hook", "fmt-hook-2"}) {
+		t.Errorf("names = %v, want [fmt-hook fmt-hook-2]", names)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		matcher string
+		want    []string
+		warn    bool
+	}{
+		{"Bash", []string{"Bash"}, false},
+		{"mcp__github__create_issue|Bash", []string{"mcp__github__create_issue", "Bash"}, false},
+		{"*", KnownTools, true},
+		{"", KnownTools, true},
+		{"Web.*", []string{"WebFetch", "WebSearch"}, true},
+		{"Edit|Multi.*", []string{"Edit", "MultiEdit"}, true},
+		{"(", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.matcher, func(t *testing.T) {
+			got, warning := expand(tt.matcher)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expand(%q) = %v, want %v", tt.matcher, got, tt.want)
+			}
+			if (warning != "") != tt.warn {
+				t.Errorf("expand(%q) warning = %q, want warning: %v", tt.matcher, warning, tt.warn)
+			}
+		})
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	if _, _, err := Import([]byte(`{"hooks": {"PreToolUse": [{"matcher": 3}]}}`)); err == nil {
+		t.Error("Import accepted a numeric matcher")
+	}
+	if _, _, err := Import([]byte(`not json`)); err == nil {
+		t.Error("Import accepted invalid JSON")
+	}
+}
@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// ElasticBulk sends records to an Elasticsearch/OpenSearch _bulk endpoint
+// (e.g. https://es:9200/_bulk).
+type ElasticBulk struct {
+	*transport
+	index string
+}
+
+type bulkAction struct {
+	Index bulkMeta `json:"index"`
+}
+
+type bulkMeta struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id,omitempty"`
+}
+
+// bulkResponse is the subset of the _bulk response needed to detect item failures.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error,omitempty"`
+	} `json:"items"`
+}
+
+// Send posts the batch as NDJSON bulk index actions. Chain IDs are used as
+// document IDs so re-sending a batch after a partial failure is idempotent.
+func (e *ElasticBulk) Send(ctx context.Context, chains []audit.ChainExecution) error {
+	if len(chains) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, c := range chains {
+		action := bulkAction{Index: bulkMeta{Index: e.index}}
+		if c.ID != 0 {
+			action.Index.ID = strconv.FormatInt(c.ID, 10)
+		}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("sink: elastic encode action for chain %d: %w", c.ID, err)
+		}
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("sink: elastic encode chain %d: %w", c.ID, err)
+		}
+	}
+
+	respBody, err := e.post(ctx, "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var resp bulkResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("sink: elastic parse bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for _, r := range item {
+			if r.Error != nil {
+				return fmt.Errorf("sink: elastic bulk item failed (status %d): %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+			}
+		}
+	}
+	return fmt.Errorf("sink: elastic bulk reported errors")
+}
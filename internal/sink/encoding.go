@@ -0,0 +1,43 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/auditpb"
+)
+
+// Encodings accepted for streaming sinks.
+const (
+	EncodingJSON      = "json"      // Go struct JSON (default)
+	EncodingProtoJSON = "protojson" // proto3 JSON mapping of hookchain.audit.v1
+	EncodingProtobuf  = "protobuf"  // binary hookchain.audit.v1.ChainExecution
+)
+
+// validEncoding reports whether encoding is supported ("" means json).
+func validEncoding(encoding string) bool {
+	switch encoding {
+	case "", EncodingJSON, EncodingProtoJSON, EncodingProtobuf:
+		return true
+	}
+	return false
+}
+
+// encodeChain serializes one record for message-oriented sinks.
+func encodeChain(encoding string, c audit.ChainExecution) ([]byte, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("sink: encode chain %d as json: %w", c.ID, err)
+		}
+		return data, nil
+	case EncodingProtoJSON:
+		return auditpb.MarshalJSON(c)
+	case EncodingProtobuf:
+		return auditpb.Marshal(c), nil
+	default:
+		return nil, fmt.Errorf("sink: unknown encoding %q", encoding)
+	}
+}
@@ -0,0 +1,158 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// NATS publishes each record as one message on a subject using the NATS
+// client text protocol (nats:// or tls:// URLs, optional user:pass@ or token@).
+type NATS struct {
+	url      *url.URL
+	subject  string
+	encoding string
+	timeout  time.Duration
+	batch    int
+}
+
+// natsConnect is the CONNECT payload sent after the server INFO line.
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+func newNATS(u *url.URL, subject, encoding string, timeout time.Duration, batch int) *NATS {
+	return &NATS{url: u, subject: subject, encoding: encoding, timeout: timeout, batch: batch}
+}
+
+// BatchSize returns the configured batch size.
+func (n *NATS) BatchSize() int { return n.batch }
+
+// Send publishes every record, then round-trips a PING so the call only
+// succeeds once the server has processed the batch.
+func (n *NATS) Send(ctx context.Context, chains []audit.ChainExecution) error {
+	if len(chains) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	conn, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("sink: nats set deadline: %w", err)
+		}
+	}
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	// Server greets with INFO.
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("sink: nats read INFO: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("sink: nats unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	connect := natsConnect{Name: "hook-chain", Lang: "go", Version: "1"}
+	if u := n.url.User; u != nil {
+		if pass, ok := u.Password(); ok {
+			connect.User, connect.Pass = u.Username(), pass
+		} else {
+			connect.Token = u.Username()
+		}
+	}
+	connectJSON, err := json.Marshal(connect)
+	if err != nil {
+		return fmt.Errorf("sink: nats marshal CONNECT: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "CONNECT %s\r\n", connectJSON); err != nil {
+		return fmt.Errorf("sink: nats write CONNECT: %w", err)
+	}
+
+	for _, c := range chains {
+		payload, err := encodeChain(n.encoding, c)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "PUB %s %d\r\n", n.subject, len(payload)); err != nil {
+			return fmt.Errorf("sink: nats write PUB: %w", err)
+		}
+		if _, err := w.Write(payload); err != nil {
+			return fmt.Errorf("sink: nats write payload: %w", err)
+		}
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return fmt.Errorf("sink: nats write payload: %w", err)
+		}
+	}
+
+	if _, err := w.WriteString("PING\r\n"); err != nil {
+		return fmt.Errorf("sink: nats write PING: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("sink: nats flush: %w", err)
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("sink: nats await PONG: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("sink: nats server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// Ignore +OK, INFO updates, and server PINGs while waiting.
+	}
+}
+
+func (n *NATS) dial(ctx context.Context) (net.Conn, error) {
+	host := n.url.Host
+	if n.url.Port() == "" {
+		host = net.JoinHostPort(n.url.Hostname(), "4222")
+	}
+
+	var d net.Dialer
+	switch n.url.Scheme {
+	case "nats":
+		conn, err := d.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return nil, fmt.Errorf("sink: nats dial %s: %w", host, err)
+		}
+		return conn, nil
+	case "tls":
+		td := tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: n.url.Hostname()}}
+		conn, err := td.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return nil, fmt.Errorf("sink: nats tls dial %s: %w", host, err)
+		}
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("sink: nats unsupported scheme %q (want nats or tls)", n.url.Scheme)
+	}
+}
@@ -0,0 +1,288 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// Sink type names accepted in config.
+const (
+	TypeSplunkHEC   = "splunk_hec"
+	TypeElasticBulk = "elastic_bulk"
+	TypeNATS        = "nats"
+	TypeKafkaREST   = "kafka_rest"
+)
+
+const (
+	defaultBatchSize  = 100
+	defaultMaxRetries = 3
+	defaultTimeout    = 10 * time.Second
+	initialBackoff    = 500 * time.Millisecond
+	maxBackoff        = 30 * time.Second
+)
+
+// Sink delivers batches of audit records to an external system.
+type Sink interface {
+	// Send delivers one batch. It returns only after the batch was accepted
+	// or retries were exhausted, so callers naturally apply backpressure.
+	Send(ctx context.Context, chains []audit.ChainExecution) error
+	// BatchSize is the maximum number of records per Send call.
+	BatchSize() int
+}
+
+// New builds the sink described by cfg.
+func New(cfg config.SinkConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("sink %q: url is required", cfg.Name)
+	}
+	if !validEncoding(cfg.Encoding) {
+		return nil, fmt.Errorf("sink %q: unknown encoding %q (want %s, %s, or %s)", cfg.Name, cfg.Encoding, EncodingJSON, EncodingProtoJSON, EncodingProtobuf)
+	}
+
+	t := newTransport(cfg)
+	switch cfg.Type {
+	case TypeSplunkHEC:
+		if cfg.Token != "" {
+			t.authHeader = "Splunk " + cfg.Token
+		}
+		return &SplunkHEC{transport: t, index: cfg.Index, sourceType: cfg.SourceType}, nil
+	case TypeElasticBulk:
+		if cfg.Index == "" {
+			return nil, fmt.Errorf("sink %q: index is required for %s", cfg.Name, TypeElasticBulk)
+		}
+		if cfg.Token != "" {
+			t.authHeader = "ApiKey " + cfg.Token
+		}
+		return &ElasticBulk{transport: t, index: cfg.Index}, nil
+	case TypeNATS:
+		if cfg.Topic == "" {
+			return nil, fmt.Errorf("sink %q: topic (subject) is required for %s", cfg.Name, TypeNATS)
+		}
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("sink %q: parse url: %w", cfg.Name, err)
+		}
+		return newNATS(u, cfg.Topic, cfg.Encoding, t.client.Timeout, t.batchSize), nil
+	case TypeKafkaREST:
+		if cfg.Topic == "" {
+			return nil, fmt.Errorf("sink %q: topic is required for %s", cfg.Name, TypeKafkaREST)
+		}
+		if cfg.Token != "" {
+			t.authHeader = "Bearer " + cfg.Token
+		}
+		return newKafkaREST(t, cfg.Topic, cfg.Encoding), nil
+	default:
+		return nil, fmt.Errorf("sink %q: unknown type %q (want %s, %s, %s, or %s)", cfg.Name, cfg.Type, TypeSplunkHEC, TypeElasticBulk, TypeNATS, TypeKafkaREST)
+	}
+}
+
+// transport is the shared HTTP delivery layer with retry and backoff.
+type transport struct {
+	client     *http.Client
+	url        string
+	authHeader string
+	batchSize  int
+	maxRetries int
+
+	// sleep is swapped out in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newTransport(cfg config.SinkConfig) *transport {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	batch := cfg.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	retries := cfg.MaxRetries
+	if retries <= 0 {
+		retries = defaultMaxRetries
+	}
+	return &transport{
+		client:     &http.Client{Timeout: timeout},
+		url:        cfg.URL,
+		batchSize:  batch,
+		maxRetries: retries,
+		sleep:      sleepCtx,
+	}
+}
+
+// BatchSize returns the configured batch size.
+func (t *transport) BatchSize() int { return t.batchSize }
+
+// post sends body, retrying on network errors, 429, and 5xx responses with
+// exponential backoff (honoring Retry-After). It returns the final response body.
+func (t *transport) post(ctx context.Context, contentType string, body []byte) ([]byte, error) {
+	backoff := initialBackoff
+	var lastErr error
+
+	for attempt := 0; attempt <= t.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := t.sleep(ctx, backoff); err != nil {
+				return nil, err
+			}
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("sink: build request: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if t.authHeader != "" {
+			req.Header.Set("Authorization", t.authHeader)
+		}
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("sink: post %s: %w", t.url, err)
+			continue
+		}
+		respBody, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if readErr != nil {
+			lastErr = fmt.Errorf("sink: read response: %w", readErr)
+			continue
+		}
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return respBody, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("sink: post %s: status %d: %s", t.url, resp.StatusCode, bytes.TrimSpace(respBody))
+			if ra := retryAfter(resp.Header.Get("Retry-After")); ra > backoff {
+				backoff = min(ra, maxBackoff)
+			}
+		default:
+			// Client errors are not retryable: the batch itself is bad.
+			return nil, fmt.Errorf("sink: post %s: status %d: %s", t.url, resp.StatusCode, bytes.TrimSpace(respBody))
+		}
+	}
+
+	return nil, fmt.Errorf("sink: giving up after %d attempts: %w", t.maxRetries+1, lastErr)
+}
+
+// retryAfter parses a Retry-After header given in seconds. Zero if absent or invalid.
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Drain delivers audit records not yet exported to the named sink, one batch
+// at a time, advancing the sink's export cursor after each accepted batch.
+// A failed batch stops the drain; the cursor stays at the last accepted
+// batch so the next run resumes there. limit caps the total number of
+// records (0 = no limit). It returns the number of records delivered.
+func Drain(ctx context.Context, db *sql.DB, name string, s Sink, limit int) (int, error) {
+	cursor, err := audit.ExportCursor(db, name)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for limit <= 0 || sent < limit {
+		batch := s.BatchSize()
+		if limit > 0 {
+			batch = min(batch, limit-sent)
+		}
+
+		chains, err := audit.ChainsAfter(db, cursor, batch)
+		if err != nil {
+			return sent, err
+		}
+		if len(chains) == 0 {
+			break
+		}
+
+		if err := s.Send(ctx, chains); err != nil {
+			return sent, fmt.Errorf("sink %q: %w", name, err)
+		}
+
+		cursor = chains[len(chains)-1].ID
+		if err := audit.SetExportCursor(db, name, cursor); err != nil {
+			return sent, err
+		}
+		sent += len(chains)
+	}
+	return sent, nil
+}
+
+// FlushOutbox delivers every chain queued in the named sink's outbox, one
+// batch at a time, acknowledging each accepted batch. A failed batch is
+// marked with the error and stops the flush so it is retried next time.
+// Queued chains that no longer exist (pruned) are acknowledged and skipped.
+// It returns the number of records delivered.
+func FlushOutbox(ctx context.Context, db *sql.DB, name string, s Sink) (int, error) {
+	sent := 0
+	for {
+		ids, err := audit.PendingOutbox(db, name, s.BatchSize())
+		if err != nil {
+			return sent, err
+		}
+		if len(ids) == 0 {
+			return sent, nil
+		}
+
+		chains := make([]audit.ChainExecution, 0, len(ids))
+		var missing []int64
+		for _, id := range ids {
+			c, err := audit.GetChain(db, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				missing = append(missing, id)
+				continue
+			}
+			if err != nil {
+				return sent, err
+			}
+			chains = append(chains, *c)
+		}
+		if err := audit.AckOutbox(db, name, missing); err != nil {
+			return sent, err
+		}
+
+		if len(chains) > 0 {
+			delivered := make([]int64, 0, len(chains))
+			for _, c := range chains {
+				delivered = append(delivered, c.ID)
+			}
+			if err := s.Send(ctx, chains); err != nil {
+				if failErr := audit.FailOutbox(db, name, delivered, err); failErr != nil {
+					return sent, errors.Join(fmt.Errorf("sink %q: %w", name, err), failErr)
+				}
+				return sent, fmt.Errorf("sink %q: %w", name, err)
+			}
+			if err := audit.AckOutbox(db, name, delivered); err != nil {
+				return sent, err
+			}
+			sent += len(chains)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func sampleChains(n int) []audit.ChainExecution {
+	chains := make([]audit.ChainExecution, n)
+	for i := range chains {
+		chains[i] = audit.ChainExecution{
+			ID:        int64(i + 1),
+			Timestamp: time.Date(2025, 6, 1, 12, 0, i, 0, time.UTC),
+			EventName: "PreToolUse",
+			ToolName:  "Bash",
+			Outcome:   audit.OutcomeAllow,
+		}
+	}
+	return chains
+}
+
+func countLines(t *testing.T, body []byte) int {
+	t.Helper()
+	n := 0
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.SinkConfig
+		wantErr bool
+	}{
+		{"splunk ok", config.SinkConfig{Name: "s", Type: TypeSplunkHEC, URL: "http://x"}, false},
+		{"elastic ok", config.SinkConfig{Name: "e", Type: TypeElasticBulk, URL: "http://x", Index: "audit"}, false},
+		{"missing url", config.SinkConfig{Name: "s", Type: TypeSplunkHEC}, true},
+		{"elastic missing index", config.SinkConfig{Name: "e", Type: TypeElasticBulk, URL: "http://x"}, true},
+		{"unknown type", config.SinkConfig{Name: "k", Type: "kafka", URL: "http://x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplunkHECSend(t *testing.T) {
+	var gotAuth string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "splunk", Type: TypeSplunkHEC, URL: srv.URL, Token: "tok", Index: "guard", SourceType: "hook-chain:audit"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(3)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotAuth != "Splunk tok" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Splunk tok")
+	}
+	if n := countLines(t, gotBody); n != 3 {
+		t.Errorf("events in body = %d, want 3", n)
+	}
+
+	var first hecEvent
+	if err := json.NewDecoder(bytes.NewReader(gotBody)).Decode(&first); err != nil {
+		t.Fatalf("decode first event: %v", err)
+	}
+	if first.Index != "guard" || first.SourceType != "hook-chain:audit" || first.Event.ID != 1 {
+		t.Errorf("first event = %+v, want index/sourcetype set and ID 1", first)
+	}
+}
+
+func TestElasticBulkSend(t *testing.T) {
+	var gotBody []byte
+	var gotType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "es", Type: TypeElasticBulk, URL: srv.URL, Index: "audit"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(2)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", gotType)
+	}
+	// One action line + one document line per record.
+	if n := countLines(t, gotBody); n != 4 {
+		t.Errorf("lines in body = %d, want 4", n)
+	}
+
+	var action bulkAction
+	if err := json.NewDecoder(bytes.NewReader(gotBody)).Decode(&action); err != nil {
+		t.Fatalf("decode action: %v", err)
+	}
+	if action.Index.Index != "audit" || action.Index.ID != "1" {
+		t.Errorf("action = %+v, want _index=audit _id=1", action)
+	}
+}
+
+func TestElasticBulkItemErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "es", Type: TypeElasticBulk, URL: srv.URL, Index: "audit"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(1)); err == nil {
+		t.Fatal("expected error for bulk item failure, got nil")
+	}
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "s", Type: TypeSplunkHEC, URL: srv.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.(*SplunkHEC).sleep = noSleep
+
+	if err := s.Send(context.Background(), sampleChains(1)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "s", Type: TypeSplunkHEC, URL: srv.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.(*SplunkHEC).sleep = noSleep
+
+	if err := s.Send(context.Background(), sampleChains(1)); err == nil {
+		t.Fatal("expected error for 400, got nil")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "s", Type: TypeSplunkHEC, URL: srv.URL, MaxRetries: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.(*SplunkHEC).sleep = noSleep
+
+	if err := s.Send(context.Background(), sampleChains(1)); err == nil {
+		t.Fatal("expected error after retries exhausted, got nil")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3 (1 + 2 retries)", got)
+	}
+}
+
+func TestDrainAdvancesCursor(t *testing.T) {
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+
+	for _, c := range sampleChains(5) {
+		c.ID = 0
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		batches = append(batches, countLines(t, body))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "splunk", Type: TypeSplunkHEC, URL: srv.URL, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	sent, err := Drain(context.Background(), a.DB(), "splunk", s, 0)
+	if err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if sent != 5 {
+		t.Errorf("sent = %d, want 5", sent)
+	}
+	if len(batches) != 3 || batches[0] != 2 || batches[2] != 1 {
+		t.Errorf("batches = %v, want [2 2 1]", batches)
+	}
+
+	cursor, err := audit.ExportCursor(a.DB(), "splunk")
+	if err != nil {
+		t.Fatalf("ExportCursor: %v", err)
+	}
+	if cursor != 5 {
+		t.Errorf("cursor = %d, want 5", cursor)
+	}
+
+	// A second drain has nothing to send.
+	sent, err = Drain(context.Background(), a.DB(), "splunk", s, 0)
+	if err != nil {
+		t.Fatalf("second Drain: %v", err)
+	}
+	if sent != 0 {
+		t.Errorf("second sent = %d, want 0", sent)
+	}
+}
+
+func TestDrainStopsOnFailure(t *testing.T) {
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+
+	for _, c := range sampleChains(4) {
+		c.ID = 0
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "splunk", Type: TypeSplunkHEC, URL: srv.URL, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	sent, err := Drain(context.Background(), a.DB(), "splunk", s, 0)
+	if err == nil {
+		t.Fatal("expected error from failing batch, got nil")
+	}
+	if sent != 2 {
+		t.Errorf("sent = %d, want 2", sent)
+	}
+	cursor, err := audit.ExportCursor(a.DB(), "splunk")
+	if err != nil {
+		t.Fatalf("ExportCursor: %v", err)
+	}
+	if cursor != 2 {
+		t.Errorf("cursor = %d, want 2 (resume after last accepted batch)", cursor)
+	}
+}
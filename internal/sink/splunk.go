@@ -0,0 +1,52 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// SplunkHEC sends records to a Splunk HTTP Event Collector endpoint
+// (e.g. https://splunk:8088/services/collector/event).
+type SplunkHEC struct {
+	*transport
+	index      string
+	sourceType string
+}
+
+// hecEvent is one event in the HEC batch payload.
+type hecEvent struct {
+	Time       float64              `json:"time"`
+	Index      string               `json:"index,omitempty"`
+	SourceType string               `json:"sourcetype,omitempty"`
+	Source     string               `json:"source"`
+	Event      audit.ChainExecution `json:"event"`
+}
+
+// Send posts the batch as concatenated HEC event objects.
+func (s *SplunkHEC) Send(ctx context.Context, chains []audit.ChainExecution) error {
+	if len(chains) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, c := range chains {
+		ev := hecEvent{
+			Time:       float64(c.Timestamp.UnixMilli()) / 1000,
+			Index:      s.index,
+			SourceType: s.sourceType,
+			Source:     "hook-chain",
+			Event:      c,
+		}
+		if err := enc.Encode(ev); err != nil {
+			return fmt.Errorf("sink: splunk encode chain %d: %w", c.ID, err)
+		}
+	}
+
+	_, err := s.post(ctx, "application/json", body.Bytes())
+	return err
+}
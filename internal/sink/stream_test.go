@@ -0,0 +1,300 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+	"github.com/Fuabioo/hook-chain/internal/auditpb"
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+// natsMessage is one PUB captured by fakeNATS.
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+// fakeNATS accepts one connection, records CONNECT and PUBs, and answers
+// PING with reply (PONG or -ERR ...).
+func fakeNATS(t *testing.T, reply string) (addr string, connect <-chan string, msgs <-chan []natsMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	connectCh := make(chan string, 1)
+	msgsCh := make(chan []natsMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		r := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576}` + "\r\n"))
+
+		var got []natsMessage
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connectCh <- strings.TrimPrefix(line, "CONNECT ")
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				n, _ := strconv.Atoi(fields[len(fields)-1])
+				buf := make([]byte, n+2)
+				if _, err := io.ReadFull(r, buf); err != nil {
+					return
+				}
+				got = append(got, natsMessage{subject: fields[1], payload: buf[:n]})
+			case line == "PING":
+				msgsCh <- got
+				_, _ = conn.Write([]byte(reply + "\r\n"))
+				return
+			}
+		}
+	}()
+	return ln.Addr().String(), connectCh, msgsCh
+}
+
+func TestNATSSend(t *testing.T) {
+	addr, connect, msgs := fakeNATS(t, "PONG")
+
+	s, err := New(config.SinkConfig{Name: "nats", Type: TypeNATS, URL: "nats://alice:secret@" + addr, Topic: "audit.chains"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(3)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var c natsConnect
+	if err := json.Unmarshal([]byte(<-connect), &c); err != nil {
+		t.Fatalf("decode CONNECT: %v", err)
+	}
+	if c.User != "alice" || c.Pass != "secret" {
+		t.Errorf("CONNECT user/pass = %q/%q, want alice/secret", c.User, c.Pass)
+	}
+
+	got := <-msgs
+	if len(got) != 3 {
+		t.Fatalf("published %d messages, want 3", len(got))
+	}
+	var first audit.ChainExecution
+	if err := json.Unmarshal(got[0].payload, &first); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if got[0].subject != "audit.chains" || first.ID != 1 {
+		t.Errorf("first message = %s %+v, want subject audit.chains and ID 1", got[0].subject, first)
+	}
+}
+
+func TestNATSServerError(t *testing.T) {
+	addr, _, _ := fakeNATS(t, "-ERR 'Authorization Violation'")
+
+	s, err := New(config.SinkConfig{Name: "nats", Type: TypeNATS, URL: "nats://" + addr, Topic: "audit"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	err = s.Send(context.Background(), sampleChains(1))
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Fatalf("Send err = %v, want server error", err)
+	}
+}
+
+func TestKafkaRESTSend(t *testing.T) {
+	var gotPath, gotType string
+	var gotBody kafkaRecords
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2}]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "kafka", Type: TypeKafkaREST, URL: srv.URL + "/", Topic: "hook-chain-audit"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(2)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotPath != "/topics/hook-chain-audit" {
+		t.Errorf("path = %q, want /topics/hook-chain-audit", gotPath)
+	}
+	if gotType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("Content-Type = %q", gotType)
+	}
+	if len(gotBody.Records) != 2 || gotBody.Records[1].Key != "2" {
+		t.Errorf("records = %+v, want 2 records keyed by chain ID", gotBody.Records)
+	}
+}
+
+func TestKafkaRESTRecordError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"offsets":[{"error_code":40403,"error":"topic not found"}]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "kafka", Type: TypeKafkaREST, URL: srv.URL, Topic: "missing"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(1)); err == nil {
+		t.Fatal("expected error for failed record, got nil")
+	}
+}
+
+func TestStreamSinkValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.SinkConfig
+	}{
+		{"nats missing subject", config.SinkConfig{Name: "n", Type: TypeNATS, URL: "nats://localhost"}},
+		{"kafka missing topic", config.SinkConfig{Name: "k", Type: TypeKafkaREST, URL: "http://x"}},
+		{"unknown encoding", config.SinkConfig{Name: "k", Type: TypeKafkaREST, URL: "http://x", Topic: "t", Encoding: "avro"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New() err = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFlushOutbox(t *testing.T) {
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+	a.SetOutboxSinks("kafka")
+
+	for _, c := range sampleChains(3) {
+		c.ID = 0
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	var fail atomic.Bool
+	var records atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var body kafkaRecords
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		records.Add(int32(len(body.Records)))
+		_, _ = w.Write([]byte(`{"offsets":[]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "kafka", Type: TypeKafkaREST, URL: srv.URL, Topic: "t", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// First attempt fails: everything stays queued with the error recorded.
+	fail.Store(true)
+	if _, err := FlushOutbox(context.Background(), a.DB(), "kafka", s); err == nil {
+		t.Fatal("expected flush error, got nil")
+	}
+	stats, err := audit.OutboxStats(a.DB())
+	if err != nil {
+		t.Fatalf("OutboxStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Pending != 3 || stats[0].MaxAttempts != 1 {
+		t.Fatalf("stats after failure = %+v, want 3 pending with 1 attempt", stats)
+	}
+
+	fail.Store(false)
+	sent, err := FlushOutbox(context.Background(), a.DB(), "kafka", s)
+	if err != nil {
+		t.Fatalf("FlushOutbox: %v", err)
+	}
+	if sent != 3 || records.Load() != 3 {
+		t.Errorf("sent = %d records = %d, want 3", sent, records.Load())
+	}
+	stats, err = audit.OutboxStats(a.DB())
+	if err != nil {
+		t.Fatalf("OutboxStats: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("stats after flush = %+v, want empty", stats)
+	}
+}
+
+// binaryRecords is the Kafka REST binary embedded format as seen by the proxy.
+type binaryRecords struct {
+	Records []binaryRecord `json:"records"`
+}
+
+type binaryRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func TestKafkaRESTProtobufEncoding(t *testing.T) {
+	var gotType string
+	var gotBody binaryRecords
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"offsets":[]}`))
+	}))
+	defer srv.Close()
+
+	s, err := New(config.SinkConfig{Name: "kafka", Type: TypeKafkaREST, URL: srv.URL, Topic: "t", Encoding: EncodingProtobuf})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Send(context.Background(), sampleChains(1)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotType != "application/vnd.kafka.binary.v2+json" {
+		t.Errorf("Content-Type = %q, want binary embedded format", gotType)
+	}
+	if len(gotBody.Records) != 1 {
+		t.Fatalf("records = %d, want 1", len(gotBody.Records))
+	}
+	raw, err := base64.StdEncoding.DecodeString(gotBody.Records[0].Value)
+	if err != nil {
+		t.Fatalf("decode base64 value: %v", err)
+	}
+	c, err := auditpb.Unmarshal(raw)
+	if err != nil {
+		t.Fatalf("auditpb.Unmarshal: %v", err)
+	}
+	if c.ID != 1 || c.ToolName != "Bash" {
+		t.Errorf("decoded chain = %+v, want ID 1 tool Bash", c)
+	}
+}
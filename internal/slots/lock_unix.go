@@ -0,0 +1,23 @@
+//go:build unix
+
+package slots
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// tryLock takes a non-blocking exclusive flock on f, returning errBusy if
+// another process holds it.
+func tryLock(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errBusy
+	}
+	if err != nil {
+		return fmt.Errorf("slots: lock %s: %w", f.Name(), err)
+	}
+	return nil
+}
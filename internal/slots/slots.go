@@ -0,0 +1,104 @@
+// Package slots limits how many hook-chain pipelines run at once on a
+// machine. Each of n lock files is a slot; a running pipeline holds an
+// exclusive lock on one. Locks are released by the OS if the process dies,
+// so a crashed invocation never leaks a slot.
+package slots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrTimeout is returned when no slot frees up before the timeout.
+var ErrTimeout = errors.New("slots: timed out waiting for a free slot")
+
+// errBusy is returned by tryLock when another process holds the lock.
+var errBusy = errors.New("slots: slot busy")
+
+const (
+	minPoll = 5 * time.Millisecond
+	maxPoll = 100 * time.Millisecond
+)
+
+// Slot is a held slot. Release it when the pipeline finishes.
+type Slot struct {
+	f *os.File
+}
+
+// DefaultDir returns the lock directory: $HOOK_CHAIN_LOCK_DIR, or
+// hook-chain/slots under $XDG_RUNTIME_DIR or the temp directory.
+func DefaultDir() string {
+	if d := os.Getenv("HOOK_CHAIN_LOCK_DIR"); d != "" {
+		return d
+	}
+	if d := os.Getenv("XDG_RUNTIME_DIR"); d != "" {
+		return filepath.Join(d, "hook-chain", "slots")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("hook-chain-%d", os.Getuid()), "slots")
+}
+
+// Acquire takes one of n slots in dir, waiting up to timeout for one to
+// free up. It returns ErrTimeout if none does, or ctx's error if ctx ends
+// first. n <= 0 means no limit and returns a nil *Slot.
+func Acquire(ctx context.Context, dir string, n int, timeout time.Duration) (*Slot, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("slots: create %s: %w", dir, err)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	start := os.Getpid() % n
+	poll := minPoll
+	for {
+		for k := range n {
+			s, err := tryAcquire(filepath.Join(dir, fmt.Sprintf("slot-%d.lock", (start+k)%n)))
+			if err == nil {
+				return s, nil
+			}
+			if !errors.Is(err, errBusy) {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, ErrTimeout
+		case <-time.After(poll):
+		}
+		poll = min(poll*2, maxPoll)
+	}
+}
+
+func tryAcquire(path string) (*Slot, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("slots: open %s: %w", path, err)
+	}
+	if err := tryLock(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &Slot{f: f}, nil
+}
+
+// Release frees the slot. Nil receiver is a no-op.
+func (s *Slot) Release() error {
+	if s == nil {
+		return nil
+	}
+	// Closing the file drops the lock.
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("slots: release: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package slots
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireLimit(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	a, err := Acquire(ctx, dir, 2, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire #1: %v", err)
+	}
+	b, err := Acquire(ctx, dir, 2, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire #2: %v", err)
+	}
+
+	if _, err := Acquire(ctx, dir, 2, 30*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Acquire #3 err = %v, want ErrTimeout", err)
+	}
+
+	// A slot released while waiting is picked up.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = a.Release()
+	}()
+	c, err := Acquire(ctx, dir, 2, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+
+	for _, s := range []*Slot{b, c} {
+		if err := s.Release(); err != nil {
+			t.Errorf("Release: %v", err)
+		}
+	}
+}
+
+func TestAcquireUnlimited(t *testing.T) {
+	s, err := Acquire(context.Background(), t.TempDir(), 0, time.Second)
+	if err != nil || s != nil {
+		t.Fatalf("Acquire(n=0) = %v, %v; want nil, nil", s, err)
+	}
+	if err := s.Release(); err != nil {
+		t.Errorf("nil Release: %v", err)
+	}
+}
+
+func TestAcquireContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	held, err := Acquire(context.Background(), dir, 1, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer func() { _ = held.Release() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Acquire(ctx, dir, 1, time.Second); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire err = %v, want context.Canceled", err)
+	}
+}
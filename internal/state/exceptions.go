@@ -0,0 +1,91 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exception waives denials from one hook for tool calls whose target
+// matches a glob pattern, until it expires.
+type Exception struct {
+	ID        string    `json:"id"`
+	Hook      string    `json:"hook"`
+	Pattern   string    `json:"pattern"` // filepath.Match glob against file paths or Bash commands
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Until     time.Time `json:"until"`
+}
+
+// Active reports whether the exception is still in effect at now.
+func (e Exception) Active(now time.Time) bool {
+	return now.Before(e.Until)
+}
+
+// Matches reports whether target is covered by the exception's pattern.
+// Relative patterns are also tried against target relative to cwd, and
+// patterns without a slash against the target's base name.
+func (e Exception) Matches(target, cwd string) bool {
+	candidates := []string{target}
+	if cwd != "" && filepath.IsAbs(target) {
+		if rel, err := filepath.Rel(cwd, target); err == nil && !strings.HasPrefix(rel, "..") {
+			candidates = append(candidates, rel)
+		}
+	}
+	if !strings.Contains(e.Pattern, "/") {
+		candidates = append(candidates, filepath.Base(target))
+	}
+	for _, c := range candidates {
+		if ok, err := filepath.Match(e.Pattern, c); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AddException stores e under the next free ID ("ex-1", "ex-2", ...) and
+// returns it with the ID set.
+func (s *State) AddException(e Exception) Exception {
+	next := 1
+	for _, x := range s.Exceptions {
+		if n, err := strconv.Atoi(strings.TrimPrefix(x.ID, "ex-")); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	e.ID = fmt.Sprintf("ex-%d", next)
+	s.Exceptions = append(s.Exceptions, e)
+	return e
+}
+
+// RemoveException deletes the exception with id. It reports whether one existed.
+func (s *State) RemoveException(id string) bool {
+	found := false
+	kept := s.Exceptions[:0]
+	for _, e := range s.Exceptions {
+		if e.ID == id {
+			found = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.Exceptions = kept
+	return found
+}
+
+// MatchException returns the first active exception for hook that covers
+// any of targets.
+func (s State) MatchException(hook string, targets []string, cwd string, now time.Time) (Exception, bool) {
+	for _, e := range s.Exceptions {
+		if e.Hook != hook || !e.Active(now) {
+			continue
+		}
+		for _, t := range targets {
+			if e.Matches(t, cwd) {
+				return e, true
+			}
+		}
+	}
+	return Exception{}, false
+}
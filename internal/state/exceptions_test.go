@@ -0,0 +1,73 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExceptionMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		cwd     string
+		want    bool
+	}{
+		{"relative glob", "docs/*.md", "docs/guide.md", "", true},
+		{"absolute target under cwd", "docs/*.md", "/repo/docs/guide.md", "/repo", true},
+		{"absolute target outside cwd", "docs/*.md", "/other/docs/guide.md", "/repo", false},
+		{"base name glob", "*.md", "/repo/docs/deep/guide.md", "/repo", true},
+		{"no match", "docs/*.md", "src/main.go", "", false},
+		{"bash command", "git push *", "git push origin main", "", true},
+		{"bad pattern", "[", "docs/x.md", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Exception{Pattern: tt.pattern}
+			if got := e.Matches(tt.target, tt.cwd); got != tt.want {
+				t.Errorf("Matches(%q) with pattern %q = %v, want %v", tt.target, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExceptionLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var st State
+	a := st.AddException(Exception{Hook: "secret-scan", Pattern: "docs/*.md", Reason: "JIRA-123", CreatedAt: now, Until: now.Add(time.Hour)})
+	b := st.AddException(Exception{Hook: "secret-scan", Pattern: "*.txt", CreatedAt: now, Until: now.Add(-time.Minute)})
+	if a.ID != "ex-1" || b.ID != "ex-2" {
+		t.Fatalf("IDs = %q, %q; want ex-1, ex-2", a.ID, b.ID)
+	}
+
+	if err := Save(path, st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if got, ok := st.MatchException("secret-scan", []string{"docs/a.md"}, "", now); !ok || got.ID != "ex-1" {
+		t.Errorf("MatchException(docs/a.md) = %+v, %v; want ex-1", got, ok)
+	}
+	if _, ok := st.MatchException("other-hook", []string{"docs/a.md"}, "", now); ok {
+		t.Error("MatchException matched a different hook")
+	}
+	if _, ok := st.MatchException("secret-scan", []string{"notes.txt"}, "", now); ok {
+		t.Error("MatchException matched an expired exception")
+	}
+
+	if !st.PruneExpired(now) || len(st.Exceptions) != 1 {
+		t.Errorf("PruneExpired left %d exceptions, want 1", len(st.Exceptions))
+	}
+	if st.AddException(Exception{Hook: "x"}).ID != "ex-2" {
+		t.Error("next ID after prune should be ex-2")
+	}
+	if !st.RemoveException("ex-1") || st.RemoveException("ex-1") {
+		t.Error("RemoveException(ex-1) should succeed once")
+	}
+}
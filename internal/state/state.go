@@ -0,0 +1,139 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DisabledHook records a CLI-issued disable for a single hook name.
+type DisabledHook struct {
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Until     time.Time `json:"until,omitzero"` // zero = until re-enabled
+}
+
+// Active reports whether the disable entry is still in effect at now.
+func (d DisabledHook) Active(now time.Time) bool {
+	return d.Until.IsZero() || now.Before(d.Until)
+}
+
+// State is the persisted local runtime state of hook-chain.
+type State struct {
+	Disabled   []DisabledHook `json:"disabled,omitempty"`
+	Exceptions []Exception    `json:"exceptions,omitempty"`
+}
+
+// DefaultPath returns the default state file path.
+// It checks $HOOK_CHAIN_STATE, then $XDG_DATA_HOME/hook-chain/state.json,
+// then falls back to ~/.local/share/hook-chain/state.json.
+func DefaultPath() string {
+	if p := os.Getenv("HOOK_CHAIN_STATE"); p != "" {
+		return p
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "state.json")
+}
+
+// Load reads the state file at path. A missing file yields an empty State.
+func Load(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return State{}, nil
+		}
+		return State{}, fmt.Errorf("state: read %s: %w", path, err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return State{}, fmt.Errorf("state: parse %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Save writes the state file atomically (temp file + rename).
+func Save(path string, st State) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("state: create directory %q: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("state: marshal: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("state: write %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("state: rename %s: %w", tmpPath, err)
+	}
+	return nil
+}
+
+// Disable adds (or replaces) a disable entry for name.
+func (s *State) Disable(d DisabledHook) {
+	s.Enable(d.Name)
+	s.Disabled = append(s.Disabled, d)
+}
+
+// Enable removes any disable entry for name. It reports whether one existed.
+func (s *State) Enable(name string) bool {
+	found := false
+	kept := s.Disabled[:0]
+	for _, d := range s.Disabled {
+		if d.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, d)
+	}
+	s.Disabled = kept
+	return found
+}
+
+// DisabledEntry returns the active disable entry for name, if any.
+func (s State) DisabledEntry(name string, now time.Time) (DisabledHook, bool) {
+	for _, d := range s.Disabled {
+		if d.Name == name && d.Active(now) {
+			return d, true
+		}
+	}
+	return DisabledHook{}, false
+}
+
+// PruneExpired drops disable entries and exceptions whose expiry has passed.
+// It reports whether any entry was removed.
+func (s *State) PruneExpired(now time.Time) bool {
+	kept := s.Disabled[:0]
+	for _, d := range s.Disabled {
+		if d.Active(now) {
+			kept = append(kept, d)
+		}
+	}
+	keptExceptions := s.Exceptions[:0]
+	for _, e := range s.Exceptions {
+		if e.Active(now) {
+			keptExceptions = append(keptExceptions, e)
+		}
+	}
+	removed := len(kept) != len(s.Disabled) || len(keptExceptions) != len(s.Exceptions)
+	s.Disabled = kept
+	s.Exceptions = keptExceptions
+	return removed
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	st, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(st.Disabled) != 0 {
+		t.Errorf("len(Disabled) = %d, want 0", len(st.Disabled))
+	}
+}
+
+func TestLoadInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for invalid JSON, got nil")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var st State
+	st.Disable(DisabledHook{Name: "guard", Reason: "flaky", CreatedAt: now, Until: now.Add(2 * time.Hour)})
+	st.Disable(DisabledHook{Name: "logger", CreatedAt: now})
+
+	if err := Save(path, st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Disabled) != 2 {
+		t.Fatalf("len(Disabled) = %d, want 2", len(got.Disabled))
+	}
+	if got.Disabled[0].Reason != "flaky" {
+		t.Errorf("Disabled[0].Reason = %q, want %q", got.Disabled[0].Reason, "flaky")
+	}
+	if !got.Disabled[1].Until.IsZero() {
+		t.Errorf("Disabled[1].Until = %v, want zero", got.Disabled[1].Until)
+	}
+}
+
+func TestDisableReplacesExisting(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var st State
+	st.Disable(DisabledHook{Name: "guard", Reason: "first", CreatedAt: now})
+	st.Disable(DisabledHook{Name: "guard", Reason: "second", CreatedAt: now})
+
+	if len(st.Disabled) != 1 {
+		t.Fatalf("len(Disabled) = %d, want 1", len(st.Disabled))
+	}
+	if st.Disabled[0].Reason != "second" {
+		t.Errorf("Reason = %q, want %q", st.Disabled[0].Reason, "second")
+	}
+}
+
+func TestDisabledEntryExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var st State
+	st.Disable(DisabledHook{Name: "timed", CreatedAt: now, Until: now.Add(time.Hour)})
+	st.Disable(DisabledHook{Name: "forever", CreatedAt: now})
+
+	tests := []struct {
+		name string
+		hook string
+		at   time.Time
+		want bool
+	}{
+		{"timed before expiry", "timed", now.Add(30 * time.Minute), true},
+		{"timed at expiry", "timed", now.Add(time.Hour), false},
+		{"timed after expiry", "timed", now.Add(2 * time.Hour), false},
+		{"forever far future", "forever", now.Add(24 * 365 * time.Hour), true},
+		{"unknown hook", "other", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := st.DisabledEntry(tt.hook, tt.at)
+			if got != tt.want {
+				t.Errorf("DisabledEntry(%q) = %v, want %v", tt.hook, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnableAndPruneExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var st State
+	st.Disable(DisabledHook{Name: "a", CreatedAt: now, Until: now.Add(-time.Minute)})
+	st.Disable(DisabledHook{Name: "b", CreatedAt: now})
+
+	if !st.PruneExpired(now) {
+		t.Error("PruneExpired = false, want true")
+	}
+	if len(st.Disabled) != 1 || st.Disabled[0].Name != "b" {
+		t.Fatalf("Disabled = %+v, want only b", st.Disabled)
+	}
+
+	if !st.Enable("b") {
+		t.Error("Enable(b) = false, want true")
+	}
+	if st.Enable("b") {
+		t.Error("second Enable(b) = true, want false")
+	}
+	if len(st.Disabled) != 0 {
+		t.Errorf("len(Disabled) = %d, want 0", len(st.Disabled))
+	}
+}
@@ -0,0 +1,180 @@
+// Package telemetry implements hook-chain's opt-in usage metrics: coarse,
+// anonymous counters (chain executions by outcome, hook-chain version, OS
+// and architecture) posted at most once a day to the endpoint configured
+// under `telemetry:`. Nothing is collected or sent until the user runs
+// `hook-chain telemetry enable`. Reports carry no hook names, commands,
+// paths, tool inputs, or session IDs; the only identifier is a random ID
+// created on enable and discarded on disable.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+// Interval is the minimum time between two reports.
+const Interval = 24 * time.Hour
+
+// Settings is the persisted opt-in state.
+type Settings struct {
+	Enabled     bool      `json:"enabled"`
+	ID          string    `json:"id,omitempty"` // random; reset by each enable
+	EnabledAt   time.Time `json:"enabled_at,omitzero"`
+	LastSent    time.Time `json:"last_sent,omitzero"`    // end of the last delivered report's window
+	LastAttempt time.Time `json:"last_attempt,omitzero"` // throttles automatic sends
+}
+
+// Report is what is sent.
+type Report struct {
+	ID      string           `json:"id"`
+	Version string           `json:"version"`
+	OS      string           `json:"os"`
+	Arch    string           `json:"arch"`
+	Since   time.Time        `json:"since"`
+	Until   time.Time        `json:"until"`
+	Chains  map[string]int64 `json:"chains"` // chain executions by outcome
+}
+
+// DefaultPath returns the settings file path.
+// It checks $XDG_DATA_HOME/hook-chain/telemetry.json, then falls back to
+// ~/.local/share/hook-chain/telemetry.json.
+func DefaultPath() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "hook-chain", "telemetry.json")
+}
+
+// Blocked returns the environment variable that forbids telemetry
+// regardless of the settings (DO_NOT_TRACK=1 or HOOK_CHAIN_TELEMETRY=0),
+// or "" when none does.
+func Blocked() string {
+	switch {
+	case os.Getenv("DO_NOT_TRACK") == "1":
+		return "DO_NOT_TRACK=1"
+	case os.Getenv("HOOK_CHAIN_TELEMETRY") == "0":
+		return "HOOK_CHAIN_TELEMETRY=0"
+	}
+	return ""
+}
+
+// Load reads the settings file at path. A missing file means disabled.
+func Load(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Settings{}, nil
+		}
+		return Settings{}, fmt.Errorf("telemetry: read %s: %w", path, err)
+	}
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Settings{}, fmt.Errorf("telemetry: parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the settings file atomically (temp file + rename).
+func Save(path string, s Settings) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("telemetry: create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("telemetry: marshal: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("telemetry: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("telemetry: rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// Enable returns opted-in settings with a fresh random ID.
+func Enable(now time.Time) (Settings, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Settings{}, fmt.Errorf("telemetry: generate id: %w", err)
+	}
+	return Settings{Enabled: true, ID: hex.EncodeToString(b), EnabledAt: now.UTC()}, nil
+}
+
+// Due reports whether an automatic report should be sent at now: a day
+// after enabling, the last report, or the last attempt.
+func (s Settings) Due(now time.Time) bool {
+	last := max(s.EnabledAt.Unix(), s.LastSent.Unix(), s.LastAttempt.Unix())
+	return s.Enabled && now.Sub(time.Unix(last, 0)) >= Interval
+}
+
+// Build collects the report for the window since the last delivered one
+// (or since enabling). db may be nil when auditing is disabled; the report
+// then has no chain counts.
+func Build(db *sql.DB, s Settings, version string, now time.Time) (Report, error) {
+	since := s.LastSent
+	if since.IsZero() {
+		since = s.EnabledAt
+	}
+	r := Report{
+		ID:      s.ID,
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Since:   since.UTC(),
+		Until:   now.UTC(),
+		Chains:  map[string]int64{},
+	}
+	if db != nil {
+		counts, err := audit.OutcomesSince(db, since)
+		if err != nil {
+			return Report{}, fmt.Errorf("telemetry: count chains: %w", err)
+		}
+		r.Chains = counts
+	}
+	return r, nil
+}
+
+// Send posts the report as JSON to endpoint.
+func Send(ctx context.Context, client *http.Client, endpoint string, r Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("telemetry: marshal report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telemetry: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telemetry: post report: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("telemetry: post report: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fuabioo/hook-chain/internal/audit"
+)
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook-chain", "telemetry.json")
+	s, err := Load(path)
+	if err != nil || s.Enabled {
+		t.Fatalf("Load(missing) = %+v, %v; want disabled", s, err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, err = Enable(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled || len(s.ID) != 32 || !s.EnabledAt.Equal(now) {
+		t.Errorf("Enable = %+v", s)
+	}
+	if other, _ := Enable(now); other.ID == s.ID {
+		t.Error("Enable reused the ID")
+	}
+	if err := Save(path, s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != s.ID || !got.EnabledAt.Equal(now) || !got.LastSent.IsZero() {
+		t.Errorf("Load = %+v, want %+v", got, s)
+	}
+}
+
+func TestDue(t *testing.T) {
+	enabled := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		s    Settings
+		now  time.Time
+		want bool
+	}{
+		{"disabled", Settings{EnabledAt: enabled}, enabled.Add(48 * time.Hour), false},
+		{"just enabled", Settings{Enabled: true, EnabledAt: enabled}, enabled.Add(time.Hour), false},
+		{"a day after enabling", Settings{Enabled: true, EnabledAt: enabled}, enabled.Add(Interval), true},
+		{"sent recently", Settings{Enabled: true, EnabledAt: enabled, LastSent: enabled.Add(40 * time.Hour)}, enabled.Add(48 * time.Hour), false},
+		{"attempted recently", Settings{Enabled: true, EnabledAt: enabled, LastAttempt: enabled.Add(40 * time.Hour)}, enabled.Add(48 * time.Hour), false},
+		{"sent a day ago", Settings{Enabled: true, EnabledAt: enabled, LastSent: enabled.Add(24 * time.Hour)}, enabled.Add(48 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Due(tt.now); got != tt.want {
+				t.Errorf("Due = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	t.Setenv("HOOK_CHAIN_TELEMETRY", "")
+	if got := Blocked(); got != "" {
+		t.Errorf("Blocked = %q, want none", got)
+	}
+	t.Setenv("HOOK_CHAIN_TELEMETRY", "0")
+	if got := Blocked(); got != "HOOK_CHAIN_TELEMETRY=0" {
+		t.Errorf("Blocked = %q", got)
+	}
+	t.Setenv("DO_NOT_TRACK", "1")
+	if got := Blocked(); got != "DO_NOT_TRACK=1" {
+		t.Errorf("Blocked = %q", got)
+	}
+}
+
+func TestBuildAndSend(t *testing.T) {
+	a, err := audit.Open(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	now := time.Now().UTC()
+	for _, c := range []audit.ChainExecution{
+		{Timestamp: now.Add(-time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeDeny, SessionID: "s1"},
+		{Timestamp: now.Add(-2 * time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeAllow, SessionID: "s1"},
+		{Timestamp: now.Add(-3 * time.Hour), EventName: "PreToolUse", ToolName: "Write", Outcome: audit.OutcomeAllow, SessionID: "s2"},
+		{Timestamp: now.Add(-48 * time.Hour), EventName: "PreToolUse", ToolName: "Bash", Outcome: audit.OutcomeDeny, SessionID: "old"},
+	} {
+		if err := a.RecordChain(c); err != nil {
+			t.Fatalf("RecordChain: %v", err)
+		}
+	}
+
+	s := Settings{Enabled: true, ID: "abc", EnabledAt: now.Add(-72 * time.Hour), LastSent: now.Add(-24 * time.Hour)}
+	r, err := Build(a.DB(), s, "v1.2.3", now)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if r.ID != "abc" || r.Version != "v1.2.3" || !r.Since.Equal(s.LastSent) || r.Chains[audit.OutcomeAllow] != 2 || r.Chains[audit.OutcomeDeny] != 1 {
+		t.Errorf("Build = %+v", r)
+	}
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	if err := Send(context.Background(), srv.Client(), srv.URL+"/ok", r); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	want := []string{"arch", "chains", "id", "os", "since", "until", "version"}
+	if len(got) != len(want) {
+		t.Errorf("payload keys = %v, want only %v", got, want)
+	}
+	for _, k := range want {
+		if _, ok := got[k]; !ok {
+			t.Errorf("payload lacks %q", k)
+		}
+	}
+	if err := Send(context.Background(), srv.Client(), srv.URL+"/fail", r); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Send(/fail) err = %v, want 403", err)
+	}
+}
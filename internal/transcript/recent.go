@@ -0,0 +1,212 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Activity kinds returned by Recent.
+const (
+	ActivityUser       = "user"        // a user prompt
+	ActivityAssistant  = "assistant"   // assistant text
+	ActivityToolUse    = "tool_use"    // a tool call made by the assistant
+	ActivityToolResult = "tool_result" // the result of a tool call
+)
+
+// maxTailBytes bounds how much of the transcript Recent reads.
+const maxTailBytes = 1 << 20
+
+// maxActivityText bounds the text kept per activity.
+const maxActivityText = 200
+
+// Activity is one summarized transcript entry.
+type Activity struct {
+	Kind      string    `json:"kind"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	Tool      string    `json:"tool,omitempty"`
+	Detail    string    `json:"detail,omitempty"` // command or path for tool calls, truncated text otherwise
+	IsError   bool      `json:"is_error,omitempty"`
+}
+
+// transcriptLine is the subset of a Claude Code transcript line Recent reads.
+type transcriptLine struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// contentBlock is one element of a message's content array.
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Name      string          `json:"name"`
+	ID        string          `json:"id"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// Recent returns up to n of the most recent activities in the transcript at
+// path, oldest first. Only the last 1 MiB of the file is read; lines that are
+// not user or assistant messages, or do not parse, are skipped.
+func Recent(path string, n int) ([]Activity, error) {
+	if path == "" || n <= 0 {
+		return nil, nil
+	}
+
+	tail, err := readTail(path, maxTailBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Activity
+	toolNames := map[string]string{}
+	for line := range bytes.SplitSeq(tail, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var tl transcriptLine
+		if err := json.Unmarshal(line, &tl); err != nil {
+			continue
+		}
+		if tl.Type != ActivityUser && tl.Type != ActivityAssistant {
+			continue
+		}
+		all = append(all, summarize(tl, toolNames)...)
+	}
+
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return all, nil
+}
+
+// readTail returns up to limit bytes from the end of the file, starting at
+// a line boundary.
+func readTail(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("transcript: open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("transcript: stat %s: %w", path, err)
+	}
+	offset := max(info.Size()-limit, 0)
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("transcript: read %s: %w", path, err)
+	}
+	if offset > 0 {
+		// Drop the partial first line.
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			buf = buf[i+1:]
+		} else {
+			buf = nil
+		}
+	}
+	return buf, nil
+}
+
+// summarize flattens one message into activities. toolNames maps tool use
+// IDs to tool names so results can be attributed to their call.
+func summarize(tl transcriptLine, toolNames map[string]string) []Activity {
+	content := bytes.TrimSpace(tl.Message.Content)
+	if len(content) == 0 {
+		return nil
+	}
+
+	// User prompts may be a bare string.
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		if text = truncate(text); text == "" {
+			return nil
+		}
+		return []Activity{{Kind: tl.Type, Timestamp: tl.Timestamp, Detail: text}}
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return nil
+	}
+	var out []Activity
+	for _, b := range blocks {
+		a := Activity{Timestamp: tl.Timestamp}
+		switch b.Type {
+		case "text":
+			a.Kind, a.Detail = tl.Type, truncate(b.Text)
+			if a.Detail == "" {
+				continue
+			}
+		case "tool_use":
+			toolNames[b.ID] = b.Name
+			a.Kind, a.Tool, a.Detail = ActivityToolUse, b.Name, truncate(toolDetail(b.Input))
+		case "tool_result":
+			a.Kind, a.Tool, a.IsError = ActivityToolResult, toolNames[b.ToolUseID], b.IsError
+			a.Detail = truncate(resultText(b.Content))
+		default:
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// detailFields are the tool input fields that identify what a call touched,
+// in order of preference.
+var detailFields = []string{"command", "file_path", "notebook_path", "path", "url", "pattern"}
+
+// toolDetail returns the most identifying field of a tool call's input.
+func toolDetail(input json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return ""
+	}
+	for _, name := range detailFields {
+		var s string
+		if raw, ok := fields[name]; ok && json.Unmarshal(raw, &s) == nil && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// resultText returns the text of a tool result, whose content is either a
+// string or an array of text blocks.
+func resultText(content json.RawMessage) string {
+	var s string
+	if json.Unmarshal(content, &s) == nil {
+		return s
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// truncate trims s and shortens it to maxActivityText runes.
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > maxActivityText {
+		return string(r[:maxActivityText-3]) + "..."
+	}
+	return s
+}
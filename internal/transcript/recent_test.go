@@ -0,0 +1,87 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleTranscript = `{"type":"summary","summary":"Cleanup session"}
+{"type":"user","timestamp":"2026-01-02T03:04:00Z","message":{"role":"user","content":"please clean up the build dir"}}
+{"type":"assistant","timestamp":"2026-01-02T03:04:01Z","message":{"role":"assistant","content":[{"type":"text","text":"Creating a scratch file first."},{"type":"tool_use","id":"toolu_1","name":"Write","input":{"file_path":"/tmp/scratch.txt","content":"x"}}]}}
+{"type":"user","timestamp":"2026-01-02T03:04:02Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"File created"}]}}
+not json
+{"type":"hook-chain-note","kind":"deny","text":"blocked"}
+{"type":"assistant","timestamp":"2026-01-02T03:04:03Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"rm /tmp/scratch.txt"}}]}}
+{"type":"user","timestamp":"2026-01-02T03:04:04Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"rm: permission denied"}],"is_error":true}]}}
+`
+
+func TestRecent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0o600); err != nil {
+		t.Fatalf("write transcript: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []Activity
+	}{
+		{"zero", 0, nil},
+		{"last two", 2, []Activity{
+			{Kind: ActivityToolUse, Tool: "Bash", Detail: "rm /tmp/scratch.txt"},
+			{Kind: ActivityToolResult, Tool: "Bash", Detail: "rm: permission denied", IsError: true},
+		}},
+		{"all", 100, []Activity{
+			{Kind: ActivityUser, Detail: "please clean up the build dir"},
+			{Kind: ActivityAssistant, Detail: "Creating a scratch file first."},
+			{Kind: ActivityToolUse, Tool: "Write", Detail: "/tmp/scratch.txt"},
+			{Kind: ActivityToolResult, Tool: "Write", Detail: "File created"},
+			{Kind: ActivityToolUse, Tool: "Bash", Detail: "rm /tmp/scratch.txt"},
+			{Kind: ActivityToolResult, Tool: "Bash", Detail: "rm: permission denied", IsError: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Recent(path, tt.n)
+			if err != nil {
+				t.Fatalf("Recent: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Recent = %+v, want %d activities", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Kind != w.Kind || g.Tool != w.Tool || g.Detail != w.Detail || g.IsError != w.IsError {
+					t.Errorf("activity %d = %+v, want %+v", i, g, w)
+				}
+				if g.Timestamp.IsZero() {
+					t.Errorf("activity %d timestamp is zero", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRecentTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	long := strings.Repeat("a", 500)
+	line := `{"type":"user","timestamp":"2026-01-02T03:04:00Z","message":{"content":"` + long + `"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatalf("write transcript: %v", err)
+	}
+	got, err := Recent(path, 1)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(got) != 1 || len([]rune(got[0].Detail)) != maxActivityText || !strings.HasSuffix(got[0].Detail, "...") {
+		t.Errorf("Recent = %+v, want one activity truncated to %d runes", got, maxActivityText)
+	}
+}
+
+func TestRecentMissingFile(t *testing.T) {
+	if _, err := Recent(filepath.Join(t.TempDir(), "missing.jsonl"), 5); err == nil {
+		t.Error("Recent(missing) err = nil, want error")
+	}
+}
@@ -0,0 +1,98 @@
+// Package transcript appends hook-chain notes next to a Claude Code session
+// transcript, so guardrail interventions show up when the session is
+// reviewed later.
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Note destinations accepted by the config's transcript.notes setting.
+const (
+	ModeOff        = "off"        // no notes (default)
+	ModeSidecar    = "sidecar"    // <transcript>.hook-chain.jsonl next to the transcript
+	ModeTranscript = "transcript" // append to the transcript file itself
+)
+
+// Note kinds.
+const (
+	KindDeny   = "deny"   // the chain denied the tool call
+	KindAsk    = "ask"    // the chain escalated to the user
+	KindError  = "error"  // a hook failed and the chain failed closed
+	KindWaived = "waived" // an exception downgraded a denial to a warning
+	KindHook   = "hook"   // free-form note supplied by a hook via transcriptNote
+)
+
+// NoteType marks hook-chain lines, so they can be told apart from Claude
+// Code's own entries when written into the transcript itself.
+const NoteType = "hook-chain-note"
+
+// Note is one JSON line appended for a chain run.
+type Note struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	SessionID string    `json:"session_id,omitempty"`
+	ToolUseID string    `json:"tool_use_id,omitempty"`
+	Event     string    `json:"hook_event_name,omitempty"`
+	Tool      string    `json:"tool_name,omitempty"`
+	Hook      string    `json:"hook,omitempty"`
+	Kind      string    `json:"kind"`
+	RuleID    string    `json:"rule_id,omitempty"`
+	Text      string    `json:"text"`
+}
+
+// NotesPath returns the file notes are appended to for mode and the
+// session's transcript path. It returns "" when notes are off or the session
+// has no transcript, and an error for an unknown mode.
+func NotesPath(mode, transcriptPath string) (string, error) {
+	switch mode {
+	case "", ModeOff:
+		return "", nil
+	case ModeSidecar:
+		if transcriptPath == "" {
+			return "", nil
+		}
+		return strings.TrimSuffix(transcriptPath, ".jsonl") + ".hook-chain.jsonl", nil
+	case ModeTranscript:
+		return transcriptPath, nil
+	default:
+		return "", fmt.Errorf("transcript: unknown notes mode %q (want %s, %s, or %s)", mode, ModeOff, ModeSidecar, ModeTranscript)
+	}
+}
+
+// Append writes notes to path as JSON lines in a single append, so
+// concurrent writers never interleave within a chain's notes.
+func Append(path string, notes []Note) error {
+	if path == "" || len(notes) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, n := range notes {
+		if n.Type == "" {
+			n.Type = NoteType
+		}
+		if err := enc.Encode(n); err != nil {
+			return fmt.Errorf("transcript: encode note: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("transcript: open %s: %w", path, err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("transcript: append to %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("transcript: close %s: %w", path, err)
+	}
+	return nil
+}
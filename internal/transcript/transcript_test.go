@@ -0,0 +1,78 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNotesPath(t *testing.T) {
+	tests := []struct {
+		mode       string
+		transcript string
+		want       string
+		wantErr    bool
+	}{
+		{"", "/s/abc.jsonl", "", false},
+		{ModeOff, "/s/abc.jsonl", "", false},
+		{ModeSidecar, "/s/abc.jsonl", "/s/abc.hook-chain.jsonl", false},
+		{ModeSidecar, "/s/abc", "/s/abc.hook-chain.jsonl", false},
+		{ModeSidecar, "", "", false},
+		{ModeTranscript, "/s/abc.jsonl", "/s/abc.jsonl", false},
+		{"inline", "/s/abc.jsonl", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NotesPath(tt.mode, tt.transcript)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NotesPath(%q, %q) err = %v, wantErr %v", tt.mode, tt.transcript, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NotesPath(%q, %q) = %q, want %q", tt.mode, tt.transcript, got, tt.want)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abc.hook-chain.jsonl")
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := Append(path, []Note{{Timestamp: ts, Kind: KindDeny, Hook: "guard", Text: "no"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Append(path, []Note{{Timestamp: ts, Kind: KindHook, Text: "a"}, {Timestamp: ts, Kind: KindWaived, Text: "b"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Append(path, nil); err != nil {
+		t.Fatalf("Append(nil): %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var kinds []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var n Note
+		if err := json.Unmarshal(sc.Bytes(), &n); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		if n.Type != NoteType {
+			t.Errorf("type = %q, want %q", n.Type, NoteType)
+		}
+		kinds = append(kinds, n.Kind)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if want := []string{KindDeny, KindHook, KindWaived}; !slices.Equal(kinds, want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+}
@@ -0,0 +1,414 @@
+// Package wizard composes hook-chain configs interactively for
+// `hook-chain config wizard`. It offers the builtins and the hook executables
+// found on PATH, builds chains per event and tool from the answers, and checks
+// the result before the caller writes it.
+package wizard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Fuabioo/hook-chain/internal/builtin"
+	"github.com/Fuabioo/hook-chain/internal/config"
+	"github.com/Fuabioo/hook-chain/internal/hookdir"
+	"github.com/Fuabioo/hook-chain/internal/pathutil"
+)
+
+// Events are the hook events offered for a new chain, most common first.
+var Events = []string{
+	"PreToolUse",
+	"PostToolUse",
+	"UserPromptSubmit",
+	"Stop",
+	"SubagentStop",
+	"SessionStart",
+	"SessionEnd",
+	"Notification",
+	"PreCompact",
+}
+
+// ErrInputClosed is returned when input ends before the wizard is done.
+var ErrInputClosed = errors.New("wizard: input ended before the config was complete")
+
+// Wizard asks questions on Out and reads answers line by line from In.
+type Wizard struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	// Builtins and Installed are offered by number when adding a hook.
+	Builtins  []string
+	Installed []string
+}
+
+// New returns a wizard reading from in and writing prompts to out, offering
+// every registered builtin.
+func New(in io.Reader, out io.Writer) *Wizard {
+	return &Wizard{
+		in:       bufio.NewScanner(in),
+		out:      out,
+		Builtins: builtin.Names(),
+	}
+}
+
+// Compose adds chains to cfg until the user declines another one.
+func (w *Wizard) Compose(cfg config.Config) (config.Config, error) {
+	if len(cfg.Chains) > 0 {
+		w.printf("Existing chains:\n")
+		for i, c := range cfg.Chains {
+			w.printf("  %d. %s\n", i+1, chainSummary(c))
+		}
+	}
+	w.printCatalog()
+
+	add := len(cfg.Chains) == 0
+	for {
+		prompt := "Add another chain?"
+		if len(cfg.Chains) == 0 {
+			prompt = "Add a chain?"
+		}
+		ok, err := w.Confirm(prompt, add)
+		if err != nil {
+			return cfg, err
+		}
+		if !ok {
+			return cfg, nil
+		}
+		chain, err := w.chain()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Chains = append(cfg.Chains, chain)
+		w.printf("Added chain: %s\n", chainSummary(chain))
+		add = false
+	}
+}
+
+// Confirm asks a yes/no question; an empty answer picks def.
+func (w *Wizard) Confirm(prompt string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	for {
+		answer, err := w.ask(prompt + " " + hint)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		w.printf("Please answer y or n.\n")
+	}
+}
+
+// chain asks for one chain's event, tools, and hooks.
+func (w *Wizard) chain() (config.ChainEntry, error) {
+	var c config.ChainEntry
+
+	w.printf("Events:\n")
+	for i, e := range Events {
+		w.printf("  %d) %s\n", i+1, e)
+	}
+	for c.Event == "" {
+		answer, err := w.askDefault("Event", Events[0])
+		if err != nil {
+			return c, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n < 1 || n > len(Events) {
+				w.printf("Pick a number from 1 to %d.\n", len(Events))
+				continue
+			}
+			answer = Events[n-1]
+		}
+		c.Event = answer
+	}
+
+	if slices.Contains(config.ToolEvents, c.Event) {
+		for len(c.Tools) == 0 {
+			answer, err := w.askDefault("Tools (comma-separated)", "Bash")
+			if err != nil {
+				return c, err
+			}
+			c.Tools = splitList(answer)
+		}
+	}
+
+	for {
+		h, done, err := w.hook(len(c.Hooks) == 0)
+		if err != nil {
+			return c, err
+		}
+		if done {
+			return c, nil
+		}
+		h.Name = uniqueName(h.Name, c.Hooks)
+		c.Hooks = append(c.Hooks, h)
+	}
+}
+
+// hook asks for the next hook of a chain. done reports that the user
+// finished the chain; first hooks cannot be skipped.
+func (w *Wizard) hook(first bool) (h config.HookEntry, done bool, err error) {
+	prompt := "Hook number or command (empty to finish)"
+	if first {
+		prompt = "Hook number or command"
+	}
+	for {
+		answer, err := w.ask(prompt)
+		if err != nil {
+			return h, false, err
+		}
+		if answer == "" {
+			if first {
+				w.printf("A chain needs at least one hook.\n")
+				continue
+			}
+			return h, true, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			switch {
+			case n >= 1 && n <= len(w.Builtins):
+				h = config.HookEntry{Name: w.Builtins[n-1], Builtin: w.Builtins[n-1]}
+			case n > len(w.Builtins) && n <= len(w.Builtins)+len(w.Installed):
+				h = config.HookEntry{Command: w.Installed[n-len(w.Builtins)-1]}
+			default:
+				w.printf("Pick a number from 1 to %d.\n", len(w.Builtins)+len(w.Installed))
+				continue
+			}
+		} else {
+			h = config.HookEntry{Command: answer}
+		}
+		break
+	}
+
+	if h.Builtin == "" {
+		fields := strings.Fields(h.Command)
+		h.Name = filepath.Base(fields[0])
+	}
+	if h.Name, err = w.askDefault("  Name", h.Name); err != nil {
+		return h, false, err
+	}
+	// The LLM reviewer has no default model; its other options do.
+	for h.Builtin == "llm-review" && h.Options == nil {
+		model, err := w.ask("  Model")
+		if err != nil {
+			return h, false, err
+		}
+		if model == "" {
+			w.printf("The LLM reviewer needs a model, such as gpt-4o-mini.\n")
+			continue
+		}
+		h.Options = map[string]any{"model": model}
+	}
+	for {
+		onError, err := w.askDefault("  On error (deny/skip/allow)", "deny")
+		if err != nil {
+			return h, false, err
+		}
+		switch onError {
+		case "deny":
+			// Default; left out of the YAML.
+		case "skip", "allow":
+			h.OnError = onError
+		default:
+			w.printf("Answer deny, skip, or allow.\n")
+			continue
+		}
+		return h, false, nil
+	}
+}
+
+// printCatalog lists the hooks that can be picked by number.
+func (w *Wizard) printCatalog() {
+	w.printf("Builtins:\n")
+	for i, name := range w.Builtins {
+		w.printf("  %d) %s\n", i+1, name)
+	}
+	if len(w.Installed) > 0 {
+		w.printf("Installed hooks:\n")
+		for i, path := range w.Installed {
+			w.printf("  %d) %s\n", len(w.Builtins)+i+1, path)
+		}
+	}
+	w.printf("Any other hook can be entered as a command.\n")
+}
+
+// ask prints prompt and returns the trimmed answer.
+func (w *Wizard) ask(prompt string) (string, error) {
+	w.printf("%s: ", prompt)
+	if !w.in.Scan() {
+		if err := w.in.Err(); err != nil {
+			return "", fmt.Errorf("wizard: read answer: %w", err)
+		}
+		return "", ErrInputClosed
+	}
+	return strings.TrimSpace(w.in.Text()), nil
+}
+
+// askDefault is ask with a default for empty answers.
+func (w *Wizard) askDefault(prompt, def string) (string, error) {
+	answer, err := w.ask(fmt.Sprintf("%s [%s]", prompt, def))
+	if answer == "" {
+		answer = def
+	}
+	return answer, err
+}
+
+func (w *Wizard) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(w.out, format, args...)
+}
+
+// Installed returns the hook executables in the directories of pathList
+// (a $PATH value): files whose name contains "hook", other than hook-chain
+// itself. The first match for each name wins, as in a shell lookup.
+func Installed(pathList string) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(pathList) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if seen[name] || name == "hook-chain" || !strings.Contains(name, "hook") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			seen[name] = true
+			found = append(found, filepath.Join(dir, name))
+		}
+	}
+	slices.SortFunc(found, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+	return found
+}
+
+// Check reports problems `hook-chain validate` would flag in cfg's chains:
+// missing tools or hooks, invalid builtins, severities, and resolution mode,
+// timeouts over the limit, malformed env entries, and commands that are not
+// on PATH.
+func Check(cfg config.Config) []error {
+	cfg, err := cfg.ExpandHookDefs()
+	if err != nil {
+		return []error{err}
+	}
+	errs := slices.Concat(cfg.ValidateTimeouts(), cfg.ValidateEnv())
+	if err := cfg.ValidateResolution(); err != nil {
+		errs = append(errs, err)
+	}
+	for i, c := range cfg.Chains {
+		prefix := fmt.Sprintf("chain %d (%s)", i+1, c.EventLabel())
+		toolEvent := slices.ContainsFunc(c.EventNames(), func(e string) bool { return slices.Contains(config.ToolEvents, e) })
+		if toolEvent && c.ToolLabel() == "" {
+			errs = append(errs, fmt.Errorf("%s: no tools", prefix))
+		}
+		if len(c.Hooks) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no hooks", prefix))
+		}
+		for _, err := range slices.Concat(c.ValidateEvents(), c.ValidateTools(), c.ValidateSeverity(), c.ValidateActions(), c.ValidateSchedule(), c.ValidateProtected(), c.ValidateQuotas(), c.ValidateRisk(), c.ValidateMaxDuration()) {
+			errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+		}
+		if err := c.Match.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+		}
+		for _, h := range slices.Concat(c.Hooks, c.Finally) {
+			for _, err := range slices.Concat(h.ValidateArgs(), h.ValidateExitCodes()) {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+			}
+			if err := h.ValidateOutput(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+			}
+			if err := h.ValidateWorkdir(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+			}
+			if err := h.ValidateType(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+				continue
+			}
+			switch typ := h.EffectiveType(); {
+			case !slices.Contains(config.HookTypes, typ):
+				errs = append(errs, fmt.Errorf("%s: hook %q: unknown type %q", prefix, h.Name, typ))
+				continue
+			case typ == config.HookTypeHTTP:
+				continue
+			}
+			if h.Builtin != "" {
+				if err := builtin.Validate(h); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+				}
+				continue
+			}
+			commands := []string{h.Command}
+			if len(h.Variants) > 0 {
+				commands = h.Variants
+			}
+			for _, cmd := range commands {
+				fields := pathutil.Fields(cmd)
+				if len(fields) == 0 {
+					errs = append(errs, fmt.Errorf("%s: hook %q: empty command", prefix, h.Name))
+				} else if _, err := hookdir.LookPath(fields[0]); err != nil {
+					errs = append(errs, fmt.Errorf("%s: hook %q: %s not found", prefix, h.Name, fields[0]))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// chainSummary describes a chain on one line.
+func chainSummary(c config.ChainEntry) string {
+	names := make([]string, len(c.Hooks))
+	for i, h := range c.Hooks {
+		names[i] = h.Name
+	}
+	s := c.EventLabel()
+	if tools := c.ToolLabel(); tools != "" {
+		s += " [" + tools + "]"
+	}
+	return s + ": " + strings.Join(names, " → ")
+}
+
+// splitList splits a comma-separated answer, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// uniqueName returns name, suffixed with a number if a hook in hooks already
+// uses it.
+func uniqueName(name string, hooks []config.HookEntry) string {
+	taken := func(n string) bool {
+		return slices.ContainsFunc(hooks, func(h config.HookEntry) bool { return h.Name == n })
+	}
+	if !taken(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if n := fmt.Sprintf("%s-%d", name, i); !taken(n) {
+			return n
+		}
+	}
+}
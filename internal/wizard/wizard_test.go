@@ -0,0 +1,188 @@
+package wizard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Fuabioo/hook-chain/internal/config"
+)
+
+func TestCompose(t *testing.T) {
+	answers := strings.Join([]string{
+		"",            // add a chain: yes
+		"1",           // event PreToolUse
+		"Bash, Write", // tools
+		"",            // first hook cannot be skipped
+		"1",           // builtin command-guard
+		"",            // name
+		"",            // on error: deny
+		"3",           // installed hook
+		"",            // name
+		"maybe",       // invalid on_error
+		"skip",        // on error
+		"3",           // installed hook again: name gets a suffix
+		"",            // name
+		"",            // on error
+		"",            // finish chain
+		"y",           // add another chain
+		"SessionEnd",  // event by name
+		"cleanup --all",
+		"tidy",
+		"",
+		"",   // finish chain
+		"no", // no more chains
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	w := New(strings.NewReader(answers), &out)
+	w.Builtins = []string{"command-guard", "write-guard"}
+	w.Installed = []string{"/usr/local/bin/lint-hook"}
+
+	cfg, err := w.Compose(config.Config{})
+	if err != nil {
+		t.Fatalf("Compose: %v\noutput:\n%s", err, out.String())
+	}
+	want := []config.ChainEntry{
+		{
+			Event: "PreToolUse",
+			Tools: []string{"Bash", "Write"},
+			Hooks: []config.HookEntry{
+				{Name: "command-guard", Builtin: "command-guard"},
+				{Name: "lint-hook", Command: "/usr/local/bin/lint-hook", OnError: "skip"},
+				{Name: "lint-hook-2", Command: "/usr/local/bin/lint-hook"},
+			},
+		},
+		{
+			Event: "SessionEnd",
+			Hooks: []config.HookEntry{{Name: "tidy", Command: "cleanup --all"}},
+		},
+	}
+	if len(cfg.Chains) != len(want) {
+		t.Fatalf("got %d chains, want %d", len(cfg.Chains), len(want))
+	}
+	for i := range want {
+		got := cfg.Chains[i]
+		if got.Event != want[i].Event || !slices.Equal(got.Tools, want[i].Tools) || !slices.EqualFunc(got.Hooks, want[i].Hooks, func(a, b config.HookEntry) bool {
+			return a.Name == b.Name && a.Command == b.Command && a.Builtin == b.Builtin && a.OnError == b.OnError
+		}) {
+			t.Errorf("chain %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	for _, msg := range []string{"A chain needs at least one hook.", "Answer deny, skip, or allow.", "3) /usr/local/bin/lint-hook"} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("output missing %q", msg)
+		}
+	}
+}
+
+func TestComposeLLMReview(t *testing.T) {
+	answers := strings.Join([]string{
+		"",  // add a chain
+		"1", // event PreToolUse
+		"Bash",
+		"1", // builtin llm-review
+		"",  // name
+		"",  // model is required
+		"gpt-4o-mini",
+		"",   // on error
+		"",   // finish chain
+		"no", // no more chains
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	w := New(strings.NewReader(answers), &out)
+	w.Builtins = []string{"llm-review"}
+	w.Installed = nil
+
+	cfg, err := w.Compose(config.Config{})
+	if err != nil {
+		t.Fatalf("Compose: %v\noutput:\n%s", err, out.String())
+	}
+	h := cfg.Chains[0].Hooks[0]
+	if h.Builtin != "llm-review" || h.Options["model"] != "gpt-4o-mini" {
+		t.Errorf("hook = %+v, want llm-review with model gpt-4o-mini", h)
+	}
+	if !strings.Contains(out.String(), "needs a model") {
+		t.Errorf("output missing the model prompt:\n%s", out.String())
+	}
+}
+
+func TestComposeInputClosed(t *testing.T) {
+	w := New(strings.NewReader("y\nPreToolUse\n"), &strings.Builder{})
+	_, err := w.Compose(config.Config{})
+	if !errors.Is(err, ErrInputClosed) {
+		t.Errorf("err = %v, want ErrInputClosed", err)
+	}
+}
+
+func TestComposeExisting(t *testing.T) {
+	existing := config.Config{Chains: []config.ChainEntry{{Event: "Stop", Hooks: []config.HookEntry{{Name: "a", Command: "a"}}}}}
+	var out strings.Builder
+	// An existing config defaults to adding nothing.
+	cfg, err := New(strings.NewReader("\n"), &out).Compose(existing)
+	if err != nil {
+		t.Fatalf("Compose: %v", err)
+	}
+	if len(cfg.Chains) != 1 {
+		t.Errorf("got %d chains, want 1", len(cfg.Chains))
+	}
+	if !strings.Contains(out.String(), "1. Stop: a") {
+		t.Errorf("output does not list the existing chain:\n%s", out.String())
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	for _, f := range []struct {
+		dir, name string
+		mode      os.FileMode
+	}{
+		{dir1, "lint-hook", 0o755},
+		{dir1, "hook-chain", 0o755},
+		{dir1, "notes-hook.txt", 0o644},
+		{dir1, "ls", 0o755},
+		{dir2, "lint-hook", 0o755},
+		{dir2, "audit-hook", 0o755},
+	} {
+		if err := os.WriteFile(filepath.Join(f.dir, f.name), nil, f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := Installed(dir1 + string(os.PathListSeparator) + dir2)
+	want := []string{filepath.Join(dir2, "audit-hook"), filepath.Join(dir1, "lint-hook")}
+	if !slices.Equal(got, want) {
+		t.Errorf("Installed = %v, want %v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cfg := config.Config{Chains: []config.ChainEntry{
+		{Event: "PreToolUse", Hooks: []config.HookEntry{{Name: "g", Builtin: "no-such-guard"}}},
+		{Event: "Stop"},
+		{Event: "SessionEnd", Hooks: []config.HookEntry{{Name: "x", Command: "hook-chain-missing-binary"}, {Name: "sh", Command: "sh -c true"}}},
+	}}
+	errs := Check(cfg)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	joined := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		"chain 1 (PreToolUse): no tools",
+		`unknown builtin "no-such-guard"`,
+		"chain 2 (Stop): no hooks",
+		`hook "x": hook-chain-missing-binary not found`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Check errors missing %q:\n%s", want, joined)
+		}
+	}
+	if len(errs) != 4 {
+		t.Errorf("got %d errors, want 4:\n%s", len(errs), joined)
+	}
+}